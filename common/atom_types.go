@@ -0,0 +1,40 @@
+package common
+
+// AtomicalInfo atomicals 的 nft 对象，包括 realm 和 subrealm
+type AtomicalInfo struct {
+	AtomicalId  string `json:"atomicalId"`
+	Number      int64  `json:"number"`
+	Type        string `json:"type"` // nft, realm, subrealm
+	Name        string `json:"name"`
+	FullName    string `json:"fullName"` // 认领成功的完整名字，比如 abc.def
+	Status      string `json:"status"`   // verified, pending_candidate, claimed_by_other, invalid, rule_mint_unsupported
+	ParentRealm string `json:"parentRealm"`
+	MintHeight  int    `json:"mintHeight"`
+	MintTime    int64  `json:"mintTime"`
+	MintTx      string `json:"mintTx"`
+	CommitTx    string `json:"commitTx"`
+	MetaHash    string `json:"metaHash"`
+	Address     string `json:"address"`
+	Outpoint    string `json:"outpoint"`
+	Burned      bool   `json:"burned"`
+}
+
+type AtomicalEvent struct {
+	Id         int64  `json:"id"`
+	AtomicalId string `json:"atomicalId"`
+	Height     int    `json:"height"`
+	TxIndex    int    `json:"txIndex"`
+	TxId       string `json:"txid"`
+	Action     string `json:"action"` // mint, transfer, burn
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Outpoint   string `json:"outpoint"`
+}
+
+// RealmResolution 逐级解析 realm 名字的结果，Missing 是没有被认领的部分
+type RealmResolution struct {
+	Name     string        `json:"name"`
+	Found    string        `json:"found"`
+	Missing  string        `json:"missing"`
+	Atomical *AtomicalInfo `json:"atomical"`
+}
@@ -308,3 +308,37 @@ type BRC20TransferInfo struct {
 	Amt     *Decimal `json:"amt"`
 	Invalid bool     `json:"invalid"`
 }
+
+// ActivityFilter 活动历史的查询条件，Cursor 是上一页最后一条记录的 cursor
+type ActivityFilter struct {
+	StartHeight int // 包含，0 表示不限制
+	EndHeight   int // 包含，0 表示不限制
+	Cursor      string
+	Limit       int
+	Desc        bool
+}
+
+type BRC20ActivityBalance struct {
+	Available    string `json:"available"`
+	Transferable string `json:"transferable"`
+}
+
+type BRC20Activity struct {
+	Cursor         string `json:"cursor"`
+	Type           string `json:"type"` // deploy, mint, inscribe-transfer, transfer, send, receive, cancel, spent
+	Ticker         string `json:"ticker"`
+	Height         int    `json:"height"`
+	TxIndex        int    `json:"txIndex"`
+	InscriptionId  string `json:"inscriptionId"`
+	InscriptionNum int64  `json:"inscriptionNum"`
+	Amount         string `json:"amount"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	FromUtxo       string `json:"fromUtxo"`
+	ToUtxo         string `json:"toUtxo"`
+	// 按地址查询时，该地址在事件之后的余额
+	Balance *BRC20ActivityBalance `json:"balance,omitempty"`
+	// 按 ticker 查询时，双方在事件之后的余额
+	FromBalance *BRC20ActivityBalance `json:"fromBalance,omitempty"`
+	ToBalance   *BRC20ActivityBalance `json:"toBalance,omitempty"`
+}
@@ -1,8 +1,58 @@
 package common
 
+// 签名的内容是 Signature 为空时的 json
 type KeyValue struct {
 	Key       string `json:"key"`
 	Value     []byte `json:"value"`
 	PubKey    []byte `json:"pubKey"`
+	Sequence  uint64 `json:"sequence,omitempty"` // 同一个 key 的写入和删除必须递增，防止重放
 	Signature []byte `json:"signature"`
 }
+
+// 签名的删除，签名的内容是 Signature 为空时的 json
+type KeyDeletion struct {
+	Key       string `json:"key"`
+	PubKey    []byte `json:"pubKey"`
+	Sequence  uint64 `json:"sequence"`
+	Signature []byte `json:"signature"`
+}
+
+// kv 存储的一次修改，Put 和 Del 只有一个不为空。Id 在本节点上递增
+type KVChange struct {
+	Id   uint64       `json:"id"`
+	Time int64        `json:"time"`
+	Put  *KeyValue    `json:"put,omitempty"`
+	Del  *KeyDeletion `json:"del,omitempty"`
+}
+
+func (p *KVChange) Key() string {
+	if p.Put != nil {
+		return p.Put.Key
+	}
+	return p.Del.Key
+}
+
+func (p *KVChange) PubKey() []byte {
+	if p.Put != nil {
+		return p.Put.PubKey
+	}
+	return p.Del.PubKey
+}
+
+func (p *KVChange) Sequence() uint64 {
+	if p.Put != nil {
+		return p.Put.Sequence
+	}
+	return p.Del.Sequence
+}
+
+// 矿机注册的公钥，ChannelAddr 是 IndexerPubKey 和 PubKey 的通道地址。
+// 由注册的索引器用 SignerPubKey 对应的私钥签名，签名的内容是 Signature 为空时的 json
+type KVRegistration struct {
+	PubKey        []byte `json:"pubKey"` // hex 字符串
+	ChannelAddr   string `json:"channelAddr"`
+	RefreshTime   int64  `json:"refreshTime"`
+	IndexerPubKey string `json:"indexerPubKey,omitempty"`
+	SignerPubKey  string `json:"signerPubKey,omitempty"`
+	Signature     []byte `json:"signature,omitempty"`
+}
@@ -0,0 +1,15 @@
+package common
+
+type RunesActivity struct {
+	Cursor  string `json:"cursor"`
+	Type    string `json:"type"`   // etch, mint, transfer-in, transfer-out, burn, cenotaph
+	Source  string `json:"source"` // transfer-in/burn: edict, pointer, default-output；没有可用输出的 burn 为空
+	Ticker  string `json:"ticker"` // 符文名字
+	RuneId  string `json:"runeId"`
+	Height  int    `json:"height"`
+	TxIndex int    `json:"txIndex"`
+	TxId    string `json:"txid"`
+	Address string `json:"address"` // etch/burn/cenotaph 为空
+	Utxo    string `json:"utxo"`    // transfer-in/mint 是收到的输出，transfer-out 是花费的输入
+	Amount  string `json:"amount"`
+}
@@ -201,6 +201,8 @@ type AssetsInUtxo struct {
 	Value    int64           `json:"Value"`
 	PkScript []byte          `json:"PkScript"`
 	Assets   []*DisplayAsset `json:"Assets"`
+	// 内存池中的输出为 false，资产由输入计算得到
+	Confirmed bool `json:"Confirmed"`
 }
 
 func (p *AssetsInUtxo) ToTxAssets() TxAssets {
@@ -0,0 +1,54 @@
+package common
+
+// 资产被销毁或者失效的原因
+const (
+	TX_FLOW_REASON_FEE         = "fee"         // 落在手续费的聪上，归矿工
+	TX_FLOW_REASON_OP_RETURN   = "op_return"   // 落在不可花费的输出上
+	TX_FLOW_REASON_CENOTAPH    = "cenotaph"    // runes: 无效的 runestone，输入中的 runes 全部销毁
+	TX_FLOW_REASON_UNALLOCATED = "unallocated" // runes/atom: 没有可以分配的输出
+	TX_FLOW_REASON_TRANSFERRED = "transferred" // brc20: transfer 铭文转移后执行，之后失效
+	TX_FLOW_REASON_RETURNED    = "returned"    // brc20: transfer 铭文作为手续费，数量退回发送者
+)
+
+// TxAssetsFlow 交易中资产从哪些输入流向哪些输出
+type TxAssetsFlow struct {
+	TxId      string `json:"txid"`
+	Confirmed bool   `json:"confirmed"`
+	BlockHash string `json:"blockHash,omitempty"`
+	// 所有输入都已解析，输出的分配结果是完整的
+	Complete     bool                 `json:"complete"`
+	Fee          int64                `json:"fee"`
+	Inputs       []*TxFlowIO          `json:"inputs"`
+	Outputs      []*TxFlowIO          `json:"outputs"`
+	Burns        []*TxFlowAsset       `json:"burns,omitempty"`
+	Invalids     []*TxFlowAsset       `json:"invalids,omitempty"`
+	Inscriptions []*TxFlowInscription `json:"inscriptions,omitempty"`
+	Warnings     []string             `json:"warnings,omitempty"`
+}
+
+type TxFlowIO struct {
+	Index    int    `json:"index"`
+	OutPoint string `json:"outpoint"`
+	Address  string `json:"address,omitempty"`
+	Value    int64  `json:"value"`
+	Resolved bool   `json:"resolved"`
+	// 输入已经被内存池中的另一笔交易花费
+	SpentBy string          `json:"spentBy,omitempty"`
+	Assets  []*DisplayAsset `json:"assets,omitempty"`
+}
+
+type TxFlowAsset struct {
+	AssetName `json:"name"`
+	Amount    string `json:"amount"`
+	Output    int    `json:"output"` // -1 表示手续费
+	Reason    string `json:"reason"`
+}
+
+type TxFlowInscription struct {
+	InscriptionId string `json:"inscriptionId"`
+	Input         int    `json:"input"`
+	Output        int    `json:"output"`           // -1 表示手续费
+	Offset        int64  `json:"offset"`           // 在输出中的偏移
+	Ticker        string `json:"ticker,omitempty"` // 有效的 ordx mint
+	Amount        int64  `json:"amount,omitempty"`
+}
@@ -626,11 +626,12 @@ func (p *TxOutput) ToAssetsInUtxo() *AssetsInUtxo {
 		}
 	}
 	return &AssetsInUtxo{
-		UtxoId:   p.UtxoId,
-		OutPoint: p.OutPointStr,
-		Value:    p.OutValue.Value,
-		PkScript: p.OutValue.PkScript,
-		Assets:   assets,
+		UtxoId:    p.UtxoId,
+		OutPoint:  p.OutPointStr,
+		Value:     p.OutValue.Value,
+		PkScript:  p.OutValue.PkScript,
+		Assets:    assets,
+		Confirmed: p.UtxoId != INVALID_ID,
 	}
 }
 
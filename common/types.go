@@ -113,6 +113,17 @@ type BlockInfo struct {
 	RewardSats int64 `json:"rewardsats"`
 }
 
+// 某一聪当前所在的位置
+type SatLocation struct {
+	Sat        int64    `json:"sat"`
+	Height     int      `json:"height"` // 产生这一聪的区块
+	Satributes []string `json:"satributes"`
+	Utxo       string   `json:"utxo"`
+	Offset     int64    `json:"offset"` // 在 utxo 中的偏移
+	Value      int64    `json:"value"`
+	Address    string   `json:"address"`
+}
+
 const INVALID_ID = math.MaxUint64
 
 const ALL_TICKERS = "*"
@@ -6,6 +6,8 @@ type RPCService struct {
 	LogPath string  `yaml:"log_path"`
 	Swagger Swagger `yaml:"swagger"`
 	API     API     `yaml:"api"`
+	// grpc 服务的监听地址，比如 0.0.0.0:9090，不配置就不启动
+	GrpcAddr string `yaml:"grpc_addr"`
 }
 
 type Swagger struct {
@@ -17,11 +19,19 @@ type API struct {
 	APIKeyList      map[string]*APIKey `yaml:"apikey_list"`
 	NoLimitApiList  []string           `yaml:"nolimit_api_list"`
 	NoLimitHostList []string           `yaml:"nolimit_host_list"`
+	// 按路由限流，每个 key 单独计数，路由使用 gin 的路由路径，比如 /v3/tick/info/:ticker
+	RouteLimitList map[string]*RateLimit `yaml:"route_limit_list"`
+	// 本机的请求不检查 api key 和限流，也可以访问管理接口。默认关闭，前面有反向代理时不要打开
+	TrustLoopback bool `yaml:"trust_loopback"`
 }
 
 type APIKey struct {
 	UserName  string     `yaml:"user_name"`
 	RateLimit *RateLimit `yaml:"rate_limit"`
+	// 覆盖全局的按路由限流
+	RouteLimitList map[string]*RateLimit `yaml:"route_limit_list"`
+	// 可以访问 /admin 下的接口
+	Admin bool `yaml:"admin"`
 }
 
 type RateLimit struct {
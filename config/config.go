@@ -21,6 +21,9 @@ type YamlConf struct {
 	RPCService RPCService `yaml:"rpc_service"`
 	PubKey	   string     `yaml:"pubkey"`
 	CheckValidateFiles bool `yaml:"check_validate_files"`
+	Protocols  Protocols  `yaml:"protocols"`
+	Replica    Replica    `yaml:"replica"`
+	KVPeers    KVPeers    `yaml:"kv_peers"`
 }
 
 type DB struct {
@@ -36,6 +39,18 @@ type Bitcoin struct {
 	Port     int    `yaml:"port"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
+	ZMQ      ZMQ    `yaml:"zmq"`
+}
+
+// ZMQ bitcoind 的 zmqpub* 地址，比如 tcp://127.0.0.1:28332，不配置就使用定时轮询
+type ZMQ struct {
+	HashBlock string `yaml:"hashblock"`
+	RawTx     string `yaml:"rawtx"`
+	Sequence  string `yaml:"sequence"`
+}
+
+func (p *ZMQ) Enabled() bool {
+	return p.HashBlock != "" || p.RawTx != "" || p.Sequence != ""
 }
 
 type Log struct {
@@ -47,6 +62,69 @@ type BasicIndex struct {
 	MaxIndexHeight  int64 `yaml:"max_index_height"`
 	NotCheckSelf    bool  `yaml:"not_checkself"`
 	PeriodFlushToDB int   `yaml:"period_flush_to_db"`
+	// bitcoind 的 blocks 目录，设置后初始同步直接读取 blk*.dat，RPC 作为备用
+	BlocksDir string `yaml:"blocks_dir"`
+	// 聪区间索引，可以查询某一聪所在的 utxo，需要从创世区块开始建立
+	SatIndex bool `yaml:"sat_index"`
+	// 各协议默认并行处理区块，打开后按原来的顺序串行处理，用于排查问题
+	SerialProtocol bool `yaml:"serial_protocol"`
+}
+
+const (
+	ROLE_PRIMARY = "primary"
+	ROLE_REPLICA = "replica"
+)
+
+// Replica 只读副本不跑数据，从主节点拉取每次提交的数据库写入
+type Replica struct {
+	// 空: 只跑数据；primary: 跑数据并记录提交日志；replica: 只读副本
+	Role string `yaml:"role"`
+	// 副本使用，主节点 rpc 的地址，比如 http://10.0.0.1:8005/mainnet
+	Primary string `yaml:"primary"`
+	// 副本使用，主节点配置的 admin api key
+	ApiKey string `yaml:"api_key"`
+	// 主节点保留的提交日志数量，默认 100
+	KeepCommits int `yaml:"keep_commits"`
+	// 主节点一次提交最多记录多少 MB 的写入，默认 256。追块时超过的提交不保存日志
+	MaxCommitLogMB int `yaml:"max_commit_log_mb"`
+	// 副本拉取的间隔，默认 5 秒
+	PollSeconds int `yaml:"poll_seconds"`
+}
+
+func (p *Replica) IsPrimary() bool {
+	return p.Role == ROLE_PRIMARY
+}
+
+func (p *Replica) IsReplica() bool {
+	return p.Role == ROLE_REPLICA
+}
+
+// KVPeers 跟其他索引器互相同步签名的 kv 存储
+type KVPeers struct {
+	// 其他索引器，见 KVPeer
+	Peers []KVPeer `yaml:"peers"`
+	// 拉取的间隔，默认 10 秒
+	PollSeconds int `yaml:"poll_seconds"`
+	// 签名本节点上注册的公钥的私钥（hex），其他索引器要把对应的公钥加到 trusted_keys 中
+	SignKey string `yaml:"sign_key"`
+	// 只接受这些公钥（hex）签名的注册，本节点 sign_key 的公钥默认信任
+	TrustedKeys []string `yaml:"trusted_keys"`
+}
+
+// KVPeer 其他索引器 rpc 的地址，比如 http://10.0.0.1:8005/mainnet。
+// 对方配置了 api key 时写成 {url: ..., api_key: ...}
+type KVPeer struct {
+	URL    string `yaml:"url"`
+	ApiKey string `yaml:"api_key"` // 对方的 api key，用 Authorization: Bearer 发送
+}
+
+// UnmarshalYAML 兼容以前只写地址的配置
+func (p *KVPeer) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&p.URL); err == nil {
+		return nil
+	}
+	type plain KVPeer
+	return unmarshal((*plain)(p))
 }
 
 type MPNConfig struct {
@@ -193,6 +271,10 @@ func LoadYamlConf(cfgPath string) (*YamlConf, error) {
 		ret.BasicIndex.MaxIndexHeight = -2
 	}
 
+	if err := ret.Protocols.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cfg: %s, error: %s", cfgPath, err)
+	}
+
 	if ret.DB.Path == "" {
 		ret.DB.Path = "db"
 	}
@@ -0,0 +1,75 @@
+package config
+
+import "fmt"
+
+// 协议索引器的名字，和配置 protocols 中的名字一致
+const (
+	PROTOCOL_EXOTIC = "exotic"
+	PROTOCOL_NFT    = "nft"
+	PROTOCOL_FT     = "ft"
+	PROTOCOL_NS     = "ns"
+	PROTOCOL_BRC20  = "brc20"
+	PROTOCOL_RUNES  = "runes"
+	PROTOCOL_ATOM   = "atom"
+)
+
+// 可以单独关闭的协议索引器。关闭的协议不打开数据库，不处理区块，也不提供接口
+type Protocols struct {
+	Exotic Protocol `yaml:"exotic"`
+	Nft    Protocol `yaml:"nft"` // ord 铭文
+	Ft     Protocol `yaml:"ft"`  // ordx
+	Ns     Protocol `yaml:"ns"`
+	Brc20  Protocol `yaml:"brc20"`
+	Runes  Protocol `yaml:"runes"`
+	Atom   Protocol `yaml:"atom"`
+}
+
+type Protocol struct {
+	Disable     bool `yaml:"disable"`
+	StartHeight int  `yaml:"start_height"` // 0 使用默认的激活高度，否则同时替换索引器内部的激活高度
+}
+
+// Get 按名字返回协议的配置，未知的协议返回 nil
+func (p *Protocols) Get(name string) *Protocol {
+	switch name {
+	case PROTOCOL_EXOTIC:
+		return &p.Exotic
+	case PROTOCOL_NFT:
+		return &p.Nft
+	case PROTOCOL_FT:
+		return &p.Ft
+	case PROTOCOL_NS:
+		return &p.Ns
+	case PROTOCOL_BRC20:
+		return &p.Brc20
+	case PROTOCOL_RUNES:
+		return &p.Runes
+	case PROTOCOL_ATOM:
+		return &p.Atom
+	}
+	return nil
+}
+
+// Validate 检查协议之间的依赖：ft 需要 nft 和 exotic，ns 和 brc20 需要 nft
+func (p *Protocols) Validate() error {
+	if p.Nft.Disable {
+		if !p.Ft.Disable {
+			return fmt.Errorf("protocol ft requires nft")
+		}
+		if !p.Ns.Disable {
+			return fmt.Errorf("protocol ns requires nft")
+		}
+		if !p.Brc20.Disable {
+			return fmt.Errorf("protocol brc20 requires nft")
+		}
+	}
+	if p.Exotic.Disable && !p.Ft.Disable {
+		return fmt.Errorf("protocol ft requires exotic")
+	}
+	for _, item := range []Protocol{p.Exotic, p.Nft, p.Ft, p.Ns, p.Brc20, p.Runes, p.Atom} {
+		if item.StartHeight < 0 {
+			return fmt.Errorf("invalid start height %d", item.StartHeight)
+		}
+	}
+	return nil
+}
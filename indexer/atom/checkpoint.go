@@ -1350,6 +1350,8 @@ var atomUtxoStartHeight, atomUtxoEndHeight int
 var atomHeightToUtxoRecords map[int]map[string]map[uint64]*atomValidate.UtxoCSVRecord
 var atomTickerStartHeight, atomTickerEndHeight int
 var atomHeightToTickerRecords map[int]map[string]*atomValidate.TickerCSVRecord
+var atomRealmStartHeight, atomRealmEndHeight int
+var atomHeightToRealmRecords map[int]map[string]*atomValidate.RealmCSVRecord
 
 func (s *Indexer) CheckPointWithBlockHeight(height int) {
 	startTime := time.Now()
@@ -1434,6 +1436,7 @@ func (s *Indexer) checkPointWithBlockHeightLocked(height int, startTime time.Tim
 	s.validateTickerDataLocked(height)
 	s.validateHolderDataLocked(height)
 	s.validateUtxoDataLocked(height)
+	s.validateRealmDataLocked(height)
 	common.Log.Infof("AtomIndexer.CheckPointWithBlockHeight %d checked, takes %v", height, time.Since(startTime))
 }
 
@@ -1519,6 +1522,81 @@ func (s *Indexer) validateTickerDataLocked(height int) {
 	common.Log.Infof("AtomIndexer.validateTickerData %d tickers check succeeded.", len(records))
 }
 
+func readAtomRealmDataToMap(dir string) (int, int) {
+	records, err := atomValidate.ReadRealmCSVDir(dir)
+	if err != nil {
+		common.Log.Panicf("ReadAtomRealmCSVDir %s failed, %v", dir, err)
+	}
+
+	startHeight := int(^uint(0) >> 1)
+	endHeight := 0
+	atomHeightToRealmRecords = make(map[int]map[string]*atomValidate.RealmCSVRecord)
+	for _, record := range records {
+		realms := atomHeightToRealmRecords[record.Height]
+		if realms == nil {
+			realms = make(map[string]*atomValidate.RealmCSVRecord)
+			atomHeightToRealmRecords[record.Height] = realms
+		}
+		realms[record.Realm] = record
+
+		if record.Height < startHeight {
+			startHeight = record.Height
+		}
+		if record.Height > endHeight {
+			endHeight = record.Height
+		}
+	}
+	if len(records) == 0 {
+		startHeight = 0
+	}
+	common.Log.Infof("readAtomRealmDataToMap height %d %d, records %d", startHeight, endHeight, len(records))
+	return startHeight, endHeight
+}
+
+func (s *Indexer) validateRealmDataLocked(height int) {
+	if s.chaincfgParam == nil || s.chaincfgParam.Net != wire.MainNet {
+		return
+	}
+	if atomHeightToRealmRecords == nil {
+		atomRealmStartHeight, atomRealmEndHeight = readAtomRealmDataToMap(atomValidateDir("realms"))
+	}
+	if len(atomHeightToRealmRecords) == 0 || height < atomRealmStartHeight || height > atomRealmEndHeight {
+		return
+	}
+
+	// 按规则付费铸造的 subrealm 还不支持，只检查顶级 realm
+	records := make(map[string]*atomValidate.RealmCSVRecord)
+	for realm, record := range atomHeightToRealmRecords[height] {
+		if !strings.Contains(realm, ".") {
+			records[realm] = record
+		}
+	}
+	if len(records) == 0 {
+		return
+	}
+	realmCount := 0
+	for realm := range s.realms {
+		if !strings.Contains(realm, ".") {
+			realmCount++
+		}
+	}
+	if realmCount != len(records) {
+		common.Log.Panicf("AtomIndexer.validateRealmData realm count different at %d: %d %d", height, realmCount, len(records))
+	}
+
+	var failed []string
+	for realm, record := range records {
+		if atomicalId := s.realms[realm]; atomicalId != record.AtomicalId {
+			common.Log.Errorf("AtomIndexer.validateRealmData %s different atomical %s/%s", realm, atomicalId, record.AtomicalId)
+			failed = append(failed, realm)
+		}
+	}
+	if len(failed) > 0 {
+		common.Log.Panicf("check atom %v realms failed", failed)
+	}
+	common.Log.Infof("AtomIndexer.validateRealmData %d realms check succeeded.", len(records))
+}
+
 func readAtomHolderDataToMap(dir string) (int, int) {
 	records, err := atomValidate.ReadHolderCSVDir(dir)
 	if err != nil {
@@ -1,6 +1,6 @@
 package atom
 
-const DB_VERSION = "1.0.0"
+const DB_VERSION = "1.1.0"
 const DB_VER_KEY = "dbver"
 const DB_STATUS_KEY = "status"
 
@@ -13,6 +13,9 @@ const (
 	DB_PREFIX_TICKER_HOLDER = "f-"
 	DB_PREFIX_MINTHISTORY   = "g-"
 	DB_PREFIX_ACTION        = "h-"
+	DB_PREFIX_NFT           = "i-"
+	DB_PREFIX_NFT_EVENT     = "j-"
+	DB_PREFIX_BALANCE       = "k-" // 每个区块的余额变化
 )
 
 const (
@@ -21,6 +24,31 @@ const (
 	OpMintDFT     = "dmt"
 	OpSplit       = "y"
 	OpCustomColor = "z"
+	OpNFT         = "nft"
+)
+
+// nft 对象的类型
+const (
+	NftTypeNft      = "nft"
+	NftTypeRealm    = "realm"
+	NftTypeSubrealm = "subrealm"
+)
+
+// realm/subrealm 名字的认领状态，普通 nft 为空
+const (
+	NftStatusVerified       = "verified"
+	NftStatusClaimedByOther = "claimed_by_other"
+	NftStatusInvalid        = "invalid"
+	// commit 之后 MintTickerDelayBlocks 个区块内，commit 更早的候选者还可以抢走名字
+	NftStatusPendingCandidate = "pending_candidate"
+	// 按 parent 设置的规则付费铸造的 subrealm，还不支持，名字不认领
+	NftStatusRuleMintUnsupported = "rule_mint_unsupported"
+)
+
+const (
+	NftActionMint     = "mint"
+	NftActionTransfer = "transfer"
+	NftActionBurn     = "burn"
 )
 
 const (
@@ -86,6 +86,20 @@ func (s *Indexer) loadMintHistoryFromDB() {
 	}
 }
 
+func (s *Indexer) loadNftsFromDB() {
+	err := s.db.BatchRead([]byte(DB_PREFIX_NFT), false, func(k, v []byte) error {
+		var nft Nft
+		if err := db.DecodeBytes(v, &nft); err != nil {
+			return err
+		}
+		s.addLoadedNftInMemory(&nft)
+		return nil
+	})
+	if err != nil {
+		common.Log.Panicf("atom load nfts failed: %v", err)
+	}
+}
+
 func sortMintHistory(items []*MintInfo) {
 	for i := 1; i < len(items); i++ {
 		item := items[i]
@@ -154,6 +168,17 @@ func (s *Indexer) UpdateDB() {
 			common.Log.Panicf("atom write action failed: %v", err)
 		}
 	}
+	for atomicalId, nft := range s.nftTouched {
+		if err := db.SetDB([]byte(GetNftKey(atomicalId)), nft, wb); err != nil {
+			common.Log.Panicf("atom write nft failed: %v", err)
+		}
+	}
+	for _, event := range s.nftEventsAdded {
+		if err := db.SetDB([]byte(GetNftEventKey(event.AtomicalId, event.Id)), event, wb); err != nil {
+			common.Log.Panicf("atom write nft event failed: %v", err)
+		}
+	}
+	s.balanceHistory.UpdateDB(wb)
 	if err := wb.Flush(); err != nil {
 		common.Log.Panicf("atom flush failed: %v", err)
 	}
@@ -166,6 +191,8 @@ func (s *Indexer) UpdateDB() {
 	s.holderTouched = make(map[string]int64)
 	s.mintsAdded = nil
 	s.actionsAdded = nil
+	s.nftTouched = make(map[string]*Nft)
+	s.nftEventsAdded = nil
 }
 
 func (s *Indexer) logDebugMemoryLocked() {
@@ -175,7 +202,7 @@ func (s *Indexer) logDebugMemoryLocked() {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 	common.Log.Infof(
-		"AtomIndexer.Memory height=%d alloc_mb=%d sys_mb=%d num_gc=%d tickers=%d utxos=%d holders=%d ticker_utxos=%d mint_history_tickers=%d pending_tickers=%d pending_ticker_ids=%d pending_utxos=%d pending_deleted=%d pending_holders=%d pending_mints=%d pending_actions=%d",
+		"AtomIndexer.Memory height=%d alloc_mb=%d sys_mb=%d num_gc=%d tickers=%d utxos=%d holders=%d ticker_utxos=%d mint_history_tickers=%d pending_tickers=%d pending_ticker_ids=%d pending_utxos=%d pending_deleted=%d pending_holders=%d pending_mints=%d pending_actions=%d nfts=%d realms=%d pending_nfts=%d pending_nft_events=%d",
 		s.status.Height,
 		m.Alloc/1024/1024,
 		m.Sys/1024/1024,
@@ -192,5 +219,22 @@ func (s *Indexer) logDebugMemoryLocked() {
 		len(s.holderTouched),
 		len(s.mintsAdded),
 		len(s.actionsAdded),
+		len(s.nftMap),
+		len(s.realms),
+		len(s.nftTouched),
+		len(s.nftEventsAdded),
 	)
 }
+
+// 第一次启用时，以当前的持有者作为余额历史的起点
+func (s *Indexer) initBalanceHistory() {
+	s.balanceHistory.Init(s.db, s.status.Height, func(add func(ticker string, addressId uint64, amount *common.Decimal)) {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		for addressId, items := range s.holderBalances {
+			for ticker, amount := range items {
+				add(ticker, addressId, common.NewDefaultDecimal(amount))
+			}
+		}
+	})
+}
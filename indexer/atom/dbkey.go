@@ -42,3 +42,15 @@ func GetMintHistoryKey(ticker string, id int64) string {
 func GetActionKey(height, txIndex int, id int64) string {
 	return fmt.Sprintf("%s%08x-%08x-%s", DB_PREFIX_ACTION, height, txIndex, common.Uint64ToString(uint64(id)))
 }
+
+func GetNftKey(atomicalId string) string {
+	return DB_PREFIX_NFT + atomicalId
+}
+
+func GetNftEventPrefix(atomicalId string) string {
+	return DB_PREFIX_NFT_EVENT + atomicalId + "-"
+}
+
+func GetNftEventKey(atomicalId string, id int64) string {
+	return GetNftEventPrefix(atomicalId) + common.Uint64ToString(uint64(id))
+}
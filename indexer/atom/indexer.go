@@ -9,6 +9,7 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/sat20-labs/indexer/common"
 	"github.com/sat20-labs/indexer/indexer/base"
+	inCommon "github.com/sat20-labs/indexer/indexer/common"
 )
 
 type Indexer struct {
@@ -25,14 +26,24 @@ type Indexer struct {
 	tickerHolders  map[string]map[uint64]int64
 	tickerUtxos    map[string]map[uint64]int64
 	mintHistory    map[string][]*MintInfo
+	nftMap         map[string]*Nft
+	utxoNfts       map[uint64]map[string]bool
+	addressNfts    map[uint64]map[string]bool
+	realms         map[string]string          // full name -> atomical id
+	subrealms      map[string]map[string]bool // parent atomical id -> children
+	pendingRealms  map[string]bool            // 还在等待期内的 realm/subrealm
 
-	tickerTouched map[string]*Ticker
-	tickerIdAdded map[int64]string
-	utxoTouched   map[string]*UtxoBalance
-	utxoDeleted   map[string]*UtxoBalance
-	holderTouched map[string]int64
-	mintsAdded    []*MintInfo
-	actionsAdded  []*ActionHistory
+	tickerTouched  map[string]*Ticker
+	tickerIdAdded  map[int64]string
+	utxoTouched    map[string]*UtxoBalance
+	utxoDeleted    map[string]*UtxoBalance
+	holderTouched  map[string]int64
+	mintsAdded     []*MintInfo
+	actionsAdded   []*ActionHistory
+	nftTouched     map[string]*Nft
+	nftEventsAdded []*NftEvent
+
+	balanceHistory *inCommon.BalanceHistory
 }
 
 func NewIndexer(db common.KVDB, param *chaincfg.Params) *Indexer {
@@ -66,20 +77,35 @@ func NewIndexer(db common.KVDB, param *chaincfg.Params) *Indexer {
 		tickerHolders:  make(map[string]map[uint64]int64),
 		tickerUtxos:    make(map[string]map[uint64]int64),
 		mintHistory:    make(map[string][]*MintInfo),
+		nftMap:         make(map[string]*Nft),
+		utxoNfts:       make(map[uint64]map[string]bool),
+		addressNfts:    make(map[uint64]map[string]bool),
+		realms:         make(map[string]string),
+		subrealms:      make(map[string]map[string]bool),
+		pendingRealms:  make(map[string]bool),
 		tickerTouched:  make(map[string]*Ticker),
 		tickerIdAdded:  make(map[int64]string),
 		utxoTouched:    make(map[string]*UtxoBalance),
 		utxoDeleted:    make(map[string]*UtxoBalance),
 		holderTouched:  make(map[string]int64),
+		nftTouched:     make(map[string]*Nft),
+		balanceHistory: inCommon.NewBalanceHistory(DB_PREFIX_BALANCE),
 	}
 }
 
+// SetEnableHeight 替换激活高度，之后的功能升级高度不变
+func (s *Indexer) SetEnableHeight(height int) {
+	s.heights.Activation = height
+}
+
 func (s *Indexer) Init(baseIndexer *base.BaseIndexer) {
 	s.baseIndexer = baseIndexer
 	s.status = s.loadStatusFromDB()
 	s.loadTickersFromDB()
 	s.loadUtxoBalancesFromDB()
 	s.loadMintHistoryFromDB()
+	s.loadNftsFromDB()
+	s.initBalanceHistory()
 }
 
 func (s *Indexer) Clone(baseIndexer *base.BaseIndexer) *Indexer {
@@ -87,6 +113,7 @@ func (s *Indexer) Clone(baseIndexer *base.BaseIndexer) *Indexer {
 	defer s.mutex.RUnlock()
 	clone := NewIndexer(s.db, s.chaincfgParam)
 	clone.baseIndexer = baseIndexer
+	clone.heights = s.heights
 	clone.status = s.status.Clone()
 	for k, v := range s.tickerTouched {
 		clone.tickerTouched[k] = v.Clone()
@@ -110,6 +137,14 @@ func (s *Indexer) Clone(baseIndexer *base.BaseIndexer) *Indexer {
 		n := *v
 		clone.actionsAdded = append(clone.actionsAdded, &n)
 	}
+	for k, v := range s.nftTouched {
+		clone.nftTouched[k] = v.Clone()
+	}
+	for _, v := range s.nftEventsAdded {
+		n := *v
+		clone.nftEventsAdded = append(clone.nftEventsAdded, &n)
+	}
+	clone.balanceHistory = s.balanceHistory.Clone()
 	return clone
 }
 
@@ -148,6 +183,13 @@ func (s *Indexer) Subtract(backup *Indexer) {
 	}
 	s.mintsAdded = filterFlushedMints(s.mintsAdded, backup.mintsAdded)
 	s.actionsAdded = filterFlushedActions(s.actionsAdded, backup.actionsAdded)
+	for k, v := range backup.nftTouched {
+		if current := s.nftTouched[k]; current == nil || *current == *v {
+			delete(s.nftTouched, k)
+		}
+	}
+	s.nftEventsAdded = filterFlushedNftEvents(s.nftEventsAdded, backup.nftEventsAdded)
+	s.balanceHistory.Subtract(backup.balanceHistory)
 }
 
 func filterFlushedMints(current, flushed []*MintInfo) []*MintInfo {
@@ -184,6 +226,23 @@ func filterFlushedActions(current, flushed []*ActionHistory) []*ActionHistory {
 	return result
 }
 
+func filterFlushedNftEvents(current, flushed []*NftEvent) []*NftEvent {
+	if len(current) == 0 || len(flushed) == 0 {
+		return current
+	}
+	flushedIds := make(map[int64]bool, len(flushed))
+	for _, item := range flushed {
+		flushedIds[item.Id] = true
+	}
+	result := make([]*NftEvent, 0, len(current))
+	for _, item := range current {
+		if !flushedIds[item.Id] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func (s *Indexer) GetDBVersion() string {
 	return s.getDBVersion()
 }
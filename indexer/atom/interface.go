@@ -105,6 +105,37 @@ func (s *Indexer) GetMintHistoryWithAddress(addressId uint64, name string, start
 	return result, total
 }
 
+// GetDeploysWithHeight 只能在跑数据的线程中调用，返回当前区块中部署的 ticker，按 id 排序
+func (s *Indexer) GetDeploysWithHeight(height int) []*Ticker {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := make([]*Ticker, 0)
+	for _, name := range s.tickerIdAdded {
+		ticker := s.tickerMap[name]
+		if ticker != nil && ticker.DeployHeight == height {
+			result = append(result, ticker.Clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
+
+// GetMintActionsWithHeight 只能在跑数据的线程中调用，返回当前区块中的铸造
+func (s *Indexer) GetMintActionsWithHeight(height int) []*ActionHistory {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	result := make([]*ActionHistory, 0)
+	for _, action := range s.actionsAdded {
+		if action.Height == height && action.Action == "mint" {
+			n := *action
+			result = append(result, &n)
+		}
+	}
+	return result
+}
+
 func (s *Indexer) GetAddressAssets(addressId uint64) map[string]int64 {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -198,7 +229,7 @@ func (s *Indexer) GetUtxoBalancesWithTick(ticker string) map[uint64]int64 {
 func (s *Indexer) HasAssetInUtxo(utxoId uint64) bool {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
-	return len(s.utxoBalances[utxoId]) > 0
+	return len(s.utxoBalances[utxoId]) > 0 || len(s.utxoNfts[utxoId]) > 0
 }
 
 func (s *Indexer) CheckSelf() bool {
@@ -218,9 +249,38 @@ func (s *Indexer) CheckSelf() bool {
 			return false
 		}
 	}
+	for utxoId, atomicalIds := range s.utxoNfts {
+		for atomicalId := range atomicalIds {
+			nft := s.nftMap[atomicalId]
+			if nft == nil || nft.Burned || nft.UtxoId != utxoId || !s.addressNfts[nft.AddressId][atomicalId] {
+				common.Log.Errorf("atom nft %s location inconsistent with utxo %d", atomicalId, utxoId)
+				return false
+			}
+		}
+	}
+	for realm, atomicalId := range s.realms {
+		nft := s.nftMap[atomicalId]
+		if nft == nil || (nft.Status != NftStatusVerified && nft.Status != NftStatusPendingCandidate) ||
+			nft.FullName != realm {
+			common.Log.Errorf("atom realm %s inconsistent with nft %s", realm, atomicalId)
+			return false
+		}
+	}
 	return true
 }
 
 func SortAssetNames(items []string) {
 	sort.Strings(items)
 }
+
+func (s *Indexer) GetHoldersWithTickAtHeight(name string, height int) (map[uint64]*common.Decimal, error) {
+	return s.balanceHistory.GetHolders(s.db, strings.ToLower(name), height)
+}
+
+func (s *Indexer) GetHolderFirstSeenAtHeight(name string, height int) (map[uint64]int, error) {
+	return s.balanceHistory.GetFirstSeen(s.db, strings.ToLower(name), height)
+}
+
+func (s *Indexer) GetAddressAssetsAtHeight(addressId uint64, height int) (map[string]*common.Decimal, error) {
+	return s.balanceHistory.GetAssets(s.db, addressId, height)
+}
@@ -0,0 +1,423 @@
+package atom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+var realmPattern = regexp.MustCompile(`^[a-z][a-z0-9\-]{0,63}$`)
+var subrealmPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9\-]{0,63}$`)
+
+type spentNft struct {
+	nft        *Nft
+	inputIndex int
+}
+
+// collectInputNfts 把输入中的 nft 从 utxo 索引中移除，位置在 applyNftTransfer 中重新设置
+func (s *Indexer) collectInputNfts(tx *common.Transaction) []*spentNft {
+	result := make([]*spentNft, 0)
+	for inputIndex, input := range tx.Inputs {
+		items := s.utxoNfts[input.UtxoId]
+		if len(items) == 0 {
+			continue
+		}
+		atomicalIds := make([]string, 0, len(items))
+		for atomicalId := range items {
+			atomicalIds = append(atomicalIds, atomicalId)
+		}
+		sortAtomicalIds(atomicalIds)
+		for _, atomicalId := range atomicalIds {
+			nft := s.nftMap[atomicalId]
+			if nft == nil {
+				continue
+			}
+			result = append(result, &spentNft{nft: nft, inputIndex: inputIndex})
+			s.removeNftLocationInMemory(nft)
+		}
+	}
+	return result
+}
+
+func (s *Indexer) handleMintNft(block *common.Block, txIndex int, tx *common.Transaction, op *Operation, spent []*spentNft) {
+	if len(tx.Outputs) == 0 || isUnspendable(tx.Outputs[0]) {
+		return
+	}
+	atomicalId := compactId(op.CommitTxId, op.CommitIndex)
+	if s.nftMap[atomicalId] != nil {
+		return
+	}
+	realm := stringArg(op.Payload.Args, "request_realm")
+	subrealm := stringArg(op.Payload.Args, "request_subrealm")
+	nftType := NftTypeNft
+	delay := MintGeneralDelayBlocks
+	if realm != "" {
+		nftType = NftTypeRealm
+		delay = MintTickerDelayBlocks
+	} else if subrealm != "" {
+		nftType = NftTypeSubrealm
+		delay = MintTickerDelayBlocks
+	}
+	if !s.validNftEnvelope(block.Height, tx, op, delay) {
+		return
+	}
+
+	input := tx.Inputs[op.InputIndex]
+	metaHash := sha256.Sum256(op.Payload.Raw)
+	nft := &Nft{
+		Id:            s.status.NftCount,
+		AtomicalId:    atomicalId,
+		Type:          nftType,
+		MintHeight:    block.Height,
+		MintTime:      block.Timestamp.Unix(),
+		MintTx:        tx.TxId,
+		CommitTx:      op.CommitTxId,
+		CommitTxIndex: input.OutTxIndex,
+		CommitIndex:   op.CommitIndex,
+		CommitHeight:  input.OutHeight,
+		MetaHash:      hex.EncodeToString(metaHash[:]),
+	}
+	s.status.NftCount++
+
+	switch nftType {
+	case NftTypeRealm:
+		nft.Name = realm
+		if realmPattern.MatchString(realm) {
+			s.claimRealmName(nft, realm)
+		} else {
+			nft.Status = NftStatusInvalid
+		}
+	case NftTypeSubrealm:
+		nft.Name = subrealm
+		nft.ParentRealm = stringArg(op.Payload.Args, "parent_realm")
+		parent := s.nftMap[nft.ParentRealm]
+		// 只支持上一级 realm 的持有者直接铸造，parent 必须在这个交易的输入中。
+		// 按 parent 的规则付费铸造还不支持，这些 subrealm 不认领名字，realm 检查点也不包括它们
+		switch {
+		case !subrealmPattern.MatchString(subrealm) || parent == nil || parent.Status != NftStatusVerified:
+			nft.Status = NftStatusInvalid
+		case !spentNftInInputs(spent, parent.AtomicalId):
+			nft.Status = NftStatusRuleMintUnsupported
+		default:
+			s.claimRealmName(nft, parent.FullName+"."+subrealm)
+		}
+	}
+
+	s.nftMap[atomicalId] = nft
+	output := tx.Outputs[0]
+	s.setNftLocationInMemory(nft, output)
+	s.recordNftEvent(block, txIndex, tx.TxId, nft, NftActionMint, 0, 0, output)
+}
+
+func (s *Indexer) validNftEnvelope(height int, tx *common.Transaction, op *Operation, delay int) bool {
+	if op.InputIndex >= len(tx.Inputs) {
+		return false
+	}
+	input := tx.Inputs[op.InputIndex]
+	if input.OutHeight < s.heights.Activation || input.OutHeight < height-delay {
+		return false
+	}
+	if height >= s.heights.Commitz && op.CommitIndex != 0 {
+		return false
+	}
+	if bitworkc := stringArg(op.Payload.Args, "bitworkc"); bitworkc != "" && !isBitworkMatch(op.CommitTxId, bitworkc) {
+		return false
+	}
+	if bitworkr := stringArg(op.Payload.Args, "bitworkr"); bitworkr != "" && !isBitworkMatch(tx.TxId, bitworkr) {
+		return false
+	}
+	return true
+}
+
+func spentNftInInputs(spent []*spentNft, atomicalId string) bool {
+	for _, item := range spent {
+		if item.nft.AtomicalId == atomicalId {
+			return true
+		}
+	}
+	return false
+}
+
+// claimRealmName 和 ticker 一样，名字属于 commit 最早的候选者。
+// 等待期内先作为候选者，等待期过后由 verifyRealmCandidates 确认
+func (s *Indexer) claimRealmName(nft *Nft, fullName string) {
+	existing := s.nftMap[s.realms[fullName]]
+	if existing != nil {
+		if existing.Status == NftStatusVerified || !nftCommitPrecedes(nft, existing) {
+			nft.Status = NftStatusClaimedByOther
+			return
+		}
+		s.unregisterRealmInMemory(existing)
+		existing.FullName = ""
+		existing.Status = NftStatusClaimedByOther
+		s.nftTouched[existing.AtomicalId] = existing.Clone()
+	}
+	nft.FullName = fullName
+	nft.Status = NftStatusPendingCandidate
+	s.registerRealmInMemory(nft)
+}
+
+// verifyRealmCandidates 候选者的 reveal 最晚在 commit 之后 MintTickerDelayBlocks 个区块，
+// 到这个高度后不会再有 commit 更早的候选者
+func (s *Indexer) verifyRealmCandidates(height int) {
+	for atomicalId := range s.pendingRealms {
+		nft := s.nftMap[atomicalId]
+		if nft == nil || height < nft.CommitHeight+MintTickerDelayBlocks {
+			continue
+		}
+		nft.Status = NftStatusVerified
+		delete(s.pendingRealms, atomicalId)
+		s.nftTouched[atomicalId] = nft.Clone()
+	}
+}
+
+func nftCommitPrecedes(candidate, existing *Nft) bool {
+	if candidate.CommitHeight != existing.CommitHeight {
+		return candidate.CommitHeight < existing.CommitHeight
+	}
+	if candidate.CommitTxIndex != existing.CommitTxIndex {
+		return candidate.CommitTxIndex < existing.CommitTxIndex
+	}
+	return candidate.CommitIndex < existing.CommitIndex
+}
+
+// applyNftTransfer nft 从输入 i 转到输出 i，输出不存在或者不可花费时转到输出 0
+func (s *Indexer) applyNftTransfer(block *common.Block, txIndex int, tx *common.Transaction, op *Operation, spent []*spentNft) {
+	for _, item := range spent {
+		nft := item.nft
+		fromUtxo, fromAddr := nft.UtxoId, nft.AddressId
+		outputIndex := item.inputIndex
+		if outputIndex >= len(tx.Outputs) || isUnspendable(tx.Outputs[outputIndex]) {
+			outputIndex = 0
+		}
+		if op != nil && op.Op == OpSplit && op.InputIndex == 0 {
+			outputIndex = 0
+		}
+		if len(tx.Outputs) == 0 || isUnspendable(tx.Outputs[outputIndex]) {
+			nft.Burned = true
+			nft.UtxoId = 0
+			nft.AddressId = 0
+			nft.Outpoint = ""
+			s.nftTouched[nft.AtomicalId] = nft.Clone()
+			s.recordNftEvent(block, txIndex, tx.TxId, nft, NftActionBurn, fromUtxo, fromAddr, nil)
+			continue
+		}
+		output := tx.Outputs[outputIndex]
+		s.setNftLocationInMemory(nft, output)
+		s.recordNftEvent(block, txIndex, tx.TxId, nft, NftActionTransfer, fromUtxo, fromAddr, output)
+	}
+}
+
+func (s *Indexer) recordNftEvent(block *common.Block, txIndex int, txid string, nft *Nft, action string,
+	fromUtxo, fromAddr uint64, output *common.TxOutputV2) {
+	event := &NftEvent{
+		Id:         s.status.NftEventCount,
+		AtomicalId: nft.AtomicalId,
+		Height:     block.Height,
+		TxIndex:    txIndex,
+		TxId:       txid,
+		Action:     action,
+		FromUtxo:   fromUtxo,
+		FromAddr:   fromAddr,
+	}
+	if output != nil {
+		event.ToUtxo = output.UtxoId
+		event.ToAddr = output.AddressId
+		event.Outpoint = output.OutPointStr
+	}
+	s.status.NftEventCount++
+	s.nftEventsAdded = append(s.nftEventsAdded, event)
+}
+
+func (s *Indexer) setNftLocationInMemory(nft *Nft, output *common.TxOutputV2) {
+	nft.UtxoId = output.UtxoId
+	nft.AddressId = output.AddressId
+	nft.Outpoint = output.OutPointStr
+	s.addNftLocationInMemory(nft)
+	s.nftTouched[nft.AtomicalId] = nft.Clone()
+}
+
+func (s *Indexer) addNftLocationInMemory(nft *Nft) {
+	if nft.Burned {
+		return
+	}
+	if _, ok := s.utxoNfts[nft.UtxoId]; !ok {
+		s.utxoNfts[nft.UtxoId] = make(map[string]bool)
+	}
+	s.utxoNfts[nft.UtxoId][nft.AtomicalId] = true
+	if _, ok := s.addressNfts[nft.AddressId]; !ok {
+		s.addressNfts[nft.AddressId] = make(map[string]bool)
+	}
+	s.addressNfts[nft.AddressId][nft.AtomicalId] = true
+}
+
+func (s *Indexer) removeNftLocationInMemory(nft *Nft) {
+	if items := s.utxoNfts[nft.UtxoId]; items != nil {
+		delete(items, nft.AtomicalId)
+		if len(items) == 0 {
+			delete(s.utxoNfts, nft.UtxoId)
+		}
+	}
+	if items := s.addressNfts[nft.AddressId]; items != nil {
+		delete(items, nft.AtomicalId)
+		if len(items) == 0 {
+			delete(s.addressNfts, nft.AddressId)
+		}
+	}
+}
+
+func (s *Indexer) registerRealmInMemory(nft *Nft) {
+	s.realms[nft.FullName] = nft.AtomicalId
+	if nft.Status == NftStatusPendingCandidate {
+		s.pendingRealms[nft.AtomicalId] = true
+	}
+	if nft.Type != NftTypeSubrealm {
+		return
+	}
+	if _, ok := s.subrealms[nft.ParentRealm]; !ok {
+		s.subrealms[nft.ParentRealm] = make(map[string]bool)
+	}
+	s.subrealms[nft.ParentRealm][nft.AtomicalId] = true
+}
+
+func (s *Indexer) unregisterRealmInMemory(nft *Nft) {
+	if s.realms[nft.FullName] == nft.AtomicalId {
+		delete(s.realms, nft.FullName)
+	}
+	delete(s.pendingRealms, nft.AtomicalId)
+	if items := s.subrealms[nft.ParentRealm]; items != nil {
+		delete(items, nft.AtomicalId)
+		if len(items) == 0 {
+			delete(s.subrealms, nft.ParentRealm)
+		}
+	}
+}
+
+func (s *Indexer) addLoadedNftInMemory(nft *Nft) {
+	s.nftMap[nft.AtomicalId] = nft
+	s.addNftLocationInMemory(nft)
+	if nft.Status == NftStatusVerified || nft.Status == NftStatusPendingCandidate {
+		s.registerRealmInMemory(nft)
+	}
+}
+
+func (s *Indexer) sortedNftsLocked(ids map[string]bool) []*Nft {
+	result := make([]*Nft, 0, len(ids))
+	for atomicalId := range ids {
+		if nft := s.nftMap[atomicalId]; nft != nil {
+			result = append(result, nft.Clone())
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
+
+func pageNfts(items []*Nft, start, limit int) ([]*Nft, int) {
+	total := len(items)
+	if start < 0 {
+		start = 0
+	}
+	if start >= total {
+		return nil, total
+	}
+	if limit <= 0 || start+limit > total {
+		limit = total - start
+	}
+	return items[start : start+limit], total
+}
+
+// GetNft 按 atomical id 查询 nft（包括 realm 和 subrealm）
+func (s *Indexer) GetNft(atomicalId string) *Nft {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.nftMap[atomicalId].Clone()
+}
+
+// ResolveRealm 逐级解析 a.b.c 这样的名字，返回能找到的最深一级，以及没有找到的部分
+func (s *Indexer) ResolveRealm(name string) (*Nft, string, string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	parts := strings.Split(strings.ToLower(strings.TrimPrefix(name, "+")), ".")
+	var found *Nft
+	foundName := ""
+	for i := range parts {
+		fullName := strings.Join(parts[:i+1], ".")
+		nft := s.nftMap[s.realms[fullName]]
+		if nft == nil {
+			break
+		}
+		found = nft
+		foundName = fullName
+	}
+	missing := strings.TrimPrefix(strings.TrimPrefix(strings.Join(parts, "."), foundName), ".")
+	return found.Clone(), foundName, missing
+}
+
+// GetSubrealms 某个 realm/subrealm 下一级已经认领的 subrealm
+func (s *Indexer) GetSubrealms(atomicalId string, start, limit int) ([]*Nft, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return pageNfts(s.sortedNftsLocked(s.subrealms[atomicalId]), start, limit)
+}
+
+func (s *Indexer) GetNftsWithAddress(addressId uint64, start, limit int) ([]*Nft, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return pageNfts(s.sortedNftsLocked(s.addressNfts[addressId]), start, limit)
+}
+
+func (s *Indexer) GetNftsWithUtxo(utxoId uint64) []*Nft {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.sortedNftsLocked(s.utxoNfts[utxoId])
+}
+
+// GetNftEvents 按时间顺序返回 nft 的事件，包括还没有写入数据库的事件
+func (s *Indexer) GetNftEvents(atomicalId string, start, limit int) ([]*NftEvent, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	events := make([]*NftEvent, 0)
+	seen := make(map[int64]bool)
+	if s.db != nil {
+		err := s.db.BatchRead([]byte(GetNftEventPrefix(atomicalId)), false, func(k, v []byte) error {
+			var event NftEvent
+			if err := db.DecodeBytes(v, &event); err != nil {
+				return err
+			}
+			seen[event.Id] = true
+			events = append(events, &event)
+			return nil
+		})
+		if err != nil {
+			common.Log.Errorf("atom read nft events of %s failed: %v", atomicalId, err)
+		}
+	}
+	for _, event := range s.nftEventsAdded {
+		if event.AtomicalId == atomicalId && !seen[event.Id] {
+			n := *event
+			events = append(events, &n)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Id < events[j].Id
+	})
+
+	total := len(events)
+	if start < 0 {
+		start = 0
+	}
+	if start >= total {
+		return nil, total
+	}
+	if limit <= 0 || start+limit > total {
+		limit = total - start
+	}
+	return events[start : start+limit], total
+}
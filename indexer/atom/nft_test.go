@@ -0,0 +1,230 @@
+package atom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/sat20-labs/indexer/common"
+)
+
+var nftOp = []byte{0x03, 'n', 'f', 't'}
+
+func mintNftTx(t *testing.T, txid, commitTx string, inputUtxo uint64, commitHeight int, args map[string]any, outputUtxo, addressId uint64) *common.Transaction {
+	return &common.Transaction{
+		TxId:    txid,
+		Inputs:  []*common.TxInput{testTxInput(inputUtxo, commitTx, 0, 1000, commitHeight, atomWitness(t, nftOp, args))},
+		Outputs: []*common.TxOutputV2{testTxOutput(outputUtxo, addressId, txid, 0, 546)},
+	}
+}
+
+func TestRealmMintTransferAndEvents(t *testing.T) {
+	idx := NewIndexer(nil, &chaincfg.TestNet4Params)
+	commitTx := strings.Repeat("a", 64)
+	revealTx := strings.Repeat("b", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height:       27010,
+		Transactions: []*common.Transaction{mintNftTx(t, revealTx, commitTx, 1, 27007, map[string]any{"request_realm": "sat20"}, 2, 100)},
+	})
+	atomicalId := compactId(commitTx, 0)
+	nft := idx.GetNft(atomicalId)
+	if nft == nil || nft.Type != NftTypeRealm || nft.Status != NftStatusVerified || nft.FullName != "sat20" || nft.UtxoId != 2 {
+		t.Fatalf("unexpected realm: %#v", nft)
+	}
+	if !idx.HasAssetInUtxo(2) {
+		t.Fatal("realm utxo should hold an asset")
+	}
+
+	spendTx := strings.Repeat("c", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height: 27011,
+		Transactions: []*common.Transaction{{
+			TxId: spendTx,
+			Inputs: []*common.TxInput{
+				testTxInput(5, strings.Repeat("d", 64), 0, 1000, 27001, nil),
+				testTxInput(2, revealTx, 0, 546, 27010, nil),
+			},
+			Outputs: []*common.TxOutputV2{testTxOutput(6, 101, spendTx, 0, 1000), testTxOutput(7, 102, spendTx, 1, 546)},
+		}},
+	})
+	if got := idx.GetNftsWithUtxo(7); len(got) != 1 || got[0].AtomicalId != atomicalId {
+		t.Fatalf("realm should move from input 1 to output 1: %#v", got)
+	}
+	if got, total := idx.GetNftsWithAddress(100, 0, 10); total != 0 || len(got) != 0 {
+		t.Fatalf("old holder should have no nft: %d", total)
+	}
+	if got, total := idx.GetNftsWithAddress(102, 0, 10); total != 1 || got[0].AtomicalId != atomicalId {
+		t.Fatalf("new holder nft mismatch: %d", total)
+	}
+	events, total := idx.GetNftEvents(atomicalId, 0, 10)
+	if total != 2 || events[0].Action != NftActionMint || events[1].Action != NftActionTransfer || events[1].ToAddr != 102 {
+		t.Fatalf("unexpected events: %d %#v", total, events)
+	}
+	if !idx.CheckSelf() {
+		t.Fatal("check self failed")
+	}
+}
+
+func TestRealmUsesEarliestCommit(t *testing.T) {
+	idx := NewIndexer(nil, &chaincfg.TestNet4Params)
+	lateCommit := strings.Repeat("a", 64)
+	earlyCommit := strings.Repeat("b", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height: 27010,
+		Transactions: []*common.Transaction{
+			mintNftTx(t, strings.Repeat("c", 64), lateCommit, 1, 27009, map[string]any{"request_realm": "sat20"}, 3, 100),
+			mintNftTx(t, strings.Repeat("d", 64), earlyCommit, 2, 27007, map[string]any{"request_realm": "sat20"}, 4, 101),
+		},
+	})
+	late := idx.GetNft(compactId(lateCommit, 0))
+	early := idx.GetNft(compactId(earlyCommit, 0))
+	if late == nil || late.Status != NftStatusClaimedByOther || late.FullName != "" {
+		t.Fatalf("late realm should lose the name: %#v", late)
+	}
+	if early == nil || early.Status != NftStatusVerified {
+		t.Fatalf("early realm should own the name: %#v", early)
+	}
+	if nft, found, missing := idx.ResolveRealm("+Sat20"); nft == nil || nft.AtomicalId != early.AtomicalId || found != "sat20" || missing != "" {
+		t.Fatalf("resolve mismatch: %#v %s %s", nft, found, missing)
+	}
+
+	invalidCommit := strings.Repeat("e", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height:       27011,
+		Transactions: []*common.Transaction{mintNftTx(t, strings.Repeat("f", 64), invalidCommit, 5, 27010, map[string]any{"request_realm": "1abc"}, 6, 102)},
+	})
+	if nft := idx.GetNft(compactId(invalidCommit, 0)); nft == nil || nft.Status != NftStatusInvalid {
+		t.Fatalf("realm starting with digit should be invalid: %#v", nft)
+	}
+}
+
+// 等待期内 commit 更早的候选者可以抢走名字，等待期过后才确认
+func TestRealmCandidateDelay(t *testing.T) {
+	idx := NewIndexer(nil, &chaincfg.TestNet4Params)
+	laterCommit := strings.Repeat("a", 64)
+	mint := mintNftTx(t, strings.Repeat("b", 64), laterCommit, 1, 27008, map[string]any{"request_realm": "sat20"}, 2, 100)
+	mint.Inputs[0].OutTxIndex = 5
+	idx.UpdateTransfer(&common.Block{Height: 27010, Transactions: []*common.Transaction{mint}})
+	later := idx.GetNft(compactId(laterCommit, 0))
+	if later == nil || later.Status != NftStatusPendingCandidate || later.FullName != "sat20" {
+		t.Fatalf("realm should be a candidate before the delay: %#v", later)
+	}
+
+	// 同一个区块 commit，交易序号更小
+	earlierCommit := strings.Repeat("c", 64)
+	mint = mintNftTx(t, strings.Repeat("d", 64), earlierCommit, 3, 27008, map[string]any{"request_realm": "sat20"}, 4, 101)
+	mint.Inputs[0].OutTxIndex = 1
+	idx.UpdateTransfer(&common.Block{Height: 27011, Transactions: []*common.Transaction{mint}})
+	later = idx.GetNft(compactId(laterCommit, 0))
+	earlier := idx.GetNft(compactId(earlierCommit, 0))
+	if later == nil || later.Status != NftStatusClaimedByOther || later.FullName != "" {
+		t.Fatalf("later candidate should lose the name: %#v", later)
+	}
+	if earlier == nil || earlier.Status != NftStatusVerified || earlier.FullName != "sat20" {
+		t.Fatalf("earlier candidate should be verified after the delay: %#v", earlier)
+	}
+	if !idx.CheckSelf() {
+		t.Fatal("check self failed")
+	}
+}
+
+func TestSubrealmRequiresParentInInputs(t *testing.T) {
+	idx := NewIndexer(nil, &chaincfg.TestNet4Params)
+	realmCommit := strings.Repeat("a", 64)
+	realmTx := strings.Repeat("b", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height:       27010,
+		Transactions: []*common.Transaction{mintNftTx(t, realmTx, realmCommit, 1, 27007, map[string]any{"request_realm": "sat20"}, 2, 100)},
+	})
+	realmId := compactId(realmCommit, 0)
+
+	// 没有花费 parent 的 subrealm 是按规则铸造，还不支持
+	orphanCommit := strings.Repeat("c", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height: 27011,
+		Transactions: []*common.Transaction{mintNftTx(t, strings.Repeat("d", 64), orphanCommit, 3, 27010,
+			map[string]any{"request_subrealm": "dev", "parent_realm": realmId}, 4, 101)},
+	})
+	if nft := idx.GetNft(compactId(orphanCommit, 0)); nft == nil || nft.Status != NftStatusRuleMintUnsupported || nft.FullName != "" {
+		t.Fatalf("subrealm without parent should be an unsupported rule mint: %#v", nft)
+	}
+
+	subCommit := strings.Repeat("e", 64)
+	subTx := strings.Repeat("f", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height: 27012,
+		Transactions: []*common.Transaction{{
+			TxId: subTx,
+			Inputs: []*common.TxInput{
+				testTxInput(5, subCommit, 0, 1000, 27009, atomWitness(t, nftOp, map[string]any{"request_subrealm": "dev", "parent_realm": realmId})),
+				testTxInput(2, realmTx, 0, 546, 27010, nil),
+			},
+			Outputs: []*common.TxOutputV2{testTxOutput(6, 102, subTx, 0, 546), testTxOutput(7, 100, subTx, 1, 546)},
+		}},
+	})
+	subId := compactId(subCommit, 0)
+	sub := idx.GetNft(subId)
+	if sub == nil || sub.Status != NftStatusVerified || sub.FullName != "sat20.dev" || sub.UtxoId != 6 {
+		t.Fatalf("unexpected subrealm: %#v", sub)
+	}
+	if got := idx.GetNftsWithUtxo(7); len(got) != 1 || got[0].AtomicalId != realmId {
+		t.Fatalf("parent realm should stay with input 1 -> output 1: %#v", got)
+	}
+	if children, total := idx.GetSubrealms(realmId, 0, 10); total != 1 || children[0].AtomicalId != subId {
+		t.Fatalf("subrealm children mismatch: %d", total)
+	}
+	nft, found, missing := idx.ResolveRealm("sat20.dev.app")
+	if nft == nil || nft.AtomicalId != subId || found != "sat20.dev" || missing != "app" {
+		t.Fatalf("resolve mismatch: %#v %s %s", nft, found, missing)
+	}
+}
+
+func TestNftBurnedWithoutSpendableOutput(t *testing.T) {
+	idx := NewIndexer(nil, &chaincfg.TestNet4Params)
+	commitTx := strings.Repeat("a", 64)
+	revealTx := strings.Repeat("b", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height:       27010,
+		Transactions: []*common.Transaction{mintNftTx(t, revealTx, commitTx, 1, 27001, map[string]any{}, 2, 100)},
+	})
+	atomicalId := compactId(commitTx, 0)
+	if nft := idx.GetNft(atomicalId); nft == nil || nft.Type != NftTypeNft {
+		t.Fatalf("unexpected nft: %#v", nft)
+	}
+
+	spendTx := strings.Repeat("c", 64)
+	idx.UpdateTransfer(&common.Block{
+		Height: 27011,
+		Transactions: []*common.Transaction{{
+			TxId:   spendTx,
+			Inputs: []*common.TxInput{testTxInput(2, revealTx, 0, 546, 27010, nil)},
+		}},
+	})
+	nft := idx.GetNft(atomicalId)
+	if nft == nil || !nft.Burned || idx.HasAssetInUtxo(2) {
+		t.Fatalf("nft should be burned: %#v", nft)
+	}
+	if events, total := idx.GetNftEvents(atomicalId, 1, 10); total != 2 || len(events) != 1 || events[0].Action != NftActionBurn {
+		t.Fatalf("unexpected events: %d %#v", total, events)
+	}
+}
+
+func TestSubtractKeepsNftEventsAddedAfterBackup(t *testing.T) {
+	idx := NewIndexer(nil, &chaincfg.TestNet4Params)
+	idx.UpdateTransfer(&common.Block{
+		Height:       27010,
+		Transactions: []*common.Transaction{mintNftTx(t, strings.Repeat("b", 64), strings.Repeat("a", 64), 1, 27007, map[string]any{"request_realm": "sat20"}, 2, 100)},
+	})
+	backup := idx.Clone(nil)
+	idx.UpdateTransfer(&common.Block{
+		Height:       27011,
+		Transactions: []*common.Transaction{mintNftTx(t, strings.Repeat("d", 64), strings.Repeat("c", 64), 3, 27009, map[string]any{"request_realm": "other"}, 4, 101)},
+	})
+	idx.Subtract(backup)
+	if len(idx.nftEventsAdded) != 1 || idx.nftEventsAdded[0].Id != 1 {
+		t.Fatalf("only the new event should remain pending: %#v", idx.nftEventsAdded)
+	}
+	if len(idx.nftTouched) != 1 || idx.nftTouched[compactId(strings.Repeat("c", 64), 0)] == nil {
+		t.Fatalf("only the new nft should remain pending: %#v", idx.nftTouched)
+	}
+}
@@ -102,7 +102,7 @@ func parseOperationCode(script []byte, pos int) (string, int) {
 	if pos+4 <= len(script) {
 		switch hex.EncodeToString(script[pos : pos+4]) {
 		case "036e6674":
-			return "nft", pos + 4
+			return OpNFT, pos + 4
 		case "03646674":
 			return OpDeployDFT, pos + 4
 		case "036d6f64":
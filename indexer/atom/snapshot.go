@@ -101,6 +101,35 @@ func (s *Indexer) writeCompareSnapshotLocked(path string) error {
 		)
 	}
 
+	fmt.Fprintf(&builder, "nft_status|nfts=%d|nft_events=%d\n", s.status.NftCount, s.status.NftEventCount)
+	nfts := make([]string, 0, len(s.nftMap))
+	for atomicalId := range s.nftMap {
+		nfts = append(nfts, atomicalId)
+	}
+	sort.Strings(nfts)
+	for _, atomicalId := range nfts {
+		nft := s.nftMap[atomicalId]
+		fmt.Fprintf(
+			&builder,
+			"nft|atomical=%s|type=%s|name=%s|status=%s|parent=%s|outpoint=%s|burned=%t\n",
+			atomicalId,
+			nft.Type,
+			nft.Name,
+			nft.Status,
+			nft.ParentRealm,
+			nft.Outpoint,
+			nft.Burned,
+		)
+	}
+	realms := make([]string, 0, len(s.realms))
+	for realm := range s.realms {
+		realms = append(realms, realm)
+	}
+	sort.Strings(realms)
+	for _, realm := range realms {
+		fmt.Fprintf(&builder, "realm|name=%s|atomical=%s\n", realm, s.realms[realm])
+	}
+
 	tmp := path + ".tmp"
 	if err := os.WriteFile(tmp, []byte(builder.String()), 0o644); err != nil {
 		return err
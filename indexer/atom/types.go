@@ -12,11 +12,13 @@ type ActivationHeights struct {
 }
 
 type Status struct {
-	Version     string
-	Height      int
-	TickerCount int64
-	MintCount   int64
-	ActionCount int64
+	Version       string
+	Height        int
+	TickerCount   int64
+	MintCount     int64
+	ActionCount   int64
+	NftCount      int64
+	NftEventCount int64
 }
 
 func (s *Status) Clone() *Status {
@@ -154,3 +156,68 @@ type ActionHistory struct {
 	Amount     int64
 	Action     string
 }
+
+// Nft atomicals 的对象式资产，realm 和 subrealm 也是 nft
+type Nft struct {
+	Id            int64 // atomical number
+	AtomicalId    string
+	Type          string
+	Name          string // 申请的 realm/subrealm 名字
+	FullName      string // 认领成功后的完整名字，例如 a.b.c
+	Status        string
+	ParentRealm   string // subrealm 的上一级 atomical id
+	MintHeight    int
+	MintTime      int64
+	MintTx        string
+	CommitTx      string
+	CommitTxIndex int
+	CommitIndex   int
+	CommitHeight  int
+	MetaHash      string
+	UtxoId        uint64
+	AddressId     uint64
+	Outpoint      string
+	Burned        bool
+}
+
+func (n *Nft) ToCommon(address string) *common.AtomicalInfo {
+	return &common.AtomicalInfo{
+		AtomicalId:  n.AtomicalId,
+		Number:      n.Id,
+		Type:        n.Type,
+		Name:        n.Name,
+		FullName:    n.FullName,
+		Status:      n.Status,
+		ParentRealm: n.ParentRealm,
+		MintHeight:  n.MintHeight,
+		MintTime:    n.MintTime,
+		MintTx:      n.MintTx,
+		CommitTx:    n.CommitTx,
+		MetaHash:    n.MetaHash,
+		Address:     address,
+		Outpoint:    n.Outpoint,
+		Burned:      n.Burned,
+	}
+}
+
+func (n *Nft) Clone() *Nft {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	return &c
+}
+
+type NftEvent struct {
+	Id         int64
+	AtomicalId string
+	Height     int
+	TxIndex    int
+	TxId       string
+	Action     string
+	FromUtxo   uint64
+	ToUtxo     uint64
+	FromAddr   uint64
+	ToAddr     uint64
+	Outpoint   string
+}
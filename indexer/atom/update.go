@@ -25,6 +25,7 @@ func (s *Indexer) UpdateTransfer(block *common.Block) {
 	for txIndex, tx := range block.Transactions {
 		op := ParseOperation(tx, block.Height >= s.heights.Density)
 		spent := s.collectInputBalances(tx)
+		spentNfts := s.collectInputNfts(tx)
 		switch {
 		case op != nil && op.InputIndex == 0 && op.Op == OpDirectFT:
 			s.handleDirectFT(block, txIndex, tx, op)
@@ -32,14 +33,21 @@ func (s *Indexer) UpdateTransfer(block *common.Block) {
 			s.handleDeployDFT(block, txIndex, tx, op)
 		case op != nil && op.InputIndex == 0 && op.Op == OpMintDFT:
 			s.handleMintDFT(block, txIndex, tx, op)
+		case op != nil && op.InputIndex == 0 && op.Op == OpNFT:
+			s.handleMintNft(block, txIndex, tx, op, spentNfts)
 		}
 		s.applyTransfer(block, txIndex, tx, op, spent)
+		s.applyNftTransfer(block, txIndex, tx, op, spentNfts)
 	}
+	s.verifyRealmCandidates(block.Height)
 	if written, err := s.writeTargetCompareSnapshotLocked(block.Height); err != nil {
 		common.Log.Errorf("AtomIndexer.writeTargetCompareSnapshot failed at %d: %v", block.Height, err)
 	} else if written {
 		common.Log.Infof("AtomIndexer target compare snapshot written at %d before checkpoint", block.Height)
 	}
+	s.balanceHistory.Commit(block.Height, func(ticker string, addressId uint64) *common.Decimal {
+		return common.NewDefaultDecimal(s.holderBalances[addressId][ticker])
+	})
 	s.checkPointWithBlockHeightLocked(block.Height, time.Now())
 }
 
@@ -811,6 +819,7 @@ func (s *Indexer) addUtxoBalanceInMemory(balance *UtxoBalance) {
 	s.utxoTouched[key] = balance.Clone()
 	s.holderTouched[GetHolderAssetKey(balance.AddressId, ticker)] = s.holderBalances[balance.AddressId][ticker]
 	s.holderTouched[GetTickerHolderKey(ticker, balance.AddressId)] = s.tickerHolders[ticker][balance.AddressId]
+	s.balanceHistory.Touch(ticker, balance.AddressId)
 }
 
 func (s *Indexer) addLoadedUtxoBalanceInMemory(balance *UtxoBalance) {
@@ -893,4 +902,5 @@ func (s *Indexer) removeUtxoBalanceFromIndexes(balance *UtxoBalance, trackPendin
 	}
 	s.holderTouched[GetHolderAssetKey(balance.AddressId, ticker)] = s.holderBalances[balance.AddressId][ticker]
 	s.holderTouched[GetTickerHolderKey(ticker, balance.AddressId)] = s.tickerHolders[ticker][balance.AddressId]
+	s.balanceHistory.Touch(ticker, balance.AddressId)
 }
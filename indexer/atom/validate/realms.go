@@ -0,0 +1,90 @@
+package validate
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RealmCSVRecord 官方 indexer 导出的 realm/subrealm 认领结果，realm 为完整名字，比如 abc.def
+type RealmCSVRecord struct {
+	Realm      string
+	Height     int
+	AtomicalId string
+}
+
+func ReadRealmCSV(path string) ([]*RealmCSVRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.TrimPrefix(h, "\ufeff")] = i
+	}
+
+	var result []*RealmCSVRecord
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		record := &RealmCSVRecord{
+			Realm:      strings.ToLower(row[col["realm"]]),
+			AtomicalId: row[col["atomical_id"]],
+		}
+		if record.Height, err = atoi(row[col["height"]]); err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+	return result, nil
+}
+
+func ReadRealmCSVDir(dir string) ([]*RealmCSVRecord, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if strings.HasSuffix(strings.ToLower(name), ".csv") {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+
+	var result []*RealmCSVRecord
+	for _, path := range files {
+		records, err := ReadRealmCSV(path)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, records...)
+	}
+	return result, nil
+}
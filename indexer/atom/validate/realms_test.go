@@ -0,0 +1,35 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadRealmCSVDir(t *testing.T) {
+	dir := t.TempDir()
+	data := "height,realm,atomical_id\n" +
+		"950000,Sat20,aa00i0\n" +
+		"950000,sat20.dev,bb00i0\n"
+	if err := os.WriteFile(filepath.Join(dir, "realms-950000.csv"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadRealmCSVDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("realm count mismatch: got %d", len(records))
+	}
+	if records[0].Realm != "sat20" || records[0].Height != 950000 || records[0].AtomicalId != "aa00i0" {
+		t.Fatalf("unexpected first realm: %+v", records[0])
+	}
+	if records[1].Realm != "sat20.dev" {
+		t.Fatalf("unexpected second realm: %+v", records[1])
+	}
+
+	records, err = ReadRealmCSVDir(filepath.Join(dir, "missing"))
+	if err != nil || records != nil {
+		t.Fatalf("missing dir should be ignored: %v %v", records, err)
+	}
+}
@@ -1,16 +1,28 @@
 package indexer
 
-import "github.com/sat20-labs/indexer/common"
+import (
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/atom"
+)
 
 func (b *IndexerMgr) GetAtomTickerMapV2(start, limit int) ([]string, int) {
+	if b.atomIndexer == nil {
+		return nil, 0
+	}
 	return b.atomIndexer.GetTickersWithRange(start, limit)
 }
 
 func (b *IndexerMgr) GetAtomTickerV2(tickerName string) *common.TickerInfo {
+	if b.atomIndexer == nil {
+		return nil
+	}
 	return b.atomIndexer.GetTickerInfo(tickerName)
 }
 
 func (b *IndexerMgr) GetAtomMintHistoryWithAddress(addressId uint64, ticker string, start int, limit int) ([]*common.MintInfo, int) {
+	if b.atomIndexer == nil {
+		return nil, 0
+	}
 	result, total := b.atomIndexer.GetMintHistoryWithAddress(addressId, ticker, start, limit)
 	for _, item := range result {
 		if item.Address == "" {
@@ -21,5 +33,94 @@ func (b *IndexerMgr) GetAtomMintHistoryWithAddress(addressId uint64, ticker stri
 }
 
 func (b *IndexerMgr) GetAtomDBVer() string {
+	if b.atomIndexer == nil {
+		return ""
+	}
 	return b.atomIndexer.GetDBVersion()
 }
+
+func (b *IndexerMgr) atomicalToCommon(nft *atom.Nft) *common.AtomicalInfo {
+	if nft == nil {
+		return nil
+	}
+	address := ""
+	if !nft.Burned {
+		address = b.GetAddressById(nft.AddressId)
+	}
+	return nft.ToCommon(address)
+}
+
+func (b *IndexerMgr) GetAtomical(atomicalId string) *common.AtomicalInfo {
+	if b.atomIndexer == nil {
+		return nil
+	}
+	return b.atomicalToCommon(b.atomIndexer.GetNft(atomicalId))
+}
+
+func (b *IndexerMgr) ResolveRealm(name string) *common.RealmResolution {
+	if b.atomIndexer == nil {
+		return nil
+	}
+	nft, found, missing := b.atomIndexer.ResolveRealm(name)
+	return &common.RealmResolution{
+		Name:     name,
+		Found:    found,
+		Missing:  missing,
+		Atomical: b.atomicalToCommon(nft),
+	}
+}
+
+func (b *IndexerMgr) GetSubrealms(atomicalId string, start, limit int) ([]*common.AtomicalInfo, int) {
+	if b.atomIndexer == nil {
+		return nil, 0
+	}
+	items, total := b.atomIndexer.GetSubrealms(atomicalId, start, limit)
+	result := make([]*common.AtomicalInfo, 0, len(items))
+	for _, item := range items {
+		result = append(result, b.atomicalToCommon(item))
+	}
+	return result, total
+}
+
+func (b *IndexerMgr) GetAtomicalNftsWithAddress(address string, start, limit int) ([]*common.AtomicalInfo, int) {
+	if b.atomIndexer == nil {
+		return nil, 0
+	}
+	addressId := b.GetAddressId(address)
+	if addressId == common.INVALID_ID {
+		return nil, 0
+	}
+	items, total := b.atomIndexer.GetNftsWithAddress(addressId, start, limit)
+	result := make([]*common.AtomicalInfo, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.ToCommon(address))
+	}
+	return result, total
+}
+
+func (b *IndexerMgr) GetAtomicalEvents(atomicalId string, start, limit int) ([]*common.AtomicalEvent, int) {
+	if b.atomIndexer == nil {
+		return nil, 0
+	}
+	items, total := b.atomIndexer.GetNftEvents(atomicalId, start, limit)
+	result := make([]*common.AtomicalEvent, 0, len(items))
+	for _, item := range items {
+		event := &common.AtomicalEvent{
+			Id:         item.Id,
+			AtomicalId: item.AtomicalId,
+			Height:     item.Height,
+			TxIndex:    item.TxIndex,
+			TxId:       item.TxId,
+			Action:     item.Action,
+			Outpoint:   item.Outpoint,
+		}
+		if item.Action != atom.NftActionMint {
+			event.From = b.GetAddressById(item.FromAddr)
+		}
+		if item.Action != atom.NftActionBurn {
+			event.To = b.GetAddressById(item.ToAddr)
+		}
+		result = append(result, event)
+	}
+	return result, total
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
@@ -14,6 +15,7 @@ import (
 	"github.com/sat20-labs/indexer/common"
 	inCommon "github.com/sat20-labs/indexer/indexer/common"
 	"github.com/sat20-labs/indexer/indexer/db"
+	"github.com/sat20-labs/indexer/share/metrics"
 	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
@@ -25,6 +27,7 @@ type AddressStatus struct {
 
 type BlockProcCallback func(*common.Block, []*common.Range)
 type UpdateDBCallback func(wantToDelete map[string]uint64)
+type PreUpdateDBCallback func()
 type PostUpdateDBCallback func()
 
 type BaseIndexer struct {
@@ -39,6 +42,8 @@ type BaseIndexer struct {
 
 	addressValueMap map[string]*common.AddressValueV2
 	idToAddressMap  map[uint64]string
+	// 各协议并行处理区块时会同时查询地址，保护上面两个缓存。RpcIndexer 按值复制，所以用指针
+	addressMutex *sync.Mutex
 
 	lastHeight        int // 内存数据同步区块
 	lastHash          string
@@ -57,6 +62,7 @@ type BaseIndexer struct {
 
 	blockprocCB    BlockProcCallback
 	updateDBCB     UpdateDBCallback
+	preUpdateDBCB  PreUpdateDBCallback
 	postUpdateDBCB PostUpdateDBCallback
 }
 
@@ -77,6 +83,7 @@ func NewBaseIndexer(
 		chaincfgParam:     chaincfgParam,
 		maxIndexHeight:    maxIndexHeight,
 		nullDataAddressId: common.INVALID_ID,
+		addressMutex:      &sync.Mutex{},
 	}
 
 	if chaincfgParam.Name != "mainnet" {
@@ -110,10 +117,30 @@ func (b *BaseIndexer) Init() {
 	b.delUTXOs = make([]*common.TxOutputV2, 0)
 }
 
+// Release 断开回调，丢弃内存数据。之后实例不能再使用
+func (b *BaseIndexer) Release() {
+	b.blockprocCB = nil
+	b.updateDBCB = nil
+	b.preUpdateDBCB = nil
+	b.postUpdateDBCB = nil
+	b.drainBlocksChan()
+
+	b.blockVector = nil
+	b.utxoIndex = nil
+	b.delUTXOs = nil
+	b.addressValueMap = nil
+	b.idToAddressMap = nil
+	b.prevBlockHashMap = nil
+}
+
 func (b *BaseIndexer) SetUpdateDBCallback(cb2 UpdateDBCallback) {
 	b.updateDBCB = cb2
 }
 
+func (b *BaseIndexer) SetPreUpdateDBCallback(cb PreUpdateDBCallback) {
+	b.preUpdateDBCB = cb
+}
+
 func (b *BaseIndexer) SetPostUpdateDBCallback(cb PostUpdateDBCallback) {
 	b.postUpdateDBCB = cb
 }
@@ -254,9 +281,15 @@ func (b *BaseIndexer) forceUpdateDB() {
 
 	*/
 	if b.updateDBCB != nil {
+		flushStartTime := time.Now()
+		defer metrics.ObserveFlush(metrics.FLUSH_TOTAL, flushStartTime)
+		if b.preUpdateDBCB != nil {
+			b.preUpdateDBCB()
+		}
 		startTime := time.Now()
 		wantToDelete := b.UpdateDB()
 		common.Log.Infof("BaseIndexer.updateBasicDB: cost: %v", time.Since(startTime))
+		metrics.ObserveFlush(metrics.FLUSH_BASE, startTime)
 		org := make(map[string]uint64)
 		for k, v := range wantToDelete {
 			org[k] = v
@@ -267,8 +300,9 @@ func (b *BaseIndexer) forceUpdateDB() {
 		// 	common.Log.Infof("%s %x", address, id )
 		// }
 
-		// startTime = time.Now()
+		startTime = time.Now()
 		b.updateDBCB(wantToDelete)
+		metrics.ObserveFlush(metrics.FLUSH_INDEXERS, startTime)
 		// common.Log.Infof("BaseIndexer.updateOrdxDB: cost: %v", time.Since(startTime))
 
 		// _, ok = wantToDelete[address]
@@ -676,7 +710,8 @@ func (b *BaseIndexer) handleReorg(currentBlock *common.Block) int {
 	//b.stats.ReorgsDetected = append(b.stats.ReorgsDetected, currentBlock.Height)
 	b.drainBlocksChan()
 
-	reorgHeight := currentBlock.Height
+	// 内存中没有记录时（比如刚重启），至少最后一个区块已经不在主链上
+	reorgHeight := b.lastHeight
 	for i := b.lastHeight - b.keepBlockHistory + 1; i <= b.lastHeight; i++ {
 		blockHash, ok := b.prevBlockHashMap[i]
 		if ok {
@@ -686,7 +721,8 @@ func (b *BaseIndexer) handleReorg(currentBlock *common.Block) int {
 					common.Log.Warnf("detected reorg at height %d, old hash %s, new hash %s", i, blockHash, hash)
 					reorgHeight = i
 					if i == b.lastHeight-b.keepBlockHistory+1 {
-						common.Log.Panic("reorg may occur in previous block!")
+						// 分叉点可能更早，由上层根据数据库中的撤销日志继续往前查找
+						common.Log.Warnf("reorg may occur in previous block!")
 					}
 					break
 				}
@@ -751,12 +787,15 @@ func (b *BaseIndexer) syncToBlock(height int, stopChan chan struct{}) int {
 				return b.handleReorg(block)
 			}
 
+			blockStartTime := time.Now()
 			localStartTime := time.Now()
 			b.prefetchIndexesFromDB(block)
 			common.Log.Infof("BaseIndexer.SyncToBlock-> prefetchIndexesFromDB: cost: %v", time.Since(localStartTime))
+			metrics.ObserveStage(metrics.STAGE_PREFETCH, localStartTime)
 			localStartTime = time.Now()
 			coinbase := b.assignOrdinals_sat20(block)
 			common.Log.Infof("BaseIndexer.SyncToBlock-> assignOrdinals: cost: %v", time.Since(localStartTime))
+			metrics.ObserveStage(metrics.STAGE_ASSIGN_ORDINALS, localStartTime)
 
 			// Update the sync stats
 			b.lastHeight = block.Height
@@ -769,6 +808,8 @@ func (b *BaseIndexer) syncToBlock(height int, stopChan chan struct{}) int {
 			//localStartTime = time.Now()
 			b.blockprocCB(block, coinbase)
 			//common.Log.Infof("BaseIndexer.SyncToBlock-> blockproc: cost: %v", time.Since(localStartTime))
+			metrics.ObserveStage(metrics.STAGE_BLOCK, blockStartTime)
+			metrics.SetSyncHeight(block.Height)
 
 			if (block.Height != 0 && block.Height%b.periodFlushToDB == 0 && height-block.Height > b.keepBlockHistory) ||
 				height-block.Height == b.keepBlockHistory {
@@ -1008,6 +1049,7 @@ func (b *BaseIndexer) SyncToChainTip(stopChan chan struct{}) int {
 		count = uint64(b.lastHeight) + 1
 	}
 	b.stats.ChainTip = int(count)
+	metrics.SetChainTip(int(count))
 
 	return b.syncToBlock(int(count), stopChan)
 }
@@ -1416,6 +1458,7 @@ func (b *BaseIndexer) loadSyncStatsFromDB() {
 	b.lastHash = b.stats.SyncBlockHash
 	b.lastHeight = b.stats.SyncHeight
 	b.lastSats = b.stats.TotalSats
+	metrics.SetSyncHeight(b.lastHeight)
 
 }
 
@@ -1799,6 +1842,8 @@ func (p *BaseIndexer) GetBlockInBuffer(height int) *common.BlockValueInDB {
 
 // only for RPC interface
 func (b *BaseIndexer) GetAddressIdFromDB(address string) uint64 {
+	b.addressMutex.Lock()
+	defer b.addressMutex.Unlock()
 
 	id, _ := b.getAddressId(address)
 	if id == common.INVALID_ID {
@@ -1817,6 +1862,8 @@ func (b *BaseIndexer) GetAddressIdFromDB(address string) uint64 {
 
 // only for RPC interface
 func (b *BaseIndexer) GetAddressByID(id uint64) (string, error) {
+	b.addressMutex.Lock()
+	defer b.addressMutex.Unlock()
 
 	addrStr, ok := b.idToAddressMap[id]
 	if ok {
@@ -1854,6 +1901,8 @@ func (p *BaseIndexer) getAddressById(addressId uint64) string {
 
 // only for api access
 func (b *BaseIndexer) getAddressValue2(address string, ldb common.KVDB) *common.AddressValueV2 {
+	b.addressMutex.Lock()
+	defer b.addressMutex.Unlock()
 	value, ok := b.addressValueMap[address]
 	if !ok {
 		data, err := db.GetAddressDataFromDBV2(ldb, address)
@@ -0,0 +1,369 @@
+package base
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// bitcoind 区块索引中 nStatus 的标志位
+const (
+	blockHaveData    = 8
+	blockHaveUndo    = 16
+	blockFailedValid = 32
+	blockFailedChild = 64
+)
+
+// 最新的几个区块可能被重组，交给 RPC 处理
+const blkSourceSafeDepth = 6
+
+var errBlockNotInSource = errors.New("block not in source")
+
+type blkPos struct {
+	status  uint64
+	file    int
+	dataPos uint32
+}
+
+type blkIndexEntry struct {
+	height  int
+	status  uint64
+	file    int
+	dataPos uint32
+	prev    chainhash.Hash
+}
+
+// BlkFileSource 直接读取 bitcoind blocks 目录下的 blk*.dat 文件。
+// 主链由打开时的区块索引（blocks/index，LevelDB）确定，更高的区块返回 errBlockNotInSource
+type BlkFileSource struct {
+	blocksDir string
+	magic     uint32
+	xorKey    []byte
+	chain     []blkPos // 按高度
+	hashes    []chainhash.Hash
+
+	mutex sync.Mutex
+	files map[int]*os.File
+}
+
+// NewBlkFileSource 读取区块索引的一个快照，bitcoind 可以同时运行。
+// bestHash 为空时使用索引中最高的有效区块作为链顶
+func NewBlkFileSource(blocksDir string, chaincfgParam *chaincfg.Params, bestHash string) (*BlkFileSource, error) {
+	xorKey, err := loadBlkXorKey(blocksDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// bitcoind 运行时会修改索引，复制一份再打开
+	snapshot, err := os.MkdirTemp("", "blkindex")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(snapshot)
+	err = copyDir(filepath.Join(blocksDir, "index"), snapshot)
+	if err != nil {
+		return nil, err
+	}
+	ldb, err := leveldb.OpenFile(snapshot, &opt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer ldb.Close()
+
+	entries, tip, err := loadBlkIndex(ldb)
+	if err != nil {
+		return nil, err
+	}
+	if bestHash != "" {
+		hash, err := chainhash.NewHashFromStr(bestHash)
+		if err == nil {
+			if _, ok := entries[*hash]; ok {
+				tip = *hash
+			}
+		}
+	}
+	if _, ok := entries[tip]; !ok {
+		return nil, fmt.Errorf("no valid block in %s", blocksDir)
+	}
+
+	source := &BlkFileSource{
+		blocksDir: blocksDir,
+		magic:     uint32(chaincfgParam.Net),
+		xorKey:    xorKey,
+		files:     make(map[int]*os.File),
+	}
+	// 从链顶往回找到创世区块
+	entry := entries[tip]
+	source.chain = make([]blkPos, entry.height+1)
+	source.hashes = make([]chainhash.Hash, entry.height+1)
+	hash := tip
+	for {
+		source.chain[entry.height] = blkPos{status: entry.status, file: entry.file, dataPos: entry.dataPos}
+		source.hashes[entry.height] = hash
+		if entry.height == 0 {
+			break
+		}
+		hash = entry.prev
+		prev, ok := entries[hash]
+		if !ok || prev.height != entry.height-1 {
+			return nil, fmt.Errorf("broken block index at height %d", entry.height-1)
+		}
+		entry = prev
+	}
+	if source.hashes[0] != *chaincfgParam.GenesisHash {
+		return nil, fmt.Errorf("genesis block mismatch, %s", source.hashes[0])
+	}
+
+	common.Log.Infof("blk file source: %s, tip %d %s", blocksDir, source.Tip(), tip)
+	return source, nil
+}
+
+func (p *BlkFileSource) Name() string {
+	return "blkfile"
+}
+
+func (p *BlkFileSource) Tip() int {
+	return len(p.chain) - 1
+}
+
+func (p *BlkFileSource) GetRawBlock(height int) ([]byte, error) {
+	if height < 0 {
+		return nil, fmt.Errorf("invalid height %d", height)
+	}
+	if height > p.Tip()-blkSourceSafeDepth {
+		return nil, errBlockNotInSource
+	}
+	entry := p.chain[height]
+	if entry.status&blockHaveData == 0 {
+		return nil, fmt.Errorf("block %d data pruned", height)
+	}
+	if entry.dataPos < 8 {
+		return nil, fmt.Errorf("invalid data pos %d", entry.dataPos)
+	}
+
+	f, err := p.openFile(entry.file)
+	if err != nil {
+		return nil, err
+	}
+	// magic(4) + size(4) + block
+	start := int64(entry.dataPos) - 8
+	head := make([]byte, 8)
+	if _, err := f.ReadAt(head, start); err != nil {
+		return nil, err
+	}
+	p.xor(head, start)
+	if binary.LittleEndian.Uint32(head[:4]) != p.magic {
+		return nil, fmt.Errorf("invalid magic in blk%05d.dat at %d", entry.file, start)
+	}
+	size := binary.LittleEndian.Uint32(head[4:])
+	if size < wire.MaxBlockHeaderPayload || size > wire.MaxBlockPayload {
+		return nil, fmt.Errorf("invalid block size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, int64(entry.dataPos)); err != nil {
+		return nil, err
+	}
+	p.xor(data, int64(entry.dataPos))
+
+	if chainhash.DoubleHashH(data[:wire.MaxBlockHeaderPayload]) != p.hashes[height] {
+		return nil, fmt.Errorf("block %d hash mismatch", height)
+	}
+	return data, nil
+}
+
+func (p *BlkFileSource) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, f := range p.files {
+		f.Close()
+	}
+	p.files = make(map[int]*os.File)
+	return nil
+}
+
+func (p *BlkFileSource) openFile(n int) (*os.File, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	f, ok := p.files[n]
+	if ok {
+		return f, nil
+	}
+	f, err := os.Open(filepath.Join(p.blocksDir, fmt.Sprintf("blk%05d.dat", n)))
+	if err != nil {
+		return nil, err
+	}
+	p.files[n] = f
+	return f, nil
+}
+
+// bitcoind 28 之后 blk 文件按照 xor.dat 中的密钥混淆，密钥按文件偏移循环使用
+func (p *BlkFileSource) xor(data []byte, offset int64) {
+	if len(p.xorKey) == 0 {
+		return
+	}
+	for i := range data {
+		data[i] ^= p.xorKey[(offset+int64(i))%int64(len(p.xorKey))]
+	}
+}
+
+func loadBlkXorKey(blocksDir string) ([]byte, error) {
+	key, err := os.ReadFile(filepath.Join(blocksDir, "xor.dat"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(key) != 8 {
+		return nil, fmt.Errorf("invalid xor.dat size %d", len(key))
+	}
+	if bytes.Equal(key, make([]byte, 8)) {
+		return nil, nil
+	}
+	return key, nil
+}
+
+// 返回所有有数据的区块，以及最高的有效区块
+func loadBlkIndex(ldb *leveldb.DB) (map[chainhash.Hash]*blkIndexEntry, chainhash.Hash, error) {
+	entries := make(map[chainhash.Hash]*blkIndexEntry)
+	var tip chainhash.Hash
+	tipHeight := -1
+
+	iter := ldb.NewIterator(util.BytesPrefix([]byte{'b'}), nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != 1+chainhash.HashSize {
+			continue
+		}
+		entry, err := parseBlkIndexEntry(iter.Value())
+		if err != nil {
+			return nil, tip, err
+		}
+		if entry.status&(blockFailedValid|blockFailedChild) != 0 {
+			continue
+		}
+		var hash chainhash.Hash
+		copy(hash[:], key[1:])
+		entries[hash] = entry
+		if entry.status&blockHaveData != 0 && entry.height > tipHeight {
+			tip = hash
+			tipHeight = entry.height
+		}
+	}
+	return entries, tip, iter.Error()
+}
+
+// CDiskBlockIndex 的序列化格式
+func parseBlkIndexEntry(value []byte) (*blkIndexEntry, error) {
+	r := bytes.NewReader(value)
+	if _, err := readBlkVarInt(r); err != nil { // client version
+		return nil, err
+	}
+	height, err := readBlkVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	status, err := readBlkVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := readBlkVarInt(r); err != nil { // nTx
+		return nil, err
+	}
+	entry := &blkIndexEntry{height: int(height), status: status}
+	if status&(blockHaveData|blockHaveUndo) != 0 {
+		file, err := readBlkVarInt(r)
+		if err != nil {
+			return nil, err
+		}
+		entry.file = int(file)
+	}
+	if status&blockHaveData != 0 {
+		pos, err := readBlkVarInt(r)
+		if err != nil {
+			return nil, err
+		}
+		entry.dataPos = uint32(pos)
+	}
+	if status&blockHaveUndo != 0 {
+		if _, err := readBlkVarInt(r); err != nil { // nUndoPos
+			return nil, err
+		}
+	}
+
+	var header wire.BlockHeader
+	if err := header.Deserialize(r); err != nil {
+		return nil, err
+	}
+	entry.prev = header.PrevBlock
+	return entry, nil
+}
+
+// bitcoind 的 VARINT，每个字节 7 位，除最后一个字节外每字节加 1
+func readBlkVarInt(r io.ByteReader) (uint64, error) {
+	var n uint64
+	for i := 0; i < 10; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = (n << 7) | uint64(b&0x7f)
+		if b&0x80 == 0 {
+			return n, nil
+		}
+		n++
+	}
+	return 0, fmt.Errorf("varint too long")
+}
+
+func copyDir(src, dst string) error {
+	items, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.IsDir() || item.Name() == "LOCK" {
+			continue
+		}
+		err := copyFile(filepath.Join(src, item.Name()), filepath.Join(dst, item.Name()))
+		if err != nil {
+			// 复制期间 bitcoind 压缩删除的文件
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err2 := out.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,208 @@
+package base
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func putBlkVarInt(buf *bytes.Buffer, n uint64) {
+	var tmp [10]byte
+	i := 0
+	for {
+		tmp[i] = byte(n & 0x7f)
+		if i > 0 {
+			tmp[i] |= 0x80
+		}
+		if n <= 0x7f {
+			break
+		}
+		n = (n >> 7) - 1
+		i++
+	}
+	for ; i >= 0; i-- {
+		buf.WriteByte(tmp[i])
+	}
+}
+
+func encodeBlkIndexEntry(height int, status uint64, file int, pos uint32, header *wire.BlockHeader) []byte {
+	var buf bytes.Buffer
+	putBlkVarInt(&buf, 289900)
+	putBlkVarInt(&buf, uint64(height))
+	putBlkVarInt(&buf, status)
+	putBlkVarInt(&buf, 1)
+	putBlkVarInt(&buf, uint64(file))
+	putBlkVarInt(&buf, uint64(pos))
+	if status&blockHaveUndo != 0 {
+		putBlkVarInt(&buf, 0)
+	}
+	header.Serialize(&buf)
+	return buf.Bytes()
+}
+
+// 生成一个 regtest 的 blocks 目录，返回每个高度的区块数据
+func makeBlocksDir(t *testing.T, count int, xorKey []byte) (string, [][]byte) {
+	dir := t.TempDir()
+	params := &chaincfg.RegressionNetParams
+	ldb, err := leveldb.OpenFile(filepath.Join(dir, "index"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blk bytes.Buffer
+	blocks := make([][]byte, 0, count)
+	prev := params.GenesisBlock
+	for height := 0; height < count; height++ {
+		block := prev
+		if height > 0 {
+			block = &wire.MsgBlock{Header: prev.Header, Transactions: prev.Transactions}
+			block.Header.PrevBlock = prev.BlockHash()
+			block.Header.Nonce = uint32(height)
+		}
+		var data bytes.Buffer
+		if err := block.Serialize(&data); err != nil {
+			t.Fatal(err)
+		}
+		binary.Write(&blk, binary.LittleEndian, uint32(params.Net))
+		binary.Write(&blk, binary.LittleEndian, uint32(data.Len()))
+		pos := uint32(blk.Len())
+		blk.Write(data.Bytes())
+		blocks = append(blocks, data.Bytes())
+
+		hash := block.BlockHash()
+		value := encodeBlkIndexEntry(height, blockHaveData|blockHaveUndo|5, 0, pos, &block.Header)
+		if err := ldb.Put(append([]byte{'b'}, hash[:]...), value, nil); err != nil {
+			t.Fatal(err)
+		}
+		prev = block
+	}
+	// 一个无效的分叉区块，高度更高也不能作为链顶
+	fork := &wire.MsgBlock{Header: prev.Header}
+	fork.Header.PrevBlock = prev.BlockHash()
+	fork.Header.Nonce = 0xffff
+	forkHash := fork.BlockHash()
+	value := encodeBlkIndexEntry(count, blockHaveData|blockFailedValid, 0, 8, &fork.Header)
+	if err := ldb.Put(append([]byte{'b'}, forkHash[:]...), value, nil); err != nil {
+		t.Fatal(err)
+	}
+	ldb.Close()
+
+	raw := blk.Bytes()
+	if len(xorKey) > 0 {
+		for i := range raw {
+			raw[i] ^= xorKey[i%len(xorKey)]
+		}
+		os.WriteFile(filepath.Join(dir, "xor.dat"), xorKey, 0644)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "blk00000.dat"), raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir, blocks
+}
+
+func TestBlkFileSourceReadsMainChain(t *testing.T) {
+	for _, xorKey := range [][]byte{nil, {1, 2, 3, 4, 5, 6, 7, 8}} {
+		dir, blocks := makeBlocksDir(t, 10, xorKey)
+		source, err := NewBlkFileSource(dir, &chaincfg.RegressionNetParams, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if source.Tip() != 9 {
+			t.Fatalf("tip %d", source.Tip())
+		}
+		for height := 0; height <= source.Tip()-blkSourceSafeDepth; height++ {
+			data, err := source.GetRawBlock(height)
+			if err != nil {
+				t.Fatalf("height %d: %v", height, err)
+			}
+			if !bytes.Equal(data, blocks[height]) {
+				t.Fatalf("height %d: block data mismatch", height)
+			}
+		}
+		if _, err := source.GetRawBlock(source.Tip()); err != errBlockNotInSource {
+			t.Fatalf("recent block should be left to rpc, got %v", err)
+		}
+		source.Close()
+	}
+}
+
+func TestBlkFileSourceUsesBestHash(t *testing.T) {
+	dir, _ := makeBlocksDir(t, 10, nil)
+	ldb, err := leveldb.OpenFile(filepath.Join(dir, "index"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iter := ldb.NewIterator(nil, nil)
+	var best chainhash.Hash
+	for iter.Next() {
+		entry, err := parseBlkIndexEntry(iter.Value())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.height == 8 && entry.status&blockFailedValid == 0 {
+			copy(best[:], iter.Key()[1:])
+		}
+	}
+	iter.Release()
+	ldb.Close()
+
+	source, err := NewBlkFileSource(dir, &chaincfg.RegressionNetParams, best.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.Tip() != 8 {
+		t.Fatalf("tip %d", source.Tip())
+	}
+}
+
+type stubBlockSource struct {
+	name   string
+	err    error
+	calls  int
+	closed bool
+}
+
+func (p *stubBlockSource) Name() string { return p.name }
+func (p *stubBlockSource) GetRawBlock(height int) ([]byte, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []byte(p.name), nil
+}
+func (p *stubBlockSource) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestFallbackBlockSource(t *testing.T) {
+	primary := &stubBlockSource{name: "primary", err: errors.New("broken")}
+	fallback := &stubBlockSource{name: "fallback"}
+	source := NewFallbackBlockSource(primary, fallback)
+
+	data, err := source.GetRawBlock(1)
+	if err != nil || string(data) != "fallback" {
+		t.Fatalf("got %s %v", data, err)
+	}
+	if primary.closed {
+		t.Fatal("primary should be kept after a read error")
+	}
+
+	primary.err = errBlockNotInSource
+	source.GetRawBlock(2)
+	if !primary.closed || source.Name() != "fallback" {
+		t.Fatal("primary should be released after the last block")
+	}
+	source.GetRawBlock(3)
+	if primary.calls != 2 || fallback.calls != 3 {
+		t.Fatalf("calls primary %d fallback %d", primary.calls, fallback.calls)
+	}
+}
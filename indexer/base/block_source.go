@@ -0,0 +1,130 @@
+package base
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// BlockSource 按高度提供区块的原始数据
+type BlockSource interface {
+	Name() string
+	GetRawBlock(height int) ([]byte, error)
+	Close() error
+}
+
+var (
+	blockSourceMutex sync.RWMutex
+	shareBlockSource BlockSource = &RpcBlockSource{}
+)
+
+// SetBlockSource 替换 FetchBlock 使用的区块来源，nil 恢复为 RPC
+func SetBlockSource(source BlockSource) {
+	if source == nil {
+		source = &RpcBlockSource{}
+	}
+	blockSourceMutex.Lock()
+	old := shareBlockSource
+	shareBlockSource = source
+	blockSourceMutex.Unlock()
+
+	if old != source {
+		old.Close()
+	}
+	common.Log.Infof("block source: %s", source.Name())
+}
+
+func getBlockSource() BlockSource {
+	blockSourceMutex.RLock()
+	defer blockSourceMutex.RUnlock()
+	return shareBlockSource
+}
+
+// RpcBlockSource 通过 bitcoind 的 getblockhash/getblock 获取区块
+type RpcBlockSource struct{}
+
+func (p *RpcBlockSource) Name() string {
+	return "rpc"
+}
+
+func (p *RpcBlockSource) GetRawBlock(height int) ([]byte, error) {
+	hash, err := getBlockHash(uint64(height))
+	if err != nil {
+		return nil, err
+	}
+	rawBlock, err := getRawBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(rawBlock)
+}
+
+func (p *RpcBlockSource) Close() error {
+	return nil
+}
+
+// FallbackBlockSource 优先使用 primary，读取失败时使用 fallback。
+// primary 没有更高的区块时（初始同步完成），关闭 primary，之后只使用 fallback
+type FallbackBlockSource struct {
+	mutex    sync.RWMutex
+	primary  BlockSource
+	fallback BlockSource
+}
+
+func NewFallbackBlockSource(primary, fallback BlockSource) *FallbackBlockSource {
+	return &FallbackBlockSource{primary: primary, fallback: fallback}
+}
+
+func (p *FallbackBlockSource) Name() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.primary == nil {
+		return p.fallback.Name()
+	}
+	return p.primary.Name() + "+" + p.fallback.Name()
+}
+
+func (p *FallbackBlockSource) GetRawBlock(height int) ([]byte, error) {
+	p.mutex.RLock()
+	primary := p.primary
+	p.mutex.RUnlock()
+
+	if primary != nil {
+		data, err := primary.GetRawBlock(height)
+		if err == nil {
+			return data, nil
+		}
+		if err == errBlockNotInSource {
+			p.releasePrimary(primary, height)
+		} else {
+			common.Log.Warnf("%s: get block %d failed, %v. use %s", primary.Name(), height, err, p.fallback.Name())
+		}
+	}
+	return p.fallback.GetRawBlock(height)
+}
+
+func (p *FallbackBlockSource) releasePrimary(primary BlockSource, height int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.primary != primary {
+		return
+	}
+	common.Log.Infof("%s has no block %d, switch to %s", primary.Name(), height, p.fallback.Name())
+	primary.Close()
+	p.primary = nil
+}
+
+func (p *FallbackBlockSource) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var err error
+	if p.primary != nil {
+		err = p.primary.Close()
+		p.primary = nil
+	}
+	if err2 := p.fallback.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -1,8 +1,6 @@
 package base
 
 import (
-	"encoding/hex"
-
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
@@ -11,24 +9,11 @@ import (
 
 // 不要panic，可能会影响写数据库
 func FetchBlock(height int, chaincfgParam *chaincfg.Params) *common.Block {
-	hash, err := getBlockHash(uint64(height))
+	source := getBlockSource()
+	blockData, err := source.GetRawBlock(height)
 	if err != nil {
-		common.Log.Errorf("getBlockHash %d failed. %v", height, err)
+		common.Log.Errorf("%s: get block %d failed. %v", source.Name(), height, err)
 		return nil
-		//common.Log.Fatalln(err)
-	}
-
-	rawBlock, err := getRawBlock(hash)
-	if err != nil {
-		common.Log.Errorf("getRawBlock %d %s failed. %v", height, hash, err)
-		return nil
-		//common.Log.Fatalln(err)
-	}
-	blockData, err := hex.DecodeString(rawBlock)
-	if err != nil {
-		common.Log.Errorf("DecodeString %d %s failed. %v", height, rawBlock, err)
-		return nil
-		//common.Log.Panicf("BaseIndexer.fetchBlock-> Failed to decode block: %v", err)
 	}
 
 	// Deserialize the bytes into a btcutil.Block.
@@ -124,7 +109,7 @@ func FetchBlock(height int, chaincfgParam *chaincfg.Params) *common.Block {
 	return bl
 }
 
-// Prefetches blocks from bitcoind and sends them to the blocksChan
+// Prefetches blocks from the block source and sends them to the blocksChan
 func (b *BaseIndexer) spawnBlockFetcher(startHeigh int, endHeight int, stopChan chan struct{}) {
 	currentHeight := startHeigh
 	for currentHeight <= endHeight {
@@ -133,7 +118,12 @@ func (b *BaseIndexer) spawnBlockFetcher(startHeigh int, endHeight int, stopChan
 			return
 		default:
 			block := FetchBlock(currentHeight, b.chaincfgParam)
-			b.blocksChan <- block
+			// 分叉后实例被丢弃，没有人再读 blocksChan
+			select {
+			case b.blocksChan <- block:
+			case <-stopChan:
+				return
+			}
 			currentHeight += 1
 		}
 	}
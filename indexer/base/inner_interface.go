@@ -10,6 +10,8 @@ import (
 */
 
 func (p *BaseIndexer) GetAddressId(address string) uint64 {
+	p.addressMutex.Lock()
+	defer p.addressMutex.Unlock()
 	id, _ := p.getAddressId(address)
 	return id
 }
@@ -60,3 +60,7 @@ func getRawBlock(blockHash string) (string, error) {
 	}
 	return h, err
 }
+
+func GetBlockHash(height int) (string, error) {
+	return getBlockHash(uint64(height))
+}
@@ -7,11 +7,22 @@ import (
 ///// rpc interface, run in mul-thread
 
 func (p *IndexerMgr) GetOrdinalsWithUtxo(utxo string) (uint64, []*common.Range, error) {
-	return p.rpcService.GetOrdinalsWithUtxo(utxo)
+	utxoId, ranges, err := p.rpcService.GetOrdinalsWithUtxo(utxo)
+	if err != nil || p.satIndexer == nil {
+		return utxoId, ranges, err
+	}
+	// 打开聪区间索引后，才有区间数据
+	ranges, err = p.satIndexer.GetRangesWithUtxoId(utxoId)
+	return utxoId, ranges, err
 }
 
 func (p *IndexerMgr) GetOrdinalsWithUtxoId(id uint64) (string, []*common.Range, error) {
-	return p.rpcService.GetOrdinalsWithUtxoId(id)
+	utxo, ranges, err := p.rpcService.GetOrdinalsWithUtxoId(id)
+	if err != nil || p.satIndexer == nil {
+		return utxo, ranges, err
+	}
+	ranges, err = p.satIndexer.GetRangesWithUtxoId(id)
+	return utxo, ranges, err
 }
 
 // 过滤已经被花费的utxo
@@ -48,6 +59,9 @@ func (p *IndexerMgr) GetBlockInfo(height int) (*common.BlockInfo, error) {
 }
 
 func (p *IndexerMgr) GetHolderAddress(inscriptionId string) string {
+	if p.nft == nil {
+		return ""
+	}
 	nft := p.nft.GetNftWithInscriptionId(inscriptionId)
 	if nft != nil {
 		address, err := p.rpcService.GetAddressByID(nft.OwnerAddressId)
@@ -0,0 +1,632 @@
+package brc20
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+// 对外展示的活动类型
+const (
+	ACTIVITY_DEPLOY            = "deploy"
+	ACTIVITY_MINT              = "mint"
+	ACTIVITY_INSCRIBE_TRANSFER = "inscribe-transfer"
+	ACTIVITY_TRANSFER          = "transfer" // ticker 视角
+	ACTIVITY_SEND              = "send"     // 地址视角，转出
+	ACTIVITY_RECEIVE           = "receive"  // 地址视角，转入
+	ACTIVITY_CANCEL            = "cancel"   // transfer 铭文被取消，比如作为手续费花费，资产回到自己的可用余额
+	ACTIVITY_SPENT             = "spent"    // 已经失效的 transfer/mint 铭文被花费，不影响余额
+)
+
+type ActivityBalance struct {
+	Available    *common.Decimal
+	Transferable *common.Decimal
+}
+
+type Activity struct {
+	*common.BRC20ActionHistory
+	Type   string
+	Cursor string
+	// 地址视角：该地址在这个事件之后的余额
+	Balance *ActivityBalance
+	// ticker 视角：双方在这个事件之后的余额
+	FromBalance *ActivityBalance
+	ToBalance   *ActivityBalance
+}
+
+// activityRecord 活动索引中的一条记录，写入时带上双方在事件之后的余额，查询时不需要回放
+type activityRecord struct {
+	History     *common.BRC20ActionHistory
+	Seq         int // 在 ticker 或者地址的活动中的序号，用于计算区间内的总数
+	FromBalance *ActivityBalance
+	ToBalance   *ActivityBalance
+}
+
+var errStopActivity = errors.New("stop")
+
+type activityKey struct {
+	height  int
+	txIndex int
+	action  int
+	nftId   int64
+}
+
+func newActivityKey(item *common.BRC20ActionHistory) activityKey {
+	return activityKey{height: item.Height, txIndex: item.TxIndex, action: item.Action, nftId: item.NftId}
+}
+
+// 同一个交易中，先铭刻后转移，然后按铭文排序
+func (k activityKey) less(other activityKey) bool {
+	if k.height != other.height {
+		return k.height < other.height
+	}
+	if k.txIndex != other.txIndex {
+		return k.txIndex < other.txIndex
+	}
+	if k.action != other.action {
+		return k.action < other.action
+	}
+	return k.nftId < other.nftId
+}
+
+func (k activityKey) String() string {
+	return fmt.Sprintf("%x_%x_%x_%x", k.height, k.txIndex, k.action, k.nftId)
+}
+
+// sortKey 定长编码，在数据库中按字节排序跟 less 一致
+func (k activityKey) sortKey() string {
+	return fmt.Sprintf("%08x%08x%02x%016x", k.height, k.txIndex, k.action, uint64(k.nftId)^(1<<63))
+}
+
+// heightStartKey 该高度第一个可能的 sortKey
+func heightStartKey(height int) string {
+	return activityKey{height: height, nftId: math.MinInt64}.sortKey()
+}
+
+func parseActivityCursor(cursor string) (activityKey, error) {
+	var key activityKey
+	_, err := fmt.Sscanf(strings.ReplaceAll(cursor, "_", " "), "%x %x %x %x",
+		&key.height, &key.txIndex, &key.action, &key.nftId)
+	if err != nil {
+		return key, fmt.Errorf("invalid cursor %s", cursor)
+	}
+	return key, nil
+}
+
+// 按发生顺序排序，同时去掉重复的记录（数据库和缓存中可能同时存在）
+func sortActionHistory(history []*common.BRC20ActionHistory) []*common.BRC20ActionHistory {
+	sort.SliceStable(history, func(i, j int) bool {
+		return newActivityKey(history[i]).less(newActivityKey(history[j]))
+	})
+	result := make([]*common.BRC20ActionHistory, 0, len(history))
+	for i, item := range history {
+		if i > 0 && newActivityKey(history[i-1]) == newActivityKey(item) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func loadActivityBalance(balances map[uint64]*ActivityBalance, addressId uint64) *ActivityBalance {
+	balance, ok := balances[addressId]
+	if !ok {
+		balance = &ActivityBalance{}
+		balances[addressId] = balance
+	}
+	return balance
+}
+
+// applyActivityBalance 按事件修改余额，返回双方修改后的余额快照
+func applyActivityBalance(balances map[uint64]*ActivityBalance, item *common.BRC20ActionHistory) (*ActivityBalance, *ActivityBalance) {
+	switch item.Action {
+	case common.BRC20_Action_InScribe_Mint:
+		to := loadActivityBalance(balances, item.ToAddr)
+		to.Available = to.Available.Add(&item.Amount)
+
+	case common.BRC20_Action_InScribe_Transfer:
+		to := loadActivityBalance(balances, item.ToAddr)
+		to.Available = to.Available.Sub(&item.Amount)
+		to.Transferable = to.Transferable.Add(&item.Amount)
+
+	case common.BRC20_Action_Transfer:
+		from := loadActivityBalance(balances, item.FromAddr)
+		from.Transferable = from.Transferable.Sub(&item.Amount)
+		to := loadActivityBalance(balances, item.ToAddr)
+		to.Available = to.Available.Add(&item.Amount)
+	}
+
+	var from, to *ActivityBalance
+	if item.FromAddr != common.INVALID_ID {
+		b := *loadActivityBalance(balances, item.FromAddr)
+		from = &b
+	}
+	if item.ToAddr != common.INVALID_ID {
+		b := *loadActivityBalance(balances, item.ToAddr)
+		to = &b
+	}
+	return from, to
+}
+
+func activityType(item *common.BRC20ActionHistory, addressId uint64) string {
+	switch item.Action {
+	case common.BRC20_Action_InScribe_Deploy:
+		return ACTIVITY_DEPLOY
+	case common.BRC20_Action_InScribe_Mint:
+		return ACTIVITY_MINT
+	case common.BRC20_Action_InScribe_Transfer:
+		return ACTIVITY_INSCRIBE_TRANSFER
+	case common.BRC20_Action_Transfer:
+		if item.FromAddr == item.ToAddr {
+			return ACTIVITY_CANCEL
+		}
+		if addressId == common.INVALID_ID {
+			return ACTIVITY_TRANSFER
+		}
+		if addressId == item.FromAddr {
+			return ACTIVITY_SEND
+		}
+		return ACTIVITY_RECEIVE
+	case common.BRC20_Action_Transfer_Spent:
+		return ACTIVITY_SPENT
+	}
+	return ""
+}
+
+func newActivity(record *activityRecord, addressId uint64) *Activity {
+	item := record.History
+	activity := &Activity{
+		BRC20ActionHistory: item,
+		Type:               activityType(item, addressId),
+		Cursor:             newActivityKey(item).String(),
+	}
+	if addressId == common.INVALID_ID {
+		activity.FromBalance = record.FromBalance
+		activity.ToBalance = record.ToBalance
+	} else if item.FromAddr == addressId {
+		activity.Balance = record.FromBalance
+	} else {
+		activity.Balance = record.ToBalance
+	}
+	return activity
+}
+
+// activityHolders 活动属于哪些地址，跟 DB_PREFIX_TRANSFER_HISTORY_HOLDER 一致，spent 只属于转出方
+func activityHolders(item *common.BRC20ActionHistory) []uint64 {
+	if item.FromAddr == common.INVALID_ID {
+		if item.ToAddr == common.INVALID_ID {
+			return nil
+		}
+		return []uint64{item.ToAddr}
+	}
+	if item.Action != common.BRC20_Action_Transfer || item.FromAddr == item.ToAddr {
+		return []uint64{item.FromAddr}
+	}
+	return []uint64{item.FromAddr, item.ToAddr}
+}
+
+func isActivityHolder(item *common.BRC20ActionHistory, addressId uint64) bool {
+	for _, holder := range activityHolders(item) {
+		if holder == addressId {
+			return true
+		}
+	}
+	return false
+}
+
+// buildActivities 从头回放所有事件计算余额，然后按高度和游标过滤，返回这一页、区间内总数和下一页的游标
+// 只用于活动索引之前部署的 ticker
+func buildActivities(history []*common.BRC20ActionHistory, addressId uint64, filter *common.ActivityFilter) ([]*Activity, int, string, error) {
+	var cursor *activityKey
+	if filter.Cursor != "" {
+		key, err := parseActivityCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		cursor = &key
+	}
+
+	history = sortActionHistory(history)
+	balances := make(map[uint64]*ActivityBalance)
+	activities := make([]*Activity, 0)
+	for _, item := range history {
+		from, to := applyActivityBalance(balances, item)
+		if filter.StartHeight > 0 && item.Height < filter.StartHeight {
+			continue
+		}
+		if filter.EndHeight > 0 && item.Height > filter.EndHeight {
+			continue
+		}
+		record := &activityRecord{History: item, FromBalance: from, ToBalance: to}
+		activities = append(activities, newActivity(record, addressId))
+	}
+	total := len(activities)
+
+	if filter.Desc {
+		for i, j := 0, len(activities)-1; i < j; i, j = i+1, j-1 {
+			activities[i], activities[j] = activities[j], activities[i]
+		}
+	}
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(activities), func(i int) bool {
+			key := newActivityKey(activities[i].BRC20ActionHistory)
+			if filter.Desc {
+				return key.less(*cursor)
+			}
+			return cursor.less(key)
+		})
+	}
+	end := len(activities)
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+	result := activities[start:end]
+
+	next := ""
+	if end < len(activities) && len(result) > 0 {
+		next = result[len(result)-1].Cursor
+	}
+	return result, total, next, nil
+}
+
+// currentActivityBalance 地址当前的余额，内存中没有就是数据库中的
+func (s *BRC20Indexer) currentActivityBalance(name string, addressId uint64) *ActivityBalance {
+	var info *common.BRC20TickAbbrInfo
+	if holder, ok := s.holderMap[addressId]; ok {
+		info = holder.Tickers[name]
+	}
+	if info == nil {
+		info = s.loadTickAbbrInfoFromDB(addressId, name)
+	}
+	if info == nil {
+		return &ActivityBalance{}
+	}
+	return &ActivityBalance{
+		Available:    info.AvailableBalance.Clone(),
+		Transferable: info.TransferableBalance.Clone(),
+	}
+}
+
+// revertActivityBalance applyActivityBalance 的逆操作
+func revertActivityBalance(item *common.BRC20ActionHistory, balance func(uint64) *ActivityBalance) {
+	switch item.Action {
+	case common.BRC20_Action_InScribe_Mint:
+		to := balance(item.ToAddr)
+		to.Available = to.Available.Sub(&item.Amount)
+
+	case common.BRC20_Action_InScribe_Transfer:
+		to := balance(item.ToAddr)
+		to.Available = to.Available.Add(&item.Amount)
+		to.Transferable = to.Transferable.Sub(&item.Amount)
+
+	case common.BRC20_Action_Transfer:
+		from := balance(item.FromAddr)
+		from.Transferable = from.Transferable.Add(&item.Amount)
+		to := balance(item.ToAddr)
+		to.Available = to.Available.Sub(&item.Amount)
+	}
+}
+
+// newActivityRecords 从当前余额倒推每个事件之后双方的余额，actions 按处理的顺序排列
+func (s *BRC20Indexer) newActivityRecords(actions []*HolderAction) []*activityRecord {
+	balances := make(map[string]map[uint64]*ActivityBalance)
+	records := make([]*activityRecord, len(actions))
+	for i := len(actions) - 1; i >= 0; i-- {
+		item := actions[i]
+		tickerBalances, ok := balances[item.Ticker]
+		if !ok {
+			tickerBalances = make(map[uint64]*ActivityBalance)
+			balances[item.Ticker] = tickerBalances
+		}
+		balance := func(addressId uint64) *ActivityBalance {
+			b, ok := tickerBalances[addressId]
+			if !ok {
+				b = s.currentActivityBalance(item.Ticker, addressId)
+				tickerBalances[addressId] = b
+			}
+			return b
+		}
+
+		record := &activityRecord{History: item}
+		if item.FromAddr != common.INVALID_ID {
+			b := *balance(item.FromAddr)
+			record.FromBalance = &b
+		}
+		if item.ToAddr != common.INVALID_ID {
+			b := *balance(item.ToAddr)
+			record.ToBalance = &b
+		}
+		revertActivityBalance(item, balance)
+		records[i] = record
+	}
+	return records
+}
+
+func sortActivityRecords(records []*activityRecord) {
+	sort.SliceStable(records, func(i, j int) bool {
+		return newActivityKey(records[i].History).less(newActivityKey(records[j].History))
+	})
+}
+
+// iterActivityFromDB 从 seek 开始按顺序读取，正向包含 seek，反向从小于 seek 的记录开始，fn 返回 false 停止
+func (s *BRC20Indexer) iterActivityFromDB(prefix, seek string, reverse bool, fn func(record *activityRecord) bool) error {
+	var seekKey []byte
+	if seek != "" {
+		seekKey = []byte(prefix + seek)
+	}
+	err := s.db.BatchReadV2([]byte(prefix), seekKey, reverse, func(k, v []byte) error {
+		if reverse && seekKey != nil && bytes.Compare(k, seekKey) >= 0 {
+			return nil
+		}
+		var record activityRecord
+		if err := db.DecodeBytes(v, &record); err != nil {
+			return err
+		}
+		if !fn(&record) {
+			return errStopActivity
+		}
+		return nil
+	})
+	if err == errStopActivity {
+		return nil
+	}
+	return err
+}
+
+func (s *BRC20Indexer) loadLastActivityFromDB(prefix string) (*activityRecord, error) {
+	var last *activityRecord
+	err := s.iterActivityFromDB(prefix, "", true, func(record *activityRecord) bool {
+		last = record
+		return false
+	})
+	return last, err
+}
+
+// updateActivityToDB 保存活动索引，序号接着数据库中的最后一条记录
+func (s *BRC20Indexer) updateActivityToDB(wb common.WriteBatch) {
+	records := s.newActivityRecords(s.holderActionList)
+	sortActivityRecords(records)
+
+	seqs := make(map[string]int)
+	put := func(prefix string, record *activityRecord) {
+		seq, ok := seqs[prefix]
+		if !ok {
+			last, err := s.loadLastActivityFromDB(prefix)
+			if err != nil {
+				common.Log.Panicf("loadLastActivityFromDB %s failed, %v", prefix, err)
+			}
+			if last != nil {
+				seq = last.Seq + 1
+			}
+		}
+		value := *record
+		value.Seq = seq
+		key := prefix + newActivityKey(record.History).sortKey()
+		err := db.SetDB([]byte(key), &value, wb)
+		if err != nil {
+			common.Log.Panicf("Error setting %s in db %v", key, err)
+		}
+		seqs[prefix] = seq + 1
+	}
+
+	for i, record := range records {
+		item := record.History
+		if i > 0 && newActivityKey(records[i-1].History) == newActivityKey(item) {
+			continue
+		}
+		put(GetActivityPrefix(item.Ticker), record)
+		for _, addressId := range activityHolders(item) {
+			put(GetHolderActivityPrefix(item.Ticker, addressId), record)
+		}
+	}
+}
+
+func (s *BRC20Indexer) pendingTickerActions(name string) []*HolderAction {
+	result := make([]*HolderAction, 0)
+	for _, item := range s.holderActionList {
+		if item.Ticker == name {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// activityIndexed 活动索引是后来加的，只有从部署开始都有索引的 ticker 才能按索引分页
+func (s *BRC20Indexer) activityIndexed(name string, pending []*HolderAction) (bool, error) {
+	var first *activityRecord
+	err := s.iterActivityFromDB(GetActivityPrefix(name), "", false, func(record *activityRecord) bool {
+		first = record
+		return false
+	})
+	if err != nil {
+		return false, err
+	}
+	if first != nil {
+		return first.History.Action == common.BRC20_Action_InScribe_Deploy, nil
+	}
+	for _, item := range pending {
+		if item.Action == common.BRC20_Action_InScribe_Deploy {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// loadActivities 按游标从活动索引中读取一页，pending 是还没有写入数据库的记录
+func (s *BRC20Indexer) loadActivities(prefix string, pending []*activityRecord, addressId uint64,
+	filter *common.ActivityFilter) ([]*Activity, int, string, error) {
+	var cursor *activityKey
+	if filter.Cursor != "" {
+		key, err := parseActivityCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		cursor = &key
+	}
+
+	// 正在写入数据库时，内存中的记录可能已经在数据库中
+	last, err := s.loadLastActivityFromDB(prefix)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	sortActivityRecords(pending)
+	if last != nil {
+		lastKey := newActivityKey(last.History)
+		newer := make([]*activityRecord, 0, len(pending))
+		for _, record := range pending {
+			if lastKey.less(newActivityKey(record.History)) {
+				newer = append(newer, record)
+			}
+		}
+		pending = newer
+	}
+
+	inRange := func(item *common.BRC20ActionHistory) bool {
+		return (filter.StartHeight <= 0 || item.Height >= filter.StartHeight) &&
+			(filter.EndHeight <= 0 || item.Height <= filter.EndHeight)
+	}
+
+	// 区间内的总数：数据库中首尾两条记录的序号之差，加上内存中的记录
+	var first *activityRecord
+	err = s.iterActivityFromDB(prefix, heightStartKey(filter.StartHeight), false, func(record *activityRecord) bool {
+		first = record
+		return false
+	})
+	if err != nil {
+		return nil, 0, "", err
+	}
+	end := ""
+	if filter.EndHeight > 0 {
+		end = heightStartKey(filter.EndHeight + 1)
+	}
+	last = nil
+	err = s.iterActivityFromDB(prefix, end, true, func(record *activityRecord) bool {
+		last = record
+		return false
+	})
+	if err != nil {
+		return nil, 0, "", err
+	}
+	total := 0
+	if first != nil && last != nil && first.Seq <= last.Seq {
+		total = last.Seq - first.Seq + 1
+	}
+	for _, record := range pending {
+		if inRange(record.History) {
+			total++
+		}
+	}
+
+	result := make([]*Activity, 0)
+	more := false
+	take := func(record *activityRecord) bool {
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			more = true
+			return false
+		}
+		result = append(result, newActivity(record, addressId))
+		return true
+	}
+
+	if filter.Desc {
+		seek := end
+		if cursor != nil && (seek == "" || cursor.sortKey() < seek) {
+			seek = cursor.sortKey()
+		}
+		done := false
+		for i := len(pending) - 1; i >= 0 && !done; i-- {
+			item := pending[i].History
+			if seek != "" && newActivityKey(item).sortKey() >= seek {
+				continue
+			}
+			done = !inRange(item) || !take(pending[i])
+		}
+		if !done {
+			err = s.iterActivityFromDB(prefix, seek, true, func(record *activityRecord) bool {
+				return inRange(record.History) && take(record)
+			})
+		}
+	} else {
+		seek := heightStartKey(filter.StartHeight)
+		if cursor != nil && cursor.sortKey() > seek {
+			seek = cursor.sortKey()
+		}
+		after := func(item *common.BRC20ActionHistory) bool {
+			return cursor == nil || cursor.less(newActivityKey(item))
+		}
+		done := false
+		err = s.iterActivityFromDB(prefix, seek, false, func(record *activityRecord) bool {
+			if !after(record.History) {
+				return true
+			}
+			done = !inRange(record.History) || !take(record)
+			return !done
+		})
+		for i := 0; i < len(pending) && !done; i++ {
+			item := pending[i].History
+			if !after(item) || newActivityKey(item).sortKey() < seek {
+				continue
+			}
+			done = !inRange(item) || !take(pending[i])
+		}
+	}
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	next := ""
+	if more && len(result) > 0 {
+		next = result[len(result)-1].Cursor
+	}
+	return result, total, next, nil
+}
+
+// GetActivityWithAddress 某个地址在某个 ticker 上的活动历史
+func (s *BRC20Indexer) GetActivityWithAddress(addressId uint64, tick string, filter *common.ActivityFilter) ([]*Activity, int, string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	name := strings.ToLower(tick)
+	pending := s.pendingTickerActions(name)
+	indexed, err := s.activityIndexed(name, pending)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if !indexed {
+		history := s.loadTickerHistoryWithHolder(name, addressId)
+		return buildActivities(history, addressId, filter)
+	}
+
+	records := make([]*activityRecord, 0)
+	for _, record := range s.newActivityRecords(pending) {
+		if isActivityHolder(record.History, addressId) {
+			records = append(records, record)
+		}
+	}
+	return s.loadActivities(GetHolderActivityPrefix(name, addressId), records, addressId, filter)
+}
+
+// GetActivityWithTicker 某个 ticker 的活动历史
+func (s *BRC20Indexer) GetActivityWithTicker(tick string, filter *common.ActivityFilter) ([]*Activity, int, string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	name := strings.ToLower(tick)
+	pending := s.pendingTickerActions(name)
+	indexed, err := s.activityIndexed(name, pending)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if !indexed {
+		history := s.loadTickerHistory(name)
+		return buildActivities(history, common.INVALID_ID, filter)
+	}
+	return s.loadActivities(GetActivityPrefix(name), s.newActivityRecords(pending), common.INVALID_ID, filter)
+}
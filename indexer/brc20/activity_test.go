@@ -0,0 +1,213 @@
+package brc20
+
+import (
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+func testActionHistory(height, txIndex, action int, nftId int64, from, to uint64, amount int64) *common.BRC20ActionHistory {
+	return &common.BRC20ActionHistory{
+		Height:   height,
+		TxIndex:  txIndex,
+		Action:   action,
+		NftId:    nftId,
+		Ticker:   "ordi",
+		Amount:   *common.NewDefaultDecimal(amount),
+		FromAddr: from,
+		ToAddr:   to,
+	}
+}
+
+func TestBuildActivitiesWithAddress(t *testing.T) {
+	const alice, bob = 0x11, 0x22
+	// 故意打乱顺序，模拟数据库和缓存中的记录
+	history := []*common.BRC20ActionHistory{
+		testActionHistory(102, 1, common.BRC20_Action_Transfer, 3, alice, bob, 40),
+		testActionHistory(100, 2, common.BRC20_Action_InScribe_Mint, 1, common.INVALID_ID, alice, 100),
+		testActionHistory(101, 1, common.BRC20_Action_InScribe_Transfer, 3, common.INVALID_ID, alice, 40),
+		testActionHistory(101, 3, common.BRC20_Action_InScribe_Transfer, 4, common.INVALID_ID, alice, 10),
+		testActionHistory(103, 0, common.BRC20_Action_Transfer, 4, alice, alice, 10),
+		testActionHistory(104, 5, common.BRC20_Action_Transfer_Spent, 1, alice, bob, 100),
+		testActionHistory(100, 2, common.BRC20_Action_InScribe_Mint, 1, common.INVALID_ID, alice, 100),
+	}
+
+	items, total, next, err := buildActivities(history, alice, &common.ActivityFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 6 || len(items) != 6 || next != "" {
+		t.Fatalf("unexpected page: total=%d len=%d next=%s", total, len(items), next)
+	}
+	expected := []struct {
+		typ          string
+		available    string
+		transferable string
+	}{
+		{ACTIVITY_MINT, "100", "0"},
+		{ACTIVITY_INSCRIBE_TRANSFER, "60", "40"},
+		{ACTIVITY_INSCRIBE_TRANSFER, "50", "50"},
+		{ACTIVITY_SEND, "50", "10"},
+		{ACTIVITY_CANCEL, "60", "0"},
+		{ACTIVITY_SPENT, "60", "0"},
+	}
+	for i, e := range expected {
+		item := items[i]
+		if item.Type != e.typ || item.Balance.Available.String() != e.available ||
+			item.Balance.Transferable.String() != e.transferable {
+			t.Fatalf("activity %d: %s %s/%s, want %s %s/%s", i, item.Type,
+				item.Balance.Available.String(), item.Balance.Transferable.String(),
+				e.typ, e.available, e.transferable)
+		}
+	}
+
+	// 高度过滤不影响余额的计算
+	items, total, next, err = buildActivities(history, alice, &common.ActivityFilter{StartHeight: 101, EndHeight: 103, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || len(items) != 2 || next != items[1].Cursor {
+		t.Fatalf("unexpected filtered page: total=%d len=%d next=%s", total, len(items), next)
+	}
+	if items[0].Balance.Available.String() != "60" {
+		t.Fatalf("balance should include events before the range: %s", items[0].Balance.Available.String())
+	}
+	items, _, next, err = buildActivities(history, alice, &common.ActivityFilter{StartHeight: 101, EndHeight: 103, Limit: 2, Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Type != ACTIVITY_SEND || items[1].Type != ACTIVITY_CANCEL || next != "" {
+		t.Fatalf("unexpected second page: %d %s", len(items), next)
+	}
+
+	items, _, _, err = buildActivities(history, alice, &common.ActivityFilter{Desc: true, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[0].Type != ACTIVITY_SPENT {
+		t.Fatalf("desc should start with the latest activity: %s", items[0].Type)
+	}
+
+	if _, _, _, err = buildActivities(history, alice, &common.ActivityFilter{Cursor: "bad"}); err == nil {
+		t.Fatal("invalid cursor should fail")
+	}
+}
+
+func TestBuildActivitiesWithTicker(t *testing.T) {
+	const alice, bob = 0x11, 0x22
+	history := []*common.BRC20ActionHistory{
+		testActionHistory(100, 0, common.BRC20_Action_InScribe_Deploy, 0, common.INVALID_ID, alice, 0),
+		testActionHistory(100, 2, common.BRC20_Action_InScribe_Mint, 1, common.INVALID_ID, alice, 100),
+		testActionHistory(101, 1, common.BRC20_Action_InScribe_Transfer, 3, common.INVALID_ID, alice, 40),
+		testActionHistory(102, 1, common.BRC20_Action_Transfer, 3, alice, bob, 40),
+	}
+	items, total, _, err := buildActivities(history, common.INVALID_ID, &common.ActivityFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || items[0].Type != ACTIVITY_DEPLOY || items[3].Type != ACTIVITY_TRANSFER {
+		t.Fatalf("unexpected ticker activities: %d", total)
+	}
+	last := items[3]
+	if last.FromBalance.Available.String() != "60" || last.FromBalance.Transferable.String() != "0" ||
+		last.ToBalance.Available.String() != "40" {
+		t.Fatalf("unexpected transfer balances: %s/%s %s",
+			last.FromBalance.Available.String(), last.FromBalance.Transferable.String(),
+			last.ToBalance.Available.String())
+	}
+}
+
+func testHolderBalance(available, transferable int64) *HolderInfo {
+	holder := NewHolderInfo()
+	holder.Tickers["ordi"] = &common.BRC20TickAbbrInfo{
+		AvailableBalance:    common.NewDefaultDecimal(available),
+		TransferableBalance: common.NewDefaultDecimal(transferable),
+		TransferableData:    make(map[uint64]*common.TransferNFT),
+	}
+	return holder
+}
+
+func TestLoadActivitiesFromIndex(t *testing.T) {
+	const alice, bob = 0x11, 0x22
+	s := NewIndexer(db.NewMemDB(""), false)
+
+	// 第一批写入数据库
+	s.holderActionList = []*HolderAction{
+		testActionHistory(100, 0, common.BRC20_Action_InScribe_Deploy, 0, common.INVALID_ID, alice, 0),
+		testActionHistory(100, 2, common.BRC20_Action_InScribe_Mint, 1, common.INVALID_ID, alice, 100),
+		testActionHistory(101, 1, common.BRC20_Action_InScribe_Transfer, 3, common.INVALID_ID, alice, 40),
+	}
+	s.holderMap[alice] = testHolderBalance(60, 40)
+	wb := s.db.NewWriteBatch()
+	s.updateActivityToDB(wb)
+	if err := wb.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	wb.Close()
+
+	// 第二批还在内存中，alice 的铭文转给 bob
+	s.holderActionList = []*HolderAction{
+		testActionHistory(102, 1, common.BRC20_Action_Transfer, 3, alice, bob, 40),
+	}
+	s.holderMap = map[uint64]*HolderInfo{
+		alice: testHolderBalance(60, 0),
+		bob:   testHolderBalance(40, 0),
+	}
+
+	items, total, next, err := s.GetActivityWithTicker("ORDI", &common.ActivityFilter{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || len(items) != 2 || items[0].Type != ACTIVITY_DEPLOY || next != items[1].Cursor {
+		t.Fatalf("unexpected first page: total=%d len=%d next=%s", total, len(items), next)
+	}
+	items, _, next, err = s.GetActivityWithTicker("ordi", &common.ActivityFilter{Limit: 2, Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Type != ACTIVITY_INSCRIBE_TRANSFER || items[1].Type != ACTIVITY_TRANSFER || next != "" {
+		t.Fatalf("unexpected second page: len=%d next=%s", len(items), next)
+	}
+	last := items[1]
+	if last.FromBalance.Available.String() != "60" || last.FromBalance.Transferable.String() != "0" ||
+		last.ToBalance.Available.String() != "40" {
+		t.Fatalf("unexpected transfer balances: %s/%s %s",
+			last.FromBalance.Available.String(), last.FromBalance.Transferable.String(),
+			last.ToBalance.Available.String())
+	}
+
+	items, total, next, err = s.GetActivityWithTicker("ordi", &common.ActivityFilter{Desc: true, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || len(items) != 1 || items[0].Type != ACTIVITY_TRANSFER {
+		t.Fatalf("unexpected desc page: total=%d len=%d", total, len(items))
+	}
+	items, _, _, err = s.GetActivityWithTicker("ordi", &common.ActivityFilter{Desc: true, Limit: 2, Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Type != ACTIVITY_INSCRIBE_TRANSFER || items[1].Type != ACTIVITY_MINT {
+		t.Fatalf("unexpected desc second page: len=%d", len(items))
+	}
+
+	// 余额是写入时保存的，不受高度过滤影响
+	items, total, _, err = s.GetActivityWithAddress(alice, "ordi", &common.ActivityFilter{StartHeight: 101})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(items) != 2 || items[0].Type != ACTIVITY_INSCRIBE_TRANSFER || items[1].Type != ACTIVITY_SEND {
+		t.Fatalf("unexpected address activities: total=%d len=%d", total, len(items))
+	}
+	if items[0].Balance.Available.String() != "60" || items[0].Balance.Transferable.String() != "40" {
+		t.Fatalf("unexpected balance: %s/%s", items[0].Balance.Available.String(), items[0].Balance.Transferable.String())
+	}
+	items, total, _, err = s.GetActivityWithAddress(bob, "ordi", &common.ActivityFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || items[0].Type != ACTIVITY_RECEIVE || items[0].Balance.Available.String() != "40" {
+		t.Fatalf("unexpected bob activities: total=%d", total)
+	}
+}
@@ -14,4 +14,7 @@ const (
 	DB_PREFIX_UTXO_TRANSFER        = "g-" // utxo -> transfer nft
 	DB_PREFIX_TRANSFER_HISTORY_HOLDER  = "h-" // +addressId+ticker+nftId 个人历史数据，value: inscribe utxoId + transfer utxoId, 用于构造 DB_PREFIX_TRANSFER_HISTORY
 	DB_PREFIX_ID_TO_TICKER         = "i-" // id -> ticker
+	DB_PREFIX_BALANCE_HISTORY      = "j-" // 每个区块的余额变化
+	DB_PREFIX_ACTIVITY             = "k-" // +ticker+activityKey 活动索引，按发生顺序排序，带双方在事件之后的余额
+	DB_PREFIX_HOLDER_ACTIVITY      = "l-" // +ticker+addressId+activityKey 个人活动索引
 )
@@ -64,6 +64,14 @@ func ParseTransferHistoryKey(input string) (string, int, int64, error) {
 	return decoderTickerName(parts[1]), int(height), nftId, nil
 }
 
+func GetActivityPrefix(tickname string) string {
+	return fmt.Sprintf("%s%s-", DB_PREFIX_ACTIVITY, encodeTickerName(tickname))
+}
+
+func GetHolderActivityPrefix(tickname string, addressId uint64) string {
+	return fmt.Sprintf("%s%s-%x-", DB_PREFIX_HOLDER_ACTIVITY, encodeTickerName(tickname), addressId)
+}
+
 func GetHolderTransferHistoryKey(tickname string, holder uint64, nftId int64) string {
 	return fmt.Sprintf("%s%s-%x-%x", DB_PREFIX_TRANSFER_HISTORY_HOLDER, encodeTickerName(tickname), holder, nftId)
 }
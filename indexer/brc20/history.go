@@ -0,0 +1,44 @@
+package brc20
+
+import (
+	"strings"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// 第一次启用时，以数据库中当前的持有者作为余额历史的起点
+func (s *BRC20Indexer) initBalanceHistory() {
+	height := s.nftIndexer.GetBaseIndexer().GetHeight()
+	s.balanceHistory.Init(s.db, height, func(add func(ticker string, addressId uint64, amount *common.Decimal)) {
+		for _, name := range s.loadTickListFromDB() {
+			for addressId, amt := range s.loadHoldersInTickerFromDB(name) {
+				add(name, addressId, amt)
+			}
+		}
+	})
+}
+
+// 区块处理完后调用，记录余额有变化的地址的最新余额
+func (s *BRC20Indexer) commitBalanceHistory(height int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.balanceHistory.Commit(height, func(ticker string, addressId uint64) *common.Decimal {
+		return s.getHolderAbbrInfo(addressId, ticker).AssetAmt()
+	})
+}
+
+// 获取该ticker在某个高度的holder和持有的资产数量
+func (s *BRC20Indexer) GetHoldersWithTickAtHeight(tickerName string, height int) (map[uint64]*common.Decimal, error) {
+	return s.balanceHistory.GetHolders(s.db, strings.ToLower(tickerName), height)
+}
+
+// 获取某个地址在某个高度的资产 return: ticker->amount
+func (s *BRC20Indexer) GetAssetSummaryByAddressAtHeight(addrId uint64, height int) (map[string]*common.Decimal, error) {
+	return s.balanceHistory.GetAssets(s.db, addrId, height)
+}
+
+// 该ticker在某个高度的holder第一次持有的高度
+func (s *BRC20Indexer) GetHolderFirstSeenAtHeight(tickerName string, height int) (map[uint64]int, error) {
+	return s.balanceHistory.GetFirstSeen(s.db, strings.ToLower(tickerName), height)
+}
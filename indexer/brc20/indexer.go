@@ -9,6 +9,7 @@ import (
 	"github.com/sat20-labs/indexer/common"
 	"github.com/sat20-labs/indexer/indexer/base"
 	"github.com/sat20-labs/indexer/indexer/brc20/validate"
+	inCommon "github.com/sat20-labs/indexer/indexer/common"
 	"github.com/sat20-labs/indexer/indexer/db"
 	"github.com/sat20-labs/indexer/indexer/nft"
 	"github.com/sat20-labs/indexer/share/base_indexer"
@@ -99,6 +100,8 @@ type BRC20Indexer struct {
 	tickerAdded      []*common.BRC20Ticker
 	tickerUpdated    map[string]*common.BRC20Ticker // key: ticker
 
+	balanceHistory *inCommon.BalanceHistory // 每个区块的余额变化，用于查询历史高度的余额和持有者
+
 	// 其他辅助信息，不需要clone
 	actionBufferMap map[uint64]*ActionInfo // key: input的utxoId，保存一个区块
 
@@ -120,9 +123,15 @@ func NewIndexer(db common.KVDB, bCheckValidateFiles bool) *BRC20Indexer {
 		transferNftMap:  make(map[uint64]*TransferNftInfo),
 		actionBufferMap: make(map[uint64]*ActionInfo),
 		tickerUpdated:   make(map[string]*common.BRC20Ticker),
+		balanceHistory:  inCommon.NewBalanceHistory(DB_PREFIX_BALANCE_HISTORY),
 	}
 }
 
+// SetEnableHeight 修改开始处理区块的高度，在 Init 之前调用
+func (s *BRC20Indexer) SetEnableHeight(height int) {
+	s.enableHeight = height
+}
+
 func (s *BRC20Indexer) setDBVersion() {
 	err := db.SetRawValueToDB([]byte(BRC20_DB_VER_KEY), []byte(BRC20_DB_VERSION), s.db)
 	if err != nil {
@@ -191,6 +200,7 @@ func (s *BRC20Indexer) Clone(nftIndexer *nft.NftIndexer) *BRC20Indexer {
 
 	newInst := NewIndexer(s.db, _enable_checking_more_files)
 	newInst.nftIndexer = nftIndexer
+	newInst.enableHeight = s.enableHeight
 
 	newInst.tickerMap = make(map[string]*BRC20TickInfo, 0)
 	for key, value := range s.tickerMap {
@@ -232,6 +242,7 @@ func (s *BRC20Indexer) Clone(nftIndexer *nft.NftIndexer) *BRC20Indexer {
 	}
 
 	newInst.status = s.status.Clone()
+	newInst.balanceHistory = s.balanceHistory.Clone()
 
 	return newInst
 }
@@ -278,6 +289,8 @@ func (s *BRC20Indexer) Subtract(another *BRC20Indexer) {
 			delete(s.tickerUpdated, name)
 		}
 	}
+
+	s.balanceHistory.Subtract(another.balanceHistory)
 }
 
 // 在系统初始化时调用一次，如果有历史数据的话。一般在NewSatIndex之后调用。
@@ -291,6 +304,7 @@ func (s *BRC20Indexer) Init(nftIndexer *nft.NftIndexer) {
 	s.status = initStatusFromDB(s.db)
 	common.Log.Infof("brc20 db version: %s", version)
 	common.Log.Info("Init ...")
+	s.initBalanceHistory()
 
 	elapsed := time.Since(startTime).Milliseconds()
 	common.Log.Infof("Init %d ms", elapsed)
@@ -1115,3 +1129,19 @@ func (s *BRC20Indexer) CheckHolderExisting(addrs []uint64) []uint64 {
 	}
 	return existingAddress
 }
+
+// 只能在跑数据的线程中调用，返回当前区块中已经生效的动作
+func (s *BRC20Indexer) GetHolderActionsWithHeight(height int) []*common.BRC20ActionHistory {
+	result := make([]*common.BRC20ActionHistory, 0)
+	for i := len(s.holderActionList) - 1; i >= 0; i-- {
+		item := s.holderActionList[i]
+		if item.Height != height {
+			break
+		}
+		result = append(result, item)
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
@@ -312,6 +312,24 @@ func (s *BRC20Indexer) getHolderAbbrInfo(addressId uint64, tickerName string) *c
 	return tickAbbrInfo
 }
 
+// 只读，不会把地址加载到 holderMap 中
+func (s *BRC20Indexer) GetAvailableBalance(addressId uint64, tickerName string) *common.Decimal {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	name := strings.ToLower(tickerName)
+	if holder, ok := s.holderMap[addressId]; ok {
+		if info, ok := holder.Tickers[name]; ok {
+			return info.AvailableBalance.Clone()
+		}
+	}
+	info := s.loadTickAbbrInfoFromDB(addressId, name)
+	if info == nil {
+		return nil
+	}
+	return info.AvailableBalance
+}
+
 // 所有进行过有效的brc20操作的地址都必须永久保留
 // 在holderMap被清零之前调用会更好
 func (s *BRC20Indexer) CheckEmptyAddress(wantToDelete map[string]uint64) {
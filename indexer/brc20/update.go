@@ -368,6 +368,7 @@ func (s *BRC20Indexer) addHolderBalance(transfer *TransferNftInfo, address uint6
 	info, tickAbbrInfo := s.loadHolderInfo(address, tickerName)
 	info.Updated()
 	tickAbbrInfo.AvailableBalance = tickAbbrInfo.AvailableBalance.Add(amt)
+	s.balanceHistory.Touch(tickerName, address)
 
 	if tickAbbrInfo.AssetAmt().Cmp(amt) == 0 {
 		ticker := s.tickerMap[tickerName].Ticker
@@ -406,6 +407,7 @@ func (s *BRC20Indexer) subHolderBalance(transfer *TransferNftInfo, address uint6
 
 	holdInfo.Updated()
 	tickAbbrInfo.TransferableBalance = tickAbbrInfo.TransferableBalance.Sub(amt)
+	s.balanceHistory.Touch(tickerName, address)
 	common.Log.Debugf("sub %d: %x %s: -%s -> %s (%s, %s)", transfer.TransferNft.NftId, address, tickerName, amt.String(),
 		tickAbbrInfo.AssetAmt().String(), tickAbbrInfo.AvailableBalance.String(), tickAbbrInfo.TransferableBalance.String())
 
@@ -764,6 +766,9 @@ func (s *BRC20Indexer) UpdateDB() {
 		}
 
 	}
+	// 活动索引，用内存中的余额倒推每个事件之后的余额
+	s.updateActivityToDB(wb)
+
 	// 写入最终结果
 	for addressId, holder := range s.holderMap {
 		for name := range holder.Tickers {
@@ -774,6 +779,8 @@ func (s *BRC20Indexer) UpdateDB() {
 		s.updateUtxoToDB(utxoId, false, wb)
 	}
 
+	s.balanceHistory.UpdateDB(wb)
+
 	err := db.SetDB([]byte(BRC20_DB_STATUS_KEY), s.status, wb)
 	if err != nil {
 		common.Log.Panicf("BRC20Indexer->UpdateDB Error setting in db %v", err)
@@ -1092,6 +1099,7 @@ func (s *BRC20Indexer) TxInputProcess(txIndex int, tx *common.Transaction,
 
 func (s *BRC20Indexer) UpdateTransferFinished(block *common.Block) {
 	s.actionBufferMap = make(map[uint64]*ActionInfo)
+	s.commitBalanceHistory(block.Height)
 	s.CheckPointWithBlockHeight(block.Height)
 	// addressId := s.nftIndexer.GetBaseIndexer().GetAddressIdFromDB("bc1psknlr5rlekaln34hvghslcjnvftgrxheysexe6p5gase343n23fqc0t3kj")
 	// s.printHistoryWithAddress("doge", addressId)
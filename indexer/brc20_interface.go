@@ -1,17 +1,25 @@
 package indexer
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/brc20"
 )
 
 
 func (b *IndexerMgr) GetBRC20TickerMapV2(start, limit int) ([]string, int) {
+	if b.brc20Indexer == nil {
+		return nil, 0
+	}
 	return b.brc20Indexer.GetTickersWithRange(start, limit)
 }
 
 func (p *IndexerMgr) GetBRC20TickerV2(tickerName string) *common.TickerInfo {
+	if p.brc20Indexer == nil {
+		return nil
+	}
 	ticker := p.brc20Indexer.GetTicker(tickerName)
 	if ticker == nil {
 		return nil
@@ -54,15 +62,24 @@ func (p *IndexerMgr) GetBRC20TickerV2(tickerName string) *common.TickerInfo {
 }
 
 func (b *IndexerMgr) GetBRC20MintAmount(tickerName string) (*common.Decimal, int64) {
+	if b.brc20Indexer == nil {
+		return nil, 0
+	}
 	return b.brc20Indexer.GetMintAmount(tickerName)
 }
 
 func (b *IndexerMgr) GetBRC20DBVer() string {
+	if b.brc20Indexer == nil {
+		return ""
+	}
 	return b.brc20Indexer.GetDBVersion()
 }
 
 func (p *IndexerMgr) GetBRC20MintHistoryWithAddress(addressId uint64, ticker string, start int, limit int) ([]*common.InscribeBaseContent, int) {
 	result := make([]*common.InscribeBaseContent, 0)
+	if p.brc20Indexer == nil {
+		return result, 0
+	}
 	infos, total := p.brc20Indexer.GetMintHistoryWithAddress(addressId, ticker, start, limit)
 	for _, info := range infos {
 		mint := p.brc20Indexer.GetMint(ticker, info.Id)
@@ -72,3 +89,81 @@ func (p *IndexerMgr) GetBRC20MintHistoryWithAddress(addressId uint64, ticker str
 	}
 	return result, total
 }
+
+func (p *IndexerMgr) brc20ActivityBalance(balance *brc20.ActivityBalance) *common.BRC20ActivityBalance {
+	if balance == nil {
+		return nil
+	}
+	return &common.BRC20ActivityBalance{
+		Available:    balance.Available.String(),
+		Transferable: balance.Transferable.String(),
+	}
+}
+
+func (p *IndexerMgr) brc20ActivitiesToCommon(items []*brc20.Activity) []*common.BRC20Activity {
+	result := make([]*common.BRC20Activity, 0, len(items))
+	for _, item := range items {
+		activity := &common.BRC20Activity{
+			Cursor:         item.Cursor,
+			Type:           item.Type,
+			Ticker:         item.Ticker,
+			Height:         item.Height,
+			TxIndex:        item.TxIndex,
+			InscriptionNum: item.NftId,
+			Amount:         item.Amount.String(),
+			Balance:        p.brc20ActivityBalance(item.Balance),
+			FromBalance:    p.brc20ActivityBalance(item.FromBalance),
+			ToBalance:      p.brc20ActivityBalance(item.ToBalance),
+		}
+		if p.nft != nil {
+			if nft := p.nft.GetNftWithId(item.NftId); nft != nil {
+				activity.InscriptionId = nft.Base.InscriptionId
+				activity.InscriptionNum = nft.Base.Id
+			}
+		}
+		if item.FromAddr != common.INVALID_ID {
+			activity.From = p.GetAddressById(item.FromAddr)
+		}
+		if item.ToAddr != common.INVALID_ID {
+			activity.To = p.GetAddressById(item.ToAddr)
+		}
+		if item.FromUtxoId != common.INVALID_ID && item.FromUtxoId != 0 {
+			activity.FromUtxo = p.GetUtxoById(item.FromUtxoId)
+		}
+		if item.ToUtxoId != common.INVALID_ID && item.ToUtxoId != 0 {
+			activity.ToUtxo = p.GetUtxoById(item.ToUtxoId)
+		}
+		result = append(result, activity)
+	}
+	return result
+}
+
+// return: 活动列表，区间内总数，下一页的 cursor
+func (p *IndexerMgr) GetBRC20ActivityWithAddress(address, ticker string, filter *common.ActivityFilter) ([]*common.BRC20Activity, int, string, error) {
+	if p.brc20Indexer == nil {
+		return nil, 0, "", fmt.Errorf("brc20 indexer is disabled")
+	}
+	addressId := p.GetAddressId(address)
+	if addressId == common.INVALID_ID {
+		return nil, 0, "", fmt.Errorf("can't find address %s", address)
+	}
+	items, total, next, err := p.brc20Indexer.GetActivityWithAddress(addressId, ticker, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return p.brc20ActivitiesToCommon(items), total, next, nil
+}
+
+func (p *IndexerMgr) GetBRC20ActivityWithTicker(ticker string, filter *common.ActivityFilter) ([]*common.BRC20Activity, int, string, error) {
+	if p.brc20Indexer == nil {
+		return nil, 0, "", fmt.Errorf("brc20 indexer is disabled")
+	}
+	if p.brc20Indexer.GetTicker(ticker) == nil {
+		return nil, 0, "", fmt.Errorf("can't find ticker %s", ticker)
+	}
+	items, total, next, err := p.brc20Indexer.GetActivityWithTicker(ticker, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return p.brc20ActivitiesToCommon(items), total, next, nil
+}
@@ -0,0 +1,420 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+// BalanceHistory 记录资产余额的历史，用于查询某个高度的余额和持有者列表，不需要回放区块。
+// 每个区块结束时，将该区块内余额有变化的地址的最新余额保存下来：
+//
+//	prefix + "t-" + ticker + "-" + addressId(%016x) + height(%08x) -> 余额
+//	prefix + "a-" + addressId(%016x) + ticker -> 空，用于查找地址持有过哪些资产
+//
+// 某个高度的余额，就是不大于该高度的最后一条记录。
+// 第一次启用时，将当前所有持有者的余额记录在起始高度，早于起始高度的查询返回错误。
+// 没有调用 NewBalanceHistory 的索引器（比如测试中）可以使用 nil，记录操作都会被忽略。
+type BalanceHistory struct {
+	prefix  string
+	mutex   sync.RWMutex
+	start   int
+	touched map[string]map[uint64]bool // 当前区块内余额有变化的地址：ticker -> addressId
+	records map[string]*BalanceRecord  // 还没有写入数据库的记录，key 是数据库的 key
+}
+
+type BalanceRecord struct {
+	Ticker    string
+	AddressId uint64
+	Height    int
+	Amount    *common.Decimal
+}
+
+var errStopBatchRead = errors.New("stop")
+
+// addressId 和 height 都是定长的，ticker 中即使有 "-"，也可以通过长度区分
+const balanceRecordSuffixLen = 16 + 8
+
+func NewBalanceHistory(prefix string) *BalanceHistory {
+	return &BalanceHistory{
+		prefix:  prefix,
+		start:   -1,
+		touched: make(map[string]map[uint64]bool),
+		records: make(map[string]*BalanceRecord),
+	}
+}
+
+func (p *BalanceHistory) startKey() []byte {
+	return []byte(p.prefix + "start")
+}
+
+func (p *BalanceHistory) tickerPrefix(ticker string) string {
+	return p.prefix + "t-" + ticker + "-"
+}
+
+func (p *BalanceHistory) holderPrefix(ticker string, addressId uint64) string {
+	return fmt.Sprintf("%s%016x", p.tickerPrefix(ticker), addressId)
+}
+
+func (p *BalanceHistory) recordKey(r *BalanceRecord) string {
+	return fmt.Sprintf("%s%08x", p.holderPrefix(r.Ticker, r.AddressId), r.Height)
+}
+
+func (p *BalanceHistory) addressPrefix(addressId uint64) string {
+	return fmt.Sprintf("%sa-%016x", p.prefix, addressId)
+}
+
+func parseBalanceRecordSuffix(suffix string) (uint64, int, error) {
+	addressId, err := strconv.ParseUint(suffix[:16], 16, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	height, err := strconv.ParseInt(suffix[16:], 16, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return addressId, int(height), nil
+}
+
+// Init 加载起始高度。如果还没有记录过，将 baseline 提供的当前余额作为 height 的记录
+func (p *BalanceHistory) Init(kvdb common.KVDB, height int,
+	baseline func(add func(ticker string, addressId uint64, amount *common.Decimal))) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if height < 0 {
+		height = 0
+	}
+	var start int
+	err := db.GetValueFromDB(p.startKey(), &start, kvdb)
+	if err == nil {
+		p.start = start
+		return
+	}
+	if err != common.ErrKeyNotFound {
+		common.Log.Panicf("load balance history start %s failed, %v", p.prefix, err)
+	}
+
+	wb := kvdb.NewWriteBatch()
+	defer wb.Close()
+	count := 0
+	baseline(func(ticker string, addressId uint64, amount *common.Decimal) {
+		if amount.Sign() == 0 {
+			return
+		}
+		r := &BalanceRecord{Ticker: ticker, AddressId: addressId, Height: height, Amount: amount.Clone()}
+		if err := p.putRecord(r, wb); err != nil {
+			common.Log.Panicf("write balance history %s failed, %v", p.prefix, err)
+		}
+		count++
+	})
+	if err := db.SetDB(p.startKey(), height, wb); err != nil {
+		common.Log.Panicf("write balance history start %s failed, %v", p.prefix, err)
+	}
+	if err := wb.Flush(); err != nil {
+		common.Log.Panicf("flush balance history %s failed, %v", p.prefix, err)
+	}
+	p.start = height
+	common.Log.Infof("balance history %s starts at %d with %d holders", p.prefix, height, count)
+}
+
+func (p *BalanceHistory) StartHeight() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.start
+}
+
+// Touch 标记该地址在当前区块内余额有变化
+func (p *BalanceHistory) Touch(ticker string, addressId uint64) {
+	if p == nil || addressId == common.INVALID_ID {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	addresses, ok := p.touched[ticker]
+	if !ok {
+		addresses = make(map[uint64]bool)
+		p.touched[ticker] = addresses
+	}
+	addresses[addressId] = true
+}
+
+// Commit 在区块结束时调用，通过 balance 读取被标记地址的最新余额
+func (p *BalanceHistory) Commit(height int, balance func(ticker string, addressId uint64) *common.Decimal) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for ticker, addresses := range p.touched {
+		for addressId := range addresses {
+			amount := balance(ticker, addressId)
+			if amount == nil {
+				amount = common.NewDecimal(0, 0)
+			}
+			r := &BalanceRecord{Ticker: ticker, AddressId: addressId, Height: height, Amount: amount.Clone()}
+			p.records[p.recordKey(r)] = r
+		}
+	}
+	p.touched = make(map[string]map[uint64]bool)
+}
+
+// 只保存UpdateDB需要用的数据
+func (p *BalanceHistory) Clone() *BalanceHistory {
+	if p == nil {
+		return nil
+	}
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	n := NewBalanceHistory(p.prefix)
+	n.start = p.start
+	for k, v := range p.records {
+		n.records[k] = v
+	}
+	return n
+}
+
+// update之后，删除已经写入数据库的记录。记录一旦生成就不会再修改
+func (p *BalanceHistory) Subtract(another *BalanceHistory) {
+	if p == nil || another == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for k := range another.records {
+		delete(p.records, k)
+	}
+}
+
+func (p *BalanceHistory) putRecord(r *BalanceRecord, wb common.WriteBatch) error {
+	if err := db.SetDB([]byte(p.recordKey(r)), r.Amount, wb); err != nil {
+		return err
+	}
+	return wb.Put([]byte(p.addressPrefix(r.AddressId)+r.Ticker), []byte{})
+}
+
+func (p *BalanceHistory) UpdateDB(wb common.WriteBatch) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, r := range p.records {
+		if err := p.putRecord(r, wb); err != nil {
+			common.Log.Panicf("write balance history %s failed, %v", p.prefix, err)
+		}
+	}
+	p.records = make(map[string]*BalanceRecord)
+}
+
+func (p *BalanceHistory) checkHeightLocked(height int) error {
+	if p.start < 0 {
+		return fmt.Errorf("balance history is not ready")
+	}
+	if height < p.start {
+		return fmt.Errorf("height %d is before the start of balance history %d", height, p.start)
+	}
+	return nil
+}
+
+func decodeBalance(v []byte) (*common.Decimal, error) {
+	var amount common.Decimal
+	if err := db.DecodeBytes(v, &amount); err != nil {
+		return nil, err
+	}
+	if amount.Value == nil {
+		amount.Value = new(big.Int)
+	}
+	return &amount, nil
+}
+
+// 如果 newer 比 older 更接近 height，返回 true
+func closerRecord(older, newer *BalanceRecord, height int) bool {
+	if newer.Height > height {
+		return false
+	}
+	return older == nil || newer.Height > older.Height
+}
+
+func (p *BalanceHistory) getBalanceLocked(kvdb common.KVDB, ticker string, addressId uint64, height int) (*common.Decimal, error) {
+	prefix := p.holderPrefix(ticker, addressId)
+	var last *BalanceRecord
+	err := kvdb.BatchRead([]byte(prefix), false, func(k, v []byte) error {
+		if len(k) != len(prefix)+8 {
+			return nil
+		}
+		h, err := strconv.ParseInt(string(k[len(prefix):]), 16, 64)
+		if err != nil {
+			return nil
+		}
+		if int(h) > height {
+			return errStopBatchRead
+		}
+		amount, err := decodeBalance(v)
+		if err != nil {
+			return err
+		}
+		last = &BalanceRecord{Ticker: ticker, AddressId: addressId, Height: int(h), Amount: amount}
+		return nil
+	})
+	if err != nil && err != errStopBatchRead {
+		return nil, err
+	}
+	for _, r := range p.records {
+		if r.Ticker == ticker && r.AddressId == addressId && closerRecord(last, r, height) {
+			last = r
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	return last.Amount.Clone(), nil
+}
+
+// GetBalance 地址在 height 高度时的余额，没有持有返回 nil
+func (p *BalanceHistory) GetBalance(kvdb common.KVDB, ticker string, addressId uint64, height int) (*common.Decimal, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if err := p.checkHeightLocked(height); err != nil {
+		return nil, err
+	}
+	return p.getBalanceLocked(kvdb, ticker, addressId, height)
+}
+
+// GetHolders ticker 在 height 高度时的持有者和余额
+func (p *BalanceHistory) GetHolders(kvdb common.KVDB, ticker string, height int) (map[uint64]*common.Decimal, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if err := p.checkHeightLocked(height); err != nil {
+		return nil, err
+	}
+
+	prefix := p.tickerPrefix(ticker)
+	lastRecords := make(map[uint64]*BalanceRecord)
+	err := kvdb.BatchRead([]byte(prefix), false, func(k, v []byte) error {
+		if len(k) != len(prefix)+balanceRecordSuffixLen {
+			return nil
+		}
+		addressId, h, err := parseBalanceRecordSuffix(string(k[len(prefix):]))
+		if err != nil || h > height {
+			return nil
+		}
+		amount, err := decodeBalance(v)
+		if err != nil {
+			return err
+		}
+		// 同一个地址的记录按高度排列
+		lastRecords[addressId] = &BalanceRecord{Ticker: ticker, AddressId: addressId, Height: h, Amount: amount}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range p.records {
+		if r.Ticker == ticker && closerRecord(lastRecords[r.AddressId], r, height) {
+			lastRecords[r.AddressId] = r
+		}
+	}
+
+	result := make(map[uint64]*common.Decimal)
+	for addressId, r := range lastRecords {
+		if r.Amount.Sign() != 0 {
+			result[addressId] = r.Amount.Clone()
+		}
+	}
+	return result, nil
+}
+
+// GetAssets 地址在 height 高度时持有的资产：ticker -> 余额
+func (p *BalanceHistory) GetAssets(kvdb common.KVDB, addressId uint64, height int) (map[string]*common.Decimal, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if err := p.checkHeightLocked(height); err != nil {
+		return nil, err
+	}
+
+	prefix := p.addressPrefix(addressId)
+	tickers := make(map[string]bool)
+	err := kvdb.BatchRead([]byte(prefix), false, func(k, v []byte) error {
+		tickers[string(k[len(prefix):])] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range p.records {
+		if r.AddressId == addressId {
+			tickers[r.Ticker] = true
+		}
+	}
+
+	result := make(map[string]*common.Decimal)
+	for ticker := range tickers {
+		amount, err := p.getBalanceLocked(kvdb, ticker, addressId, height)
+		if err != nil {
+			return nil, err
+		}
+		if amount.Sign() != 0 {
+			result[ticker] = amount
+		}
+	}
+	return result, nil
+}
+
+// GetFirstSeen ticker 在 height 高度时的持有者第一次持有该资产的高度。
+// 余额历史启用之前就持有的地址，返回的是起始高度
+func (p *BalanceHistory) GetFirstSeen(kvdb common.KVDB, ticker string, height int) (map[uint64]int, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if err := p.checkHeightLocked(height); err != nil {
+		return nil, err
+	}
+
+	prefix := p.tickerPrefix(ticker)
+	records := make(map[uint64][]*BalanceRecord)
+	err := kvdb.BatchRead([]byte(prefix), false, func(k, v []byte) error {
+		if len(k) != len(prefix)+balanceRecordSuffixLen {
+			return nil
+		}
+		addressId, h, err := parseBalanceRecordSuffix(string(k[len(prefix):]))
+		if err != nil || h > height {
+			return nil
+		}
+		amount, err := decodeBalance(v)
+		if err != nil {
+			return err
+		}
+		records[addressId] = append(records[addressId], &BalanceRecord{Ticker: ticker, AddressId: addressId, Height: h, Amount: amount})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range p.records {
+		if r.Ticker == ticker && r.Height <= height {
+			records[r.AddressId] = append(records[r.AddressId], r)
+		}
+	}
+
+	result := make(map[uint64]int)
+	for addressId, list := range records {
+		sort.Slice(list, func(i, j int) bool { return list[i].Height < list[j].Height })
+		// 当前已经不持有的地址不返回；中间清空过再持有的，仍然以第一次为准
+		if list[len(list)-1].Amount.Sign() == 0 {
+			continue
+		}
+		for _, r := range list {
+			if r.Amount.Sign() != 0 {
+				result[addressId] = r.Height
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+func TestBalanceHistoryAtHeight(t *testing.T) {
+	kvdb := db.NewKVDB(t.TempDir())
+	if kvdb == nil {
+		t.Fatal("open db failed")
+	}
+	defer kvdb.Close()
+
+	history := NewBalanceHistory("bh-")
+	history.Init(kvdb, 100, func(add func(ticker string, addressId uint64, amount *common.Decimal)) {
+		add("ordi", 1, common.NewDefaultDecimal(50))
+		add("ordi-x", 1, common.NewDefaultDecimal(7))
+	})
+
+	balances := map[uint64]int64{1: 30, 2: 20}
+	history.Touch("ordi", 1)
+	history.Touch("ordi", 2)
+	history.Commit(101, func(ticker string, addressId uint64) *common.Decimal {
+		return common.NewDefaultDecimal(balances[addressId])
+	})
+
+	// 备份的实例写入数据库，然后从当前实例中删除
+	backup := history.Clone()
+	wb := kvdb.NewWriteBatch()
+	backup.UpdateDB(wb)
+	if err := wb.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	wb.Close()
+	history.Subtract(backup)
+
+	// 还在内存中的记录
+	history.Touch("ordi", 2)
+	history.Commit(103, func(ticker string, addressId uint64) *common.Decimal { return nil })
+
+	if _, err := history.GetBalance(kvdb, "ordi", 1, 99); err == nil {
+		t.Fatal("height before the start should fail")
+	}
+	for height, want := range map[int]int64{100: 50, 101: 30, 102: 30, 103: 30} {
+		amt, err := history.GetBalance(kvdb, "ordi", 1, height)
+		if err != nil || amt.Int64() != want {
+			t.Fatalf("balance at %d = %v, %v, want %d", height, amt, err, want)
+		}
+	}
+
+	holders, err := history.GetHolders(kvdb, "ordi", 102)
+	if err != nil || len(holders) != 2 || holders[1].Int64() != 30 || holders[2].Int64() != 20 {
+		t.Fatalf("holders at 102 = %v, %v", holders, err)
+	}
+	holders, err = history.GetHolders(kvdb, "ordi", 103)
+	if err != nil || len(holders) != 1 || holders[1].Int64() != 30 {
+		t.Fatalf("holders at 103 = %v, %v", holders, err)
+	}
+
+	assets, err := history.GetAssets(kvdb, 2, 101)
+	if err != nil || len(assets) != 1 || assets["ordi"].Int64() != 20 {
+		t.Fatalf("assets at 101 = %v, %v", assets, err)
+	}
+	assets, err = history.GetAssets(kvdb, 2, 103)
+	if err != nil || len(assets) != 0 {
+		t.Fatalf("assets at 103 = %v, %v", assets, err)
+	}
+	assets, err = history.GetAssets(kvdb, 1, 100)
+	if err != nil || len(assets) != 2 || assets["ordi-x"].Int64() != 7 {
+		t.Fatalf("assets at 100 = %v, %v", assets, err)
+	}
+
+	firstSeen, err := history.GetFirstSeen(kvdb, "ordi", 102)
+	if err != nil || len(firstSeen) != 2 || firstSeen[1] != 100 || firstSeen[2] != 101 {
+		t.Fatalf("first seen at 102 = %v, %v", firstSeen, err)
+	}
+	firstSeen, err = history.GetFirstSeen(kvdb, "ordi", 103)
+	if err != nil || len(firstSeen) != 1 || firstSeen[1] != 100 {
+		t.Fatalf("first seen at 103 = %v, %v", firstSeen, err)
+	}
+
+	// 重新加载时不再写入起始记录
+	reloaded := NewBalanceHistory("bh-")
+	reloaded.Init(kvdb, 200, func(add func(ticker string, addressId uint64, amount *common.Decimal)) {
+		t.Fatal("baseline should only be written once")
+	})
+	if reloaded.StartHeight() != 100 {
+		t.Fatalf("start height = %d, want 100", reloaded.StartHeight())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
 	"github.com/sat20-labs/indexer/indexer/db"
 )
 
@@ -28,44 +29,85 @@ func openDB(filepath string, cacheSizeMB int) (common.KVDB, error) {
 func (p *IndexerMgr) initDB() (err error) {
 	common.Log.Info("InitDB-> start...")
 
+	if p.undoJournal == nil {
+		p.undoJournal = db.NewUndoJournal()
+	}
+	if p.changeLog == nil && p.cfg.Replica.IsPrimary() {
+		limit := p.cfg.Replica.MaxCommitLogMB
+		if limit <= 0 {
+			limit = defaultReplicaMaxCommitLogMB
+		}
+		p.changeLog = db.NewChangeLog(limit * 1024 * 1024)
+	}
+
 	p.baseDB, err = openDB(p.dbDir+"base", baseBuildDBCacheMB)
 	if err != nil {
 		return err
 	}
+	p.baseDB = p.journalDB("base", p.baseDB)
 
-	p.nftDB, err = openDB(p.dbDir+"nft", nftBuildDBCacheMB)
-	if err != nil {
-		return err
+	if p.IsProtocolEnabled(config.PROTOCOL_NFT) {
+		p.nftDB, err = openDB(p.dbDir+"nft", nftBuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.nftDB = p.journalDB("nft", p.nftDB)
 	}
 
-	p.nsDB, err = openDB(p.dbDir+"ns", defaultBuildDBCacheMB)
-	if err != nil {
-		return err
+	if p.IsProtocolEnabled(config.PROTOCOL_NS) {
+		p.nsDB, err = openDB(p.dbDir+"ns", defaultBuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.nsDB = p.journalDB("ns", p.nsDB)
 	}
 
-	p.exoticDB, err = openDB(p.dbDir+"exotic", defaultBuildDBCacheMB)
-	if err != nil {
-		return err
+	if p.IsProtocolEnabled(config.PROTOCOL_EXOTIC) {
+		p.exoticDB, err = openDB(p.dbDir+"exotic", defaultBuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.exoticDB = p.journalDB("exotic", p.exoticDB)
 	}
 
-	p.ftDB, err = openDB(p.dbDir+"ft", defaultBuildDBCacheMB)
-	if err != nil {
-		return err
+	if p.IsProtocolEnabled(config.PROTOCOL_FT) {
+		p.ftDB, err = openDB(p.dbDir+"ft", defaultBuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.ftDB = p.journalDB("ft", p.ftDB)
 	}
 
-	p.brc20DB, err = openDB(p.dbDir+"brc20", brc20BuildDBCacheMB)
-	if err != nil {
-		return err
+	if p.IsProtocolEnabled(config.PROTOCOL_BRC20) {
+		p.brc20DB, err = openDB(p.dbDir+"brc20", brc20BuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.brc20DB = p.journalDB("brc20", p.brc20DB)
 	}
 
-	p.runesDB, err = openDB(p.dbDir+"runes", defaultBuildDBCacheMB)
-	if err != nil {
-		return err
+	if p.IsProtocolEnabled(config.PROTOCOL_RUNES) {
+		p.runesDB, err = openDB(p.dbDir+"runes", defaultBuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.runesDB = p.journalDB("runes", p.runesDB)
 	}
 
-	p.atomDB, err = openDB(p.dbDir+"atom", defaultBuildDBCacheMB)
-	if err != nil {
-		return err
+	if p.IsProtocolEnabled(config.PROTOCOL_ATOM) {
+		p.atomDB, err = openDB(p.dbDir+"atom", defaultBuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.atomDB = p.journalDB("atom", p.atomDB)
+	}
+
+	if p.cfg.BasicIndex.SatIndex {
+		p.satDB, err = openDB(p.dbDir+"sat", defaultBuildDBCacheMB)
+		if err != nil {
+			return err
+		}
+		p.satDB = p.journalDB("sat", p.satDB)
 	}
 
 	p.localDB, err = openDB(p.dbDir+"local", defaultBuildDBCacheMB)
@@ -85,6 +127,11 @@ func getCollectionKey(ntype, ticker string) []byte {
 	return []byte("c-" + ntype + "-" + ticker)
 }
 
+// 交易的资产流向，见 tx_flow.go
+func getTxFlowKey(txid string) []byte {
+	return []byte("tf-" + txid)
+}
+
 func parseCollectionKey(key string) (string, string, error) {
 	parts := strings.Split(key, "-")
 	if len(parts) != 3 {
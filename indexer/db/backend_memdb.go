@@ -0,0 +1,45 @@
+//go:build memdb
+
+package db
+
+import (
+	"fmt"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+func newKVDB(path string) common.KVDB {
+	return NewMemDB(path)
+}
+
+func newKVDBWithCache(path string, cacheSizeMB int) common.KVDB {
+	return NewMemDB(path)
+}
+
+// 内存数据库的检查点在 memStores 中，按路径移动
+func restoreCheckpoint(src, dst string) error {
+	memStoreMutex.Lock()
+	defer memStoreMutex.Unlock()
+	store, ok := memStores[src]
+	if !ok {
+		return nil
+	}
+	memStores[dst] = store
+	delete(memStores, src)
+	return nil
+}
+
+func removeCheckpoint(dir string) error {
+	RemoveMemDB(dir)
+	return nil
+}
+
+// EnsureEmptyDB 内存数据库不在磁盘上，检查 memStores 中 path 下有没有数据
+func EnsureEmptyDB(path string) error {
+	memStoreMutex.Lock()
+	defer memStoreMutex.Unlock()
+	if store, ok := memStores[path]; ok && store.tree.Size() != 0 {
+		return fmt.Errorf("%s is not empty", path)
+	}
+	return nil
+}
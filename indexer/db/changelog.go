@@ -0,0 +1,290 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+/*
+提交日志（commit log），用于只读副本同步主节点的数据库
+
+主节点每次提交时，记录所有链上数据库的写入（新值），提交完成后保存在主数据库中。
+副本按顺序拉取并在本地重放，跟主节点的数据库保持一致。
+
+key 格式（只在主数据库中）：
+	!replog-m-<height> -> UndoMeta
+	!replog-d-<height> -> CommitLog
+*/
+
+const (
+	DB_KEY_COMMIT_LOG      = "!replog-"
+	DB_KEY_COMMIT_LOG_META = DB_KEY_COMMIT_LOG + "m-"
+	DB_KEY_COMMIT_LOG_DATA = DB_KEY_COMMIT_LOG + "d-"
+)
+
+var ErrCheckpointUnsupported = errors.New("database backend does not support checkpoint")
+var ErrApplyWithoutJournal = errors.New("changes must be applied to a JournalDB with an open undo journal")
+
+type Change struct {
+	Key     []byte
+	Value   []byte
+	Deleted bool
+}
+
+// CommitLog 一次提交中各数据库的写入，按写入顺序排列
+type CommitLog struct {
+	UndoMeta
+	Changes map[string][]*Change // 数据库名称 -> 写入
+}
+
+func GetCommitLogMetaKey(height int) []byte {
+	return []byte(fmt.Sprintf("%s%010d", DB_KEY_COMMIT_LOG_META, height))
+}
+
+func GetCommitLogDataKey(height int) []byte {
+	return []byte(fmt.Sprintf("%s%010d", DB_KEY_COMMIT_LOG_DATA, height))
+}
+
+// ChangeLog 多个数据库共享的写入记录，由 IndexerMgr 在提交前打开
+type ChangeLog struct {
+	mutex    sync.Mutex
+	active   bool
+	changes  map[string][]*Change
+	size     int
+	limit    int  // 一次提交最多记录的字节数，0 不限制
+	overflow bool // 超过 limit，这次提交不保存日志
+}
+
+// NewChangeLog limit 是一次提交最多记录的字节数。追块时一次提交有很多区块的写入，
+// 超过后这次提交不保存日志，副本需要用新的快照重新开始
+func NewChangeLog(limit int) *ChangeLog {
+	return &ChangeLog{limit: limit}
+}
+
+func (p *ChangeLog) Begin() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.active = true
+	p.changes = make(map[string][]*Change)
+	p.size = 0
+	p.overflow = false
+}
+
+// End 返回这次提交的写入，没有打开或者超过大小限制时返回 nil
+func (p *ChangeLog) End(meta *UndoMeta) *CommitLog {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.active {
+		return nil
+	}
+	var result *CommitLog
+	if p.overflow {
+		common.Log.Warnf("commit %d writes more than %d bytes, commit log dropped, replicas behind it need a new checkpoint",
+			meta.Height, p.limit)
+	} else {
+		result = &CommitLog{UndoMeta: *meta, Changes: p.changes}
+	}
+	p.active = false
+	p.changes = nil
+	return result
+}
+
+func (p *ChangeLog) isActive() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.active && !p.overflow
+}
+
+// fits 再记录 size 字节是否还在限制内，超过时丢掉已经记录的写入
+func (p *ChangeLog) fits(size int) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if !p.active || p.overflow {
+		return false
+	}
+	if p.limit > 0 && p.size+size > p.limit {
+		p.overflow = true
+		p.changes = nil
+		return false
+	}
+	return true
+}
+
+// 一个 WriteBatch 写入成功后才记录
+func (p *ChangeLog) append(name string, changes []*Change, size int) {
+	if !p.fits(size) {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.changes[name] = append(p.changes[name], changes...)
+	p.size += size
+}
+
+func SetCommitLog(kvdb common.KVDB, log *CommitLog) error {
+	wb := kvdb.NewWriteBatch()
+	defer wb.Close()
+	if err := SetDB(GetCommitLogDataKey(log.Height), log, wb); err != nil {
+		return err
+	}
+	if err := SetDB(GetCommitLogMetaKey(log.Height), &log.UndoMeta, wb); err != nil {
+		return err
+	}
+	return wb.Flush()
+}
+
+// GetRawCommitLog 编码后的提交日志，可以直接发给副本
+func GetRawCommitLog(kvdb common.KVDB, height int) ([]byte, error) {
+	return kvdb.Read(GetCommitLogDataKey(height))
+}
+
+func DecodeCommitLog(buf []byte) (*CommitLog, error) {
+	var log CommitLog
+	if err := DecodeBytes(buf, &log); err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+func deleteCommitLog(wb common.WriteBatch, height int) error {
+	if err := wb.Delete(GetCommitLogDataKey(height)); err != nil {
+		return err
+	}
+	return wb.Delete(GetCommitLogMetaKey(height))
+}
+
+// GetCommitLogMetas 按高度从低到高返回所有提交日志的高度信息
+func GetCommitLogMetas(kvdb common.KVDB) ([]*UndoMeta, error) {
+	result := make([]*UndoMeta, 0)
+	err := kvdb.BatchRead([]byte(DB_KEY_COMMIT_LOG_META), false, func(k, v []byte) error {
+		var meta UndoMeta
+		if err := DecodeBytes(v, &meta); err != nil {
+			return err
+		}
+		result = append(result, &meta)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Height < result[j].Height
+	})
+	return result, nil
+}
+
+// FindNextCommitLog 副本当前在 (height, hash)，返回它需要重放的下一个提交日志。
+// 副本已经是最新时返回 nil；副本在分叉上时，返回分叉点之后的提交日志，副本需要先回滚到它的 PrevHeight
+func FindNextCommitLog(kvdb common.KVDB, height int, hash string) (*UndoMeta, error) {
+	metas, err := GetCommitLogMetas(kvdb)
+	if err != nil {
+		return nil, err
+	}
+	var next *UndoMeta
+	matched := false
+	for _, meta := range metas {
+		if meta.Height == height && meta.Hash == hash {
+			matched = true
+			next = nil
+			continue
+		}
+		if meta.PrevHeight == height && meta.PrevHash == hash {
+			next = meta
+			break
+		}
+		if meta.PrevHeight < height {
+			next = meta
+		}
+	}
+	if next != nil {
+		return next, nil
+	}
+	if matched || len(metas) == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("no commit log follows %d %s, the earliest is %d", height, hash, metas[0].Height)
+}
+
+// PruneCommitLogs 只保留最近 keep 个提交日志
+func PruneCommitLogs(kvdb common.KVDB, keep int) error {
+	metas, err := GetCommitLogMetas(kvdb)
+	if err != nil {
+		return err
+	}
+	if len(metas) <= keep {
+		return nil
+	}
+	wb := kvdb.NewWriteBatch()
+	defer wb.Close()
+	for _, meta := range metas[:len(metas)-keep] {
+		if err := deleteCommitLog(wb, meta.Height); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+// DeleteCommitLogsAbove 数据库回滚后，删除高于 height 的提交日志
+func DeleteCommitLogsAbove(kvdb common.KVDB, height int) error {
+	metas, err := GetCommitLogMetas(kvdb)
+	if err != nil {
+		return err
+	}
+	wb := kvdb.NewWriteBatch()
+	defer wb.Close()
+	for _, meta := range metas {
+		if meta.Height > height {
+			if err := deleteCommitLog(wb, meta.Height); err != nil {
+				return err
+			}
+		}
+	}
+	return wb.Flush()
+}
+
+// ApplyChanges 在副本上重放写入。底层的 WriteBatch 太大时会自己提交一部分，
+// 所以只能通过打开了撤销日志的 JournalDB 写入，撤销记录在数据之前落盘，中途退出后可以回滚
+func ApplyChanges(kvdb common.KVDB, changes []*Change) error {
+	p, ok := kvdb.(*JournalDB)
+	if !ok {
+		return ErrApplyWithoutJournal
+	}
+	meta, ok := p.journal.current()
+	if !ok || meta.NoUndo {
+		return ErrApplyWithoutJournal
+	}
+	wb := kvdb.NewWriteBatch()
+	defer wb.Close()
+	for _, change := range changes {
+		var err error
+		if change.Deleted {
+			err = wb.Delete(change.Key)
+		} else {
+			err = wb.Put(change.Key, change.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+type checkpointer interface {
+	Checkpoint(dir string) error
+}
+
+// CheckpointDB 在 dir 生成数据库的一致性快照，可以直接作为副本的数据库打开
+func CheckpointDB(kvdb common.KVDB, dir string) error {
+	if kvdb == nil {
+		return ErrCheckpointUnsupported
+	}
+	p, ok := kvdb.(checkpointer)
+	if !ok {
+		return ErrCheckpointUnsupported
+	}
+	return p.Checkpoint(dir)
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"testing"
+)
+
+// 主节点记录的写入，在副本上重放后数据一致
+func TestChangeLogReplay(t *testing.T) {
+	journal := NewUndoJournal()
+	changes := NewChangeLog(0)
+	primary := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	primary.SetChangeLog("base", changes)
+	replicaJournal := NewUndoJournal()
+	replica := NewJournalDB(NewKVDB(t.TempDir()), replicaJournal)
+	defer primary.Close()
+	defer replica.Close()
+
+	// 没有打开时不记录
+	commitTestWrite(t, primary, "a", "0")
+	if log := changes.End(&UndoMeta{Height: 1}); log != nil {
+		t.Fatalf("unexpected commit log %v", log)
+	}
+
+	meta := &UndoMeta{Height: 2, Hash: "2", PrevHeight: 1, PrevHash: "1"}
+	journal.Begin(meta)
+	changes.Begin()
+	commitTestWrite(t, primary, "a", "1")
+	commitTestWrite(t, primary, "b", "2")
+	if err := primary.Delete([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	journal.End()
+	log := changes.End(meta)
+	if log == nil || len(log.Changes["base"]) != 3 {
+		t.Fatalf("commit log %v", log)
+	}
+
+	if err := SetCommitLog(primary, log); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := GetRawCommitLog(primary, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeCommitLog(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Height != 2 || decoded.PrevHash != "1" {
+		t.Fatalf("decoded meta %v", decoded.UndoMeta)
+	}
+
+	// 没有撤销日志时不能重放
+	if err := ApplyChanges(replica, decoded.Changes["base"]); err != ErrApplyWithoutJournal {
+		t.Fatalf("apply without journal: %v", err)
+	}
+	replicaJournal.Begin(&decoded.UndoMeta)
+	if err := ApplyChanges(replica, decoded.Changes["base"]); err != nil {
+		t.Fatal(err)
+	}
+	replicaJournal.End()
+	expectValue(t, replica, "a", "")
+	expectValue(t, replica, "b", "2")
+
+	// 可以回滚重放的提交
+	if _, err := RollbackToHeight(replica, 1); err != nil {
+		t.Fatal(err)
+	}
+	expectValue(t, replica, "b", "")
+}
+
+// 超过大小限制的提交不保存日志，下一次提交重新开始记录
+func TestChangeLogLimit(t *testing.T) {
+	defer func(size int) { journalChunkSize = size }(journalChunkSize)
+	journalChunkSize = 1
+	journal := NewUndoJournal()
+	changes := NewChangeLog(10)
+	primary := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	primary.SetChangeLog("base", changes)
+	defer primary.Close()
+
+	meta := &UndoMeta{Height: 2, Hash: "2", PrevHeight: 1, PrevHash: "1", NoUndo: true}
+	journal.Begin(meta)
+	changes.Begin()
+	commitTestWrite(t, primary, "a", "0123456789")
+	commitTestWrite(t, primary, "b", "1")
+	journal.End()
+	if log := changes.End(meta); log != nil {
+		t.Fatalf("commit log over the limit is kept, %v", log)
+	}
+	expectValue(t, primary, "a", "0123456789")
+	expectValue(t, primary, "b", "1")
+
+	meta = &UndoMeta{Height: 3, Hash: "3", PrevHeight: 2, PrevHash: "2"}
+	journal.Begin(meta)
+	changes.Begin()
+	commitTestWrite(t, primary, "b", "2")
+	journal.End()
+	if log := changes.End(meta); log == nil || len(log.Changes["base"]) != 1 {
+		t.Fatalf("commit log %v", log)
+	}
+}
+
+func TestFindNextCommitLog(t *testing.T) {
+	kvdb := NewKVDB(t.TempDir())
+	defer kvdb.Close()
+
+	next, err := FindNextCommitLog(kvdb, 10, "10")
+	if err != nil || next != nil {
+		t.Fatalf("no commit log, got %v, %v", next, err)
+	}
+
+	metas := []UndoMeta{
+		{Height: 11, Hash: "11", PrevHeight: 10, PrevHash: "10"},
+		{Height: 13, Hash: "13", PrevHeight: 11, PrevHash: "11"},
+		// 主节点在 12 分叉，回滚到 11 后重新提交
+		{Height: 14, Hash: "14b", PrevHeight: 11, PrevHash: "11"},
+	}
+	for i := range metas {
+		log := &CommitLog{UndoMeta: metas[i], Changes: map[string][]*Change{}}
+		if err := SetCommitLog(kvdb, log); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 13 已经被回滚，删除
+	wb := kvdb.NewWriteBatch()
+	if err := deleteCommitLog(wb, 13); err != nil {
+		t.Fatal(err)
+	}
+	if err := wb.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	wb.Close()
+
+	cases := []struct {
+		height int
+		hash   string
+		want   int
+		fail   bool
+	}{
+		{10, "10", 11, false},
+		{11, "11", 14, false},
+		{14, "14b", -1, false},
+		// 副本停在已经被回滚的 13 上
+		{13, "13", 14, false},
+		// 副本太旧了
+		{5, "5", -1, true},
+	}
+	for _, c := range cases {
+		next, err := FindNextCommitLog(kvdb, c.height, c.hash)
+		if c.fail {
+			if err == nil {
+				t.Fatalf("%d: expected error, got %v", c.height, next)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%d: %v", c.height, err)
+		}
+		got := -1
+		if next != nil {
+			got = next.Height
+		}
+		if got != c.want {
+			t.Fatalf("%d: next commit log %d, want %d", c.height, got, c.want)
+		}
+	}
+
+	if err := PruneCommitLogs(kvdb, 1); err != nil {
+		t.Fatal(err)
+	}
+	if metas, err := GetCommitLogMetas(kvdb); err != nil || len(metas) != 1 || metas[0].Height != 14 {
+		t.Fatalf("after prune %v, %v", metas, err)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !memdb
+
+package db
+
+import (
+	"fmt"
+	"os"
+)
+
+func restoreCheckpoint(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+func removeCheckpoint(dir string) error {
+	return os.RemoveAll(dir)
+}
+
+// EnsureEmptyDB path 下没有数据库文件时返回 nil，目录不存在就创建
+func EnsureEmptyDB(path string) error {
+	items, err := os.ReadDir(path)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(path, 0755)
+	}
+	if err != nil {
+		return err
+	}
+	if len(items) != 0 {
+		return fmt.Errorf("%s is not empty", path)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+/*
+多个数据库的提交日志
+
+一次 flush 要依次写多个数据库，中途被杀掉时，各数据库的高度会不一致。
+写入前在主数据库记录 pending，写入过程中所有数据库都记录撤销日志，
+最后在每个数据库中写入已提交的高度，再删除 pending。
+启动时如果还有 pending，说明上次的提交没有完成，用撤销日志把所有数据库回滚到提交前的高度。
+追块时的提交不记录撤销日志（UndoMeta.NoUndo），提交前由 IndexerMgr 给所有数据库做一个检查点，
+这样的提交中断后，启动时用检查点替换数据库（RestoreCheckpoint），不需要从快照恢复。
+各数据库已提交的高度不一致时，用撤销日志都回滚到最低的高度。
+
+key 格式：
+	!commit-pending -> UndoMeta  只在主数据库中
+	!commit-height  -> int       每个数据库已经提交的高度，跟数据一起记录撤销日志
+*/
+
+const (
+	DB_KEY_COMMIT_PENDING = "!commit-pending"
+	DB_KEY_COMMIT_HEIGHT  = "!commit-height"
+)
+
+func SetPendingCommit(kvdb common.KVDB, meta *UndoMeta) error {
+	return GobSetDB([]byte(DB_KEY_COMMIT_PENDING), meta, kvdb)
+}
+
+// GetPendingCommit 没有未完成的提交时返回 nil
+func GetPendingCommit(kvdb common.KVDB) (*UndoMeta, error) {
+	var meta UndoMeta
+	err := GobGetDB([]byte(DB_KEY_COMMIT_PENDING), &meta, kvdb)
+	if err == common.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func ClearPendingCommit(kvdb common.KVDB) error {
+	return kvdb.Delete([]byte(DB_KEY_COMMIT_PENDING))
+}
+
+func SetCommitHeight(kvdb common.KVDB, height int) error {
+	return GobSetDB([]byte(DB_KEY_COMMIT_HEIGHT), height, kvdb)
+}
+
+// GetCommitHeight 以前版本写入的数据库没有这个记录，返回 ErrKeyNotFound
+func GetCommitHeight(kvdb common.KVDB) (int, error) {
+	var height int
+	err := GobGetDB([]byte(DB_KEY_COMMIT_HEIGHT), &height, kvdb)
+	if err != nil {
+		return -1, err
+	}
+	return height, nil
+}
+
+// RecoverPendingCommit 回滚没有完成的提交，main 是记录 pending 的数据库，也应该在 dbs 中。
+// 返回回滚到的高度，没有未完成的提交时返回 -2
+func RecoverPendingCommit(main common.KVDB, dbs []common.KVDB) (int, error) {
+	pending, err := GetPendingCommit(main)
+	if err != nil {
+		return -2, err
+	}
+	if pending == nil {
+		return -2, nil
+	}
+	if pending.NoUndo {
+		return -2, fmt.Errorf("commit %d without undo journal was interrupted and has no checkpoint, restore the db from a snapshot", pending.Height)
+	}
+
+	for _, kvdb := range dbs {
+		if _, err := RollbackToHeight(kvdb, pending.PrevHeight); err != nil {
+			return -2, fmt.Errorf("rollback commit %d to %d failed, %v", pending.Height, pending.PrevHeight, err)
+		}
+	}
+	for _, kvdb := range dbs {
+		height, err := GetCommitHeight(kvdb)
+		if err == nil && height > pending.PrevHeight {
+			return -2, fmt.Errorf("commit height %d is still above %d after rollback", height, pending.PrevHeight)
+		}
+	}
+	if err := ClearPendingCommit(main); err != nil {
+		return -2, err
+	}
+	return pending.PrevHeight, nil
+}
+
+// RecoverCommitHeights 把已提交高度更高的数据库回滚到最低的高度，返回这个高度，各数据库一致时返回 -2。
+// 以前版本的数据库，或者刚打开的协议，没有提交高度，不参与比较
+func RecoverCommitHeights(dbs []common.KVDB) (int, error) {
+	heights := make(map[common.KVDB]int)
+	lowest := -2
+	for _, kvdb := range dbs {
+		h, err := GetCommitHeight(kvdb)
+		if err == common.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return -2, err
+		}
+		heights[kvdb] = h
+		if lowest == -2 || h < lowest {
+			lowest = h
+		}
+	}
+
+	rolledBack := false
+	for kvdb, h := range heights {
+		if h == lowest {
+			continue
+		}
+		if _, err := RollbackToHeight(kvdb, lowest); err != nil {
+			return -2, fmt.Errorf("rollback db from %d to %d failed, %v", h, lowest, err)
+		}
+		// 撤销日志不够时回滚不到
+		if h, err := GetCommitHeight(kvdb); err != nil || h != lowest {
+			return -2, fmt.Errorf("can't roll back db to %d with the undo journal, still at %d", lowest, h)
+		}
+		rolledBack = true
+	}
+	if !rolledBack {
+		return -2, nil
+	}
+	return lowest, nil
+}
+
+// RestoreCheckpoint 用 CheckpointDB 在 src 生成的检查点替换 dst 的数据库，两个数据库都不能是打开的。
+// src 不存在时什么也不做，中途退出后可以再次调用
+func RestoreCheckpoint(src, dst string) error {
+	return restoreCheckpoint(src, dst)
+}
+
+// RemoveCheckpoint 删除 CheckpointDB 生成的检查点
+func RemoveCheckpoint(dir string) error {
+	return removeCheckpoint(dir)
+}
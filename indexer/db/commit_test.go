@@ -0,0 +1,139 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+func commitTestWrite(t *testing.T, database *JournalDB, key, value string) {
+	wb := database.NewWriteBatch()
+	defer wb.Close()
+	if err := wb.Put([]byte(key), []byte(value)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if err := wb.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+}
+
+func TestRecoverPendingCommit(t *testing.T) {
+	journal := NewUndoJournal()
+	main := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	other := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	dbs := []*JournalDB{main, other}
+	defer main.Close()
+	defer other.Close()
+
+	commit := func(meta *UndoMeta, crash bool) {
+		if err := SetPendingCommit(main, meta); err != nil {
+			t.Fatal(err)
+		}
+		journal.Begin(meta)
+		commitTestWrite(t, main, "k", "main"+meta.Hash)
+		if crash {
+			// 另一个数据库还没有写入就退出了
+			journal.End()
+			return
+		}
+		commitTestWrite(t, other, "k", "other"+meta.Hash)
+		for _, database := range dbs {
+			if err := SetCommitHeight(database, meta.Height); err != nil {
+				t.Fatal(err)
+			}
+		}
+		journal.End()
+		if err := ClearPendingCommit(main); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	commit(&UndoMeta{Height: 10, Hash: "10", PrevHeight: -1}, false)
+	height, err := RecoverPendingCommit(main, []common.KVDB{main, other})
+	if err != nil || height != -2 {
+		t.Fatalf("nothing to recover, got %d, %v", height, err)
+	}
+
+	commit(&UndoMeta{Height: 20, Hash: "20", PrevHeight: 10}, true)
+	expectValue(t, main, "k", "main20")
+	expectValue(t, other, "k", "other10")
+
+	height, err = RecoverPendingCommit(main, []common.KVDB{main, other})
+	if err != nil || height != 10 {
+		t.Fatalf("RecoverPendingCommit = %d, %v", height, err)
+	}
+	expectValue(t, main, "k", "main10")
+	expectValue(t, other, "k", "other10")
+	for _, database := range dbs {
+		if h, err := GetCommitHeight(database); err != nil || h != 10 {
+			t.Fatalf("commit height %d, %v", h, err)
+		}
+	}
+	if pending, err := GetPendingCommit(main); err != nil || pending != nil {
+		t.Fatalf("pending commit not cleared, %v %v", pending, err)
+	}
+}
+
+func TestRecoverPendingCommitWithoutUndo(t *testing.T) {
+	journal := NewUndoJournal()
+	main := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	defer main.Close()
+
+	meta := &UndoMeta{Height: 20, Hash: "20", PrevHeight: 10, NoUndo: true}
+	if err := SetPendingCommit(main, meta); err != nil {
+		t.Fatal(err)
+	}
+	journal.Begin(meta)
+	commitTestWrite(t, main, "k", "main20")
+	journal.End()
+	if metas, _ := GetUndoMetas(main); len(metas) != 0 {
+		t.Fatalf("undo journal recorded in catch-up commit, %v", metas)
+	}
+
+	if _, err := RecoverPendingCommit(main, []common.KVDB{main}); err == nil {
+		t.Fatal("expected interrupted commit without undo journal to be refused")
+	}
+}
+
+func TestRecoverCommitHeights(t *testing.T) {
+	journal := NewUndoJournal()
+	main := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	other := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	legacy := NewJournalDB(NewKVDB(t.TempDir()), journal)
+	defer main.Close()
+	defer other.Close()
+	defer legacy.Close()
+	dbs := []common.KVDB{main, other, legacy}
+
+	commit := func(meta *UndoMeta, dbs ...*JournalDB) {
+		journal.Begin(meta)
+		for _, database := range dbs {
+			commitTestWrite(t, database, "k", meta.Hash)
+			if err := SetCommitHeight(database, meta.Height); err != nil {
+				t.Fatal(err)
+			}
+		}
+		journal.End()
+	}
+	commit(&UndoMeta{Height: 10, Hash: "10", PrevHeight: -1}, main, other)
+	if height, err := RecoverCommitHeights(dbs); err != nil || height != -2 {
+		t.Fatalf("nothing to recover, got %d, %v", height, err)
+	}
+
+	// 只有 main 写入了 20
+	commit(&UndoMeta{Height: 20, Hash: "20", PrevHeight: 10}, main)
+	height, err := RecoverCommitHeights(dbs)
+	if err != nil || height != 10 {
+		t.Fatalf("RecoverCommitHeights = %d, %v", height, err)
+	}
+	expectValue(t, main, "k", "10")
+	if h, err := GetCommitHeight(main); err != nil || h != 10 {
+		t.Fatalf("commit height %d, %v", h, err)
+	}
+
+	// 没有撤销日志，回滚不了
+	commit(&UndoMeta{Height: 30, Hash: "30", PrevHeight: 10, NoUndo: true}, main)
+	if _, err := RecoverCommitHeights(dbs); err == nil {
+		t.Fatal("expected heights without undo journal to be refused")
+	}
+}
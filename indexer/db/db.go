@@ -2,6 +2,7 @@ package db
 
 import (
 	"errors"
+	"time"
 
 	"github.com/sat20-labs/indexer/common"
 )
@@ -23,6 +24,34 @@ func RunDBGC(kvdb common.KVDB) error {
 	return runner.RunGC()
 }
 
+var ErrStatsUnsupported = errors.New("database backend does not support stats")
+
+// DBStats 数据库后端的统计信息，用于监控
+type DBStats struct {
+	DiskSize           uint64
+	ReadAmp            int
+	CompactionCount    int64
+	CompactionRunning  int64
+	CompactionBytes    int64 // 正在进行的压缩写入的字节数
+	CompactionDebt     uint64
+	CompactionDuration time.Duration // 打开后压缩累计耗时
+}
+
+type statsProvider interface {
+	Stats() (*DBStats, error)
+}
+
+func GetDBStats(kvdb common.KVDB) (*DBStats, error) {
+	if kvdb == nil {
+		return nil, ErrStatsUnsupported
+	}
+	provider, ok := kvdb.(statsProvider)
+	if !ok {
+		return nil, ErrStatsUnsupported
+	}
+	return provider.Stats()
+}
+
 func NewKVDB(path string) common.KVDB {
 	//return NewLevelDB(path)
 	return newKVDB(path)
@@ -33,3 +62,20 @@ func NewKVDB(path string) common.KVDB {
 func NewKVDBWithCache(path string, cacheSizeMB int) common.KVDB {
 	return newKVDBWithCache(path, cacheSizeMB)
 }
+
+// nextPrefix 返回“字典序上紧邻 prefix 的下界”，可作为 UpperBound（开区间）。
+// 若 prefix 全为 0xFF，返回 nil（表示无上界）；此时要多一道 HasPrefix 检查。
+func nextPrefix(prefix []byte) []byte {
+	if len(prefix) == 0 {
+		return nil
+	}
+	out := append([]byte{}, prefix...)
+	for i := len(out) - 1; i >= 0; i-- {
+		if out[i] != 0xFF {
+			out[i]++
+			return out[:i+1]
+		}
+	}
+	// 全 0xFF，没有更大前缀；返回 nil 表示不设上界
+	return nil
+}
@@ -0,0 +1,33 @@
+//go:build !badger && !memdb
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestGetDBStats(t *testing.T) {
+	raw := NewKVDB(t.TempDir())
+	if raw == nil {
+		t.Fatal("open db failed")
+	}
+	defer raw.Close()
+	for i := 0; i < 100; i++ {
+		raw.Write([]byte(fmt.Sprintf("k%d", i)), []byte("v"))
+	}
+
+	// JournalDB 转发到底层数据库
+	stats, err := GetDBStats(NewJournalDB(raw, NewUndoJournal()))
+	if err != nil {
+		t.Fatalf("GetDBStats: %v", err)
+	}
+	if stats.DiskSize == 0 {
+		t.Fatal("disk size should not be 0")
+	}
+
+	if _, err := GetDBStats(&fakeUnsupportedDB{}); !errors.Is(err, ErrStatsUnsupported) {
+		t.Fatalf("GetDBStats(unsupported) error = %v", err)
+	}
+}
@@ -0,0 +1,449 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+/*
+撤销日志（undo journal）
+
+每次把内存数据写入数据库时（一次 flush 可能包含多个区块），记录被修改的 key 的旧值。
+区块重组时，按高度从高到低、按写入顺序的逆序回放旧值，
+就能把数据库原地恢复到某个已经写入过的高度，不需要关闭数据库，也不需要重新跑数据。
+
+底层的 WriteBatch 太大时会自己提交（见 pebbleWriteBatch.ensureCapacity），所以写入先缓存在
+journalWriteBatch 中，每 journalChunkSize 先同步写入这些 key 的旧值，再交给底层的 WriteBatch，
+任何时候被中断，已经写入数据库的修改都有对应的旧值。
+
+key 格式：
+	!undo-m-<height>        -> UndoMeta    本次写入对应的高度和区块hash
+	!undo-d-<height>-<seq>  -> []UndoEntry 本次写入中，一个 WriteBatch 的旧值
+*/
+
+const (
+	DB_KEY_UNDO      = "!undo-"
+	DB_KEY_UNDO_META = DB_KEY_UNDO + "m-"
+	DB_KEY_UNDO_DATA = DB_KEY_UNDO + "d-"
+)
+
+// 缓存的写入达到这个大小时，写入旧值后交给底层的 WriteBatch
+var journalChunkSize = 16 << 20
+
+type UndoEntry struct {
+	Key     []byte
+	Value   []byte
+	Existed bool
+}
+
+type UndoMeta struct {
+	Height     int
+	Hash       string
+	PrevHeight int // 写入前数据库所在的高度
+	PrevHash   string
+	NoUndo     bool // 追块时的提交，不记录旧值，中断后不能回滚
+}
+
+func GetUndoMetaKey(height int) []byte {
+	return []byte(fmt.Sprintf("%s%010d", DB_KEY_UNDO_META, height))
+}
+
+func getUndoDataPrefix(height int) []byte {
+	return []byte(fmt.Sprintf("%s%010d-", DB_KEY_UNDO_DATA, height))
+}
+
+func getUndoDataKey(height int, seq uint32) []byte {
+	key := getUndoDataPrefix(height)
+	return binary.BigEndian.AppendUint32(key, seq)
+}
+
+// UndoJournal 多个数据库共享的写入状态，由 IndexerMgr 在写数据库前设置
+type UndoJournal struct {
+	mutex  sync.Mutex
+	active bool
+	meta   UndoMeta
+}
+
+func NewUndoJournal() *UndoJournal {
+	return &UndoJournal{}
+}
+
+// Begin 接下来的写入，都记录在 height 的撤销日志中
+func (p *UndoJournal) Begin(meta *UndoMeta) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.active = true
+	p.meta = *meta
+}
+
+// End 停止记录撤销日志
+func (p *UndoJournal) End() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.active = false
+}
+
+func (p *UndoJournal) current() (UndoMeta, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.meta, p.active
+}
+
+// JournalDB 在写入时记录撤销日志的 KVDB
+type JournalDB struct {
+	common.KVDB
+	journal *UndoJournal
+
+	mutex sync.Mutex
+	seqs  map[int]uint32 // height -> 下一个 seq
+
+	// 主节点为副本记录写入的新值
+	name    string
+	changes *ChangeLog
+}
+
+func NewJournalDB(kvdb common.KVDB, journal *UndoJournal) *JournalDB {
+	return &JournalDB{
+		KVDB:    kvdb,
+		journal: journal,
+		seqs:    make(map[int]uint32),
+	}
+}
+
+// SetChangeLog 记录日志期间的写入，name 是副本上对应的数据库
+func (p *JournalDB) SetChangeLog(name string, changes *ChangeLog) {
+	p.name = name
+	p.changes = changes
+}
+
+func (p *JournalDB) Checkpoint(dir string) error {
+	return CheckpointDB(p.KVDB, dir)
+}
+
+func (p *JournalDB) RunGC() error {
+	return RunDBGC(p.KVDB)
+}
+
+func (p *JournalDB) Stats() (*DBStats, error) {
+	return GetDBStats(p.KVDB)
+}
+
+func (p *JournalDB) nextSeq(height int) uint32 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	seq, ok := p.seqs[height]
+	if !ok {
+		// 重启后可能继续写同一个高度
+		p.KVDB.BatchRead(getUndoDataPrefix(height), false, func(k, v []byte) error {
+			seq++
+			return nil
+		})
+	}
+	p.seqs[height] = seq + 1
+	return seq
+}
+
+func (p *JournalDB) Write(key, value []byte) error {
+	if _, ok := p.journal.current(); !ok {
+		return p.KVDB.Write(key, value)
+	}
+	wb := p.NewWriteBatch()
+	defer wb.Close()
+	if err := wb.Put(key, value); err != nil {
+		return err
+	}
+	return wb.Flush()
+}
+
+func (p *JournalDB) Delete(key []byte) error {
+	if _, ok := p.journal.current(); !ok {
+		return p.KVDB.Delete(key)
+	}
+	wb := p.NewWriteBatch()
+	defer wb.Close()
+	if err := wb.Delete(key); err != nil {
+		return err
+	}
+	return wb.Flush()
+}
+
+func (p *JournalDB) NewWriteBatch() common.WriteBatch {
+	meta, ok := p.journal.current()
+	if !ok {
+		return p.KVDB.NewWriteBatch()
+	}
+	return &journalWriteBatch{
+		WriteBatch: p.KVDB.NewWriteBatch(),
+		db:         p,
+		meta:       meta,
+		seen:       make(map[string]bool),
+		logging:    p.changes != nil && p.changes.isActive(),
+	}
+}
+
+type journalWriteBatch struct {
+	common.WriteBatch
+	db          *JournalDB
+	meta        UndoMeta
+	seen        map[string]bool
+	entries     []*UndoEntry
+	pending     []*Change // 旧值还没有写入数据库的修改
+	size        int
+	logging     bool
+	redo        []*Change
+	redoSize    int
+	redoChecked int // 上次检查提交日志大小时的 redoSize
+}
+
+// 同一个 batch 中，只需要记录第一次修改前的值
+func (p *journalWriteBatch) record(key []byte) error {
+	if bytes.HasPrefix(key, []byte(DB_KEY_UNDO)) {
+		return nil
+	}
+	if p.seen[string(key)] {
+		return nil
+	}
+	p.seen[string(key)] = true
+
+	entry := &UndoEntry{Key: append([]byte{}, key...)}
+	value, err := p.db.KVDB.Read(key)
+	if err == nil {
+		entry.Value = value
+		entry.Existed = true
+	} else if err != common.ErrKeyNotFound {
+		return err
+	}
+	p.entries = append(p.entries, entry)
+	return nil
+}
+
+func (p *journalWriteBatch) Put(key, value []byte) error {
+	if p.logging {
+		p.logRedo(&Change{Key: append([]byte{}, key...), Value: append([]byte{}, value...)})
+	}
+	if p.meta.NoUndo {
+		return p.WriteBatch.Put(key, value)
+	}
+	if err := p.record(key); err != nil {
+		return err
+	}
+	p.pending = append(p.pending, &Change{Key: append([]byte{}, key...), Value: append([]byte{}, value...)})
+	p.size += len(key) + len(value)
+	return p.spillIfFull()
+}
+
+func (p *journalWriteBatch) Delete(key []byte) error {
+	if p.logging {
+		p.logRedo(&Change{Key: append([]byte{}, key...), Deleted: true})
+	}
+	if p.meta.NoUndo {
+		return p.WriteBatch.Delete(key)
+	}
+	if err := p.record(key); err != nil {
+		return err
+	}
+	p.pending = append(p.pending, &Change{Key: append([]byte{}, key...), Deleted: true})
+	p.size += len(key)
+	return p.spillIfFull()
+}
+
+// logRedo 记录副本需要的新值。每增加 journalChunkSize 检查一次，超过提交日志的大小限制后不再记录
+func (p *journalWriteBatch) logRedo(change *Change) {
+	p.redo = append(p.redo, change)
+	p.redoSize += len(change.Key) + len(change.Value)
+	if p.redoSize-p.redoChecked < journalChunkSize {
+		return
+	}
+	p.redoChecked = p.redoSize
+	if !p.db.changes.fits(p.redoSize) {
+		p.logging = false
+		p.redo = nil
+	}
+}
+
+func (p *journalWriteBatch) spillIfFull() error {
+	if p.size < journalChunkSize {
+		return nil
+	}
+	return p.spill()
+}
+
+// 先同步写入旧值，再把缓存的修改交给底层的 WriteBatch
+func (p *journalWriteBatch) spill() error {
+	if len(p.entries) > 0 {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(p.entries); err != nil {
+			return err
+		}
+		wb := p.db.KVDB.NewWriteBatch()
+		defer wb.Close()
+		seq := p.db.nextSeq(p.meta.Height)
+		if err := wb.Put(getUndoDataKey(p.meta.Height, seq), buf.Bytes()); err != nil {
+			return err
+		}
+		if err := SetDB(GetUndoMetaKey(p.meta.Height), &p.meta, wb); err != nil {
+			return err
+		}
+		if err := wb.Flush(); err != nil {
+			return err
+		}
+		p.entries = nil
+	}
+
+	for _, change := range p.pending {
+		var err error
+		if change.Deleted {
+			err = p.WriteBatch.Delete(change.Key)
+		} else {
+			err = p.WriteBatch.Put(change.Key, change.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.pending = nil
+	p.size = 0
+	return nil
+}
+
+func (p *journalWriteBatch) Flush() error {
+	if err := p.spill(); err != nil {
+		return err
+	}
+	p.seen = make(map[string]bool)
+	if err := p.WriteBatch.Flush(); err != nil {
+		return err
+	}
+	if len(p.redo) > 0 {
+		p.db.changes.append(p.db.name, p.redo, p.redoSize)
+		p.redo = nil
+		p.redoSize = 0
+		p.redoChecked = 0
+	}
+	return nil
+}
+
+// GetUndoMetas 按高度从高到低返回所有撤销日志
+func GetUndoMetas(kvdb common.KVDB) ([]*UndoMeta, error) {
+	result := make([]*UndoMeta, 0)
+	err := kvdb.BatchRead([]byte(DB_KEY_UNDO_META), false, func(k, v []byte) error {
+		var meta UndoMeta
+		if err := DecodeBytes(v, &meta); err != nil {
+			return err
+		}
+		result = append(result, &meta)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Height > result[j].Height
+	})
+	return result, nil
+}
+
+// RollbackToHeight 回放所有高于 height 的撤销日志，数据库恢复到 height 写入完成时的状态
+func RollbackToHeight(kvdb common.KVDB, height int) (int, error) {
+	if p, ok := kvdb.(*JournalDB); ok {
+		kvdb = p.KVDB
+		defer func() {
+			p.mutex.Lock()
+			p.seqs = make(map[int]uint32)
+			p.mutex.Unlock()
+		}()
+	}
+
+	metas, err := GetUndoMetas(kvdb)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, meta := range metas {
+		if meta.Height <= height {
+			break
+		}
+
+		var keys, chunks [][]byte
+		err := kvdb.BatchRead(getUndoDataPrefix(meta.Height), false, func(k, v []byte) error {
+			keys = append(keys, append([]byte{}, k...))
+			chunks = append(chunks, append([]byte{}, v...))
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+
+		wb := kvdb.NewWriteBatch()
+		for i := len(chunks) - 1; i >= 0; i-- {
+			var entries []*UndoEntry
+			if err := DecodeBytes(chunks[i], &entries); err != nil {
+				wb.Close()
+				return count, err
+			}
+			for j := len(entries) - 1; j >= 0; j-- {
+				entry := entries[j]
+				if entry.Existed {
+					err = wb.Put(entry.Key, entry.Value)
+				} else {
+					err = wb.Delete(entry.Key)
+				}
+				if err != nil {
+					wb.Close()
+					return count, err
+				}
+			}
+		}
+		for _, key := range keys {
+			wb.Delete(key)
+		}
+		wb.Delete(GetUndoMetaKey(meta.Height))
+		err = wb.Flush()
+		wb.Close()
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// PruneUndoJournal 删除低于 height 的撤销日志
+func PruneUndoJournal(kvdb common.KVDB, height int) error {
+	if p, ok := kvdb.(*JournalDB); ok {
+		kvdb = p.KVDB
+		p.mutex.Lock()
+		for h := range p.seqs {
+			if h < height {
+				delete(p.seqs, h)
+			}
+		}
+		p.mutex.Unlock()
+	}
+
+	metas, err := GetUndoMetas(kvdb)
+	if err != nil {
+		return err
+	}
+	wb := kvdb.NewWriteBatch()
+	defer wb.Close()
+	for _, meta := range metas {
+		if meta.Height >= height {
+			continue
+		}
+		err := kvdb.BatchRead(getUndoDataPrefix(meta.Height), false, func(k, v []byte) error {
+			return wb.Delete(append([]byte{}, k...))
+		})
+		if err != nil {
+			return err
+		}
+		wb.Delete(GetUndoMetaKey(meta.Height))
+	}
+	return wb.Flush()
+}
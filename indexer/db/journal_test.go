@@ -0,0 +1,177 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+func TestJournalDBRollbackRestoresPreviousHeights(t *testing.T) {
+	raw := NewKVDB(t.TempDir())
+	if raw == nil {
+		t.Fatal("open db failed")
+	}
+	defer raw.Close()
+
+	journal := NewUndoJournal()
+	database := NewJournalDB(raw, journal)
+
+	// height 10 不记录撤销日志
+	if err := database.Write([]byte("k1"), []byte("v1")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	journal.Begin(&UndoMeta{Height: 12, Hash: "h12", PrevHeight: 10})
+	wb := database.NewWriteBatch()
+	wb.Put([]byte("k1"), []byte("v2"))
+	wb.Put([]byte("k1"), []byte("v3"))
+	wb.Put([]byte("k2"), []byte("v1"))
+	if err := wb.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	wb.Close()
+	if err := database.Delete([]byte("k2")); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	journal.Begin(&UndoMeta{Height: 14, Hash: "h14", PrevHeight: 12})
+	wb = database.NewWriteBatch()
+	wb.Delete([]byte("k1"))
+	wb.Put([]byte("k3"), []byte("v1"))
+	if err := wb.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	wb.Close()
+	journal.End()
+
+	metas, err := GetUndoMetas(database)
+	if err != nil {
+		t.Fatalf("GetUndoMetas: %v", err)
+	}
+	if len(metas) != 2 || metas[0].Height != 14 || metas[1].Height != 12 {
+		t.Fatalf("unexpected metas %v", metas)
+	}
+
+	n, err := RollbackToHeight(database, 12)
+	if err != nil || n != 1 {
+		t.Fatalf("RollbackToHeight(12) = %d, %v", n, err)
+	}
+	expectValue(t, database, "k1", "v3")
+	expectValue(t, database, "k2", "")
+	expectValue(t, database, "k3", "")
+
+	n, err = RollbackToHeight(database, 10)
+	if err != nil || n != 1 {
+		t.Fatalf("RollbackToHeight(10) = %d, %v", n, err)
+	}
+	expectValue(t, database, "k1", "v1")
+	expectValue(t, database, "k2", "")
+
+	metas, _ = GetUndoMetas(database)
+	if len(metas) != 0 {
+		t.Fatalf("undo journal not cleaned, %d left", len(metas))
+	}
+}
+
+func TestPruneUndoJournal(t *testing.T) {
+	raw := NewKVDB(t.TempDir())
+	if raw == nil {
+		t.Fatal("open db failed")
+	}
+	defer raw.Close()
+
+	journal := NewUndoJournal()
+	database := NewJournalDB(raw, journal)
+	for _, height := range []int{1, 2, 3} {
+		journal.Begin(&UndoMeta{Height: height})
+		if err := database.Write([]byte("k"), []byte{byte(height)}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	journal.End()
+
+	if err := PruneUndoJournal(database, 3); err != nil {
+		t.Fatalf("PruneUndoJournal: %v", err)
+	}
+	metas, _ := GetUndoMetas(database)
+	if len(metas) != 1 || metas[0].Height != 3 {
+		t.Fatalf("unexpected metas after prune %v", metas)
+	}
+	count := 0
+	database.BatchRead([]byte(DB_KEY_UNDO_DATA), false, func(k, v []byte) error {
+		count++
+		return nil
+	})
+	if count != 1 {
+		t.Fatalf("undo data count = %d, want 1", count)
+	}
+}
+
+// 底层的 WriteBatch 每次写入都直接提交，跟 pebble 的 batch 太大时自己提交一样
+type autoCommitDB struct {
+	common.KVDB
+}
+
+func (p *autoCommitDB) NewWriteBatch() common.WriteBatch {
+	return &autoCommitWriteBatch{WriteBatch: p.KVDB.NewWriteBatch(), db: p.KVDB}
+}
+
+type autoCommitWriteBatch struct {
+	common.WriteBatch
+	db common.KVDB
+}
+
+func (p *autoCommitWriteBatch) Put(key, value []byte) error {
+	return p.db.Write(key, value)
+}
+
+func (p *autoCommitWriteBatch) Delete(key []byte) error {
+	return p.db.Delete(key)
+}
+
+func TestJournalDBAutoCommittedBatchCanRollback(t *testing.T) {
+	raw := NewKVDB(t.TempDir())
+	if raw == nil {
+		t.Fatal("open db failed")
+	}
+	defer raw.Close()
+	defer func(size int) { journalChunkSize = size }(journalChunkSize)
+	journalChunkSize = 1
+
+	journal := NewUndoJournal()
+	database := NewJournalDB(&autoCommitDB{raw}, journal)
+	if err := database.Write([]byte("k1"), []byte("v1")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// 写入已经提交到数据库，没有 Flush 就退出了
+	journal.Begin(&UndoMeta{Height: 12, Hash: "h12", PrevHeight: 10})
+	wb := database.NewWriteBatch()
+	wb.Put([]byte("k1"), []byte("v2"))
+	wb.Put([]byte("k2"), []byte("v1"))
+	wb.Delete([]byte("k1"))
+	journal.End()
+	expectValue(t, database, "k1", "")
+	expectValue(t, database, "k2", "v1")
+
+	if n, err := RollbackToHeight(database, 10); err != nil || n != 1 {
+		t.Fatalf("RollbackToHeight(10) = %d, %v", n, err)
+	}
+	expectValue(t, database, "k1", "v1")
+	expectValue(t, database, "k2", "")
+}
+
+func expectValue(t *testing.T, database common.KVDB, key, want string) {
+	t.Helper()
+	value, err := database.Read([]byte(key))
+	if want == "" {
+		if err != common.ErrKeyNotFound {
+			t.Fatalf("%s = %q, want not found", key, value)
+		}
+		return
+	}
+	if err != nil || !bytes.Equal(value, []byte(want)) {
+		t.Fatalf("%s = %q (%v), want %q", key, value, err, want)
+	}
+}
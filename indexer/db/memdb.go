@@ -0,0 +1,307 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/emirpasic/gods/trees/redblacktree"
+	"github.com/sat20-labs/indexer/common"
+)
+
+/*
+内存数据库，用于测试，不需要磁盘上的数据库
+
+用 -tags memdb 编译时，NewKVDB 返回内存数据库。
+同一个进程中，用相同的 path 重新打开，可以读到关闭前的数据，用来模拟重启。
+遍历时的回调函数拿到的是数据的 copy，可以在回调中写数据库。
+*/
+
+var ErrMemDBClosed = errors.New("memdb closed")
+
+type memStore struct {
+	mutex sync.RWMutex
+	tree  *redblacktree.Tree // string -> []byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{tree: redblacktree.NewWithStringComparator()}
+}
+
+var (
+	memStoreMutex sync.Mutex
+	memStores     = make(map[string]*memStore)
+)
+
+type memDB struct {
+	path   string
+	store  *memStore
+	closed bool
+}
+
+// NewMemDB path 为空时，返回一个不能重新打开的数据库
+func NewMemDB(path string) common.KVDB {
+	if path == "" {
+		return &memDB{store: newMemStore()}
+	}
+	memStoreMutex.Lock()
+	defer memStoreMutex.Unlock()
+	store, ok := memStores[path]
+	if !ok {
+		store = newMemStore()
+		memStores[path] = store
+	}
+	return &memDB{path: path, store: store}
+}
+
+// RemoveMemDB 删除 path 下的数据，下次打开是空的数据库
+func RemoveMemDB(path string) {
+	memStoreMutex.Lock()
+	defer memStoreMutex.Unlock()
+	delete(memStores, path)
+}
+
+func (p *memDB) Read(key []byte) ([]byte, error) {
+	if p.closed {
+		return nil, ErrMemDBClosed
+	}
+	p.store.mutex.RLock()
+	defer p.store.mutex.RUnlock()
+	return p.store.get(key)
+}
+
+func (p *memStore) get(key []byte) ([]byte, error) {
+	value, ok := p.tree.Get(string(key))
+	if !ok {
+		return nil, common.ErrKeyNotFound
+	}
+	return append([]byte{}, value.([]byte)...), nil
+}
+
+func (p *memDB) Write(key, value []byte) error {
+	if p.closed {
+		return ErrMemDBClosed
+	}
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	p.store.tree.Put(string(key), append([]byte{}, value...))
+	return nil
+}
+
+func (p *memDB) Delete(key []byte) error {
+	if p.closed {
+		return ErrMemDBClosed
+	}
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	p.store.tree.Remove(string(key))
+	return nil
+}
+
+func (p *memDB) DropPrefix(prefix []byte) error {
+	if p.closed {
+		return ErrMemDBClosed
+	}
+	keys := make([]string, 0)
+	p.store.scan(prefix, nil, false, func(k, v []byte) bool {
+		keys = append(keys, string(k))
+		return true
+	})
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	for _, k := range keys {
+		p.store.tree.Remove(k)
+	}
+	return nil
+}
+
+func (p *memDB) DropAll() error {
+	if p.closed {
+		return ErrMemDBClosed
+	}
+	p.store.mutex.Lock()
+	defer p.store.mutex.Unlock()
+	p.store.tree.Clear()
+	return nil
+}
+
+func (p *memDB) Close() error {
+	p.closed = true
+	return nil
+}
+
+// 在读锁中取出数据，跟 pebbleDB.iter 的遍历顺序一致：
+// 正向从 start（或者 prefix）开始，包含 start；反向从小于 start 的最大键开始
+func (p *memStore) scan(prefix, start []byte, reverse bool, fn func(k, v []byte) bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	it := p.tree.Iterator()
+	var ok bool
+	if reverse {
+		upper := nextPrefix(prefix)
+		if len(start) > 0 && (upper == nil || bytes.Compare(start, upper) < 0) {
+			upper = start
+		}
+		if upper == nil {
+			it.End()
+		} else if node, found := p.tree.Ceiling(string(upper)); found {
+			it = p.tree.IteratorAt(node)
+		} else {
+			it.End()
+		}
+		ok = it.Prev()
+	} else {
+		lower := prefix
+		if len(start) > 0 && bytes.Compare(start, lower) > 0 {
+			lower = start
+		}
+		if node, found := p.tree.Ceiling(string(lower)); found {
+			it = p.tree.IteratorAt(node)
+			ok = true
+		}
+	}
+
+	for ; ok; ok = memIterNext(&it, reverse) {
+		k := []byte(it.Key().(string))
+		if !bytes.HasPrefix(k, prefix) {
+			break
+		}
+		if !fn(k, it.Value().([]byte)) {
+			break
+		}
+	}
+}
+
+func memIterNext(it *redblacktree.Iterator, reverse bool) bool {
+	if reverse {
+		return it.Prev()
+	}
+	return it.Next()
+}
+
+func (p *memDB) iter(prefix, start []byte, reverse bool, r func(k, v []byte) error) error {
+	if p.closed {
+		return ErrMemDBClosed
+	}
+	type kv struct {
+		k, v []byte
+	}
+	items := make([]kv, 0)
+	p.store.scan(prefix, start, reverse, func(k, v []byte) bool {
+		items = append(items, kv{k, append([]byte{}, v...)})
+		return true
+	})
+	for _, item := range items {
+		if err := r(item.k, item.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *memDB) BatchRead(prefix []byte, reverse bool, r func(k, v []byte) error) error {
+	return p.iter(prefix, nil, reverse, r)
+}
+
+func (p *memDB) BatchReadV2(prefix, seekKey []byte, reverse bool, r func(k, v []byte) error) error {
+	return p.iter(prefix, seekKey, reverse, r)
+}
+
+type memReadBatch struct {
+	store *memStore
+}
+
+func (p *memReadBatch) Get(key []byte) ([]byte, error) {
+	p.store.mutex.RLock()
+	defer p.store.mutex.RUnlock()
+	return p.store.get(key)
+}
+
+func (p *memReadBatch) GetRef(key []byte) ([]byte, error) {
+	return p.Get(key)
+}
+
+func (p *memDB) View(fn func(txn common.ReadBatch) error) error {
+	if p.closed {
+		return ErrMemDBClosed
+	}
+	return fn(&memReadBatch{store: p.store})
+}
+
+type memWriteBatch struct {
+	db     *memDB
+	writes []*Change
+	closed bool
+}
+
+func (p *memWriteBatch) Put(key, value []byte) error {
+	if p.closed {
+		return errors.New("writebatch closed")
+	}
+	p.writes = append(p.writes, &Change{
+		Key:   append([]byte{}, key...),
+		Value: append([]byte{}, value...),
+	})
+	return nil
+}
+
+func (p *memWriteBatch) Delete(key []byte) error {
+	if p.closed {
+		return errors.New("writebatch closed")
+	}
+	p.writes = append(p.writes, &Change{Key: append([]byte{}, key...), Deleted: true})
+	return nil
+}
+
+// Flush 一次写入所有数据，其他读者看不到中间状态
+func (p *memWriteBatch) Flush() error {
+	if p.closed {
+		return errors.New("writebatch closed")
+	}
+	if p.db.closed {
+		return ErrMemDBClosed
+	}
+	store := p.db.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	for _, w := range p.writes {
+		if w.Deleted {
+			store.tree.Remove(string(w.Key))
+		} else {
+			store.tree.Put(string(w.Key), w.Value)
+		}
+	}
+	p.writes = nil
+	return nil
+}
+
+func (p *memWriteBatch) Close() {
+	p.closed = true
+	p.writes = nil
+}
+
+func (p *memDB) NewWriteBatch() common.WriteBatch {
+	return &memWriteBatch{db: p}
+}
+
+// Checkpoint 复制一份数据，用 NewMemDB(dir) 打开
+func (p *memDB) Checkpoint(dir string) error {
+	if p.closed {
+		return ErrMemDBClosed
+	}
+	memStoreMutex.Lock()
+	defer memStoreMutex.Unlock()
+	if _, ok := memStores[dir]; ok {
+		return errors.New("checkpoint " + dir + " already exists")
+	}
+	store := newMemStore()
+	p.store.mutex.RLock()
+	it := p.store.tree.Iterator()
+	for it.Next() {
+		store.tree.Put(it.Key(), it.Value())
+	}
+	p.store.mutex.RUnlock()
+	memStores[dir] = store
+	return nil
+}
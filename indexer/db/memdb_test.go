@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+func memDBKeys(t *testing.T, kvdb common.KVDB, prefix, seek string, reverse bool) []string {
+	t.Helper()
+	result := make([]string, 0)
+	var seekKey []byte
+	if seek != "" {
+		seekKey = []byte(seek)
+	}
+	err := kvdb.BatchReadV2([]byte(prefix), seekKey, reverse, func(k, v []byte) error {
+		result = append(result, string(k))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestMemDBBatchRead(t *testing.T) {
+	kvdb := NewMemDB("")
+	for _, k := range []string{"a1", "b1", "b2", "b3", "c1"} {
+		if err := kvdb.Write([]byte(k), []byte("v"+k)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		prefix, seek string
+		reverse      bool
+		want         []string
+	}{
+		{"", "", false, []string{"a1", "b1", "b2", "b3", "c1"}},
+		{"", "", true, []string{"c1", "b3", "b2", "b1", "a1"}},
+		{"b", "", false, []string{"b1", "b2", "b3"}},
+		{"b", "", true, []string{"b3", "b2", "b1"}},
+		{"b", "b2", false, []string{"b2", "b3"}},
+		// 反向不包含 seek
+		{"b", "b2", true, []string{"b1"}},
+		{"d", "", false, []string{}},
+	}
+	for _, c := range cases {
+		got := memDBKeys(t, kvdb, c.prefix, c.seek, c.reverse)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("prefix %q seek %q reverse %v: got %v, want %v", c.prefix, c.seek, c.reverse, got, c.want)
+		}
+	}
+
+	// 遍历时可以写数据库
+	err := kvdb.BatchRead([]byte("b"), false, func(k, v []byte) error {
+		return kvdb.Delete(k)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := memDBKeys(t, kvdb, "", "", false); !reflect.DeepEqual(got, []string{"a1", "c1"}) {
+		t.Fatalf("after delete %v", got)
+	}
+}
+
+func TestMemDBWriteBatch(t *testing.T) {
+	path := t.Name()
+	defer RemoveMemDB(path)
+	kvdb := NewMemDB(path)
+
+	wb := kvdb.NewWriteBatch()
+	for i := 0; i < 3; i++ {
+		wb.Put([]byte(fmt.Sprintf("k%d", i)), []byte("v"))
+	}
+	wb.Delete([]byte("k1"))
+	if _, err := kvdb.Read([]byte("k0")); err != common.ErrKeyNotFound {
+		t.Fatalf("write before flush, %v", err)
+	}
+	if err := wb.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	wb.Close()
+
+	err := kvdb.View(func(txn common.ReadBatch) error {
+		if _, err := txn.Get([]byte("k1")); err != common.ErrKeyNotFound {
+			t.Fatalf("k1 should be deleted, %v", err)
+		}
+		v, err := txn.GetRef([]byte("k2"))
+		if err != nil || string(v) != "v" {
+			t.Fatalf("k2 = %q, %v", v, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新打开后数据还在
+	kvdb.Close()
+	if _, err := kvdb.Read([]byte("k0")); err != ErrMemDBClosed {
+		t.Fatalf("read after close, %v", err)
+	}
+	kvdb = NewMemDB(path)
+	expectValue(t, kvdb, "k0", "v")
+	expectValue(t, kvdb, "k1", "")
+
+	checkpoint := path + "-checkpoint"
+	defer RemoveMemDB(checkpoint)
+	if err := CheckpointDB(kvdb, checkpoint); err != nil {
+		t.Fatal(err)
+	}
+	kvdb.Write([]byte("k0"), []byte("new"))
+	expectValue(t, NewMemDB(checkpoint), "k0", "v")
+}
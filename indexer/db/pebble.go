@@ -306,21 +306,20 @@ func (p *pebbleDB) Close() error {
 	return p.close()
 }
 
-// nextPrefix 返回“字典序上紧邻 prefix 的下界”，可作为 UpperBound（开区间）。
-// 若 prefix 全为 0xFF，返回 nil（表示无上界）；此时要多一道 HasPrefix 检查。
-func nextPrefix(prefix []byte) []byte {
-	if len(prefix) == 0 {
-		return nil
-	}
-	out := append([]byte{}, prefix...)
-	for i := len(out) - 1; i >= 0; i-- {
-		if out[i] != 0xFF {
-			out[i]++
-			return out[:i+1]
-		}
-	}
-	// 全 0xFF，没有更大前缀；返回 nil 表示不设上界
-	return nil
+func (p *pebbleDB) Stats() (*DBStats, error) {
+	if p == nil || p.db == nil {
+		return nil, ErrStatsUnsupported
+	}
+	m := p.db.Metrics()
+	return &DBStats{
+		DiskSize:           m.DiskSpaceUsage(),
+		ReadAmp:            m.ReadAmp(),
+		CompactionCount:    m.Compact.Count,
+		CompactionRunning:  m.Compact.NumInProgress,
+		CompactionBytes:    m.Compact.InProgressBytes,
+		CompactionDebt:     m.Compact.EstimatedDebt,
+		CompactionDuration: m.Compact.Duration,
+	}, nil
 }
 
 // 统一的迭代器入口：支持前缀、起始键、正/反向
@@ -736,3 +735,8 @@ func (p *pebbleWriteBatch) ensureCapacity(extra int) error {
 func (p *pebbleDB) NewWriteBatch() common.WriteBatch {
 	return &pebbleWriteBatch{db: p.db, batch: p.db.NewBatch()}
 }
+
+// Checkpoint 在 dir 生成数据库的一致性快照，dir 不能已经存在
+func (p *pebbleDB) Checkpoint(dir string) error {
+	return p.db.Checkpoint(dir, pebble.WithFlushedWAL())
+}
@@ -0,0 +1,294 @@
+package indexer
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/indexer/runes/table"
+	"github.com/sat20-labs/indexer/share/event_stream"
+)
+
+/*
+推送给 rpc 订阅者的事件，只在有订阅者时才构造。
+区块中的事件在区块处理完成后发出，如果之后发生重组，会再发出一个 reorg 事件。
+*/
+
+func hasEventSubscribers() bool {
+	return event_stream.ShareEventHub.HasSubscribers()
+}
+
+func toEventAssets(output *common.TxOutput) []*event_stream.AssetAmount {
+	if len(output.Assets) == 0 {
+		return nil
+	}
+	result := make([]*event_stream.AssetAmount, 0, len(output.Assets))
+	for _, asset := range output.Assets {
+		result = append(result, &event_stream.AssetAmount{
+			Name:       asset.Name.String(),
+			Amount:     asset.Amount.String(),
+			BindingSat: asset.BindingSat,
+		})
+	}
+	return result
+}
+
+func toEventTxIO(output *common.TxOutputV2) *event_stream.TxIO {
+	return &event_stream.TxIO{
+		Utxo:    output.OutPointStr,
+		Address: output.GetAddress(),
+		Value:   output.OutValue.Value,
+		Assets:  toEventAssets(&output.TxOutput),
+	}
+}
+
+func appendAddress(addresses []string, seen map[string]bool, address string) []string {
+	if address == "" || seen[address] {
+		return addresses
+	}
+	seen[address] = true
+	return append(addresses, address)
+}
+
+// 在区块处理完成后调用，只能在跑数据的线程中调用
+func (s *IndexerMgr) publishBlockEvents(block *common.Block) {
+	defer func() { s.ordxEvents = nil }()
+	if !hasEventSubscribers() {
+		return
+	}
+	hub := event_stream.ShareEventHub
+
+	hub.Publish(&event_stream.Event{
+		Type:      event_stream.EVENT_NEW_BLOCK,
+		Time:      block.Timestamp.Unix(),
+		Height:    block.Height,
+		BlockHash: block.Hash,
+	})
+
+	for i, tx := range block.Transactions {
+		ev := &event_stream.Event{
+			Type:      event_stream.EVENT_TRANSFER,
+			Height:    block.Height,
+			BlockHash: block.Hash,
+			TxId:      tx.TxId,
+		}
+		seen := make(map[string]bool)
+		if i != 0 {
+			for _, input := range tx.Inputs {
+				io := toEventTxIO(&input.TxOutputV2)
+				ev.Inputs = append(ev.Inputs, io)
+				ev.Addresses = appendAddress(ev.Addresses, seen, io.Address)
+			}
+		}
+		for _, output := range tx.Outputs {
+			io := toEventTxIO(output)
+			if io.Utxo == "" {
+				io.Utxo = fmt.Sprintf("%s:%d", tx.TxId, output.TxOutIndex)
+			}
+			ev.Outputs = append(ev.Outputs, io)
+			ev.Addresses = appendAddress(ev.Addresses, seen, io.Address)
+		}
+		hub.Publish(ev)
+	}
+
+	s.publishOrdxEvents(block)
+	if s.isProtocolActive(config.PROTOCOL_BRC20, block.Height) {
+		for _, action := range s.brc20Indexer.GetHolderActionsWithHeight(block.Height) {
+			s.publishBrc20Action(block, action)
+		}
+	}
+	if s.isProtocolActive(config.PROTOCOL_RUNES, block.Height) {
+		for _, activity := range s.RunesIndexer.GetActivitiesWithHeight(block.Height) {
+			s.publishRunesActivity(block, activity)
+		}
+	}
+	if s.isProtocolActive(config.PROTOCOL_ATOM, block.Height) {
+		s.publishAtomEvents(block)
+	}
+}
+
+func (s *IndexerMgr) addressesOfIds(ids ...uint64) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if id == common.INVALID_ID {
+			continue
+		}
+		address, err := s.base.GetAddressByID(id)
+		if err == nil {
+			result = appendAddress(result, seen, address)
+		}
+	}
+	return result
+}
+
+func (s *IndexerMgr) publishBrc20Action(block *common.Block, action *common.BRC20ActionHistory) {
+	var typ string
+	switch action.Action {
+	case common.BRC20_Action_InScribe_Deploy:
+		typ = event_stream.EVENT_DEPLOY
+	case common.BRC20_Action_InScribe_Mint:
+		typ = event_stream.EVENT_MINT
+	case common.BRC20_Action_InScribe_Transfer:
+		typ = event_stream.EVENT_INSCRIBE_TRANSFER
+	case common.BRC20_Action_Transfer:
+		typ = event_stream.EVENT_TRANSFER
+	default:
+		return
+	}
+
+	ev := &event_stream.Event{
+		Type:      typ,
+		Height:    block.Height,
+		BlockHash: block.Hash,
+		Protocol:  common.PROTOCOL_NAME_BRC20,
+		Ticker:    action.Ticker,
+		Amount:    action.Amount.String(),
+	}
+	if action.TxIndex >= 0 && action.TxIndex < len(block.Transactions) {
+		ev.TxId = block.Transactions[action.TxIndex].TxId
+	}
+	ev.Addresses = s.addressesOfIds(action.FromAddr, action.ToAddr)
+	event_stream.ShareEventHub.Publish(ev)
+}
+
+// 只推送部署和铸造，转账在 EVENT_TRANSFER 的输入输出资产中
+func (s *IndexerMgr) publishRunesActivity(block *common.Block, activity *table.RuneActivity) {
+	var typ string
+	switch activity.Type {
+	case table.RUNE_ACTIVITY_ETCH:
+		typ = event_stream.EVENT_DEPLOY
+	case table.RUNE_ACTIVITY_MINT:
+		typ = event_stream.EVENT_MINT
+	default:
+		return
+	}
+	info := s.RunesIndexer.GetRuneInfoWithId(activity.RuneId.String())
+	if info == nil {
+		return
+	}
+	event_stream.ShareEventHub.Publish(&event_stream.Event{
+		Type:      typ,
+		Height:    block.Height,
+		BlockHash: block.Hash,
+		TxId:      activity.TxId,
+		Protocol:  common.PROTOCOL_NAME_RUNES,
+		Ticker:    info.Name,
+		Amount:    common.NewDecimalFromUint128(activity.Amount.Value, int(info.Divisibility)).String(),
+		Addresses: s.addressesOfIds(activity.AddressId),
+	})
+}
+
+func (s *IndexerMgr) publishAtomEvents(block *common.Block) {
+	for _, ticker := range s.atomIndexer.GetDeploysWithHeight(block.Height) {
+		event_stream.ShareEventHub.Publish(&event_stream.Event{
+			Type:      event_stream.EVENT_DEPLOY,
+			Height:    block.Height,
+			BlockHash: block.Hash,
+			TxId:      ticker.DeployTx,
+			Protocol:  common.PROTOCOL_NAME_ATOM,
+			Ticker:    ticker.Name,
+		})
+	}
+	for _, action := range s.atomIndexer.GetMintActionsWithHeight(block.Height) {
+		event_stream.ShareEventHub.Publish(&event_stream.Event{
+			Type:      event_stream.EVENT_MINT,
+			Height:    block.Height,
+			BlockHash: block.Hash,
+			TxId:      action.TxId,
+			Protocol:  common.PROTOCOL_NAME_ATOM,
+			Ticker:    action.Ticker,
+			Amount:    fmt.Sprintf("%d", action.Amount),
+			Addresses: s.addressesOfIds(action.ToAddr),
+		})
+	}
+}
+
+// 在 ordinals 阶段中调用，事件先放入队列，在 mergeBlock 中跟其他事件一起按顺序发出
+func (s *IndexerMgr) queueOrdxEvent(typ string, ticker string, amount int64, nft *common.Nft) {
+	if !hasEventSubscribers() {
+		return
+	}
+	ev := &event_stream.Event{
+		Type:     typ,
+		Height:   int(nft.Base.BlockHeight),
+		TxId:     nft.Base.InscriptionId,
+		Protocol: common.PROTOCOL_NAME_ORDX,
+		Ticker:   ticker,
+	}
+	if typ == event_stream.EVENT_MINT {
+		ev.Amount = fmt.Sprintf("%d", amount)
+	}
+	if len(ev.TxId) > 64 {
+		ev.TxId = ev.TxId[:64]
+	}
+	if address, err := s.base.GetAddressByID(nft.OwnerAddressId); err == nil {
+		ev.Addresses = []string{address}
+	}
+	s.ordxEvents = append(s.ordxEvents, ev)
+}
+
+func (s *IndexerMgr) publishOrdxEvents(block *common.Block) {
+	for _, ev := range s.ordxEvents {
+		ev.BlockHash = block.Hash
+		event_stream.ShareEventHub.Publish(ev)
+	}
+}
+
+func (s *IndexerMgr) publishReorgEvent(height int) {
+	if !hasEventSubscribers() {
+		return
+	}
+	event_stream.ShareEventHub.Publish(&event_stream.Event{
+		Type:   event_stream.EVENT_REORG,
+		Height: height,
+	})
+}
+
+// 交易进入内存池
+func (p *MiniMemPool) publishMempoolTx(tx *wire.MsgTx, spent []string) {
+	if !hasEventSubscribers() {
+		return
+	}
+	ev := &event_stream.Event{
+		Type: event_stream.EVENT_MEMPOOL_TX,
+		TxId: tx.TxID(),
+	}
+	for _, outpoint := range spent {
+		ev.Inputs = append(ev.Inputs, &event_stream.TxIO{Utxo: outpoint})
+	}
+	seen := make(map[string]bool)
+	for i, txOut := range tx.TxOut {
+		address, _ := common.PkScriptToAddr(txOut.PkScript, instance.GetChainParam())
+		ev.Outputs = append(ev.Outputs, &event_stream.TxIO{
+			Utxo:    fmt.Sprintf("%s:%d", ev.TxId, i),
+			Address: address,
+			Value:   txOut.Value,
+		})
+		ev.Addresses = appendAddress(ev.Addresses, seen, address)
+	}
+	event_stream.ShareEventHub.Publish(ev)
+}
+
+// 内存池中的交易花费了一个已经确认的utxo
+func (p *MiniMemPool) publishMempoolSpend(txID string, output *common.TxOutput) {
+	if !hasEventSubscribers() {
+		return
+	}
+	address, _ := p.addressForOutput(output)
+	ev := &event_stream.Event{
+		Type: event_stream.EVENT_MEMPOOL_SPEND,
+		TxId: txID,
+		Inputs: []*event_stream.TxIO{{
+			Utxo:    output.OutPointStr,
+			Address: address,
+			Value:   output.OutValue.Value,
+			Assets:  toEventAssets(output),
+		}},
+	}
+	if address != "" {
+		ev.Addresses = []string{address}
+	}
+	event_stream.ShareEventHub.Publish(ev)
+}
@@ -0,0 +1,46 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/share/event_stream"
+)
+
+func TestPublishAssetEvents(t *testing.T) {
+	chain := newPipelineTestChain(t)
+	sub := event_stream.ShareEventHub.Subscribe(event_stream.NewFilter(
+		[]string{event_stream.EVENT_DEPLOY, event_stream.EVENT_MINT, event_stream.EVENT_INSCRIBE_TRANSFER}, nil, nil, nil), 0)
+	defer sub.Close()
+
+	h := newPipelineTestHarness(t, chain, false)
+	defer h.Close()
+	if err := h.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]bool)
+	for len(sub.C) > 0 {
+		ev := <-sub.C
+		got[ev.Protocol+" "+ev.Type] = true
+		if ev.Protocol == common.PROTOCOL_NAME_RUNES && ev.Type == event_stream.EVENT_MINT && ev.Amount != "100" {
+			t.Errorf("runes mint amount %s", ev.Amount)
+		}
+		// ordx 的事件在 mergeBlock 中发出，带有区块的 hash
+		if ev.Protocol == common.PROTOCOL_NAME_ORDX && ev.BlockHash == "" {
+			t.Errorf("ordx %s event without block hash", ev.Type)
+		}
+	}
+	for _, want := range []string{
+		"brc20 deploy", "brc20 mint", "brc20 inscribe_transfer",
+		"ordx deploy", "ordx mint",
+		"runes deploy", "runes mint",
+	} {
+		if !got[want] {
+			t.Errorf("missing %s event, got %v", want, got)
+		}
+	}
+	if sub.Dropped() != 0 {
+		t.Errorf("dropped %d events", sub.Dropped())
+	}
+}
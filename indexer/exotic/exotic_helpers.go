@@ -1,6 +1,7 @@
 package exotic
 
 import (
+	"sort"
 	"time"
 
 	"github.com/sat20-labs/indexer/common"
@@ -131,8 +132,14 @@ func (p *ExoticIndexer) generateRarityAssetWithBlock(block *common.Block, coinba
 		return
 	}
 
-	for name, blocks := range _defaultAssetInBlockSubSidy {
-		_, ok := blocks[height]
+	// 按名字的顺序处理，新 ticker 的 Id 不受 map 遍历顺序的影响
+	names := make([]string, 0, len(_defaultAssetInBlockSubSidy))
+	for name := range _defaultAssetInBlockSubSidy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, ok := _defaultAssetInBlockSubSidy[name][height]
 		if ok {
 
 			asset := common.AssetInfo{
@@ -7,16 +7,22 @@ import (
 
 
 func (b *IndexerMgr) GetExotics(utxoId uint64) map[string]common.AssetOffsets {
-	return b.exotic.GetAssetsWithUtxo(utxoId)
+	return b.getExoticsWithUtxo(utxoId)
 }
 
 
 func (b *IndexerMgr) GetExoticsWithType(utxoId uint64, typ string) common.AssetOffsets {
+	if b.exotic == nil {
+		return nil
+	}
 	return b.exotic.GetExoticsWithType(utxoId, typ)
 }
 
 
 func (b *IndexerMgr) getExoticsWithUtxo(utxoId uint64) map[string]common.AssetOffsets {
+	if b.exotic == nil {
+		return nil
+	}
 	return b.exotic.GetAssetsWithUtxo(utxoId)
 }
 
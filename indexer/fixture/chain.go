@@ -0,0 +1,371 @@
+package fixture
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OLProtocol/go-bitcoind"
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/share/bitcoin_rpc"
+)
+
+/*
+测试用的区块链
+
+按脚本生成区块，代替 bitcoind 给索引器提供区块，不需要网络和主网数据。
+Chain 实现了 bitcoin_rpc.BitcoinRPC 中跟区块相关的接口，Install 后 BaseIndexer 通过默认的
+RpcBlockSource 读取这里的区块；也可以用 base.SetBlockSource(chain.BlockSource()) 设置。
+
+	chain := fixture.NewChain(&chaincfg.MainNetParams)
+	defer chain.Install()()
+	b0 := chain.Mine(fixture.PkScript("alice"))
+	tx := fixture.NewTx([]wire.OutPoint{fixture.OutPoint(b0.Transactions[0], 0)},
+		fixture.Output(fixture.PkScript("bob"), 1000))
+	chain.Mine(fixture.PkScript("miner"), tx)
+	chain.Reorg(1) // 下一个区块替换高度 1 的区块
+
+分叉后新链的高度要不低于原来的高度，否则 BaseIndexer 查询不存在的区块 hash 时会反复重试。
+*/
+
+var ErrNotSupported = errors.New("not supported by fixture chain")
+
+type Chain struct {
+	mutex  sync.RWMutex
+	params *chaincfg.Params
+	blocks []*wire.MsgBlock
+	hashes map[string]int // 当前链上的区块 hash -> 高度
+	time   time.Time
+	nonce  uint32 // 分叉后重新生成的区块 hash 不同
+}
+
+func NewChain(params *chaincfg.Params) *Chain {
+	return &Chain{
+		params: params,
+		hashes: make(map[string]int),
+		time:   time.Unix(1700000000, 0),
+	}
+}
+
+// Install 替换 bitcoin_rpc.ShareBitconRpc，返回恢复的函数。这是全局状态，使用的测试不能并行
+func (p *Chain) Install() func() {
+	previous := bitcoin_rpc.ShareBitconRpc
+	bitcoin_rpc.ShareBitconRpc = p
+	return func() {
+		bitcoin_rpc.ShareBitconRpc = previous
+	}
+}
+
+func (p *Chain) Params() *chaincfg.Params {
+	return p.params
+}
+
+// Height 最高区块的高度，没有区块时是 -1
+func (p *Chain) Height() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return len(p.blocks) - 1
+}
+
+func (p *Chain) Block(height int) *wire.MsgBlock {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if height < 0 || height >= len(p.blocks) {
+		return nil
+	}
+	return p.blocks[height]
+}
+
+// Mine 生成下一个区块，coinbase 把奖励和手续费都给 coinbaseScript。
+// 输入必须是链上已有的输出，手续费按输入输出的差计算
+func (p *Chain) Mine(coinbaseScript []byte, txs ...*wire.MsgTx) *wire.MsgBlock {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	height := len(p.blocks)
+	var fee int64
+	for _, tx := range txs {
+		for _, in := range tx.TxIn {
+			out := p.findOutput(in.PreviousOutPoint)
+			if out == nil {
+				panic(fmt.Sprintf("fixture: input %s not found", in.PreviousOutPoint))
+			}
+			fee += out.Value
+		}
+		for _, out := range tx.TxOut {
+			fee -= out.Value
+		}
+	}
+	if fee < 0 {
+		panic(fmt.Sprintf("fixture: outputs exceed inputs in block %d", height))
+	}
+
+	p.nonce++
+	sigScript, err := txscript.NewScriptBuilder().
+		AddInt64(int64(height)).AddInt64(int64(p.nonce)).Script()
+	if err != nil {
+		panic(err)
+	}
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  sigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(blockchain.CalcBlockSubsidy(int32(height), p.params)+fee, coinbaseScript))
+
+	var prevHash chainhash.Hash
+	if height > 0 {
+		prevHash = p.blocks[height-1].BlockHash()
+	}
+	p.time = p.time.Add(10 * time.Minute)
+	block := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:   4,
+			PrevBlock: prevHash,
+			Timestamp: p.time,
+			Bits:      p.params.PowLimitBits,
+			Nonce:     p.nonce,
+		},
+		Transactions: append([]*wire.MsgTx{coinbase}, txs...),
+	}
+	utxos := make([]*btcutil.Tx, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		utxos = append(utxos, btcutil.NewTx(tx))
+	}
+	block.Header.MerkleRoot = blockchain.CalcMerkleRoot(utxos, false)
+
+	p.blocks = append(p.blocks, block)
+	p.hashes[block.BlockHash().String()] = height
+	return block
+}
+
+// Reorg 删除 height 和之后的区块，接着生成的区块从 height 开始
+func (p *Chain) Reorg(height int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if height < 0 || height > len(p.blocks) {
+		panic(fmt.Sprintf("fixture: reorg at %d, chain height %d", height, len(p.blocks)-1))
+	}
+	for _, block := range p.blocks[height:] {
+		delete(p.hashes, block.BlockHash().String())
+	}
+	p.blocks = p.blocks[:height]
+}
+
+func (p *Chain) findOutput(outpoint wire.OutPoint) *wire.TxOut {
+	for _, block := range p.blocks {
+		for _, tx := range block.Transactions {
+			if tx.TxHash() == outpoint.Hash {
+				if int(outpoint.Index) < len(tx.TxOut) {
+					return tx.TxOut[outpoint.Index]
+				}
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Chain) findTx(txid string) *wire.MsgTx {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	for _, block := range p.blocks {
+		for _, tx := range block.Transactions {
+			if tx.TxHash().String() == txid {
+				return tx
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Chain) rawBlock(height int) ([]byte, error) {
+	block := p.Block(height)
+	if block == nil {
+		return nil, fmt.Errorf("fixture: no block at %d", height)
+	}
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// BlockSource 实现 base.BlockSource
+func (p *Chain) BlockSource() *BlockSource {
+	return &BlockSource{chain: p}
+}
+
+type BlockSource struct {
+	chain *Chain
+}
+
+func (p *BlockSource) Name() string {
+	return "fixture"
+}
+
+func (p *BlockSource) GetRawBlock(height int) ([]byte, error) {
+	return p.chain.rawBlock(height)
+}
+
+func (p *BlockSource) Close() error {
+	return nil
+}
+
+// bitcoin_rpc.BitcoinRPC
+
+func (p *Chain) GetBlockCount() (uint64, error) {
+	height := p.Height()
+	if height < 0 {
+		return 0, fmt.Errorf("fixture: empty chain")
+	}
+	return uint64(height), nil
+}
+
+func (p *Chain) GetBestBlockHash() (string, error) {
+	return p.GetBlockHash(uint64(p.Height()))
+}
+
+func (p *Chain) GetBlockHash(height uint64) (string, error) {
+	block := p.Block(int(height))
+	if block == nil {
+		return "", fmt.Errorf("fixture: no block at %d", height)
+	}
+	return block.BlockHash().String(), nil
+}
+
+func (p *Chain) GetRawBlock(blockHash string) (string, error) {
+	p.mutex.RLock()
+	height, ok := p.hashes[blockHash]
+	p.mutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("fixture: block %s not found", blockHash)
+	}
+	buf, err := p.rawBlock(height)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (p *Chain) GetRawTx(txid string) (string, error) {
+	tx := p.findTx(txid)
+	if tx == nil {
+		return "", fmt.Errorf("fixture: tx %s not found", txid)
+	}
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+func (p *Chain) GetBlockHeader(blockhash string) (*bitcoind.BlockHeader, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *Chain) TestTx(signedTxs []string) ([]bitcoind.TransactionTestResult, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *Chain) SendTx(signedTxHex string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (p *Chain) GetTx(txid string) (*bitcoind.RawTransaction, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *Chain) GetTxOut(txid string, vout uint32, includeMempool bool) (*bitcoind.UTransactionOut, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *Chain) GetMemPoolEntry(txid string) (*bitcoind.MemPoolEntry, error) {
+	return nil, ErrNotSupported
+}
+
+func (p *Chain) GetMemPool() ([]string, error) {
+	return nil, nil
+}
+
+func (p *Chain) EstimateSmartFeeWithMode(minconf int, mode string) (*bitcoind.EstimateSmartFeeResult, error) {
+	return nil, ErrNotSupported
+}
+
+// 构造交易
+
+// PkScript 由 seed 生成固定的 P2WPKH 脚本
+func PkScript(seed string) []byte {
+	hash := sha256.Sum256([]byte(seed))
+	script, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(hash[:20]).Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// Address PkScript(seed) 对应的地址
+func Address(seed string, params *chaincfg.Params) string {
+	hash := sha256.Sum256([]byte(seed))
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(hash[:20], params)
+	if err != nil {
+		panic(err)
+	}
+	return addr.EncodeAddress()
+}
+
+func OutPoint(tx *wire.MsgTx, vout int) wire.OutPoint {
+	return wire.OutPoint{Hash: tx.TxHash(), Index: uint32(vout)}
+}
+
+func Output(pkScript []byte, value int64) *wire.TxOut {
+	return wire.NewTxOut(value, pkScript)
+}
+
+// NewTx 花费 inputs，输出到 outputs，没有签名
+func NewTx(inputs []wire.OutPoint, outputs ...*wire.TxOut) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for _, in := range inputs {
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: in.Hash, Index: in.Index}, nil, nil))
+	}
+	for _, out := range outputs {
+		tx.AddTxOut(out)
+	}
+	return tx
+}
+
+// InscriptionWitness 把铭文信封放在 tapscript 中，第一个元素是空的签名，最后是控制块，都不做验证
+func InscriptionWitness(contentType string, body []byte) wire.TxWitness {
+	builder := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("ord")).
+		// 字段的 tag 要用 OP_PUSHBYTES_1，OP_1 会被 ord 当作 cursed
+		AddOp(txscript.OP_DATA_1).AddOp(1).
+		AddData([]byte(contentType)).
+		AddOp(txscript.OP_0)
+	for len(body) > 0 {
+		n := len(body)
+		if n > txscript.MaxScriptElementSize {
+			n = txscript.MaxScriptElementSize
+		}
+		builder.AddData(body[:n])
+		body = body[n:]
+	}
+	script, err := builder.AddOp(txscript.OP_ENDIF).Script()
+	if err != nil {
+		panic(err)
+	}
+	controlBlock := append([]byte{byte(txscript.BaseLeafVersion)}, make([]byte, 32)...)
+	return wire.TxWitness{make([]byte, 64), script, controlBlock}
+}
@@ -13,4 +13,5 @@ const (
 	DB_PREFIX_FREEZE_STATE   = "fz-"
 	DB_PREFIX_IMAGE          = "img-"
 	DB_PREFIX_TICKER_INFO    = "ti-"
+	DB_PREFIX_BALANCE        = "bh-"
 )
@@ -0,0 +1,49 @@
+package ft
+
+import (
+	"strings"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// 第一次启用时，以当前的持有者作为余额历史的起点
+func (s *FTIndexer) initBalanceHistory() {
+	height := s.nftIndexer.GetBaseIndexer().GetHeight()
+	s.balanceHistory.Init(s.db, height, func(add func(ticker string, addressId uint64, amount *common.Decimal)) {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		for ticker := range s.utxoMap {
+			for addressId, amount := range s.getHolderAndAmountWithTick(ticker) {
+				add(ticker, addressId, common.NewDecimal(amount, 0))
+			}
+		}
+	})
+}
+
+// 区块处理完后调用，记录余额有变化的地址的最新余额
+func (p *FTIndexer) commitBalanceHistory(height int) {
+	holders := make(map[string]map[uint64]int64)
+	p.balanceHistory.Commit(height, func(ticker string, addressId uint64) *common.Decimal {
+		holderMap, ok := holders[ticker]
+		if !ok {
+			holderMap = p.getHolderAndAmountWithTick(ticker)
+			holders[ticker] = holderMap
+		}
+		return common.NewDecimal(holderMap[addressId], 0)
+	})
+}
+
+// 获取该ticker在某个高度的holder和持有的数量
+func (p *FTIndexer) GetHoldersWithTickAtHeight(tickerName string, height int) (map[uint64]*common.Decimal, error) {
+	return p.balanceHistory.GetHolders(p.db, strings.ToLower(tickerName), height)
+}
+
+// 获取某个地址在某个高度的资产 return: ticker->amount
+func (p *FTIndexer) GetAssetSummaryByAddressAtHeight(addressId uint64, height int) (map[string]*common.Decimal, error) {
+	return p.balanceHistory.GetAssets(p.db, addressId, height)
+}
+
+// 该ticker在某个高度的holder第一次持有的高度
+func (p *FTIndexer) GetHolderFirstSeenAtHeight(tickerName string, height int) (map[uint64]int, error) {
+	return p.balanceHistory.GetFirstSeen(p.db, strings.ToLower(tickerName), height)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/sat20-labs/indexer/common"
+	inCommon "github.com/sat20-labs/indexer/indexer/common"
 	"github.com/sat20-labs/indexer/indexer/db"
 	"github.com/sat20-labs/indexer/indexer/exotic"
 	"github.com/sat20-labs/indexer/indexer/nft"
@@ -47,6 +48,8 @@ type FTIndexer struct {
 	freezeTouched   map[string]*common.FreezeState            // 当前块内新增/更新的冻结状态，供 UpdateDB 增量落库
 	freezeDeleted   map[string]*common.FreezeState            // 当前块内删除的冻结状态，供 UpdateDB 增量删库
 
+	balanceHistory *inCommon.BalanceHistory // 每个区块的余额变化，用于查询历史高度的余额和持有者
+
 	// 校验数据，不需要保存
 	holderMapInPrevBlock map[uint64]int64
 
@@ -67,11 +70,17 @@ func NewOrdxIndexer(db common.KVDB) *FTIndexer {
 		enableHeight = 28883
 	}
 	return &FTIndexer{
-		db:           db,
-		enableHeight: enableHeight,
+		db:             db,
+		enableHeight:   enableHeight,
+		balanceHistory: inCommon.NewBalanceHistory(DB_PREFIX_BALANCE),
 	}
 }
 
+// SetEnableHeight 修改开始处理区块的高度，在 Init 之前调用
+func (s *FTIndexer) SetEnableHeight(height int) {
+	s.enableHeight = height
+}
+
 func (s *FTIndexer) setDBVersion() {
 	err := db.SetRawValueToDB([]byte(ORDX_DB_VER_KEY), []byte(ORDX_DB_VERSION), s.db)
 	if err != nil {
@@ -96,6 +105,7 @@ func (s *FTIndexer) Clone(nftIndexer *nft.NftIndexer) *FTIndexer {
 
 	newInst := NewOrdxIndexer(s.db)
 	newInst.nftIndexer = nftIndexer
+	newInst.enableHeight = s.enableHeight
 
 	newInst.holderActionList = make([]*HolderAction, len(s.holderActionList))
 	copy(newInst.holderActionList, s.holderActionList)
@@ -150,6 +160,7 @@ func (s *FTIndexer) Clone(nftIndexer *nft.NftIndexer) *FTIndexer {
 		newInst.freezeAuthoritySnapshot[key] = value
 	}
 	newInst.reloadRequestHeight = s.reloadRequestHeight
+	newInst.balanceHistory = s.balanceHistory.Clone()
 
 	newInst.tickerAdded = make(map[string]*common.Ticker, len(s.tickerAdded))
 	for key, value := range s.tickerAdded {
@@ -256,6 +267,7 @@ func (s *FTIndexer) Subtract(another *FTIndexer) {
 	s.reloadFreezeDirectives = make(map[string]*common.FreezeDirective)
 	s.freezeAuthoritySnapshot = make(map[string]uint64)
 	s.reloadRequestHeight = 0
+	s.balanceHistory.Subtract(another.balanceHistory)
 
 	// 不需要更新 holderInfo 和 utxoMap
 }
@@ -312,6 +324,8 @@ func (s *FTIndexer) Init(nftIndexer *nft.NftIndexer) {
 		s.mutex.Unlock()
 	}
 
+	s.initBalanceHistory()
+
 	s.CheckSelf()
 
 	elapsed := time.Since(startTime).Milliseconds()
@@ -105,6 +105,7 @@ func (p *FTIndexer) addHolder(utxo *common.TxOutputV2, ticker string, assetInfo
 	}
 
 	amt := info.AddTickerAsset(ticker, assetInfo)
+	p.balanceHistory.Touch(ticker, info.AddressId)
 	utxovalue, ok := p.utxoMap[ticker]
 	if !ok {
 		utxovalue = make(map[uint64]int64, 0)
@@ -259,6 +260,7 @@ func (p *FTIndexer) handleTxUnbind(tx *common.Transaction) {
 
 		holder.RemoveTickerAsset(ticker, assetInfo)
 		p.deleteUtxoMap(ticker, target.UtxoId)
+		p.balanceHistory.Touch(ticker, holder.AddressId)
 
 		action := &HolderAction{
 			UtxoId:    target.UtxoId,
@@ -451,6 +453,7 @@ func (p *FTIndexer) UpdateTransfer(block *common.Block, coinbase []*common.Range
 				delete(p.holderInfo, utxo)
 				for name := range holder.Tickers {
 					p.deleteUtxoMap(name, utxo)
+					p.balanceHistory.Touch(name, holder.AddressId)
 				}
 			}
 
@@ -492,6 +495,7 @@ func (p *FTIndexer) UpdateTransfer(block *common.Block, coinbase []*common.Range
 	}
 
 	p.actionBufferMap = make(map[uint64][]*ActionInfo)
+	p.commitBalanceHistory(block.Height)
 
 	common.Log.Infof("FTIndexer->UpdateTransfer loop %d in %v", len(block.Transactions), time.Since(startTime))
 
@@ -666,6 +670,7 @@ func (p *FTIndexer) UpdateDB() {
 			common.Log.Infof("Error deleting freeze state %s: %v\n", state.Ticker, err)
 		}
 	}
+	p.balanceHistory.UpdateDB(wb)
 	//common.Log.Infof("OrdxIndexer->UpdateDB->SetDB(ticker.HolderActionList(%d), cost: %v",len(p.holderActionList), time.Since(startTime))
 
 	err := wb.Flush()
@@ -5,27 +5,43 @@ import (
 	"strings"
 
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
 )
 
 // 检查一个tick中的哪些nft已经被分拆
 func (b *IndexerMgr) GetSplittedInscriptionsWithTick(tickerName string) []string {
+	if b.ftIndexer == nil {
+		return nil
+	}
 	return b.ftIndexer.GetSplittedInscriptionsWithTick(tickerName)
 }
 
 func (b *IndexerMgr) GetMintPermissionInfo(ticker, address string) int64 {
+	if b.ftIndexer == nil {
+		return 0
+	}
 	ticker = strings.ToLower(ticker)
 	return b.getMintAmount(ticker, b.GetAddressId(address))
 }
 
 func (b *IndexerMgr) GetTickerMap() (map[string]*common.Ticker, error) {
+	if b.ftIndexer == nil {
+		return nil, fmt.Errorf("protocol %s is disabled", config.PROTOCOL_FT)
+	}
 	return b.ftIndexer.GetTickerMap()
 }
 
 func (b *IndexerMgr) GetOrdxTickerMapV2(start, limit int) ([]string, int) {
+	if b.ftIndexer == nil {
+		return nil, 0
+	}
 	return b.ftIndexer.GetTickersWithRange(start, limit)
 }
 
 func (b *IndexerMgr) GetTicker(ticker string) *common.Ticker {
+	if b.ftIndexer == nil {
+		return nil
+	}
 	return b.ftIndexer.GetTicker(ticker)
 }
 
@@ -33,9 +49,11 @@ func (p *IndexerMgr) GetTickerV2(tickerName string, typ string) *common.TickerIn
 	var ticker *common.Ticker
 	switch typ {
 	case common.ASSET_TYPE_FT:
-		ticker = p.ftIndexer.GetTicker(tickerName)
+		ticker = p.GetTicker(tickerName)
 	case common.ASSET_TYPE_EXOTIC:
-		ticker = p.exotic.GetTicker(tickerName)
+		if p.exotic != nil {
+			ticker = p.exotic.GetTicker(tickerName)
+		}
 	}
 	if ticker == nil {
 		return nil
@@ -78,15 +96,24 @@ func (p *IndexerMgr) GetTickerV2(tickerName string, typ string) *common.TickerIn
 }
 
 func (b *IndexerMgr) GetMintAmount(tickerName string) (int64, int64) {
+	if b.ftIndexer == nil {
+		return 0, 0
+	}
 	return b.ftIndexer.GetMintAmount(tickerName)
 }
 
 func (b *IndexerMgr) GetOrdxDBVer() string {
+	if b.ftIndexer == nil {
+		return ""
+	}
 	return b.ftIndexer.GetDBVersion()
 }
 
 func (p *IndexerMgr) GetFTMintHistoryWithAddress(addressId uint64, ticker string, start int, limit int) ([]*common.InscribeBaseContent, int) {
 	result := make([]*common.InscribeBaseContent, 0)
+	if p.ftIndexer == nil {
+		return result, 0
+	}
 	infos, total := p.ftIndexer.GetMintHistoryWithAddress(addressId, ticker, start, limit)
 	for _, info := range infos {
 		mint := p.ftIndexer.GetMint(info.InscriptionId)
@@ -96,3 +123,10 @@ func (p *IndexerMgr) GetFTMintHistoryWithAddress(addressId uint64, ticker string
 	}
 	return result, total
 }
+
+func (b *IndexerMgr) consumeFtReloadRequest() (int, []*common.FreezeDirective) {
+	if b.ftIndexer == nil {
+		return 0, nil
+	}
+	return b.ftIndexer.ConsumeReloadRequest()
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
 	base_indexer "github.com/sat20-labs/indexer/indexer/base"
 	"github.com/sat20-labs/indexer/indexer/brc20"
 	indexer "github.com/sat20-labs/indexer/indexer/common"
@@ -16,17 +17,106 @@ import (
 	"github.com/sat20-labs/indexer/indexer/ord"
 	ordCommon "github.com/sat20-labs/indexer/indexer/ord/common"
 	"github.com/sat20-labs/indexer/indexer/ord/ord0_14_1"
+	"github.com/sat20-labs/indexer/share/event_stream"
 	"github.com/sat20-labs/indexer/share/bitcoin_rpc"
+	"github.com/sat20-labs/indexer/share/metrics"
 )
 
 func (s *IndexerMgr) processOrdProtocol(block *common.Block, coinbase []*common.Range) {
-	s.exotic.UpdateTransfer(block, coinbase) // 生成稀有资产，为ordx协议做准备
+	measureStartTime := time.Now()
+	var txFlows []*txFlowRecord
+	if s.shouldRecordTxFlows(block.Height) {
+		txFlows = s.collectTxFlowInputs(block)
+	}
+	// 依赖关系见 pipeline.go
+	s.runStages(
+		blockStage{"ordinals", func() { s.processOrdinals(block, coinbase) }},
+		blockStage{"runes", func() {
+			if s.isProtocolActive(config.PROTOCOL_RUNES, block.Height) {
+				stageStartTime := time.Now()
+				s.RunesIndexer.UpdateTransfer(block)
+				metrics.ObserveStage(metrics.STAGE_RUNES, stageStartTime)
+			}
+		}},
+		blockStage{"atom", func() {
+			if s.isProtocolActive(config.PROTOCOL_ATOM, block.Height) {
+				stageStartTime := time.Now()
+				s.atomIndexer.UpdateTransfer(block)
+				metrics.ObserveStage(metrics.STAGE_ATOM, stageStartTime)
+			}
+		}},
+		blockStage{"sat", func() {
+			if s.satIndexer != nil {
+				s.satIndexer.UpdateTransfer(block, coinbase)
+			}
+		}},
+	)
+	s.mergeBlock(block, txFlows)
 
-	if block.Height < s.ordFirstHeight {
-		return
+	common.Log.Infof("processOrdProtocol %d is done, cost: %v", block.Height, time.Since(measureStartTime))
+}
+
+// mergeBlock 所有协议处理完区块后，在当前 goroutine 按固定顺序汇总各协议的结果：
+// 先记录交易的资产流向，再发出事件。这时区块中的资产已经确定，跟串行还是并行处理无关
+func (s *IndexerMgr) mergeBlock(block *common.Block, txFlows []*txFlowRecord) {
+	if txFlows != nil {
+		s.recordTxFlows(block, txFlows)
+	}
+	s.publishBlockEvents(block)
+}
+
+// exotic -> 铭文 -> nft -> (ns, brc20, ft)
+func (s *IndexerMgr) processOrdinals(block *common.Block, coinbase []*common.Range) {
+	if s.isProtocolActive(config.PROTOCOL_EXOTIC, block.Height) {
+		stageStartTime := time.Now()
+		s.exotic.UpdateTransfer(block, coinbase) // 生成稀有资产，为ordx协议做准备
+		metrics.ObserveStage(metrics.STAGE_EXOTIC, stageStartTime)
 	}
 
 	//detectOrdMap := make(map[string]int, 0)
+	if s.isProtocolActive(config.PROTOCOL_NFT, block.Height) {
+		s.processInscriptions(block, coinbase)
+	}
+
+	if s.isProtocolActive(config.PROTOCOL_FT, block.Height) {
+		freezeAuthority := s.ftIndexer.BuildFreezeAuthoritySnapshot()
+		s.ftIndexer.SetFreezeAuthoritySnapshot(freezeAuthority)
+		s.prepareFreezeLookahead(block.Height, freezeAuthority)
+	}
+
+	if s.isProtocolActive(config.PROTOCOL_NFT, block.Height) {
+		stageStartTime := time.Now()
+		s.nft.UpdateTransfer(block, coinbase)
+		metrics.ObserveStage(metrics.STAGE_NFT, stageStartTime)
+	}
+
+	// 都只读取 nft 的数据
+	s.runStages(
+		blockStage{"ns", func() {
+			if s.isProtocolActive(config.PROTOCOL_NS, block.Height) {
+				stageStartTime := time.Now()
+				s.ns.UpdateTransfer(block)
+				metrics.ObserveStage(metrics.STAGE_NS, stageStartTime)
+			}
+		}},
+		blockStage{"brc20", func() {
+			if s.isProtocolActive(config.PROTOCOL_BRC20, block.Height) {
+				stageStartTime := time.Now()
+				s.brc20Indexer.UpdateTransfer(block, coinbase) // 由nftindexer内部调用过去
+				metrics.ObserveStage(metrics.STAGE_BRC20, stageStartTime)
+			}
+		}},
+		blockStage{"ft", func() {
+			if s.isProtocolActive(config.PROTOCOL_FT, block.Height) {
+				stageStartTime := time.Now()
+				s.ftIndexer.UpdateTransfer(block, coinbase) // 依赖前面生成的稀有资产
+				metrics.ObserveStage(metrics.STAGE_FT, stageStartTime)
+			}
+		}},
+	)
+}
+
+func (s *IndexerMgr) processInscriptions(block *common.Block, coinbase []*common.Range) {
 	measureStartTime := time.Now()
 	//common.Log.Info("processOrdProtocol ...")
 	count := 0
@@ -57,23 +147,8 @@ func (s *IndexerMgr) processOrdProtocol(block *common.Block, coinbase []*common.
 		}
 	}
 	common.Log.Infof("processOrdProtocol loop %d finished. cost: %v", count, time.Since(measureStartTime))
+	metrics.ObserveStage(metrics.STAGE_INSCRIPTIONS, measureStartTime)
 	common.Log.Infof("height: %d, total cursed: %d", block.Height, s.nft.GetStatus().CurseCount)
-
-	freezeAuthority := s.ftIndexer.BuildFreezeAuthoritySnapshot()
-	s.ftIndexer.SetFreezeAuthoritySnapshot(freezeAuthority)
-	s.prepareFreezeLookahead(block.Height, freezeAuthority)
-
-	//time2 := time.Now()
-	s.nft.UpdateTransfer(block, coinbase)
-	s.ns.UpdateTransfer(block)
-	s.brc20Indexer.UpdateTransfer(block, coinbase) // 由nftindexer内部调用过去
-	s.RunesIndexer.UpdateTransfer(block)
-	//s.atomIndexer.UpdateTransfer(block)
-	s.ftIndexer.UpdateTransfer(block, coinbase) // 依赖前面生成的稀有资产
-
-	//common.Log.Infof("processOrdProtocol UpdateTransfer finished. cost: %v", time.Since(time2))
-
-	common.Log.Infof("processOrdProtocol %d is done, cost: %v", block.Height, time.Since(measureStartTime))
 }
 
 func (s *IndexerMgr) prepareFreezeLookahead(height int, freezeAuthority map[string]uint64) {
@@ -974,6 +1049,7 @@ func (s *IndexerMgr) handleOrdX(in *common.TxInput, out *common.TxOutputV2,
 		}
 
 		s.ftIndexer.UpdateTick(in, ticker)
+		s.queueOrdxEvent(event_stream.EVENT_DEPLOY, ticker.Name, 0, nft)
 
 	case "mint":
 		mintInfo := common.ParseMintContent(ordxInfo)
@@ -993,6 +1069,7 @@ func (s *IndexerMgr) handleOrdX(in *common.TxInput, out *common.TxOutputV2,
 		}
 
 		s.ftIndexer.UpdateMint(in, mint)
+		s.queueOrdxEvent(event_stream.EVENT_MINT, mint.Name, mint.Amt, nft)
 
 	default:
 		//common.Log.Warnf("handleOrdX unknown ordx type: %s, content: %s, txid: %s", ordxType, content, tx.Txid)
@@ -1118,8 +1195,14 @@ func (s *IndexerMgr) handleOrd(input *common.TxInput,
 	protocol, content := ord.GetProtocol(insc)
 	switch protocol {
 	case "ordx":
+		if !s.isProtocolActive(config.PROTOCOL_FT, block.Height) {
+			return
+		}
 		s.handleOrdX(input, output, inOffset, outOffset, insc, nft)
 	case "sns":
+		if !s.isProtocolActive(config.PROTOCOL_NS, block.Height) {
+			return
+		}
 		domain := common.ParseDomainContent(string(insc.Inscription.Body))
 		if domain == nil {
 			domain = common.ParseDomainContent(string(content))
@@ -1151,6 +1234,9 @@ func (s *IndexerMgr) handleOrd(input *common.TxInput,
 			}
 		}
 	case "brc-20":
+		if !s.isProtocolActive(config.PROTOCOL_BRC20, block.Height) {
+			return
+		}
 		if !s.brc20Indexer.CheckInscription(nft) {
 			common.Log.Debugf("brc20: %s inscription is ignored", nft.Base.InscriptionId)
 			return
@@ -1158,6 +1244,9 @@ func (s *IndexerMgr) handleOrd(input *common.TxInput,
 		s.handleBrc20(input, output, insc, nft)
 
 	case "primary-name":
+		if !s.isProtocolActive(config.PROTOCOL_NS, block.Height) {
+			return
+		}
 		primaryNameContent := common.ParseCommonContent(string(insc.Inscription.Body))
 		if primaryNameContent != nil {
 			switch primaryNameContent.Op {
@@ -1175,6 +1264,9 @@ func (s *IndexerMgr) handleOrd(input *common.TxInput,
 		// content: { "p": "sns", "op": "reg", "name": "1866.sats"}
 		// or ： text/plain;charset=utf-8 {"p":"sns","op":"reg","name":"good.sats"}
 	case "btcname":
+		if !s.isProtocolActive(config.PROTOCOL_NS, block.Height) {
+			return
+		}
 		commonContent := common.ParseCommonContent(string(insc.Inscription.Body))
 		if commonContent != nil {
 			switch commonContent.Op {
@@ -1208,8 +1300,10 @@ func (s *IndexerMgr) handleOrd(input *common.TxInput,
 		if protocol == "" {
 			protocol := insc.Inscription.Metaprotocol
 			if string(protocol) == "ordx" {
-				s.handleOrdX(input, output, inOffset, outOffset, insc, nft)
-			} else {
+				if s.isProtocolActive(config.PROTOCOL_FT, block.Height) {
+					s.handleOrdX(input, output, inOffset, outOffset, insc, nft)
+				}
+			} else if s.isProtocolActive(config.PROTOCOL_NS, block.Height) {
 				if len(insc.Inscription.Body) <= common.MAX_NAME_LEN {
 					s.handleSnsName(string(insc.Inscription.Body), nft)
 				}
@@ -0,0 +1,76 @@
+package indexer
+
+import (
+	"fmt"
+
+	"github.com/sat20-labs/indexer/config"
+	base_indexer "github.com/sat20-labs/indexer/indexer/base"
+	"github.com/sat20-labs/indexer/indexer/fixture"
+)
+
+/*
+测试用的 IndexerMgr
+
+用 fixture.Chain 生成的区块代替 bitcoind，数据库在 cfg.DB.Path 下（用 -tags memdb 编译时在内存中），
+可以在本地端到端地测试协议、分叉，以及 updateDB 中备份实例的 clone/subtract/flush。
+Sync 跟 StartDaemon 中的同步流程一样，但是在调用者的 goroutine 中执行，执行完就可以检查数据。
+
+IndexerMgr 和 bitcoin_rpc.ShareBitconRpc 都是全局的，同时只能有一个 Harness。
+*/
+
+type Harness struct {
+	*IndexerMgr
+	Chain   *fixture.Chain
+	restore func()
+}
+
+func NewHarness(cfg *config.YamlConf, chain *fixture.Chain) *Harness {
+	restore := chain.Install()
+	base_indexer.SetBlockSource(nil)
+
+	instance = nil
+	mgr := NewIndexerMgr(cfg)
+	mgr.Init()
+	return &Harness{
+		IndexerMgr: mgr,
+		Chain:      chain,
+		restore:    restore,
+	}
+}
+
+// Sync 同步到 Chain 的最高区块。跟 StartDaemon 一样，追块时 BaseIndexer 直接写数据库，
+// 到达链顶后由 updateDB 通过备份实例写数据库，分叉时回滚后重新同步
+func (h *Harness) Sync() error {
+	stopChan := make(chan struct{})
+	for {
+		ret := h.base.SyncToChainTip(stopChan)
+		if ret > 0 {
+			if err := h.handleReorg(ret); err != nil {
+				return err
+			}
+			continue
+		}
+		if ret < 0 {
+			return fmt.Errorf("sync to %d failed, %d", h.Chain.Height(), ret)
+		}
+		if h.base.GetHeight() == h.base.GetChainTip() {
+			h.base.SetUpdateDBCallback(nil)
+			h.updateDB()
+		}
+		return nil
+	}
+}
+
+// Restart 关闭数据库后重新打开，跟重启进程一样从数据库加载数据
+func (h *Harness) Restart() {
+	cfg := h.cfg
+	h.Close()
+	*h = *NewHarness(cfg, h.Chain)
+}
+
+func (h *Harness) Close() {
+	h.miniMempool.Stop()
+	h.withIndexerStateWriteBarrier("shutdown", h.closeDB)
+	h.restore()
+	instance = nil
+}
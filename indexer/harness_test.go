@@ -0,0 +1,196 @@
+package indexer
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/indexer/fixture"
+)
+
+// 每个区块花费上一个区块的 coinbase，给 alice 一个输出；分叉的区块给 bob
+type harnessTestScript struct {
+	t      *testing.T
+	h      *Harness
+	alice  map[wire.OutPoint]bool
+	owners map[int][]wire.OutPoint // 高度 -> 这个区块中给 alice 的输出
+}
+
+func newHarnessTestScript(t *testing.T) *harnessTestScript {
+	cfg := &config.YamlConf{}
+	cfg.Chain = common.ChainMainnet
+	cfg.DB.Path = filepath.Join(t.TempDir(), "db") + string(filepath.Separator)
+	chain := fixture.NewChain(&chaincfg.MainNetParams)
+	chain.Mine(fixture.PkScript("miner"))
+
+	s := &harnessTestScript{
+		t:      t,
+		h:      NewHarness(cfg, chain),
+		alice:  make(map[wire.OutPoint]bool),
+		owners: make(map[int][]wire.OutPoint),
+	}
+	t.Cleanup(func() {
+		s.h.Close()
+	})
+	return s
+}
+
+func (s *harnessTestScript) mine(to string) {
+	chain := s.h.Chain
+	height := chain.Height() + 1
+	prev := chain.Block(height - 1).Transactions[0]
+	value := prev.TxOut[0].Value
+	tx := fixture.NewTx([]wire.OutPoint{fixture.OutPoint(prev, 0)},
+		fixture.Output(fixture.PkScript(to), 1000),
+		fixture.Output(fixture.PkScript("miner"), value-2000))
+	chain.Mine(fixture.PkScript("miner"), tx)
+
+	delete(s.owners, height)
+	if to == "alice" {
+		outpoint := fixture.OutPoint(tx, 0)
+		s.alice[outpoint] = true
+		s.owners[height] = []wire.OutPoint{outpoint}
+	}
+}
+
+func (s *harnessTestScript) reorg(height int) {
+	for h, outpoints := range s.owners {
+		if h >= height {
+			for _, outpoint := range outpoints {
+				delete(s.alice, outpoint)
+			}
+			delete(s.owners, h)
+		}
+	}
+	s.h.Chain.Reorg(height)
+}
+
+func (s *harnessTestScript) sync() {
+	s.t.Helper()
+	if err := s.h.Sync(); err != nil {
+		s.t.Fatal(err)
+	}
+	if s.h.base.GetHeight() != s.h.Chain.Height() {
+		s.t.Fatalf("synced to %d, chain height %d", s.h.base.GetHeight(), s.h.Chain.Height())
+	}
+	s.check()
+}
+
+func (s *harnessTestScript) check() {
+	s.t.Helper()
+	want := make([]string, 0, len(s.alice))
+	for outpoint := range s.alice {
+		want = append(want, outpoint.String())
+	}
+	got := s.h.rpcService.GetUTXOs2(fixture.Address("alice", &chaincfg.MainNetParams))
+	sort.Strings(want)
+	sort.Strings(got)
+	if len(want) == 0 && len(got) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		s.t.Fatalf("height %d: alice has %v, want %v", s.h.base.GetHeight(), got, want)
+	}
+}
+
+func TestHarnessSyncAndReorg(t *testing.T) {
+	s := newHarnessTestScript(t)
+
+	// 追块，BaseIndexer 直接写数据库
+	for i := 0; i < 20; i++ {
+		s.mine("alice")
+	}
+	s.sync()
+
+	// 链顶，每个区块都走 updateDB 的备份实例
+	for i := 0; i < 15; i++ {
+		s.mine("alice")
+		s.sync()
+	}
+	syncHeight := s.h.base.GetSyncHeight()
+	if syncHeight <= 20 {
+		t.Fatalf("db not flushed at tip, sync height %d", syncHeight)
+	}
+
+	// 只在内存中的分叉
+	s.reorg(s.h.Chain.Height() - 1)
+	for i := 0; i < 3; i++ {
+		s.mine("bob")
+	}
+	s.sync()
+
+	// 已经写入数据库的分叉，用撤销日志回滚
+	s.reorg(s.h.base.GetSyncHeight() - 2)
+	for i := 0; i < 12; i++ {
+		s.mine("alice")
+	}
+	s.sync()
+
+	// 重启后从数据库加载，内存中还没写入数据库的区块重新同步
+	syncHeight = s.h.base.GetSyncHeight()
+	s.h.Restart()
+	if s.h.base.GetHeight() != syncHeight {
+		t.Fatalf("restarted at %d, db sync height %d", s.h.base.GetHeight(), syncHeight)
+	}
+	s.mine("alice")
+	s.sync()
+}
+
+// 比撤销日志还深的分叉，返回错误停止同步，数据库不变
+func TestHarnessReorgDeeperThanUndoJournal(t *testing.T) {
+	s := newHarnessTestScript(t)
+	for i := 0; i < 80; i++ {
+		s.mine("alice")
+	}
+	s.sync()
+	syncHeight := s.h.base.GetSyncHeight()
+
+	s.reorg(2)
+	for i := 0; i < 90; i++ {
+		s.mine("bob")
+	}
+	if err := s.h.Sync(); err == nil {
+		t.Fatal("reorg deeper than the undo journal should fail")
+	}
+	if s.h.base.GetSyncHeight() != syncHeight {
+		t.Fatalf("db height changed from %d to %d", syncHeight, s.h.base.GetSyncHeight())
+	}
+}
+
+// 追块时的提交不记录撤销日志，中途退出后用提交前的检查点恢复
+func TestHarnessRecoverCatchUpCommit(t *testing.T) {
+	s := newHarnessTestScript(t)
+	for i := 0; i < 40; i++ {
+		s.mine("alice")
+	}
+	s.sync()
+	syncHeight := s.h.base.GetSyncHeight()
+
+	// 模拟追块中的提交写了一半就退出
+	s.h.base.GetSyncStats().ChainTip += 1000
+	// badger 做不了检查点，这时还是记录撤销日志
+	s.h.beginCommit(s.h.base)
+	for _, kvdb := range s.h.chainDBs() {
+		if err := kvdb.Write([]byte("interrupted"), []byte("1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.h.commitMutex.Unlock()
+
+	s.h.Restart()
+	if s.h.base.GetSyncHeight() != syncHeight {
+		t.Fatalf("restored to %d, want %d", s.h.base.GetSyncHeight(), syncHeight)
+	}
+	for _, kvdb := range s.h.chainDBs() {
+		if v, _ := kvdb.Read([]byte("interrupted")); v != nil {
+			t.Fatal("partial commit not rolled back")
+		}
+	}
+	s.mine("alice")
+	s.sync()
+}
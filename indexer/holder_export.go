@@ -0,0 +1,452 @@
+package indexer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// 持有者快照导出，用于空投等场景。同一个 ticker 和高度，导出的文件内容完全一致：
+//
+//	<protocol>-<ticker>-holders-<height>.csv    和 indexer/*/validate 中的 holders csv 兼容，多了 utxos 和 first_seen 两列
+//	<protocol>-<ticker>-holders-<height>.jsonl  每行一个持有者
+//	manifest.json                               文件列表和 sha256
+//
+// 按余额从大到小排列，余额相同时按地址排列
+
+const (
+	HolderExportManifestFile = "manifest.json"
+
+	HolderExportRunning = "running"
+	HolderExportDone    = "done"
+	HolderExportFailed  = "failed"
+
+	maxRunningHolderExports = 2
+	holderExportRetry       = 3
+)
+
+var holderExportCSVHeader = []string{"ticker", "height", "address", "amount", "utxos", "first_seen"}
+
+type HolderExportRecord struct {
+	Ticker  string `json:"ticker"`
+	Height  int    `json:"height"`
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+	// 历史高度没有 utxo 的数据
+	Utxos *int `json:"utxos,omitempty"`
+	// 第一次持有的高度。有余额历史的协议，不早于余额历史的起始高度；
+	// 其他协议是当前持有的 utxo 中最早生成的高度
+	FirstSeen int `json:"first_seen"`
+
+	amount *common.Decimal
+}
+
+type HolderExportFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+type HolderExportManifest struct {
+	Ticker      string `json:"ticker"`
+	Height      int    `json:"height"`
+	Holders     int    `json:"holders"`
+	TotalAmount string `json:"totalAmount"`
+	HasUtxos    bool   `json:"hasUtxos"`
+	// csv 文件的 sha256
+	ContentHash string              `json:"contentHash"`
+	Files       []*HolderExportFile `json:"files"`
+}
+
+type HolderExportJob struct {
+	Id        string                `json:"id"`
+	Ticker    string                `json:"ticker"`
+	Height    int                   `json:"height"` // 0 表示当前同步高度
+	Status    string                `json:"status"`
+	Error     string                `json:"error,omitempty"`
+	StartTime int64                 `json:"startTime"`
+	EndTime   int64                 `json:"endTime,omitempty"`
+	Manifest  *HolderExportManifest `json:"manifest,omitempty"`
+
+	dir string
+}
+
+func (p *HolderExportJob) clone() *HolderExportJob {
+	n := *p
+	return &n
+}
+
+func holderExportSafeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func holderExportFileName(tickerName *common.TickerName, height int, ext string) string {
+	return fmt.Sprintf("%s-%s-holders-%d%s", tickerName.Protocol,
+		holderExportSafeName(tickerName.Ticker), height, ext)
+}
+
+func (b *IndexerMgr) holderExportDir() string {
+	return filepath.Join(b.dbDir, "exports")
+}
+
+// 有余额历史的协议，返回持有者第一次持有的高度
+func (b *IndexerMgr) getHolderFirstSeenAtHeight(tickerName *common.TickerName, height int) (map[uint64]int, error) {
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return nil, fmt.Errorf("protocol %s is not enabled", tickerName.Protocol)
+	}
+	switch tickerName.Protocol {
+	case common.PROTOCOL_NAME_ORDX:
+		if tickerName.Type != common.ASSET_TYPE_FT {
+			break
+		}
+		return b.ftIndexer.GetHolderFirstSeenAtHeight(tickerName.Ticker, height)
+	case common.PROTOCOL_NAME_BRC20:
+		return b.brc20Indexer.GetHolderFirstSeenAtHeight(tickerName.Ticker, height)
+	case common.PROTOCOL_NAME_RUNES:
+		return b.RunesIndexer.GetHolderFirstSeenAtHeight(tickerName.Ticker, height)
+	case common.PROTOCOL_NAME_ATOM:
+		return b.atomIndexer.GetHolderFirstSeenAtHeight(tickerName.Ticker, height)
+	}
+	return nil, fmt.Errorf("%s has no balance history", tickerName.String())
+}
+
+// 地址中已确认的包含该资产的 utxo，不包括内存池
+func (b *IndexerMgr) getConfirmedAssetUtxos(address string, tickerName *common.TickerName) []uint64 {
+	utxos, err := b.GetUTXOsWithAddress(address)
+	if err != nil {
+		return nil
+	}
+	result := make([]uint64, 0)
+	for utxoId := range utxos {
+		utxo, err := b.rpcService.GetUtxoByID(utxoId)
+		if err != nil {
+			continue
+		}
+		info := b.GetTxOutputWithUtxoV2(utxo, true)
+		if info != nil && b.containAsset(info, tickerName) {
+			result = append(result, utxoId)
+		}
+	}
+	return result
+}
+
+func (b *IndexerMgr) newHolderExportRecords(tickerName *common.TickerName, height int,
+	holders map[uint64]*common.Decimal, firstSeen map[uint64]int, withUtxos bool) []*HolderExportRecord {
+	result := make([]*HolderExportRecord, 0, len(holders))
+	for addressId, amt := range holders {
+		if amt == nil || amt.Sign() == 0 {
+			continue
+		}
+		r := &HolderExportRecord{
+			Ticker:    tickerName.Ticker,
+			Height:    height,
+			Address:   b.GetAddressById(addressId),
+			Amount:    amt.String(),
+			FirstSeen: firstSeen[addressId],
+			amount:    amt,
+		}
+		if withUtxos {
+			utxos := b.getConfirmedAssetUtxos(r.Address, tickerName)
+			count := len(utxos)
+			r.Utxos = &count
+			if _, ok := firstSeen[addressId]; !ok {
+				for _, utxoId := range utxos {
+					h, _, _ := common.FromUtxoId(utxoId)
+					if r.FirstSeen == 0 || h < r.FirstSeen {
+						r.FirstSeen = h
+					}
+				}
+			}
+		}
+		result = append(result, r)
+	}
+	sortHolderExportRecords(result)
+	return result
+}
+
+func sortHolderExportRecords(records []*HolderExportRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		if c := records[i].amount.Cmp(records[j].amount); c != 0 {
+			return c > 0
+		}
+		if records[i].Address != records[j].Address {
+			return records[i].Address < records[j].Address
+		}
+		return records[i].FirstSeen < records[j].FirstSeen
+	})
+}
+
+// CollectHolderExport 收集 ticker 在 height 的持有者，height 为 0 时使用当前同步高度。
+// 当前高度的数据来自 GetHoldersWithTickV2，收集过程中有新区块时重新收集；历史高度来自余额历史
+func (b *IndexerMgr) CollectHolderExport(tickerName *common.TickerName, height int) ([]*HolderExportRecord, int, error) {
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return nil, 0, fmt.Errorf("protocol %s is not enabled", tickerName.Protocol)
+	}
+	if tickerName.Type != common.ASSET_TYPE_FT && tickerName.Type != common.ASSET_TYPE_EXOTIC {
+		return nil, 0, fmt.Errorf("unsupported asset type %s", tickerName.Type)
+	}
+
+	syncHeight := b.GetSyncHeight()
+	if height != 0 && height != syncHeight {
+		holders, err := b.GetHoldersWithTickAtHeight(tickerName, height)
+		if err != nil {
+			return nil, 0, err
+		}
+		firstSeen, err := b.getHolderFirstSeenAtHeight(tickerName, height)
+		if err != nil {
+			return nil, 0, err
+		}
+		return b.newHolderExportRecords(tickerName, height, holders, firstSeen, false), height, nil
+	}
+
+	for i := 0; i < holderExportRetry; i++ {
+		holders := b.GetHoldersWithTickV2(tickerName)
+		firstSeen, err := b.getHolderFirstSeenAtHeight(tickerName, syncHeight)
+		if err != nil {
+			firstSeen = nil
+		}
+		records := b.newHolderExportRecords(tickerName, syncHeight, holders, firstSeen, true)
+		newHeight := b.GetSyncHeight()
+		if newHeight == syncHeight {
+			return records, syncHeight, nil
+		}
+		if height != 0 {
+			break
+		}
+		syncHeight = newHeight
+	}
+	return nil, 0, fmt.Errorf("sync height changed during export, try a history height")
+}
+
+// 写入文件的同时计算 sha256 和大小
+type hashedFile struct {
+	file   *os.File
+	hash   hash.Hash
+	size   int64
+	writer *bufio.Writer
+}
+
+func createHashedFile(path string) (*hashedFile, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &hashedFile{file: f, hash: sha256.New()}
+	p.writer = bufio.NewWriter(p)
+	return p, nil
+}
+
+func (p *hashedFile) Write(data []byte) (int, error) {
+	n, err := p.file.Write(data)
+	p.hash.Write(data[:n])
+	p.size += int64(n)
+	return n, err
+}
+
+func (p *hashedFile) Close(name string) (*HolderExportFile, error) {
+	if err := p.writer.Flush(); err != nil {
+		p.file.Close()
+		return nil, err
+	}
+	if err := p.file.Close(); err != nil {
+		return nil, err
+	}
+	return &HolderExportFile{
+		Name:   name,
+		Size:   p.size,
+		Sha256: hex.EncodeToString(p.hash.Sum(nil)),
+	}, nil
+}
+
+// WriteHolderExport 将持有者写入 dir，返回写入的 manifest
+func WriteHolderExport(dir string, tickerName *common.TickerName, height int,
+	records []*HolderExportRecord) (*HolderExportManifest, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	csvName := holderExportFileName(tickerName, height, ".csv")
+	csvFile, err := createHashedFile(filepath.Join(dir, csvName))
+	if err != nil {
+		return nil, err
+	}
+	w := csv.NewWriter(csvFile.writer)
+	w.Write(holderExportCSVHeader)
+	for _, r := range records {
+		utxos := ""
+		if r.Utxos != nil {
+			utxos = strconv.Itoa(*r.Utxos)
+		}
+		w.Write([]string{r.Ticker, strconv.Itoa(r.Height), r.Address, r.Amount, utxos, strconv.Itoa(r.FirstSeen)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		csvFile.Close(csvName)
+		return nil, err
+	}
+	csvInfo, err := csvFile.Close(csvName)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonName := holderExportFileName(tickerName, height, ".jsonl")
+	jsonFile, err := createHashedFile(filepath.Join(dir, jsonName))
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(jsonFile.writer)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			jsonFile.Close(jsonName)
+			return nil, err
+		}
+	}
+	jsonInfo, err := jsonFile.Close(jsonName)
+	if err != nil {
+		return nil, err
+	}
+
+	var total *common.Decimal
+	for _, r := range records {
+		total = total.AddAlignPrecision(r.amount)
+	}
+	manifest := &HolderExportManifest{
+		Ticker:      tickerName.String(),
+		Height:      height,
+		Holders:     len(records),
+		TotalAmount: total.String(),
+		HasUtxos:    len(records) == 0 || records[0].Utxos != nil,
+		ContentHash: csvInfo.Sha256,
+		Files:       []*HolderExportFile{csvInfo, jsonInfo},
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, HolderExportManifestFile), data, 0644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// StartHolderExport 在后台导出持有者快照，同样的任务正在运行时返回该任务
+func (b *IndexerMgr) StartHolderExport(tickerName *common.TickerName, height int) (*HolderExportJob, error) {
+	if height < 0 {
+		return nil, fmt.Errorf("invalid height %d", height)
+	}
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return nil, fmt.Errorf("protocol %s is not enabled", tickerName.Protocol)
+	}
+	if height != 0 {
+		if err := b.checkHistoryHeight(height); err != nil {
+			return nil, err
+		}
+	}
+
+	b.exportMutex.Lock()
+	defer b.exportMutex.Unlock()
+	if b.exportJobs == nil {
+		b.exportJobs = make(map[string]*HolderExportJob)
+	}
+	running := 0
+	for _, job := range b.exportJobs {
+		if job.Status != HolderExportRunning {
+			continue
+		}
+		if job.Ticker == tickerName.String() && job.Height == height {
+			return job.clone(), nil
+		}
+		running++
+	}
+	if running >= maxRunningHolderExports {
+		return nil, fmt.Errorf("too many running export jobs")
+	}
+
+	now := time.Now()
+	id := fmt.Sprintf("%s-%d-%d", holderExportSafeName(tickerName.String()), height, now.UnixNano())
+	job := &HolderExportJob{
+		Id:        id,
+		Ticker:    tickerName.String(),
+		Height:    height,
+		Status:    HolderExportRunning,
+		StartTime: now.Unix(),
+		dir:       filepath.Join(b.holderExportDir(), id),
+	}
+	b.exportJobs[id] = job
+
+	ticker := *tickerName
+	go b.runHolderExport(job.clone(), &ticker)
+	return job.clone(), nil
+}
+
+func (b *IndexerMgr) runHolderExport(job *HolderExportJob, tickerName *common.TickerName) {
+	common.Log.Infof("holder export %s started", job.Id)
+	manifest, err := func() (*HolderExportManifest, error) {
+		records, height, err := b.CollectHolderExport(tickerName, job.Height)
+		if err != nil {
+			return nil, err
+		}
+		return WriteHolderExport(job.dir, tickerName, height, records)
+	}()
+
+	b.exportMutex.Lock()
+	defer b.exportMutex.Unlock()
+	p := b.exportJobs[job.Id]
+	p.EndTime = time.Now().Unix()
+	if err != nil {
+		p.Status = HolderExportFailed
+		p.Error = err.Error()
+		common.Log.Errorf("holder export %s failed, %v", job.Id, err)
+		return
+	}
+	p.Status = HolderExportDone
+	p.Manifest = manifest
+	common.Log.Infof("holder export %s finished, %d holders at %d", job.Id, manifest.Holders, manifest.Height)
+}
+
+func (b *IndexerMgr) GetHolderExportJob(id string) *HolderExportJob {
+	b.exportMutex.Lock()
+	defer b.exportMutex.Unlock()
+	job, ok := b.exportJobs[id]
+	if !ok {
+		return nil
+	}
+	return job.clone()
+}
+
+// GetHolderExportFile 已完成任务中文件的路径，只能访问 manifest 中的文件
+func (b *IndexerMgr) GetHolderExportFile(id, name string) (string, error) {
+	job := b.GetHolderExportJob(id)
+	if job == nil {
+		return "", fmt.Errorf("export job %s not found", id)
+	}
+	if job.Status != HolderExportDone {
+		return "", fmt.Errorf("export job %s is %s", id, job.Status)
+	}
+	if name == HolderExportManifestFile {
+		return filepath.Join(job.dir, name), nil
+	}
+	for _, f := range job.Manifest.Files {
+		if f.Name == name {
+			return filepath.Join(job.dir, name), nil
+		}
+	}
+	return "", fmt.Errorf("file %s not found in export job %s", name, id)
+}
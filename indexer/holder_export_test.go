@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+func TestWriteHolderExportDeterministic(t *testing.T) {
+	tickerName := &common.TickerName{Protocol: common.PROTOCOL_NAME_RUNES, Type: common.ASSET_TYPE_FT, Ticker: "840000:3"}
+	// 输入的顺序不同，导出的结果相同
+	dec := func(s string) *common.Decimal {
+		d, err := common.NewDecimalFromString(s, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	newRecords := func(order []int) []*HolderExportRecord {
+		all := []*HolderExportRecord{
+			{Ticker: "840000:3", Height: 840010, Address: "bc1q", Amount: "0.5", FirstSeen: 840001, amount: dec("0.5")},
+			{Ticker: "840000:3", Height: 840010, Address: "bc1z", Amount: "2", FirstSeen: 840000, amount: dec("2")},
+			{Ticker: "840000:3", Height: 840010, Address: "bc1p", Amount: "0.5", FirstSeen: 840002, amount: dec("0.5")},
+		}
+		records := make([]*HolderExportRecord, 0, len(order))
+		for _, i := range order {
+			records = append(records, all[i])
+		}
+		sortHolderExportRecords(records)
+		return records
+	}
+
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	m1, err := WriteHolderExport(dir1, tickerName, 840010, newRecords([]int{0, 1, 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := WriteHolderExport(dir2, tickerName, 840010, newRecords([]int{2, 0, 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m1.ContentHash != m2.ContentHash || m1.Files[1].Sha256 != m2.Files[1].Sha256 {
+		t.Fatal("exports of the same data should be identical")
+	}
+	if m1.Holders != 3 || m1.TotalAmount != "3" || m1.HasUtxos {
+		t.Fatalf("unexpected manifest %+v", m1)
+	}
+	manifest1, _ := os.ReadFile(filepath.Join(dir1, HolderExportManifestFile))
+	manifest2, _ := os.ReadFile(filepath.Join(dir2, HolderExportManifestFile))
+	if string(manifest1) != string(manifest2) {
+		t.Fatal("manifest should be identical")
+	}
+
+	if m1.Files[0].Name != "runes-840000_3-holders-840010.csv" {
+		t.Fatalf("csv name %s", m1.Files[0].Name)
+	}
+	data, err := os.ReadFile(filepath.Join(dir1, m1.Files[0].Name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{
+		"ticker,height,address,amount,utxos,first_seen",
+		"840000:3,840010,bc1z,2,,840000",
+		"840000:3,840010,bc1p,0.5,,840002",
+		"840000:3,840010,bc1q,0.5,,840001",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("csv lines %v", lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("line %d = %s, want %s", i, lines[i], want[i])
+		}
+	}
+}
@@ -22,7 +22,11 @@ import (
 	"github.com/sat20-labs/indexer/indexer/nft"
 	"github.com/sat20-labs/indexer/indexer/ns"
 	"github.com/sat20-labs/indexer/indexer/runes"
+	"github.com/sat20-labs/indexer/indexer/satindex"
+	"github.com/sat20-labs/indexer/share/bitcoin_rpc"
 	"github.com/sat20-labs/indexer/share/btclucky"
+	"github.com/sat20-labs/indexer/share/event_stream"
+	"github.com/sat20-labs/indexer/share/metrics"
 
 	"github.com/btcsuite/btcd/chaincfg"
 )
@@ -39,9 +43,15 @@ type IndexerMgr struct {
 	brc20DB  common.KVDB
 	runesDB  common.KVDB
 	atomDB   common.KVDB
+	satDB    common.KVDB
 	// data from market
 	localDB common.KVDB
 	kvDB    common.KVDB
+	// 链上数据库共享的撤销日志状态
+	undoJournal   *db.UndoJournal
+	pendingCommit *db.UndoMeta  // 正在写入的提交
+	changeLog     *db.ChangeLog // 主节点为副本记录的提交日志
+	commitMutex   sync.Mutex    // 提交、回滚和数据库快照互斥
 
 	// 保护这两个数据
 	reloading     int32
@@ -54,9 +64,13 @@ type IndexerMgr struct {
 	maxIndexHeight  int
 	periodFlushToDB int
 	notCheckSelf    bool
+	serialProtocol  bool // 各协议串行处理区块
 
 	//mpn         *mpn.MemPoolNode
 	miniMempool *MiniMemPool
+	// zmq 通知有新区块
+	newBlockChan chan struct{}
+	syncPending  atomic.Bool
 
 	brc20Indexer *brc20.BRC20Indexer
 	RunesIndexer *runes.Indexer
@@ -65,12 +79,14 @@ type IndexerMgr struct {
 	ftIndexer    *ft.FTIndexer
 	ns           *ns.NameService
 	nft          *nft.NftIndexer
+	satIndexer   *satindex.SatIndexer // 没有开启时为 nil
 
 	// 跑数据
 	lastCheckHeight int
 	lastDBGCAttempt time.Time
 	lastDBGC        time.Time
 	base            *base_indexer.BaseIndexer
+	ordxEvents      []*event_stream.Event // 处理区块时产生的 ordx 事件，在 mergeBlock 中发出
 	// 备份所有需要写入数据库的数据
 	baseBackupDB   *base_indexer.BaseIndexer
 	exoticBackupDB *exotic.ExoticIndexer
@@ -80,6 +96,7 @@ type IndexerMgr struct {
 	ftBackupDB     *ft.FTIndexer
 	nsBackupDB     *ns.NameService
 	nftBackupDB    *nft.NftIndexer
+	satBackupDB    *satindex.SatIndexer
 
 	/////////////////////////////////
 	mutex   sync.RWMutex                           // 保护下面的数据
@@ -97,6 +114,9 @@ type IndexerMgr struct {
 	lastBTCLuckyTip      int
 	lastBTCLuckyTipHash  string
 	/////////////////////////////////
+
+	exportMutex sync.Mutex
+	exportJobs  map[string]*HolderExportJob // 持有者快照导出任务，只保存在内存中
 }
 
 const dbGCInterval = time.Hour
@@ -138,10 +158,13 @@ func NewIndexerMgr(
 		maxIndexHeight:  int(yamlcfg.BasicIndex.MaxIndexHeight),
 		notCheckSelf:    yamlcfg.BasicIndex.NotCheckSelf,
 		periodFlushToDB: yamlcfg.BasicIndex.PeriodFlushToDB,
+		serialProtocol:  yamlcfg.BasicIndex.SerialProtocol,
 		miniMempool:     NewMiniMemPool(),
+		newBlockChan:    make(chan struct{}, 1),
 	}
 
 	instance = mgr
+	instance.registerMetrics()
 	switch instance.chaincfgParam.Name {
 	case "mainnet":
 		instance.ordFirstHeight = 767430
@@ -207,10 +230,47 @@ func (b *IndexerMgr) Init() {
 	if err != nil {
 		common.Log.Panicf("initDB failed. %v", err)
 	}
+	b.recoverCommit()
+	if err := b.migrateKVRegistrations(); err != nil {
+		common.Log.Errorf("migrate kv registrations failed, %v", err)
+	}
+	b.initBlockSource()
+	b.initIndexers()
+}
+
+// initBlockSource 配置了 blocks 目录时，从本地 blk 文件读取区块
+func (b *IndexerMgr) initBlockSource() {
+	blocksDir := b.cfg.BasicIndex.BlocksDir
+	if blocksDir == "" {
+		return
+	}
+	bestHash := ""
+	if bitcoin_rpc.ShareBitconRpc != nil {
+		hash, err := bitcoin_rpc.ShareBitconRpc.GetBestBlockHash()
+		if err != nil {
+			common.Log.Warnf("GetBestBlockHash failed, %v", err)
+		}
+		bestHash = hash
+	}
+	source, err := base_indexer.NewBlkFileSource(blocksDir, b.chaincfgParam, bestHash)
+	if err != nil {
+		common.Log.Errorf("open blocks dir %s failed, %v. use rpc", blocksDir, err)
+		return
+	}
+	base_indexer.SetBlockSource(base_indexer.NewFallbackBlockSource(source, &base_indexer.RpcBlockSource{}))
+}
+
+// initIndexers 在已经打开的数据库上，重新加载所有索引器的内存数据
+func (b *IndexerMgr) initIndexers() {
+	b.releaseIndexers()
 	b.base = base_indexer.NewBaseIndexer(b.baseDB, b.chaincfgParam, b.maxIndexHeight, b.periodFlushToDB)
 	b.base.Init()
 	b.base.SetUpdateDBCallback(b.forceUpdateDB)
+	b.base.SetPreUpdateDBCallback(func() {
+		b.beginCommit(b.base)
+	})
 	b.base.SetPostUpdateDBCallback(func() {
+		b.endCommit()
 		b.runDBGC(time.Now(), false)
 	})
 	b.base.SetBlockCallback(b.processOrdProtocol)
@@ -230,25 +290,85 @@ func (b *IndexerMgr) Init() {
 		exotic.SatributeList = append(exotic.SatributeList, exotic.Customized)
 	}
 
-	b.exotic = exotic.NewExoticIndexer(b.exoticDB)
-	b.exotic.Init(b.base)
-	b.nft = nft.NewNftIndexer(b.nftDB)
-	b.nft.Init(b.base, b)
-	b.ftIndexer = ft.NewOrdxIndexer(b.ftDB)
-	b.ftIndexer.Init(b.nft)
-	if len(b.pendingFreezeReplay) > 0 {
-		b.ftIndexer.SetPendingHistoricalFreezeReplay(b.pendingFreezeReplay)
-	}
-	b.ns = ns.NewNameService(b.nsDB)
-	b.ns.Init(b.nft)
-	b.brc20Indexer = brc20.NewIndexer(b.brc20DB, b.cfg.CheckValidateFiles)
-	b.brc20Indexer.Init(b.nft)
-	b.RunesIndexer = runes.NewIndexer(b.runesDB, b.chaincfgParam, b.cfg.CheckValidateFiles)
-	b.RunesIndexer.Init(b.base)
-	b.atomIndexer = atom.NewIndexer(b.atomDB, b.chaincfgParam)
-	b.atomIndexer.Init(b.base)
+	// 关闭的协议保持为 nil
+	if b.IsProtocolEnabled(config.PROTOCOL_EXOTIC) {
+		b.exotic = exotic.NewExoticIndexer(b.exoticDB)
+		b.exotic.Init(b.base)
+	}
+	if b.IsProtocolEnabled(config.PROTOCOL_NFT) {
+		b.nft = nft.NewNftIndexer(b.nftDB)
+		if height := b.configuredStartHeight(config.PROTOCOL_NFT); height > 0 {
+			b.nft.SetEnableHeight(height)
+		}
+		b.nft.Init(b.base, b)
+	}
+	if b.IsProtocolEnabled(config.PROTOCOL_FT) {
+		b.ftIndexer = ft.NewOrdxIndexer(b.ftDB)
+		if height := b.configuredStartHeight(config.PROTOCOL_FT); height > 0 {
+			b.ftIndexer.SetEnableHeight(height)
+		}
+		b.ftIndexer.Init(b.nft)
+		if len(b.pendingFreezeReplay) > 0 {
+			b.ftIndexer.SetPendingHistoricalFreezeReplay(b.pendingFreezeReplay)
+		}
+	}
+	if b.IsProtocolEnabled(config.PROTOCOL_NS) {
+		b.ns = ns.NewNameService(b.nsDB)
+		b.ns.Init(b.nft)
+	}
+	if b.IsProtocolEnabled(config.PROTOCOL_BRC20) {
+		b.brc20Indexer = brc20.NewIndexer(b.brc20DB, b.cfg.CheckValidateFiles)
+		if height := b.configuredStartHeight(config.PROTOCOL_BRC20); height > 0 {
+			b.brc20Indexer.SetEnableHeight(height)
+		}
+		b.brc20Indexer.Init(b.nft)
+	}
+	if b.IsProtocolEnabled(config.PROTOCOL_RUNES) {
+		b.RunesIndexer = runes.NewIndexer(b.runesDB, b.chaincfgParam, b.cfg.CheckValidateFiles)
+		if height := b.configuredStartHeight(config.PROTOCOL_RUNES); height > 0 {
+			b.RunesIndexer.SetEnableHeight(height)
+		}
+		b.RunesIndexer.Init(b.base)
+	}
+	if b.IsProtocolEnabled(config.PROTOCOL_ATOM) {
+		b.atomIndexer = atom.NewIndexer(b.atomDB, b.chaincfgParam)
+		if height := b.configuredStartHeight(config.PROTOCOL_ATOM); height > 0 {
+			b.atomIndexer.SetEnableHeight(height)
+		}
+		b.atomIndexer.Init(b.base)
+	}
+	if b.satDB != nil {
+		b.satIndexer = satindex.NewSatIndexer(b.satDB)
+		if err := b.satIndexer.Init(b.base); err != nil {
+			common.Log.Errorf("sat index disabled, %v", err)
+			b.satIndexer = nil
+		}
+	}
 	b.miniMempool.init()
 
+	b.addressToNftMap = nil
+	b.addressToNameMap = nil
+	b.freezeLookaheadCache = make(map[int]*common.Block)
+}
+
+// releaseIndexers 断开旧实例的回调，丢弃内存数据。分叉或重新加载时旧实例不再使用
+func (b *IndexerMgr) releaseIndexers() {
+	if b.base != nil {
+		b.base.Release()
+	}
+	if b.baseBackupDB != nil {
+		b.baseBackupDB.Release()
+	}
+
+	b.exotic = nil
+	b.nft = nil
+	b.ftIndexer = nil
+	b.ns = nil
+	b.brc20Indexer = nil
+	b.RunesIndexer = nil
+	b.atomIndexer = nil
+	b.satIndexer = nil
+
 	b.baseBackupDB = nil
 	b.exoticBackupDB = nil
 	b.ftBackupDB = nil
@@ -257,10 +377,7 @@ func (b *IndexerMgr) Init() {
 	b.atomBackupDB = nil
 	b.nsBackupDB = nil
 	b.nftBackupDB = nil
-
-	b.addressToNftMap = nil
-	b.addressToNameMap = nil
-	b.freezeLookaheadCache = make(map[int]*common.Block)
+	b.satBackupDB = nil
 }
 
 func (b *IndexerMgr) GetBaseDB() common.KVDB {
@@ -268,7 +385,19 @@ func (b *IndexerMgr) GetBaseDB() common.KVDB {
 }
 
 func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
+	go b.runKVPeers(stopChan)
+
+	if b.cfg.Replica.IsReplica() {
+		b.runReplica(stopChan)
+		return
+	}
+
 	n := 10
+	// 使用 zmq 时，轮询只是兜底
+	zmqEnabled := b.cfg.ShareRPC.Bitcoin.ZMQ.Enabled()
+	if zmqEnabled {
+		n = 60
+	}
 	if value := os.Getenv("ATOM_DEBUG_POLL_SECONDS"); value != "" {
 		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
 			n = seconds
@@ -289,6 +418,8 @@ func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
 	// 	return
 	// }
 
+	zmqSub := b.startZMQ()
+
 	bWantExit := false
 	isRunning := false
 	disableSync := false // 启动rpc，不再同步数据
@@ -301,10 +432,11 @@ func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
 			isRunning = true
 			go func() {
 				for !bWantExit {
+					b.syncPending.Store(false)
 					lastHeight = b.base.GetHeight()
 					ret := b.base.SyncToChainTip(stopIndexerChan)
 					if ret == 0 {
-						if reloadHeight, directives := b.ftIndexer.ConsumeReloadRequest(); reloadHeight > 0 {
+						if reloadHeight, directives := b.consumeFtReloadRequest(); reloadHeight > 0 {
 							b.pendingFreezeReplay = directives
 							b.handleHistoricalReload(reloadHeight)
 							b.base.SyncToChainTip(stopIndexerChan)
@@ -325,7 +457,7 @@ func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
 								}
 							}
 
-							if lastHeight == b.base.GetHeight() {
+							if lastHeight == b.base.GetHeight() && !zmqEnabled {
 								// 没有新区块了
 								time.Sleep(10*time.Second)
 							}
@@ -362,8 +494,12 @@ func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
 
 					} else if ret > 0 {
 						// handle reorg
-						b.handleReorg(ret)
-						b.base.SyncToChainTip(stopIndexerChan)
+						if err := b.handleReorg(ret); err != nil {
+							common.Log.Errorf("stop syncing, the db needs to be rebuilt or restored from a snapshot")
+							bWantExit = true
+						} else {
+							b.base.SyncToChainTip(stopIndexerChan)
+						}
 					} else {
 						if ret == -1 {
 							common.Log.Infof("IndexerMgr inner thread exit by SIGINT signal")
@@ -377,6 +513,10 @@ func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
 				}
 
 				isRunning = false
+				// 同步过程中收到的通知
+				if b.syncPending.Load() {
+					b.notifyNewBlock()
+				}
 			}()
 		}
 	}
@@ -389,6 +529,11 @@ func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
 				break
 			}
 			tick()
+		case <-b.newBlockChan:
+			if bWantExit {
+				break
+			}
+			tick()
 		case <-stopChan:
 			common.Log.Info("IndexerMgr got SIGINT")
 			if bWantExit {
@@ -411,6 +556,9 @@ func (b *IndexerMgr) StartDaemon(stopChan chan bool) {
 	}
 
 	ticker.Stop()
+	if zmqSub != nil {
+		zmqSub.Stop()
+	}
 
 	b.miniMempool.Stop()
 	// mpn.StopMPN(mpnode)
@@ -434,6 +582,7 @@ func (b *IndexerMgr) dbgc() (ran bool, success bool) {
 		b.brc20DB,
 		b.runesDB,
 		b.atomDB,
+		b.satDB,
 	}
 	failures := 0
 	for _, database := range databases {
@@ -475,6 +624,10 @@ func (b *IndexerMgr) closeDB() {
 	common.Log.Infof("IndexerMgr->closeDB ")
 	_, _ = b.dbgc()
 
+	if b.satDB != nil {
+		b.satDB.Close()
+		b.satDB = nil
+	}
 	if b.atomDB != nil {
 		b.atomDB.Close()
 		b.atomDB = nil
@@ -521,65 +674,32 @@ func (b *IndexerMgr) checkSelf() {
 	start := time.Now()
 	// 关闭所有无关实例
 	// 检查一个关闭一个，节省空间
-	ok := false
-	for {
-		ok = b.atomIndexer.CheckSelf()
-		if !ok {
-			break
-		}
-		b.atomDB.Close()
-		b.atomDB = nil
-
-		ok = b.brc20Indexer.CheckSelf()
-		if !ok {
-			break
-		}
-		b.brc20DB.Close()
-		b.brc20DB = nil
-
-		ok = b.RunesIndexer.CheckSelf()
-		if !ok {
-			break
-		}
-		b.runesDB.Close()
-		b.runesDB = nil
-
-		ok = b.ftIndexer.CheckSelf()
-		if !ok {
-			break
-		}
-		b.ftDB.Close()
-		b.ftDB = nil
-
-		ok = b.ns.CheckSelf()
-		if !ok {
-			break
-		}
-		b.nsDB.Close()
-		b.nsDB = nil
-
-		ok = b.nft.CheckSelf()
-		if !ok {
-			break
-		}
-		b.nftDB.Close()
-		b.nftDB = nil
-
-		ok = b.exotic.CheckSelf()
-		if !ok {
-			break
+	type checker struct {
+		enabled bool
+		check   func() bool
+		db      *common.KVDB
+	}
+	checkers := []checker{
+		{b.atomIndexer != nil, func() bool { return b.atomIndexer.CheckSelf() }, &b.atomDB},
+		{b.brc20Indexer != nil, func() bool { return b.brc20Indexer.CheckSelf() }, &b.brc20DB},
+		{b.RunesIndexer != nil, func() bool { return b.RunesIndexer.CheckSelf() }, &b.runesDB},
+		{b.ftIndexer != nil, func() bool { return b.ftIndexer.CheckSelf() }, &b.ftDB},
+		{b.ns != nil, func() bool { return b.ns.CheckSelf() }, &b.nsDB},
+		{b.nft != nil, func() bool { return b.nft.CheckSelf() }, &b.nftDB},
+		{b.exotic != nil, func() bool { return b.exotic.CheckSelf() }, &b.exoticDB},
+		{true, func() bool { return b.base.CheckSelf() }, &b.baseDB},
+	}
+	ok := true
+	for _, item := range checkers {
+		if !item.enabled {
+			continue
 		}
-		b.exoticDB.Close()
-		b.exoticDB = nil
-
-		ok = b.base.CheckSelf()
+		ok = item.check()
 		if !ok {
 			break
 		}
-		b.baseDB.Close()
-		b.baseDB = nil
-
-		break
+		(*item.db).Close()
+		*item.db = nil
 	}
 
 	if ok {
@@ -592,27 +712,58 @@ func (b *IndexerMgr) checkSelf() {
 
 func (b *IndexerMgr) forceUpdateDB(wantToDelete map[string]uint64) {
 	startTime := time.Now()
-	b.exotic.UpdateDB()
-	b.nft.UpdateDB()
-	b.ns.UpdateDB()
-	b.ftIndexer.UpdateDB()
-	b.RunesIndexer.UpdateDB()
-	b.atomIndexer.UpdateDB()
-	b.brc20Indexer.CheckEmptyAddress(wantToDelete)
-	b.brc20Indexer.UpdateDB()
+	if b.exotic != nil {
+		b.exotic.UpdateDB()
+	}
+	if b.nft != nil {
+		b.nft.UpdateDB()
+	}
+	if b.ns != nil {
+		b.ns.UpdateDB()
+	}
+	if b.ftIndexer != nil {
+		b.ftIndexer.UpdateDB()
+	}
+	if b.RunesIndexer != nil {
+		b.RunesIndexer.UpdateDB()
+	}
+	if b.atomIndexer != nil {
+		b.atomIndexer.UpdateDB()
+	}
+	if b.brc20Indexer != nil {
+		b.brc20Indexer.CheckEmptyAddress(wantToDelete)
+		b.brc20Indexer.UpdateDB()
+	}
+	if b.satIndexer != nil {
+		b.satIndexer.UpdateDB()
+	}
 
 	common.Log.Infof("IndexerMgr.forceUpdateDB: takes: %v", time.Since(startTime))
 }
 
-func (b *IndexerMgr) handleReorg(height int) {
+// 撤销日志不够回滚时返回错误，数据库保持不变，调用方停止同步
+func (b *IndexerMgr) handleReorg(height int) error {
 	common.Log.Infof("IndexerMgr handleReorg enter...")
 	b.miniMempool.Stop()
+	var err error
 	b.withIndexerStateWriteBarrier("reorg", func() {
-		b.closeDB()
-		b.Init()
+		// 已经写入数据库的区块，用撤销日志原地回滚，不需要重新打开数据库
+		if height <= b.base.GetSyncHeight() {
+			err = b.rollbackDB(height)
+			if err != nil {
+				return
+			}
+		}
+		b.initIndexers()
 		b.base.SetReorgHeight(height)
 	})
+	if err != nil {
+		common.Log.Errorf("IndexerMgr handleReorg at %d failed, %v", height, err)
+		return err
+	}
+	b.publishReorgEvent(height)
 	common.Log.Infof("IndexerMgr handleReorg completed.")
+	return nil
 }
 
 func (b *IndexerMgr) handleHistoricalReload(height int) {
@@ -711,7 +862,9 @@ func (b *IndexerMgr) withIndexerStateWriteBarrier(label string, update func()) {
 		// Reopen RPC admission before allowing mempool readers that may
 		// immediately enter an RPC-gated indexer read.
 		atomic.AddInt32(&b.reloading, -1)
-		common.Log.Infof("%s reader barrier exited, held %v", label, time.Since(start))
+		held := time.Since(start)
+		common.Log.Infof("%s reader barrier exited, held %v", label, held)
+		metrics.ObserveBarrier(label, held)
 	}()
 
 	update()
@@ -751,45 +904,98 @@ func (b *IndexerMgr) writeAtomDebugSnapshot() {
 
 func (b *IndexerMgr) performUpdateDBInBuffer() {
 	b.cleanDBBuffer() // must before UpdateDB
+	b.beginCommit(b.baseBackupDB)
 	wantToDelete := b.baseBackupDB.UpdateDB()
 	org := make(map[string]uint64)
 	for k, v := range wantToDelete {
 		org[k] = v
 	}
-	b.exoticBackupDB.UpdateDB()
-	b.nftBackupDB.UpdateDB()
-	b.nsBackupDB.UpdateDB()
-	b.ftBackupDB.UpdateDB()
-	b.runesBackupDB.UpdateDB()
-	b.atomBackupDB.UpdateDB()
-	b.brc20BackupDB.CheckEmptyAddress(wantToDelete)
-	b.brc20BackupDB.UpdateDB()
+	if b.exoticBackupDB != nil {
+		b.exoticBackupDB.UpdateDB()
+	}
+	if b.nftBackupDB != nil {
+		b.nftBackupDB.UpdateDB()
+	}
+	if b.nsBackupDB != nil {
+		b.nsBackupDB.UpdateDB()
+	}
+	if b.ftBackupDB != nil {
+		b.ftBackupDB.UpdateDB()
+	}
+	if b.runesBackupDB != nil {
+		b.runesBackupDB.UpdateDB()
+	}
+	if b.atomBackupDB != nil {
+		b.atomBackupDB.UpdateDB()
+	}
+	if b.brc20BackupDB != nil {
+		b.brc20BackupDB.CheckEmptyAddress(wantToDelete)
+		b.brc20BackupDB.UpdateDB()
+	}
+	if b.satBackupDB != nil {
+		b.satBackupDB.UpdateDB()
+	}
 	b.baseBackupDB.CleanEmptyAddress(org, wantToDelete)
 
 	b.base.SetSyncStats(b.baseBackupDB.GetSyncStats())
+	b.endCommit()
 }
 
 func (b *IndexerMgr) prepareDBBuffer() {
 	b.baseBackupDB = b.base.Clone(true)
-	b.exoticBackupDB = b.exotic.Clone(b.baseBackupDB)
-	b.runesBackupDB = b.RunesIndexer.Clone(b.baseBackupDB)
-	b.atomBackupDB = b.atomIndexer.Clone(b.baseBackupDB)
-	b.nftBackupDB = b.nft.Clone(b.baseBackupDB)
-	b.nsBackupDB = b.ns.Clone(b.nftBackupDB)
-	b.ftBackupDB = b.ftIndexer.Clone(b.nftBackupDB)
-	b.brc20BackupDB = b.brc20Indexer.Clone(b.nftBackupDB)
+	if b.exotic != nil {
+		b.exoticBackupDB = b.exotic.Clone(b.baseBackupDB)
+	}
+	if b.RunesIndexer != nil {
+		b.runesBackupDB = b.RunesIndexer.Clone(b.baseBackupDB)
+	}
+	if b.atomIndexer != nil {
+		b.atomBackupDB = b.atomIndexer.Clone(b.baseBackupDB)
+	}
+	if b.nft != nil {
+		b.nftBackupDB = b.nft.Clone(b.baseBackupDB)
+	}
+	if b.ns != nil {
+		b.nsBackupDB = b.ns.Clone(b.nftBackupDB)
+	}
+	if b.ftIndexer != nil {
+		b.ftBackupDB = b.ftIndexer.Clone(b.nftBackupDB)
+	}
+	if b.brc20Indexer != nil {
+		b.brc20BackupDB = b.brc20Indexer.Clone(b.nftBackupDB)
+	}
+	if b.satIndexer != nil {
+		b.satBackupDB = b.satIndexer.Clone(b.baseBackupDB)
+	}
 	common.Log.Infof("prepareDBBuffer backup instance with %d", b.baseBackupDB.GetHeight())
 }
 
 func (b *IndexerMgr) cleanDBBuffer() {
 	b.base.Subtract(b.baseBackupDB)
-	b.exotic.Subtract(b.exoticBackupDB)
-	b.nft.Subtract(b.nftBackupDB)
-	b.ns.Subtract(b.nsBackupDB)
-	b.ftIndexer.Subtract(b.ftBackupDB)
-	b.brc20Indexer.Subtract(b.brc20BackupDB)
-	b.RunesIndexer.Subtract(b.runesBackupDB)
-	b.atomIndexer.Subtract(b.atomBackupDB)
+	if b.exotic != nil {
+		b.exotic.Subtract(b.exoticBackupDB)
+	}
+	if b.nft != nil {
+		b.nft.Subtract(b.nftBackupDB)
+	}
+	if b.ns != nil {
+		b.ns.Subtract(b.nsBackupDB)
+	}
+	if b.ftIndexer != nil {
+		b.ftIndexer.Subtract(b.ftBackupDB)
+	}
+	if b.brc20Indexer != nil {
+		b.brc20Indexer.Subtract(b.brc20BackupDB)
+	}
+	if b.RunesIndexer != nil {
+		b.RunesIndexer.Subtract(b.runesBackupDB)
+	}
+	if b.atomIndexer != nil {
+		b.atomIndexer.Subtract(b.atomBackupDB)
+	}
+	if b.satIndexer != nil {
+		b.satIndexer.Subtract(b.satBackupDB)
+	}
 
 	common.Log.Infof("cleanDBBuffer backup instance with %d", b.baseBackupDB.GetHeight())
 }
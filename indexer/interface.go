@@ -5,6 +5,7 @@ import (
 
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
 	"github.com/sat20-labs/indexer/indexer/db"
 )
 
@@ -32,41 +33,47 @@ func (b *IndexerMgr) GetHoldersWithTick(name string) map[uint64]int64 {
 	default:
 	}
 
+	if b.ftIndexer == nil {
+		return nil
+	}
 	return b.ftIndexer.GetHolderAndAmountWithTick(name)
 }
 
 func (b *IndexerMgr) GetHolderAmountWithTick(name string) int {
+	if b.ftIndexer == nil {
+		return 0
+	}
 	am := b.ftIndexer.GetHoldersWithTick(name)
 	return len(am)
 }
 
 func (b *IndexerMgr) HasAssetInUtxoId(utxoId uint64, excludingExotic bool) bool {
 	// 过滤disable的nft，TODO brc20 的transfer nft，是否需要自动disable？
-	if b.nft.HasNftInUtxo(utxoId) {
+	if b.nft != nil && b.nft.HasNftInUtxo(utxoId) {
 		return true
 	}
 
 	// Use the underlying name indexer here rather than the public
 	// HasNameInUtxo wrapper. This helper is also used from already-admitted RPC
 	// reads and must not recursively enter the RPC admission gate.
-	if b.ns.HasNamesInUtxo(utxoId) {
+	if b.ns != nil && b.ns.HasNamesInUtxo(utxoId) {
 		return true
 	}
 
-	if b.ftIndexer.HasAssetInUtxo(utxoId) {
+	if b.ftIndexer != nil && b.ftIndexer.HasAssetInUtxo(utxoId) {
 		return true
 	}
-	if b.RunesIndexer.IsExistAsset(utxoId) {
+	if b.RunesIndexer != nil && b.RunesIndexer.IsExistAsset(utxoId) {
 		return true
 	}
-	if b.brc20Indexer.IsExistAsset(utxoId) {
+	if b.brc20Indexer != nil && b.brc20Indexer.IsExistAsset(utxoId) {
 		return true
 	}
-	if b.atomIndexer.HasAssetInUtxo(utxoId) {
+	if b.atomIndexer != nil && b.atomIndexer.HasAssetInUtxo(utxoId) {
 		return true
 	}
 
-	if !excludingExotic {
+	if !excludingExotic && b.exotic != nil {
 		if b.exotic.HasExoticInUtxo(utxoId) {
 			return true
 		}
@@ -76,33 +83,30 @@ func (b *IndexerMgr) HasAssetInUtxoId(utxoId uint64, excludingExotic bool) bool
 }
 
 func (b *IndexerMgr) HasAssetInUtxo(utxoId uint64, excludingExotic bool) bool {
-	result := b.nft.HasNftInUtxo(utxoId)
-	if result {
+	if b.nft != nil && b.nft.HasNftInUtxo(utxoId) {
 		return true
 	}
 
-	if b.ns.HasNamesInUtxo(utxoId) {
+	if b.ns != nil && b.ns.HasNamesInUtxo(utxoId) {
 		return true
 	}
 
-	result = b.ftIndexer.HasAssetInUtxo(utxoId)
-	if result {
+	if b.ftIndexer != nil && b.ftIndexer.HasAssetInUtxo(utxoId) {
 		return true
 	}
 
-	result = b.RunesIndexer.IsExistAsset(utxoId)
-	if result {
+	if b.RunesIndexer != nil && b.RunesIndexer.IsExistAsset(utxoId) {
 		return true
 	}
-	if b.atomIndexer.HasAssetInUtxo(utxoId) {
+	if b.atomIndexer != nil && b.atomIndexer.HasAssetInUtxo(utxoId) {
 		return true
 	}
 
-	if !excludingExotic && b.exotic.HasExoticInUtxo(utxoId) {
+	if !excludingExotic && b.exotic != nil && b.exotic.HasExoticInUtxo(utxoId) {
 		return true
 	}
 
-	return result
+	return false
 }
 
 // return: utxoId->asset amount
@@ -148,6 +152,9 @@ func (b *IndexerMgr) GetAssetUTXOsInAddressWithTick(address string, ticker *comm
 		}
 
 	case common.ASSET_TYPE_NS:
+		if b.ns == nil {
+			return nil, fmt.Errorf("protocol %s is disabled", config.PROTOCOL_NS)
+		}
 		if ticker.Ticker != common.ALL_TICKERS {
 			bSpecialTicker = true
 		}
@@ -170,6 +177,9 @@ func (b *IndexerMgr) GetAssetUTXOsInAddressWithTick(address string, ticker *comm
 		}
 
 	case common.ASSET_TYPE_EXOTIC:
+		if b.exotic == nil {
+			return nil, fmt.Errorf("protocol %s is disabled", config.PROTOCOL_EXOTIC)
+		}
 		if ticker.Ticker != common.ALL_TICKERS {
 			bSpecialTicker = true
 		}
@@ -191,6 +201,9 @@ func (b *IndexerMgr) GetAssetUTXOsInAddressWithTick(address string, ticker *comm
 		}
 
 	case common.ASSET_TYPE_FT:
+		if b.ftIndexer == nil {
+			return nil, fmt.Errorf("protocol %s is disabled", config.PROTOCOL_FT)
+		}
 		result = b.ftIndexer.GetAssetUtxosWithTicker(b.rpcService.GetAddressId(address), ticker.Ticker)
 	}
 
@@ -219,15 +232,19 @@ func (b *IndexerMgr) GetAssetSummaryInAddress(address string) map[common.TickerN
 		result[tickName] = v
 	}
 
-	ftAsset := b.ftIndexer.GetAssetSummaryByAddress(utxos)
-	for k, v := range ftAsset {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
-		result[tickName] = v
+	if b.ftIndexer != nil {
+		ftAsset := b.ftIndexer.GetAssetSummaryByAddress(utxos)
+		for k, v := range ftAsset {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
+			result[tickName] = v
+		}
 	}
-	atomAsset := b.atomIndexer.GetAssetSummaryByAddress(utxos)
-	for k, v := range atomAsset {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: k}
-		result[tickName] = v
+	if b.atomIndexer != nil {
+		atomAsset := b.atomIndexer.GetAssetSummaryByAddress(utxos)
+		for k, v := range atomAsset {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: k}
+			result[tickName] = v
+		}
 	}
 
 	plainUtxoMap := make(map[uint64]int64)
@@ -279,6 +296,9 @@ func (b *IndexerMgr) GetAssetUTXOsInAddress(address string) map[common.TickerNam
 			result[tickName] = append(result[tickName], utxoId)
 		}
 
+		if b.ns == nil {
+			continue
+		}
 		names := b.ns.GetNamesWithUtxo2(utxoId)
 		if len(names) > 0 {
 			for _, name := range names {
@@ -288,17 +308,21 @@ func (b *IndexerMgr) GetAssetUTXOsInAddress(address string) map[common.TickerNam
 		}
 	}
 
-	ret = b.ftIndexer.GetAssetUtxos(utxos)
-	for k, v := range ret {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
-		result[tickName] = v
+	if b.ftIndexer != nil {
+		ret = b.ftIndexer.GetAssetUtxos(utxos)
+		for k, v := range ret {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
+			result[tickName] = v
+		}
 	}
 
-	for utxoId := range utxos {
-		atomAssets := b.atomIndexer.GetUtxoAssets(utxoId)
-		for ticker := range atomAssets {
-			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: ticker}
-			result[tickName] = append(result[tickName], utxoId)
+	if b.atomIndexer != nil {
+		for utxoId := range utxos {
+			atomAssets := b.atomIndexer.GetUtxoAssets(utxoId)
+			for ticker := range atomAssets {
+				tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: ticker}
+				result[tickName] = append(result[tickName], utxoId)
+			}
 		}
 	}
 
@@ -315,10 +339,10 @@ type AssetInfoInUtxo struct {
 func (b *IndexerMgr) GetUnbindingAssetsWithUtxoV2(utxoId uint64) map[common.TickerName]*AssetInfoInUtxo {
 	result := make(map[common.TickerName]*AssetInfoInUtxo)
 
-	//t1 := time.Now()
-	runesAssets := b.RunesIndexer.GetUtxoAssets(utxoId)
-	//common.Log.Infof("RunesIndexer.GetUtxoAssets takes %v", time.Since(t1))
-	if len(runesAssets) > 0 {
+	if b.RunesIndexer != nil {
+		//t1 := time.Now()
+		runesAssets := b.RunesIndexer.GetUtxoAssets(utxoId)
+		//common.Log.Infof("RunesIndexer.GetUtxoAssets takes %v", time.Since(t1))
 		for _, v := range runesAssets {
 			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_RUNES, Type: common.ASSET_TYPE_FT, Ticker: v.Rune}
 			result[tickName] = &AssetInfoInUtxo{
@@ -327,12 +351,14 @@ func (b *IndexerMgr) GetUnbindingAssetsWithUtxoV2(utxoId uint64) map[common.Tick
 		}
 	}
 
-	brc20Asset := b.brc20Indexer.GetUtxoAssets(utxoId)
-	if brc20Asset != nil {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_BRC20, Type: common.ASSET_TYPE_FT, Ticker: brc20Asset.Name}
-		result[tickName] = &AssetInfoInUtxo{
-			Amt:     brc20Asset.Amt,
-			Invalid: brc20Asset.Invalid,
+	if b.brc20Indexer != nil {
+		brc20Asset := b.brc20Indexer.GetUtxoAssets(utxoId)
+		if brc20Asset != nil {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_BRC20, Type: common.ASSET_TYPE_FT, Ticker: brc20Asset.Name}
+			result[tickName] = &AssetInfoInUtxo{
+				Amt:     brc20Asset.Amt,
+				Invalid: brc20Asset.Invalid,
+			}
 		}
 	}
 
@@ -342,15 +368,15 @@ func (b *IndexerMgr) GetUnbindingAssetsWithUtxoV2(utxoId uint64) map[common.Tick
 // return: ticker -> assets(inscriptionId->Ranges)
 func (b *IndexerMgr) GetAssetsWithUtxo(utxoId uint64) map[common.TickerName]common.AssetOffsets {
 	result := make(map[common.TickerName]common.AssetOffsets)
-	ftAssets := b.ftIndexer.GetAssetsWithUtxo(utxoId)
-	if len(ftAssets) > 0 {
+	if b.ftIndexer != nil {
+		ftAssets := b.ftIndexer.GetAssetsWithUtxo(utxoId)
 		for k, v := range ftAssets {
 			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
 			result[tickName] = v
 		}
 	}
-	atomAssets := b.atomIndexer.GetAssetsWithUtxo(utxoId)
-	if len(atomAssets) > 0 {
+	if b.atomIndexer != nil {
+		atomAssets := b.atomIndexer.GetAssetsWithUtxo(utxoId)
 		for k, v := range atomAssets {
 			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: k}
 			result[tickName] = v
@@ -391,6 +417,9 @@ func (b *IndexerMgr) GetMintHistory(tick string, start, limit int) []*common.Min
 	case common.ASSET_TYPE_EXOTIC:
 	default:
 
+	}
+	if b.ftIndexer == nil {
+		return nil
 	}
 	return b.ftIndexer.GetMintHistory(tick, start, limit)
 }
@@ -403,6 +432,9 @@ func (b *IndexerMgr) GetMintHistoryWithAddress(address string, tick *common.Tick
 	case common.PROTOCOL_NAME_ORDX:
 		switch tick.Type {
 		case common.ASSET_TYPE_FT:
+			if b.ftIndexer == nil {
+				return nil, 0
+			}
 			return b.ftIndexer.GetMintHistoryWithAddress(addressId, tick.Ticker, start, limit)
 		case common.ASSET_TYPE_NFT:
 			return b.GetNftHistoryWithAddress(addressId, start, limit)
@@ -414,6 +446,9 @@ func (b *IndexerMgr) GetMintHistoryWithAddress(address string, tick *common.Tick
 		}
 
 	case common.PROTOCOL_NAME_BRC20:
+		if b.brc20Indexer == nil {
+			return nil, 0
+		}
 		return b.brc20Indexer.GetMintHistoryWithAddress(addressId, tick.Ticker, start, limit)
 	case common.PROTOCOL_NAME_RUNES:
 
@@ -424,6 +459,9 @@ func (b *IndexerMgr) GetMintHistoryWithAddress(address string, tick *common.Tick
 }
 
 func (b *IndexerMgr) GetMintInfo(inscriptionId string) *common.Mint {
+	if b.nft == nil {
+		return nil
+	}
 	nft := b.nft.GetNftWithInscriptionId(inscriptionId)
 	if nft == nil {
 		common.Log.Errorf("can't find ticker by %s", inscriptionId)
@@ -447,10 +485,16 @@ func (b *IndexerMgr) GetMintInfo(inscriptionId string) *common.Mint {
 		}
 	}
 
+	if b.ftIndexer == nil {
+		return nil
+	}
 	return b.ftIndexer.GetMint(inscriptionId)
 }
 
 func (b *IndexerMgr) GetNftWithInscriptionId(inscriptionId string) *common.Nft {
+	if b.nft == nil {
+		return nil
+	}
 	return b.nft.GetNftWithInscriptionId(inscriptionId)
 }
 
@@ -496,10 +540,16 @@ func (b *IndexerMgr) GetCollection(ntype, ticker string, ids []string) ([]string
 
 // on-chain gallery
 func (p *IndexerMgr) GetGalleryWithInscriptionId(id string) *common.GalleryInfo {
+	if p.nft == nil {
+		return nil
+	}
 	return p.nft.GetGalleryWithInscriptionId(id)
 }
 
 // on-chain collection
 func (p *IndexerMgr) GetCollectionWithInscriptionId(id string) *common.GalleryInfo {
+	if p.nft == nil {
+		return nil
+	}
 	return p.nft.GetCollectionWithInscriptionId(id)
 }
@@ -0,0 +1,110 @@
+package indexer
+
+import (
+	"fmt"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// 历史高度的查询只支持有余额历史的协议：ordx ft、brc20、runes 和 atom
+
+func (b *IndexerMgr) checkHistoryHeight(height int) error {
+	if height <= 0 {
+		return fmt.Errorf("invalid height %d", height)
+	}
+	if syncHeight := b.GetSyncHeight(); height > syncHeight {
+		return fmt.Errorf("height %d is higher than the sync height %d", height, syncHeight)
+	}
+	return nil
+}
+
+// GetAssetSummaryInAddressAtHeight 地址在某个高度持有的同质化资产，不包括内存池中的数据
+func (b *IndexerMgr) GetAssetSummaryInAddressAtHeight(address string, height int) (map[common.TickerName]*common.Decimal, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	if err := b.checkHistoryHeight(height); err != nil {
+		return nil, err
+	}
+	result := make(map[common.TickerName]*common.Decimal)
+	addressId := b.rpcService.GetAddressId(address)
+	if addressId == common.INVALID_ID {
+		return result, nil
+	}
+
+	type history struct {
+		protocol string
+		get      func(addressId uint64, height int) (map[string]*common.Decimal, error)
+	}
+	histories := make([]history, 0, 4)
+	if b.ftIndexer != nil {
+		histories = append(histories, history{common.PROTOCOL_NAME_ORDX, b.ftIndexer.GetAssetSummaryByAddressAtHeight})
+	}
+	if b.brc20Indexer != nil {
+		histories = append(histories, history{common.PROTOCOL_NAME_BRC20, b.brc20Indexer.GetAssetSummaryByAddressAtHeight})
+	}
+	if b.RunesIndexer != nil {
+		histories = append(histories, history{common.PROTOCOL_NAME_RUNES, b.RunesIndexer.GetAddressAssetsAtHeight})
+	}
+	if b.atomIndexer != nil {
+		histories = append(histories, history{common.PROTOCOL_NAME_ATOM, b.atomIndexer.GetAddressAssetsAtHeight})
+	}
+	for _, h := range histories {
+		assets, err := h.get(addressId, height)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", h.protocol, err)
+		}
+		for k, v := range assets {
+			tickName := common.TickerName{Protocol: h.protocol, Type: common.ASSET_TYPE_FT, Ticker: k}
+			result[tickName] = v
+		}
+	}
+	return result, nil
+}
+
+// GetHoldersWithTickAtHeight return: addressId -> asset amount
+func (b *IndexerMgr) GetHoldersWithTickAtHeight(tickerName *common.TickerName, height int) (map[uint64]*common.Decimal, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	if err := b.checkHistoryHeight(height); err != nil {
+		return nil, err
+	}
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return nil, fmt.Errorf("protocol %s is not enabled", tickerName.Protocol)
+	}
+	switch tickerName.Protocol {
+	case common.PROTOCOL_NAME_ORDX:
+		if tickerName.Type != common.ASSET_TYPE_FT {
+			break
+		}
+		return b.ftIndexer.GetHoldersWithTickAtHeight(tickerName.Ticker, height)
+	case common.PROTOCOL_NAME_BRC20:
+		return b.brc20Indexer.GetHoldersWithTickAtHeight(tickerName.Ticker, height)
+	case common.PROTOCOL_NAME_RUNES:
+		return b.RunesIndexer.GetHoldersWithTickAtHeight(tickerName.Ticker, height)
+	case common.PROTOCOL_NAME_ATOM:
+		return b.atomIndexer.GetHoldersWithTickAtHeight(tickerName.Ticker, height)
+	}
+	return nil, fmt.Errorf("%s has no balance history", tickerName.String())
+}
+
+// CheckUtxoAtHeight utxo 是否在某个高度之前已经生成。utxo 中的资产在花费之前基本不会变化
+// （ordx 的 unbind 和冻结除外），所以未花费的 utxo 在该高度的资产按当前的资产返回
+func (b *IndexerMgr) CheckUtxoAtHeight(utxo string, height int) error {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	if err := b.checkHistoryHeight(height); err != nil {
+		return err
+	}
+	utxoId, _, err := b.rpcService.GetOrdinalsWithUtxo(utxo)
+	if err != nil || utxoId == common.INVALID_ID {
+		return fmt.Errorf("can't find utxo %s", utxo)
+	}
+	created, _, _ := common.FromUtxoId(utxoId)
+	if created > height {
+		return fmt.Errorf("utxo %s is created at %d, after height %d", utxo, created, height)
+	}
+	return nil
+}
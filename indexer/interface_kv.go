@@ -2,7 +2,6 @@ package indexer
 
 import (
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -26,11 +25,7 @@ func isRegistrationFresh(refreshTime, now int64) bool {
 	return age >= 0 && age < int64(supportedKeyGracePeriod.Seconds())
 }
 
-type RegisterPubKeyInfo struct {
-	PubKey      []byte
-	ChannelAddr string
-	RefreshTime int64
-}
+type RegisterPubKeyInfo = common.KVRegistration
 
 func getKvKey(pubkey string, key string) string {
 	return fmt.Sprintf("/%s/%s", pubkey, key)
@@ -66,16 +61,16 @@ func (b *IndexerMgr) isSupportedKey(pubkey []byte) bool {
 		common.Log.Infof("GobGetDB %s failed, %v", key, err)
 		return false
 	}
+	// 刷新时间没有超时的不需要查资产
+	if isRegistrationFresh(value.RefreshTime, time.Now().Unix()) {
+		return true
+	}
+
 	// Preserve the original authorization semantics. GetAssetSummaryInAddress
 	// now uses internal name-index helpers, so it is safe to call while the
 	// outer RPC admission token is held.
 	assets := b.GetAssetSummaryInAddress(value.ChannelAddr)
-	if len(assets) != 0 {
-		return true
-	}
-
-	// 如果没有资产，是否超时？
-	return isRegistrationFresh(value.RefreshTime, time.Now().Unix())
+	return len(assets) != 0
 }
 
 func (b *IndexerMgr) PutKVs(kvs []*common.KeyValue) error {
@@ -92,8 +87,11 @@ func (b *IndexerMgr) PutKVs(kvs []*common.KeyValue) error {
 		return err
 	}
 
-	wb := b.kvDB.NewWriteBatch()
-	defer wb.Close()
+	w, err := newKVWriter(b.kvDB)
+	if err != nil {
+		return err
+	}
+	defer w.close()
 
 	checkedPubKey := make(map[string]bool)
 	for _, value := range kvs {
@@ -111,32 +109,22 @@ func (b *IndexerMgr) PutKVs(kvs []*common.KeyValue) error {
 			return fmt.Errorf("too large data %d", len(value.Value))
 		}
 
-		sig := value.Signature
-		value.Signature = nil
-		msg, err := json.Marshal(value)
-		if err != nil {
-			common.Log.Errorf("json.Marshal failed. %v", err)
-			return err
-		}
-		value.Signature = sig
-
 		// verify the signature
-		err = common.VerifySignOfMessage(msg, sig, value.PubKey)
+		err = verifyKVPut(value)
 		if err != nil {
-			common.Log.Errorf("verify signature of key %s failed, %v", value.Key, err)
-			return fmt.Errorf("verify signature of key %s failed, %v", value.Key, err)
+			common.Log.Errorf("%v", err)
+			return err
 		}
 
-		key := getKvKey(pkStr, value.Key)
-		err = db.SetDB([]byte(key), value, wb)
+		err = w.put(value)
 		if err != nil {
-			common.Log.Errorf("setting key %s failed, %v", key, err)
+			common.Log.Errorf("setting key %s failed, %v", value.Key, err)
 			return err
 		}
-		common.Log.Infof("keyValue saved. %s", key)
+		common.Log.Infof("keyValue saved. %s %d", getKvKey(pkStr, value.Key), value.Sequence)
 	}
 
-	err = wb.Flush()
+	err = w.flush()
 	if err != nil {
 		common.Log.Errorf("flushing writes to db %v", err)
 		return err
@@ -145,35 +133,63 @@ func (b *IndexerMgr) PutKVs(kvs []*common.KeyValue) error {
 	return nil
 }
 
-func (b *IndexerMgr) DelKVs(pubkey []byte, keys []string) error {
+// DelKVs 每个删除都要有签名，序号比这个 key 最后一次修改的大
+func (b *IndexerMgr) DelKVs(dels []*common.KeyDeletion) error {
 	b.rpcEnter()
 	defer b.rpcLeft()
 	b.kvMutex.Lock()
 	defer b.kvMutex.Unlock()
 
-	if len(keys) > maxKVKeysPerPubKey {
-		return fmt.Errorf("too many keys in one request: %d (max %d)", len(keys), maxKVKeysPerPubKey)
+	if len(dels) == 0 {
+		return fmt.Errorf("empty KV request")
+	}
+	if len(dels) > maxKVKeysPerPubKey {
+		return fmt.Errorf("too many keys in one request: %d (max %d)", len(dels), maxKVKeysPerPubKey)
+	}
+	total := 0
+	for _, value := range dels {
+		if value == nil {
+			return fmt.Errorf("nil KV deletion")
+		}
+		total += len(value.Key) + len(value.PubKey) + len(value.Signature)
 	}
-	if kvKeyRequestSize(keys) > maxKVRequestBytes {
+	if total > maxKVRequestBytes {
 		return fmt.Errorf("delete request too large (max %d bytes)", maxKVRequestBytes)
 	}
 
-	wb := b.kvDB.NewWriteBatch()
-	defer wb.Close()
+	w, err := newKVWriter(b.kvDB)
+	if err != nil {
+		return err
+	}
+	defer w.close()
 
-	pkStr := hex.EncodeToString(pubkey)
+	checkedPubKey := make(map[string]bool)
+	for _, value := range dels {
+		pkStr := hex.EncodeToString(value.PubKey)
+		_, ok := checkedPubKey[pkStr]
+		if !ok {
+			if !b.isSupportedKey(value.PubKey) {
+				common.Log.Errorf("unsupport pubkey")
+				return fmt.Errorf("unsupport pubkey")
+			}
+			checkedPubKey[pkStr] = true
+		}
 
-	for _, k := range keys {
-		key := getKvKey(pkStr, k)
-		err := wb.Delete([]byte(key))
+		err = verifyKVDelete(value)
 		if err != nil {
-			common.Log.Errorf("deleting key %s failed, %v", key, err)
+			common.Log.Errorf("%v", err)
 			return err
 		}
-		common.Log.Infof("keyValue deleted. %s", key)
+
+		err = w.del(value)
+		if err != nil {
+			common.Log.Errorf("deleting key %s failed, %v", value.Key, err)
+			return err
+		}
+		common.Log.Infof("keyValue deleted. %s %d", getKvKey(pkStr, value.Key), value.Sequence)
 	}
 
-	err := wb.Flush()
+	err = w.flush()
 	if err != nil {
 		common.Log.Errorf("flushing writes to db %v", err)
 		return err
@@ -269,14 +285,6 @@ func (b *IndexerMgr) countKVKeys(pubkey string) (int, error) {
 	return count, nil
 }
 
-func kvKeyRequestSize(keys []string) int {
-	total := 0
-	for _, key := range keys {
-		total += len(key)
-	}
-	return total
-}
-
 func (b *IndexerMgr) GetKVs(pubkey []byte, keys []string) ([]*common.KeyValue, error) {
 	b.rpcEnter()
 	defer b.rpcLeft()
@@ -315,17 +323,16 @@ func (b *IndexerMgr) RegisterPubKey(minerPubKey string) (string, error) {
 	// 暂时保留该pubkey，但是如果在一定时间内没有挖矿所得进入该地址，就可能删除
 	// 暂时只支持保留100个地址
 
-	var indexerPubkey string
-	if b.cfg.PubKey != "" {
-		indexerPubkey = b.cfg.PubKey
-	} else {
-		indexerPubkey = common.GetBootstrapPubKey()
-	}
+	indexerPubkey := b.indexerPubKey()
 
 	key := getRegisterKey(minerPubKey)
 	var value RegisterPubKeyInfo
 	err := db.GobGetDB([]byte(key), &value, b.kvDB)
 	if err == nil && string(value.PubKey) == minerPubKey {
+		// 可能是从其他索引器同步过来的，通道地址用的是那个索引器的公钥
+		if value.IndexerPubKey != "" {
+			return value.IndexerPubKey, nil
+		}
 		return indexerPubkey, nil
 	}
 
@@ -343,9 +350,14 @@ func (b *IndexerMgr) RegisterPubKey(minerPubKey string) (string, error) {
 	}
 
 	value = RegisterPubKeyInfo{
-		PubKey:      []byte(minerPubKey),
-		ChannelAddr: channelAddr,
-		RefreshTime: time.Now().Unix(),
+		PubKey:        []byte(minerPubKey),
+		ChannelAddr:   channelAddr,
+		RefreshTime:   time.Now().Unix(),
+		IndexerPubKey: indexerPubkey,
+	}
+	// 其他索引器只接受签名的注册
+	if err := b.signKVRegistration(&value); err != nil {
+		return "", err
 	}
 	err = db.GobSetDB([]byte(key), &value, b.kvDB)
 	if err != nil {
@@ -359,6 +371,10 @@ func (b *IndexerMgr) GetIndexerPubKey() string {
 	b.rpcEnter()
 	defer b.rpcLeft()
 
+	return b.indexerPubKey()
+}
+
+func (b *IndexerMgr) indexerPubKey() string {
 	if b.cfg.PubKey != "" {
 		return b.cfg.PubKey
 	}
@@ -1,11 +1,17 @@
 package indexer
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
 )
 
 func TestValidateKVWriteRequestLimitsDistinctKeysPerPubKey(t *testing.T) {
@@ -64,3 +70,200 @@ func TestRegistrationFreshUsesForwardAge(t *testing.T) {
 		t.Fatal("future registration timestamp should not be accepted")
 	}
 }
+
+type kvTestSigner struct {
+	t       *testing.T
+	privKey *btcec.PrivateKey
+	pubkey  []byte
+}
+
+func newKVTestSigner(t *testing.T) *kvTestSigner {
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &kvTestSigner{t: t, privKey: privKey, pubkey: privKey.PubKey().SerializeCompressed()}
+}
+
+func (p *kvTestSigner) sign(value any) []byte {
+	msg, err := json.Marshal(value)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return ecdsa.Sign(p.privKey, chainhash.HashB(msg)).Serialize()
+}
+
+func (p *kvTestSigner) put(key, value string, sequence uint64) *common.KeyValue {
+	kv := &common.KeyValue{Key: key, Value: []byte(value), PubKey: p.pubkey, Sequence: sequence}
+	kv.Signature = p.sign(kv)
+	return kv
+}
+
+func (p *kvTestSigner) del(key string, sequence uint64) *common.KeyDeletion {
+	kv := &common.KeyDeletion{Key: key, PubKey: p.pubkey, Sequence: sequence}
+	kv.Signature = p.sign(kv)
+	return kv
+}
+
+func kvTestWrite(kvdb common.KVDB, fn func(w *kvWriter) error) error {
+	w, err := newKVWriter(kvdb)
+	if err != nil {
+		return err
+	}
+	defer w.close()
+	if err := fn(w); err != nil {
+		return err
+	}
+	return w.flush()
+}
+
+func TestKVSignatureCoversSequence(t *testing.T) {
+	signer := newKVTestSigner(t)
+	put := signer.put("a", "1", 1)
+	if err := verifyKVPut(put); err != nil {
+		t.Fatal(err)
+	}
+	put.Sequence = 2
+	if err := verifyKVPut(put); err == nil {
+		t.Fatal("expected a modified sequence to be rejected")
+	}
+
+	del := signer.del("a", 3)
+	if err := verifyKVDelete(del); err != nil {
+		t.Fatal(err)
+	}
+	del.Key = "b"
+	if err := verifyKVDelete(del); err == nil {
+		t.Fatal("expected a modified key to be rejected")
+	}
+}
+
+func TestKVWriterRejectsReplay(t *testing.T) {
+	kvdb := db.NewMemDB("")
+	mgr := &IndexerMgr{kvDB: kvdb}
+	signer := newKVTestSigner(t)
+
+	v1 := signer.put("a", "1", 1)
+	v2 := signer.put("a", "2", 2)
+	if err := kvTestWrite(kvdb, func(w *kvWriter) error { return w.put(v1) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := kvTestWrite(kvdb, func(w *kvWriter) error { return w.put(v2) }); err != nil {
+		t.Fatal(err)
+	}
+	// 旧的签名数据不能把值改回去
+	if err := kvTestWrite(kvdb, func(w *kvWriter) error { return w.put(v1) }); err == nil {
+		t.Fatal("expected replayed put to be rejected")
+	}
+	// 同一个请求中也要递增
+	err := kvTestWrite(kvdb, func(w *kvWriter) error {
+		if err := w.put(signer.put("b", "1", 5)); err != nil {
+			return err
+		}
+		return w.put(signer.put("b", "2", 5))
+	})
+	if err == nil {
+		t.Fatal("expected repeated sequence in one request to be rejected")
+	}
+
+	// 删除后，旧的写入也不能重放
+	if err := kvTestWrite(kvdb, func(w *kvWriter) error { return w.del(signer.del("a", 2)) }); err == nil {
+		t.Fatal("expected delete with a used sequence to be rejected")
+	}
+	if err := kvTestWrite(kvdb, func(w *kvWriter) error { return w.del(signer.del("a", 3)) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := kvTestWrite(kvdb, func(w *kvWriter) error { return w.put(v2) }); err == nil {
+		t.Fatal("expected put replayed after delete to be rejected")
+	}
+	values, err := mgr.GetKVs(signer.pubkey, []string{"a"})
+	if err != nil || len(values) != 0 {
+		t.Fatalf("deleted key returns %v, %v", values, err)
+	}
+
+	history, err := mgr.GetKVHistory(signer.pubkey, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[0].Put == nil || history[2].Del == nil || history[2].Sequence() != 3 {
+		t.Fatalf("unexpected history %v", history)
+	}
+}
+
+func TestKVHistoryAndChangeFeed(t *testing.T) {
+	kvdb := db.NewMemDB("")
+	mgr := &IndexerMgr{kvDB: kvdb}
+	signer := newKVTestSigner(t)
+
+	n := maxKVVersions + 4
+	for i := 1; i <= n; i++ {
+		value := signer.put("a", fmt.Sprintf("%d", i), uint64(i))
+		if err := kvTestWrite(kvdb, func(w *kvWriter) error { return w.put(value) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	history, err := mgr.GetKVHistory(signer.pubkey, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != maxKVVersions || history[len(history)-1].Sequence() != uint64(n) ||
+		history[0].Sequence() != uint64(n-maxKVVersions+1) {
+		t.Fatalf("history has %d versions", len(history))
+	}
+
+	changes, last, err := mgr.GetKVChanges(0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != uint64(n) || len(changes) != 5 || changes[0].Id != 1 || changes[4].Id != 5 {
+		t.Fatalf("unexpected changes %v, last %d", changes, last)
+	}
+	changes, _, err = mgr.GetKVChanges(uint64(n-2), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[1].Id != uint64(n) || string(changes[1].Put.Value) != fmt.Sprintf("%d", n) {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+	if err := verifyKVPut(changes[1].Put); err != nil {
+		t.Fatalf("change feed entries should keep the signature, %v", err)
+	}
+}
+
+func TestListKVs(t *testing.T) {
+	kvdb := db.NewMemDB("")
+	mgr := &IndexerMgr{kvDB: kvdb}
+	signer := newKVTestSigner(t)
+	other := newKVTestSigner(t)
+
+	err := kvTestWrite(kvdb, func(w *kvWriter) error {
+		for _, key := range []string{"ch/1", "ch/2", "ch/3", "miner/1"} {
+			if err := w.put(signer.put(key, key, 1)); err != nil {
+				return err
+			}
+		}
+		return w.put(other.put("ch/4", "", 1))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	cursor := ""
+	for {
+		values, next, err := mgr.ListKVs(signer.pubkey, "ch/", cursor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, value := range values {
+			keys = append(keys, value.Key)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if strings.Join(keys, ",") != "ch/1,ch/2,ch/3" {
+		t.Fatalf("listed %v", keys)
+	}
+}
@@ -0,0 +1,110 @@
+package indexer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/satindex"
+)
+
+// 聪区间索引需要在配置中打开 basic_index.sat_index
+
+func (b *IndexerMgr) IsSatIndexEnabled() bool {
+	return b.satIndexer != nil
+}
+
+// 产生这一聪的区块，以及这个区块新产生的聪
+func (b *IndexerMgr) getSatBlock(sat int64) (int, *common.Range, error) {
+	top := b.rpcService.GetHeight()
+	info, err := b.rpcService.GetBlockInfo(top)
+	if err != nil {
+		return -1, nil, err
+	}
+	if sat < 0 || sat >= info.TotalSats {
+		return -1, nil, fmt.Errorf("sat %d not mined yet", sat)
+	}
+
+	var searchErr error
+	height := sort.Search(top+1, func(h int) bool {
+		info, err := b.rpcService.GetBlockInfo(h)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return info.TotalSats > sat
+	})
+	if searchErr != nil {
+		return -1, nil, searchErr
+	}
+
+	start := int64(0)
+	if height > 0 {
+		prev, err := b.rpcService.GetBlockInfo(height - 1)
+		if err != nil {
+			return -1, nil, err
+		}
+		start = prev.TotalSats
+	}
+	info, err = b.rpcService.GetBlockInfo(height)
+	if err != nil {
+		return -1, nil, err
+	}
+	return height, &common.Range{Start: start, Size: info.TotalSats - start}, nil
+}
+
+// LocateSat 当前持有这一聪的 utxo 和偏移，以及这一聪的稀有属性
+func (b *IndexerMgr) LocateSat(sat int64) (*common.SatLocation, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	if b.satIndexer == nil {
+		return nil, fmt.Errorf("sat index is not enabled")
+	}
+	height, reward, err := b.getSatBlock(sat)
+	if err != nil {
+		return nil, err
+	}
+	result := &common.SatLocation{
+		Sat:        sat,
+		Height:     height,
+		Satributes: satindex.Satributes(sat, height, reward),
+	}
+
+	utxoId, offset, err := b.satIndexer.FindSat(sat)
+	if err != nil {
+		// 已经被烧掉，或者还在未确认的交易中
+		common.Log.Debugf("LocateSat %d failed, %v", sat, err)
+		return result, nil
+	}
+	utxo, err := b.rpcService.GetUtxoByID(utxoId)
+	if err != nil {
+		return result, nil
+	}
+	result.Utxo = utxo
+	result.Offset = offset
+	info, err := b.rpcService.GetUtxoInfo(utxo)
+	if err == nil {
+		result.Value = info.Value
+		address, err := common.PkScriptToAddr(info.PkScript, b.chaincfgParam)
+		if err == nil {
+			result.Address = address
+		}
+	}
+	return result, nil
+}
+
+// GetSatRangesWithUtxo utxo 中的聪区间
+func (b *IndexerMgr) GetSatRangesWithUtxo(utxo string) ([]*common.Range, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	if b.satIndexer == nil {
+		return nil, fmt.Errorf("sat index is not enabled")
+	}
+	utxoId, _, err := b.rpcService.GetOrdinalsWithUtxo(utxo)
+	if err != nil {
+		return nil, err
+	}
+	return b.satIndexer.GetRangesWithUtxoId(utxoId)
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
 	atomidx "github.com/sat20-labs/indexer/indexer/atom"
 )
 
@@ -29,7 +30,7 @@ func (b *IndexerMgr) containAsset(output *common.TxOutput, ticker *common.AssetN
 			return false
 		}
 		// 只有nft
-		if b.nft.HasNftInUtxo(output.UtxoId) {
+		if b.nft != nil && b.nft.HasNftInUtxo(output.UtxoId) {
 			// 有其他没有被disabled的nft
 			return false
 		}
@@ -45,25 +46,30 @@ func (b *IndexerMgr) containAsset(output *common.TxOutput, ticker *common.AssetN
 }
 
 func (b *IndexerMgr) hasAssetInUtxoIdNoRPC(utxoId uint64, excludingExotic bool) bool {
-	if b.nft.HasNftInUtxo(utxoId) {
+	if b.nft != nil && b.nft.HasNftInUtxo(utxoId) {
 		return true
 	}
-	if b.ns.HasNamesInUtxo(utxoId) {
+	return b.hasNonNftAssetInUtxoId(utxoId, excludingExotic)
+}
+
+// hasNonNftAssetInUtxoId 除了nft之外的资产
+func (b *IndexerMgr) hasNonNftAssetInUtxoId(utxoId uint64, excludingExotic bool) bool {
+	if b.ns != nil && b.ns.HasNamesInUtxo(utxoId) {
 		return true
 	}
-	if b.ftIndexer.HasAssetInUtxo(utxoId) {
+	if b.ftIndexer != nil && b.ftIndexer.HasAssetInUtxo(utxoId) {
 		return true
 	}
-	if b.RunesIndexer.IsExistAsset(utxoId) {
+	if b.RunesIndexer != nil && b.RunesIndexer.IsExistAsset(utxoId) {
 		return true
 	}
-	if b.brc20Indexer.IsExistAsset(utxoId) {
+	if b.brc20Indexer != nil && b.brc20Indexer.IsExistAsset(utxoId) {
 		return true
 	}
-	if b.atomIndexer.HasAssetInUtxo(utxoId) {
+	if b.atomIndexer != nil && b.atomIndexer.HasAssetInUtxo(utxoId) {
 		return true
 	}
-	if !excludingExotic && b.exotic.HasExoticInUtxo(utxoId) {
+	if !excludingExotic && b.exotic != nil && b.exotic.HasExoticInUtxo(utxoId) {
 		return true
 	}
 	return false
@@ -77,8 +83,9 @@ func removeConfirmedPlainDuplicates(unconfirmed map[string]*common.TxOutput, con
 	}
 }
 
-// GetAssetUTXOsInAddressWithTickV3 returns confirmed UTXOs plus, for plain sats
-// only, unconfirmed outputs that MiniMemPool has fully classified as asset-free.
+// GetAssetUTXOsInAddressWithTickV3 returns confirmed UTXOs plus unconfirmed
+// outputs classified by MiniMemPool: asset-free outputs for plain sats, and
+// outputs whose computed assets contain the ticker otherwise.
 func (b *IndexerMgr) GetAssetUTXOsInAddressWithTickV3(address string, ticker *common.AssetName, includeInvalid bool) ([]*common.AssetsInUtxo, error) {
 	b.rpcEnter()
 	defer b.rpcLeft()
@@ -106,8 +113,7 @@ func (b *IndexerMgr) GetAssetUTXOsInAddressWithTickV3(address string, ticker *co
 		}
 	}
 
-	// Unconfirmed asset outputs are intentionally not reconstructed. Only
-	// outputs proven plain by MiniMemPool are safe to expose for immediate use.
+	// 内存池中的输出，资产由已确认或者已经分类的输入计算得到
 	if common.IsPlainAsset(ticker) {
 		for outpoint, info := range b.miniMempool.GetUnconfirmedPlainUtxoByAddress(address) {
 			if _, confirmed := confirmedOutpoints[outpoint]; confirmed {
@@ -115,6 +121,15 @@ func (b *IndexerMgr) GetAssetUTXOsInAddressWithTickV3(address string, ticker *co
 			}
 			mid = append(mid, info)
 		}
+	} else {
+		for outpoint, info := range b.miniMempool.GetUnconfirmedAssetUtxoByAddress(address) {
+			if _, confirmed := confirmedOutpoints[outpoint]; confirmed {
+				continue
+			}
+			if b.containAsset(info, ticker) {
+				mid = append(mid, info)
+			}
+		}
 	}
 
 	sort.Slice(mid, func(i, j int) bool {
@@ -146,13 +161,18 @@ func (b *IndexerMgr) getTxOutputWithUtxoV2(utxo string, excludingInvalid bool) *
 	}
 
 	output := common.NewTxOutput(0)
-	output.UtxoId = info.UtxoId
 	output.OutPointStr = utxo
 	output.OutValue.Value = info.Value
 	output.OutValue.PkScript = info.PkScript
+	b.fillTxOutputAssets(output, info.UtxoId, excludingInvalid)
+	return output
+}
 
-	assetmap := b.GetAssetsWithUtxo(info.UtxoId)
-	assetmap2 := b.GetUnbindingAssetsWithUtxoV2(info.UtxoId)
+// 各协议索引器中这个 utxo 上的资产
+func (b *IndexerMgr) fillTxOutputAssets(output *common.TxOutput, utxoId uint64, excludingInvalid bool) {
+	output.UtxoId = utxoId
+	assetmap := b.GetAssetsWithUtxo(utxoId)
+	assetmap2 := b.GetUnbindingAssetsWithUtxoV2(utxoId)
 	builder := common.NewTxAssetsBuilder(len(assetmap) + len(assetmap2))
 	for k, v := range assetmap {
 		offsets := v
@@ -195,7 +215,6 @@ func (b *IndexerMgr) getTxOutputWithUtxoV2(utxo string, excludingInvalid bool) *
 		builder.Add(&asset)
 	}
 	output.Assets = builder.Build()
-	return output
 }
 
 func (b *IndexerMgr) GetTxOutputWithUtxoV3(utxo string, excludingInvalid bool) *common.AssetsInUtxo {
@@ -207,7 +226,11 @@ func (b *IndexerMgr) GetTxOutputWithUtxoV3(utxo string, excludingInvalid bool) *
 func (b *IndexerMgr) getTxOutputWithUtxoV3(utxo string, excludingInvalid bool) *common.AssetsInUtxo {
 	output := b.getTxOutputWithUtxoV2(utxo, excludingInvalid)
 	if output == nil {
-		return nil
+		// 还在内存池中的输出
+		output = b.miniMempool.GetUnconfirmedOutput(utxo)
+		if output == nil {
+			return nil
+		}
 	}
 	return output.ToAssetsInUtxo()
 }
@@ -246,7 +269,8 @@ func (b *IndexerMgr) GetTickerInfo(tickerName *common.TickerName) *common.Ticker
 }
 
 // GetAssetSummaryInAddressV3 includes currently available unconfirmed plain
-// outputs in ALL_SAT and PLAIN_SAT. Unconfirmed asset balances remain excluded.
+// outputs in ALL_SAT and PLAIN_SAT, fungible assets in unconfirmed outputs and
+// brc20 received through transfer inscriptions spent in the mempool.
 func (b *IndexerMgr) GetAssetSummaryInAddressV3(address string) map[common.TickerName]*common.Decimal {
 	b.rpcEnter()
 	defer b.rpcLeft()
@@ -257,6 +281,7 @@ func (b *IndexerMgr) GetAssetSummaryInAddressV3(address string) map[common.Ticke
 	}
 	unconfirmedSpents := b.miniMempool.GetUnconfirmedSpentUtxoByAddress(address)
 	unconfirmedPlain := b.miniMempool.GetUnconfirmedPlainUtxoByAddress(address)
+	unconfirmedAssets := b.miniMempool.GetUnconfirmedAssetUtxoByAddress(address)
 	confirmedOutpoints := make(map[string]struct{}, len(utxos))
 	for utxoID := range utxos {
 		if outpoint, err := b.rpcService.GetUtxoByID(utxoID); err == nil {
@@ -264,6 +289,7 @@ func (b *IndexerMgr) GetAssetSummaryInAddressV3(address string) map[common.Ticke
 		}
 	}
 	removeConfirmedPlainDuplicates(unconfirmedPlain, confirmedOutpoints)
+	removeConfirmedPlainDuplicates(unconfirmedAssets, confirmedOutpoints)
 
 	result := make(map[common.TickerName]*common.Decimal)
 	nsAsset := b.getSubNameSummaryWithAddress(address, unconfirmedSpents)
@@ -278,13 +304,18 @@ func (b *IndexerMgr) GetAssetSummaryInAddressV3(address string) map[common.Ticke
 		result[tickName] = common.NewDefaultDecimal(v)
 	}
 
-	ftAsset := b.ftIndexer.GetAssetSummaryByAddress(utxos)
-	for k, v := range ftAsset {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
-		result[tickName] = common.NewDefaultDecimal(v)
+	if b.ftIndexer != nil {
+		ftAsset := b.ftIndexer.GetAssetSummaryByAddress(utxos)
+		for k, v := range ftAsset {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
+			result[tickName] = common.NewDefaultDecimal(v)
+		}
 	}
 
-	brc20Asset := b.brc20Indexer.GetAssetSummaryByAddress(b.rpcService.GetAddressId(address))
+	brc20Asset := make(map[string]*common.Decimal)
+	if b.brc20Indexer != nil {
+		brc20Asset = b.brc20Indexer.GetAssetSummaryByAddress(b.rpcService.GetAddressId(address))
+	}
 	for _, output := range unconfirmedSpents {
 		if len(output.Assets) == 0 {
 			continue
@@ -301,6 +332,9 @@ func (b *IndexerMgr) GetAssetSummaryInAddressV3(address string) map[common.Ticke
 			}
 		}
 	}
+	for k, v := range b.miniMempool.GetUnconfirmedBrc20ByAddress(address) {
+		brc20Asset[k] = brc20Asset[k].Add(v)
+	}
 	for k, v := range brc20Asset {
 		if v.IsZero() {
 			continue
@@ -309,16 +343,31 @@ func (b *IndexerMgr) GetAssetSummaryInAddressV3(address string) map[common.Ticke
 		result[tickName] = v
 	}
 
-	runesAsset := b.RunesIndexer.GetAddressAssets(b.rpcService.GetAddressId(address), utxos)
-	for _, v := range runesAsset {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_RUNES, Type: common.ASSET_TYPE_FT, Ticker: v.Rune}
-		result[tickName] = common.NewDecimalFromUint128(v.Balance, int(v.Divisibility))
+	if b.RunesIndexer != nil {
+		runesAsset := b.RunesIndexer.GetAddressAssets(b.rpcService.GetAddressId(address), utxos)
+		for _, v := range runesAsset {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_RUNES, Type: common.ASSET_TYPE_FT, Ticker: v.Rune}
+			result[tickName] = common.NewDecimalFromUint128(v.Balance, int(v.Divisibility))
+		}
 	}
 
-	atomAsset := b.atomIndexer.GetAssetSummaryByAddress(utxos)
-	for k, v := range atomAsset {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: k}
-		result[tickName] = common.NewDefaultDecimal(v)
+	if b.atomIndexer != nil {
+		atomAsset := b.atomIndexer.GetAssetSummaryByAddress(utxos)
+		for k, v := range atomAsset {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: k}
+			result[tickName] = common.NewDefaultDecimal(v)
+		}
+	}
+
+	// transfer 铭文不改变 brc20 的余额，nft 和名字只统计已确认的
+	for _, output := range unconfirmedAssets {
+		for _, asset := range output.Assets {
+			if output.Invalids[asset.Name] || !common.IsFungibleToken(&asset.Name) ||
+				asset.Name.Protocol == common.PROTOCOL_NAME_BRC20 {
+				continue
+			}
+			result[asset.Name] = result[asset.Name].Add(&asset.Amount)
+		}
 	}
 
 	totalSats := int64(0)
@@ -333,6 +382,9 @@ func (b *IndexerMgr) GetAssetSummaryInAddressV3(address string) map[common.Ticke
 	for _, output := range unconfirmedPlain {
 		totalSats += output.Value()
 	}
+	for _, output := range unconfirmedAssets {
+		totalSats += output.Value()
+	}
 	result[common.ASSET_ALL_SAT] = common.NewDefaultDecimal(totalSats)
 
 	exAssets, plainUtxos := b.getExoticSummaryByAddress(plainUtxoMap)
@@ -364,6 +416,9 @@ func (b *IndexerMgr) GetMintHistoryWithAddressV2(address string,
 	case common.PROTOCOL_NAME_ORDX:
 		switch tick.Type {
 		case common.ASSET_TYPE_FT:
+			if b.ftIndexer == nil {
+				return nil, 0
+			}
 			return b.ftIndexer.GetMintHistoryWithAddressV2(addressId, tick.Ticker, start, limit)
 		case common.ASSET_TYPE_NFT:
 		case common.ASSET_TYPE_NS:
@@ -371,6 +426,9 @@ func (b *IndexerMgr) GetMintHistoryWithAddressV2(address string,
 			return nil, 0
 		}
 	case common.PROTOCOL_NAME_BRC20:
+		if b.brc20Indexer == nil {
+			return nil, 0
+		}
 		return b.brc20Indexer.GetMintHistoryWithAddressV2(addressId, tick.Ticker, start, limit)
 	case common.PROTOCOL_NAME_RUNES:
 		return b.GetRunesMintHistoryWithAddress(addressId, tick.Ticker, start, limit)
@@ -385,25 +443,33 @@ func (b *IndexerMgr) GetAssetsWithUtxoV2(utxoId uint64) map[common.TickerName]*c
 	defer b.rpcLeft()
 
 	result := make(map[common.TickerName]*common.Decimal)
-	ftAssets := b.ftIndexer.GetAssetsWithUtxoV2(utxoId)
-	for k, v := range ftAssets {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
-		result[tickName] = common.NewDefaultDecimal(v)
+	if b.ftIndexer != nil {
+		ftAssets := b.ftIndexer.GetAssetsWithUtxoV2(utxoId)
+		for k, v := range ftAssets {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: k}
+			result[tickName] = common.NewDefaultDecimal(v)
+		}
 	}
-	runesAssets := b.RunesIndexer.GetUtxoAssets(utxoId)
-	for _, v := range runesAssets {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_RUNES, Type: common.ASSET_TYPE_FT, Ticker: v.Rune}
-		result[tickName] = common.NewDecimalFromUint128(v.Balance, 0)
+	if b.RunesIndexer != nil {
+		runesAssets := b.RunesIndexer.GetUtxoAssets(utxoId)
+		for _, v := range runesAssets {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_RUNES, Type: common.ASSET_TYPE_FT, Ticker: v.Rune}
+			result[tickName] = common.NewDecimalFromUint128(v.Balance, 0)
+		}
 	}
-	brc20Asset := b.brc20Indexer.GetUtxoAssets(utxoId)
-	if brc20Asset != nil && !brc20Asset.Invalid {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_BRC20, Type: common.ASSET_TYPE_FT, Ticker: brc20Asset.Name}
-		result[tickName] = brc20Asset.Amt
+	if b.brc20Indexer != nil {
+		brc20Asset := b.brc20Indexer.GetUtxoAssets(utxoId)
+		if brc20Asset != nil && !brc20Asset.Invalid {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_BRC20, Type: common.ASSET_TYPE_FT, Ticker: brc20Asset.Name}
+			result[tickName] = brc20Asset.Amt
+		}
 	}
-	atomAssets := b.atomIndexer.GetUtxoAssets(utxoId)
-	for ticker, amount := range atomAssets {
-		tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: ticker}
-		result[tickName] = common.NewDefaultDecimal(amount)
+	if b.atomIndexer != nil {
+		atomAssets := b.atomIndexer.GetUtxoAssets(utxoId)
+		for ticker, amount := range atomAssets {
+			tickName := common.TickerName{Protocol: common.PROTOCOL_NAME_ATOM, Type: common.ASSET_TYPE_FT, Ticker: ticker}
+			result[tickName] = common.NewDefaultDecimal(amount)
+		}
 	}
 	nfts := b.getNftsWithUtxo(utxoId)
 	for k, v := range nfts {
@@ -446,9 +512,17 @@ func (b *IndexerMgr) GetHoldersWithTickV2(tickerName *common.TickerName) map[uin
 	b.rpcEnter()
 	defer b.rpcLeft()
 	result := make(map[uint64]*common.Decimal)
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return result
+	}
 	switch tickerName.Protocol {
 	case common.PROTOCOL_NAME_ORDX:
-		holders := b.ftIndexer.GetHolderAndAmountWithTick(tickerName.Ticker)
+		var holders map[uint64]int64
+		if tickerName.Type == common.ASSET_TYPE_EXOTIC {
+			holders = b.exotic.GetHolderAndAmountWithTick(tickerName.Ticker)
+		} else {
+			holders = b.ftIndexer.GetHolderAndAmountWithTick(tickerName.Ticker)
+		}
 		for k, v := range holders {
 			result[k] = common.NewDefaultDecimal(v)
 		}
@@ -465,6 +539,9 @@ func (b *IndexerMgr) GetHoldersWithTickV2(tickerName *common.TickerName) map[uin
 func (b *IndexerMgr) GetMintAmountV2(tickerName *common.TickerName) (*common.Decimal, int64) {
 	b.rpcEnter()
 	defer b.rpcLeft()
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return nil, 0
+	}
 	switch tickerName.Protocol {
 	case common.PROTOCOL_NAME_ORDX:
 		amt, times := b.ftIndexer.GetMintAmount(tickerName.Ticker)
@@ -485,6 +562,9 @@ func (b *IndexerMgr) GetMintHistoryV2(tickerName *common.TickerName, start,
 	defer b.rpcLeft()
 
 	result := make([]*common.MintInfo, 0)
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return result
+	}
 	switch tickerName.Protocol {
 	case common.PROTOCOL_NAME_ORDX:
 		var ordxMintInfo []*common.MintAbbrInfo
@@ -544,6 +624,9 @@ func (b *IndexerMgr) IsAllowDeploy(tickerName *common.TickerName) error {
 	if tickerName.Type != common.ASSET_TYPE_FT {
 		return fmt.Errorf("invalid asset type")
 	}
+	if !b.isTickerProtocolEnabled(tickerName) {
+		return fmt.Errorf("protocol %s is disabled", tickerName.Protocol)
+	}
 	var err error
 	switch tickerName.Protocol {
 	case common.PROTOCOL_NAME_ORDX:
@@ -618,6 +701,10 @@ func (b *IndexerMgr) UnlockOrdinals(utxos []string, pubkey, sig []byte) (map[str
 			continue
 		}
 		buf := fmt.Sprintf("%s-%s-%s", utxo, hex.EncodeToString(pubkey), hex.EncodeToString(sig))
+		if b.nft == nil {
+			failed[utxo] = fmt.Errorf("protocol %s is disabled", config.PROTOCOL_NFT)
+			continue
+		}
 		if err = b.nft.DisableNftsInUtxo(info.UtxoId, []byte(buf)); err != nil {
 			failed[utxo] = err
 		}
@@ -638,15 +725,10 @@ func (b *IndexerMgr) GetLockedUTXOsInAddress(address string) ([]*common.AssetsIn
 		if err != nil {
 			continue
 		}
-		if b.ns.HasNamesInUtxo(utxoId) ||
-			b.ftIndexer.HasAssetInUtxo(utxoId) ||
-			b.RunesIndexer.IsExistAsset(utxoId) ||
-			b.brc20Indexer.IsExistAsset(utxoId) ||
-			b.atomIndexer.HasAssetInUtxo(utxoId) ||
-			b.exotic.HasExoticInUtxo(utxoId) {
+		if b.hasNonNftAssetInUtxoId(utxoId, false) {
 			continue
 		}
-		if !b.nft.HasNftInUtxo(utxoId) {
+		if b.nft == nil || !b.nft.HasNftInUtxo(utxoId) {
 			continue
 		}
 		info := b.getTxOutputWithUtxoV3(utxo, true)
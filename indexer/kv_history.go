@@ -0,0 +1,429 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+/*
+kv 存储的版本和修改记录
+
+每次写入和删除都带有签名和序号，同一个 key 的序号必须递增，旧的签名数据不能再次提交。
+	/<pubkey>/<key>                          当前的值
+	/history/<pubkey>/<hex(key)>/<sequence>  每个 key 最近的 maxKVVersions 个版本，包括删除
+	/feed/<id>                               所有修改，按 id 递增，保留最近的 maxKVFeedEntries 个
+	/feedid                                  最后的 id
+删除后仍然保留最后的版本，以后的写入要用更大的序号。
+*/
+
+const (
+	maxKVVersions    = 16
+	maxKVFeedEntries = 10000
+
+	defaultKVPageLimit = 100
+	maxKVPageLimit     = 1000
+)
+
+var errKVStop = errors.New("stop")
+
+const kvHistoryPrefix = "/history/"
+
+func getKvHistoryPrefix(pubkey string, key string) string {
+	return fmt.Sprintf("%s%s/%s/", kvHistoryPrefix, pubkey, hex.EncodeToString([]byte(key)))
+}
+
+func getKvHistoryKey(pubkey string, key string, sequence uint64) string {
+	return fmt.Sprintf("%s%016x", getKvHistoryPrefix(pubkey, key), sequence)
+}
+
+func getKvFeedKey(id uint64) string {
+	return fmt.Sprintf("/feed/%016x", id)
+}
+
+const kvFeedPrefix = "/feed/"
+const kvFeedIdKey = "/feedid"
+
+func verifyKVPut(value *common.KeyValue) error {
+	sig := value.Signature
+	value.Signature = nil
+	msg, err := json.Marshal(value)
+	value.Signature = sig
+	if err != nil {
+		return err
+	}
+	err = common.VerifySignOfMessage(msg, sig, value.PubKey)
+	if err != nil {
+		return fmt.Errorf("verify signature of key %s failed, %v", value.Key, err)
+	}
+	return nil
+}
+
+func verifyKVDelete(value *common.KeyDeletion) error {
+	sig := value.Signature
+	value.Signature = nil
+	msg, err := json.Marshal(value)
+	value.Signature = sig
+	if err != nil {
+		return err
+	}
+	err = common.VerifySignOfMessage(msg, sig, value.PubKey)
+	if err != nil {
+		return fmt.Errorf("verify signature of deleting key %s failed, %v", value.Key, err)
+	}
+	return nil
+}
+
+// 一次请求中的所有修改，在同一个 WriteBatch 中写入。调用者持有 kvMutex
+type kvWriter struct {
+	kvdb    common.KVDB
+	wb      common.WriteBatch
+	lastId  uint64
+	changes map[string]*common.KVChange // 这次请求中已经修改的 key -> 修改
+	now     int64
+}
+
+func newKVWriter(kvdb common.KVDB) (*kvWriter, error) {
+	lastId, err := getKVFeedLastId(kvdb)
+	if err != nil {
+		return nil, err
+	}
+	return &kvWriter{
+		kvdb:    kvdb,
+		wb:      kvdb.NewWriteBatch(),
+		lastId:  lastId,
+		changes: make(map[string]*common.KVChange),
+		now:     time.Now().Unix(),
+	}, nil
+}
+
+func getKVFeedLastId(kvdb common.KVDB) (uint64, error) {
+	buf, err := kvdb.Read([]byte(kvFeedIdKey))
+	if err != nil {
+		if errors.Is(err, common.ErrKeyNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(buf) != 8 {
+		return 0, fmt.Errorf("invalid kv feed id")
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+// 最后一次修改，没有修改过时返回 nil。升级前写入的值没有历史记录，序号和时间都是 0
+func (p *kvWriter) lastChange(pkStr, key string) (*common.KVChange, error) {
+	if change, ok := p.changes[getKvKey(pkStr, key)]; ok {
+		return change, nil
+	}
+
+	var last *common.KVChange
+	err := p.kvdb.BatchRead([]byte(getKvHistoryPrefix(pkStr, key)), true, func(k, v []byte) error {
+		var change common.KVChange
+		if err := db.DecodeBytes(v, &change); err != nil {
+			return err
+		}
+		last = &change
+		return errKVStop
+	})
+	if err != nil && !errors.Is(err, errKVStop) {
+		return nil, err
+	}
+	if last != nil {
+		return last, nil
+	}
+
+	var value common.KeyValue
+	err = db.GetValueFromDB([]byte(getKvKey(pkStr, key)), &value, p.kvdb)
+	if err == nil {
+		return &common.KVChange{Put: &value}, nil
+	}
+	if errors.Is(err, common.ErrKeyNotFound) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+func (p *kvWriter) checkSequence(pkStr, key string, sequence uint64) error {
+	last, err := p.lastChange(pkStr, key)
+	if err != nil {
+		return err
+	}
+	if last != nil && sequence <= last.Sequence() {
+		return fmt.Errorf("sequence %d of key %s is not greater than %d", sequence, key, last.Sequence())
+	}
+	return nil
+}
+
+func (p *kvWriter) put(value *common.KeyValue) error {
+	pkStr := hex.EncodeToString(value.PubKey)
+	if err := p.checkSequence(pkStr, value.Key, value.Sequence); err != nil {
+		return err
+	}
+	key := getKvKey(pkStr, value.Key)
+	if err := db.SetDB([]byte(key), value, p.wb); err != nil {
+		return err
+	}
+	return p.record(pkStr, &common.KVChange{Put: value})
+}
+
+func (p *kvWriter) del(value *common.KeyDeletion) error {
+	pkStr := hex.EncodeToString(value.PubKey)
+	if err := p.checkSequence(pkStr, value.Key, value.Sequence); err != nil {
+		return err
+	}
+	key := getKvKey(pkStr, value.Key)
+	if err := p.wb.Delete([]byte(key)); err != nil {
+		return err
+	}
+	return p.record(pkStr, &common.KVChange{Del: value})
+}
+
+// apply 写入其他索引器同步过来的修改，保留原来的时间。已经有这个修改或者更新的修改时返回 false。
+// 序号相同的不同修改（在不同的索引器上同时写入）见 kvChangeWins
+func (p *kvWriter) apply(change *common.KVChange) (bool, error) {
+	var err error
+	if change.Put != nil {
+		err = verifyKVPut(change.Put)
+	} else if change.Del != nil {
+		err = verifyKVDelete(change.Del)
+	} else {
+		err = fmt.Errorf("empty kv change %d", change.Id)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	pkStr := hex.EncodeToString(change.PubKey())
+	last, err := p.lastChange(pkStr, change.Key())
+	if err != nil {
+		return false, err
+	}
+	if last != nil && !kvChangeWins(change, last) {
+		return false, nil
+	}
+
+	key := getKvKey(pkStr, change.Key())
+	if change.Put != nil {
+		err = db.SetDB([]byte(key), change.Put, p.wb)
+	} else {
+		err = p.wb.Delete([]byte(key))
+	}
+	if err != nil {
+		return false, err
+	}
+	applied := &common.KVChange{Time: change.Time, Put: change.Put, Del: change.Del}
+	return true, p.record(pkStr, applied)
+}
+
+func kvChangeSignature(change *common.KVChange) []byte {
+	if change.Put != nil {
+		return change.Put.Signature
+	}
+	return change.Del.Signature
+}
+
+// kvChangeWins 序号大的修改更新。序号相同时没有先后之分(时间不在签名中，不能信任)，
+// 比较签名只是为了让各索引器选出同一个修改，并不代表哪个修改更新
+func kvChangeWins(change, last *common.KVChange) bool {
+	if change.Sequence() != last.Sequence() {
+		return change.Sequence() > last.Sequence()
+	}
+	return bytes.Compare(kvChangeSignature(change), kvChangeSignature(last)) > 0
+}
+
+// 写入历史版本和修改记录，删除过期的记录
+func (p *kvWriter) record(pkStr string, change *common.KVChange) error {
+	p.lastId++
+	change.Id = p.lastId
+	if change.Time == 0 {
+		change.Time = p.now
+	}
+	key := change.Key()
+	sequence := change.Sequence()
+	p.changes[getKvKey(pkStr, key)] = change
+
+	if err := db.SetDB([]byte(getKvHistoryKey(pkStr, key, sequence)), change, p.wb); err != nil {
+		return err
+	}
+	if err := db.SetDB([]byte(getKvFeedKey(change.Id)), change, p.wb); err != nil {
+		return err
+	}
+	if change.Id > maxKVFeedEntries {
+		if err := p.wb.Delete([]byte(getKvFeedKey(change.Id - maxKVFeedEntries))); err != nil {
+			return err
+		}
+	}
+
+	// 数据库中的版本加上这个版本，保留 maxKVVersions 个
+	count := 0
+	return p.kvdb.BatchRead([]byte(getKvHistoryPrefix(pkStr, key)), true, func(k, v []byte) error {
+		count++
+		if count < maxKVVersions {
+			return nil
+		}
+		return p.wb.Delete(k)
+	})
+}
+
+func (p *kvWriter) flush() error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, p.lastId)
+	if err := p.wb.Put([]byte(kvFeedIdKey), buf); err != nil {
+		return err
+	}
+	return p.wb.Flush()
+}
+
+func (p *kvWriter) close() {
+	p.wb.Close()
+}
+
+func kvPageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultKVPageLimit
+	}
+	if limit > maxKVPageLimit {
+		return maxKVPageLimit
+	}
+	return limit
+}
+
+// ListKVs 按 key 的顺序列出 pubkey 下以 prefix 开头的值，从 cursor 之后开始。
+// 返回下一页的 cursor，没有更多数据时为空
+func (b *IndexerMgr) ListKVs(pubkey []byte, prefix, cursor string, limit int) ([]*common.KeyValue, string, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	limit = kvPageLimit(limit)
+	pkStr := hex.EncodeToString(pubkey)
+	var seek []byte
+	if cursor != "" {
+		seek = []byte(getKvKey(pkStr, cursor))
+	}
+
+	result := make([]*common.KeyValue, 0)
+	more := false
+	err := b.kvDB.BatchReadV2([]byte(getKvKey(pkStr, prefix)), seek, false, func(k, v []byte) error {
+		if seek != nil && string(k) == string(seek) {
+			return nil
+		}
+		if len(result) == limit {
+			more = true
+			return errKVStop
+		}
+		var value common.KeyValue
+		if err := db.DecodeBytes(v, &value); err != nil {
+			common.Log.Errorf("decoding key %s failed, %v", string(k), err)
+			return nil
+		}
+		result = append(result, &value)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errKVStop) {
+		return nil, "", err
+	}
+
+	next := ""
+	if more {
+		next = result[len(result)-1].Key
+	}
+	return result, next, nil
+}
+
+// GetKVHistory key 最近的版本，从旧到新，最后一个可能是删除
+func (b *IndexerMgr) GetKVHistory(pubkey []byte, key string) ([]*common.KVChange, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	pkStr := hex.EncodeToString(pubkey)
+	result := make([]*common.KVChange, 0)
+	err := b.kvDB.BatchRead([]byte(getKvHistoryPrefix(pkStr, key)), false, func(k, v []byte) error {
+		var change common.KVChange
+		if err := db.DecodeBytes(v, &change); err != nil {
+			return err
+		}
+		result = append(result, &change)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetKVChanges id 大于 since 的修改，同时返回最后的 id。
+// 只保留最近的 maxKVFeedEntries 个修改，第一个修改的 id 不是 since+1 时，调用者错过了一些修改
+func (b *IndexerMgr) GetKVChanges(since uint64, limit int) ([]*common.KVChange, uint64, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	limit = kvPageLimit(limit)
+	lastId, err := getKVFeedLastId(b.kvDB)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]*common.KVChange, 0)
+	err = b.kvDB.BatchReadV2([]byte(kvFeedPrefix), []byte(getKvFeedKey(since+1)), false, func(k, v []byte) error {
+		if len(result) == limit {
+			return errKVStop
+		}
+		var change common.KVChange
+		if err := db.DecodeBytes(v, &change); err != nil {
+			return err
+		}
+		result = append(result, &change)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errKVStop) {
+		return nil, 0, err
+	}
+	return result, lastId, nil
+}
+
+// GetKVRecords 按数据库中的顺序返回所有 key 的历史版本，从 cursor 之后开始，用于其他索引器全量同步。
+// 返回下一页的 cursor，没有更多数据时为空
+func (b *IndexerMgr) GetKVRecords(cursor string, limit int) ([]*common.KVChange, string, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	limit = kvPageLimit(limit)
+	var seek []byte
+	if cursor != "" {
+		seek = []byte(kvHistoryPrefix + cursor)
+	}
+
+	result := make([]*common.KVChange, 0)
+	next := ""
+	err := b.kvDB.BatchReadV2([]byte(kvHistoryPrefix), seek, false, func(k, v []byte) error {
+		if seek != nil && string(k) == string(seek) {
+			return nil
+		}
+		if len(result) == limit {
+			return errKVStop
+		}
+		var change common.KVChange
+		if err := db.DecodeBytes(v, &change); err != nil {
+			return err
+		}
+		result = append(result, &change)
+		next = strings.TrimPrefix(string(k), kvHistoryPrefix)
+		return nil
+	})
+	if errors.Is(err, errKVStop) {
+		return result, next, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return result, "", nil
+}
@@ -0,0 +1,548 @@
+package indexer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+/*
+kv 存储在多个索引器之间同步
+
+配置在 kv_peers.peers 中的索引器互相拉取对方的修改记录（/kv/changes），每个修改都有用户的签名，
+在本地重新验证签名，并且跟 PutKVs/DelKVs 一样检查公钥、数据大小和 key 的数量，再按 kvWriter.apply 的规则写入。
+同步过来的修改也会出现在本地的修改记录中，所以不需要每两个索引器都互相配置。
+启动时先全量同步一次（/kv/records），之后增量同步；对方的修改记录被截断或者重置时，重新全量同步。
+注册的公钥（RegisterPubKeyInfo）每一轮都同步，按刷新时间更新。注册要有 kv_peers.trusted_keys 中的公钥的签名，
+刷新时间不能在将来，通道地址用注册时的索引器公钥重新计算验证。
+*/
+
+const (
+	defaultKVPeerPollSeconds = 10
+	kvPeerPageLimit          = 500
+	// 注册的刷新时间最多比本地时间晚这么多
+	maxKVRegistrationClockSkew = 10 * time.Minute
+)
+
+// 其他索引器，*IndexerMgr 也实现了这个接口，测试中可以直接同步进程中的多个实例
+type kvPeer interface {
+	GetKVChanges(since uint64, limit int) ([]*common.KVChange, uint64, error)
+	GetKVRecords(cursor string, limit int) ([]*common.KVChange, string, error)
+	GetKVRegistrations() ([]*RegisterPubKeyInfo, error)
+}
+
+type kvPeerState struct {
+	name   string
+	peer   kvPeer
+	cursor uint64 // 已经同步的对方的修改 id
+	synced bool   // 已经全量同步过
+}
+
+func newKVPeerState(name string, peer kvPeer) *kvPeerState {
+	return &kvPeerState{name: name, peer: peer}
+}
+
+func (b *IndexerMgr) runKVPeers(stopChan chan bool) {
+	if len(b.cfg.KVPeers.Peers) == 0 {
+		return
+	}
+	n := b.cfg.KVPeers.PollSeconds
+	if n <= 0 {
+		n = defaultKVPeerPollSeconds
+	}
+	ticker := time.NewTicker(time.Duration(n) * time.Second)
+	defer ticker.Stop()
+
+	peers := make([]*kvPeerState, 0, len(b.cfg.KVPeers.Peers))
+	names := make([]string, 0, len(b.cfg.KVPeers.Peers))
+	for _, peer := range b.cfg.KVPeers.Peers {
+		peers = append(peers, newKVPeerState(peer.URL, newKVHttpPeer(peer.URL, peer.ApiKey)))
+		names = append(names, peer.URL)
+	}
+	common.Log.Infof("kv replication with %v started", names)
+	for {
+		for _, peer := range peers {
+			if err := b.syncKVPeer(peer); err != nil {
+				common.Log.Errorf("kv replication with %s failed, %v", peer.name, err)
+			}
+		}
+
+		select {
+		case <-stopChan:
+			common.Log.Infof("kv replication exited.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// 一轮同步，拉取对方新的注册和所有新的修改。先同步注册，新注册的公钥的修改才能通过检查
+func (b *IndexerMgr) syncKVPeer(p *kvPeerState) error {
+	regs, err := p.peer.GetKVRegistrations()
+	if err != nil {
+		return err
+	}
+	n, err := b.applyKVRegistrations(regs)
+	if err != nil {
+		return err
+	}
+	if n != 0 {
+		common.Log.Infof("kv replication applied %d registrations from %s", n, p.name)
+	}
+
+	for {
+		if !p.synced {
+			if err := b.catchUpKVPeer(p); err != nil {
+				return err
+			}
+		}
+
+		changes, last, err := p.peer.GetKVChanges(p.cursor, kvPeerPageLimit)
+		if err != nil {
+			return err
+		}
+		if last < p.cursor || (len(changes) != 0 && changes[0].Id != p.cursor+1) {
+			common.Log.Warnf("kv changes of %s are truncated or reset at %d, catch up again", p.name, p.cursor)
+			p.synced = false
+			continue
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		n, err := b.applyKVChanges(changes)
+		if err != nil {
+			return err
+		}
+		p.cursor = changes[len(changes)-1].Id
+		if n != 0 {
+			common.Log.Infof("kv replication applied %d changes from %s, at %d", n, p.name, p.cursor)
+		}
+	}
+}
+
+// 全量同步，之后从开始时对方的最后一个修改开始增量同步
+func (b *IndexerMgr) catchUpKVPeer(p *kvPeerState) error {
+	_, last, err := p.peer.GetKVChanges(0, 1)
+	if err != nil {
+		return err
+	}
+
+	nChanges := 0
+	cursor := ""
+	for {
+		records, next, err := p.peer.GetKVRecords(cursor, kvPeerPageLimit)
+		if err != nil {
+			return err
+		}
+		n, err := b.applyKVChanges(records)
+		if err != nil {
+			return err
+		}
+		nChanges += n
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	p.cursor = last
+	p.synced = true
+	common.Log.Infof("kv replication caught up with %s at %d, %d changes applied", p.name, last, nChanges)
+	return nil
+}
+
+// 签名不对或者没有通过检查的修改不会写入，也不影响其他修改
+func (b *IndexerMgr) applyKVChanges(changes []*common.KVChange) (int, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+	b.kvMutex.Lock()
+	defer b.kvMutex.Unlock()
+
+	if b.kvDB == nil {
+		return 0, fmt.Errorf("kv db closed")
+	}
+	w, err := newKVWriter(b.kvDB)
+	if err != nil {
+		return 0, err
+	}
+	defer w.close()
+
+	checker := newKVChangeChecker(b)
+	count := 0
+	for _, change := range changes {
+		if err := checker.check(change); err != nil {
+			common.Log.Errorf("ignore kv change %d, %v", change.Id, err)
+			continue
+		}
+		applied, err := w.apply(change)
+		if err != nil {
+			common.Log.Errorf("ignore kv change %d, %v", change.Id, err)
+			continue
+		}
+		if applied {
+			checker.applied(change)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return count, w.flush()
+}
+
+// 跟 PutKVs/DelKVs 相同的检查：支持的公钥，数据大小，每个公钥的 key 的数量
+type kvChangeChecker struct {
+	b         *IndexerMgr
+	supported map[string]bool
+	newKeys   map[string]map[string]struct{} // 这一批中新增的 key
+}
+
+func newKVChangeChecker(b *IndexerMgr) *kvChangeChecker {
+	return &kvChangeChecker{
+		b:         b,
+		supported: make(map[string]bool),
+		newKeys:   make(map[string]map[string]struct{}),
+	}
+}
+
+func (p *kvChangeChecker) check(change *common.KVChange) error {
+	if change.Put == nil && change.Del == nil {
+		return fmt.Errorf("empty kv change")
+	}
+	pkStr := hex.EncodeToString(change.PubKey())
+	supported, ok := p.supported[pkStr]
+	if !ok {
+		supported = p.b.isSupportedKey(change.PubKey())
+		p.supported[pkStr] = supported
+	}
+	if !supported {
+		return fmt.Errorf("unsupport pubkey %s", pkStr)
+	}
+	if change.Put == nil {
+		return nil
+	}
+
+	if len(change.Put.Value) > maxKVValueBytes {
+		return fmt.Errorf("too large data %d", len(change.Put.Value))
+	}
+	if _, ok := p.newKeys[pkStr][change.Put.Key]; ok {
+		return nil
+	}
+	_, err := p.b.kvDB.Read([]byte(getKvKey(pkStr, change.Put.Key)))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, common.ErrKeyNotFound) {
+		return err
+	}
+	existingKeys, err := p.b.countKVKeys(pkStr)
+	if err != nil {
+		return err
+	}
+	if existingKeys+len(p.newKeys[pkStr]) >= maxKVKeysPerPubKey {
+		return fmt.Errorf("KV key limit exceeded for pubkey %s, max %d", pkStr, maxKVKeysPerPubKey)
+	}
+	return nil
+}
+
+func (p *kvChangeChecker) applied(change *common.KVChange) {
+	if change.Put == nil {
+		return
+	}
+	pkStr := hex.EncodeToString(change.PubKey())
+	if p.newKeys[pkStr] == nil {
+		p.newKeys[pkStr] = make(map[string]struct{})
+	}
+	p.newKeys[pkStr][change.Put.Key] = struct{}{}
+}
+
+func (b *IndexerMgr) GetKVRegistrations() ([]*RegisterPubKeyInfo, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	return b.readKVRegistrations()
+}
+
+func (b *IndexerMgr) readKVRegistrations() ([]*RegisterPubKeyInfo, error) {
+	result := make([]*RegisterPubKeyInfo, 0)
+	err := b.kvDB.BatchRead([]byte(getRegisterKey("")), false, func(k, v []byte) error {
+		var value RegisterPubKeyInfo
+		if err := db.DecodeBytes(v, &value); err != nil {
+			common.Log.Errorf("decoding key %s failed, %v", string(k), err)
+			return nil
+		}
+		result = append(result, &value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// migrateKVRegistrations 启动时处理升级前的注册：用的是本地的公钥，也没有签名。
+// 没有配置 sign_key 时只补上公钥，配置后下次启动再签名
+func (b *IndexerMgr) migrateKVRegistrations() error {
+	b.kvMutex.Lock()
+	defer b.kvMutex.Unlock()
+
+	regs, err := b.readKVRegistrations()
+	if err != nil {
+		return err
+	}
+	privKey, err := b.kvSignKey()
+	if err != nil {
+		return err
+	}
+	wb := b.kvDB.NewWriteBatch()
+	defer wb.Close()
+	count := 0
+	for _, reg := range regs {
+		changed := false
+		if reg.IndexerPubKey == "" {
+			reg.IndexerPubKey = b.indexerPubKey()
+			changed = true
+		}
+		if len(reg.Signature) == 0 && privKey != nil && reg.IndexerPubKey == b.indexerPubKey() {
+			if err := b.signKVRegistration(reg); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		if err := db.SetDB([]byte(getRegisterKey(string(reg.PubKey))), reg, wb); err != nil {
+			return err
+		}
+		count++
+	}
+	if count == 0 {
+		return nil
+	}
+	common.Log.Infof("%d kv registrations migrated", count)
+	return wb.Flush()
+}
+
+func (b *IndexerMgr) applyKVRegistrations(regs []*RegisterPubKeyInfo) (int, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+	b.kvMutex.Lock()
+	defer b.kvMutex.Unlock()
+
+	if b.kvDB == nil {
+		return 0, fmt.Errorf("kv db closed")
+	}
+	count := 0
+	for _, reg := range regs {
+		minerPubKey := string(reg.PubKey)
+		if err := b.verifyKVRegistration(reg); err != nil {
+			common.Log.Errorf("ignore registration of %s, %v", minerPubKey, err)
+			continue
+		}
+
+		key := getRegisterKey(minerPubKey)
+		var value RegisterPubKeyInfo
+		err := db.GobGetDB([]byte(key), &value, b.kvDB)
+		if err == nil && value.RefreshTime >= reg.RefreshTime {
+			continue
+		}
+		if err != nil && !errors.Is(err, common.ErrKeyNotFound) {
+			return count, err
+		}
+		if err := db.GobSetDB([]byte(key), reg, b.kvDB); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// 本节点签名注册用的私钥，没有配置时返回 nil
+func (b *IndexerMgr) kvSignKey() (*btcec.PrivateKey, error) {
+	if b.cfg.KVPeers.SignKey == "" {
+		return nil, nil
+	}
+	buf, err := hex.DecodeString(b.cfg.KVPeers.SignKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kv_peers.sign_key, %v", err)
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(buf)
+	return privKey, nil
+}
+
+func kvRegistrationMessage(reg *RegisterPubKeyInfo) ([]byte, error) {
+	sig := reg.Signature
+	reg.Signature = nil
+	msg, err := json.Marshal(reg)
+	reg.Signature = sig
+	return msg, err
+}
+
+// 没有配置签名的私钥时不签名，其他索引器不会接受这个注册
+func (b *IndexerMgr) signKVRegistration(reg *RegisterPubKeyInfo) error {
+	privKey, err := b.kvSignKey()
+	if err != nil || privKey == nil {
+		return err
+	}
+	reg.SignerPubKey = hex.EncodeToString(privKey.PubKey().SerializeCompressed())
+	msg, err := kvRegistrationMessage(reg)
+	if err != nil {
+		return err
+	}
+	reg.Signature = ecdsa.Sign(privKey, chainhash.HashB(msg)).Serialize()
+	return nil
+}
+
+func (b *IndexerMgr) isTrustedKVSigner(pubkey string) bool {
+	for _, key := range b.cfg.KVPeers.TrustedKeys {
+		if strings.EqualFold(key, pubkey) {
+			return true
+		}
+	}
+	privKey, err := b.kvSignKey()
+	return err == nil && privKey != nil &&
+		hex.EncodeToString(privKey.PubKey().SerializeCompressed()) == strings.ToLower(pubkey)
+}
+
+func (b *IndexerMgr) verifyKVRegistration(reg *RegisterPubKeyInfo) error {
+	if !b.isTrustedKVSigner(reg.SignerPubKey) {
+		return fmt.Errorf("registration signed by untrusted key %q", reg.SignerPubKey)
+	}
+	signer, err := hex.DecodeString(reg.SignerPubKey)
+	if err != nil {
+		return err
+	}
+	msg, err := kvRegistrationMessage(reg)
+	if err != nil {
+		return err
+	}
+	if err := common.VerifySignOfMessage(msg, reg.Signature, signer); err != nil {
+		return fmt.Errorf("verify signature of registration failed, %v", err)
+	}
+	if reg.RefreshTime > time.Now().Add(maxKVRegistrationClockSkew).Unix() {
+		return fmt.Errorf("refresh time %d is in the future", reg.RefreshTime)
+	}
+
+	pk1, err := hex.DecodeString(reg.IndexerPubKey)
+	if err != nil {
+		return err
+	}
+	pk2, err := hex.DecodeString(string(reg.PubKey))
+	if err != nil {
+		return err
+	}
+	channelAddr, err := common.GetChannelAddress(pk1, pk2, b.chaincfgParam)
+	if err != nil {
+		return err
+	}
+	if channelAddr != reg.ChannelAddr {
+		return fmt.Errorf("channel address %s mismatch, expected %s", reg.ChannelAddr, channelAddr)
+	}
+	return nil
+}
+
+// 通过 rpc 访问其他索引器
+type kvHttpPeer struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newKVHttpPeer(peer, apiKey string) *kvHttpPeer {
+	return &kvHttpPeer{
+		url:    strings.TrimSuffix(peer, "/"),
+		apiKey: apiKey,
+		client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+type kvPeerResp struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (p *kvPeerResp) check() error {
+	if p.Code != 0 {
+		return fmt.Errorf("peer returns %d %s", p.Code, p.Msg)
+	}
+	return nil
+}
+
+func (p *kvHttpPeer) get(path string, query url.Values, result interface{ check() error }) error {
+	u := p.url + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer returns %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return err
+	}
+	return result.check()
+}
+
+func (p *kvHttpPeer) GetKVChanges(since uint64, limit int) ([]*common.KVChange, uint64, error) {
+	var result struct {
+		kvPeerResp
+		Changes []*common.KVChange `json:"changes"`
+		Last    uint64             `json:"last"`
+	}
+	query := url.Values{}
+	query.Set("since", fmt.Sprintf("%d", since))
+	query.Set("limit", fmt.Sprintf("%d", limit))
+	if err := p.get("/kv/changes", query, &result); err != nil {
+		return nil, 0, err
+	}
+	return result.Changes, result.Last, nil
+}
+
+func (p *kvHttpPeer) GetKVRecords(cursor string, limit int) ([]*common.KVChange, string, error) {
+	var result struct {
+		kvPeerResp
+		Records []*common.KVChange `json:"records"`
+		Next    string             `json:"next"`
+	}
+	query := url.Values{}
+	query.Set("cursor", cursor)
+	query.Set("limit", fmt.Sprintf("%d", limit))
+	if err := p.get("/kv/records", query, &result); err != nil {
+		return nil, "", err
+	}
+	return result.Records, result.Next, nil
+}
+
+func (p *kvHttpPeer) GetKVRegistrations() ([]*RegisterPubKeyInfo, error) {
+	var result struct {
+		kvPeerResp
+		Registrations []*RegisterPubKeyInfo `json:"registrations"`
+	}
+	if err := p.get("/kv/registrations", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Registrations, nil
+}
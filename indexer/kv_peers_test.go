@@ -0,0 +1,445 @@
+package indexer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/indexer/db"
+	"gopkg.in/yaml.v2"
+)
+
+func newKVPeerTestIndexer(t *testing.T) *IndexerMgr {
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.YamlConf{PubKey: hex.EncodeToString(privKey.PubKey().SerializeCompressed())}
+	cfg.KVPeers.SignKey = hex.EncodeToString(signKey.Serialize())
+	return &IndexerMgr{
+		cfg:           cfg,
+		kvDB:          db.NewMemDB(""),
+		chaincfgParam: &chaincfg.MainNetParams,
+	}
+}
+
+func kvPeerTestSignerPubKey(t *testing.T, b *IndexerMgr) string {
+	privKey, err := b.kvSignKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(privKey.PubKey().SerializeCompressed())
+}
+
+// 互相信任对方签名的注册
+func kvPeerTestTrust(t *testing.T, indexers ...*IndexerMgr) {
+	for _, b := range indexers {
+		for _, other := range indexers {
+			if other != b {
+				b.cfg.KVPeers.TrustedKeys = append(b.cfg.KVPeers.TrustedKeys, kvPeerTestSignerPubKey(t, other))
+			}
+		}
+	}
+}
+
+func kvPeerTestRegister(t *testing.T, b *IndexerMgr, signer *kvTestSigner) {
+	t.Helper()
+	if _, err := b.RegisterPubKey(hex.EncodeToString(signer.pubkey)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func kvPeerTestSync(t *testing.T, b *IndexerMgr, p *kvPeerState) {
+	t.Helper()
+	if err := b.syncKVPeer(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func kvPeerTestValue(t *testing.T, b *IndexerMgr, pubkey []byte, key string) string {
+	t.Helper()
+	values, err := b.GetKVs(pubkey, []string{key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return string(values[0].Value)
+}
+
+func TestKVPeersReplicateThroughPeers(t *testing.T) {
+	a := newKVPeerTestIndexer(t)
+	b := newKVPeerTestIndexer(t)
+	c := newKVPeerTestIndexer(t)
+	bFromA := newKVPeerState("a", a)
+	cFromB := newKVPeerState("b", b)
+	signer := newKVTestSigner(t)
+	kvPeerTestTrust(t, a, b, c)
+	kvPeerTestRegister(t, a, signer)
+
+	err := kvTestWrite(a.kvDB, func(w *kvWriter) error {
+		if err := w.put(signer.put("x", "1", 1)); err != nil {
+			return err
+		}
+		return w.put(signer.put("y", "1", 1))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kvPeerTestSync(t, b, bFromA)
+	kvPeerTestSync(t, c, cFromB)
+	if v := kvPeerTestValue(t, c, signer.pubkey, "x"); v != "1" {
+		t.Fatalf("c has x=%q", v)
+	}
+
+	// 增量同步写入和删除
+	err = kvTestWrite(a.kvDB, func(w *kvWriter) error {
+		if err := w.put(signer.put("x", "2", 2)); err != nil {
+			return err
+		}
+		return w.del(signer.del("y", 2))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kvPeerTestSync(t, b, bFromA)
+	kvPeerTestSync(t, c, cFromB)
+	if v := kvPeerTestValue(t, c, signer.pubkey, "x"); v != "2" {
+		t.Fatalf("c has x=%q", v)
+	}
+	if v := kvPeerTestValue(t, c, signer.pubkey, "y"); v != "" {
+		t.Fatalf("c has deleted y=%q", v)
+	}
+	history, err := c.GetKVHistory(signer.pubkey, "y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Del == nil {
+		t.Fatalf("unexpected history of y on c, %v", history)
+	}
+
+	// c 上不能重放旧的写入
+	err = kvTestWrite(c.kvDB, func(w *kvWriter) error { return w.put(signer.put("y", "1", 1)) })
+	if err == nil {
+		t.Fatal("expected replayed put to be rejected on the follower")
+	}
+}
+
+func TestKVPeersResolveConflicts(t *testing.T) {
+	a := newKVPeerTestIndexer(t)
+	b := newKVPeerTestIndexer(t)
+	aFromB := newKVPeerState("b", b)
+	bFromA := newKVPeerState("a", a)
+	signer := newKVTestSigner(t)
+	kvPeerTestTrust(t, a, b)
+	kvPeerTestRegister(t, a, signer)
+
+	// 同一个序号在两个索引器上分别写入
+	if err := kvTestWrite(a.kvDB, func(w *kvWriter) error { return w.put(signer.put("x", "a", 1)) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := kvTestWrite(b.kvDB, func(w *kvWriter) error { return w.put(signer.put("x", "b", 1)) }); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		kvPeerTestSync(t, a, aFromB)
+		kvPeerTestSync(t, b, bFromA)
+	}
+	va := kvPeerTestValue(t, a, signer.pubkey, "x")
+	vb := kvPeerTestValue(t, b, signer.pubkey, "x")
+	if va != vb || va == "" {
+		t.Fatalf("conflict resolved differently, a=%q b=%q", va, vb)
+	}
+
+	// 更大的序号优先
+	if err := kvTestWrite(b.kvDB, func(w *kvWriter) error { return w.put(signer.put("x", "c", 2)) }); err != nil {
+		t.Fatal(err)
+	}
+	kvPeerTestSync(t, a, aFromB)
+	kvPeerTestSync(t, b, bFromA)
+	if v := kvPeerTestValue(t, a, signer.pubkey, "x"); v != "c" {
+		t.Fatalf("a has x=%q", v)
+	}
+	if v := kvPeerTestValue(t, b, signer.pubkey, "x"); v != "c" {
+		t.Fatalf("b has x=%q", v)
+	}
+}
+
+type kvForgedPeer struct {
+	*IndexerMgr
+}
+
+func (p *kvForgedPeer) GetKVChanges(since uint64, limit int) ([]*common.KVChange, uint64, error) {
+	changes, last, err := p.IndexerMgr.GetKVChanges(since, limit)
+	for _, change := range changes {
+		if change.Put != nil {
+			change.Put.Value = []byte("forged")
+		}
+	}
+	return changes, last, err
+}
+
+func (p *kvForgedPeer) GetKVRegistrations() ([]*RegisterPubKeyInfo, error) {
+	regs, err := p.IndexerMgr.GetKVRegistrations()
+	for _, reg := range regs {
+		reg.ChannelAddr = "bc1qforged"
+	}
+	return regs, err
+}
+
+func TestKVPeersVerifyRecords(t *testing.T) {
+	a := newKVPeerTestIndexer(t)
+	b := newKVPeerTestIndexer(t)
+	signer := newKVTestSigner(t)
+	kvPeerTestTrust(t, a, b)
+	kvPeerTestRegister(t, a, signer)
+	kvPeerTestRegister(t, b, signer)
+	reg, err := b.GetKVRegistrations()
+	if err != nil || len(reg) != 1 {
+		t.Fatalf("registration on b, %v %v", reg, err)
+	}
+
+	bFromA := newKVPeerState("a", &kvForgedPeer{a})
+	bFromA.synced = true
+	kvPeerTestSync(t, b, bFromA)
+	if err := kvTestWrite(a.kvDB, func(w *kvWriter) error { return w.put(signer.put("x", "1", 1)) }); err != nil {
+		t.Fatal(err)
+	}
+	kvPeerTestSync(t, b, bFromA)
+	if v := kvPeerTestValue(t, b, signer.pubkey, "x"); v != "" {
+		t.Fatalf("forged value x=%q applied", v)
+	}
+
+	// 全量同步，注册的通道地址被修改过
+	bFromA.synced = false
+	kvPeerTestSync(t, b, bFromA)
+	regs, err := b.GetKVRegistrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regs) != 1 || regs[0].ChannelAddr != reg[0].ChannelAddr {
+		t.Fatalf("forged registration applied, %v", regs)
+	}
+}
+
+func TestKVPeersVerifyRegistrations(t *testing.T) {
+	a := newKVPeerTestIndexer(t)
+	b := newKVPeerTestIndexer(t)
+	signer := newKVTestSigner(t)
+	kvPeerTestRegister(t, a, signer)
+	regs, err := a.GetKVRegistrations()
+	if err != nil || len(regs) != 1 {
+		t.Fatalf("registration on a, %v %v", regs, err)
+	}
+	valid := *regs[0]
+
+	// 没有信任 a 的签名
+	if n, err := b.applyKVRegistrations(regs); err != nil || n != 0 {
+		t.Fatalf("registration of untrusted signer applied, %d %v", n, err)
+	}
+
+	kvPeerTestTrust(t, a, b)
+	unsigned := valid
+	unsigned.Signature = nil
+	future := valid
+	future.RefreshTime = time.Now().Add(time.Hour).Unix()
+	for name, reg := range map[string]*RegisterPubKeyInfo{"unsigned": &unsigned, "future": &future} {
+		if n, err := b.applyKVRegistrations([]*RegisterPubKeyInfo{reg}); err != nil || n != 0 {
+			t.Fatalf("%s registration applied, %d %v", name, n, err)
+		}
+	}
+
+	// 重新签名的将来的刷新时间也不接受
+	if err := a.signKVRegistration(&future); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := b.applyKVRegistrations([]*RegisterPubKeyInfo{&future}); err != nil || n != 0 {
+		t.Fatalf("future registration applied, %d %v", n, err)
+	}
+
+	if n, err := b.applyKVRegistrations([]*RegisterPubKeyInfo{&valid}); err != nil || n != 1 {
+		t.Fatalf("valid registration not applied, %d %v", n, err)
+	}
+}
+
+func TestKVPeersCheckChanges(t *testing.T) {
+	a := newKVPeerTestIndexer(t)
+	b := newKVPeerTestIndexer(t)
+	signer := newKVTestSigner(t)
+	kvPeerTestTrust(t, a, b)
+
+	// 没有注册的公钥
+	changes := []*common.KVChange{{Id: 1, Put: signer.put("x", "1", 1)}}
+	if n, err := b.applyKVChanges(changes); err != nil || n != 0 {
+		t.Fatalf("change of unsupported pubkey applied, %d %v", n, err)
+	}
+
+	kvPeerTestRegister(t, b, signer)
+	large := signer.put("large", strings.Repeat("x", maxKVValueBytes+1), 1)
+	if n, err := b.applyKVChanges([]*common.KVChange{{Id: 1, Put: large}}); err != nil || n != 0 {
+		t.Fatalf("too large value applied, %d %v", n, err)
+	}
+
+	// key 的数量不能超过上限，已有的 key 还能修改
+	changes = changes[:0]
+	for i := 0; i <= maxKVKeysPerPubKey; i++ {
+		changes = append(changes, &common.KVChange{Id: uint64(i + 1), Put: signer.put(fmt.Sprintf("k%d", i), "1", 1)})
+	}
+	if n, err := b.applyKVChanges(changes); err != nil || n != maxKVKeysPerPubKey {
+		t.Fatalf("applied %d changes, %v", n, err)
+	}
+	changes = []*common.KVChange{
+		{Id: 1, Put: signer.put("k0", "2", 2)},
+		{Id: 2, Put: signer.put("overflow", "1", 1)},
+	}
+	if n, err := b.applyKVChanges(changes); err != nil || n != 1 {
+		t.Fatalf("applied %d changes, %v", n, err)
+	}
+	if v := kvPeerTestValue(t, b, signer.pubkey, "k0"); v != "2" {
+		t.Fatalf("b has k0=%q", v)
+	}
+}
+
+// 时间不在签名中，不影响冲突的结果
+func TestKVChangeWinsIgnoresTime(t *testing.T) {
+	signer := newKVTestSigner(t)
+	x := &common.KVChange{Put: signer.put("x", "a", 1)}
+	y := &common.KVChange{Put: signer.put("x", "b", 1)}
+	want := kvChangeWins(x, y)
+	if kvChangeWins(y, x) == want {
+		t.Fatal("conflict is not decided")
+	}
+	x.Time, y.Time = 1, 100
+	if kvChangeWins(x, y) != want {
+		t.Fatal("conflict is decided by the unsigned time")
+	}
+	x.Time, y.Time = 100, 1
+	if kvChangeWins(x, y) != want {
+		t.Fatal("conflict is decided by the unsigned time")
+	}
+}
+
+func TestKVPeersCatchUpOnRestart(t *testing.T) {
+	a := newKVPeerTestIndexer(t)
+	b := newKVPeerTestIndexer(t)
+	signer := newKVTestSigner(t)
+	minerPubKey := hex.EncodeToString(signer.pubkey)
+	kvPeerTestTrust(t, a, b)
+
+	indexerPubKey, err := a.RegisterPubKey(minerPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		value := signer.put("x", string(rune('0'+i)), uint64(i))
+		if err := kvTestWrite(a.kvDB, func(w *kvWriter) error { return w.put(value) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// b 重启后不知道之前同步到哪里，全量同步
+	kvPeerTestSync(t, b, newKVPeerState("a", a))
+	if v := kvPeerTestValue(t, b, signer.pubkey, "x"); v != "3" {
+		t.Fatalf("b has x=%q", v)
+	}
+	history, err := b.GetKVHistory(signer.pubkey, "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("b has %d versions", len(history))
+	}
+	if pubkey, err := b.RegisterPubKey(minerPubKey); err != nil || pubkey != indexerPubKey {
+		t.Fatalf("registration not replicated, %s %v", pubkey, err)
+	}
+
+	// 对方的修改记录重置了
+	p := newKVPeerState("a", a)
+	kvPeerTestSync(t, b, p)
+	p.cursor += 100
+	if err := kvTestWrite(a.kvDB, func(w *kvWriter) error { return w.put(signer.put("x", "4", 4)) }); err != nil {
+		t.Fatal(err)
+	}
+	kvPeerTestSync(t, b, p)
+	if v := kvPeerTestValue(t, b, signer.pubkey, "x"); v != "4" {
+		t.Fatalf("b has x=%q", v)
+	}
+}
+
+// 升级前的注册没有签名，启动时签名一次，读取时不再修改
+func TestKVPeersMigrateLegacyRegistrations(t *testing.T) {
+	a := newKVPeerTestIndexer(t)
+	b := newKVPeerTestIndexer(t)
+	kvPeerTestTrust(t, a, b)
+	signer := newKVTestSigner(t)
+	indexerPubKey, _ := hex.DecodeString(a.indexerPubKey())
+	channelAddr, err := common.GetChannelAddress(indexerPubKey, signer.pubkey, a.chaincfgParam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	minerPubKey := hex.EncodeToString(signer.pubkey)
+	legacy := &RegisterPubKeyInfo{
+		PubKey:      []byte(minerPubKey),
+		ChannelAddr: channelAddr,
+		RefreshTime: time.Now().Unix(),
+	}
+	if err := db.GobSetDB([]byte(getRegisterKey(minerPubKey)), legacy, a.kvDB); err != nil {
+		t.Fatal(err)
+	}
+
+	regs, err := a.GetKVRegistrations()
+	if err != nil || len(regs) != 1 || len(regs[0].Signature) != 0 {
+		t.Fatalf("legacy registration changed by reading, %v %v", regs, err)
+	}
+	if err := a.migrateKVRegistrations(); err != nil {
+		t.Fatal(err)
+	}
+	regs, err = a.GetKVRegistrations()
+	if err != nil || len(regs) != 1 || regs[0].IndexerPubKey != a.indexerPubKey() || len(regs[0].Signature) == 0 {
+		t.Fatalf("legacy registration not migrated, %v %v", regs, err)
+	}
+	if n, err := b.applyKVRegistrations(regs); err != nil || n != 1 {
+		t.Fatalf("migrated registration not applied, %d %v", n, err)
+	}
+}
+
+// 配置中的 api key 用 Bearer 发给对方，只写地址的旧配置也能解析
+func TestKVHttpPeerSendsApiKey(t *testing.T) {
+	var conf config.KVPeers
+	err := yaml.Unmarshal([]byte("peers:\n  - http://a\n  - { url: http://b, api_key: secret }\n"), &conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Peers) != 2 || conf.Peers[0].URL != "http://a" || conf.Peers[0].ApiKey != "" ||
+		conf.Peers[1].URL != "http://b" || conf.Peers[1].ApiKey != "secret" {
+		t.Fatalf("peers %+v", conf.Peers)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"code":0,"registrations":[]}`)
+	}))
+	defer server.Close()
+	if _, err := newKVHttpPeer(server.URL, "").GetKVRegistrations(); err == nil {
+		t.Fatal("request without api key should fail")
+	}
+	if _, err := newKVHttpPeer(server.URL, conf.Peers[1].ApiKey).GetKVRegistrations(); err != nil {
+		t.Fatal(err)
+	}
+}
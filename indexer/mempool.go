@@ -19,12 +19,14 @@ import (
 const mempoolRetryMaxPasses = 64
 
 // MiniMemPool is a deliberately small mempool view. It tracks confirmed UTXOs
-// spent by unconfirmed transactions and only exposes new outputs that can be
-// proven to be plain sats. Unconfirmed asset outputs are classified as
-// non-plain/unknown and are never recursively rebuilt.
+// spent by unconfirmed transactions and classifies new outputs once all their
+// inputs are known: plain sats, outputs carrying assets computed from their
+// inputs (resolved parent first along a chain), or non-plain when the assets
+// can't be simulated. Parents are never rebuilt on demand.
 type UserUtxoInMempool struct {
 	SpentUtxo               map[string]*common.TxOutput
 	UnconfirmedPlainUtxoMap map[string]*common.TxOutput
+	UnconfirmedAssetUtxoMap map[string]*common.TxOutput
 }
 
 type MiniMemPool struct {
@@ -46,9 +48,12 @@ type MiniMemPool struct {
 	// recursively rebuilding its parent. The per-address map below only keeps
 	// currently available plain outputs.
 	knownPlainUtxoMap map[string]*common.TxOutput
-	utxoStateMap      map[string]mempoolUtxoState
-	classifiedTxMap   map[string]bool
-	addrUtxoMap       map[string]*UserUtxoInMempool
+	// assetUtxoMap 同上，保存资产已经计算出来的输出
+	assetUtxoMap     map[string]*mempoolAssetOutput
+	brc20EffectsByTx map[string]*mempoolBrc20Effects
+	utxoStateMap     map[string]mempoolUtxoState
+	classifiedTxMap  map[string]bool
+	addrUtxoMap      map[string]*UserUtxoInMempool
 
 	// Serialize transaction classification and all graph mutations.
 	processingMutex sync.Mutex
@@ -67,6 +72,8 @@ type MiniMemPool struct {
 	workerWG       sync.WaitGroup
 	peer           *peer.Peer
 	lastSyncTime   int64
+	// 交易和区块由 zmq 推送，不再连接 P2P
+	zmqMode bool
 }
 
 func NewMiniMemPool() *MiniMemPool {
@@ -83,6 +90,8 @@ func (p *MiniMemPool) resetStateLocked() {
 	p.childrenByTx = make(map[string]map[string]struct{})
 	p.confirmedSpent = make(map[string]struct{})
 	p.knownPlainUtxoMap = make(map[string]*common.TxOutput)
+	p.assetUtxoMap = make(map[string]*mempoolAssetOutput)
+	p.brc20EffectsByTx = make(map[string]*mempoolBrc20Effects)
 	p.utxoStateMap = make(map[string]mempoolUtxoState)
 	p.classifiedTxMap = make(map[string]bool)
 	p.addrUtxoMap = make(map[string]*UserUtxoInMempool)
@@ -104,12 +113,15 @@ func (p *MiniMemPool) Start(cfg *config.Bitcoin) {
 	}
 	p.running = true
 	p.stopChan = make(chan struct{})
+	p.zmqMode = cfg.ZMQ.RawTx != ""
 	stop := p.stopChan
 	p.lifecycleMutex.Unlock()
 
-	netParam := instance.GetChainParam()
-	addr := fmt.Sprintf("%s:%s", cfg.Host, netParam.DefaultPort)
-	p.startWorker(stop, func() { p.listenP2PTx(addr, stop) })
+	if !p.zmqMode {
+		netParam := instance.GetChainParam()
+		addr := fmt.Sprintf("%s:%s", cfg.Host, netParam.DefaultPort)
+		p.startWorker(stop, func() { p.listenP2PTx(addr, stop) })
+	}
 	p.startWorker(stop, func() { p.traceThread(stop) })
 	p.scheduleSync(true)
 }
@@ -140,11 +152,14 @@ func (p *MiniMemPool) traceThread(stop <-chan struct{}) {
 		case <-ticker.C:
 			p.mutex.RLock()
 			availablePlain := 0
+			availableAsset := 0
 			for _, user := range p.addrUtxoMap {
 				availablePlain += len(user.UnconfirmedPlainUtxoMap)
+				availableAsset += len(user.UnconfirmedAssetUtxoMap)
 			}
-			common.Log.Infof("mempool: tx=%d spent=%d confirmed-spent=%d known-plain=%d available-plain=%d",
-				len(p.txMap), len(p.spentByOutpoint), len(p.confirmedSpent), len(p.knownPlainUtxoMap), availablePlain)
+			common.Log.Infof("mempool: tx=%d spent=%d confirmed-spent=%d known-plain=%d available-plain=%d known-asset=%d available-asset=%d",
+				len(p.txMap), len(p.spentByOutpoint), len(p.confirmedSpent), len(p.knownPlainUtxoMap), availablePlain,
+				len(p.assetUtxoMap), availableAsset)
 			p.mutex.RUnlock()
 		}
 	}
@@ -355,11 +370,11 @@ func (p *MiniMemPool) txBroadcasted(tx *wire.MsgTx) {
 		p.mutex.Lock()
 		p.classifiedTxMap[txID] = true
 		p.mutex.Unlock()
-		common.Log.Debugf("mempool tx %s output classification stopped by non-plain/unknown mempool input", txID)
+		common.Log.Debugf("mempool tx %s output classification stopped by unresolved mempool input", txID)
 		return
 	}
 
-	outputs, occupied, ok := p.allocateKnownMempoolTx(tx, inputs)
+	allocation, ok := p.allocateKnownMempoolTx(tx, inputs)
 	if !ok {
 		p.mutex.Lock()
 		p.classifiedTxMap[txID] = true
@@ -367,7 +382,7 @@ func (p *MiniMemPool) txBroadcasted(tx *wire.MsgTx) {
 		common.Log.Debugf("mempool tx %s output classification intentionally unresolved", txID)
 		return
 	}
-	p.commitMempoolOutputs(tx, outputs, occupied)
+	p.commitMempoolOutputs(tx, allocation)
 }
 
 func (p *MiniMemPool) admitTransactionLocked(tx *wire.MsgTx) {
@@ -407,8 +422,9 @@ func (p *MiniMemPool) admitTransactionLocked(tx *wire.MsgTx) {
 		}
 		p.childrenByTx[parentID][txID] = struct{}{}
 		p.spentByOutpoint[outpoint] = txID
-		p.removePlainAvailabilityLocked(outpoint)
+		p.removeAvailabilityLocked(outpoint)
 	}
+	p.publishMempoolTx(tx, inputs)
 }
 
 func (p *MiniMemPool) commitMempoolSpentInputs(txID string, inputs []*mempoolResolvedInput) {
@@ -426,41 +442,46 @@ func (p *MiniMemPool) commitMempoolSpentInputs(txID string, inputs []*mempoolRes
 		}
 		p.spentUtxoMap[outpoint] = info.Clone()
 		p.addSpentToAddressLocked(outpoint, info)
-		p.removePlainAvailabilityLocked(outpoint)
+		p.removeAvailabilityLocked(outpoint)
+		p.publishMempoolSpend(txID, info)
 	}
 }
 
-func (p *MiniMemPool) commitMempoolOutputs(tx *wire.MsgTx, outputs []*common.TxOutput, occupied []bool) {
+func (p *MiniMemPool) commitMempoolOutputs(tx *wire.MsgTx, allocation *mempoolTxAllocation) {
 	txID := tx.TxID()
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	for i, output := range outputs {
-		if output == nil || i >= len(occupied) {
+	for i, assetOutput := range allocation.outputs {
+		if assetOutput == nil || i >= len(allocation.unknown) {
 			continue
 		}
 		outpoint := fmt.Sprintf("%s:%d", txID, i)
-		if mempoolOutputUnspendable(tx.TxOut[i]) || occupied[i] {
+		if mempoolOutputUnspendable(tx.TxOut[i]) || allocation.unknown[i] {
 			p.utxoStateMap[outpoint] = mempoolUtxoNonPlain
-			p.removeKnownPlainLocked(outpoint)
+			p.removeKnownOutputLocked(outpoint)
 			continue
 		}
 
-		plain := output.Clone()
-		plain.UtxoId = common.INVALID_ID
-		plain.OutPointStr = outpoint
-		plain.Assets = nil
-		plain.Offsets = make(map[common.AssetName]common.AssetOffsets)
-		plain.SatBindingMap = make(map[int64]*common.AssetInfo)
-		plain.Invalids = make(map[common.AssetName]bool)
-		p.utxoStateMap[outpoint] = mempoolUtxoPlain
-		p.knownPlainUtxoMap[outpoint] = plain
-
-		if p.spentByOutpoint[outpoint] == "" {
-			if _, confirmed := p.confirmedSpent[outpoint]; !confirmed {
-				p.addPlainAvailabilityLocked(outpoint, plain)
-			}
+		known := assetOutput.clone()
+		known.output.UtxoId = common.INVALID_ID
+		known.output.OutPointStr = outpoint
+		if known.output.HasAsset() {
+			p.utxoStateMap[outpoint] = mempoolUtxoAsset
+			p.assetUtxoMap[outpoint] = known
+		} else {
+			plain := known.output
+			plain.Assets = nil
+			plain.Offsets = make(map[common.AssetName]common.AssetOffsets)
+			plain.SatBindingMap = make(map[int64]*common.AssetInfo)
+			plain.Invalids = make(map[common.AssetName]bool)
+			p.utxoStateMap[outpoint] = mempoolUtxoPlain
+			p.knownPlainUtxoMap[outpoint] = plain
 		}
+		p.restoreAvailabilityLocked(outpoint)
+	}
+	if !allocation.brc20.empty() {
+		p.brc20EffectsByTx[txID] = allocation.brc20
 	}
 	p.classifiedTxMap[txID] = true
 }
@@ -471,6 +492,7 @@ func (p *MiniMemPool) getOrCreateUserLocked(address string) *UserUtxoInMempool {
 		user = &UserUtxoInMempool{
 			SpentUtxo:               make(map[string]*common.TxOutput),
 			UnconfirmedPlainUtxoMap: make(map[string]*common.TxOutput),
+			UnconfirmedAssetUtxoMap: make(map[string]*common.TxOutput),
 		}
 		p.addrUtxoMap[address] = user
 	}
@@ -505,40 +527,45 @@ func (p *MiniMemPool) removeSpentDetailLocked(outpoint string) {
 	}
 }
 
-func (p *MiniMemPool) addPlainAvailabilityLocked(outpoint string, output *common.TxOutput) {
+func (p *MiniMemPool) addAvailabilityLocked(outpoint string) {
+	output := p.knownOutputLocked(outpoint)
 	address, ok := p.addressForOutput(output)
 	if !ok {
 		return
 	}
-	p.getOrCreateUserLocked(address).UnconfirmedPlainUtxoMap[outpoint] = output.Clone()
+	user := p.getOrCreateUserLocked(address)
+	if p.utxoStateMap[outpoint] == mempoolUtxoAsset {
+		user.UnconfirmedAssetUtxoMap[outpoint] = output.Clone()
+	} else {
+		user.UnconfirmedPlainUtxoMap[outpoint] = output.Clone()
+	}
 }
 
-func (p *MiniMemPool) removePlainAvailabilityLocked(outpoint string) {
-	output := p.knownPlainUtxoMap[outpoint]
+func (p *MiniMemPool) removeAvailabilityLocked(outpoint string) {
+	output := p.knownOutputLocked(outpoint)
 	address, ok := p.addressForOutput(output)
 	if !ok {
 		return
 	}
 	if user := p.addrUtxoMap[address]; user != nil {
 		delete(user.UnconfirmedPlainUtxoMap, outpoint)
+		delete(user.UnconfirmedAssetUtxoMap, outpoint)
 	}
 }
 
-func (p *MiniMemPool) restorePlainAvailabilityLocked(outpoint string) {
-	if p.spentByOutpoint[outpoint] != "" {
-		return
-	}
-	if _, confirmed := p.confirmedSpent[outpoint]; confirmed {
+func (p *MiniMemPool) restoreAvailabilityLocked(outpoint string) {
+	if !p.availableLocked(outpoint) {
 		return
 	}
-	if output := p.knownPlainUtxoMap[outpoint]; output != nil {
-		p.addPlainAvailabilityLocked(outpoint, output)
+	if p.knownOutputLocked(outpoint) != nil {
+		p.addAvailabilityLocked(outpoint)
 	}
 }
 
-func (p *MiniMemPool) removeKnownPlainLocked(outpoint string) {
-	p.removePlainAvailabilityLocked(outpoint)
+func (p *MiniMemPool) removeKnownOutputLocked(outpoint string) {
+	p.removeAvailabilityLocked(outpoint)
 	delete(p.knownPlainUtxoMap, outpoint)
+	delete(p.assetUtxoMap, outpoint)
 }
 
 // removeTransactionLocked requires p.mutex. recursive is used for RBF/conflict
@@ -574,7 +601,7 @@ func (p *MiniMemPool) removeTransactionLocked(txID string, restoreInputs, recurs
 			delete(p.spentByOutpoint, outpoint)
 			if restoreInputs {
 				p.removeSpentDetailLocked(outpoint)
-				p.restorePlainAvailabilityLocked(outpoint)
+				p.restoreAvailabilityLocked(outpoint)
 			}
 		}
 	}
@@ -582,11 +609,12 @@ func (p *MiniMemPool) removeTransactionLocked(txID string, restoreInputs, recurs
 	if tx != nil {
 		for i := range tx.TxOut {
 			outpoint := fmt.Sprintf("%s:%d", txID, i)
-			p.removeKnownPlainLocked(outpoint)
+			p.removeKnownOutputLocked(outpoint)
 			delete(p.utxoStateMap, outpoint)
 		}
 	}
 	delete(p.txMap, txID)
+	delete(p.brc20EffectsByTx, txID)
 	delete(p.classifiedTxMap, txID)
 	delete(p.inputsByTx, txID)
 	delete(p.childrenByTx, txID)
@@ -646,7 +674,7 @@ func (p *MiniMemPool) confirmTransactionLocked(tx *wire.MsgTx) {
 		}
 		delete(p.spentByOutpoint, outpoint)
 		p.confirmedSpent[outpoint] = struct{}{}
-		p.removePlainAvailabilityLocked(outpoint)
+		p.removeAvailabilityLocked(outpoint)
 	}
 	// Preserve valid descendants: once this transaction confirms, children may
 	// remain in the node mempool and will resolve through the confirmed index.
@@ -834,6 +862,14 @@ func (p *MiniMemPool) IsSpent(utxo string) bool {
 	return confirmed
 }
 
+// Stats returns the number of tracked transactions, inputs spent by them and
+// outputs created by them.
+func (p *MiniMemPool) Stats() (txs, spentUtxos, newUtxos int) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return len(p.txMap), len(p.spentByOutpoint), len(p.utxoStateMap)
+}
+
 func (p *MiniMemPool) GetSpentUtxoByAddress(address string) []string {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
@@ -868,13 +904,9 @@ func (p *MiniMemPool) GetUnconfirmedPlainUtxoByAddress(address string) map[strin
 	}
 	result := make(map[string]*common.TxOutput, len(addrUtxo.UnconfirmedPlainUtxoMap))
 	for outpoint, output := range addrUtxo.UnconfirmedPlainUtxoMap {
-		if p.spentByOutpoint[outpoint] != "" {
-			continue
-		}
-		if _, confirmed := p.confirmedSpent[outpoint]; confirmed {
-			continue
+		if p.availableLocked(outpoint) {
+			result[outpoint] = output.Clone()
 		}
-		result[outpoint] = output.Clone()
 	}
 	return result
 }
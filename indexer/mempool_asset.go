@@ -15,6 +15,7 @@ import (
 	atomidx "github.com/sat20-labs/indexer/indexer/atom"
 	"github.com/sat20-labs/indexer/indexer/ord"
 	"github.com/sat20-labs/indexer/indexer/ord/ord0_14_1"
+	"github.com/sat20-labs/indexer/indexer/runes"
 	"github.com/sat20-labs/indexer/indexer/runes/runestone"
 	"lukechampine.com/uint128"
 )
@@ -25,6 +26,8 @@ const (
 	mempoolUtxoUnknown mempoolUtxoState = iota
 	mempoolUtxoPlain
 	mempoolUtxoNonPlain
+	// 资产已经计算出来，子交易可以继续使用
+	mempoolUtxoAsset
 )
 
 type mempoolResolveStatus uint8
@@ -39,12 +42,16 @@ type mempoolResolvedInput struct {
 	output    *common.TxOutput
 	confirmed bool
 	index     int
+	// 未确认的输入，runes 和 atom 不在索引器中，由父交易计算得到
+	runes []*runes.UtxoAsset
+	atom  []*atomidx.UtxoBalance
 }
 
-// resolveMempoolInputs deliberately never walks to an unconfirmed parent.
-// Inputs are analyzable only when they are either confirmed in the indexer or
-// are outputs already classified as plain by MiniMemPool. A known non-plain
-// mempool input permanently blocks output classification for this transaction.
+// resolveMempoolInputs never rebuilds an unconfirmed parent on demand. Inputs
+// are analyzable when they are confirmed in the indexer or are outputs already
+// classified by MiniMemPool, either as plain or with computed assets, so a
+// chain resolves parent first through the retry passes. A mempool input whose
+// assets can't be computed permanently blocks classification of this tx.
 func (p *MiniMemPool) resolveMempoolInputs(tx *wire.MsgTx) ([]*mempoolResolvedInput, mempoolResolveStatus) {
 	inputs := make([]*mempoolResolvedInput, 0, len(tx.TxIn))
 	status := mempoolResolveComplete
@@ -60,6 +67,7 @@ func (p *MiniMemPool) resolveMempoolInputs(tx *wire.MsgTx) ([]*mempoolResolvedIn
 		parentTx, parentInPool := p.txMap[parentID]
 		state, stateKnown := p.utxoStateMap[outpoint]
 		plainOutput := p.knownPlainUtxoMap[outpoint]
+		assetOutput := p.assetUtxoMap[outpoint]
 		parentClassified := p.classifiedTxMap[parentID]
 		p.mutex.RUnlock()
 
@@ -73,6 +81,10 @@ func (p *MiniMemPool) resolveMempoolInputs(tx *wire.MsgTx) ([]*mempoolResolvedIn
 			switch {
 			case stateKnown && state == mempoolUtxoPlain && plainOutput != nil:
 				inputs = append(inputs, &mempoolResolvedInput{output: plainOutput.Clone(), confirmed: false, index: i})
+			case stateKnown && state == mempoolUtxoAsset && assetOutput != nil:
+				input := assetOutput.toResolvedInput()
+				input.index = i
+				inputs = append(inputs, input)
 			case stateKnown && state == mempoolUtxoNonPlain:
 				inputs = append(inputs, &mempoolResolvedInput{output: placeholder, confirmed: false, index: i})
 				status = mempoolResolveBlocked
@@ -102,37 +114,42 @@ func (p *MiniMemPool) resolveMempoolInputs(tx *wire.MsgTx) ([]*mempoolResolvedIn
 	return inputs, status
 }
 
-func (p *MiniMemPool) allocateKnownMempoolTx(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]*common.TxOutput, []bool, bool) {
-	outputs, occupied, ok := allocateBoundMempoolOutputs(tx, inputs)
-	if !ok {
-		return nil, nil, false
+func (p *mempoolResolvedInput) runesAssets() []*runes.UtxoAsset {
+	if !p.confirmed {
+		return p.runes
 	}
+	if p.output == nil || p.output.UtxoId == common.INVALID_ID {
+		return nil
+	}
+	return instance.RunesIndexer.GetUtxoAssets(p.output.UtxoId)
+}
 
-	runeOccupied, ok := allocateMempoolRunes(tx, inputs)
-	if !ok {
-		return nil, nil, false
+func (p *mempoolResolvedInput) atomBalances() []*atomidx.UtxoBalance {
+	if !p.confirmed {
+		return p.atom
 	}
-	for i := range occupied {
-		occupied[i] = occupied[i] || runeOccupied[i]
+	if p.output == nil || p.output.UtxoId == common.INVALID_ID {
+		return nil
 	}
+	return instance.atomIndexer.GetUtxoBalances(p.output.UtxoId)
+}
 
-	atomOccupied, ok := allocateMempoolAtom(tx, inputs)
+// cutBoundMempoolOutputs 按聪的偏移，把输入中绑定聪的资产分配到输出，
+// 返回的 fee 是所有输出之后剩下的部分，也就是给矿工的手续费，可能为 nil
+func cutBoundMempoolOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]*common.TxOutput, *common.TxOutput, bool) {
+	aggregate, ok := aggregateMempoolInputs(inputs)
 	if !ok {
 		return nil, nil, false
 	}
-	for i := range occupied {
-		occupied[i] = occupied[i] || atomOccupied[i]
-	}
-
-	markMempoolInscriptionOutputs(tx, inputs, occupied)
-	return outputs, occupied, true
+	return cutMempoolAggregate(tx, aggregate)
 }
 
-func allocateBoundMempoolOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]*common.TxOutput, []bool, bool) {
+// aggregateMempoolInputs 把输入按顺序拼接起来，runes 和 atom 不绑定聪，单独计算
+func aggregateMempoolInputs(inputs []*mempoolResolvedInput) (*common.TxOutput, bool) {
 	aggregate := common.NewTxOutput(0)
 	for _, resolved := range inputs {
 		if resolved == nil || resolved.output == nil {
-			return nil, nil, false
+			return nil, false
 		}
 		input := resolved.output.Clone()
 		remove := make([]common.AssetName, 0)
@@ -148,16 +165,18 @@ func allocateBoundMempoolOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInput)
 
 		for _, asset := range input.Assets {
 			if asset.BindingSat == 0 && len(input.Offsets[asset.Name]) == 0 {
-				return nil, nil, false
+				return nil, false
 			}
 		}
 		if err := aggregate.Append(input); err != nil {
-			return nil, nil, false
+			return nil, false
 		}
 	}
+	return aggregate, true
+}
 
+func cutMempoolAggregate(tx *wire.MsgTx, aggregate *common.TxOutput) ([]*common.TxOutput, *common.TxOutput, bool) {
 	outputs := make([]*common.TxOutput, len(tx.TxOut))
-	occupied := make([]bool, len(tx.TxOut))
 	remaining := aggregate
 	for i, txOut := range tx.TxOut {
 		if remaining == nil {
@@ -177,25 +196,62 @@ func allocateBoundMempoolOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInput)
 		part.OutValue.PkScript = txOut.PkScript
 		part.OutPointStr = fmt.Sprintf("%s:%d", tx.TxID(), i)
 		outputs[i] = part
-		occupied[i] = part.HasAsset()
 		remaining = rest
 	}
-	return outputs, occupied, true
+	return outputs, remaining, true
+}
+
+type mempoolRunesFlow struct {
+	assets   map[runestone.RuneId]*runes.UtxoAsset
+	outputs  []map[runestone.RuneId]uint128.Uint128
+	burns    []*mempoolRunesBurn
+	cenotaph bool
+	// 含有 mint 或 etching，新发行的部分没有模拟
+	issuance bool
+}
+
+func (p *mempoolRunesFlow) allocate(output int, id runestone.RuneId, amount uint128.Uint128) {
+	if p.outputs[output] == nil {
+		p.outputs[output] = make(map[runestone.RuneId]uint128.Uint128)
+	}
+	p.outputs[output][id] = p.outputs[output][id].Add(amount)
+}
+
+type mempoolRunesBurn struct {
+	id     runestone.RuneId
+	amount uint128.Uint128
+	output int // -1 表示没有对应的输出
+	reason string
+}
+
+func (p *mempoolRunesFlow) burn(id runestone.RuneId, amount uint128.Uint128, output int, reason string) {
+	if amount.IsZero() {
+		return
+	}
+	p.burns = append(p.burns, &mempoolRunesBurn{id: id, amount: amount, output: output, reason: reason})
 }
 
-func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]bool, bool) {
-	occupied := make([]bool, len(tx.TxOut))
+// simulateMempoolRunes 按 runestone 的规则，计算输入中的 runes 在输出中的分配和销毁
+func simulateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) (*mempoolRunesFlow, bool) {
+	flow := &mempoolRunesFlow{
+		assets:  make(map[runestone.RuneId]*runes.UtxoAsset),
+		outputs: make([]map[runestone.RuneId]uint128.Uint128, len(tx.TxOut)),
+	}
 	balances := make(map[runestone.RuneId]uint128.Uint128)
 	for _, resolved := range inputs {
-		if resolved == nil || !resolved.confirmed || resolved.output == nil || resolved.output.UtxoId == common.INVALID_ID {
+		if instance.RunesIndexer == nil {
+			break
+		}
+		if resolved == nil {
 			continue
 		}
-		for _, asset := range instance.RunesIndexer.GetUtxoAssets(resolved.output.UtxoId) {
+		for _, asset := range resolved.runesAssets() {
 			id, err := runestone.RuneIdFromString(asset.RuneId)
 			if err != nil || id == nil {
 				return nil, false
 			}
 			balances[*id] = balances[*id].Add(asset.Balance)
+			flow.assets[*id] = asset
 		}
 	}
 
@@ -207,7 +263,11 @@ func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]boo
 	}
 
 	if artifact != nil && artifact.Cenotaph != nil {
-		return occupied, true
+		flow.cenotaph = true
+		for id, balance := range balances {
+			flow.burn(id, balance, -1, common.TX_FLOW_REASON_CENOTAPH)
+		}
+		return flow, true
 	}
 
 	var stone *runestone.Runestone
@@ -217,12 +277,12 @@ func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]boo
 			return nil, false
 		}
 		if stone.Mint != nil || stone.Etching != nil {
-			return nil, false
+			flow.issuance = true
 		}
 	}
 
 	if len(balances) == 0 {
-		return occupied, true
+		return flow, true
 	}
 
 	if stone != nil {
@@ -240,6 +300,7 @@ func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]boo
 			if edict.Output == uint32(len(tx.TxOut)) {
 				destinations := mempoolSpendableOutputIndexes(tx)
 				if len(destinations) == 0 {
+					flow.burn(edict.ID, balance, -1, common.TX_FLOW_REASON_UNALLOCATED)
 					balances[edict.ID] = uint128.Zero
 					continue
 				}
@@ -247,8 +308,12 @@ func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]boo
 					share := balance.Div64(uint64(len(destinations)))
 					remainder := balance.Mod64(uint64(len(destinations)))
 					for pos, output := range destinations {
-						if !share.IsZero() || uint64(pos) < remainder {
-							occupied[output] = true
+						amount := share
+						if uint64(pos) < remainder {
+							amount = amount.Add64(1)
+						}
+						if !amount.IsZero() {
+							flow.allocate(output, edict.ID, amount)
 						}
 					}
 					balances[edict.ID] = uint128.Zero
@@ -264,7 +329,7 @@ func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]boo
 						take = balance
 					}
 					if !take.IsZero() {
-						occupied[output] = true
+						flow.allocate(output, edict.ID, take)
 						balance = balance.Sub(take)
 					}
 				}
@@ -278,8 +343,10 @@ func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]boo
 			}
 			if !take.IsZero() {
 				output := int(edict.Output)
-				if !mempoolOutputUnspendable(tx.TxOut[output]) {
-					occupied[output] = true
+				if mempoolOutputUnspendable(tx.TxOut[output]) {
+					flow.burn(edict.ID, take, output, common.TX_FLOW_REASON_OP_RETURN)
+				} else {
+					flow.allocate(output, edict.ID, take)
 				}
 				balance = balance.Sub(take)
 				balances[edict.ID] = balance
@@ -301,15 +368,20 @@ func allocateMempoolRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]boo
 			}
 		}
 	}
-	if defaultOutput >= 0 && !mempoolOutputUnspendable(tx.TxOut[defaultOutput]) {
-		for _, balance := range balances {
-			if !balance.IsZero() {
-				occupied[defaultOutput] = true
-				break
-			}
+	for id, balance := range balances {
+		if balance.IsZero() {
+			continue
+		}
+		switch {
+		case defaultOutput < 0:
+			flow.burn(id, balance, -1, common.TX_FLOW_REASON_UNALLOCATED)
+		case mempoolOutputUnspendable(tx.TxOut[defaultOutput]):
+			flow.burn(id, balance, defaultOutput, common.TX_FLOW_REASON_OP_RETURN)
+		default:
+			flow.allocate(defaultOutput, id, balance)
 		}
 	}
-	return occupied, true
+	return flow, true
 }
 
 func mempoolSpendableOutputIndexes(tx *wire.MsgTx) []int {
@@ -341,14 +413,48 @@ type mempoolAtomAssignment struct {
 	amount int64
 }
 
-func allocateMempoolAtom(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]bool, bool) {
-	occupied := make([]bool, len(tx.TxOut))
+type mempoolAtomFlow struct {
+	ids         []string          // 分配的顺序
+	tickers     map[string]string // atomicalId -> ticker
+	spent       map[string]int64  // atomicalId -> 输入中的数量
+	assignments map[string][]mempoolAtomAssignment
+	// DirectFT/MintDFT 铸造到第一个输出
+	mintOutput bool
+}
+
+// burned 没有分配到可花费输出的数量
+func (p *mempoolAtomFlow) burned(tx *wire.MsgTx, atomicalID string) int64 {
+	remaining := p.spent[atomicalID]
+	for _, assignment := range p.assignments[atomicalID] {
+		if assignment.amount <= 0 || assignment.output < 0 || assignment.output >= len(tx.TxOut) {
+			continue
+		}
+		if mempoolOutputUnspendable(tx.TxOut[assignment.output]) {
+			continue
+		}
+		remaining -= assignment.amount
+	}
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func assignMempoolAtom(tx *wire.MsgTx, inputs []*mempoolResolvedInput) (*mempoolAtomFlow, bool) {
+	flow := &mempoolAtomFlow{
+		tickers:     make(map[string]string),
+		spent:       make(map[string]int64),
+		assignments: make(map[string][]mempoolAtomAssignment),
+	}
 	spent := make([]mempoolAtomBalance, 0)
 	for _, resolved := range inputs {
-		if resolved == nil || !resolved.confirmed || resolved.output == nil || resolved.output.UtxoId == common.INVALID_ID {
+		if instance.atomIndexer == nil {
+			break
+		}
+		if resolved == nil {
 			continue
 		}
-		for _, balance := range instance.atomIndexer.GetUtxoBalances(resolved.output.UtxoId) {
+		for _, balance := range resolved.atomBalances() {
 			if balance == nil || balance.Amount <= 0 {
 				continue
 			}
@@ -357,6 +463,7 @@ func allocateMempoolAtom(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]bool
 				amount:     balance.Amount,
 				inputIndex: resolved.index,
 			})
+			flow.tickers[balance.AtomicalId] = balance.Ticker
 		}
 	}
 
@@ -373,13 +480,13 @@ func allocateMempoolAtom(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]bool
 	op := atomidx.ParseOperation(atomTx, height >= densityHeight)
 
 	if op != nil && len(tx.TxOut) > 0 && (op.Op == atomidx.OpDirectFT || op.Op == atomidx.OpMintDFT) {
-		occupied[0] = true
+		flow.mintOutput = true
 	}
 	if len(spent) == 0 {
-		return occupied, true
+		return flow, true
 	}
 
-	grouped := make(map[string]int64)
+	grouped := flow.spent
 	fromInput := make(map[string]map[int]bool)
 	for _, item := range spent {
 		grouped[item.atomicalID] += item.amount
@@ -404,6 +511,7 @@ func allocateMempoolAtom(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]bool
 		}
 		sort.Slice(ids, func(i, j int) bool { return mempoolCompareAtomicalIDs(ids[i], ids[j]) < 0 })
 	}
+	flow.ids = ids
 
 	customActivated := height >= coloringHeight
 	assignmentsByID := make(map[string][]mempoolAtomAssignment, len(ids))
@@ -442,19 +550,8 @@ func allocateMempoolAtom(tx *wire.MsgTx, inputs []*mempoolResolvedInput) ([]bool
 			assignmentsByID[id] = assignments
 		}
 	}
-
-	for _, assignments := range assignmentsByID {
-		for _, assignment := range assignments {
-			if assignment.amount <= 0 || assignment.output < 0 || assignment.output >= len(tx.TxOut) {
-				continue
-			}
-			if mempoolOutputUnspendable(tx.TxOut[assignment.output]) {
-				continue
-			}
-			occupied[assignment.output] = true
-		}
-	}
-	return occupied, true
+	flow.assignments = assignmentsByID
+	return flow, true
 }
 
 func mempoolCommonTransaction(tx *wire.MsgTx, inputs []*mempoolResolvedInput) *common.Transaction {
@@ -627,9 +724,22 @@ func mempoolAtomicalIDSortKey(id string) ([]byte, bool) {
 	return key, true
 }
 
-func markMempoolInscriptionOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInput, occupied []bool) {
+// 交易中新铸造的铭文，以及它落在输出中的聪偏移
+type mempoolInscriptionPlacement struct {
+	id    string
+	input int
+	start int64
+	// 有效的 ordx mint 绑定的聪数量
+	ticker string
+	amt    int64
+	sats   int64
+	// brc20 的 transfer 铭文，余额是否足够由内存池检查
+	brc20 *common.BRC20TransferContent
+}
+
+func placeMempoolInscriptions(tx *wire.MsgTx, inputs []*mempoolResolvedInput) []*mempoolInscriptionPlacement {
 	if len(tx.TxOut) == 0 || len(inputs) == 0 {
-		return
+		return nil
 	}
 	height := instance.GetSyncHeight() + 1
 	inputBase := make(map[int]int64, len(inputs))
@@ -646,6 +756,8 @@ func markMempoolInscriptionOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInpu
 		totalOutput += output.Value
 	}
 
+	result := make([]*mempoolInscriptionPlacement, 0)
+	txID := tx.TxID()
 	for i, txIn := range tx.TxIn {
 		inscriptions := ord0_14_1.GetInscriptionsInTxInput(txIn.Witness, height, i)
 		for _, inscription := range inscriptions {
@@ -656,7 +768,13 @@ func markMempoolInscriptionOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInpu
 					start = 0
 				}
 			}
-			mempoolMarkSatRange(tx, start, start+1, occupied)
+			placement := &mempoolInscriptionPlacement{
+				id:    fmt.Sprintf("%si%d", txID, len(result)),
+				input: i,
+				start: start,
+			}
+			result = append(result, placement)
+			placement.brc20 = parseMempoolBrc20Transfer(inscription)
 
 			ordxInfo, isOrdx := ord.IsOrdXProtocol(inscription)
 			if !isOrdx {
@@ -693,9 +811,28 @@ func markMempoolInscriptionOutputs(tx *wire.MsgTx, inputs []*mempoolResolvedInpu
 			if localOffset+sats > inputs[inputIndex].output.Value() {
 				continue
 			}
-			mempoolMarkSatRange(tx, start, start+sats, occupied)
+			placement.ticker = mint.Ticker
+			placement.amt = amt
+			placement.sats = sats
 		}
 	}
+	return result
+}
+
+func parseMempoolBrc20Transfer(inscription *ord.InscriptionResult) *common.BRC20TransferContent {
+	if protocol, _ := ord.GetProtocol(inscription); protocol != "brc-20" {
+		return nil
+	}
+	contentType := strings.Split(string(inscription.Inscription.ContentType), ";")[0]
+	if contentType != "text/plain" && contentType != "application/json" {
+		return nil
+	}
+	content := string(inscription.Inscription.Body)
+	base := common.ParseBrc20BaseContent(content)
+	if base == nil || strings.ToLower(base.Op) != "transfer" {
+		return nil
+	}
+	return common.ParseBrc20TransferContent(content)
 }
 
 func mempoolInputAtGlobalOffset(inputs []*mempoolResolvedInput, offset int64) (int, int64, bool) {
@@ -713,19 +850,31 @@ func mempoolInputAtGlobalOffset(inputs []*mempoolResolvedInput, offset int64) (i
 	return -1, 0, false
 }
 
-func mempoolMarkSatRange(tx *wire.MsgTx, start, end int64, occupied []bool) {
+type mempoolOutputRange struct {
+	output     int
+	start, end int64 // 输出中的偏移
+}
+
+// mempoolOutputRanges 输入中 [start, end) 这段聪落在哪些输出中，超出所有输出的部分是手续费
+func mempoolOutputRanges(tx *wire.MsgTx, start, end int64) []mempoolOutputRange {
 	if end <= start || start < 0 {
-		return
+		return nil
 	}
+	result := make([]mempoolOutputRange, 0, 1)
 	var base int64
 	for i, output := range tx.TxOut {
 		next := base + output.Value
 		if output.Value > 0 && start < next && end > base {
-			occupied[i] = true
+			result = append(result, mempoolOutputRange{
+				output: i,
+				start:  max(start, base) - base,
+				end:    min(end, next) - base,
+			})
 		}
 		base = next
 		if base >= end {
 			break
 		}
 	}
+	return result
 }
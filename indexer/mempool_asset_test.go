@@ -29,27 +29,60 @@ func TestAllocateBoundMempoolOutputsFindsPlainChange(t *testing.T) {
 	tx.AddTxOut(wire.NewTxOut(330, []byte{0x51}))
 	tx.AddTxOut(wire.NewTxOut(99000, []byte{0x51}))
 
-	outputs, occupied, ok := allocateBoundMempoolOutputs(tx, []*mempoolResolvedInput{
+	outputs, _, ok := cutBoundMempoolOutputs(tx, []*mempoolResolvedInput{
 		{output: assetInput, confirmed: true, index: 0},
 		{output: plainInput, confirmed: true, index: 1},
 	})
 	if !ok {
 		t.Fatal("allocation unexpectedly unresolved")
 	}
-	if len(outputs) != 2 || len(occupied) != 2 {
-		t.Fatalf("unexpected result sizes: outputs=%d occupied=%d", len(outputs), len(occupied))
+	if len(outputs) != 2 {
+		t.Fatalf("unexpected result size: outputs=%d", len(outputs))
 	}
-	if !occupied[0] {
-		t.Fatal("asset output must be non-plain")
-	}
-	if occupied[1] {
-		t.Fatal("change output should be classified plain")
+	if !outputs[0].HasAsset() {
+		t.Fatal("asset output must carry the asset")
 	}
 	if outputs[1].Value() != 99000 || outputs[1].HasAsset() {
 		t.Fatalf("unexpected plain change: value=%d assets=%v", outputs[1].Value(), outputs[1].Assets)
 	}
 }
 
+func TestCutBoundMempoolOutputsReturnsAssetsInFee(t *testing.T) {
+	assetName := common.AssetName{
+		Protocol: common.PROTOCOL_NAME_ORDX,
+		Type:     common.ASSET_TYPE_FT,
+		Ticker:   "test",
+	}
+	// 资产在输入的最后 100 聪上，输出只用掉了前面的 900 聪
+	input := common.NewTxOutput(1000)
+	input.OutPointStr = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa:0"
+	input.Assets = common.TxAssets{{
+		Name:       assetName,
+		Amount:     *common.NewDefaultDecimal(100),
+		BindingSat: 1,
+	}}
+	input.Offsets[assetName] = common.AssetOffsets{{Start: 900, End: 1000}}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(900, []byte{0x51}))
+
+	outputs, fee, ok := cutBoundMempoolOutputs(tx, []*mempoolResolvedInput{
+		{output: input, confirmed: true, index: 0},
+	})
+	if !ok {
+		t.Fatal("allocation unexpectedly unresolved")
+	}
+	if outputs[0].HasAsset() {
+		t.Fatalf("output should be plain, got %v", outputs[0].Assets)
+	}
+	if fee == nil || fee.Value() != 100 {
+		t.Fatalf("unexpected fee part: %v", fee)
+	}
+	if amt := fee.GetAsset(&assetName); amt == nil || amt.Int64() != 100 {
+		t.Fatalf("asset in fee = %v, want 100", amt)
+	}
+}
+
 func TestMempoolAtomRegularAllocationPreservesPlainOutput(t *testing.T) {
 	tx := wire.NewMsgTx(wire.TxVersion)
 	tx.AddTxOut(wire.NewTxOut(600, []byte{0x51}))
@@ -78,15 +111,18 @@ func TestMempoolAtomRegularAllocationCanPartiallyColorOutput(t *testing.T) {
 	}
 }
 
-func TestMempoolMarkSatRangeAcrossOutputs(t *testing.T) {
+func TestMempoolOutputRangesAcrossOutputs(t *testing.T) {
 	tx := wire.NewMsgTx(wire.TxVersion)
 	tx.AddTxOut(wire.NewTxOut(100, []byte{0x51}))
 	tx.AddTxOut(wire.NewTxOut(100, []byte{0x51}))
 	tx.AddTxOut(wire.NewTxOut(100, []byte{0x51}))
 
-	occupied := make([]bool, 3)
-	mempoolMarkSatRange(tx, 90, 110, occupied)
-	if !occupied[0] || !occupied[1] || occupied[2] {
-		t.Fatalf("unexpected occupied outputs: %v", occupied)
+	ranges := mempoolOutputRanges(tx, 90, 110)
+	want := []mempoolOutputRange{{output: 0, start: 90, end: 100}, {output: 1, start: 0, end: 10}}
+	if len(ranges) != len(want) || ranges[0] != want[0] || ranges[1] != want[1] {
+		t.Fatalf("unexpected ranges: %+v", ranges)
+	}
+	if fee := mempoolOutputRanges(tx, 300, 301); len(fee) != 0 {
+		t.Fatalf("sats after all outputs belong to the fee, got %+v", fee)
 	}
 }
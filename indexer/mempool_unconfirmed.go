@@ -0,0 +1,412 @@
+package indexer
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	atomidx "github.com/sat20-labs/indexer/indexer/atom"
+	"github.com/sat20-labs/indexer/indexer/brc20"
+	"github.com/sat20-labs/indexer/indexer/runes"
+)
+
+// mempoolAssetOutput 内存池中带资产的输出。runes 和 atom 不能从聪的偏移推算，
+// 另外保存一份，子交易花费这个输出时直接使用，不需要查询索引器
+type mempoolAssetOutput struct {
+	output *common.TxOutput
+	runes  []*runes.UtxoAsset
+	atom   []*atomidx.UtxoBalance
+}
+
+func (p *mempoolAssetOutput) clone() *mempoolAssetOutput {
+	n := &mempoolAssetOutput{
+		output: p.output.Clone(),
+		runes:  make([]*runes.UtxoAsset, 0, len(p.runes)),
+		atom:   make([]*atomidx.UtxoBalance, 0, len(p.atom)),
+	}
+	for _, asset := range p.runes {
+		copied := *asset
+		n.runes = append(n.runes, &copied)
+	}
+	for _, balance := range p.atom {
+		n.atom = append(n.atom, balance.Clone())
+	}
+	return n
+}
+
+func (p *mempoolAssetOutput) toResolvedInput() *mempoolResolvedInput {
+	n := p.clone()
+	return &mempoolResolvedInput{output: n.output, runes: n.runes, atom: n.atom}
+}
+
+type mempoolBrc20Amount struct {
+	address string
+	ticker  string
+	amount  *common.Decimal
+}
+
+// mempoolBrc20Effects 交易对 brc20 余额的影响，随交易一起删除
+type mempoolBrc20Effects struct {
+	// 新的 transfer 铭文，占用了铭刻者的 available 余额
+	inscribes []*mempoolBrc20Amount
+	// 被花费的 transfer 铭文，余额转给接收者，作为手续费时退回发送者
+	credits []*mempoolBrc20Amount
+}
+
+func (p *mempoolBrc20Effects) empty() bool {
+	return p == nil || (len(p.inscribes) == 0 && len(p.credits) == 0)
+}
+
+type mempoolTxAllocation struct {
+	outputs []*mempoolAssetOutput
+	// 输出中有没有模拟的资产（比如 atom 的铸造），只能标记为 non-plain
+	unknown []bool
+	brc20   *mempoolBrc20Effects
+}
+
+// allocateKnownMempoolTx 所有输入都已知时，计算每个输出中的资产
+func (p *MiniMemPool) allocateKnownMempoolTx(tx *wire.MsgTx, inputs []*mempoolResolvedInput) (*mempoolTxAllocation, bool) {
+	runesFlow, ok := simulateMempoolRunes(tx, inputs)
+	if !ok || runesFlow.issuance {
+		return nil, false
+	}
+	atomFlow, ok := assignMempoolAtom(tx, inputs)
+	if !ok {
+		return nil, false
+	}
+
+	aggregate, ok := aggregateMempoolInputs(inputs)
+	if !ok {
+		return nil, false
+	}
+	// transfer 铭文花费一次就失效了，余额的转移记录在 brc20 的 credits 中
+	brc20Names := make([]common.AssetName, 0)
+	for _, asset := range aggregate.Assets {
+		if asset.Name.Protocol == common.PROTOCOL_NAME_BRC20 {
+			brc20Names = append(brc20Names, asset.Name)
+		}
+	}
+	for _, name := range brc20Names {
+		aggregate.RemoveAsset(&name)
+	}
+	bound, _, ok := cutMempoolAggregate(tx, aggregate)
+	if !ok {
+		return nil, false
+	}
+
+	result := &mempoolTxAllocation{
+		outputs: make([]*mempoolAssetOutput, len(tx.TxOut)),
+		unknown: make([]bool, len(tx.TxOut)),
+		brc20:   &mempoolBrc20Effects{},
+	}
+	for i, output := range bound {
+		result.outputs[i] = &mempoolAssetOutput{output: output}
+	}
+	addMempoolRunes(result.outputs, runesFlow)
+	addMempoolAtom(tx, result.outputs, atomFlow)
+	if atomFlow.mintOutput && len(tx.TxOut) > 0 {
+		result.unknown[0] = true
+	}
+
+	placements := placeMempoolInscriptions(tx, inputs)
+	addMempoolInscriptions(tx, result.outputs, placements)
+	result.brc20.credits = p.creditMempoolBrc20Transfers(tx, inputs, result.outputs)
+	result.brc20.inscribes = p.inscribeMempoolBrc20Transfers(tx, placements, result.outputs)
+	return result, true
+}
+
+func addMempoolRunes(outputs []*mempoolAssetOutput, flow *mempoolRunesFlow) {
+	for i, balances := range flow.outputs {
+		for id, amount := range balances {
+			if amount.IsZero() {
+				continue
+			}
+			meta := flow.assets[id]
+			asset := &common.AssetInfo{
+				Name: common.AssetName{
+					Protocol: common.PROTOCOL_NAME_RUNES,
+					Type:     common.ASSET_TYPE_FT,
+					Ticker:   meta.Rune,
+				},
+				Amount:     *common.NewDecimalFromUint128(amount, int(meta.Divisibility)),
+				BindingSat: 0,
+			}
+			output := outputs[i]
+			output.output.Assets.Add(asset)
+			output.runes = append(output.runes, &runes.UtxoAsset{
+				Rune:         meta.Rune,
+				RuneId:       id.String(),
+				Balance:      amount,
+				Divisibility: meta.Divisibility,
+				Symbol:       meta.Symbol,
+			})
+		}
+	}
+}
+
+// atom 染色输出开头的聪
+func addMempoolAtom(tx *wire.MsgTx, outputs []*mempoolAssetOutput, flow *mempoolAtomFlow) {
+	for _, id := range flow.ids {
+		for _, assignment := range flow.assignments[id] {
+			if assignment.amount <= 0 || assignment.output < 0 || assignment.output >= len(tx.TxOut) {
+				continue
+			}
+			if mempoolOutputUnspendable(tx.TxOut[assignment.output]) {
+				continue
+			}
+			output := outputs[assignment.output]
+			colored := common.NewTxOutput(0)
+			name := common.AssetName{
+				Protocol: common.PROTOCOL_NAME_ATOM,
+				Type:     common.ASSET_TYPE_FT,
+				Ticker:   flow.tickers[id],
+			}
+			colored.Assets = common.TxAssets{{
+				Name:       name,
+				Amount:     *common.NewDefaultDecimal(assignment.amount),
+				BindingSat: 1,
+			}}
+			colored.Offsets[name] = common.AssetOffsets{{Start: 0, End: assignment.amount}}
+			output.output.Merge(colored)
+			output.atom = append(output.atom, &atomidx.UtxoBalance{
+				UtxoId:     common.INVALID_ID,
+				Outpoint:   output.output.OutPointStr,
+				AtomicalId: id,
+				Ticker:     flow.tickers[id],
+				Amount:     assignment.amount,
+			})
+		}
+	}
+}
+
+// 新铸造的铭文作为 nft 绑定在它的聪上，有效的 ordx mint 同时绑定 ft
+func addMempoolInscriptions(tx *wire.MsgTx, outputs []*mempoolAssetOutput, placements []*mempoolInscriptionPlacement) {
+	for _, placement := range placements {
+		nftName := common.AssetName{
+			Protocol: common.PROTOCOL_NAME_ORDX,
+			Type:     common.ASSET_TYPE_NFT,
+			Ticker:   placement.id,
+		}
+		for _, r := range mempoolOutputRanges(tx, placement.start, placement.start+1) {
+			mergeMempoolBoundAsset(outputs[r.output].output, nftName, 1, r)
+		}
+
+		if placement.sats <= 0 {
+			continue
+		}
+		n := placement.amt / placement.sats
+		ftName := common.AssetName{
+			Protocol: common.PROTOCOL_NAME_ORDX,
+			Type:     common.ASSET_TYPE_FT,
+			Ticker:   placement.ticker,
+		}
+		for _, r := range mempoolOutputRanges(tx, placement.start, placement.start+placement.sats) {
+			output := outputs[r.output].output
+			// 已经绑定了同一个资产的聪，不能重复铸造
+			overlap := common.IntersectAssetOffsets(output.Offsets[ftName], common.AssetOffsets{{Start: r.start, End: r.end}})
+			if len(overlap) != 0 {
+				continue
+			}
+			mergeMempoolBoundAsset(output, ftName, n, r)
+		}
+	}
+}
+
+func mergeMempoolBoundAsset(output *common.TxOutput, name common.AssetName, n int64, r mempoolOutputRange) {
+	bound := common.NewTxOutput(0)
+	bound.Assets = common.TxAssets{{
+		Name:       name,
+		Amount:     *common.NewDefaultDecimal((r.end - r.start) * n),
+		BindingSat: uint32(n),
+	}}
+	bound.Offsets[name] = common.AssetOffsets{{Start: r.start, End: r.end}}
+	output.Merge(bound)
+}
+
+// creditMempoolBrc20Transfers 输入中的 transfer 铭文转给它所在的输出，落在手续费中时退回给发送者
+func (p *MiniMemPool) creditMempoolBrc20Transfers(tx *wire.MsgTx, inputs []*mempoolResolvedInput, outputs []*mempoolAssetOutput) []*mempoolBrc20Amount {
+	result := make([]*mempoolBrc20Amount, 0)
+	var base int64
+	for _, resolved := range inputs {
+		if resolved == nil || resolved.output == nil {
+			continue
+		}
+		input := resolved.output
+		for offset, asset := range input.SatBindingMap {
+			if asset == nil || asset.Name.Protocol != common.PROTOCOL_NAME_BRC20 || input.Invalids[asset.Name] {
+				continue
+			}
+			receiver := input
+			if ranges := mempoolOutputRanges(tx, base+offset, base+offset+1); len(ranges) != 0 {
+				if mempoolOutputUnspendable(tx.TxOut[ranges[0].output]) {
+					continue
+				}
+				receiver = outputs[ranges[0].output].output
+			}
+			address, ok := p.addressForOutput(receiver)
+			if !ok {
+				continue
+			}
+			result = append(result, &mempoolBrc20Amount{
+				address: address,
+				ticker:  strings.ToLower(asset.Name.Ticker),
+				amount:  asset.Amount.Clone(),
+			})
+		}
+		base += input.Value()
+	}
+	return result
+}
+
+// inscribeMempoolBrc20Transfers 新的 transfer 铭文，铭刻者的 available 余额足够时才有效，
+// 内存池中其他交易已经占用的余额要扣除
+func (p *MiniMemPool) inscribeMempoolBrc20Transfers(tx *wire.MsgTx, placements []*mempoolInscriptionPlacement, outputs []*mempoolAssetOutput) []*mempoolBrc20Amount {
+	result := make([]*mempoolBrc20Amount, 0)
+	if instance.brc20Indexer == nil || !instance.isProtocolActive(config.PROTOCOL_BRC20, instance.GetSyncHeight()+1) {
+		return result
+	}
+	for _, placement := range placements {
+		if placement.brc20 == nil {
+			continue
+		}
+		ranges := mempoolOutputRanges(tx, placement.start, placement.start+1)
+		if len(ranges) == 0 || mempoolOutputUnspendable(tx.TxOut[ranges[0].output]) {
+			continue
+		}
+		r := ranges[0]
+		output := outputs[r.output].output
+		name := common.AssetName{
+			Protocol: common.PROTOCOL_NAME_BRC20,
+			Type:     common.ASSET_TYPE_FT,
+			Ticker:   strings.ToLower(placement.brc20.Ticker),
+		}
+		// 一个输出中只有第一个 transfer 铭文有效
+		hasTransfer := false
+		for _, asset := range output.Assets {
+			if asset.Name.Protocol == common.PROTOCOL_NAME_BRC20 {
+				hasTransfer = true
+				break
+			}
+		}
+		if hasTransfer {
+			continue
+		}
+		ticker := instance.brc20Indexer.GetTicker(name.Ticker)
+		if ticker == nil {
+			continue
+		}
+		amt, err := brc20.ParseBrc20Amount(placement.brc20.Amt, int(ticker.Decimal))
+		if err != nil || amt.Sign() <= 0 {
+			continue
+		}
+		address, ok := p.addressForOutput(output)
+		if !ok {
+			continue
+		}
+		available := instance.brc20Indexer.GetAvailableBalance(instance.rpcService.GetAddressId(address), name.Ticker)
+		available = available.Sub(p.pendingBrc20Inscribes(address, name.Ticker))
+		for _, inscribe := range result {
+			if inscribe.address == address && inscribe.ticker == name.Ticker {
+				available = available.Sub(inscribe.amount)
+			}
+		}
+		if available == nil || amt.Cmp(available) > 0 {
+			continue
+		}
+
+		asset := common.AssetInfo{
+			Name:       name,
+			Amount:     *amt.Clone(),
+			BindingSat: 0,
+		}
+		output.Assets.Add(&asset)
+		output.Offsets[name] = common.AssetOffsets{{Start: r.start, End: r.start + 1}}
+		output.SatBindingMap[r.start] = asset.Clone()
+		result = append(result, &mempoolBrc20Amount{address: address, ticker: name.Ticker, amount: amt})
+	}
+	return result
+}
+
+func (p *MiniMemPool) pendingBrc20Inscribes(address, ticker string) *common.Decimal {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	var result *common.Decimal
+	for _, effects := range p.brc20EffectsByTx {
+		for _, inscribe := range effects.inscribes {
+			if inscribe.address == address && inscribe.ticker == ticker {
+				result = result.Add(inscribe.amount)
+			}
+		}
+	}
+	return result
+}
+
+func (p *MiniMemPool) knownOutputLocked(outpoint string) *common.TxOutput {
+	if output := p.knownPlainUtxoMap[outpoint]; output != nil {
+		return output
+	}
+	if asset := p.assetUtxoMap[outpoint]; asset != nil {
+		return asset.output
+	}
+	return nil
+}
+
+func (p *MiniMemPool) availableLocked(outpoint string) bool {
+	if p.spentByOutpoint[outpoint] != "" {
+		return false
+	}
+	_, confirmed := p.confirmedSpent[outpoint]
+	return !confirmed
+}
+
+// GetUnconfirmedOutput returns a classified mempool output with its computed
+// assets, nil if the output is unknown or its assets can't be resolved.
+func (p *MiniMemPool) GetUnconfirmedOutput(outpoint string) *common.TxOutput {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	switch p.utxoStateMap[outpoint] {
+	case mempoolUtxoPlain, mempoolUtxoAsset:
+		if output := p.knownOutputLocked(outpoint); output != nil {
+			return output.Clone()
+		}
+	}
+	return nil
+}
+
+// GetUnconfirmedAssetUtxoByAddress returns currently unspent mempool outputs
+// whose assets were computed from confirmed or already classified inputs.
+func (p *MiniMemPool) GetUnconfirmedAssetUtxoByAddress(address string) map[string]*common.TxOutput {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	addrUtxo := p.addrUtxoMap[address]
+	if addrUtxo == nil {
+		return nil
+	}
+	result := make(map[string]*common.TxOutput, len(addrUtxo.UnconfirmedAssetUtxoMap))
+	for outpoint, output := range addrUtxo.UnconfirmedAssetUtxoMap {
+		if p.availableLocked(outpoint) {
+			result[outpoint] = output.Clone()
+		}
+	}
+	return result
+}
+
+// GetUnconfirmedBrc20ByAddress returns brc20 balances the address receives from
+// transfer inscriptions spent in the mempool, keyed by lower case ticker.
+func (p *MiniMemPool) GetUnconfirmedBrc20ByAddress(address string) map[string]*common.Decimal {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	result := make(map[string]*common.Decimal)
+	for _, effects := range p.brc20EffectsByTx {
+		for _, credit := range effects.credits {
+			if credit.address == address {
+				result[credit.ticker] = result[credit.ticker].Add(credit.amount)
+			}
+		}
+	}
+	return result
+}
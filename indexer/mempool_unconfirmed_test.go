@@ -0,0 +1,139 @@
+package indexer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	"lukechampine.com/uint128"
+)
+
+func TestMempoolChainedAssetOutputResolves(t *testing.T) {
+	old := instance
+	instance = &IndexerMgr{chaincfgParam: &chaincfg.MainNetParams, RunesIndexer: &runes.Indexer{}}
+	defer func() { instance = old }()
+
+	// p2wpkh
+	pkScript := append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x11}, 20)...)
+	address, err := common.PkScriptToAddr(pkScript, instance.GetChainParam())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent := wire.NewMsgTx(wire.TxVersion)
+	parent.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{5}, Index: 0}, nil, nil))
+	parent.AddTxOut(wire.NewTxOut(1_000, pkScript))
+	child := wire.NewMsgTx(wire.TxVersion)
+	child.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: parent.TxHash(), Index: 0}, nil, nil))
+	child.AddTxOut(wire.NewTxOut(900, pkScript))
+
+	ordxName := common.AssetName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: "test"}
+	runeID := runestone.RuneId{Block: 840000, Tx: 1}
+	output := common.NewTxOutput(1_000)
+	output.OutValue.PkScript = pkScript
+	output.Assets = common.TxAssets{{Name: ordxName, Amount: *common.NewDefaultDecimal(10), BindingSat: 1}}
+	output.Offsets[ordxName] = common.AssetOffsets{{Start: 0, End: 10}}
+	parentOutput := &mempoolAssetOutput{output: output}
+	addMempoolRunes([]*mempoolAssetOutput{parentOutput}, &mempoolRunesFlow{
+		assets:  map[runestone.RuneId]*runes.UtxoAsset{runeID: {Rune: "TESTRUNE", RuneId: runeID.String(), Divisibility: 2}},
+		outputs: []map[runestone.RuneId]uint128.Uint128{{runeID: uint128.From64(500)}},
+	})
+
+	pool := NewMiniMemPool()
+	pool.mutex.Lock()
+	pool.admitTransactionLocked(parent)
+	pool.mutex.Unlock()
+	pool.commitMempoolOutputs(parent, &mempoolTxAllocation{
+		outputs: []*mempoolAssetOutput{parentOutput},
+		unknown: []bool{false},
+	})
+
+	outpoint := parent.TxID() + ":0"
+	if _, ok := pool.GetUnconfirmedAssetUtxoByAddress(address)[outpoint]; !ok {
+		t.Fatal("asset output should be available to its owner")
+	}
+	exposed := pool.GetUnconfirmedOutput(outpoint).ToAssetsInUtxo()
+	if exposed.Confirmed || len(exposed.Assets) != 2 {
+		t.Fatalf("unexpected unconfirmed output: %+v", exposed)
+	}
+
+	pool.mutex.Lock()
+	pool.admitTransactionLocked(child)
+	pool.mutex.Unlock()
+	if len(pool.GetUnconfirmedAssetUtxoByAddress(address)) != 0 {
+		t.Fatal("spent asset output should not be available")
+	}
+
+	inputs, status := pool.resolveMempoolInputs(child)
+	if status != mempoolResolveComplete || len(inputs) != 1 {
+		t.Fatalf("child should resolve through its classified parent, status %d", status)
+	}
+	flow, ok := simulateMempoolRunes(child, inputs)
+	if !ok || flow.outputs[0][runeID] != uint128.From64(500) {
+		t.Fatalf("runes of the parent output should move to the child, %+v", flow)
+	}
+	outputs, _, ok := cutBoundMempoolOutputs(child, inputs)
+	if !ok {
+		t.Fatal("bound assets of the parent output should be allocated")
+	}
+	if amt := outputs[0].GetAsset(&ordxName); amt == nil || amt.Int64() != 10 {
+		t.Fatalf("ordx in child output = %v, want 10", amt)
+	}
+
+	pool.mutex.Lock()
+	pool.removeTransactionLocked(child.TxID(), true, true)
+	pool.mutex.Unlock()
+	if _, ok := pool.GetUnconfirmedAssetUtxoByAddress(address)[outpoint]; !ok {
+		t.Fatal("removing the child should restore the parent output")
+	}
+}
+
+func TestMempoolBrc20CreditsFollowTransferInscription(t *testing.T) {
+	old := instance
+	instance = &IndexerMgr{chaincfgParam: &chaincfg.MainNetParams}
+	defer func() { instance = old }()
+
+	sender := append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x22}, 20)...)
+	receiver := append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x33}, 20)...)
+	senderAddress, _ := common.PkScriptToAddr(sender, instance.GetChainParam())
+	receiverAddress, _ := common.PkScriptToAddr(receiver, instance.GetChainParam())
+
+	name := common.AssetName{Protocol: common.PROTOCOL_NAME_BRC20, Type: common.ASSET_TYPE_FT, Ticker: "ordi"}
+	transfer := common.AssetInfo{Name: name, Amount: *common.NewDefaultDecimal(100)}
+	input := common.NewTxOutput(546)
+	input.OutValue.PkScript = sender
+	input.Assets = common.TxAssets{transfer}
+	input.Offsets[name] = common.AssetOffsets{{Start: 0, End: 1}}
+	input.SatBindingMap[0] = transfer.Clone()
+	inputs := []*mempoolResolvedInput{{output: input, confirmed: true}}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{6}, Index: 0}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(546, receiver))
+	outputs := []*mempoolAssetOutput{{output: common.NewTxOutput(546)}}
+	outputs[0].output.OutValue.PkScript = receiver
+
+	pool := NewMiniMemPool()
+	credits := pool.creditMempoolBrc20Transfers(tx, inputs, outputs)
+	if len(credits) != 1 || credits[0].address != receiverAddress || credits[0].amount.Int64() != 100 {
+		t.Fatalf("transfer should credit the receiver, %+v", credits)
+	}
+
+	// 铭文落在手续费中，退回发送者
+	tx.TxOut[0].Value = 0
+	outputs[0].output.OutValue.Value = 0
+	credits = pool.creditMempoolBrc20Transfers(tx, inputs, outputs)
+	if len(credits) != 1 || credits[0].address != senderAddress {
+		t.Fatalf("transfer in fee should return to the sender, %+v", credits)
+	}
+
+	pool.brc20EffectsByTx[tx.TxID()] = &mempoolBrc20Effects{credits: credits}
+	if amt := pool.GetUnconfirmedBrc20ByAddress(senderAddress)["ordi"]; amt == nil || amt.Int64() != 100 {
+		t.Fatalf("unconfirmed brc20 of sender = %v, want 100", amt)
+	}
+}
@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/hex"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/share/bitcoin_rpc"
+)
+
+// enterZMQCallback zmq 的回调不在 workerWG 中，需要自己登记，Stop 才能等待它结束
+func (p *MiniMemPool) enterZMQCallback() (chan struct{}, bool) {
+	p.lifecycleMutex.Lock()
+	defer p.lifecycleMutex.Unlock()
+	if !p.running || !p.zmqMode || p.stopChan == nil {
+		return nil, false
+	}
+	p.workerWG.Add(1)
+	return p.stopChan, true
+}
+
+// rawTxReceived 对应 zmq 的 rawtx 通知
+func (p *MiniMemPool) rawTxReceived(raw []byte) {
+	stop, ok := p.enterZMQCallback()
+	if !ok {
+		return
+	}
+	defer p.workerWG.Done()
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		common.Log.Errorf("zmq rawtx deserialize failed, %v", err)
+		return
+	}
+	if p.shouldStop(stop) {
+		return
+	}
+	common.Log.Debugf("zmq rawtx %s", tx.TxID())
+	p.txBroadcasted(tx)
+	p.retryPendingTransactions(mempoolRetryMaxPasses)
+}
+
+// txRemoved 对应 sequence 通知中的 R，交易因为替换、冲突或者过期被移出 mempool
+func (p *MiniMemPool) txRemoved(txID string) {
+	_, ok := p.enterZMQCallback()
+	if !ok {
+		return
+	}
+	defer p.workerWG.Done()
+
+	p.processingMutex.Lock()
+	p.mutex.Lock()
+	if _, exists := p.txMap[txID]; exists {
+		p.removeTransactionLocked(txID, true, true)
+		common.Log.Debugf("zmq removed tx %s from mempool", txID)
+	}
+	p.mutex.Unlock()
+	p.processingMutex.Unlock()
+}
+
+// blockConnected 没有 P2P 连接时，从 rpc 读取新区块来确认交易
+func (p *MiniMemPool) blockConnected(hash string) {
+	p.lifecycleMutex.Lock()
+	stop := p.stopChan
+	zmqMode := p.zmqMode
+	p.lifecycleMutex.Unlock()
+	if !zmqMode || stop == nil {
+		return
+	}
+
+	// 读区块比较慢，不阻塞 zmq 的接收线程
+	p.startWorker(stop, func() {
+		blockHex, err := bitcoin_rpc.ShareBitconRpc.GetRawBlock(hash)
+		if err != nil {
+			common.Log.Errorf("GetRawBlock %s failed, %v", hash, err)
+			p.scheduleSync(false)
+			return
+		}
+		blockBytes, err := hex.DecodeString(blockHex)
+		if err != nil {
+			common.Log.Errorf("decode block %s failed, %v", hash, err)
+			return
+		}
+		var block wire.MsgBlock
+		if err := block.Deserialize(bytes.NewReader(blockBytes)); err != nil {
+			common.Log.Errorf("deserialize block %s failed, %v", hash, err)
+			return
+		}
+		if p.shouldStop(stop) {
+			return
+		}
+		p.ProcessBlock(&block)
+	})
+}
+
+// resyncFromRPC 可能丢失了 zmq 通知，用 rpc 重新对齐
+func (p *MiniMemPool) resyncFromRPC() {
+	p.scheduleSync(false)
+}
@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func startZMQTestPool(pool *MiniMemPool, zmqMode bool) {
+	pool.lifecycleMutex.Lock()
+	pool.running = true
+	pool.zmqMode = zmqMode
+	pool.stopChan = make(chan struct{})
+	pool.lifecycleMutex.Unlock()
+}
+
+func TestMempoolZMQTxRemovedEvictsDescendants(t *testing.T) {
+	pool := NewMiniMemPool()
+	root := wire.OutPoint{Hash: chainhash.Hash{3}, Index: 0}
+	parent := makeMempoolTestTx(root, 1_000)
+	child := makeMempoolTestTx(wire.OutPoint{Hash: parent.TxHash(), Index: 0}, 900)
+
+	pool.mutex.Lock()
+	pool.admitTransactionLocked(parent)
+	pool.admitTransactionLocked(child)
+	pool.mutex.Unlock()
+
+	// P2P 模式下忽略 zmq 的通知
+	startZMQTestPool(pool, false)
+	pool.txRemoved(parent.TxID())
+	if txs, _, _ := pool.Stats(); txs != 2 {
+		t.Fatalf("txRemoved should be ignored without zmq, size %d", txs)
+	}
+	pool.Stop()
+
+	startZMQTestPool(pool, true)
+	defer pool.Stop()
+	pool.txRemoved(parent.TxID())
+
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+	if len(pool.txMap) != 0 {
+		t.Fatalf("removed tx and its descendants should be evicted, size %d", len(pool.txMap))
+	}
+	if _, spent := pool.spentByOutpoint[root.String()]; spent {
+		t.Fatal("root input should be released")
+	}
+}
+
+func TestMempoolZMQRawTxIgnoredWhenStopped(t *testing.T) {
+	pool := NewMiniMemPool()
+	tx := makeMempoolTestTx(wire.OutPoint{Hash: chainhash.Hash{4}, Index: 0}, 1_000)
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pool.rawTxReceived(buf.Bytes())
+	pool.blockConnected(tx.TxID())
+	if txs, _, _ := pool.Stats(); txs != 0 {
+		t.Fatalf("stopped mempool should ignore zmq rawtx, size %d", txs)
+	}
+}
+
+func TestNotifyNewBlockCoalesces(t *testing.T) {
+	b := &IndexerMgr{newBlockChan: make(chan struct{}, 1)}
+	b.notifyNewBlock()
+	b.notifyNewBlock()
+	if !b.syncPending.Load() {
+		t.Fatal("sync should be pending")
+	}
+	<-b.newBlockChan
+	select {
+	case <-b.newBlockChan:
+		t.Fatal("notifications should be coalesced")
+	default:
+	}
+}
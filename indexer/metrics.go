@@ -0,0 +1,105 @@
+package indexer
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+	"github.com/sat20-labs/indexer/share/metrics"
+)
+
+var (
+	mempoolTxsDesc = prometheus.NewDesc("sat20_indexer_mempool_txs",
+		"Transactions tracked by the mini mempool.", nil, nil)
+	mempoolSpentUtxosDesc = prometheus.NewDesc("sat20_indexer_mempool_spent_utxos",
+		"Outputs spent by mempool transactions.", nil, nil)
+	mempoolNewUtxosDesc = prometheus.NewDesc("sat20_indexer_mempool_new_utxos",
+		"Outputs created by mempool transactions.", nil, nil)
+
+	dbLabels             = []string{"db"}
+	dbDiskSizeDesc       = prometheus.NewDesc("sat20_indexer_db_disk_bytes", "Disk space used by the database.", dbLabels, nil)
+	dbReadAmpDesc        = prometheus.NewDesc("sat20_indexer_db_read_amp", "Database read amplification.", dbLabels, nil)
+	dbCompactionsDesc    = prometheus.NewDesc("sat20_indexer_db_compactions_total", "Compactions since the database was opened.", dbLabels, nil)
+	dbCompactingDesc     = prometheus.NewDesc("sat20_indexer_db_compactions_running", "Compactions in progress.", dbLabels, nil)
+	dbCompactBytesDesc   = prometheus.NewDesc("sat20_indexer_db_compaction_in_progress_bytes", "Bytes written by in-progress compactions.", dbLabels, nil)
+	dbCompactDebtDesc    = prometheus.NewDesc("sat20_indexer_db_compaction_debt_bytes", "Estimated bytes to compact to reach a stable state.", dbLabels, nil)
+	dbCompactSecondsDesc = prometheus.NewDesc("sat20_indexer_db_compaction_seconds_total", "Time spent on compactions since the database was opened.", dbLabels, nil)
+)
+
+// indexerCollector 在抓取时读取当前 IndexerMgr 的内存池和数据库的状态。
+// 只注册一次，重新创建 IndexerMgr 时替换 mgr，旧的实例不会被抓取，也不会被一直引用
+type indexerCollector struct {
+	mgr atomic.Pointer[IndexerMgr]
+}
+
+var (
+	collector         = &indexerCollector{}
+	registerCollector sync.Once
+)
+
+func (p *indexerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- mempoolTxsDesc
+	ch <- mempoolSpentUtxosDesc
+	ch <- mempoolNewUtxosDesc
+	ch <- dbDiskSizeDesc
+	ch <- dbReadAmpDesc
+	ch <- dbCompactionsDesc
+	ch <- dbCompactingDesc
+	ch <- dbCompactBytesDesc
+	ch <- dbCompactDebtDesc
+	ch <- dbCompactSecondsDesc
+}
+
+func (p *indexerCollector) Collect(ch chan<- prometheus.Metric) {
+	mgr := p.mgr.Load()
+	if mgr == nil {
+		return
+	}
+	if mgr.miniMempool != nil {
+		txs, spent, created := mgr.miniMempool.Stats()
+		ch <- prometheus.MustNewConstMetric(mempoolTxsDesc, prometheus.GaugeValue, float64(txs))
+		ch <- prometheus.MustNewConstMetric(mempoolSpentUtxosDesc, prometheus.GaugeValue, float64(spent))
+		ch <- prometheus.MustNewConstMetric(mempoolNewUtxosDesc, prometheus.GaugeValue, float64(created))
+	}
+
+	// 写屏障期间数据库可能正在关闭，跳过，不能阻塞抓取
+	if !mgr.tryRpcEnter() {
+		return
+	}
+	defer mgr.rpcLeft()
+	for _, item := range mgr.snapshotDBs() {
+		stats, err := db.GetDBStats(item.db)
+		if err != nil {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(dbDiskSizeDesc, prometheus.GaugeValue, float64(stats.DiskSize), item.name)
+		ch <- prometheus.MustNewConstMetric(dbReadAmpDesc, prometheus.GaugeValue, float64(stats.ReadAmp), item.name)
+		ch <- prometheus.MustNewConstMetric(dbCompactionsDesc, prometheus.CounterValue, float64(stats.CompactionCount), item.name)
+		ch <- prometheus.MustNewConstMetric(dbCompactingDesc, prometheus.GaugeValue, float64(stats.CompactionRunning), item.name)
+		ch <- prometheus.MustNewConstMetric(dbCompactBytesDesc, prometheus.GaugeValue, float64(stats.CompactionBytes), item.name)
+		ch <- prometheus.MustNewConstMetric(dbCompactDebtDesc, prometheus.GaugeValue, float64(stats.CompactionDebt), item.name)
+		ch <- prometheus.MustNewConstMetric(dbCompactSecondsDesc, prometheus.CounterValue, stats.CompactionDuration.Seconds(), item.name)
+	}
+}
+
+// tryRpcEnter 和 rpcEnter 一样，但写屏障期间直接返回 false
+func (b *IndexerMgr) tryRpcEnter() bool {
+	atomic.AddInt32(&b.rpcProcessing, 1)
+	if atomic.LoadInt32(&b.reloading) > 0 {
+		atomic.AddInt32(&b.rpcProcessing, -1)
+		return false
+	}
+	return true
+}
+
+func (b *IndexerMgr) registerMetrics() {
+	collector.mgr.Store(b)
+	registerCollector.Do(func() {
+		err := metrics.Register(collector)
+		if err != nil {
+			common.Log.Errorf("register indexer metrics failed, %v", err)
+		}
+	})
+}
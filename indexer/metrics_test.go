@@ -0,0 +1,22 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// 重新创建 IndexerMgr 后，抓取的是新的实例
+func TestMetricsCollectorFollowsNewMgr(t *testing.T) {
+	first := newHarnessTestScript(t).h
+	if collector.mgr.Load() != first.IndexerMgr {
+		t.Fatal("collector is not bound to the first mgr")
+	}
+	first.Restart()
+	if collector.mgr.Load() != first.IndexerMgr {
+		t.Fatal("collector still refers to the old mgr")
+	}
+	if n := testutil.CollectAndCount(collector, "sat20_indexer_mempool_txs"); n != 1 {
+		t.Fatalf("collected %d mempool metrics", n)
+	}
+}
@@ -100,6 +100,11 @@ func NewNftIndexer(db common.KVDB) *NftIndexer {
 	return ns
 }
 
+// SetEnableHeight 修改开始处理区块的高度，在 Init 之前调用
+func (p *NftIndexer) SetEnableHeight(height int) {
+	p.enableHeight = height
+}
+
 // 只能被调用一次
 func (p *NftIndexer) Init(baseIndexer *base.BaseIndexer,
 	cb indexerCommon.BlockProcCallback) {
@@ -139,6 +144,7 @@ func (p *NftIndexer) Clone(baseIndexer *base.BaseIndexer) *NftIndexer {
 
 	newInst := NewNftIndexer(p.db)
 	newInst.baseIndexer = baseIndexer
+	newInst.enableHeight = p.enableHeight
 
 	newInst.disabledSats = p.disabledSats // 仅在rpc中使用
 	newInst.utxoMap = make(map[uint64]map[int64]int64)
@@ -8,20 +8,32 @@ import (
 )
 
 func (b *IndexerMgr) GetNftStatus() *common.NftStatus {
+	if b.nft == nil {
+		return nil
+	}
 	return b.nft.GetStatus()
 }
 
 func (b *IndexerMgr) GetNftInfo(id int64) *common.Nft {
+	if b.nft == nil {
+		return nil
+	}
 	return b.nft.GetNftWithId(id)
 }
 
 func (b *IndexerMgr) GetNftInfoWithInscriptionId(id string) *common.Nft {
+	if b.nft == nil {
+		return nil
+	}
 	return b.nft.GetNftWithInscriptionId(id)
 }
 
 // result: nft ids
 func (b *IndexerMgr) GetNftsWithUtxo(utxoId uint64) []string {
 	result := make([]string, 0)
+	if b.nft == nil {
+		return result
+	}
 	sats := b.nft.GetSatsWithUtxo(utxoId)
 	for sat := range sats {
 		info := b.GetNftsWithSat(sat)
@@ -35,10 +47,16 @@ func (b *IndexerMgr) GetNftsWithUtxo(utxoId uint64) []string {
 }
 
 func (b *IndexerMgr) GetNftsWithSat(sat int64) *common.NftsInSat {
+	if b.nft == nil {
+		return nil
+	}
 	return b.nft.GetNftsWithSat(sat)
 }
 
 func (b *IndexerMgr) GetNfts(start, limit int) ([]int64, int) {
+	if b.nft == nil {
+		return nil, 0
+	}
 	return b.nft.GetNfts(start, limit)
 }
 
@@ -60,6 +78,9 @@ func (b *IndexerMgr) getNftWithAddressInBuffer(address string) []*common.Nft {
 }
 
 func (b *IndexerMgr) initAddressToNftMap(address string) []*common.Nft {
+	if b.nft == nil {
+		return nil
+	}
 	utxoMap, err := b.GetUTXOsWithAddress(address)
 	if err != nil {
 		common.Log.Warnf("GetNftsWithAddress %s failed. %v", address, err)
@@ -125,6 +146,9 @@ func (b *IndexerMgr) getNftAmountWithAddress(address string, unconfirmedSpents m
 
 func (b *IndexerMgr) getNftsWithUtxo(utxoId uint64) map[string]common.AssetOffsets {
 	result := make(map[string]common.AssetOffsets)
+	if b.nft == nil {
+		return result
+	}
 	sats := b.nft.GetSatsWithUtxo(utxoId)
 	for sat := range sats {
 		nfts := b.nft.GetNftsWithSat(sat)
@@ -145,6 +169,9 @@ func (b *IndexerMgr) getNftsWithUtxo(utxoId uint64) map[string]common.AssetOffse
 
 func (p *IndexerMgr) GetNftHistory(start int, limit int) ([]*common.MintAbbrInfo, int) {
 	result := make([]*common.MintAbbrInfo, 0)
+	if p.nft == nil {
+		return result, 0
+	}
 	ids, total := p.nft.GetNfts(start, limit)
 	for _, id := range ids {
 		nft := p.nft.GetNftWithId(id)
@@ -159,6 +186,9 @@ func (p *IndexerMgr) GetNftHistory(start int, limit int) ([]*common.MintAbbrInfo
 // gen address
 func (p *IndexerMgr) GetNftHistoryWithAddress(addressId uint64, start int, limit int) ([]*common.MintAbbrInfo, int) {
 	result := make([]*common.MintAbbrInfo, 0)
+	if p.nft == nil {
+		return result, 0
+	}
 	ids, total := p.nft.GetNftsWithInscriptionAddress(addressId, start, limit)
 	for _, id := range ids {
 		nft := p.nft.GetNftWithId(id)
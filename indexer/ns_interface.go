@@ -8,6 +8,9 @@ import (
 )
 
 func (b *IndexerMgr) GetNSStatus() *common.NameServiceStatus {
+	if b.ns == nil {
+		return nil
+	}
 	return b.ns.GetStatus()
 }
 
@@ -38,6 +41,9 @@ func (b *IndexerMgr) GetNameInfo(name string) *common.NameInfo {
 	b.rpcEnter()
 	defer b.rpcLeft()
 
+	if b.ns == nil {
+		return nil
+	}
 	reg := b.ns.GetNameRegisterInfo(name)
 	if reg == nil {
 		common.Log.Errorf("GetNameRegisterInfo %s failed", name)
@@ -51,6 +57,9 @@ func (b *IndexerMgr) IsNameExist(name string) bool {
 	b.rpcEnter()
 	defer b.rpcLeft()
 
+	if b.ns == nil {
+		return false
+	}
 	return b.ns.IsNameExist(name)
 }
 
@@ -62,6 +71,9 @@ func (b *IndexerMgr) GetNameWithInscriptionId(id string) *common.NameInfo {
 }
 
 func (b *IndexerMgr) getNameWithInscriptionId(id string) *common.NameInfo {
+	if b.ns == nil {
+		return nil
+	}
 	reg := b.ns.GetNameRegisterInfoWithInscriptionId(id)
 	if reg == nil {
 		common.Log.Errorf("GetNameWithInscriptionId %s failed", id)
@@ -75,6 +87,9 @@ func (b *IndexerMgr) GetNamesWithUtxo(utxoId uint64) []string {
 	b.rpcEnter()
 	defer b.rpcLeft()
 
+	if b.ns == nil {
+		return nil
+	}
 	return b.ns.GetNamesWithUtxo2(utxoId)
 }
 
@@ -82,6 +97,9 @@ func (b *IndexerMgr) GetNames(start, limit int) []string {
 	b.rpcEnter()
 	defer b.rpcLeft()
 
+	if b.ns == nil {
+		return nil
+	}
 	return b.ns.GetNames(start, limit)
 }
 
@@ -103,6 +121,9 @@ func (b *IndexerMgr) getNamesWithAddressInBuffer(address string) []*common.Nft {
 }
 
 func (b *IndexerMgr) initAddressToNameMap(address string) []*common.Nft {
+	if b.ns == nil {
+		return nil
+	}
 	nfts := b.getNftWithAddressInBuffer(address)
 	names := make([]*common.Nft, 0)
 	for _, nft := range nfts {
@@ -318,6 +339,9 @@ func (b *IndexerMgr) GetNamesWithSat(sat int64) []*common.NameInfo {
 	defer b.rpcLeft()
 
 	result := make([]*common.NameInfo, 0)
+	if b.ns == nil {
+		return result
+	}
 
 	names := b.ns.GetNameRegisterInfoWithSat(sat)
 	for _, name := range names {
@@ -334,11 +358,17 @@ func (b *IndexerMgr) HasNameInUtxo(utxoId uint64) bool {
 	b.rpcEnter()
 	defer b.rpcLeft()
 
+	if b.ns == nil {
+		return false
+	}
 	return b.ns.HasNamesInUtxo(utxoId)
 }
 
 func (b *IndexerMgr) getNamesWithUtxo(utxoId uint64) map[string]common.AssetOffsets {
 	result := make(map[string]common.AssetOffsets)
+	if b.ns == nil {
+		return result
+	}
 	names := b.ns.GetNamesWithUtxo(utxoId)
 	for _, name := range names {
 		offsets := common.AssetOffsets{
@@ -361,6 +391,9 @@ func (p *IndexerMgr) GetNameHistory(start int, limit int) []*common.MintAbbrInfo
 
 func (p *IndexerMgr) getNameHistory(start int, limit int) []*common.MintAbbrInfo {
 	result := make([]*common.MintAbbrInfo, 0)
+	if p.ns == nil {
+		return result
+	}
 	names := p.ns.GetNames(start, limit)
 	for _, name := range names {
 		reg := p.ns.GetNameRegisterInfo(name)
@@ -376,6 +409,9 @@ func (p *IndexerMgr) GetNameHistoryWithAddress(addressId uint64, start int, limi
 	p.rpcEnter()
 	defer p.rpcLeft()
 	result := make([]*common.MintAbbrInfo, 0)
+	if p.ns == nil {
+		return result, 0
+	}
 	nfts, total := p.ns.GetNamesWithInscriptionAddress(addressId, start, limit)
 	for _, nft := range nfts {
 		info := common.NewMintAbbrInfo2(nft.Base)
@@ -0,0 +1,58 @@
+package indexer
+
+import (
+	"fmt"
+	"sync"
+)
+
+/*
+区块中各协议的处理顺序:
+
+	base → exotic → 铭文解析 → nft → (ns | brc20 | ft)
+	     ↘ runes
+	     ↘ atom
+	     ↘ sat index
+
+ordx 的铸造需要读取 exotic 写到输入中的稀有资产，所以 exotic 放在 nft 前面。
+runes、atom 和聪区间索引不依赖铭文，可以跟上面的链并行。
+每个协议只修改自己的数据，区块数据在 nft 之后只读，基础索引的地址缓存有锁保护。
+需要读取多个协议结果的步骤(交易资产流向、事件)放在 mergeBlock 中，等所有阶段结束后按固定顺序执行。
+*/
+
+type blockStage struct {
+	name string
+	run  func()
+}
+
+// runStages 并行执行，全部结束后才返回。有 panic 时按 stages 的顺序重新抛出第一个，
+// 保证出错的结果跟串行执行一样。serial 为 true 时按顺序执行
+func (s *IndexerMgr) runStages(stages ...blockStage) {
+	if s.serialProtocol || len(stages) <= 1 {
+		for _, stage := range stages {
+			stage.run()
+		}
+		return
+	}
+
+	panics := make([]interface{}, len(stages))
+	var wg sync.WaitGroup
+	for i, stage := range stages {
+		wg.Add(1)
+		go func(i int, stage blockStage) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					panics[i] = fmt.Errorf("%s: %v", stage.name, r)
+				}
+			}()
+			stage.run()
+		}(i, stage)
+	}
+	wg.Wait()
+
+	for _, r := range panics {
+		if r != nil {
+			panic(r)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/indexer/db"
+	"github.com/sat20-labs/indexer/indexer/exotic"
+	"github.com/sat20-labs/indexer/indexer/fixture"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	"lukechampine.com/uint128"
+)
+
+const pipelineTestInscription = "text/plain;charset=utf-8"
+
+// 所有协议都打开，从高度 1 开始处理
+func newPipelineTestHarness(t *testing.T, chain *fixture.Chain, serial bool) *Harness {
+	cfg := &config.YamlConf{}
+	cfg.Chain = common.ChainMainnet
+	cfg.DB.Path = filepath.Join(t.TempDir(), "db") + string(filepath.Separator)
+	cfg.BasicIndex.SerialProtocol = serial
+	for _, name := range []string{config.PROTOCOL_NFT, config.PROTOCOL_FT, config.PROTOCOL_NS,
+		config.PROTOCOL_BRC20, config.PROTOCOL_RUNES, config.PROTOCOL_ATOM} {
+		cfg.Protocols.Get(name).StartHeight = 1
+	}
+	return NewHarness(cfg, chain)
+}
+
+func newPipelineTestRunestone(t *testing.T, r *runestone.Runestone) *wire.TxOut {
+	script, err := r.Encipher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixture.Output(script, 0)
+}
+
+// atom 的 nft 铸造脚本，见 atom/parser.go
+func newPipelineTestAtomWitness(t *testing.T, args map[string]any) wire.TxWitness {
+	payload, err := cbor.Marshal(map[string]any{"args": args})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := txscript.NewScriptBuilder().
+		AddData(make([]byte, 32)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("atom")).
+		AddData([]byte("nft")).
+		AddData(payload).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wire.TxWitness{script}
+}
+
+// 同一个区块中有普通铭文、名字、brc20、ordx、runes 和 atom 的 commit，之后铸造、转账
+func newPipelineTestChain(t *testing.T) *fixture.Chain {
+	chain := fixture.NewChain(&chaincfg.MainNetParams)
+	b0 := chain.Mine(fixture.PkScript("miner"))
+
+	// 拆成多个输出，每个交易花费一个
+	fund := fixture.NewTx([]wire.OutPoint{fixture.OutPoint(b0.Transactions[0], 0)})
+	for i := 0; i < 10; i++ {
+		fund.AddTxOut(fixture.Output(fixture.PkScript("alice"), 100000))
+	}
+	chain.Mine(fixture.PkScript("miner"), fund)
+	next := 0
+	spend := func() wire.OutPoint {
+		next++
+		return fixture.OutPoint(fund, next-1)
+	}
+	inscribe := func(body string) *wire.MsgTx {
+		tx := fixture.NewTx([]wire.OutPoint{spend()}, fixture.Output(fixture.PkScript("alice"), 10000))
+		tx.TxIn[0].Witness = fixture.InscriptionWitness(pipelineTestInscription, []byte(body))
+		return tx
+	}
+
+	amount := uint128.From64(100)
+	limit := uint128.From64(10)
+	premine := uint128.From64(1000)
+	etching := fixture.NewTx([]wire.OutPoint{spend()},
+		fixture.Output(fixture.PkScript("alice"), 10000),
+		newPipelineTestRunestone(t, &runestone.Runestone{Etching: &runestone.Etching{
+			Premine: &premine,
+			Terms:   &runestone.Terms{Amount: &amount, Cap: &limit},
+		}}))
+	text := inscribe("hello")
+	atomCommit := fixture.NewTx([]wire.OutPoint{spend()}, fixture.Output(fixture.PkScript("alice"), 10000))
+	chain.Mine(fixture.PkScript("miner"),
+		text,
+		inscribe("alice.btc"),
+		inscribe(`{"p":"brc-20","op":"deploy","tick":"ordi","max":"1000","lim":"100"}`),
+		inscribe(`{"p":"ordx","op":"deploy","tick":"pipeline","lim":"100"}`),
+		etching,
+		atomCommit,
+	)
+	runeId := &runestone.RuneId{Block: 2, Tx: 5}
+
+	atomReveal := fixture.NewTx([]wire.OutPoint{fixture.OutPoint(atomCommit, 0)}, fixture.Output(fixture.PkScript("alice"), 9000))
+	atomReveal.TxIn[0].Witness = newPipelineTestAtomWitness(t, map[string]any{"request_realm": "pipeline"})
+	chain.Mine(fixture.PkScript("miner"),
+		inscribe(`{"p":"brc-20","op":"mint","tick":"ordi","amt":"100"}`),
+		inscribe(`{"p":"ordx","op":"mint","tick":"pipeline"}`),
+		fixture.NewTx([]wire.OutPoint{spend()},
+			fixture.Output(fixture.PkScript("alice"), 10000),
+			newPipelineTestRunestone(t, &runestone.Runestone{Mint: runeId})),
+		atomReveal,
+	)
+	transfer := inscribe(`{"p":"brc-20","op":"transfer","tick":"ordi","amt":"50"}`)
+	chain.Mine(fixture.PkScript("miner"), transfer)
+
+	// brc20 转账、铭文和 runes 转给 bob，剩下的 runes 回到 alice
+	change := uint32(1)
+	chain.Mine(fixture.PkScript("miner"),
+		fixture.NewTx([]wire.OutPoint{fixture.OutPoint(transfer, 0)},
+			fixture.Output(fixture.PkScript("bob"), 9000)),
+		fixture.NewTx([]wire.OutPoint{fixture.OutPoint(text, 0), fixture.OutPoint(etching, 0)},
+			fixture.Output(fixture.PkScript("bob"), 9000),
+			fixture.Output(fixture.PkScript("alice"), 9000),
+			newPipelineTestRunestone(t, &runestone.Runestone{
+				Edicts:  []runestone.Edict{{ID: *runeId, Amount: uint128.From64(400), Output: 0}},
+				Pointer: &change,
+			})),
+	)
+
+	// 追块时按周期写数据库，多生成一些区块让上面的数据都写到数据库中
+	for i := 0; i < 20; i++ {
+		chain.Mine(fixture.PkScript("miner"))
+	}
+	return chain
+}
+
+// 各协议用 gob 保存 map，同样的数据编码后的字节不一定一样，所以只比较 key，数据通过接口比较
+func dumpPipelineTestDB(t *testing.T, kv common.KVDB) map[string][]byte {
+	result := make(map[string][]byte)
+	err := kv.BatchRead([]byte{}, false, func(k, v []byte) error {
+		result[string(k)] = normalizePipelineTestValue(k, v)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// exotic 的默认 ticker 用当前时间作为 BlockTime，比较前清零；
+// undo 记录按写入的顺序保存，比较前按 key 排序
+func normalizePipelineTestValue(key, value []byte) []byte {
+	var encoded interface{}
+	switch {
+	case bytes.HasPrefix(key, []byte(exotic.DB_PREFIX_TICKER)):
+		var ticker common.Ticker
+		if db.DecodeBytes(value, &ticker) != nil || ticker.Base == nil {
+			return append([]byte{}, value...)
+		}
+		ticker.Base.BlockTime = 0
+		encoded = &ticker
+	case bytes.HasPrefix(key, []byte(db.DB_KEY_UNDO_DATA)):
+		var entries []db.UndoEntry
+		if db.DecodeBytes(value, &entries) != nil {
+			return append([]byte{}, value...)
+		}
+		for i := range entries {
+			if entries[i].Existed {
+				entries[i].Value = normalizePipelineTestValue(entries[i].Key, entries[i].Value)
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].Key, entries[j].Key) < 0 })
+		encoded = entries
+	default:
+		return append([]byte{}, value...)
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(encoded); err != nil {
+		return append([]byte{}, value...)
+	}
+	return buf.Bytes()
+}
+
+// gob 和 proto 编码 map 的顺序是随机的，字节不同时，按字节排序后再比较，只允许元素的顺序不同
+func samePipelineTestValue(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	if len(a) != len(b) {
+		return false
+	}
+	sa := append([]byte{}, a...)
+	sb := append([]byte{}, b...)
+	sort.Slice(sa, func(i, j int) bool { return sa[i] < sa[j] })
+	sort.Slice(sb, func(i, j int) bool { return sb[i] < sb[j] })
+	return bytes.Equal(sa, sb)
+}
+
+// 地址上的资产汇总和每个 utxo 中的资产
+func dumpPipelineTestAssets(t *testing.T, h *Harness, names ...string) map[string]string {
+	result := make(map[string]string)
+	dump := func(key string, value interface{}) {
+		buf, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[key] = string(buf)
+	}
+	for _, name := range names {
+		address := fixture.Address(name, &chaincfg.MainNetParams)
+		summary := make(map[string]string)
+		for ticker, amount := range h.GetAssetSummaryInAddressV3(address) {
+			summary[ticker.String()] = amount.String()
+		}
+		dump(name, summary)
+		for _, utxo := range h.rpcService.GetUTXOs2(address) {
+			dump(utxo, h.GetTxOutputWithUtxoV3(utxo, false))
+		}
+	}
+	return result
+}
+
+// 所有协议都打开，并行处理区块后数据库中的 key 和查询到的资产，跟串行处理完全一样
+func TestProcessOrdProtocolDeterministic(t *testing.T) {
+	chain := newPipelineTestChain(t)
+	run := func(serial bool) (map[string]map[string][]byte, map[string]string) {
+		h := newPipelineTestHarness(t, chain, serial)
+		defer h.Close()
+		if err := h.Sync(); err != nil {
+			t.Fatal(err)
+		}
+
+		runeInfo := h.RunesIndexer.GetRuneInfoWithId("2:5")
+		if runeInfo == nil {
+			t.Fatal("rune is not etched")
+		}
+		runeTicker := "runes:f:" + runeInfo.Name
+		assets := dumpPipelineTestAssets(t, h, "alice", "bob", "miner")
+		want := map[string]map[string]string{
+			"alice": {"brc20:f:ordi": "50", "ordx:f:pipeline": "100", runeTicker: "700"},
+			"bob":   {"brc20:f:ordi": "50", runeTicker: "400"},
+		}
+		for name, tickers := range want {
+			for ticker, amount := range tickers {
+				if !strings.Contains(assets[name], fmt.Sprintf("%q:%q", ticker, amount)) {
+					t.Errorf("%s has %s, want %s %s", name, assets[name], ticker, amount)
+				}
+			}
+		}
+		if h.ns.GetNameRegisterInfo("alice.btc") == nil {
+			t.Errorf("name is not registered")
+		}
+		realm := h.ResolveRealm("pipeline").Atomical
+		if realm == nil || realm.Status != "verified" || realm.Address != fixture.Address("alice", &chaincfg.MainNetParams) {
+			t.Errorf("atom realm is not minted to alice, %+v", realm)
+		}
+
+		dbs := map[string]map[string][]byte{
+			"base":   dumpPipelineTestDB(t, h.baseDB),
+			"exotic": dumpPipelineTestDB(t, h.exoticDB),
+			"nft":    dumpPipelineTestDB(t, h.nftDB),
+			"ft":     dumpPipelineTestDB(t, h.ftDB),
+			"ns":     dumpPipelineTestDB(t, h.nsDB),
+			"brc20":  dumpPipelineTestDB(t, h.brc20DB),
+			"runes":  dumpPipelineTestDB(t, h.runesDB),
+			"atom":   dumpPipelineTestDB(t, h.atomDB),
+		}
+		return dbs, assets
+	}
+
+	serialDBs, serialAssets := run(true)
+	parallelDBs, parallelAssets := run(false)
+	for name, data := range serialDBs {
+		if len(data) == 0 {
+			t.Fatalf("%s db is empty", name)
+		}
+		for k, v := range data {
+			if pv, ok := parallelDBs[name][k]; !ok || !samePipelineTestValue(v, pv) {
+				t.Errorf("%s db: key %q serial has %x, parallel has %x", name, k, v, pv)
+			}
+		}
+		for k := range parallelDBs[name] {
+			if _, ok := data[k]; !ok {
+				t.Errorf("%s db: key %q only in parallel", name, k)
+			}
+		}
+	}
+	if !reflect.DeepEqual(serialAssets, parallelAssets) {
+		t.Fatalf("serial has assets %v, parallel has %v", serialAssets, parallelAssets)
+	}
+}
+
+func TestRunStagesPanic(t *testing.T) {
+	b := &IndexerMgr{}
+	defer func() {
+		r := recover()
+		if r == nil || fmt.Sprint(r) != "first: 1" {
+			t.Fatalf("unexpected panic %v", r)
+		}
+	}()
+	// 不管哪个先出错，都按顺序抛出第一个
+	b.runStages(
+		blockStage{"first", func() { panic(1) }},
+		blockStage{"second", func() { panic(2) }},
+	)
+}
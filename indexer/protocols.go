@@ -0,0 +1,67 @@
+package indexer
+
+import (
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+)
+
+func (b *IndexerMgr) protocolConf(name string) *config.Protocol {
+	if b.cfg == nil {
+		return nil
+	}
+	return b.cfg.Protocols.Get(name)
+}
+
+// IsProtocolEnabled 没有配置的协议默认打开
+func (b *IndexerMgr) IsProtocolEnabled(name string) bool {
+	conf := b.protocolConf(name)
+	return conf == nil || !conf.Disable
+}
+
+// ProtocolStartHeight 协议开始处理区块的高度。exotic 默认从 0 开始，其他协议默认从第一个铭文的高度开始
+func (b *IndexerMgr) ProtocolStartHeight(name string) int {
+	if height := b.configuredStartHeight(name); height > 0 {
+		return height
+	}
+	if name == config.PROTOCOL_EXOTIC {
+		return 0
+	}
+	return b.ordFirstHeight
+}
+
+// configuredStartHeight 配置中的开始高度，没有配置返回 0。索引器内部的激活高度也用这个值
+func (b *IndexerMgr) configuredStartHeight(name string) int {
+	conf := b.protocolConf(name)
+	if conf == nil {
+		return 0
+	}
+	return conf.StartHeight
+}
+
+func (b *IndexerMgr) isProtocolActive(name string, height int) bool {
+	return b.IsProtocolEnabled(name) && height >= b.ProtocolStartHeight(name)
+}
+
+// isTickerProtocolEnabled 资产所属的协议索引器是否打开
+func (b *IndexerMgr) isTickerProtocolEnabled(tickerName *common.TickerName) bool {
+	switch tickerName.Protocol {
+	case common.PROTOCOL_NAME_ORDX:
+		switch tickerName.Type {
+		case common.ASSET_TYPE_NFT:
+			return b.nft != nil
+		case common.ASSET_TYPE_NS:
+			return b.ns != nil
+		case common.ASSET_TYPE_EXOTIC:
+			return b.exotic != nil
+		default:
+			return b.ftIndexer != nil
+		}
+	case common.PROTOCOL_NAME_BRC20:
+		return b.brc20Indexer != nil
+	case common.PROTOCOL_NAME_RUNES:
+		return b.RunesIndexer != nil
+	case common.PROTOCOL_NAME_ATOM:
+		return b.atomIndexer != nil
+	}
+	return true
+}
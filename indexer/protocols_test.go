@@ -0,0 +1,63 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/sat20-labs/indexer/config"
+)
+
+func TestProtocolStartHeight(t *testing.T) {
+	cfg := &config.YamlConf{}
+	cfg.Protocols.Runes.StartHeight = 840000
+	cfg.Protocols.Brc20.Disable = true
+	b := &IndexerMgr{cfg: cfg, ordFirstHeight: 767430}
+
+	// 没有配置的协议保持原来的激活高度
+	if h := b.ProtocolStartHeight(config.PROTOCOL_EXOTIC); h != 0 {
+		t.Fatalf("exotic start height %d", h)
+	}
+	if h := b.ProtocolStartHeight(config.PROTOCOL_NFT); h != 767430 {
+		t.Fatalf("nft start height %d", h)
+	}
+	if h := b.ProtocolStartHeight(config.PROTOCOL_RUNES); h != 840000 {
+		t.Fatalf("runes start height %d", h)
+	}
+
+	if b.isProtocolActive(config.PROTOCOL_RUNES, 839999) {
+		t.Fatal("runes should not be active before its start height")
+	}
+	if !b.isProtocolActive(config.PROTOCOL_RUNES, 840000) {
+		t.Fatal("runes should be active at its start height")
+	}
+	if b.isProtocolActive(config.PROTOCOL_BRC20, 900000) {
+		t.Fatal("disabled protocol should never be active")
+	}
+	if !b.IsProtocolEnabled(config.PROTOCOL_ATOM) {
+		t.Fatal("protocols are enabled by default")
+	}
+}
+
+func TestProtocolsValidate(t *testing.T) {
+	// 只保留 runes 和基础的 utxo 索引
+	p := config.Protocols{}
+	p.Exotic.Disable = true
+	p.Nft.Disable = true
+	p.Ft.Disable = true
+	p.Ns.Disable = true
+	p.Brc20.Disable = true
+	p.Atom.Disable = true
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Ft.Disable = false
+	if err := p.Validate(); err == nil {
+		t.Fatal("ft without nft should fail")
+	}
+
+	p = config.Protocols{}
+	p.Runes.StartHeight = -1
+	if err := p.Validate(); err == nil {
+		t.Fatal("negative start height should fail")
+	}
+}
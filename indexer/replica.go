@@ -0,0 +1,298 @@
+package indexer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+/*
+只读副本
+
+主节点（replica.role: primary）把每次提交的数据库写入保存为提交日志（追块时也记录，只保留最近的 keep_commits 个，
+超过 max_commit_log_mb 的提交不记录），
+副本（replica.role: replica）不跑数据，定时从主节点拉取提交日志，在本地按同样的提交流程重放，
+然后重新加载索引器，对外提供 rpc 服务。
+
+副本的数据跟主节点数据库中的数据一致，比主节点内存中的数据落后几个区块（见 updateDB）。
+新的副本需要先用主节点生成的数据库快照（POST /admin/replica/checkpoint）作为自己的数据库。
+*/
+
+const (
+	defaultReplicaKeepCommits    = 100
+	defaultReplicaMaxCommitLogMB = 256
+	defaultReplicaPollSeconds    = 5
+	replicaMaxPullCommits        = 20 // 一次拉取后重放的提交数量，重放完才重新加载索引器
+)
+
+// 主节点: 在提交完成前保存提交日志
+func (b *IndexerMgr) saveCommitLog(meta *db.UndoMeta) {
+	log := b.changeLog.End(meta)
+	if log == nil {
+		return
+	}
+	if err := db.SetCommitLog(b.baseDB, log); err != nil {
+		common.Log.Errorf("SetCommitLog %d failed, %v", meta.Height, err)
+		return
+	}
+	keep := b.cfg.Replica.KeepCommits
+	if keep <= 0 {
+		keep = defaultReplicaKeepCommits
+	}
+	if err := db.PruneCommitLogs(b.baseDB, keep); err != nil {
+		common.Log.Errorf("PruneCommitLogs failed, %v", err)
+	}
+}
+
+// GetReplicaCommitLog 副本在 (height, hash) 之后需要重放的提交日志，已经是最新时返回 nil
+func (b *IndexerMgr) GetReplicaCommitLog(height int, hash string) ([]byte, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	if b.changeLog == nil {
+		return nil, fmt.Errorf("replica.role is not primary")
+	}
+	meta, err := db.FindNextCommitLog(b.baseDB, height, hash)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return db.GetRawCommitLog(b.baseDB, meta.Height)
+}
+
+type ReplicaCheckpoint struct {
+	Dir    string `json:"dir"`
+	Height int    `json:"height"`
+}
+
+// CreateReplicaCheckpoint 在数据库目录下生成所有链上数据库的快照，复制到副本的数据库目录就可以启动副本
+func (b *IndexerMgr) CreateReplicaCheckpoint() (*ReplicaCheckpoint, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	// 不能跟提交同时进行，保证各数据库在同一个高度
+	b.commitMutex.Lock()
+	defer b.commitMutex.Unlock()
+
+	height, err := db.GetCommitHeight(b.baseDB)
+	if err != nil {
+		return nil, fmt.Errorf("no commit in db yet, %v", err)
+	}
+	dir := filepath.Join(b.dbDir, fmt.Sprintf("checkpoint-%d", height))
+	if _, err := os.Stat(dir); err == nil {
+		return &ReplicaCheckpoint{Dir: dir, Height: height}, nil
+	}
+	tmp := dir + ".tmp"
+	os.RemoveAll(tmp)
+	for name, kvdb := range b.chainDBsByName() {
+		if err := db.CheckpointDB(kvdb, filepath.Join(tmp, name)); err != nil {
+			os.RemoveAll(tmp)
+			return nil, fmt.Errorf("checkpoint %s failed, %v", name, err)
+		}
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+	common.Log.Infof("replica checkpoint at %d created in %s", height, dir)
+	return &ReplicaCheckpoint{Dir: dir, Height: height}, nil
+}
+
+// 副本: 代替 StartDaemon 中的同步
+func (b *IndexerMgr) runReplica(stopChan chan bool) {
+	n := b.cfg.Replica.PollSeconds
+	if n <= 0 {
+		n = defaultReplicaPollSeconds
+	}
+	ticker := time.NewTicker(time.Duration(n) * time.Second)
+	defer ticker.Stop()
+
+	common.Log.Infof("replica of %s starts at %d", b.cfg.Replica.Primary, b.base.GetSyncHeight())
+	for {
+		for {
+			more, err := b.pullCommitLog()
+			if err != nil {
+				common.Log.Errorf("replica pull commit log failed, %v", err)
+				break
+			}
+			if !more {
+				break
+			}
+		}
+
+		select {
+		case <-stopChan:
+			b.withIndexerStateWriteBarrier("shutdown", b.closeDB)
+			common.Log.Infof("replica exited.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pullCommitLog 先拉取后面的多个提交日志，一起重放后只重新加载一次索引器。还有没拉取的日志时返回 true
+func (b *IndexerMgr) pullCommitLog() (bool, error) {
+	stats := b.base.GetSyncStats()
+	height, hash := stats.SyncHeight, stats.SyncBlockHash
+	limit := b.cfg.Replica.MaxCommitLogMB
+	if limit <= 0 {
+		limit = defaultReplicaMaxCommitLogMB
+	}
+	logs := make([]*db.CommitLog, 0)
+	size := 0
+	more := true
+	var err error
+	for len(logs) < replicaMaxPullCommits && size < limit*1024*1024 {
+		var buf []byte
+		buf, err = fetchCommitLog(b.cfg.Replica.Primary, b.cfg.Replica.ApiKey, height, hash)
+		if err != nil || buf == nil {
+			more = false
+			break
+		}
+		var log *db.CommitLog
+		log, err = db.DecodeCommitLog(buf)
+		if err != nil {
+			more = false
+			break
+		}
+		logs = append(logs, log)
+		size += len(buf)
+		height, hash = log.Height, log.Hash
+	}
+	if len(logs) == 0 {
+		return false, err
+	}
+
+	applied := 0
+	var applyErr error
+	b.withIndexerStateWriteBarrier("replica", func() {
+		height, hash := stats.SyncHeight, stats.SyncBlockHash
+		for _, log := range logs {
+			if applyErr = b.applyCommitLog(log, height, hash); applyErr != nil {
+				break
+			}
+			height, hash = log.Height, log.Hash
+			applied++
+		}
+		b.initIndexers()
+	})
+	if applied > 0 {
+		common.Log.Infof("replica applied commit %d to %d", logs[0].Height, logs[applied-1].Height)
+	}
+	if applyErr != nil {
+		return false, applyErr
+	}
+	return more, err
+}
+
+// 跟主节点一样的提交流程，中途退出时启动后回滚。syncHeight 和 syncHash 是副本数据库当前的提交
+func (b *IndexerMgr) applyCommitLog(log *db.CommitLog, syncHeight int, syncHash string) error {
+	b.commitMutex.Lock()
+	defer b.commitMutex.Unlock()
+
+	if log.PrevHeight != syncHeight || log.PrevHash != syncHash {
+		if log.PrevHeight >= syncHeight {
+			return fmt.Errorf("commit %d follows %d but replica is at %d", log.Height, log.PrevHeight, syncHeight)
+		}
+		// 主节点回滚过
+		common.Log.Infof("replica rollback from %d to %d", syncHeight, log.PrevHeight)
+		for _, kvdb := range b.chainDBs() {
+			if _, err := db.RollbackToHeight(kvdb, log.PrevHeight); err != nil {
+				return err
+			}
+		}
+		height, err := db.GetCommitHeight(b.baseDB)
+		if err != nil || height != log.PrevHeight {
+			return fmt.Errorf("replica can't rollback to %d, please restore from a checkpoint", log.PrevHeight)
+		}
+	}
+
+	// 主节点追块时不记录旧值，副本总是记录，重放中途退出或者主节点回滚时都要用到
+	meta := &log.UndoMeta
+	meta.NoUndo = false
+	if err := db.SetPendingCommit(b.baseDB, meta); err != nil {
+		return err
+	}
+	b.undoJournal.Begin(meta)
+	dbs := b.chainDBsByName()
+	for _, name := range chainDBNames {
+		changes := log.Changes[name]
+		if len(changes) == 0 {
+			continue
+		}
+		kvdb, ok := dbs[name]
+		if !ok {
+			common.Log.Warnf("replica ignores changes of %s, the protocol is disabled", name)
+			continue
+		}
+		if err := db.ApplyChanges(kvdb, changes); err != nil {
+			b.undoJournal.End()
+			return err
+		}
+	}
+	b.undoJournal.End()
+	if err := db.ClearPendingCommit(b.baseDB); err != nil {
+		return err
+	}
+
+	pruneHeight := meta.Height - b.undoJournalKeepBlocks()
+	if pruneHeight > 0 {
+		for _, kvdb := range b.chainDBs() {
+			if err := db.PruneUndoJournal(kvdb, pruneHeight); err != nil {
+				common.Log.Errorf("PruneUndoJournal %d failed, %v", pruneHeight, err)
+			}
+		}
+	}
+	return nil
+}
+
+// 主节点没有新的提交时返回 nil
+func fetchCommitLog(primary, apiKey string, height int, hash string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("height", fmt.Sprintf("%d", height))
+	query.Set("hash", hash)
+	req, err := http.NewRequest(http.MethodGet,
+		strings.TrimSuffix(primary, "/")+"/admin/replica/log?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("primary returns %s", resp.Status)
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var result struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&result); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("primary returns %d %s", result.Code, result.Msg)
+	}
+	return body, nil
+}
@@ -0,0 +1,167 @@
+package indexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/indexer/db"
+	"github.com/sat20-labs/indexer/indexer/fixture"
+)
+
+// 主节点追块时的提交也要有提交日志，否则副本接不上
+func TestPrimaryRecordsCatchUpCommitLog(t *testing.T) {
+	cfg := &config.YamlConf{}
+	cfg.Chain = common.ChainMainnet
+	cfg.DB.Path = filepath.Join(t.TempDir(), "db") + string(filepath.Separator)
+	cfg.Replica.Role = config.ROLE_PRIMARY
+	chain := fixture.NewChain(&chaincfg.MainNetParams)
+	chain.Mine(fixture.PkScript("miner"))
+	s := &harnessTestScript{t: t, h: NewHarness(cfg, chain)}
+	t.Cleanup(func() {
+		s.h.Close()
+	})
+
+	for i := 0; i < s.h.undoJournalKeepBlocks()+30; i++ {
+		s.mine("miner")
+	}
+	s.sync()
+
+	var first db.UndoMeta
+	catchUp := 0
+	total := 0
+	err := s.h.baseDB.BatchRead([]byte(db.DB_KEY_COMMIT_LOG_META), false, func(k, v []byte) error {
+		var meta db.UndoMeta
+		if err := db.DecodeBytes(v, &meta); err != nil {
+			return err
+		}
+		if total == 0 {
+			first = meta
+		}
+		total++
+		// 做不了检查点的数据库追块时也记录撤销日志，按高度判断
+		if s.h.Chain.Height()-meta.Height > s.h.undoJournalKeepBlocks() {
+			catchUp++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if catchUp == 0 || total == 0 {
+		t.Fatalf("commit logs: %d, catch-up: %d", total, catchUp)
+	}
+
+	// 副本从第一个提交开始可以一直接到最新的提交
+	height, hash := first.PrevHeight, first.PrevHash
+	for i := 0; i < total; i++ {
+		next, err := db.FindNextCommitLog(s.h.baseDB, height, hash)
+		if err != nil || next == nil {
+			t.Fatalf("no commit log follows %d, %v", height, err)
+		}
+		height, hash = next.Height, next.Hash
+	}
+	if height != s.h.base.GetSyncHeight() {
+		t.Fatalf("commit logs end at %d, synced to %d", height, s.h.base.GetSyncHeight())
+	}
+}
+
+// 副本一次拉取多个提交日志，重放后跟主节点的数据一样
+func TestReplicaPullCommitLogs(t *testing.T) {
+	cfg := &config.YamlConf{}
+	cfg.Chain = common.ChainMainnet
+	cfg.DB.Path = filepath.Join(t.TempDir(), "db") + string(filepath.Separator)
+	cfg.Replica.Role = config.ROLE_PRIMARY
+	cfg.Replica.KeepCommits = 1000
+	cfg.BasicIndex.PeriodFlushToDB = 1
+	chain := fixture.NewChain(&chaincfg.MainNetParams)
+	chain.Mine(fixture.PkScript("miner"))
+	s := &harnessTestScript{t: t, h: NewHarness(cfg, chain), alice: make(map[wire.OutPoint]bool), owners: make(map[int][]wire.OutPoint)}
+	for i := 0; i < 30; i++ {
+		s.mine("alice")
+	}
+	s.sync()
+	for i := 0; i < 30; i++ {
+		s.mine("alice")
+		s.sync()
+	}
+	syncHeight := s.h.base.GetSyncHeight()
+
+	// 同时只能有一个 Harness，先取出主节点的提交日志
+	logs := make([][]byte, 0)
+	metas := make([]*db.UndoMeta, 0)
+	err := s.h.baseDB.BatchRead([]byte(db.DB_KEY_COMMIT_LOG_DATA), false, func(k, v []byte) error {
+		log, err := db.DecodeCommitLog(v)
+		if err != nil {
+			return err
+		}
+		logs = append(logs, append([]byte{}, v...))
+		metas = append(metas, &log.UndoMeta)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.h.Close()
+	if len(logs) <= replicaMaxPullCommits {
+		t.Fatalf("only %d commit logs", len(logs))
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		height, _ := strconv.Atoi(r.URL.Query().Get("height"))
+		hash := r.URL.Query().Get("hash")
+		for i, meta := range metas {
+			if meta.PrevHeight == height && meta.PrevHash == hash {
+				w.Write(logs[i])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	replicaCfg := &config.YamlConf{}
+	replicaCfg.Chain = common.ChainMainnet
+	replicaCfg.DB.Path = filepath.Join(t.TempDir(), "replica") + string(filepath.Separator)
+	replicaCfg.Replica.Role = config.ROLE_REPLICA
+	replicaCfg.Replica.Primary = server.URL
+	s.h = NewHarness(replicaCfg, chain)
+	defer s.h.Close()
+
+	more, err := s.h.pullCommitLog()
+	if err != nil || !more {
+		t.Fatalf("first pull: %v, %v", more, err)
+	}
+	if height := s.h.base.GetSyncHeight(); height != metas[replicaMaxPullCommits-1].Height {
+		t.Fatalf("first pull applied to %d, want %d", height, metas[replicaMaxPullCommits-1].Height)
+	}
+	for more {
+		if more, err = s.h.pullCommitLog(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.h.base.GetSyncHeight() != syncHeight {
+		t.Fatalf("replica at %d, primary at %d", s.h.base.GetSyncHeight(), syncHeight)
+	}
+	// 每个提交一个请求，每次拉取最后多一个没有新日志的请求
+	if requests > len(logs)+(len(logs)+replicaMaxPullCommits-1)/replicaMaxPullCommits+1 {
+		t.Fatalf("%d requests for %d commit logs", requests, len(logs))
+	}
+	// 副本只有主节点数据库中的数据
+	for height, outpoints := range s.owners {
+		if height > syncHeight {
+			for _, outpoint := range outpoints {
+				delete(s.alice, outpoint)
+			}
+		}
+	}
+	s.check()
+}
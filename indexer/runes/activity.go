@@ -0,0 +1,131 @@
+package runes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	"github.com/sat20-labs/indexer/indexer/runes/table"
+)
+
+type Activity struct {
+	*table.RuneActivity
+	Cursor string
+}
+
+func activityCursor(item *table.RuneActivity) string {
+	return fmt.Sprintf("%x_%x_%x", item.Height, item.TxIndex, item.Seq)
+}
+
+func parseActivityCursor(cursor string) (*table.RuneActivity, error) {
+	key := &table.RuneActivity{}
+	_, err := fmt.Sscanf(strings.ReplaceAll(cursor, "_", " "), "%x %x %x",
+		&key.Height, &key.TxIndex, &key.Seq)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %s", cursor)
+	}
+	return key, nil
+}
+
+// buildActivities history 已经按发生顺序排列，按高度和游标过滤，返回这一页、区间内总数和下一页的游标
+func buildActivities(history []*table.RuneActivity, filter *common.ActivityFilter) ([]*Activity, int, string, error) {
+	var cursor *table.RuneActivity
+	if filter.Cursor != "" {
+		key, err := parseActivityCursor(filter.Cursor)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		cursor = key
+	}
+
+	activities := make([]*Activity, 0)
+	for _, item := range history {
+		if filter.StartHeight > 0 && item.Height < uint64(filter.StartHeight) {
+			continue
+		}
+		if filter.EndHeight > 0 && item.Height > uint64(filter.EndHeight) {
+			continue
+		}
+		activities = append(activities, &Activity{RuneActivity: item, Cursor: activityCursor(item)})
+	}
+	total := len(activities)
+
+	if filter.Desc {
+		for i, j := 0, len(activities)-1; i < j; i, j = i+1, j-1 {
+			activities[i], activities[j] = activities[j], activities[i]
+		}
+	}
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(activities), func(i int) bool {
+			if filter.Desc {
+				return activities[i].Less(cursor)
+			}
+			return cursor.Less(activities[i].RuneActivity)
+		})
+	}
+	end := len(activities)
+	if filter.Limit > 0 && start+filter.Limit < end {
+		end = start + filter.Limit
+	}
+	result := activities[start:end]
+
+	next := ""
+	if end < len(activities) && len(result) > 0 {
+		next = result[len(result)-1].Cursor
+	}
+	return result, total, next, nil
+}
+
+// GetActivitiesWithHeight 只能在跑数据的线程中调用，返回当前区块中的活动记录
+func (s *Indexer) GetActivitiesWithHeight(height int) []*table.RuneActivity {
+	if s.height != height {
+		return nil
+	}
+	return s.blockActivities
+}
+
+// GetActivityWithRuneId 某个符文的活动历史，runeId 可以是名字或者id
+func (s *Indexer) GetActivityWithRuneId(runeId string, filter *common.ActivityFilter) ([]*Activity, int, string, error) {
+	runeInfo := s.GetRuneInfo(runeId)
+	if runeInfo == nil {
+		return nil, 0, "", fmt.Errorf("%s not found", runeId)
+	}
+	id, err := runestone.RuneIdFromString(runeInfo.Id)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	history, err := s.runeActivityTbl.GetListWithRuneId(id)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return buildActivities(history, filter)
+}
+
+// GetActivityWithAddress 某个地址的活动历史，runeId 为空时返回所有符文的活动
+func (s *Indexer) GetActivityWithAddress(addressId uint64, runeId string, filter *common.ActivityFilter) ([]*Activity, int, string, error) {
+	history, err := s.runeActivityTbl.GetListWithAddressId(addressId)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if runeId != "" {
+		runeInfo := s.GetRuneInfo(runeId)
+		if runeInfo == nil {
+			return nil, 0, "", fmt.Errorf("%s not found", runeId)
+		}
+		id, err := runestone.RuneIdFromString(runeInfo.Id)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		filtered := make([]*table.RuneActivity, 0, len(history))
+		for _, item := range history {
+			if item.RuneId.Cmp(*id) == 0 {
+				filtered = append(filtered, item)
+			}
+		}
+		history = filtered
+	}
+	return buildActivities(history, filter)
+}
@@ -0,0 +1,72 @@
+package runes
+
+import (
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	"github.com/sat20-labs/indexer/indexer/runes/table"
+	"lukechampine.com/uint128"
+)
+
+func TestRuneActivityKey(t *testing.T) {
+	item := &table.RuneActivity{
+		RuneId:    &runestone.RuneId{Block: 840000, Tx: 3},
+		Height:    840010,
+		TxIndex:   12,
+		Seq:       2,
+		Type:      table.RUNE_ACTIVITY_TRANSFER_IN,
+		Source:    table.RUNE_SOURCE_POINTER,
+		TxId:      "aa",
+		AddressId: 0x1234,
+		UtxoId:    0x5678,
+		Amount:    runestone.Lot{Value: uint128.From64(1000)},
+	}
+	for _, key := range []string{item.RuneIdKey(), item.AddressKey()} {
+		got, err := table.RuneActivityFromPb(key, item.ToPb())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Height != item.Height || got.TxIndex != item.TxIndex || got.Seq != item.Seq ||
+			got.RuneId.Cmp(*item.RuneId) != 0 || got.Type != item.Type || got.Source != item.Source ||
+			got.AddressId != item.AddressId || got.UtxoId != item.UtxoId || got.Amount.Value != item.Amount.Value {
+			t.Fatalf("%s: got %+v", key, got)
+		}
+	}
+}
+
+func TestBuildRuneActivities(t *testing.T) {
+	history := make([]*table.RuneActivity, 0)
+	for height := uint64(100); height < 103; height++ {
+		for seq := uint32(0); seq < 2; seq++ {
+			history = append(history, &table.RuneActivity{Height: height, TxIndex: 1, Seq: seq})
+		}
+	}
+
+	items, total, next, err := buildActivities(history, &common.ActivityFilter{StartHeight: 101, Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || len(items) != 3 || next != items[2].Cursor {
+		t.Fatalf("unexpected page: total=%d len=%d next=%s", total, len(items), next)
+	}
+	items, _, next, err = buildActivities(history, &common.ActivityFilter{StartHeight: 101, Limit: 3, Cursor: next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || next != "" || items[0].Height != 102 || items[0].Seq != 1 {
+		t.Fatalf("unexpected second page: len=%d next=%s", len(items), next)
+	}
+
+	items, _, _, err = buildActivities(history, &common.ActivityFilter{Desc: true, Limit: 2, Cursor: "66_1_0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Height != 101 || items[0].Seq != 1 || items[1].Seq != 0 {
+		t.Fatalf("unexpected desc page: %+v", items)
+	}
+
+	if _, _, _, err = buildActivities(history, &common.ActivityFilter{Cursor: "bad"}); err == nil {
+		t.Fatal("expected invalid cursor error")
+	}
+}
@@ -0,0 +1,91 @@
+package runes
+
+import (
+	"fmt"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	"github.com/sat20-labs/indexer/indexer/runes/table"
+	"lukechampine.com/uint128"
+)
+
+// 第一次启用时，以当前的持有者作为余额历史的起点
+func (s *Indexer) initBalanceHistory() {
+	s.balanceHistory.Init(s.dbWrite.Db, s.height, func(add func(ticker string, addressId uint64, amount *common.Decimal)) {
+		for _, entry := range s.idToEntryTbl.GetList() {
+			for _, v := range s.runeIdAddressToBalanceTbl.GetBalances(&entry.RuneId) {
+				v128 := uint128.Uint128{Hi: v.Balance.Value.Hi, Lo: v.Balance.Value.Lo}
+				add(entry.RuneId.String(), v.AddressId, common.NewDecimalFromUint128(v128, int(entry.Divisibility)))
+			}
+		}
+	})
+}
+
+// 区块处理完后调用，记录余额有变化的地址的最新余额
+func (s *Indexer) commitBalanceHistory(height int) {
+	entries := make(map[string]*runestone.RuneEntry)
+	s.balanceHistory.Commit(height, func(ticker string, addressId uint64) *common.Decimal {
+		runeId, err := runestone.RuneIdFromString(ticker)
+		if err != nil {
+			common.Log.Panicf("RuneIndexer.commitBalanceHistory-> invalid rune id %s", ticker)
+		}
+		entry, ok := entries[ticker]
+		if !ok {
+			entry = s.idToEntryTbl.Get(runeId)
+			if entry == nil {
+				common.Log.Panicf("RuneIndexer.commitBalanceHistory-> rune %s not found", ticker)
+			}
+			entries[ticker] = entry
+		}
+		value := s.runeIdAddressToBalanceTbl.Get(&table.RuneIdAddressToBalance{RuneId: runeId, AddressId: addressId})
+		if value == nil {
+			return nil
+		}
+		return common.NewDecimalFromUint128(value.Balance.Value, int(entry.Divisibility))
+	})
+}
+
+func (s *Indexer) flushBalanceHistory() {
+	wb := s.dbWrite.Db.NewWriteBatch()
+	defer wb.Close()
+	s.balanceHistory.UpdateDB(wb)
+	if err := wb.Flush(); err != nil {
+		common.Log.Panicf("RuneIndexer.flushBalanceHistory-> flush err: %v", err)
+	}
+}
+
+// key: addressId, value: amount。runeId 可以是名字或者id
+func (s *Indexer) GetHoldersWithTickAtHeight(runeId string, height int) (map[uint64]*common.Decimal, error) {
+	runeInfo := s.GetRuneInfo(runeId)
+	if runeInfo == nil {
+		return nil, fmt.Errorf("%s not found", runeId)
+	}
+	return s.balanceHistory.GetHolders(s.dbWrite.Db, runeInfo.Id, height)
+}
+
+// 地址在某个高度持有的符文 return: rune name -> amount
+func (s *Indexer) GetAddressAssetsAtHeight(addressId uint64, height int) (map[string]*common.Decimal, error) {
+	assets, err := s.balanceHistory.GetAssets(s.dbWrite.Db, addressId, height)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*common.Decimal, len(assets))
+	for id, amt := range assets {
+		runeInfo := s.GetRuneInfoWithId(id)
+		if runeInfo == nil {
+			common.Log.Errorf("RuneIndexer.GetAddressAssetsAtHeight-> rune %s not found", id)
+			continue
+		}
+		result[runeInfo.Name] = amt
+	}
+	return result, nil
+}
+
+// key: addressId, value: 第一次持有的高度。runeId 可以是名字或者id
+func (s *Indexer) GetHolderFirstSeenAtHeight(runeId string, height int) (map[uint64]int, error) {
+	runeInfo := s.GetRuneInfo(runeId)
+	if runeInfo == nil {
+		return nil, fmt.Errorf("%s not found", runeId)
+	}
+	return s.balanceHistory.GetFirstSeen(s.dbWrite.Db, runeInfo.Id, height)
+}
@@ -8,6 +8,7 @@ import (
 	cmap "github.com/orcaman/concurrent-map/v2"
 	"github.com/sat20-labs/indexer/common"
 	"github.com/sat20-labs/indexer/indexer/base"
+	inCommon "github.com/sat20-labs/indexer/indexer/common"
 	"github.com/sat20-labs/indexer/indexer/runes/pb"
 	"github.com/sat20-labs/indexer/indexer/runes/runestone"
 	"github.com/sat20-labs/indexer/indexer/runes/store"
@@ -33,11 +34,15 @@ type Indexer struct {
 	runeIdOutpointToBalanceTbl *table.RuneIdOutpointToBalanceTable // RuneId+utxoId -> 该utxo包含该符文的资产数量
 	runeIdToMintHistoryTbl     *table.RuneToMintHistoryTable       // runeId+addressId -> utxoId + amount
 	runeIdAddressToCountTbl    *table.RuneIdAddressToCountTable    // runeId+addressId -> utxo的数量
+	runeActivityTbl            *table.RuneActivityTable            // runeId/addressId+高度 -> 活动记录
 
 	//addressOutpointToBalancesTbl  *table.AddressOutpointToBalancesTable // addressId+utxoId -> runeId+balance  TODO 这个没用
 
+	balanceHistory *inCommon.BalanceHistory // 每个区块的余额变化，key 是 runeId.String()
+
 	// transferUpdate 临时使用
 	burnedMap                  table.RuneIdLotMap
+	blockActivities            []*table.RuneActivity // 当前区块的活动记录，用于推送事件
 	HolderUpdateCount          int
 	HolderRemoveCount          int
 
@@ -67,9 +72,16 @@ func NewIndexer(db common.KVDB, param *chaincfg.Params, bCheckValidateFile bool)
 		//addressOutpointToBalancesTbl:  table.NewAddressOutpointToBalancesTable(store.NewCache[pb.AddressOutpointToBalance](dbWrite)),
 		runeIdAddressToCountTbl: table.NewRuneIdAddressToCountTable(store.NewCache[pb.RuneIdAddressToCount](dbWrite)),
 		runeIdToMintHistoryTbl:  table.NewRuneIdToMintHistoryTable(store.NewCache[pb.RuneIdToMintHistory](dbWrite)),
+		runeActivityTbl:         table.NewRuneActivityTable(store.NewCache[pb.RuneActivity](dbWrite)),
+		balanceHistory:          inCommon.NewBalanceHistory(store.BALANCE_HISTORY),
 	}
 }
 
+// SetEnableHeight 修改开始处理区块的高度，在 Init 之前调用
+func (s *Indexer) SetEnableHeight(height int) {
+	s.enableHeight = height
+}
+
 func (s *Indexer) setDefaultRune() {
 	firstRuneValue, err := uint128.FromString("2055900680524219742")
 	if err != nil {
@@ -131,11 +143,14 @@ func (s *Indexer) Init(baseIndexer *base.BaseIndexer) {
 			}
 		}
 	}
+
+	s.initBalanceHistory()
 }
 
 func (s *Indexer) Clone(baseIndexer *base.BaseIndexer) *Indexer {
 	cloneIndex := NewIndexer(s.dbWrite.Db, s.chaincfgParam, _enable_checking_more_files)
 	cloneIndex.height = s.height
+	cloneIndex.enableHeight = s.enableHeight
 	cloneIndex.Status.Version = s.Status.Version
 	cloneIndex.Status.Height = s.Status.Height
 	cloneIndex.Status.Number = s.Status.Number
@@ -146,12 +161,14 @@ func (s *Indexer) Clone(baseIndexer *base.BaseIndexer) *Indexer {
 	}
 
 	s.dbWrite.Clone(cloneIndex.dbWrite)
+	cloneIndex.balanceHistory = s.balanceHistory.Clone()
 
 	return cloneIndex
 }
 
 func (s *Indexer) Subtract(backupIndexer *Indexer) {
 	backupIndexer.dbWrite.Subtract(s.dbWrite)
+	s.balanceHistory.Subtract(backupIndexer.balanceHistory)
 }
 
 func (s *Indexer) CheckSelf() bool {
@@ -1331,6 +1331,102 @@ func (x *RuneIdAddressToCount) GetCount() uint64 {
 	return 0
 }
 
+// 符文活动记录，高度、交易序号和序号在 key 中
+type RuneActivity struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type      uint32  `protobuf:"varint,1,opt,name=Type,proto3" json:"Type,omitempty"`
+	Source    uint32  `protobuf:"varint,2,opt,name=Source,proto3" json:"Source,omitempty"`
+	RuneId    *RuneId `protobuf:"bytes,3,opt,name=RuneId,proto3" json:"RuneId,omitempty"`
+	TxId      string  `protobuf:"bytes,4,opt,name=TxId,proto3" json:"TxId,omitempty"`
+	AddressId uint64  `protobuf:"varint,5,opt,name=AddressId,proto3" json:"AddressId,omitempty"`
+	UtxoId    uint64  `protobuf:"varint,6,opt,name=UtxoId,proto3" json:"UtxoId,omitempty"`
+	Amount    *Lot    `protobuf:"bytes,7,opt,name=Amount,proto3" json:"Amount,omitempty"`
+}
+
+func (x *RuneActivity) Reset() {
+	*x = RuneActivity{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_indexer_runes_pb_runes_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RuneActivity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RuneActivity) ProtoMessage() {}
+
+func (x *RuneActivity) ProtoReflect() protoreflect.Message {
+	mi := &file_indexer_runes_pb_runes_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RuneActivity.ProtoReflect.Descriptor instead.
+func (*RuneActivity) Descriptor() ([]byte, []int) {
+	return file_indexer_runes_pb_runes_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RuneActivity) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *RuneActivity) GetSource() uint32 {
+	if x != nil {
+		return x.Source
+	}
+	return 0
+}
+
+func (x *RuneActivity) GetRuneId() *RuneId {
+	if x != nil {
+		return x.RuneId
+	}
+	return nil
+}
+
+func (x *RuneActivity) GetTxId() string {
+	if x != nil {
+		return x.TxId
+	}
+	return ""
+}
+
+func (x *RuneActivity) GetAddressId() uint64 {
+	if x != nil {
+		return x.AddressId
+	}
+	return 0
+}
+
+func (x *RuneActivity) GetUtxoId() uint64 {
+	if x != nil {
+		return x.UtxoId
+	}
+	return 0
+}
+
+func (x *RuneActivity) GetAmount() *Lot {
+	if x != nil {
+		return x.Amount
+	}
+	return nil
+}
+
 var File_indexer_runes_pb_runes_proto protoreflect.FileDescriptor
 
 var file_indexer_runes_pb_runes_proto_rawDesc = []byte{
@@ -1472,8 +1568,21 @@ var file_indexer_runes_pb_runes_proto_rawDesc = []byte{
 	0x6e, 0x63, 0x65, 0x22, 0x2c, 0x0a, 0x14, 0x52, 0x75, 0x6e, 0x65, 0x49, 0x64, 0x41, 0x64, 0x64,
 	0x72, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x43,
 	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x43, 0x6f, 0x75, 0x6e,
-	0x74, 0x42, 0x0b, 0x5a, 0x09, 0x2f, 0x72, 0x75, 0x6e, 0x65, 0x73, 0x2f, 0x70, 0x62, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x74, 0x22, 0xd5, 0x01, 0x0a, 0x0c, 0x52, 0x75, 0x6e, 0x65, 0x41, 0x63, 0x74, 0x69, 0x76, 0x69,
+	0x74, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x28,
+	0x0a, 0x06, 0x52, 0x75, 0x6e, 0x65, 0x49, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x70, 0x62, 0x2e, 0x72, 0x75, 0x6e, 0x65, 0x73, 0x2e, 0x52, 0x75, 0x6e, 0x65, 0x49, 0x64,
+	0x52, 0x06, 0x52, 0x75, 0x6e, 0x65, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x54, 0x78, 0x49, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x78, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09,
+	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x49, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x09, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x55, 0x74,
+	0x78, 0x6f, 0x49, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x55, 0x74, 0x78, 0x6f,
+	0x49, 0x64, 0x12, 0x25, 0x0a, 0x06, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x70, 0x62, 0x2e, 0x72, 0x75, 0x6e, 0x65, 0x73, 0x2e, 0x4c, 0x6f,
+	0x74, 0x52, 0x06, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x0b, 0x5a, 0x09, 0x2f, 0x72, 0x75,
+	0x6e, 0x65, 0x73, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1488,7 +1597,7 @@ func file_indexer_runes_pb_runes_proto_rawDescGZIP() []byte {
 	return file_indexer_runes_pb_runes_proto_rawDescData
 }
 
-var file_indexer_runes_pb_runes_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_indexer_runes_pb_runes_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_indexer_runes_pb_runes_proto_goTypes = []any{
 	(*Uint128)(nil),                    // 0: pb.runes.Uint128
 	(*Uint8)(nil),                      // 1: pb.runes.Uint8
@@ -1513,6 +1622,7 @@ var file_indexer_runes_pb_runes_proto_goTypes = []any{
 	(*RuneIdAddressToBalance)(nil),     // 20: pb.runes.RuneIdAddressToBalance
 	(*AddressOutpointToBalance)(nil),   // 21: pb.runes.AddressOutpointToBalance
 	(*RuneIdAddressToCount)(nil),       // 22: pb.runes.RuneIdAddressToCount
+	(*RuneActivity)(nil),               // 23: pb.runes.RuneActivity
 }
 var file_indexer_runes_pb_runes_proto_depIdxs = []int32{
 	0,  // 0: pb.runes.Rune.value:type_name -> pb.runes.Uint128
@@ -1539,11 +1649,13 @@ var file_indexer_runes_pb_runes_proto_depIdxs = []int32{
 	10, // 21: pb.runes.RuneIdAddressToBalance.Balance:type_name -> pb.runes.Lot
 	2,  // 22: pb.runes.AddressOutpointToBalance.runeId:type_name -> pb.runes.RuneId
 	10, // 23: pb.runes.AddressOutpointToBalance.Balance:type_name -> pb.runes.Lot
-	24, // [24:24] is the sub-list for method output_type
-	24, // [24:24] is the sub-list for method input_type
-	24, // [24:24] is the sub-list for extension type_name
-	24, // [24:24] is the sub-list for extension extendee
-	0,  // [0:24] is the sub-list for field type_name
+	2,  // 24: pb.runes.RuneActivity.RuneId:type_name -> pb.runes.RuneId
+	10, // 25: pb.runes.RuneActivity.Amount:type_name -> pb.runes.Lot
+	26, // [26:26] is the sub-list for method output_type
+	26, // [26:26] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_indexer_runes_pb_runes_proto_init() }
@@ -1828,6 +1940,18 @@ func file_indexer_runes_pb_runes_proto_init() {
 				return nil
 			}
 		}
+		file_indexer_runes_pb_runes_proto_msgTypes[23].Exporter = func(v any, i int) any {
+			switch v := v.(*RuneActivity); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -1835,7 +1959,7 @@ func file_indexer_runes_pb_runes_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_indexer_runes_pb_runes_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   23,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
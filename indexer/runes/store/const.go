@@ -43,4 +43,15 @@ const (
 	// 表: address和outpoint映射balance
 	// 存储: key = roab-%addressid%-%outpoint%-%lot% value = address & runeid & lot
 	ADDRESS_OUTPOINT_TO_BALANCE = "i-"
+
+	// 每个区块的余额变化，见 indexer/common.BalanceHistory
+	BALANCE_HISTORY = "j-"
+
+	// 表: runeid映射活动记录
+	// 存储: key = k-%runeid%-%height%-%txindex%-%seq% value = activity
+	RUNEID_TO_ACTIVITY = "k-"
+
+	// 表: address映射活动记录，包含该地址所有符文的活动
+	// 存储: key = l-%addressid%-%height%-%txindex%-%seq% value = activity
+	ADDRESS_TO_ACTIVITY = "l-"
 )
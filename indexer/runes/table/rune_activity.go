@@ -0,0 +1,186 @@
+package table
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes/pb"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	"github.com/sat20-labs/indexer/indexer/runes/store"
+	"lukechampine.com/uint128"
+)
+
+type RuneActivityType uint32
+
+const (
+	RUNE_ACTIVITY_ETCH         RuneActivityType = 1 // 部署，数量是预挖数量
+	RUNE_ACTIVITY_MINT         RuneActivityType = 2
+	RUNE_ACTIVITY_TRANSFER_IN  RuneActivityType = 3 // 输出中收到的符文，包括铸造和预挖分配到的
+	RUNE_ACTIVITY_TRANSFER_OUT RuneActivityType = 4 // 输入中花费的符文
+	RUNE_ACTIVITY_BURN         RuneActivityType = 5 // 分配到 OP_RETURN 或者没有可用的输出
+	RUNE_ACTIVITY_CENOTAPH     RuneActivityType = 6 // cenotaph 导致输入、铸造和预挖全部销毁
+)
+
+// 转入和销毁的来源
+type RuneActivitySource uint32
+
+const (
+	RUNE_SOURCE_NONE           RuneActivitySource = 0
+	RUNE_SOURCE_EDICT          RuneActivitySource = 1
+	RUNE_SOURCE_POINTER        RuneActivitySource = 2
+	RUNE_SOURCE_DEFAULT_OUTPUT RuneActivitySource = 3 // 没有 pointer 时分配到第一个非 OP_RETURN 输出
+)
+
+func (t RuneActivityType) String() string {
+	switch t {
+	case RUNE_ACTIVITY_ETCH:
+		return "etch"
+	case RUNE_ACTIVITY_MINT:
+		return "mint"
+	case RUNE_ACTIVITY_TRANSFER_IN:
+		return "transfer-in"
+	case RUNE_ACTIVITY_TRANSFER_OUT:
+		return "transfer-out"
+	case RUNE_ACTIVITY_BURN:
+		return "burn"
+	case RUNE_ACTIVITY_CENOTAPH:
+		return "cenotaph"
+	}
+	return ""
+}
+
+func (s RuneActivitySource) String() string {
+	switch s {
+	case RUNE_SOURCE_EDICT:
+		return "edict"
+	case RUNE_SOURCE_POINTER:
+		return "pointer"
+	case RUNE_SOURCE_DEFAULT_OUTPUT:
+		return "default-output"
+	}
+	return ""
+}
+
+// AddressId 为 common.INVALID_ID 表示没有地址，比如部署和销毁，这时只按 runeid 索引
+type RuneActivity struct {
+	RuneId    *runestone.RuneId
+	Height    uint64
+	TxIndex   uint32
+	Seq       uint32 // 同一个交易中的序号
+	Type      RuneActivityType
+	Source    RuneActivitySource
+	TxId      string
+	AddressId uint64
+	UtxoId    uint64
+	Amount    runestone.Lot
+}
+
+func (s *RuneActivity) ToPb() *pb.RuneActivity {
+	return &pb.RuneActivity{
+		Type:      uint32(s.Type),
+		Source:    uint32(s.Source),
+		RuneId:    &pb.RuneId{Block: s.RuneId.Block, Tx: s.RuneId.Tx},
+		TxId:      s.TxId,
+		AddressId: s.AddressId,
+		UtxoId:    s.UtxoId,
+		Amount: &pb.Lot{
+			Value: &pb.Uint128{
+				Hi: s.Amount.Value.Hi,
+				Lo: s.Amount.Value.Lo,
+			},
+		},
+	}
+}
+
+// 固定长度，保证数据库中按发生顺序排列
+func (s *RuneActivity) posKey() string {
+	return fmt.Sprintf("%08x-%08x-%08x", s.Height, s.TxIndex, s.Seq)
+}
+
+func (s *RuneActivity) RuneIdKey() string {
+	return store.RUNEID_TO_ACTIVITY + s.RuneId.Hex() + "-" + s.posKey()
+}
+
+func (s *RuneActivity) AddressKey() string {
+	return store.ADDRESS_TO_ACTIVITY + fmt.Sprintf("%x", s.AddressId) + "-" + s.posKey()
+}
+
+func RuneActivityFromPb(key string, v *pb.RuneActivity) (*RuneActivity, error) {
+	parts := strings.Split(key, "-")
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("invalid rune activity key %s", key)
+	}
+	ret := &RuneActivity{
+		Type:      RuneActivityType(v.Type),
+		Source:    RuneActivitySource(v.Source),
+		TxId:      v.TxId,
+		AddressId: v.AddressId,
+		UtxoId:    v.UtxoId,
+	}
+	n := len(parts)
+	if _, err := fmt.Sscanf(parts[n-3]+" "+parts[n-2]+" "+parts[n-1], "%x %x %x",
+		&ret.Height, &ret.TxIndex, &ret.Seq); err != nil {
+		return nil, fmt.Errorf("invalid rune activity key %s", key)
+	}
+	if v.RuneId != nil {
+		ret.RuneId = &runestone.RuneId{Block: v.RuneId.Block, Tx: v.RuneId.Tx}
+	}
+	if v.Amount != nil && v.Amount.Value != nil {
+		ret.Amount = runestone.Lot{Value: uint128.Uint128{Hi: v.Amount.Value.Hi, Lo: v.Amount.Value.Lo}}
+	}
+	return ret, nil
+}
+
+type RuneActivityTable struct {
+	Table[pb.RuneActivity]
+}
+
+func NewRuneActivityTable(cache *store.Cache[pb.RuneActivity]) *RuneActivityTable {
+	return &RuneActivityTable{Table: Table[pb.RuneActivity]{Cache: cache}}
+}
+
+func (s *RuneActivityTable) Insert(v *RuneActivity) {
+	value := v.ToPb()
+	s.Cache.Set([]byte(v.RuneIdKey()), value)
+	if v.AddressId != common.INVALID_ID {
+		s.Cache.Set([]byte(v.AddressKey()), value)
+	}
+}
+
+func (s *RuneActivityTable) getList(prefix string) (ret []*RuneActivity, err error) {
+	pbVal := s.Cache.GetList([]byte(prefix), true)
+	ret = make([]*RuneActivity, 0, len(pbVal))
+	for k, v := range pbVal {
+		activity, err := RuneActivityFromPb(k, v)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, activity)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Less(ret[j])
+	})
+	return
+}
+
+// GetListWithRuneId 按发生顺序返回
+func (s *RuneActivityTable) GetListWithRuneId(runeId *runestone.RuneId) ([]*RuneActivity, error) {
+	return s.getList(store.RUNEID_TO_ACTIVITY + runeId.Hex() + "-")
+}
+
+// GetListWithAddressId 按发生顺序返回该地址所有符文的活动
+func (s *RuneActivityTable) GetListWithAddressId(addressId uint64) ([]*RuneActivity, error) {
+	return s.getList(store.ADDRESS_TO_ACTIVITY + fmt.Sprintf("%x", addressId) + "-")
+}
+
+func (s *RuneActivity) Less(other *RuneActivity) bool {
+	if s.Height != other.Height {
+		return s.Height < other.Height
+	}
+	if s.TxIndex != other.TxIndex {
+		return s.TxIndex < other.TxIndex
+	}
+	return s.Seq < other.Seq
+}
@@ -30,6 +30,7 @@ func (s *Indexer) UpdateDB() {
 
 	s.Status.Height = s.height
 	s.Status.Update()
+	s.flushBalanceHistory()
 	s.dbWrite.FlushToDB()
 
 	common.Log.Infof("RuneIndexer.UpdateDB-> db commit success, height:%d", s.Status.Height)
@@ -49,6 +50,7 @@ func (s *Indexer) UpdateTransfer(block *common.Block) {
 	s.HolderRemoveCount = 0
 
 	s.burnedMap = make(table.RuneIdLotMap)
+	s.blockActivities = nil
 	s.minimumRune = runestone.MinimumAtHeight(s.chaincfgParam.Net, uint64(block.Height))
 	s.blockTime = uint64(block.Timestamp.Unix())
 	common.Log.Tracef("RuneIndexer.UpdateTransfer->prepare block height:%d, minimumRune:%s(%s)",
@@ -66,6 +68,7 @@ func (s *Indexer) UpdateTransfer(block *common.Block) {
 	format := "RuneIndexer.UpdateTransfer-> handle block succ, tx count:%d, update holder count:%d, remove holder count:%d, block took time:%v"
 	common.Log.Infof(format, txCount, s.HolderUpdateCount, s.HolderRemoveCount, sinceTime)
 	s.update()
+	s.commitBalanceHistory(block.Height)
 
 	s.CheckPointWithBlockHeight(block.Height)
 
@@ -91,17 +94,47 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 	// 	common.Log.Infof("%v", artifact.Runestone.Etching)
 	// }
 
-	unallocated := s.unallocated(tx)
+	unallocated, spent := s.unallocated(tx)
 
 	type RuneIdLotMapVec map[uint32]table.RuneIdLotMap
 	allocated := make(RuneIdLotMapVec, len(tx.Outputs))
+	edictAllocated := make(RuneIdLotMapVec, len(tx.Outputs)) // allocated 中由 edict 分配的部分，用于活动记录
 	for outputIndex := range tx.Outputs {
 		allocated[uint32(outputIndex)] = make(table.RuneIdLotMap)
+		edictAllocated[uint32(outputIndex)] = make(table.RuneIdLotMap)
 	}
 
 	var mintAmount *runestone.Lot
 	var outIndex *uint32
 	var mintRuneId *runestone.RuneId
+	var etchId *runestone.RuneId
+	var etchPremine runestone.Lot
+
+	// 活动记录，seq 是交易内的序号
+	var activitySeq uint32
+	addActivity := func(activityType table.RuneActivityType, source table.RuneActivitySource,
+		id runestone.RuneId, addressId, utxoId uint64, amount *runestone.Lot) {
+		activity := &table.RuneActivity{
+			RuneId:    &id,
+			Height:    uint64(s.height),
+			TxIndex:   tx_index,
+			Seq:       activitySeq,
+			Type:      activityType,
+			Source:    source,
+			TxId:      tx.TxId,
+			AddressId: addressId,
+			UtxoId:    utxoId,
+			Amount:    *amount,
+		}
+		s.runeActivityTbl.Insert(activity)
+		s.blockActivities = append(s.blockActivities, activity)
+		activitySeq++
+	}
+	for _, input := range spent {
+		for _, v := range input.RuneIdLots {
+			addActivity(table.RUNE_ACTIVITY_TRANSFER_OUT, table.RUNE_SOURCE_NONE, v.RuneId, input.AddressId, input.UtxoId, &v.Lot)
+		}
+	}
 
 	if artifact != nil {
 		isParseOk = true
@@ -119,6 +152,7 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 		}
 
 		etchedId, etchedRune := s.etched(tx_index, tx, artifact)
+		etchId = etchedId
 		if artifact.Runestone != nil {
 			if etchedId != nil {
 				premine := &uint128.Uint128{}
@@ -127,6 +161,7 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 				}
 				premineAmount := runestone.NewLot(premine)
 				unallocated.GetOrDefault(etchedId).AddAssign(premineAmount) // 预分配
+				etchPremine = *premineAmount
 			}
 
 			zeroId := runestone.RuneId{Block: uint64(0), Tx: uint32(0)}
@@ -171,6 +206,7 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 					if amount.Value.Cmp(uint128.Zero) > 0 {
 						balance.SubAssign(*amount)
 						allocated[output].GetOrDefault(id).AddAssign(amount)
+						edictAllocated[output].GetOrDefault(id).AddAssign(amount)
 					}
 				}
 
@@ -237,10 +273,15 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 	}
 
 	burned := make(table.RuneIdLotMap)
+	cenotaphBurned := make(table.RuneIdLotMap) // 只用于活动记录
+	noOutputBurned := make(table.RuneIdLotMap)
+	defaultAllocated := make(table.RuneIdLotMap) // 分配到 outIndex 的剩余部分
+	defaultSource := table.RUNE_SOURCE_DEFAULT_OUTPUT
 
 	if artifact != nil && artifact.Cenotaph != nil {
 		for id, v := range unallocated {
 			burned.GetOrDefault(&id).AddAssign(v)
+			cenotaphBurned.GetOrDefault(&id).AddAssign(v)
 		}
 	} else {
 		var pointer *uint32
@@ -263,6 +304,7 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 		} else if (*pointer) < uint32(len(allocated)) {
 			outIndex = pointer
 			find = true
+			defaultSource = table.RUNE_SOURCE_POINTER
 		} else if (*pointer) >= uint32(len(allocated)) {
 			common.Log.Panicf("RuneIndexer.index_runes-> pointer out of range") // 无效的符文，前面应该已经设置为Cenotaph
 		}
@@ -270,17 +312,36 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 			for id, balance := range unallocated {
 				if balance.Value.Cmp(uint128.Zero) > 0 {
 					allocated[*outIndex].GetOrDefault(&id).AddAssign(balance) //
+					defaultAllocated.GetOrDefault(&id).AddAssign(balance)
 				}
 			}
 		} else {
 			for id, balance := range unallocated {
 				if balance.Value.Cmp(uint128.Zero) > 0 {
 					burned.GetOrDefault(&id).AddAssign(balance) // 没有有效的输出，直接烧了
+					noOutputBurned.GetOrDefault(&id).AddAssign(balance)
 				}
 			}
 		}
 	}
 
+	if etchId != nil {
+		addActivity(table.RUNE_ACTIVITY_ETCH, table.RUNE_SOURCE_NONE, *etchId, common.INVALID_ID, 0, &etchPremine)
+	}
+	if mintAmount != nil {
+		// 和铸造历史一样，以 outIndex 作为铸造者，cenotaph 或者没有有效输出时铸造的符文被烧掉
+		var mintAddressId uint64 = common.INVALID_ID
+		var mintUtxoId uint64
+		if artifact.Cenotaph == nil && outIndex != nil && tx.Outputs[*outIndex].OutValue.PkScript[0] != txscript.OP_RETURN {
+			address, err := parseTxVoutScriptAddress(tx, int(*outIndex), *s.chaincfgParam)
+			if err == nil {
+				mintAddressId = s.baseIndexer.GetAddressId(string(address))
+				mintUtxoId = tx.Outputs[*outIndex].UtxoId
+			}
+		}
+		addActivity(table.RUNE_ACTIVITY_MINT, table.RUNE_SOURCE_NONE, *mintRuneId, mintAddressId, mintUtxoId, mintAmount)
+	}
+
 	type RuneIdOutpointAddressToBalance struct {
 		RuneId    *runestone.RuneId
 		OutPoint  *table.OutPoint
@@ -291,6 +352,7 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 	}
 	type RuneBalanceArray []*RuneIdOutpointAddressToBalance
 	runeBalanceArray := make(RuneBalanceArray, 0)
+	outputAddressIds := make(map[uint32]uint64) // 活动记录使用
 
 	// update outpoint balances
 	for vout, balances := range allocated {
@@ -317,6 +379,7 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 				tx.TxId, vout, s.chaincfgParam.Net, err)
 		}
 		addressId := s.baseIndexer.GetAddressId(string(address))
+		outputAddressIds[vout] = addressId
 		outpointToBalancesValue := &table.OutpointToBalancesValue{
 			UtxoId:     outpoint.UtxoId,
 			AddressId:  addressId,
@@ -344,6 +407,33 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 		s.burnedMap.GetOrDefault(&id).AddAssign(amount)
 	}
 
+	// 按输出顺序记录转入和销毁
+	for vout := range tx.Outputs {
+		addressId, ok := outputAddressIds[uint32(vout)]
+		activityType := table.RUNE_ACTIVITY_TRANSFER_IN
+		var utxoId uint64
+		if ok {
+			utxoId = tx.Outputs[vout].UtxoId
+		} else {
+			activityType = table.RUNE_ACTIVITY_BURN
+			addressId = common.INVALID_ID
+		}
+		for _, v := range edictAllocated[uint32(vout)].GetSortArray() {
+			addActivity(activityType, table.RUNE_SOURCE_EDICT, v.RuneId, addressId, utxoId, &v.Lot)
+		}
+		if outIndex != nil && *outIndex == uint32(vout) {
+			for _, v := range defaultAllocated.GetSortArray() {
+				addActivity(activityType, defaultSource, v.RuneId, addressId, utxoId, &v.Lot)
+			}
+		}
+	}
+	for _, v := range noOutputBurned.GetSortArray() {
+		addActivity(table.RUNE_ACTIVITY_BURN, table.RUNE_SOURCE_NONE, v.RuneId, common.INVALID_ID, 0, &v.Lot)
+	}
+	for _, v := range cenotaphBurned.GetSortArray() {
+		addActivity(table.RUNE_ACTIVITY_CENOTAPH, table.RUNE_SOURCE_NONE, v.RuneId, common.INVALID_ID, 0, &v.Lot)
+	}
+
 	// if artifact != nil && artifact.Runestone == nil { 有默认的转移
 	// 	return
 	// }
@@ -417,6 +507,7 @@ func (s *Indexer) index_runes(tx_index uint32, tx *common.Transaction) (isParseO
 			}
 		}
 		s.runeIdAddressToBalanceTbl.Insert(value)
+		s.balanceHistory.Touch(runeBalance.RuneId.String(), runeBalance.AddressId)
 	}
 
 	// update runeIdToMintHistory
@@ -509,7 +600,8 @@ type RuneIdOutPointAddressId struct {
 	AddressId uint64
 }
 
-func (s *Indexer) unallocated(tx *common.Transaction) (ret1 table.RuneIdLotMap) {
+// ret2: 花费的输入中的符文，按输入的顺序
+func (s *Indexer) unallocated(tx *common.Transaction) (ret1 table.RuneIdLotMap, ret2 []*table.OutpointToBalancesValue) {
 	ret1 = make(table.RuneIdLotMap)
 	for _, input := range tx.Inputs {
 		outpoint := &table.OutPoint{
@@ -517,6 +609,7 @@ func (s *Indexer) unallocated(tx *common.Transaction) (ret1 table.RuneIdLotMap)
 		}
 		oldValue := s.outpointToBalancesTbl.Remove(outpoint)
 		if oldValue != nil {
+			ret2 = append(ret2, oldValue)
 			for _, val := range oldValue.RuneIdLots {
 				if val.Lot.Value.IsZero() {
 					common.Log.Panicf("unallocated input rune is zero. tx %s", tx.TxId)
@@ -580,6 +673,7 @@ func (s *Indexer) unallocated(tx *common.Transaction) (ret1 table.RuneIdLotMap)
 				} else {
 					s.runeIdAddressToBalanceTbl.Remove(oldruneIdAddressToBalanceValue)
 				}
+				s.balanceHistory.Touch(val.RuneId.String(), oldValue.AddressId)
 
 			}
 		}
@@ -1,12 +1,18 @@
 package indexer
 
 import (
+	"fmt"
+
 	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes"
 )
 
 
 func (b *IndexerMgr) GetRunesTickerMap() map[string]*common.TickerInfo {
 	result := make(map[string]*common.TickerInfo)
+	if b.RunesIndexer == nil {
+		return result
+	}
 	runeInfos := b.RunesIndexer.GetAllRuneInfos()
 	for _, runeInfo := range runeInfos {
 		assetName := common.TickerName{
@@ -52,10 +58,16 @@ func (b *IndexerMgr) GetRunesTickerMap() map[string]*common.TickerInfo {
 }
 
 func (b *IndexerMgr) GetRunesTickerMapV2(start, limit int) ([]string, int) {
+	if b.RunesIndexer == nil {
+		return nil, 0
+	}
 	return b.RunesIndexer.GetRuneIdsWithRange(start, limit)
 }
 
 func (p *IndexerMgr) GetRunesTickerV2(tickerName string) *common.TickerInfo {
+	if p.RunesIndexer == nil {
+		return nil
+	}
 	ticker := p.RunesIndexer.GetRuneInfo(tickerName)
 	if ticker == nil {
 		return nil
@@ -96,6 +108,9 @@ func (p *IndexerMgr) GetRunesTickerV2(tickerName string) *common.TickerInfo {
 }
 
 func (b *IndexerMgr) GetRunesMintAmount(tickerName string) (*common.Decimal, int64) {
+	if b.RunesIndexer == nil {
+		return nil, 0
+	}
 	info := b.RunesIndexer.GetRuneInfo(tickerName)
 	if info == nil {
 		return nil, 0
@@ -114,6 +129,9 @@ func (b *IndexerMgr) GetRunesDBVer() string {
 func (p *IndexerMgr) GetRunesMintHistoryWithAddress(addressId uint64,
 	ticker string, start int, limit int) ([]*common.MintInfo, int) {
 	result := make([]*common.MintInfo, 0)
+	if p.RunesIndexer == nil {
+		return result, 0
+	}
 	infos, total := p.RunesIndexer.GetAddressMintHistory(ticker, addressId, uint64(start), uint64(limit))
 	for _, info := range infos {
 		result = append(result, &common.MintInfo{
@@ -130,6 +148,9 @@ func (p *IndexerMgr) GetRunesMintHistoryWithAddress(addressId uint64,
 func (p *IndexerMgr) GetRunesMintHistory(
 	ticker string, start int, limit int) ([]*common.MintInfo, int) {
 	result := make([]*common.MintInfo, 0)
+	if p.RunesIndexer == nil {
+		return result, 0
+	}
 	infos, total := p.RunesIndexer.GetMintHistory(ticker, uint64(start), uint64(limit))
 	for _, info := range infos {
 		result = append(result, &common.MintInfo{
@@ -142,3 +163,65 @@ func (p *IndexerMgr) GetRunesMintHistory(
 	}
 	return result, int(total)
 }
+
+func (p *IndexerMgr) runesActivitiesToCommon(items []*runes.Activity) []*common.RunesActivity {
+	result := make([]*common.RunesActivity, 0, len(items))
+	runeInfos := make(map[string]*runes.RuneInfo)
+	for _, item := range items {
+		id := item.RuneId.String()
+		runeInfo, ok := runeInfos[id]
+		if !ok {
+			runeInfo = p.RunesIndexer.GetRuneInfoWithId(id)
+			runeInfos[id] = runeInfo
+		}
+		activity := &common.RunesActivity{
+			Cursor:  item.Cursor,
+			Type:    item.Type.String(),
+			Source:  item.Source.String(),
+			RuneId:  id,
+			Height:  int(item.Height),
+			TxIndex: int(item.TxIndex),
+			TxId:    item.TxId,
+			Amount:  item.Amount.String(),
+		}
+		if runeInfo != nil {
+			activity.Ticker = runeInfo.Name
+			activity.Amount = common.NewDecimalFromUint128(item.Amount.Value, int(runeInfo.Divisibility)).String()
+		}
+		if item.AddressId != common.INVALID_ID {
+			activity.Address = p.GetAddressById(item.AddressId)
+		}
+		if item.UtxoId != 0 {
+			activity.Utxo = p.GetUtxoById(item.UtxoId)
+		}
+		result = append(result, activity)
+	}
+	return result
+}
+
+// return: 活动列表，区间内总数，下一页的 cursor。ticker 为空时返回该地址所有符文的活动
+func (p *IndexerMgr) GetRunesActivityWithAddress(address, ticker string, filter *common.ActivityFilter) ([]*common.RunesActivity, int, string, error) {
+	if p.RunesIndexer == nil {
+		return nil, 0, "", fmt.Errorf("runes indexer is disabled")
+	}
+	addressId := p.GetAddressId(address)
+	if addressId == common.INVALID_ID {
+		return nil, 0, "", fmt.Errorf("can't find address %s", address)
+	}
+	items, total, next, err := p.RunesIndexer.GetActivityWithAddress(addressId, ticker, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return p.runesActivitiesToCommon(items), total, next, nil
+}
+
+func (p *IndexerMgr) GetRunesActivityWithTicker(ticker string, filter *common.ActivityFilter) ([]*common.RunesActivity, int, string, error) {
+	if p.RunesIndexer == nil {
+		return nil, 0, "", fmt.Errorf("runes indexer is disabled")
+	}
+	items, total, next, err := p.RunesIndexer.GetActivityWithRuneId(ticker, filter)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return p.runesActivitiesToCommon(items), total, next, nil
+}
@@ -0,0 +1,118 @@
+package satindex
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+const (
+	STATUS_KEY = "status"
+
+	DB_PREFIX_UTXO_RANGES = "u-" // utxoId -> ranges
+	DB_PREFIX_RANGE_START = "s-" // range start -> utxoId
+
+	DB_VERSION = "1.0.0"
+)
+
+type Status struct {
+	Version   string
+	Height    int   // 已经索引的区块高度
+	TotalSats int64 // 已经产生的聪，也就是下一个新聪的编号
+}
+
+func (p *Status) Clone() *Status {
+	a := *p
+	return &a
+}
+
+// 没有数据时返回 nil
+func initStatusFromDB(ldb common.KVDB) *Status {
+	stats := &Status{}
+	err := db.GetValueFromDB([]byte(STATUS_KEY), stats, ldb)
+	if err == common.ErrKeyNotFound {
+		common.Log.Info("initStatusFromDB no stats found in db")
+		return nil
+	} else if err != nil {
+		common.Log.Panicf("initStatusFromDB failed. %v", err)
+	}
+	common.Log.Infof("sat index stats: %v", stats)
+
+	if stats.Version != DB_VERSION {
+		common.Log.Panicf("sat index data version inconsistent %s", DB_VERSION)
+	}
+
+	return stats
+}
+
+func GetUtxoRangesKey(utxoId uint64) []byte {
+	return []byte(fmt.Sprintf("%s%d", DB_PREFIX_UTXO_RANGES, utxoId))
+}
+
+// 固定长度，保证按聪编号排序
+func GetRangeStartKey(start int64) []byte {
+	return []byte(fmt.Sprintf("%s%016x", DB_PREFIX_RANGE_START, start))
+}
+
+func encodeUtxoId(utxoId uint64) []byte {
+	return binary.AppendUvarint(nil, utxoId)
+}
+
+func encodeRanges(ranges []*common.Range) []byte {
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64*len(ranges))
+	for _, rng := range ranges {
+		buf = binary.AppendUvarint(buf, uint64(rng.Start))
+		buf = binary.AppendUvarint(buf, uint64(rng.Size))
+	}
+	return buf
+}
+
+func decodeRanges(buf []byte) ([]*common.Range, error) {
+	result := make([]*common.Range, 0)
+	for len(buf) > 0 {
+		start, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid range start")
+		}
+		buf = buf[n:]
+		size, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid range size")
+		}
+		buf = buf[n:]
+		result = append(result, &common.Range{Start: int64(start), Size: int64(size)})
+	}
+	return result, nil
+}
+
+func (p *SatIndexer) loadUtxoRangesFromDB(utxoId uint64) ([]*common.Range, error) {
+	value, err := p.db.Read(GetUtxoRangesKey(utxoId))
+	if err != nil {
+		return nil, err
+	}
+	return decodeRanges(value)
+}
+
+// 找到起点不大于 sat 的最后一个区间所在的 utxo
+func (p *SatIndexer) findRangeStartFromDB(sat int64) (uint64, bool, error) {
+	var utxoId uint64
+	found := false
+	err := p.db.BatchReadV2([]byte(DB_PREFIX_RANGE_START), GetRangeStartKey(sat+1), true, func(k, v []byte) error {
+		var start int64
+		_, err := fmt.Sscanf(string(k[len(DB_PREFIX_RANGE_START):]), "%x", &start)
+		if err == nil && start <= sat {
+			id, n := binary.Uvarint(v)
+			if n > 0 {
+				utxoId = id
+				found = true
+			}
+		}
+		return errStopBatchRead
+	})
+	if err != nil && err != errStopBatchRead {
+		return 0, false, err
+	}
+	return utxoId, found, nil
+}
@@ -0,0 +1,211 @@
+package satindex
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/base"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+var errStopBatchRead = errors.New("stop batch read")
+
+// SatIndexer 按 sat20 的编号规则，记录每个 utxo 中的聪区间，可以反查某一聪所在的 utxo
+type SatIndexer struct {
+	db          common.KVDB
+	status      *Status
+	baseIndexer *base.BaseIndexer
+
+	mutex sync.RWMutex // 只保护这几个结构
+
+	utxoAdded   map[uint64][]*common.Range // 还没写入数据库的 utxo
+	utxoDeleted map[uint64][]*common.Range // 已经花费的 utxo，需要从数据库删除
+}
+
+func NewSatIndexer(db common.KVDB) *SatIndexer {
+	return &SatIndexer{
+		db: db,
+	}
+}
+
+// Init 聪区间只能从创世区块开始建立，数据库高度跟基础索引不一致时返回错误
+func (p *SatIndexer) Init(baseIndexer *base.BaseIndexer) error {
+	p.baseIndexer = baseIndexer
+	p.status = initStatusFromDB(p.db)
+	if p.status == nil {
+		p.status = &Status{Version: DB_VERSION, Height: -1}
+	}
+	if p.status.Height != baseIndexer.GetSyncHeight() {
+		return fmt.Errorf("sat index at height %d but base index at %d, sat index must be built from genesis",
+			p.status.Height, baseIndexer.GetSyncHeight())
+	}
+
+	p.mutex.Lock()
+	p.utxoAdded = make(map[uint64][]*common.Range)
+	p.utxoDeleted = make(map[uint64][]*common.Range)
+	p.mutex.Unlock()
+	return nil
+}
+
+// 只保存UpdateDB需要用的数据
+func (p *SatIndexer) Clone(baseIndexer *base.BaseIndexer) *SatIndexer {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	newInst := NewSatIndexer(p.db)
+	newInst.status = p.status.Clone()
+	newInst.baseIndexer = baseIndexer
+	// 区间一旦生成就不会再修改，不需要深拷贝
+	newInst.utxoAdded = make(map[uint64][]*common.Range, len(p.utxoAdded))
+	for k, v := range p.utxoAdded {
+		newInst.utxoAdded[k] = v
+	}
+	newInst.utxoDeleted = make(map[uint64][]*common.Range, len(p.utxoDeleted))
+	for k, v := range p.utxoDeleted {
+		newInst.utxoDeleted[k] = v
+	}
+	return newInst
+}
+
+// update之后，删除原来instance中的数据
+func (p *SatIndexer) Subtract(another *SatIndexer) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for k := range another.utxoAdded {
+		delete(p.utxoAdded, k)
+	}
+	for k := range another.utxoDeleted {
+		delete(p.utxoDeleted, k)
+	}
+}
+
+func (p *SatIndexer) getUtxoRanges(utxoId uint64) ([]*common.Range, error) {
+	ranges, ok := p.utxoAdded[utxoId]
+	if ok {
+		return ranges, nil
+	}
+	return p.loadUtxoRangesFromDB(utxoId)
+}
+
+// UpdateTransfer 把输入的聪区间按顺序分配到输出，剩下的作为网络费进入 coinbase
+func (p *SatIndexer) UpdateTransfer(block *common.Block, coinbase []*common.Range) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	startTime := time.Now()
+	fees := make([]*common.Range, 0)
+	for _, tx := range block.Transactions[1:] {
+		inputs := make([]*common.Range, 0)
+		for _, input := range tx.Inputs {
+			ranges, err := p.getUtxoRanges(input.UtxoId)
+			if err != nil {
+				common.Log.Panicf("SatIndexer.UpdateTransfer can't find ranges of %s, %v", input.OutPointStr, err)
+			}
+			delete(p.utxoAdded, input.UtxoId)
+			p.utxoDeleted[input.UtxoId] = ranges
+			inputs = appendRanges(inputs, ranges)
+		}
+		remaining := p.assignOutputs(tx, inputs)
+		fees = appendRanges(fees, remaining)
+	}
+
+	// sat20: 新产生的聪在前面，后面跟着每一笔交易的网络费
+	newSats := int64(0)
+	if len(coinbase) > 0 {
+		newSats = coinbase[0].Size
+	}
+	inputs := make([]*common.Range, 0)
+	if newSats > 0 {
+		inputs = append(inputs, &common.Range{Start: p.status.TotalSats, Size: newSats})
+	}
+	inputs = appendRanges(inputs, fees)
+	p.assignOutputs(block.Transactions[0], inputs)
+
+	p.status.Height = block.Height
+	p.status.TotalSats += newSats
+	common.Log.Debugf("SatIndexer.UpdateTransfer %d, cost: %v", block.Height, time.Since(startTime))
+}
+
+func (p *SatIndexer) assignOutputs(tx *common.Transaction, inputs []*common.Range) []*common.Range {
+	remaining := inputs
+	for _, output := range tx.Outputs {
+		value := output.OutValue.Value
+		if value == 0 {
+			continue
+		}
+		if common.GetOrdinalsSize(remaining) < value {
+			common.Log.Panicf("SatIndexer.assignOutputs %s has not enough sats", output.OutPointStr)
+		}
+		var transferred []*common.Range
+		transferred, remaining = common.TransferRanges(remaining, value)
+		p.utxoAdded[output.UtxoId] = transferred
+	}
+	return remaining
+}
+
+// 相邻的区间合并
+func appendRanges(ranges []*common.Range, more []*common.Range) []*common.Range {
+	for _, rng := range more {
+		if rng.Size == 0 {
+			continue
+		}
+		if len(ranges) > 0 {
+			last := ranges[len(ranges)-1]
+			if last.Start+last.Size == rng.Start {
+				ranges[len(ranges)-1] = &common.Range{Start: last.Start, Size: last.Size + rng.Size}
+				continue
+			}
+		}
+		ranges = append(ranges, rng)
+	}
+	return ranges
+}
+
+func (p *SatIndexer) UpdateDB() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	startTime := time.Now()
+	wb := p.db.NewWriteBatch()
+	defer wb.Close()
+
+	// 先删除，花费的区间起点可能跟新的 utxo 一样
+	for utxoId, ranges := range p.utxoDeleted {
+		if err := wb.Delete(GetUtxoRangesKey(utxoId)); err != nil {
+			common.Log.Panicf("Error deleting db %v", err)
+		}
+		for _, rng := range ranges {
+			if err := wb.Delete(GetRangeStartKey(rng.Start)); err != nil {
+				common.Log.Panicf("Error deleting db %v", err)
+			}
+		}
+	}
+	for utxoId, ranges := range p.utxoAdded {
+		if err := db.SetRawDB(GetUtxoRangesKey(utxoId), encodeRanges(ranges), wb); err != nil {
+			common.Log.Panicf("Error setting db %v", err)
+		}
+		value := encodeUtxoId(utxoId)
+		for _, rng := range ranges {
+			if err := db.SetRawDB(GetRangeStartKey(rng.Start), value, wb); err != nil {
+				common.Log.Panicf("Error setting db %v", err)
+			}
+		}
+	}
+	if err := db.SetDB([]byte(STATUS_KEY), p.status, wb); err != nil {
+		common.Log.Panicf("Error setting db %v", err)
+	}
+	if err := wb.Flush(); err != nil {
+		common.Log.Panicf("SatIndexer.UpdateDB-> Error satwb flushing writes to db %v", err)
+	}
+
+	common.Log.Infof("SatIndexer.UpdateDB: added %d, deleted %d, takes: %v",
+		len(p.utxoAdded), len(p.utxoDeleted), time.Since(startTime))
+
+	// reset memory buffer
+	p.utxoAdded = make(map[uint64][]*common.Range)
+	p.utxoDeleted = make(map[uint64][]*common.Range)
+}
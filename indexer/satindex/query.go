@@ -0,0 +1,49 @@
+package satindex
+
+import (
+	"fmt"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// GetRangesWithUtxoId utxo 中的聪区间，按输出中的顺序排列
+func (p *SatIndexer) GetRangesWithUtxoId(utxoId uint64) ([]*common.Range, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if _, ok := p.utxoDeleted[utxoId]; ok {
+		return nil, fmt.Errorf("utxo %d is spent", utxoId)
+	}
+	ranges, err := p.getUtxoRanges(utxoId)
+	if err != nil {
+		return nil, err
+	}
+	return common.CloneRanges(ranges), nil
+}
+
+// FindSat 当前持有这一聪的 utxo，以及这一聪在 utxo 中的偏移
+func (p *SatIndexer) FindSat(sat int64) (uint64, int64, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	// 还没写入数据库的 utxo
+	for utxoId, ranges := range p.utxoAdded {
+		if common.IsSatInRanges(sat, ranges) {
+			return utxoId, common.GetSatOffset(ranges, sat), nil
+		}
+	}
+
+	utxoId, found, err := p.findRangeStartFromDB(sat)
+	if err != nil {
+		return common.INVALID_ID, 0, err
+	}
+	if found {
+		if _, ok := p.utxoDeleted[utxoId]; !ok {
+			ranges, err := p.loadUtxoRangesFromDB(utxoId)
+			if err == nil && common.IsSatInRanges(sat, ranges) {
+				return utxoId, common.GetSatOffset(ranges, sat), nil
+			}
+		}
+	}
+	return common.INVALID_ID, 0, fmt.Errorf("sat %d not found in any utxo", sat)
+}
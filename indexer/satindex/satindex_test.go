@@ -0,0 +1,127 @@
+package satindex
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/base"
+	"github.com/sat20-labs/indexer/indexer/db"
+	"github.com/sat20-labs/indexer/indexer/exotic"
+)
+
+func newTestOutput(height, tx, vout int, value int64) *common.TxOutputV2 {
+	return &common.TxOutputV2{
+		TxOutput: common.TxOutput{
+			UtxoId:   common.ToUtxoId(height, tx, vout),
+			OutValue: wire.TxOut{Value: value},
+		},
+		TxOutIndex: vout,
+	}
+}
+
+func newTestInput(output *common.TxOutputV2) *common.TxInput {
+	return &common.TxInput{TxOutputV2: *output}
+}
+
+func checkSat(t *testing.T, p *SatIndexer, sat int64, utxoId uint64, offset int64) {
+	id, off, err := p.FindSat(sat)
+	if err != nil {
+		t.Fatalf("sat %d: %v", sat, err)
+	}
+	if id != utxoId || off != offset {
+		t.Fatalf("sat %d: got %x:%d, expected %x:%d", sat, id, off, utxoId, offset)
+	}
+}
+
+func TestSatIndexTransfer(t *testing.T) {
+	kv := db.NewKVDB(t.TempDir())
+	baseIndexer := base.NewBaseIndexer(kv, &chaincfg.MainNetParams, 0, 100)
+	baseIndexer.Init()
+	p := NewSatIndexer(kv)
+	if err := p.Init(baseIndexer); err != nil {
+		t.Fatal(err)
+	}
+
+	// 区块0: coinbase 产生 [0, 100)
+	genesis := newTestOutput(0, 0, 0, 100)
+	p.UpdateTransfer(&common.Block{Height: 0, Transactions: []*common.Transaction{
+		{Outputs: []*common.TxOutputV2{genesis}},
+	}}, []*common.Range{{Start: 0, Size: 100}})
+	p.UpdateDB()
+
+	// 区块1: 花费 genesis，网络费 10 聪；coinbase 产生 [100, 200)
+	out0 := newTestOutput(1, 1, 0, 30)
+	opreturn := newTestOutput(1, 1, 1, 0)
+	out2 := newTestOutput(1, 1, 2, 60)
+	cb0 := newTestOutput(1, 0, 0, 60)
+	cb1 := newTestOutput(1, 0, 1, 50)
+	p.UpdateTransfer(&common.Block{Height: 1, Transactions: []*common.Transaction{
+		{Outputs: []*common.TxOutputV2{cb0, cb1}},
+		{Inputs: []*common.TxInput{newTestInput(genesis)}, Outputs: []*common.TxOutputV2{out0, opreturn, out2}},
+	}}, []*common.Range{{Start: 0, Size: 100}, {Start: 0, Size: 10}})
+
+	check := func() {
+		checkSat(t, p, 5, out0.UtxoId, 5)
+		checkSat(t, p, 30, out2.UtxoId, 0)
+		checkSat(t, p, 130, cb0.UtxoId, 30)
+		checkSat(t, p, 95, cb1.UtxoId, 45)
+		if _, _, err := p.FindSat(200); err == nil {
+			t.Fatal("sat 200 is not mined")
+		}
+		if _, err := p.GetRangesWithUtxoId(genesis.UtxoId); err == nil {
+			t.Fatal("genesis is spent")
+		}
+		ranges, err := p.GetRangesWithUtxoId(cb1.UtxoId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ranges) != 2 || ranges[0].Start != 160 || ranges[0].Size != 40 ||
+			ranges[1].Start != 90 || ranges[1].Size != 10 {
+			t.Fatalf("unexpected ranges %v", ranges)
+		}
+	}
+	check()
+
+	// 跟 IndexerMgr 一样，通过备份实例写入数据库
+	backup := p.Clone(baseIndexer)
+	p.Subtract(backup)
+	backup.UpdateDB()
+	check()
+
+	// 基础索引的高度跟聪区间索引不一致
+	if err := NewSatIndexer(kv).Init(baseIndexer); err == nil {
+		t.Fatal("expected height mismatch error")
+	}
+}
+
+func TestSatributes(t *testing.T) {
+	reward := &common.Range{Start: 100, Size: 100}
+	cases := []struct {
+		sat      int64
+		height   int
+		expected []string
+	}{
+		{100, 1, []string{exotic.Uncommon, exotic.Vintage}},
+		{199, 1, []string{exotic.Black, exotic.Vintage}},
+		{150, 9, []string{exotic.Block9, exotic.Vintage, exotic.Nakamoto}},
+		{100, exotic.HalvingInterval, []string{exotic.Epic}},
+		{100, exotic.DificultyAdjustmentInterval * 1000, []string{exotic.Rare}},
+		{99, 1, []string{}},
+	}
+	for _, c := range cases {
+		got := Satributes(c.sat, c.height, reward)
+		if len(got) != len(c.expected) {
+			t.Fatalf("sat %d height %d: got %v", c.sat, c.height, got)
+		}
+		for i := range got {
+			if got[i] != c.expected[i] {
+				t.Fatalf("sat %d height %d: got %v", c.sat, c.height, got)
+			}
+		}
+	}
+	if got := Satributes(0, 0, &common.Range{Start: 0, Size: 100}); len(got) == 0 || got[0] != exotic.Mythic {
+		t.Fatalf("unexpected genesis satributes %v", got)
+	}
+}
@@ -0,0 +1,51 @@
+package satindex
+
+import (
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/exotic"
+)
+
+// Satributes 按 sat20 的编号计算稀有属性，reward 是这一聪所在区块新产生的聪
+func Satributes(sat int64, height int, reward *common.Range) []string {
+	result := make([]string, 0)
+	if reward == nil || sat < reward.Start || sat >= reward.Start+reward.Size {
+		return result
+	}
+
+	if sat == reward.Start {
+		if height == 0 {
+			result = append(result, exotic.Mythic)
+		} else if height%exotic.CycleInterval == 0 {
+			result = append(result, exotic.Legendary)
+		} else if height%exotic.HalvingInterval == 0 {
+			result = append(result, exotic.Epic)
+		} else if height%exotic.DificultyAdjustmentInterval == 0 {
+			result = append(result, exotic.Rare)
+		} else {
+			result = append(result, exotic.Uncommon)
+		}
+	}
+	if sat == reward.Start+reward.Size-1 {
+		result = append(result, exotic.Black)
+	}
+
+	if exotic.IsSatInRange(exotic.PizzaRanges, exotic.Sat(sat)) {
+		result = append(result, exotic.Pizza)
+	}
+	if exotic.IsSatInRange(exotic.FirstTransactionRanges, exotic.Sat(sat)) {
+		result = append(result, exotic.FirstTransaction)
+	}
+	if height == 9 {
+		result = append(result, exotic.Block9)
+	}
+	if height == 78 {
+		result = append(result, exotic.Block78)
+	}
+	if height <= 1000 {
+		result = append(result, exotic.Vintage)
+	}
+	if exotic.IsInBlocks(exotic.NakamotoBlocks, height) {
+		result = append(result, exotic.Nakamoto)
+	}
+	return result
+}
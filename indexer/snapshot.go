@@ -0,0 +1,312 @@
+package indexer
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+const (
+	SnapshotManifestFile = "manifest.json"
+	snapshotFileExt      = ".gob.zst"
+)
+
+// SnapshotManifest 描述一个快照目录：所有数据库在同一个区块高度的完整数据
+type SnapshotManifest struct {
+	Chain         string            `json:"chain"`
+	Height        int               `json:"height"`
+	BlockHash     string            `json:"blockHash"`
+	BaseDBVersion string            `json:"baseDBVersion"`
+	CodeVersion   string            `json:"codeVersion"`
+	CreatedAt     int64             `json:"createdAt"`
+	DBs           []*SnapshotDBInfo `json:"dbs"`
+}
+
+type SnapshotDBInfo struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Entries int64  `json:"entries"`
+	Size    int64  `json:"size"`
+	Sha256  string `json:"sha256"`
+}
+
+type snapshotDB struct {
+	name string
+	db   common.KVDB
+}
+
+// 名字和 initDB 中的目录名一致：链上数据库(见 chainDBNames)加上本地数据库
+var snapshotDBNames = append(append([]string{}, chainDBNames...), localDBNames...)
+
+// 协议数据库在协议关闭时不会打开，快照中可以没有
+var snapshotRequiredDBNames = append([]string{"base"}, localDBNames...)
+
+func isSnapshotDB(name string) bool {
+	for _, n := range snapshotDBNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isSnapshotRequiredDB(name string) bool {
+	for _, n := range snapshotRequiredDBNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// 按 snapshotDBNames 的顺序返回所有数据库，没有打开的 db 为 nil
+func (b *IndexerMgr) snapshotDBs() []*snapshotDB {
+	all := b.chainDBsByName()
+	for name, kvdb := range b.localDBsByName() {
+		all[name] = kvdb
+	}
+	result := make([]*snapshotDB, 0, len(snapshotDBNames))
+	for _, name := range snapshotDBNames {
+		result = append(result, &snapshotDB{name, all[name]})
+	}
+	return result
+}
+
+// ExportSnapshot 在写屏障内把所有数据库导出到 dir，数据库中的数据都对应同一个已经写入的区块
+func (b *IndexerMgr) ExportSnapshot(dir string) (manifest *SnapshotManifest, err error) {
+	if err := ensureEmptyDir(dir); err != nil {
+		return nil, err
+	}
+
+	b.withIndexerStateWriteBarrier("snapshot export", func() {
+		stats := b.base.GetSyncStats()
+		manifest = &SnapshotManifest{
+			Chain:         b.cfg.Chain,
+			Height:        stats.SyncHeight,
+			BlockHash:     stats.SyncBlockHash,
+			BaseDBVersion: b.GetBaseDBVer(),
+			CodeVersion:   common.ORDX_INDEXER_VERSION,
+			CreatedAt:     time.Now().Unix(),
+		}
+		if manifest.BaseDBVersion != common.BASE_DB_VERSION {
+			err = fmt.Errorf("DB version %s, but code base %s", manifest.BaseDBVersion, common.BASE_DB_VERSION)
+			return
+		}
+
+		for _, item := range b.snapshotDBs() {
+			if item.db == nil {
+				if isSnapshotRequiredDB(item.name) {
+					err = fmt.Errorf("db %s is not opened", item.name)
+					return
+				}
+				continue
+			}
+			start := time.Now()
+			var info *SnapshotDBInfo
+			info, err = exportSnapshotDB(dir, item.name, item.db)
+			if err != nil {
+				err = fmt.Errorf("export %s failed, %v", item.name, err)
+				return
+			}
+			common.Log.Infof("snapshot export %s: %d entries, %d bytes, %v", item.name, info.Entries, info.Size, time.Since(start))
+			manifest.DBs = append(manifest.DBs, info)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// manifest 最后写入，没有 manifest 的目录是不完整的快照
+	err = os.WriteFile(filepath.Join(dir, SnapshotManifestFile), data, 0644)
+	if err != nil {
+		return nil, err
+	}
+	common.Log.Infof("snapshot exported to %s, height %d %s", dir, manifest.Height, manifest.BlockHash)
+	return manifest, nil
+}
+
+func exportSnapshotDB(dir, name string, db common.KVDB) (*SnapshotDBInfo, error) {
+	info := &SnapshotDBInfo{Name: name, File: name + snapshotFileExt}
+	f, err := os.Create(filepath.Join(dir, info.File))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(f, hasher)}
+	zw, err := zstd.NewWriter(counter)
+	if err != nil {
+		return nil, err
+	}
+	enc := gob.NewEncoder(zw)
+	err = db.BatchRead(nil, false, func(k, v []byte) error {
+		info.Entries++
+		return enc.Encode([2][]byte{k, v})
+	})
+	if err != nil {
+		zw.Close()
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if err := f.Sync(); err != nil {
+		return nil, err
+	}
+	info.Size = counter.n
+	info.Sha256 = hex.EncodeToString(hasher.Sum(nil))
+	return info, nil
+}
+
+// LoadSnapshotManifest 读取并检查快照中的所有文件
+func LoadSnapshotManifest(dir string) (*SnapshotManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SnapshotManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &SnapshotManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]bool)
+	for _, info := range manifest.DBs {
+		if !isSnapshotDB(info.Name) {
+			return nil, fmt.Errorf("unknown db %s in snapshot", info.Name)
+		}
+		files[info.Name] = true
+		sum, err := fileSha256(filepath.Join(dir, info.File))
+		if err != nil {
+			return nil, err
+		}
+		if sum != info.Sha256 {
+			return nil, fmt.Errorf("%s checksum mismatch, %s != %s", info.File, sum, info.Sha256)
+		}
+	}
+	for _, name := range snapshotRequiredDBNames {
+		if !files[name] {
+			return nil, fmt.Errorf("db %s missing in snapshot", name)
+		}
+	}
+	return manifest, nil
+}
+
+// ImportSnapshot 用快照初始化一个新节点的数据库目录，数据库目录必须不存在或者为空。
+// 需要在打开数据库之前调用
+func ImportSnapshot(snapshotDir, dbDir, chain string) (*SnapshotManifest, error) {
+	manifest, err := LoadSnapshotManifest(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Chain != chain {
+		return nil, fmt.Errorf("snapshot chain %s, but config %s", manifest.Chain, chain)
+	}
+	if manifest.BaseDBVersion != common.BASE_DB_VERSION {
+		return nil, fmt.Errorf("snapshot DB version %s, but code base %s", manifest.BaseDBVersion, common.BASE_DB_VERSION)
+	}
+	for _, info := range manifest.DBs {
+		if err := db.EnsureEmptyDB(filepath.Join(dbDir, info.Name)); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, info := range manifest.DBs {
+		start := time.Now()
+		err := importSnapshotDB(filepath.Join(snapshotDir, info.File), filepath.Join(dbDir, info.Name))
+		if err != nil {
+			return nil, fmt.Errorf("import %s failed, %v", info.Name, err)
+		}
+		common.Log.Infof("snapshot import %s: %d entries, %v", info.Name, info.Entries, time.Since(start))
+	}
+	common.Log.Infof("snapshot imported to %s, height %d %s", dbDir, manifest.Height, manifest.BlockHash)
+	return manifest, nil
+}
+
+func importSnapshotDB(file, path string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	db, err := openDB(path, defaultBuildDBCacheMB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	wb := db.NewWriteBatch()
+	defer wb.Close()
+	dec := gob.NewDecoder(zr)
+	for {
+		var kv [2][]byte
+		if err := dec.Decode(&kv); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if err := wb.Put(kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+func ensureEmptyDir(dir string) error {
+	items, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		return err
+	}
+	if len(items) != 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+	return nil
+}
+
+func fileSha256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (p *countingWriter) Write(data []byte) (int, error) {
+	n, err := p.w.Write(data)
+	p.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,126 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+func writeTestSnapshot(t *testing.T, dir string) *SnapshotManifest {
+	manifest := &SnapshotManifest{
+		Chain:         common.ChainTestnet4,
+		Height:        100,
+		BlockHash:     "hash",
+		BaseDBVersion: common.BASE_DB_VERSION,
+	}
+	for _, name := range snapshotDBNames {
+		kv := db.NewKVDBWithCache(filepath.Join(t.TempDir(), name), 1)
+		for i := 0; i < 3; i++ {
+			if err := kv.Write([]byte(fmt.Sprintf("%s-%d", name, i)), []byte(name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		info, err := exportSnapshotDB(dir, name, kv)
+		kv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Entries != 3 {
+			t.Fatalf("%s entries %d", name, info.Entries)
+		}
+		manifest.DBs = append(manifest.DBs, info)
+	}
+	data, _ := json.Marshal(manifest)
+	if err := os.WriteFile(filepath.Join(dir, SnapshotManifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
+
+func TestSnapshotImportRestoresAllDBs(t *testing.T) {
+	snapshotDir := t.TempDir()
+	writeTestSnapshot(t, snapshotDir)
+
+	dbDir := t.TempDir()
+	manifest, err := ImportSnapshot(snapshotDir, dbDir, common.ChainTestnet4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Height != 100 {
+		t.Fatalf("height %d", manifest.Height)
+	}
+	for _, name := range snapshotDBNames {
+		kv := db.NewKVDBWithCache(filepath.Join(dbDir, name), 1)
+		value, err := kv.Read([]byte(name + "-2"))
+		kv.Close()
+		if err != nil || string(value) != name {
+			t.Fatalf("%s: %s %v", name, value, err)
+		}
+	}
+
+	// 已经有数据的目录不能覆盖
+	if _, err := ImportSnapshot(snapshotDir, dbDir, common.ChainTestnet4); err == nil {
+		t.Fatal("import into a non-empty db dir should fail")
+	}
+}
+
+func TestSnapshotImportRejectsBadSnapshot(t *testing.T) {
+	snapshotDir := t.TempDir()
+	manifest := writeTestSnapshot(t, snapshotDir)
+
+	if _, err := ImportSnapshot(snapshotDir, t.TempDir(), common.ChainMainnet); err == nil ||
+		!strings.Contains(err.Error(), "chain") {
+		t.Fatalf("chain mismatch should fail, %v", err)
+	}
+
+	file := filepath.Join(snapshotDir, manifest.DBs[0].File)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSnapshotManifest(snapshotDir); err == nil ||
+		!strings.Contains(err.Error(), "checksum") {
+		t.Fatalf("corrupted file should fail, %v", err)
+	}
+}
+
+func TestSnapshotWithoutDisabledProtocolDBs(t *testing.T) {
+	snapshotDir := t.TempDir()
+	manifest := writeTestSnapshot(t, snapshotDir)
+
+	// 只保留必须的数据库，其他协议被关闭
+	dbs := manifest.DBs[:0]
+	for _, info := range manifest.DBs {
+		if isSnapshotRequiredDB(info.Name) {
+			dbs = append(dbs, info)
+		}
+	}
+	manifest.DBs = dbs
+	data, _ := json.Marshal(manifest)
+	if err := os.WriteFile(filepath.Join(snapshotDir, SnapshotManifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSnapshotManifest(snapshotDir); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest.DBs = manifest.DBs[1:]
+	data, _ = json.Marshal(manifest)
+	if err := os.WriteFile(filepath.Join(snapshotDir, SnapshotManifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSnapshotManifest(snapshotDir); err == nil ||
+		!strings.Contains(err.Error(), "missing") {
+		t.Fatalf("snapshot without base db should fail, %v", err)
+	}
+}
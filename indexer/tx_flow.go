@@ -0,0 +1,461 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/db"
+	"github.com/sat20-labs/indexer/share/bitcoin_rpc"
+)
+
+// GetTxAssetsFlow 链上或者内存池中的交易。
+// 输入在索引器中还能找到时（内存池中的交易，或者所在区块还没有被索引），按输入模拟分配；
+// 已经被索引的交易，用处理区块时记录的输入和输出（见 recordTxFlows），
+// 没有记录时（追块时处理的区块），只能给出输出中还没有被花费的资产。
+func (b *IndexerMgr) GetTxAssetsFlow(txid string) (*common.TxAssetsFlow, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+
+	rawTx, err := bitcoin_rpc.ShareBitconRpc.GetTx(txid)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := bitcoin_rpc.DecodeMsgTx(rawTx.Hex)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawTx.Confirmations == 0 || !b.isTxIndexed(tx) {
+		flow, err := b.simulateTxAssetsFlow(tx)
+		if err != nil {
+			return nil, err
+		}
+		flow.Confirmed = rawTx.Confirmations > 0
+		flow.BlockHash = rawTx.BlockHash
+		return flow, nil
+	}
+	return b.confirmedTxAssetsFlow(tx, rawTx.BlockHash), nil
+}
+
+func (b *IndexerMgr) confirmedTxAssetsFlow(tx *wire.MsgTx, blockHash string) *common.TxAssetsFlow {
+	txid := tx.TxID()
+	flow := &common.TxAssetsFlow{
+		TxId:      txid,
+		Confirmed: true,
+		BlockHash: blockHash,
+	}
+	if record := b.loadTxFlowRecord(txid, blockHash); record != nil {
+		flow.Complete = true
+		flow.Inputs = record.Inputs
+		flow.Outputs = record.Outputs
+		flow.Fee = record.Fee
+		return flow
+	}
+
+	flow.Inputs = make([]*common.TxFlowIO, 0, len(tx.TxIn))
+	flow.Outputs = make([]*common.TxFlowIO, 0, len(tx.TxOut))
+	flow.Warnings = []string{"inputs of an indexed transaction are spent, only unspent outputs are resolved"}
+	for i, txIn := range tx.TxIn {
+		flow.Inputs = append(flow.Inputs, &common.TxFlowIO{
+			Index:    i,
+			OutPoint: txIn.PreviousOutPoint.String(),
+		})
+	}
+	for i, txOut := range tx.TxOut {
+		io := newTxFlowOutput(txid, i, txOut)
+		if info := b.getTxOutputWithUtxoV2(io.OutPoint, false); info != nil {
+			io.Resolved = true
+			io.Assets = info.ToAssetsInUtxo().Assets
+		}
+		flow.Outputs = append(flow.Outputs, io)
+	}
+	return flow
+}
+
+// 处理区块时记录的交易输入和输出中的资产，输入被花费以后还能查询。
+// 只记录带资产的交易，BlockHash 跟节点返回的不一致时（分叉）不使用
+type txFlowRecord struct {
+	BlockHash string
+	Fee       int64
+	Inputs    []*common.TxFlowIO
+	Outputs   []*common.TxFlowIO
+}
+
+func (b *IndexerMgr) loadTxFlowRecord(txid, blockHash string) *txFlowRecord {
+	if b.localDB == nil {
+		return nil
+	}
+	var record txFlowRecord
+	if err := db.GobGetDB(getTxFlowKey(txid), &record, b.localDB); err != nil {
+		if !errors.Is(err, common.ErrKeyNotFound) {
+			common.Log.Errorf("loading tx flow of %s failed, %v", txid, err)
+		}
+		return nil
+	}
+	if record.BlockHash != blockHash {
+		return nil
+	}
+	return &record
+}
+
+// 追块时不记录，每个输入都要查一遍各协议的索引
+func (s *IndexerMgr) shouldRecordTxFlows(height int) bool {
+	return s.localDB != nil && s.base.GetChainTip()-height <= s.undoJournalKeepBlocks()
+}
+
+// 在各协议处理区块之前调用，这时输入中的资产还没有被转移
+func (s *IndexerMgr) collectTxFlowInputs(block *common.Block) []*txFlowRecord {
+	records := make([]*txFlowRecord, len(block.Transactions))
+	for txIndex, tx := range block.Transactions {
+		if txIndex == 0 {
+			continue
+		}
+		record := &txFlowRecord{
+			BlockHash: block.Hash,
+			Inputs:    make([]*common.TxFlowIO, 0, len(tx.Inputs)),
+		}
+		for i, input := range tx.Inputs {
+			output := common.NewTxOutput(input.OutValue.Value)
+			s.fillTxOutputAssets(output, input.UtxoId, false)
+			record.Inputs = append(record.Inputs, &common.TxFlowIO{
+				Index:    i,
+				OutPoint: input.OutPointStr,
+				Address:  input.GetAddress(),
+				Value:    input.OutValue.Value,
+				Resolved: true,
+				Assets:   output.ToAssetsInUtxo().Assets,
+			})
+			record.Fee += input.OutValue.Value
+		}
+		records[txIndex] = record
+	}
+	return records
+}
+
+// 在各协议处理区块之后调用。同一个区块中又被花费的输出，资产已经转走，记录中没有资产
+func (s *IndexerMgr) recordTxFlows(block *common.Block, records []*txFlowRecord) {
+	wb := s.localDB.NewWriteBatch()
+	defer wb.Close()
+
+	count := 0
+	for txIndex, tx := range block.Transactions {
+		record := records[txIndex]
+		if record == nil {
+			continue
+		}
+		hasAsset := false
+		for _, input := range record.Inputs {
+			hasAsset = hasAsset || len(input.Assets) != 0
+		}
+		record.Outputs = make([]*common.TxFlowIO, 0, len(tx.Outputs))
+		for i, out := range tx.Outputs {
+			output := common.NewTxOutput(out.OutValue.Value)
+			s.fillTxOutputAssets(output, out.UtxoId, false)
+			io := &common.TxFlowIO{
+				Index:    i,
+				OutPoint: fmt.Sprintf("%s:%d", tx.TxId, i),
+				Address:  out.GetAddress(),
+				Value:    out.OutValue.Value,
+				Resolved: true,
+				Assets:   output.ToAssetsInUtxo().Assets,
+			}
+			hasAsset = hasAsset || len(io.Assets) != 0
+			record.Outputs = append(record.Outputs, io)
+			record.Fee -= out.OutValue.Value
+		}
+		if !hasAsset {
+			continue
+		}
+		if err := db.SetDB(getTxFlowKey(tx.TxId), record, wb); err != nil {
+			common.Log.Errorf("saving tx flow of %s failed, %v", tx.TxId, err)
+			return
+		}
+		count++
+	}
+	if count == 0 {
+		return
+	}
+	if err := wb.Flush(); err != nil {
+		common.Log.Errorf("saving tx flows of block %d failed, %v", block.Height, err)
+	}
+}
+
+// 交易的输入都已经被花费，说明交易已经被索引
+func (b *IndexerMgr) isTxIndexed(tx *wire.MsgTx) bool {
+	if isCoinbaseTx(tx) {
+		return true
+	}
+	for _, txIn := range tx.TxIn {
+		if _, err := b.rpcService.GetUtxoInfo(txIn.PreviousOutPoint.String()); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func isCoinbaseTx(tx *wire.MsgTx) bool {
+	return len(tx.TxIn) == 1 && tx.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex
+}
+
+// SimulateTxAssetsFlow 还没有广播的交易（可以没有签名），预览资产的分配，避免误烧资产
+func (b *IndexerMgr) SimulateTxAssetsFlow(tx *wire.MsgTx) (*common.TxAssetsFlow, error) {
+	b.rpcEnter()
+	defer b.rpcLeft()
+	return b.simulateTxAssetsFlow(tx)
+}
+
+func newTxFlowOutput(txid string, index int, txOut *wire.TxOut) *common.TxFlowIO {
+	address, _ := common.PkScriptToAddr(txOut.PkScript, instance.GetChainParam())
+	return &common.TxFlowIO{
+		Index:    index,
+		OutPoint: fmt.Sprintf("%s:%d", txid, index),
+		Address:  address,
+		Value:    txOut.Value,
+	}
+}
+
+// 和内存池使用相同的输入解析和分配规则
+func (b *IndexerMgr) simulateTxAssetsFlow(tx *wire.MsgTx) (*common.TxAssetsFlow, error) {
+	if isCoinbaseTx(tx) {
+		return nil, fmt.Errorf("coinbase transaction is not supported")
+	}
+	txid := tx.TxID()
+	pool := b.miniMempool
+	flow := &common.TxAssetsFlow{
+		TxId:     txid,
+		Complete: true,
+		Inputs:   make([]*common.TxFlowIO, 0, len(tx.TxIn)),
+		Outputs:  make([]*common.TxFlowIO, 0, len(tx.TxOut)),
+	}
+
+	inputs, status := pool.resolveMempoolInputs(tx)
+	var totalInput int64
+	for _, resolved := range inputs {
+		outpoint := tx.TxIn[resolved.index].PreviousOutPoint.String()
+		io := &common.TxFlowIO{
+			Index:    resolved.index,
+			OutPoint: outpoint,
+			SpentBy:  pool.spenderOf(outpoint, txid),
+		}
+		if resolved.output != nil && (resolved.confirmed || pool.isKnownOutput(outpoint)) {
+			io.Resolved = true
+			io.Value = resolved.output.Value()
+			io.Address, _ = pool.addressForOutput(resolved.output)
+			io.Assets = resolved.output.ToAssetsInUtxo().Assets
+			totalInput += io.Value
+		}
+		if io.SpentBy != "" {
+			flow.Warnings = append(flow.Warnings,
+				fmt.Sprintf("input %d is already spent by mempool tx %s", io.Index, io.SpentBy))
+		}
+		flow.Inputs = append(flow.Inputs, io)
+	}
+
+	var totalOutput int64
+	for i, txOut := range tx.TxOut {
+		flow.Outputs = append(flow.Outputs, newTxFlowOutput(txid, i, txOut))
+		totalOutput += txOut.Value
+	}
+
+	if status != mempoolResolveComplete {
+		flow.Complete = false
+		flow.Warnings = append(flow.Warnings, "some inputs are unknown or carry unresolved unconfirmed assets, allocation is not available")
+		return flow, nil
+	}
+	flow.Fee = totalInput - totalOutput
+	if flow.Fee < 0 {
+		return nil, fmt.Errorf("outputs %d exceed inputs %d", totalOutput, totalInput)
+	}
+	for _, io := range flow.Outputs {
+		io.Resolved = true
+	}
+
+	b.flowBoundAssets(tx, inputs, flow)
+	b.flowRunes(tx, inputs, flow)
+	b.flowAtom(tx, inputs, flow)
+	b.flowInscriptions(tx, inputs, flow)
+	return flow, nil
+}
+
+// ordx/brc20/exotic/nft/ns，资产绑定在聪上，按偏移分配
+func (b *IndexerMgr) flowBoundAssets(tx *wire.MsgTx, inputs []*mempoolResolvedInput, flow *common.TxAssetsFlow) {
+	outputs, fee, ok := cutBoundMempoolOutputs(tx, inputs)
+	if !ok {
+		flow.Complete = false
+		flow.Warnings = append(flow.Warnings, "assets bound to sats can't be allocated")
+		return
+	}
+
+	for i, output := range outputs {
+		if !output.HasAsset() {
+			continue
+		}
+		unspendable := mempoolOutputUnspendable(tx.TxOut[i])
+		for _, asset := range output.Assets {
+			switch {
+			case unspendable:
+				flow.Burns = append(flow.Burns, newTxFlowAsset(&asset, i, common.TX_FLOW_REASON_OP_RETURN))
+			case asset.Name.Protocol == common.PROTOCOL_NAME_BRC20:
+				flow.Invalids = append(flow.Invalids, newTxFlowAsset(&asset, i, common.TX_FLOW_REASON_TRANSFERRED))
+			}
+		}
+		flow.Outputs[i].Assets = append(flow.Outputs[i].Assets, output.ToAssetsInUtxo().Assets...)
+	}
+
+	if fee == nil {
+		return
+	}
+	for _, asset := range fee.Assets {
+		if asset.Name.Protocol == common.PROTOCOL_NAME_BRC20 {
+			flow.Invalids = append(flow.Invalids, newTxFlowAsset(&asset, -1, common.TX_FLOW_REASON_RETURNED))
+		} else {
+			flow.Burns = append(flow.Burns, newTxFlowAsset(&asset, -1, common.TX_FLOW_REASON_FEE))
+		}
+	}
+}
+
+func newTxFlowAsset(asset *common.AssetInfo, output int, reason string) *common.TxFlowAsset {
+	return &common.TxFlowAsset{
+		AssetName: asset.Name,
+		Amount:    asset.Amount.String(),
+		Output:    output,
+		Reason:    reason,
+	}
+}
+
+func (b *IndexerMgr) flowRunes(tx *wire.MsgTx, inputs []*mempoolResolvedInput, flow *common.TxAssetsFlow) {
+	runesFlow, ok := simulateMempoolRunes(tx, inputs)
+	if !ok {
+		flow.Complete = false
+		flow.Warnings = append(flow.Warnings, "runestone can't be simulated")
+		return
+	}
+	if runesFlow.issuance {
+		flow.Warnings = append(flow.Warnings, "runes mint or etching in this tx is not simulated")
+	}
+
+	for i, balances := range runesFlow.outputs {
+		for id, amount := range balances {
+			asset := runesFlow.assets[id]
+			flow.Outputs[i].Assets = append(flow.Outputs[i].Assets, &common.DisplayAsset{
+				AssetName: common.AssetName{
+					Protocol: common.PROTOCOL_NAME_RUNES,
+					Type:     common.ASSET_TYPE_FT,
+					Ticker:   asset.Rune,
+				},
+				Amount:    common.NewDecimalFromUint128(amount, int(asset.Divisibility)).String(),
+				Precision: int(asset.Divisibility),
+			})
+		}
+	}
+	for _, burn := range runesFlow.burns {
+		asset := runesFlow.assets[burn.id]
+		flow.Burns = append(flow.Burns, &common.TxFlowAsset{
+			AssetName: common.AssetName{
+				Protocol: common.PROTOCOL_NAME_RUNES,
+				Type:     common.ASSET_TYPE_FT,
+				Ticker:   asset.Rune,
+			},
+			Amount: common.NewDecimalFromUint128(burn.amount, int(asset.Divisibility)).String(),
+			Output: burn.output,
+			Reason: burn.reason,
+		})
+	}
+}
+
+func (b *IndexerMgr) flowAtom(tx *wire.MsgTx, inputs []*mempoolResolvedInput, flow *common.TxAssetsFlow) {
+	atomFlow, ok := assignMempoolAtom(tx, inputs)
+	if !ok {
+		flow.Complete = false
+		flow.Warnings = append(flow.Warnings, "atomicals transfer can't be simulated")
+		return
+	}
+	if atomFlow.mintOutput {
+		flow.Warnings = append(flow.Warnings, "atomicals mint in this tx is not simulated")
+	}
+
+	for _, id := range atomFlow.ids {
+		name := common.AssetName{
+			Protocol: common.PROTOCOL_NAME_ATOM,
+			Type:     common.ASSET_TYPE_FT,
+			Ticker:   atomFlow.tickers[id],
+		}
+		var opReturn int64
+		for _, assignment := range atomFlow.assignments[id] {
+			if assignment.amount <= 0 || assignment.output < 0 || assignment.output >= len(tx.TxOut) {
+				continue
+			}
+			amount := strconv.FormatInt(assignment.amount, 10)
+			if mempoolOutputUnspendable(tx.TxOut[assignment.output]) {
+				opReturn += assignment.amount
+				flow.Burns = append(flow.Burns, &common.TxFlowAsset{
+					AssetName: name,
+					Amount:    amount,
+					Output:    assignment.output,
+					Reason:    common.TX_FLOW_REASON_OP_RETURN,
+				})
+				continue
+			}
+			flow.Outputs[assignment.output].Assets = append(flow.Outputs[assignment.output].Assets, &common.DisplayAsset{
+				AssetName:  name,
+				Amount:     amount,
+				BindingSat: 1,
+			})
+		}
+		if unallocated := atomFlow.burned(tx, id) - opReturn; unallocated > 0 {
+			flow.Burns = append(flow.Burns, &common.TxFlowAsset{
+				AssetName: name,
+				Amount:    strconv.FormatInt(unallocated, 10),
+				Output:    -1,
+				Reason:    common.TX_FLOW_REASON_UNALLOCATED,
+			})
+		}
+	}
+}
+
+// 交易中新铸造的铭文落在哪个输出
+func (b *IndexerMgr) flowInscriptions(tx *wire.MsgTx, inputs []*mempoolResolvedInput, flow *common.TxAssetsFlow) {
+	for _, placement := range placeMempoolInscriptions(tx, inputs) {
+		inscription := &common.TxFlowInscription{
+			InscriptionId: placement.id,
+			Input:         placement.input,
+			Output:        -1,
+			Ticker:        placement.ticker,
+			Amount:        placement.amt,
+		}
+		var base int64
+		for i, txOut := range tx.TxOut {
+			if placement.start >= base && placement.start < base+txOut.Value {
+				inscription.Output = i
+				inscription.Offset = placement.start - base
+				break
+			}
+			base += txOut.Value
+		}
+		flow.Inscriptions = append(flow.Inscriptions, inscription)
+	}
+}
+
+func (p *MiniMemPool) isKnownOutput(outpoint string) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	switch p.utxoStateMap[outpoint] {
+	case mempoolUtxoPlain, mempoolUtxoAsset:
+		return p.knownOutputLocked(outpoint) != nil
+	}
+	return false
+}
+
+// 内存池中花费了该输出的其他交易
+func (p *MiniMemPool) spenderOf(outpoint, txid string) string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	spender := p.spentByOutpoint[outpoint]
+	if spender == txid {
+		return ""
+	}
+	return spender
+}
@@ -0,0 +1,57 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+func txFlowTestAssets(io *common.TxFlowIO) map[string]bool {
+	result := make(map[string]bool)
+	for _, asset := range io.Assets {
+		result[asset.AssetName.String()] = true
+	}
+	return result
+}
+
+// 每个区块花费上一个区块的 coinbase，输入中有 uncommon 和 black 两个稀有聪
+func TestConfirmedTxAssetsFlow(t *testing.T) {
+	s := newHarnessTestScript(t)
+	for i := 0; i < 3; i++ {
+		s.mine("alice")
+		s.sync()
+	}
+	chain := s.h.Chain
+	height := chain.Height()
+	block := chain.Block(height)
+	tx := block.Transactions[1]
+
+	// 之后的区块花费了交易的输入和输出
+	for i := 0; i < 2; i++ {
+		s.mine("alice")
+		s.sync()
+	}
+	flow := s.h.confirmedTxAssetsFlow(tx, block.BlockHash().String())
+	if !flow.Complete || len(flow.Warnings) != 0 {
+		t.Fatalf("flow of %s is not recorded, %v", tx.TxID(), flow.Warnings)
+	}
+	if flow.Fee != 1000 || len(flow.Inputs) != 1 || len(flow.Outputs) != 2 {
+		t.Fatalf("unexpected flow %+v", flow)
+	}
+	input := txFlowTestAssets(flow.Inputs[0])
+	if !input["ordx:e:uncommon"] || !input["ordx:e:black"] {
+		t.Fatalf("unexpected input assets %v", input)
+	}
+	if output := txFlowTestAssets(flow.Outputs[0]); !output["ordx:e:uncommon"] || len(output) != 1 {
+		t.Fatalf("unexpected output 0 assets %v", output)
+	}
+	if output := txFlowTestAssets(flow.Outputs[1]); !output["ordx:e:black"] || len(output) != 1 {
+		t.Fatalf("unexpected output 1 assets %v", output)
+	}
+
+	// 分叉后交易在另外一个区块中，不使用旧的记录
+	flow = s.h.confirmedTxAssetsFlow(tx, chain.Block(height-1).BlockHash().String())
+	if flow.Complete || len(flow.Warnings) == 0 {
+		t.Fatalf("record of another block is used, %+v", flow)
+	}
+}
@@ -0,0 +1,343 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sat20-labs/indexer/common"
+	base_indexer "github.com/sat20-labs/indexer/indexer/base"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+// 撤销日志保留的区块数量是 GetBlockHistory 的倍数。
+// 数据库中的数据最多到 (h - GetBlockHistory)，这之前的分叉，都依靠撤销日志回滚。
+const undoJournalHistoryFactor = 6
+
+// name 跟数据库目录名一致，副本按这个名称重放写入
+func (b *IndexerMgr) journalDB(name string, kvdb common.KVDB) common.KVDB {
+	result := db.NewJournalDB(kvdb, b.undoJournal)
+	if b.changeLog != nil {
+		result.SetChangeLog(name, b.changeLog)
+	}
+	return result
+}
+
+func (b *IndexerMgr) undoJournalKeepBlocks() int {
+	return undoJournalHistoryFactor * b.base.GetBlockHistory()
+}
+
+// 每次写入链上数据库都是一次提交，所有数据库都记录撤销日志，中途退出时可以回滚，见 db/commit.go
+func (b *IndexerMgr) beginCommit(compiling *base_indexer.BaseIndexer) {
+	b.commitMutex.Lock()
+	stats := compiling.GetSyncStats()
+	meta := &db.UndoMeta{
+		Height:     compiling.GetHeight(),
+		Hash:       compiling.GetHash(),
+		PrevHeight: stats.SyncHeight,
+		PrevHash:   stats.SyncBlockHash,
+		// 追块时不会有这么深的分叉，不记录旧值，每个写入的 key 少一次读。
+		// 中断后用提交前的检查点恢复，做不了检查点时还是记录旧值
+		NoUndo: b.base.GetChainTip()-compiling.GetHeight() > b.undoJournalKeepBlocks(),
+	}
+	if meta.NoUndo {
+		if err := b.checkpointChainDBs(meta); err != nil {
+			common.Log.Warnf("checkpoint before commit %d failed, record the undo journal instead, %v", meta.Height, err)
+			b.removeCommitCheckpoint()
+			meta.NoUndo = false
+		}
+	}
+	if err := db.SetPendingCommit(b.baseDB, meta); err != nil {
+		common.Log.Panicf("SetPendingCommit %d failed, %v", meta.Height, err)
+	}
+	b.pendingCommit = meta
+	b.undoJournal.Begin(meta)
+	// 追块时也要记录，否则主节点停机后再追上来，副本就接不上了。只保留最近的 KeepCommits 个
+	if b.changeLog != nil {
+		b.changeLog.Begin()
+	}
+}
+
+func (b *IndexerMgr) endCommit() {
+	defer b.commitMutex.Unlock()
+	meta := b.pendingCommit
+	// 跟数据在同一个撤销日志中，回滚时一起恢复
+	for _, kvdb := range b.chainDBs() {
+		if err := db.SetCommitHeight(kvdb, meta.Height); err != nil {
+			common.Log.Panicf("SetCommitHeight %d failed, %v", meta.Height, err)
+		}
+	}
+	b.undoJournal.End()
+	if b.changeLog != nil {
+		b.saveCommitLog(meta)
+	}
+	if err := db.ClearPendingCommit(b.baseDB); err != nil {
+		common.Log.Panicf("ClearPendingCommit %d failed, %v", meta.Height, err)
+	}
+	b.pendingCommit = nil
+	if meta.NoUndo {
+		b.removeCommitCheckpoint()
+	}
+
+	// 追块时不会有这么深的分叉，提交完成后撤销日志就没用了
+	syncHeight := b.base.GetSyncHeight()
+	pruneHeight := syncHeight - b.undoJournalKeepBlocks()
+	if b.base.GetChainTip()-syncHeight > b.undoJournalKeepBlocks() {
+		pruneHeight = syncHeight + 1
+	}
+	if pruneHeight <= 0 {
+		return
+	}
+	for _, kvdb := range b.chainDBs() {
+		if err := db.PruneUndoJournal(kvdb, pruneHeight); err != nil {
+			common.Log.Errorf("PruneUndoJournal %d failed, %v", pruneHeight, err)
+		}
+	}
+}
+
+// recoverCommit 启动时回滚上次没有完成的提交，并检查各数据库的提交高度是否一致
+func (b *IndexerMgr) recoverCommit() {
+	pending, err := db.GetPendingCommit(b.baseDB)
+	if err != nil {
+		common.Log.Panicf("GetPendingCommit failed, %v", err)
+	}
+	if pending != nil && pending.NoUndo {
+		checkpoint := b.readCommitCheckpoint()
+		if checkpoint == nil || checkpoint.Height != pending.Height {
+			common.Log.Panicf("commit %d without undo journal was interrupted and has no checkpoint, restore the db from a snapshot",
+				pending.Height)
+		}
+		common.Log.Warnf("last commit %d was interrupted, all db restored to %d from the checkpoint", pending.Height, pending.PrevHeight)
+		b.closeDB()
+		b.restoreCommitCheckpoint(checkpoint)
+		if err := b.initDB(); err != nil {
+			common.Log.Panicf("initDB failed. %v", err)
+		}
+		if err := db.DeleteCommitLogsAbove(b.baseDB, pending.PrevHeight); err != nil {
+			common.Log.Errorf("DeleteCommitLogsAbove %d failed, %v", pending.PrevHeight, err)
+		}
+	}
+	// 提交已经完成，或者检查点没有做完
+	b.removeCommitCheckpoint()
+
+	dbs := b.chainDBs()
+	height, err := db.RecoverPendingCommit(b.baseDB, dbs)
+	if err != nil {
+		common.Log.Panicf("recover pending commit failed, %v", err)
+	}
+	if height > -2 {
+		common.Log.Warnf("last commit was interrupted, all db rolled back to %d", height)
+		if err := db.DeleteCommitLogsAbove(b.baseDB, height); err != nil {
+			common.Log.Errorf("DeleteCommitLogsAbove %d failed, %v", height, err)
+		}
+	}
+
+	// 回滚不了的时候不能在不一致的数据上继续同步
+	height, err = db.RecoverCommitHeights(dbs)
+	if err != nil {
+		common.Log.Panicf("db commit heights are inconsistent, %v", err)
+	}
+	if height > -2 {
+		common.Log.Warnf("db commit heights are inconsistent, all db rolled back to %d", height)
+		if err := db.DeleteCommitLogsAbove(b.baseDB, height); err != nil {
+			common.Log.Errorf("DeleteCommitLogsAbove %d failed, %v", height, err)
+		}
+	}
+}
+
+// 链上数据库的名称，跟目录名一致
+var chainDBNames = []string{"base", "exotic", "nft", "ns", "ft", "brc20", "runes", "atom", "sat"}
+
+// 不跟区块一起提交的本地数据库
+var localDBNames = []string{"local", "dkvs"}
+
+// 关闭的协议没有数据库
+func (b *IndexerMgr) chainDBs() []common.KVDB {
+	all := b.chainDBsByName()
+	result := make([]common.KVDB, 0, len(all))
+	for _, name := range chainDBNames {
+		if kvdb, ok := all[name]; ok {
+			result = append(result, kvdb)
+		}
+	}
+	return result
+}
+
+func (b *IndexerMgr) chainDBsByName() map[string]common.KVDB {
+	all := map[string]common.KVDB{
+		"base":   b.baseDB,
+		"exotic": b.exoticDB,
+		"nft":    b.nftDB,
+		"ns":     b.nsDB,
+		"ft":     b.ftDB,
+		"brc20":  b.brc20DB,
+		"runes":  b.runesDB,
+		"atom":   b.atomDB,
+		"sat":    b.satDB,
+	}
+	for name, kvdb := range all {
+		if kvdb == nil {
+			delete(all, name)
+		}
+	}
+	return all
+}
+
+func (b *IndexerMgr) localDBsByName() map[string]common.KVDB {
+	all := map[string]common.KVDB{
+		"local": b.localDB,
+		"dkvs":  b.kvDB,
+	}
+	for name, kvdb := range all {
+		if kvdb == nil {
+			delete(all, name)
+		}
+	}
+	return all
+}
+
+// 找到数据库中还在主链上的最高的写入高度，撤销日志不够时返回错误
+func (b *IndexerMgr) findUndoTarget(reorgHeight int) (int, error) {
+	metas, err := db.GetUndoMetas(b.baseDB)
+	if err != nil {
+		return 0, fmt.Errorf("GetUndoMetas failed, %v", err)
+	}
+
+	isOnChain := func(height int, hash string) (bool, error) {
+		if height < 0 {
+			return true, nil
+		}
+		chainHash, err := base_indexer.GetBlockHash(height)
+		if err != nil {
+			return false, fmt.Errorf("GetBlockHash %d failed, %v", height, err)
+		}
+		return chainHash == hash, nil
+	}
+
+	for _, meta := range metas {
+		if meta.Height >= reorgHeight {
+			continue
+		}
+		onChain, err := isOnChain(meta.Height, meta.Hash)
+		if err != nil {
+			return 0, err
+		}
+		if onChain {
+			return meta.Height, nil
+		}
+	}
+	if len(metas) > 0 {
+		earliest := metas[len(metas)-1]
+		if earliest.PrevHeight < reorgHeight {
+			onChain, err := isOnChain(earliest.PrevHeight, earliest.PrevHash)
+			if err != nil {
+				return 0, err
+			}
+			if onChain {
+				return earliest.PrevHeight, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("reorg at %d is deeper than the undo journal, db height %d",
+		reorgHeight, b.base.GetSyncHeight())
+}
+
+// rollbackDB 将所有链上数据库回滚到 reorgHeight 之前的一个写入高度
+func (b *IndexerMgr) rollbackDB(reorgHeight int) error {
+	b.commitMutex.Lock()
+	defer b.commitMutex.Unlock()
+
+	target, err := b.findUndoTarget(reorgHeight)
+	if err != nil {
+		return err
+	}
+
+	common.Log.Infof("rollback db from %d to %d", b.base.GetSyncHeight(), target)
+	for _, kvdb := range b.chainDBs() {
+		n, err := db.RollbackToHeight(kvdb, target)
+		if err != nil {
+			return fmt.Errorf("RollbackToHeight %d failed, %v", target, err)
+		}
+		common.Log.Debugf("rollback %d undo records", n)
+	}
+	if err := db.DeleteCommitLogsAbove(b.baseDB, target); err != nil {
+		common.Log.Errorf("DeleteCommitLogsAbove %d failed, %v", target, err)
+	}
+	return nil
+}
+
+// commitCheckpoint 追块时提交前的检查点，meta 文件最后写入，没有 meta 文件的检查点是不完整的
+type commitCheckpoint struct {
+	Height     int      `json:"height"`
+	PrevHeight int      `json:"prevHeight"`
+	DBs        []string `json:"dbs"`
+}
+
+const commitCheckpointMetaFile = "commit.json"
+
+func (b *IndexerMgr) commitCheckpointDir() string {
+	return b.dbDir + "commit-checkpoint"
+}
+
+// checkpointChainDBs 在提交前给所有链上数据库做一个检查点，pebble 的检查点是硬链接，不复制数据
+func (b *IndexerMgr) checkpointChainDBs(meta *db.UndoMeta) error {
+	b.removeCommitCheckpoint()
+	dir := b.commitCheckpointDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	checkpoint := &commitCheckpoint{Height: meta.Height, PrevHeight: meta.PrevHeight}
+	all := b.chainDBsByName()
+	for _, name := range chainDBNames {
+		kvdb, ok := all[name]
+		if !ok {
+			continue
+		}
+		if err := db.CheckpointDB(kvdb, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("checkpoint %s failed, %v", name, err)
+		}
+		checkpoint.DBs = append(checkpoint.DBs, name)
+	}
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, commitCheckpointMetaFile), data, 0644)
+}
+
+// readCommitCheckpoint 没有完整的检查点时返回 nil
+func (b *IndexerMgr) readCommitCheckpoint() *commitCheckpoint {
+	data, err := os.ReadFile(filepath.Join(b.commitCheckpointDir(), commitCheckpointMetaFile))
+	if err != nil {
+		return nil
+	}
+	var checkpoint commitCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		common.Log.Errorf("invalid commit checkpoint, %v", err)
+		return nil
+	}
+	return &checkpoint
+}
+
+// restoreCommitCheckpoint 数据库都关闭后，用检查点替换。中途退出后再次启动会继续替换
+func (b *IndexerMgr) restoreCommitCheckpoint(checkpoint *commitCheckpoint) {
+	dir := b.commitCheckpointDir()
+	for _, name := range checkpoint.DBs {
+		if err := db.RestoreCheckpoint(filepath.Join(dir, name), b.dbDir+name); err != nil {
+			common.Log.Panicf("restore %s from the commit checkpoint failed, %v", name, err)
+		}
+	}
+}
+
+func (b *IndexerMgr) removeCommitCheckpoint() {
+	dir := b.commitCheckpointDir()
+	for _, name := range chainDBNames {
+		if err := db.RemoveCheckpoint(filepath.Join(dir, name)); err != nil {
+			common.Log.Errorf("remove commit checkpoint %s failed, %v", name, err)
+		}
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		common.Log.Errorf("remove commit checkpoint failed, %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package indexer
+
+import (
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/share/bitcoin_zmq"
+)
+
+// startZMQ 订阅 bitcoind 的通知，新区块立即触发同步，没有配置时返回 nil，继续使用轮询
+func (b *IndexerMgr) startZMQ() *bitcoin_zmq.Subscriber {
+	cfg := &b.cfg.ShareRPC.Bitcoin.ZMQ
+	if !cfg.Enabled() {
+		return nil
+	}
+	hasHashBlock := cfg.HashBlock != ""
+	sub := bitcoin_zmq.NewSubscriber(cfg, &bitcoin_zmq.Listener{
+		OnBlock: func(hash string) {
+			common.Log.Infof("zmq hashblock %s", hash)
+			b.notifyNewBlock()
+			b.miniMempool.blockConnected(hash)
+		},
+		OnRawTx: b.miniMempool.rawTxReceived,
+		OnSequence: func(hash string, label byte, _ uint64) {
+			switch label {
+			case bitcoin_zmq.SEQUENCE_BLOCK_CONNECTED:
+				b.notifyNewBlock()
+				if !hasHashBlock {
+					b.miniMempool.blockConnected(hash)
+				}
+			case bitcoin_zmq.SEQUENCE_BLOCK_DISCONNECTED:
+				b.notifyNewBlock()
+			case bitcoin_zmq.SEQUENCE_TX_REMOVED:
+				b.miniMempool.txRemoved(hash)
+			}
+		},
+		OnGap: func(topic string) {
+			b.notifyNewBlock()
+			if topic != bitcoin_zmq.TOPIC_HASHBLOCK {
+				b.miniMempool.resyncFromRPC()
+			}
+		},
+	})
+	sub.Start()
+	return sub
+}
+
+// notifyNewBlock 多次通知合并成一次同步
+func (b *IndexerMgr) notifyNewBlock() {
+	b.syncPending.Store(true)
+	select {
+	case b.newBlockChan <- struct{}{}:
+	default:
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"flag"
+
 	"github.com/sat20-labs/indexer/common"
 	"github.com/sat20-labs/indexer/config"
 	"github.com/sat20-labs/indexer/indexer"
@@ -14,6 +16,11 @@ func init() {
 }
 
 func main() {
+	flag.String("env", ".env", "config file, default ./.env") // InitConfig 直接读取
+	snapshotExport := flag.String("snapshot_export", "", "export all databases to a snapshot directory and exit")
+	snapshotImport := flag.String("snapshot_import", "", "init the empty db path from a snapshot directory, then start")
+	flag.Parse()
+
 	yamlcfg := config.InitConfig("")
 	config.InitLog(yamlcfg)
 
@@ -23,6 +30,14 @@ func main() {
 		common.Log.Info("shut down")
 	}()
 
+	if *snapshotImport != "" {
+		err := importSnapshot(yamlcfg, *snapshotImport)
+		if err != nil {
+			common.Log.Error(err)
+			return
+		}
+	}
+
 	err := InitRpc(yamlcfg)
 	if err != nil {
 		common.Log.Error(err)
@@ -33,6 +48,14 @@ func main() {
 	base_indexer.InitBaseIndexer(indexerMgr)
 	indexerMgr.Init()
 
+	if *snapshotExport != "" {
+		err := exportSnapshot(indexerMgr, *snapshotExport)
+		if err != nil {
+			common.Log.Error(err)
+		}
+		return
+	}
+
 	stopChan := make(chan bool)
 	cb := func() {
 		common.Log.Info("handle SIGINT for close base indexer")
@@ -82,6 +105,12 @@ func InitRpcService(conf *config.YamlConf, indexerMgr *indexer.IndexerMgr, stopC
 			return rpc, err
 		}
 		common.Log.Info("rpc started")
+		if rpcService.GrpcAddr != "" {
+			if err := rpc.StartGrpc(rpcService.GrpcAddr); err != nil {
+				return rpc, err
+			}
+			common.Log.Infof("grpc started at %s", rpcService.GrpcAddr)
+		}
 	}
 	return rpc, nil
 }
@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer"
+	"github.com/sat20-labs/indexer/rpcserver/wire"
+)
+
+type HolderExportReq struct {
+	// 比如 ordx:f:pearl, brc20:f:ordi, runes:f:840000:3, ordx:e:uncommon
+	Ticker string `json:"ticker"`
+	// 0 表示当前同步高度，其他高度需要有余额历史
+	Height int `json:"height"`
+}
+
+type HolderExportResp struct {
+	wire.BaseResp
+	Data *indexer.HolderExportJob `json:"data"`
+}
+
+// @Summary Start a holder snapshot export job
+// @Description Export the holders of a ticker at a height to sorted CSV/JSONL files with a manifest, asynchronously
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param req body HolderExportReq true "ticker and height"
+// @Success 200 {object} HolderExportResp "Successful response"
+// @Failure 403 "Admin permission required"
+// @Router /admin/export/holders [post]
+func (s *Service) startHolderExport(c *gin.Context) {
+	resp := &HolderExportResp{
+		BaseResp: wire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+	var req HolderExportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if req.Ticker == "" {
+		resp.Code = -1
+		resp.Msg = "ticker is required"
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	job, err := s.indexer.StartHolderExport(common.NewAssetNameFromString(req.Ticker), req.Height)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = job
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get a holder snapshot export job
+// @Description Get the status and the manifest of a holder snapshot export job
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "job id"
+// @Success 200 {object} HolderExportResp "Successful response"
+// @Failure 403 "Admin permission required"
+// @Router /admin/export/holders/{id} [get]
+func (s *Service) getHolderExport(c *gin.Context) {
+	resp := &HolderExportResp{
+		BaseResp: wire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+	job := s.indexer.GetHolderExportJob(c.Param("id"))
+	if job == nil {
+		resp.Code = -1
+		resp.Msg = "export job not found"
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = job
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Download a holder snapshot export file
+// @Description Download the csv, jsonl or manifest.json of a finished export job
+// @Tags admin
+// @Produce octet-stream
+// @Security Bearer
+// @Param id path string true "job id"
+// @Param file path string true "file name in the manifest, or manifest.json"
+// @Success 200 {file} file "file content"
+// @Failure 403 "Admin permission required"
+// @Router /admin/export/holders/{id}/{file} [get]
+func (s *Service) downloadHolderExport(c *gin.Context) {
+	path, err := s.indexer.GetHolderExportFile(c.Param("id"), c.Param("file"))
+	if err != nil {
+		c.JSON(http.StatusOK, &wire.BaseResp{Code: -1, Msg: err.Error()})
+		return
+	}
+	c.FileAttachment(path, c.Param("file"))
+}
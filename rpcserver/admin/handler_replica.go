@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/indexer"
+	"github.com/sat20-labs/indexer/rpcserver/wire"
+)
+
+type ReplicaCheckpointResp struct {
+	wire.BaseResp
+	Data *indexer.ReplicaCheckpoint `json:"data"`
+}
+
+// @Summary Get the next commit log for a replica
+// @Description Return the gob encoded commit log a replica at (height, hash) should apply next, 204 if the replica is up to date
+// @Tags admin
+// @Produce octet-stream
+// @Security Bearer
+// @Param height query int true "replica sync height"
+// @Param hash query string true "replica sync block hash"
+// @Success 200 {file} binary "Commit log"
+// @Success 204 "Replica is up to date"
+// @Failure 403 "Admin permission required"
+// @Router /admin/replica/log [get]
+func (s *Service) getReplicaCommitLog(c *gin.Context) {
+	resp := &wire.BaseResp{
+		Code: 0,
+		Msg:  "ok",
+	}
+	height, err := strconv.Atoi(c.Query("height"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = "invalid height"
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	buf, err := s.indexer.GetReplicaCommitLog(height, c.Query("hash"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if buf == nil {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Data(http.StatusOK, "application/octet-stream", buf)
+}
+
+// @Summary Create a checkpoint for new replicas
+// @Description Create a consistent checkpoint of all chain databases in the db directory of the primary, copy it to the db directory of a replica to start it
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} ReplicaCheckpointResp "Successful response"
+// @Failure 403 "Admin permission required"
+// @Router /admin/replica/checkpoint [post]
+func (s *Service) createReplicaCheckpoint(c *gin.Context) {
+	resp := &ReplicaCheckpointResp{
+		BaseResp: wire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+	checkpoint, err := s.indexer.CreateReplicaCheckpoint()
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = checkpoint
+	c.JSON(http.StatusOK, resp)
+}
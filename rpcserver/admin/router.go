@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/indexer"
+)
+
+type Service struct {
+	indexer *indexer.IndexerMgr
+}
+
+func NewService(i *indexer.IndexerMgr) *Service {
+	return &Service{
+		indexer: i,
+	}
+}
+
+// auth 限制只有管理员可以访问
+func (s *Service) InitRouter(r *gin.Engine, basePath string, auth gin.HandlerFunc) {
+	group := r.Group(basePath+"/admin", auth)
+	// 启动持有者快照导出任务
+	group.POST("/export/holders", s.startHolderExport)
+	// 查询导出任务的状态
+	group.GET("/export/holders/:id", s.getHolderExport)
+	// 下载导出的文件
+	group.GET("/export/holders/:id/:file", s.downloadHolderExport)
+	// 副本拉取主节点的提交日志
+	group.GET("/replica/log", s.getReplicaCommitLog)
+	// 生成新副本使用的数据库快照
+	group.POST("/replica/checkpoint", s.createReplicaCheckpoint)
+}
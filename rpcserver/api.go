@@ -1,8 +1,10 @@
 package rpcserver
 
 import (
+	"math"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,8 +20,13 @@ import (
 type APIDoc struct {
 	api          *config.API
 	initApiConf  bool
-	apiConfMutex sync.Mutex
-	apiLimitMap  sync.Map
+	apiConfMutex sync.RWMutex
+	apiLimitMap  sync.Map // *config.RateLimit -> *limiter.Limiter
+	quota        *QuotaStore
+}
+
+func NewAPIDoc(db common.KVDB) *APIDoc {
+	return &APIDoc{quota: NewQuotaStore(db)}
 }
 
 //	@contact.name	API Support
@@ -49,133 +56,187 @@ func InitApiDoc(swaggerHost, schemes, basePath string) {
 	docs.SwaggerInfo.BasePath = basePath
 }
 
+// InitApiConf 只有配置了 apikey_list 才启用鉴权和限流
 func (s *APIDoc) InitApiConf(cfgData *config.API) error {
-	if cfgData == nil {
+	if cfgData == nil || len(cfgData.APIKeyList) == 0 {
 		return nil
 	}
+
+	s.apiConfMutex.Lock()
 	s.api = cfgData
-	readApiAuthConf := func() error {
-		s.apiConfMutex.Lock()
-		defer s.apiConfMutex.Unlock()
+	s.initApiConf = true
+	s.apiConfMutex.Unlock()
 
-		s.api = cfgData
-		s.initApiConf = true
-		return nil
+	if s.quota == nil {
+		s.quota = NewQuotaStore(nil)
+	}
+	go s.quota.run()
+	return nil
+}
+
+func isLoopbackIp(ip string) bool {
+	addr := net.ParseIP(ip)
+	return addr != nil && addr.IsLoopback()
+}
+
+// 支持 "Bearer <key>"，兼容旧的直接填 key 的方式
+func getApiKey(c *gin.Context) string {
+	authorization := strings.TrimSpace(c.GetHeader("Authorization"))
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
 	}
+	return authorization
+}
 
-	err := readApiAuthConf()
-	if err != nil {
-		return err
+func rateOf(rateLimit *config.RateLimit) float64 {
+	if rateLimit.PerSecond > 0 {
+		return float64(rateLimit.PerSecond)
 	}
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			err := readApiAuthConf()
-			if err != nil {
-				common.Log.Errorf("rpc.readApiAuthConf-> readApiAuthConf error: %v", err)
-			}
+	return float64(rateLimit.Max)
+}
+
+func (s *APIDoc) getLimiter(rateLimit *config.RateLimit) *limiter.Limiter {
+	v, ok := s.apiLimitMap.Load(rateLimit)
+	if ok {
+		return v.(*limiter.Limiter)
+	}
+	lmt := tollbooth.NewLimiter(rateOf(rateLimit), &limiter.ExpirableOptions{DefaultExpirationTTL: time.Hour})
+	if rateLimit.Burst > 0 {
+		lmt.SetBurst(rateLimit.Burst)
+	}
+	lmt.SetTokenBucketExpirationTTL(time.Minute)
+	v, _ = s.apiLimitMap.LoadOrStore(rateLimit, lmt)
+	return v.(*limiter.Limiter)
+}
+
+func tooManyRequests(c *gin.Context, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+	c.Abort()
+}
+
+// allow 先检查每秒的速率，再检查每天的总量，超限时返回需要等待的时间
+func (s *APIDoc) allow(counterKey string, rateLimit *config.RateLimit) (bool, time.Duration) {
+	if rateLimit == nil {
+		return true, 0
+	}
+	if rate := rateOf(rateLimit); rate > 0 {
+		if s.getLimiter(rateLimit).LimitReached(counterKey) {
+			return false, time.Duration(float64(time.Second) / rate)
 		}
-	}()
-	return nil
+	}
+	if rateLimit.PerDay > 0 {
+		return s.quota.Incr(counterKey, rateLimit.PerDay)
+	}
+	return true, 0
+}
+
+// checkRateLimit 超限时已经写好 429 的响应
+func (s *APIDoc) checkRateLimit(c *gin.Context, counterKey string, rateLimit *config.RateLimit) bool {
+	ok, retryAfter := s.allow(counterKey, rateLimit)
+	if !ok {
+		tooManyRequests(c, retryAfter)
+	}
+	return ok
 }
 
 func (s *APIDoc) ApplyApiConf(r *gin.Engine, basePath string) error {
-	localIpList := make([]string, 0)
-	if len(localIpList) == 0 {
-		addrs, err := net.InterfaceAddrs()
-		if err != nil {
-			return err
-		}
-		for _, addr := range addrs {
-			ipNet, ok := addr.(*net.IPNet)
-			if ok && ipNet.IP.To4() != nil {
-				localIpList = append(localIpList, ipNet.IP.String())
-			}
-		}
-		localIpList = append(localIpList, "localhost")
+	joinPath := func(api string) string {
+		return strings.TrimSuffix(basePath, "/") + "/" + strings.TrimPrefix(api, "/")
 	}
 
 	r.Use(func(c *gin.Context) {
-		if !s.initApiConf {
+		s.apiConfMutex.RLock()
+		api := s.api
+		enabled := s.initApiConf
+		s.apiConfMutex.RUnlock()
+		if !enabled {
 			c.Next()
 			return
 		}
-		for _, ip := range localIpList {
-			if strings.Contains(c.Request.Host, ip) {
-				c.Next()
-				return
-			}
-		}
 
-		s.apiConfMutex.Lock()
-		defer s.apiConfMutex.Unlock()
-		for _, apiUrl := range s.api.NoLimitApiList {
-			if basePath+apiUrl == c.Request.URL.Path {
+		// 只信任连接的来源地址，Host 和 X-Forwarded-For 等头都可以伪造
+		clientIp := c.RemoteIP()
+		if api.TrustLoopback && isLoopbackIp(clientIp) {
+			c.Next()
+			return
+		}
+		for _, host := range api.NoLimitHostList {
+			if clientIp == host {
 				c.Next()
 				return
 			}
 		}
 
-		clientIp := c.ClientIP()
-		common.Log.Debugf("authorization client Ip: %s", clientIp)
-		for _, host := range s.api.NoLimitHostList {
-			if clientIp == host {
+		route := c.FullPath()
+		for _, apiUrl := range api.NoLimitApiList {
+			path := joinPath(apiUrl)
+			if path == c.Request.URL.Path || path == route {
 				c.Next()
 				return
 			}
 		}
 
-		authorization := c.GetHeader("Authorization")
-		apiKey := s.api.APIKeyList[authorization]
-		if apiKey == nil {
+		key := getApiKey(c)
+		apiKey := api.APIKeyList[key]
+		if key == "" || apiKey == nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API Key"})
 			c.Abort()
 			return
 		}
-		if apiKey.RateLimit.PerSecond == 0 || apiKey.RateLimit.PerDay == 0 {
-			c.Next()
-			return
-		}
 
-		var rateLimit *RateLimit
-		v, ok := s.apiLimitMap.Load(apiKey)
-		if !ok {
-			lmt := tollbooth.NewLimiter(float64(apiKey.RateLimit.PerSecond), &limiter.ExpirableOptions{DefaultExpirationTTL: time.Hour})
-			lmt.SetMax(float64(apiKey.RateLimit.Max))
-			lmt.SetBurst(apiKey.RateLimit.Burst)
-			lmt.SetTokenBucketExpirationTTL(time.Minute)
-			// lmt.SetOnLimitReached(func(w http.ResponseWriter, r *http.Request) {
-			// 	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
-			// 	c.Abort()
-			// })
-			rateLimit = &RateLimit{limit: lmt, reqCount: 0}
-			s.apiLimitMap.Store(apiKey, rateLimit)
-		} else {
-			rateLimit = v.(*RateLimit)
+		if !s.checkRateLimit(c, key, apiKey.RateLimit) {
+			return
 		}
-
-		now := time.Now()
-		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
-		if now.Before(today.AddDate(0, 0, 1)) {
-			rateLimit.reqCount++
-			if rateLimit.reqCount > apiKey.RateLimit.PerDay {
-				c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
-				c.Abort()
+		if route != "" {
+			var routeLimit *config.RateLimit
+			for apiUrl, limit := range apiKey.RouteLimitList {
+				if joinPath(apiUrl) == route {
+					routeLimit = limit
+					break
+				}
+			}
+			if routeLimit == nil {
+				for apiUrl, limit := range api.RouteLimitList {
+					if joinPath(apiUrl) == route {
+						routeLimit = limit
+						break
+					}
+				}
+			}
+			if !s.checkRateLimit(c, key+"|"+route, routeLimit) {
 				return
 			}
-		} else {
-			rateLimit.reqCount = 1
-		}
-
-		httpError := tollbooth.LimitByRequest(rateLimit.limit, c.Writer, c.Request)
-		if httpError != nil {
-			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
-			c.Abort()
-			return
 		}
 		c.Next()
 	})
 
 	return nil
 }
+
+// AdminAuth 管理接口需要配置了 admin 的 api key，打开 trust_loopback 时本机也可以访问，不受 nolimit_host_list 影响
+func (s *APIDoc) AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.apiConfMutex.RLock()
+		api := s.api
+		s.apiConfMutex.RUnlock()
+		if api != nil {
+			// 代理头可以伪造，只看连接的来源地址
+			if api.TrustLoopback && isLoopbackIp(c.RemoteIP()) {
+				c.Next()
+				return
+			}
+			key := getApiKey(c)
+			if apiKey := api.APIKeyList[key]; key != "" && apiKey != nil && apiKey.Admin {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin permission required"})
+		c.Abort()
+	}
+}
@@ -0,0 +1,225 @@
+package rpcserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/indexer/db"
+)
+
+func newApiTestEngine(t *testing.T, apidoc *APIDoc, apiConf *config.API) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	if err := apidoc.InitApiConf(apiConf); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	if err := apidoc.ApplyApiConf(r, "/testnet"); err != nil {
+		t.Fatal(err)
+	}
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	r.GET("/testnet/health", ok)
+	r.GET("/testnet/v3/tick/info/:ticker", ok)
+	r.GET("/testnet/v3/tick/holders/:ticker", ok)
+	return r
+}
+
+func doApiRequest(r *gin.Engine, path, remoteAddr, authorization string) *httptest.ResponseRecorder {
+	return doApiRequestWithHeader(r, path, remoteAddr, authorization, nil)
+}
+
+func doApiRequestWithHeader(r *gin.Engine, path, remoteAddr, authorization string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestApiKeyAndRateLimit(t *testing.T) {
+	apiConf := &config.API{
+		APIKeyList: map[string]*config.APIKey{
+			"key1": {UserName: "user1", RateLimit: &config.RateLimit{PerSecond: 1, Burst: 2}},
+			"key2": {UserName: "user2", RateLimit: &config.RateLimit{PerDay: 2}},
+		},
+		NoLimitApiList:  []string{"/health"},
+		NoLimitHostList: []string{"10.0.0.9"},
+		RouteLimitList: map[string]*config.RateLimit{
+			"/v3/tick/holders/:ticker": {PerDay: 1},
+		},
+	}
+	r := newApiTestEngine(t, NewAPIDoc(nil), apiConf)
+	const remote = "10.0.0.1:1234"
+
+	// Host 头不能绕过鉴权
+	req := httptest.NewRequest(http.MethodGet, "/testnet/v3/tick/info/ordi", nil)
+	req.RemoteAddr = remote
+	req.Host = "localhost"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("host header should not bypass auth: %d", w.Code)
+	}
+	if w := doApiRequest(r, "/testnet/v3/tick/info/ordi", remote, "Bearer bad"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("invalid key: %d", w.Code)
+	}
+
+	// 免限制的路由和地址
+	if w := doApiRequest(r, "/testnet/health", remote, ""); w.Code != http.StatusOK {
+		t.Fatalf("nolimit api: %d", w.Code)
+	}
+	if w := doApiRequest(r, "/testnet/v3/tick/info/ordi", "10.0.0.9:80", ""); w.Code != http.StatusOK {
+		t.Fatalf("nolimit host: %d", w.Code)
+	}
+	// 本机默认也要鉴权，打开 trust_loopback 之后才放行
+	if w := doApiRequest(r, "/testnet/v3/tick/info/ordi", "127.0.0.1:80", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("loopback without trust_loopback: %d", w.Code)
+	}
+	apiConf.TrustLoopback = true
+	if w := doApiRequest(r, "/testnet/v3/tick/info/ordi", "127.0.0.1:80", ""); w.Code != http.StatusOK {
+		t.Fatalf("loopback: %d", w.Code)
+	}
+
+	// 每秒限流，burst 之后返回 429
+	for i := 0; i < 2; i++ {
+		if w := doApiRequest(r, "/testnet/v3/tick/info/ordi", remote, "Bearer key1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: %d", i, w.Code)
+		}
+	}
+	w = doApiRequest(r, "/testnet/v3/tick/info/ordi", remote, "key1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("per second limit: %d %s", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// 路由的每天限额
+	if w := doApiRequest(r, "/testnet/v3/tick/holders/ordi", remote, "Bearer key2"); w.Code != http.StatusOK {
+		t.Fatalf("route first request: %d", w.Code)
+	}
+	w = doApiRequest(r, "/testnet/v3/tick/holders/sats", remote, "Bearer key2")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("route daily limit: %d", w.Code)
+	}
+	// key 的每天限额，被路由拒绝的请求也计数
+	w = doApiRequest(r, "/testnet/v3/tick/info/ordi", remote, "Bearer key2")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("key daily limit: %d", w.Code)
+	}
+}
+
+func TestApiSpoofedForwardedFor(t *testing.T) {
+	apiConf := &config.API{
+		APIKeyList: map[string]*config.APIKey{
+			"key1": {UserName: "user1", RateLimit: &config.RateLimit{PerDay: 1}},
+		},
+		NoLimitHostList: []string{"10.0.0.9"},
+	}
+	r := newApiTestEngine(t, NewAPIDoc(nil), apiConf)
+	const path = "/testnet/v3/tick/info/ordi"
+
+	// 代理头不能冒充本机或者不限流的主机
+	for _, ip := range []string{"127.0.0.1", "10.0.0.9"} {
+		header := map[string]string{"X-Forwarded-For": ip, "X-Real-IP": ip}
+		if w := doApiRequestWithHeader(r, path, "10.0.0.1:1234", "", header); w.Code != http.StatusUnauthorized {
+			t.Fatalf("spoofed %s without key: %d", ip, w.Code)
+		}
+	}
+	header := map[string]string{"X-Forwarded-For": "127.0.0.1"}
+	if w := doApiRequestWithHeader(r, path, "10.0.0.1:1234", "Bearer key1", header); w.Code != http.StatusOK {
+		t.Fatalf("first request: %d", w.Code)
+	}
+	if w := doApiRequestWithHeader(r, path, "10.0.0.1:1234", "Bearer key1", header); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("spoofed header should not skip rate limit: %d", w.Code)
+	}
+}
+
+func TestQuotaStorePersistsDailyCount(t *testing.T) {
+	kv := db.NewKVDBWithCache(filepath.Join(t.TempDir(), "local"), 1)
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+
+	store := NewQuotaStore(kv)
+	store.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if ok, _ := store.Incr("key", 3); !ok {
+			t.Fatalf("request %d should pass", i)
+		}
+	}
+	store.Flush()
+
+	// 重启之后继续当天的计数
+	store = NewQuotaStore(kv)
+	store.now = func() time.Time { return now }
+	if ok, _ := store.Incr("key", 3); !ok {
+		t.Fatal("third request should pass")
+	}
+	ok, retryAfter := store.Incr("key", 3)
+	if ok || retryAfter != time.Minute {
+		t.Fatalf("fourth request should be limited until midnight: %v %v", ok, retryAfter)
+	}
+	store.Flush()
+
+	// 第二天重新计数，旧的记录被清理
+	now = now.Add(time.Hour)
+	if ok, _ := store.Incr("key", 3); !ok {
+		t.Fatal("new day should reset the quota")
+	}
+	store.Flush()
+	if _, err := kv.Read([]byte(getQuotaKey("20240501", "key"))); err == nil {
+		t.Fatal("old quota should be pruned")
+	}
+	if _, err := kv.Read([]byte(getQuotaKey("20240502", "key"))); err != nil {
+		t.Fatalf("today's quota should be saved: %v", err)
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	apiConf := &config.API{
+		APIKeyList: map[string]*config.APIKey{
+			"key1":  {UserName: "user1"},
+			"admin": {UserName: "admin", Admin: true},
+		},
+		NoLimitHostList: []string{"10.0.0.9"},
+	}
+	apidoc := NewAPIDoc(nil)
+	r := newApiTestEngine(t, apidoc, apiConf)
+	r.GET("/testnet/admin/export/holders/:id", apidoc.AdminAuth(), func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	const path = "/testnet/admin/export/holders/1"
+	if w := doApiRequest(r, path, "127.0.0.1:1234", ""); w.Code == http.StatusOK {
+		t.Fatalf("loopback without trust_loopback should be rejected")
+	}
+	if w := doApiRequest(r, path, "10.0.0.1:1234", "Bearer key1"); w.Code != http.StatusForbidden {
+		t.Fatalf("normal key should be forbidden: %d", w.Code)
+	}
+	// 不限流的主机也需要管理员的 key
+	if w := doApiRequest(r, path, "10.0.0.9:1234", ""); w.Code != http.StatusForbidden {
+		t.Fatalf("nolimit host should be forbidden: %d", w.Code)
+	}
+	if w := doApiRequest(r, path, "10.0.0.1:1234", "Bearer admin"); w.Code != http.StatusOK {
+		t.Fatalf("admin key should pass: %d", w.Code)
+	}
+	header := map[string]string{"X-Forwarded-For": "127.0.0.1", "X-Real-IP": "127.0.0.1"}
+	if w := doApiRequestWithHeader(r, path, "10.0.0.1:1234", "", header); w.Code == http.StatusOK {
+		t.Fatalf("spoofed loopback should be rejected")
+	}
+	if w := doApiRequestWithHeader(r, path, "10.0.0.1:1234", "Bearer key1", header); w.Code != http.StatusForbidden {
+		t.Fatalf("spoofed loopback with normal key should be forbidden: %d", w.Code)
+	}
+
+	apiConf.TrustLoopback = true
+	if w := doApiRequest(r, path, "127.0.0.1:1234", ""); w.Code != http.StatusOK {
+		t.Fatalf("trusted loopback should pass: %d", w.Code)
+	}
+	if w := doApiRequestWithHeader(r, path, "10.0.0.1:1234", "", header); w.Code == http.StatusOK {
+		t.Fatalf("spoofed loopback should be rejected with trust_loopback")
+	}
+}
@@ -60,7 +60,39 @@ func (s *Service) getSatInfo(c *gin.Context) {
 		c.JSON(http.StatusOK, resp)
 		return
 	}
-	resp.Data = s.model.GetSatInfo(satNumber)
+	resp.Data, err = s.model.GetSatInfo(satNumber)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Retrieves the sat ranges in a utxo
+// @Description Retrieves the sat ranges in a utxo, requires the sat index
+// @Tags ordx
+// @Produce json
+// @Security Bearer
+// @Param utxo path string true "utxo"
+// @Success 200 {object} wire.UtxoSatRangesResp "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /utxo/ranges/{utxo} [get]
+func (s *Service) getSatRangesWithUtxo(c *gin.Context) {
+	resp := &wire.UtxoSatRangesResp{
+		BaseResp: wire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+		Data: nil,
+	}
+	data, err := s.model.GetSatRangesWithUtxo(c.Param("utxo"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = data
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -158,15 +158,38 @@ func (s *Model) getAllUtxos(address string, start, limit int) ([]*wire.PlainUtxo
 }
 
 
-func (s *Model) GetSatInfo(sat int64) *wire.SatInfo {
-	sm := exotic.Sat(sat)
-
+func (s *Model) GetSatInfo(sat int64) (*wire.SatInfo, error) {
+	location, err := s.indexer.LocateSat(sat)
+	if err != nil {
+		return nil, err
+	}
+	height := int64(location.Height)
 	return &wire.SatInfo{
-		Sat:        int64(sm),
-		Height:     sm.Height(),
-		Epoch:      int64(sm.Epoch()),
-		Cycle:      int64(sm.Cycle()),
-		Period:     int64(sm.Period()),
-		Satributes: sm.Satributes(),
+		Sat:        location.Sat,
+		Height:     height,
+		Epoch:      height / exotic.HalvingInterval,
+		Cycle:      height / exotic.CycleInterval,
+		Period:     height / exotic.DificultyAdjustmentInterval,
+		Satributes: location.Satributes,
+		Utxo:       location.Utxo,
+		Offset:     location.Offset,
+		Value:      location.Value,
+		Address:    location.Address,
+	}, nil
+}
+
+func (s *Model) GetSatRangesWithUtxo(utxo string) (*wire.UtxoSatRanges, error) {
+	ranges, err := s.indexer.GetSatRangesWithUtxo(utxo)
+	if err != nil {
+		return nil, err
+	}
+	result := &wire.UtxoSatRanges{
+		Utxo: utxo,
+		Sats: make([]wire.SatRange, 0, len(ranges)),
+	}
+	for _, rng := range ranges {
+		result.Sats = append(result.Sats, wire.SatRange{Start: rng.Start, Size: rng.Size, Offset: result.Value})
+		result.Value += rng.Size
 	}
+	return result, nil
 }
@@ -20,6 +20,10 @@ func (s *Service) InitRouter(r *gin.Engine, basePath string) {
 	r.GET(basePath+"/health", s.getHealth)
 	//查询支持的稀有聪类型
 	r.GET(basePath+"/info/satributes", s.getSatributes)
+	//查询某一聪的属性和当前所在的utxo，需要打开聪区间索引
+	r.GET(basePath+"/sat/:sat", s.getSatInfo)
+	//查询utxo中的聪区间，需要打开聪区间索引
+	r.GET(basePath+"/utxo/ranges/:utxo", s.getSatRangesWithUtxo)
 	//获取地址上大于指定value的utxo;如果value=0,获得所有可用的utxo
 	r.GET(basePath+"/utxo/address/:address/:value", s.getPlainUtxos)
 	//获取地址上获得所有utxo
@@ -40,6 +40,13 @@ func (w *gzipResponseWriter) Write(data []byte) (int, error) {
 // CompressionMiddleware handles gzip, brotli, and zstd compression
 func CompressionMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// 事件流需要即时推送，不压缩
+		if c.Request.Header.Get("Upgrade") != "" ||
+			strings.Contains(c.Request.Header.Get("Accept"), "text/event-stream") ||
+			strings.Contains(c.Request.URL.Path, "/v3/events") {
+			c.Next()
+			return
+		}
 		acceptEncoding := c.Request.Header.Get("Accept-Encoding")
 		if strings.Contains(acceptEncoding, "br") {
 			c.Writer.Header().Set("Content-Encoding", "br")
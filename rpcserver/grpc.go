@@ -0,0 +1,161 @@
+package rpcserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/rpcserver/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func grpcApiKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	authorization := strings.TrimSpace(values[0])
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return authorization
+}
+
+func grpcClientIp(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+type grpcCounter struct {
+	counterKey string
+	rateLimit  *config.RateLimit
+}
+
+// grpcCounters 和 rest 使用同样的 api key 和限流配置，路由限流使用 grpc 的方法名，比如 /pb.rpcserver.Indexer/GetHolders
+// 不需要限流时返回 nil
+func (s *APIDoc) grpcCounters(ctx context.Context, method string) ([]grpcCounter, error) {
+	s.apiConfMutex.RLock()
+	api := s.api
+	enabled := s.initApiConf
+	s.apiConfMutex.RUnlock()
+	if !enabled {
+		return nil, nil
+	}
+
+	clientIp := grpcClientIp(ctx)
+	if api.TrustLoopback && isLoopbackIp(clientIp) {
+		return nil, nil
+	}
+	for _, host := range api.NoLimitHostList {
+		if clientIp == host {
+			return nil, nil
+		}
+	}
+	for _, apiUrl := range api.NoLimitApiList {
+		if apiUrl == method {
+			return nil, nil
+		}
+	}
+
+	key := grpcApiKey(ctx)
+	apiKey := api.APIKeyList[key]
+	if key == "" || apiKey == nil {
+		return nil, status.Error(codes.Unauthenticated, "Invalid API Key")
+	}
+	routeLimit := apiKey.RouteLimitList[method]
+	if routeLimit == nil {
+		routeLimit = api.RouteLimitList[method]
+	}
+	return []grpcCounter{{key, apiKey.RateLimit}, {key + "|" + method, routeLimit}}, nil
+}
+
+func (s *APIDoc) chargeGrpc(counters []grpcCounter) error {
+	for _, v := range counters {
+		if ok, retryAfter := s.allow(v.counterKey, v.rateLimit); !ok {
+			return status.Errorf(codes.ResourceExhausted, "Rate limit exceeded, retry after %v", retryAfter)
+		}
+	}
+	return nil
+}
+
+func (s *APIDoc) checkGrpc(ctx context.Context, method string) error {
+	counters, err := s.grpcCounters(ctx, method)
+	if err != nil {
+		return err
+	}
+	return s.chargeGrpc(counters)
+}
+
+func (s *APIDoc) GrpcUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := s.checkGrpc(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// grpcLimitedStream 流中的每个请求都按一次调用计数
+type grpcLimitedStream struct {
+	grpc.ServerStream
+	apidoc   *APIDoc
+	counters []grpcCounter
+}
+
+func (s *grpcLimitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.apidoc.chargeGrpc(s.counters)
+}
+
+// 流式接口在建立时检查 api key，之后每收到一个请求计数一次
+func (s *APIDoc) GrpcStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		counters, err := s.grpcCounters(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		if counters == nil {
+			return handler(srv, ss)
+		}
+		return handler(srv, &grpcLimitedStream{ServerStream: ss, apidoc: s, counters: counters})
+	}
+}
+
+// StartGrpc 需要在 Start 之后调用，使用 Start 中加载的 api 配置
+func (s *Rpc) StartGrpc(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("grpc listen %s failed: %v", addr, err)
+	}
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(s.apidoc.GrpcUnaryInterceptor()),
+		grpc.StreamInterceptor(s.apidoc.GrpcStreamInterceptor()),
+	)
+	pb.RegisterIndexerServer(server, s.grpcServer)
+	go func() {
+		if err := server.Serve(l); err != nil {
+			common.Log.Errorf("grpc server stopped: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,135 @@
+package rpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/rpcserver/ordx"
+	"github.com/sat20-labs/indexer/rpcserver/pb"
+	"github.com/sat20-labs/indexer/share/base_indexer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// 只实现测试用到的接口
+type grpcTestIndexer struct {
+	base_indexer.Indexer
+	utxos map[string]*common.AssetsInUtxo
+}
+
+func (p *grpcTestIndexer) IsUtxoSpent(utxo string) bool {
+	return false
+}
+
+func (p *grpcTestIndexer) GetTxOutputWithUtxoV3(utxo string, excludingInvalid bool) *common.AssetsInUtxo {
+	return p.utxos[utxo]
+}
+
+// bufconn 的对端地址不是 ip，按非本机处理
+func newGrpcTestClient(t *testing.T, apiConf *config.API) pb.IndexerClient {
+	indexer := &grpcTestIndexer{utxos: map[string]*common.AssetsInUtxo{
+		"tx:0": {
+			UtxoId:   1,
+			OutPoint: "tx:0",
+			Value:    330,
+			Assets: []*common.DisplayAsset{{
+				AssetName: common.AssetName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: "pearl"},
+				Amount:    "330",
+			}},
+			Confirmed: true,
+		},
+	}}
+	apidoc := NewAPIDoc(nil)
+	if err := apidoc.InitApiConf(apiConf); err != nil {
+		t.Fatal(err)
+	}
+	s := &Rpc{apidoc: apidoc, grpcServer: ordx.NewGrpcServer(indexer)}
+
+	l := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(s.apidoc.GrpcUnaryInterceptor()),
+		grpc.StreamInterceptor(s.apidoc.GrpcStreamInterceptor()),
+	)
+	pb.RegisterIndexerServer(server, s.grpcServer)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewIndexerClient(conn)
+}
+
+func TestGrpcUtxoInfo(t *testing.T) {
+	apiConf := &config.API{
+		APIKeyList: map[string]*config.APIKey{
+			"key1": {UserName: "user1"},
+		},
+		RouteLimitList: map[string]*config.RateLimit{
+			"/pb.rpcserver.Indexer/GetUtxosInfo":    {PerDay: 1},
+			"/pb.rpcserver.Indexer/StreamUtxosInfo": {PerDay: 2},
+		},
+	}
+	client := newGrpcTestClient(t, apiConf)
+
+	_, err := client.GetUtxoInfo(context.Background(), &pb.UtxoInfoRequest{Utxo: "tx:0"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("request without key should fail: %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer key1")
+	info, err := client.GetUtxoInfo(ctx, &pb.UtxoInfoRequest{Utxo: "tx:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Value != 330 || len(info.Assets) != 1 || info.Assets[0].Name.Ticker != "pearl" || info.Assets[0].Amount != "330" {
+		t.Fatalf("unexpected utxo info %v", info)
+	}
+	if _, err := client.GetUtxoInfo(ctx, &pb.UtxoInfoRequest{Utxo: "tx:1"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unknown utxo should be not found: %v", err)
+	}
+
+	// 批量查询跳过找不到的 utxo，和 rest 一致
+	infos, err := client.GetUtxosInfo(ctx, &pb.UtxosInfoRequest{Utxos: []string{"tx:0", "tx:1"}})
+	if err != nil || len(infos.Utxos) != 1 {
+		t.Fatalf("batch = %v, %v", infos, err)
+	}
+	if _, err := client.GetUtxosInfo(ctx, &pb.UtxosInfoRequest{Utxos: []string{"tx:0"}}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("route limit should apply: %v", err)
+	}
+
+	stream, err := client.StreamUtxosInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, utxo := range []string{"tx:0", "tx:1"} {
+		if err := stream.Send(&pb.UtxoInfoRequest{Utxo: utxo}); err != nil {
+			t.Fatal(err)
+		}
+		result, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Utxo != utxo || (utxo == "tx:0") != (result.Info != nil) || (utxo == "tx:1") != (result.Error != "") {
+			t.Fatalf("unexpected stream result %v", result)
+		}
+	}
+	// 流中的每个请求都计数
+	if err := stream.Send(&pb.UtxoInfoRequest{Utxo: "tx:0"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("route limit should apply to each stream message: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package rpcserver
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/share/metrics"
+)
+
+// 响应以 rpcwire.BaseResp 开头，code 不为 0 表示失败
+var okRespPrefix = []byte(`{"code":0`)
+
+type metricsResponseWriter struct {
+	gin.ResponseWriter
+	head []byte
+}
+
+func (w *metricsResponseWriter) Write(data []byte) (int, error) {
+	if n := len(okRespPrefix) - len(w.head); n > 0 {
+		if n > len(data) {
+			n = len(data)
+		}
+		w.head = append(w.head, data[:n]...)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *metricsResponseWriter) failed() bool {
+	if w.Status() >= http.StatusBadRequest {
+		return true
+	}
+	if len(w.head) == 0 || w.head[0] != '{' {
+		return false
+	}
+	// 只检查 json 对象的 code 字段
+	if !bytes.HasPrefix(w.head, []byte(`{"code":`)) {
+		return false
+	}
+	return !bytes.Equal(w.head, okRespPrefix)
+}
+
+// MetricsMiddleware 按路由记录请求耗时和失败次数
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		writer := &metricsResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		metrics.RPCSeconds.WithLabelValues(method, route, strconv.Itoa(writer.Status())).
+			Observe(time.Since(start).Seconds())
+		if writer.failed() {
+			metrics.RPCErrors.WithLabelValues(method, route).Inc()
+		}
+	}
+}
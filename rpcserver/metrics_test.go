@@ -0,0 +1,56 @@
+package rpcserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sat20-labs/indexer/rpcserver/wire"
+	"github.com/sat20-labs/indexer/share/metrics"
+)
+
+func TestMetricsMiddlewareCountsErrorsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	r.Use(MetricsMiddleware())
+	r.GET("/test/ok/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, &wire.BaseResp{Code: 0, Msg: "ok"})
+	})
+	r.GET("/test/fail/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, &wire.BaseResp{Code: -1, Msg: "not found"})
+	})
+	r.GET("/test/bad", func(c *gin.Context) {
+		c.String(http.StatusBadRequest, "bad request")
+	})
+
+	for _, path := range []string{"/test/ok/1", "/test/ok/2", "/test/fail/1", "/test/bad"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if n := testutil.ToFloat64(metrics.RPCErrors.WithLabelValues("GET", "/test/ok/:id")); n != 0 {
+		t.Fatalf("ok route errors %v", n)
+	}
+	if n := testutil.ToFloat64(metrics.RPCErrors.WithLabelValues("GET", "/test/fail/:id")); n != 1 {
+		t.Fatalf("fail route errors %v", n)
+	}
+	if n := testutil.ToFloat64(metrics.RPCErrors.WithLabelValues("GET", "/test/bad")); n != 1 {
+		t.Fatalf("bad route errors %v", n)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, name := range []string{
+		`sat20_indexer_rpc_request_seconds_count{method="GET",route="/test/ok/:id",status="200"} 2`,
+		"sat20_indexer_sync_height",
+		"sat20_indexer_chain_tip",
+	} {
+		if !strings.Contains(body, name) {
+			t.Errorf("%s not in /metrics", name)
+		}
+	}
+}
@@ -203,132 +203,6 @@ func (s *Service) getInscriptionPreview(c *gin.Context) {
 	}
 }
 
-// @Summary ordinal recursive endpoint for get block hash
-// @Description ordinal recursive endpoint for get block hash
-// @Tags ordx.ord.r
-// @Produce json
-// @Param height path uint64 true "height"
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/blockhash/{height} [get]
-// func (s *Service) getRBlockHash(c *gin.Context) {
-// 	height := c.Param("height")
-// 	blockHash, err := ord_rpc.ShareOrdinalsRpc.GetRBlockHash(height)
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, blockHash)
-// }
-
-// @Summary ordinal recursive endpoint for get lastest block hash
-// @Description ordinal recursive endpoint for get lastest block hash
-// @Tags ordx.ord.r
-// @Produce json
-// @Security Bearer
-// @Success 200 {object} string "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/blockhash [get]
-// func (s *Service) getRLastestBlockHash(c *gin.Context) {
-// 	blockHash, err := ord_rpc.ShareOrdinalsRpc.GetRLastestBlockHash()
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, blockHash)
-// }
-
-// @Summary ordinal recursive endpoint for get lastest block height
-// @Description ordinal recursive endpoint for get lastest block height
-// @Tags ordx.ord.r
-// @Produce json
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/blockheight [get]
-// func (s *Service) getRLastestBlockHeight(c *gin.Context) {
-// 	blockHeight, err := ord_rpc.ShareOrdinalsRpc.GetRLastestBlockHeight()
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, blockHeight)
-// }
-
-// @Summary ordinal recursive endpoint for get block info
-// @Description ordinal recursive endpoint for get block info
-// @Tags ordx.ord.r
-// @Produce json
-// @Param query path string true "block height or block hash"
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/blockinfo/{query} [get]
-// func (s *Service) getRBlockInfo(c *gin.Context) {
-// 	blockInfo, err := ord_rpc.ShareOrdinalsRpc.GetRBlockInfo(c.Param("query"))
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, blockInfo)
-// }
-
-// @Summary ordinal recursive endpoint for get UNIX time stamp of latest block
-// @Description ordinal recursive endpoint for get UNIX time stamp of latest block
-// @Tags ordx.ord.r
-// @Produce json
-// @Param query path string true "block height or block hash"
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/blocktime [get]
-// func (s *Service) getRLatestBlockTimestamp(c *gin.Context) {
-// 	blockInfo, err := ord_rpc.ShareOrdinalsRpc.GetRLatestBlockTimestamp()
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, blockInfo)
-// }
-
-// @Summary ordinal recursive endpoint for get the first 100 children ids
-// @Description ordinal recursive endpoint for get the first 100 children ids
-// @Tags ordx.ord.r
-// @Produce json
-// @Param inscriptionid path string true "inscription ID example: 79b0e9dbfaf11e664abafbd8fec7d734bfa2d59013f25c50aaac1264f700832di0"
-// @Param page path string false "page example: 0"
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/children/{inscriptionid}/{page} [get]
-// func (s *Service) getRChildrenInscriptionIdList(c *gin.Context) {
-// 	inscriptionIdList, err := ord_rpc.ShareOrdinalsRpc.GetRChildrenInscriptionIdList(c.Param("inscriptionid"), c.Param("page"))
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, inscriptionIdList)
-// }
-
-// @Summary ordinal recursive endpoint for get inscription info
-// @Description ordinal recursive endpoint for get inscription info
-// @Tags ordx.ord.r
-// @Produce json
-// @Param inscriptionid path string true "inscription ID example: 79b0e9dbfaf11e664abafbd8fec7d734bfa2d59013f25c50aaac1264f700832di0"
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/inscription/{inscriptionid} [get]
-// func (s *Service) getRInscriptionInfo(c *gin.Context) {
-// 	inscription, err := ord_rpc.ShareOrdinalsRpc.GetRInscriptionInfo(c.Param("inscriptionid"))
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, inscription)
-// }
-
 // @Summary ordinal recursive endpoint for get hex-encoded CBOR metadata of an inscription
 // @Description ordinal recursive endpoint for get hex-encoded CBOR metadata of an inscription
 // @Tags ordx.ord.r
@@ -359,41 +233,3 @@ func (s *Service) getRMetadata(c *gin.Context) {
 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, []byte(cborData))
 	c.Writer.Flush()
 }
-
-// @Summary ordinal recursive endpoint for get the first 100 inscription ids on a sat
-// @Description ordinal recursive endpoint for get the first 100 inscription ids on a sat
-// @Tags ordx.ord.r
-// @Produce json
-// @Param satnumber path string true "sat number example: 1165647477496168"
-// @Param page path string false "page example: 0"
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/sat/{satnumber}/{page} [get]
-// func (s *Service) getRSatInscriptionIdList(c *gin.Context) {
-// 	inscriptionIdList, err := ord_rpc.ShareOrdinalsRpc.GetRSatInscriptionIdList(c.Param("satnumber"), c.Param("page"))
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, inscriptionIdList)
-// }
-
-// @Summary ordinal recursive endpoint for get the inscription id at <INDEX> of all inscriptions on a sat
-// @Description ordinal recursive endpoint for get the inscription id at <INDEX> of all inscriptions on a sat
-// @Tags ordx.ord.r
-// @Produce json
-// @Param satnumber path string true "sat number example: 1165647477496168"
-// @Param index path string false "page example: -1"
-// @Security Bearer
-// @Success 200 {object} []byte "Successful response"
-// @Failure 401 "Invalid API Key"
-// @Router /ord/r/sat/{satnumber}/at/{index} [get]
-// func (s *Service) getRSatInscriptionId(c *gin.Context) {
-// 	inscriptionID, err := ord_rpc.ShareOrdinalsRpc.GetRSatInscriptionId(c.Param("satnumber"), c.Param("index"))
-// 	if err != nil {
-// 		c.JSON(http.StatusOK, err.Error())
-// 		return
-// 	}
-// 	c.Data(http.StatusOK, CONTENT_TYPE_JSON, inscriptionID)
-// }
@@ -0,0 +1,421 @@
+package ord
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/OLProtocol/go-bitcoind"
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/share/base_indexer"
+	"github.com/sat20-labs/indexer/share/bitcoin_rpc"
+)
+
+// ord 递归接口每页返回的数量
+const R_PAGE_SIZE = 100
+
+// 超过这个确认数的区块才允许缓存
+const R_STABLE_DEPTH = 6
+
+func writeRJson(c *gin.Context, data any) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		c.Data(http.StatusInternalServerError, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	c.Data(http.StatusOK, CONTENT_TYPE_JSON, body)
+}
+
+// 已经确定的数据，允许缓存
+func setRCacheable(c *gin.Context) {
+	c.Writer.Header().Set(CACHE_CONTROL, "public, max-age=1209600, immutable")
+}
+
+// 随着区块变化的数据，不允许缓存
+func setRNoCache(c *gin.Context) {
+	c.Writer.Header().Set(CACHE_CONTROL, "no-store")
+}
+
+func parseRPage(c *gin.Context) (int, error) {
+	page := c.Param("page")
+	if page == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(page)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid page: %s", page)
+	}
+	return n, nil
+}
+
+// nftId 列表按页转换成铭文 id
+func toRInscriptionIds(nftIds []int64, page int) *RInscriptionIds {
+	result := &RInscriptionIds{Ids: make([]string, 0), Page: page}
+	// 先比较页数，page 很大时 page*R_PAGE_SIZE 会溢出
+	if page > len(nftIds)/R_PAGE_SIZE {
+		return result
+	}
+	start := page * R_PAGE_SIZE
+	if start >= len(nftIds) {
+		return result
+	}
+	end := start + R_PAGE_SIZE
+	if end < len(nftIds) {
+		result.More = true
+	} else {
+		end = len(nftIds)
+	}
+	for _, id := range nftIds[start:end] {
+		nft := base_indexer.ShareBaseIndexer.GetNftInfo(id)
+		if nft == nil {
+			continue
+		}
+		result.Ids = append(result.Ids, nft.Base.InscriptionId)
+	}
+	return result
+}
+
+func getSatNftIds(c *gin.Context) ([]int64, bool) {
+	satNumber := c.Param("satnumber")
+	sat, err := strconv.ParseInt(satNumber, 10, 64)
+	if err != nil || sat < 0 {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf("invalid sat number: %s", satNumber)))
+		return nil, false
+	}
+	info := base_indexer.ShareBaseIndexer.GetNftsWithSat(sat)
+	if info == nil {
+		return nil, true
+	}
+	return info.Nfts, true
+}
+
+// ord 使用的区块补贴，和实际的 coinbase 输出无关
+func blockSubsidy(height int64) int64 {
+	halvings := height / 210000
+	if halvings >= 64 {
+		return 0
+	}
+	return (50 * 1e8) >> halvings
+}
+
+func newRBlockInfo(header *bitcoind.BlockHeader) (*RBlockInfo, error) {
+	bits, err := strconv.ParseUint(header.Bits, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bits %s", header.Bits)
+	}
+	info := &RBlockInfo{
+		Bits:             uint32(bits),
+		Chainwork:        header.Chainwork,
+		Confirmations:    header.Confirmations,
+		Difficulty:       header.Difficulty,
+		Hash:             header.Hash,
+		Height:           header.Height,
+		MerkleRoot:       header.Merkleroot,
+		Nonce:            header.Nonce,
+		Subsidy:          blockSubsidy(header.Height),
+		Target:           fmt.Sprintf("%064x", blockchain.CompactToBig(uint32(bits))),
+		Timestamp:        header.Time,
+		TransactionCount: header.Txes,
+		Version:          header.Version,
+	}
+	if header.Mediantime != 0 {
+		info.MedianTime = &header.Mediantime
+	}
+	if header.Nextblockhash != "" {
+		info.NextBlock = &header.Nextblockhash
+	}
+	if header.Previousblockhash != "" {
+		info.PreviousBlock = &header.Previousblockhash
+	}
+	return info, nil
+}
+
+// @Summary ordinal recursive endpoint for get block hash
+// @Description ordinal recursive endpoint for get block hash
+// @Tags ordx.ord.r
+// @Produce json
+// @Param height path uint64 true "height"
+// @Security Bearer
+// @Success 200 {object} string "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/blockhash/{height} [get]
+func (s *Service) getRBlockHash(c *gin.Context) {
+	height, err := strconv.ParseUint(c.Param("height"), 10, 64)
+	if err != nil {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf("invalid height: %s", c.Param("height"))))
+		return
+	}
+	if height > uint64(base_indexer.ShareBaseIndexer.GetSyncHeight()) {
+		c.Data(http.StatusNotFound, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf("block %d not found", height)))
+		return
+	}
+	hash, err := bitcoin_rpc.ShareBitconRpc.GetBlockHash(height)
+	if err != nil {
+		c.Data(http.StatusInternalServerError, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	// 足够深的区块不会再被重组
+	if height+R_STABLE_DEPTH <= uint64(base_indexer.ShareBaseIndexer.GetSyncHeight()) {
+		setRCacheable(c)
+	}
+	writeRJson(c, hash)
+}
+
+// @Summary ordinal recursive endpoint for get lastest block hash
+// @Description ordinal recursive endpoint for get lastest block hash
+// @Tags ordx.ord.r
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} string "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/blockhash [get]
+func (s *Service) getRLastestBlockHash(c *gin.Context) {
+	height := base_indexer.ShareBaseIndexer.GetSyncHeight()
+	hash, err := bitcoin_rpc.ShareBitconRpc.GetBlockHash(uint64(height))
+	if err != nil {
+		c.Data(http.StatusInternalServerError, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	setRNoCache(c)
+	writeRJson(c, hash)
+}
+
+// @Summary ordinal recursive endpoint for get lastest block height
+// @Description ordinal recursive endpoint for get lastest block height
+// @Tags ordx.ord.r
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} int "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/blockheight [get]
+func (s *Service) getRLastestBlockHeight(c *gin.Context) {
+	setRNoCache(c)
+	writeRJson(c, base_indexer.ShareBaseIndexer.GetSyncHeight())
+}
+
+// @Summary ordinal recursive endpoint for get block info
+// @Description ordinal recursive endpoint for get block info
+// @Tags ordx.ord.r
+// @Produce json
+// @Param query path string true "block height or block hash"
+// @Security Bearer
+// @Success 200 {object} RBlockInfo "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/blockinfo/{query} [get]
+func (s *Service) getRBlockInfo(c *gin.Context) {
+	query := c.Param("query")
+	syncHeight := base_indexer.ShareBaseIndexer.GetSyncHeight()
+	hash := query
+	if height, err := strconv.ParseUint(query, 10, 64); err == nil {
+		if height > uint64(syncHeight) {
+			c.Data(http.StatusNotFound, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf("block %s not found", query)))
+			return
+		}
+		hash, err = bitcoin_rpc.ShareBitconRpc.GetBlockHash(height)
+		if err != nil {
+			c.Data(http.StatusInternalServerError, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+			return
+		}
+	} else if len(query) != 64 {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf("invalid query: %s", query)))
+		return
+	}
+
+	header, err := bitcoin_rpc.ShareBitconRpc.GetBlockHeader(hash)
+	if err != nil || header.Height > int64(syncHeight) {
+		c.Data(http.StatusNotFound, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf("block %s not found", query)))
+		return
+	}
+	info, err := newRBlockInfo(header)
+	if err != nil {
+		c.Data(http.StatusInternalServerError, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	// 确认数和下一个区块会变化
+	setRNoCache(c)
+	writeRJson(c, info)
+}
+
+// @Summary ordinal recursive endpoint for get UNIX time stamp of latest block
+// @Description ordinal recursive endpoint for get UNIX time stamp of latest block
+// @Tags ordx.ord.r
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} int64 "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/blocktime [get]
+func (s *Service) getRLatestBlockTimestamp(c *gin.Context) {
+	height := base_indexer.ShareBaseIndexer.GetSyncHeight()
+	info, err := base_indexer.ShareBaseIndexer.GetBlockInfo(height)
+	if err != nil {
+		c.Data(http.StatusInternalServerError, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	setRNoCache(c)
+	writeRJson(c, info.Timestamp)
+}
+
+// @Summary ordinal recursive endpoint for get the first 100 children ids
+// @Description ordinal recursive endpoint for get the first 100 children ids
+// @Tags ordx.ord.r
+// @Produce json
+// @Param inscriptionid path string true "inscription ID example: 79b0e9dbfaf11e664abafbd8fec7d734bfa2d59013f25c50aaac1264f700832di0"
+// @Param page path string false "page example: 0"
+// @Security Bearer
+// @Success 200 {object} RInscriptionIds "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/children/{inscriptionid}/{page} [get]
+func (s *Service) getRChildrenInscriptionIdList(c *gin.Context) {
+	inscriptionId := c.Param("inscriptionid")
+	err := checkInscriptionId(inscriptionId)
+	if err != nil {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	page, err := parseRPage(c)
+	if err != nil {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	if base_indexer.ShareBaseIndexer.GetNftInfoWithInscriptionId(inscriptionId) == nil {
+		c.Data(http.StatusNotFound, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf(`inscription %s not found`, inscriptionId)))
+		return
+	}
+
+	var children []int64
+	collection := base_indexer.ShareBaseIndexer.GetCollectionWithInscriptionId(inscriptionId)
+	if collection != nil {
+		children = collection.Items
+	}
+	setRNoCache(c)
+	writeRJson(c, toRInscriptionIds(children, page))
+}
+
+// @Summary ordinal recursive endpoint for get inscription info
+// @Description ordinal recursive endpoint for get inscription info
+// @Tags ordx.ord.r
+// @Produce json
+// @Param inscriptionid path string true "inscription ID example: 79b0e9dbfaf11e664abafbd8fec7d734bfa2d59013f25c50aaac1264f700832di0"
+// @Security Bearer
+// @Success 200 {object} RInscriptionInfo "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/inscription/{inscriptionid} [get]
+func (s *Service) getRInscriptionInfo(c *gin.Context) {
+	inscriptionId := c.Param("inscriptionid")
+	err := checkInscriptionId(inscriptionId)
+	if err != nil {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	nft := base_indexer.ShareBaseIndexer.GetNftInfoWithInscriptionId(inscriptionId)
+	if nft == nil {
+		c.Data(http.StatusNotFound, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf(`inscription %s not found`, inscriptionId)))
+		return
+	}
+
+	info := &RInscriptionInfo{
+		Charms:    make([]string, 0),
+		Height:    int(nft.Base.BlockHeight),
+		Id:        nft.Base.InscriptionId,
+		Number:    nft.Base.Id,
+		Timestamp: nft.Base.BlockTime,
+	}
+	if len(nft.Base.Content) > 0 {
+		length := len(nft.Base.Content)
+		info.ContentLength = &length
+	}
+	if len(nft.Base.ContentType) > 0 {
+		contentType := string(nft.Base.ContentType)
+		info.ContentType = &contentType
+	}
+	if nft.Base.Delegate != "" {
+		info.Delegate = &nft.Base.Delegate
+	}
+	if nft.Base.Sat >= 0 {
+		info.Sat = &nft.Base.Sat
+	}
+	if address := base_indexer.ShareBaseIndexer.GetAddressById(nft.OwnerAddressId); address != "" {
+		info.Address = &address
+	}
+	utxo := base_indexer.ShareBaseIndexer.GetUtxoById(nft.UtxoId)
+	if utxo != "" {
+		info.Output = utxo
+		info.Satpoint = fmt.Sprintf("%s:%d", utxo, nft.Offset)
+		if value := base_indexer.ShareBaseIndexer.GetUtxoValue(utxo); value > 0 {
+			info.Value = &value
+		}
+	} else {
+		// 已经作为手续费，或者输出不在索引中
+		info.Output = fmt.Sprintf("%064x:%d", 0, 0)
+		info.Satpoint = fmt.Sprintf("%s:%d", info.Output, nft.Offset)
+	}
+
+	setRNoCache(c)
+	writeRJson(c, info)
+}
+
+// @Summary ordinal recursive endpoint for get the first 100 inscription ids on a sat
+// @Description ordinal recursive endpoint for get the first 100 inscription ids on a sat
+// @Tags ordx.ord.r
+// @Produce json
+// @Param satnumber path string true "sat number example: 1165647477496168"
+// @Param page path string false "page example: 0"
+// @Security Bearer
+// @Success 200 {object} RInscriptionIds "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/sat/{satnumber}/{page} [get]
+func (s *Service) getRSatInscriptionIdList(c *gin.Context) {
+	page, err := parseRPage(c)
+	if err != nil {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(err.Error()))
+		return
+	}
+	nftIds, ok := getSatNftIds(c)
+	if !ok {
+		return
+	}
+	setRNoCache(c)
+	writeRJson(c, toRInscriptionIds(nftIds, page))
+}
+
+// @Summary ordinal recursive endpoint for get the inscription id at <INDEX> of all inscriptions on a sat
+// @Description ordinal recursive endpoint for get the inscription id at <INDEX> of all inscriptions on a sat
+// @Tags ordx.ord.r
+// @Produce json
+// @Param satnumber path string true "sat number example: 1165647477496168"
+// @Param index path string false "page example: -1"
+// @Security Bearer
+// @Success 200 {object} RInscriptionId "Successful response"
+// @Failure 401 "Invalid API Key"
+// @Router /ord/r/sat/{satnumber}/at/{index} [get]
+func (s *Service) getRSatInscriptionId(c *gin.Context) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.Data(http.StatusBadRequest, CONTEXT_TYPE_TEXT, []byte(fmt.Sprintf("invalid index: %s", c.Param("index"))))
+		return
+	}
+	nftIds, ok := getSatNftIds(c)
+	if !ok {
+		return
+	}
+
+	result := &RInscriptionId{}
+	if index < 0 {
+		index += len(nftIds)
+	}
+	if index >= 0 && index < len(nftIds) {
+		nft := base_indexer.ShareBaseIndexer.GetNftInfo(nftIds[index])
+		if nft != nil {
+			result.Id = &nft.Base.InscriptionId
+		}
+	}
+	// 负数索引指向最新的铭文，会随着新的铭刻变化
+	if c.Param("index")[0] != '-' && result.Id != nil {
+		setRCacheable(c)
+	} else {
+		setRNoCache(c)
+	}
+	writeRJson(c, result)
+}
@@ -0,0 +1,87 @@
+package ord
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/OLProtocol/go-bitcoind"
+	"github.com/gin-gonic/gin"
+)
+
+func TestInitRouterRegistersRecursiveEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewService().InitRouter(r, "")
+
+	routes := make(map[string]bool)
+	for _, route := range r.Routes() {
+		routes[route.Path] = true
+	}
+	for _, path := range []string{
+		"/ord/r/blockhash",
+		"/ord/r/blockhash/:height",
+		"/ord/r/blockheight",
+		"/ord/r/blockinfo/:query",
+		"/ord/r/blocktime",
+		"/ord/r/children/:inscriptionid",
+		"/ord/r/children/:inscriptionid/:page",
+		"/ord/r/inscription/:inscriptionid",
+		"/ord/r/sat/:satnumber",
+		"/ord/r/sat/:satnumber/:page",
+		"/ord/r/sat/:satnumber/at/:index",
+	} {
+		if !routes[path] {
+			t.Errorf("route %s not registered", path)
+		}
+	}
+}
+
+func TestNewRBlockInfo(t *testing.T) {
+	header := &bitcoind.BlockHeader{
+		Hash:              "000000000000000000020d3b3bcb3f2e7a2d6c06e2e9d6b22c58e4a2c4d7f8a1",
+		Height:            840000,
+		Bits:              "17034219",
+		Time:              1713571767,
+		Txes:              3050,
+		Previousblockhash: "0000000000000000000172014ba58d66455762add0512355ad651207918494ab",
+	}
+	info, err := newRBlockInfo(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Bits != 0x17034219 {
+		t.Fatalf("bits %x", info.Bits)
+	}
+	if info.Target != fmt.Sprintf("%064s", "0342190000000000000000000000000000000000000000") {
+		t.Fatalf("target %s", info.Target)
+	}
+	if info.Subsidy != 312500000 {
+		t.Fatalf("subsidy %d", info.Subsidy)
+	}
+	if info.NextBlock != nil || info.MedianTime != nil {
+		t.Fatalf("missing fields should be null")
+	}
+	if info.PreviousBlock == nil || *info.PreviousBlock != header.Previousblockhash {
+		t.Fatalf("previous block %v", info.PreviousBlock)
+	}
+
+	if _, err := newRBlockInfo(&bitcoind.BlockHeader{Bits: "xyz"}); err == nil {
+		t.Fatalf("invalid bits should fail")
+	}
+}
+
+func TestToRInscriptionIdsPageOutOfRange(t *testing.T) {
+	result := toRInscriptionIds(make([]int64, R_PAGE_SIZE), 1)
+	if result.More || len(result.Ids) != 0 || result.Page != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// page*R_PAGE_SIZE 溢出
+	for _, page := range []int{math.MaxInt, math.MaxInt/R_PAGE_SIZE + 1} {
+		result = toRInscriptionIds(make([]int64, R_PAGE_SIZE), page)
+		if result.More || len(result.Ids) != 0 {
+			t.Fatalf("page %d: unexpected result %+v", page, result)
+		}
+	}
+}
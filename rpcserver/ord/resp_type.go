@@ -1,57 +1,49 @@
 package ord
 
-// import (
-// 	"github.com/sat20-labs/indexer/server/define"
-// 	"github.com/sat20-labs/indexer/share/ord_rpc"
-// )
-
-// type InscriptionContentResp struct {
-// 	define.BaseResp
-// 	Data []byte `json:"data"`
-// }
-
-// type OrdinalStatusResp struct {
-// 	define.BaseResp
-// 	IsOrdx bool            `json:"isOrdx"`
-// 	Data   *ord_rpc.Status `json:"data"`
-// }
-
-// type RBlockHashResp struct {
-// 	define.BaseResp
-// 	Data string `json:"data"`
-// }
-
-// type RBlockHeightResp struct {
-// 	define.BaseResp
-// 	Data uint64 `json:"data"`
-// }
-
-// type RBlockInfoResp struct {
-// 	define.BaseResp
-// 	Data *ord_rpc.RBlockInfo `json:"data"`
-// }
-
-// type RBlockTimestampResp struct {
-// 	define.BaseResp
-// 	Data uint64 `json:"data"`
-// }
-
-// type RInscriptionIdsPaginationResp struct {
-// 	define.BaseResp
-// 	Data *ord_rpc.RInscriptionIdsPagination `json:"data"`
-// }
-
-// type RInscriptionResp struct {
-// 	define.BaseResp
-// 	Data *ord_rpc.Inscription `json:"data"`
-// }
-
-// type RMetadataResp struct {
-// 	define.BaseResp
-// 	Data string `json:"data"`
-// }
-
-// type RInscriptionIdResp struct {
-// 	define.BaseResp
-// 	Data *ord_rpc.InscriptionId `json:"data"`
-// }
+// 和 ord 的递归接口返回的 json 保持一致
+
+type RBlockInfo struct {
+	Bits             uint32  `json:"bits"`
+	Chainwork        string  `json:"chainwork"`
+	Confirmations    int     `json:"confirmations"`
+	Difficulty       float64 `json:"difficulty"`
+	Hash             string  `json:"hash"`
+	Height           int64   `json:"height"`
+	MedianTime       *int64  `json:"median_time"`
+	MerkleRoot       string  `json:"merkle_root"`
+	NextBlock        *string `json:"next_block"`
+	Nonce            uint32  `json:"nonce"`
+	PreviousBlock    *string `json:"previous_block"`
+	Subsidy          int64   `json:"subsidy"`
+	Target           string  `json:"target"`
+	Timestamp        int64   `json:"timestamp"`
+	TransactionCount int     `json:"transaction_count"`
+	Version          uint32  `json:"version"`
+}
+
+type RInscriptionIds struct {
+	Ids  []string `json:"ids"`
+	More bool     `json:"more"`
+	Page int      `json:"page"`
+}
+
+type RInscriptionId struct {
+	Id *string `json:"id"`
+}
+
+type RInscriptionInfo struct {
+	Address       *string  `json:"address"`
+	Charms        []string `json:"charms"`
+	ContentLength *int     `json:"content_length"`
+	ContentType   *string  `json:"content_type"`
+	Delegate      *string  `json:"delegate"`
+	Fee           int64    `json:"fee"`
+	Height        int      `json:"height"`
+	Id            string   `json:"id"`
+	Number        int64    `json:"number"`
+	Output        string   `json:"output"`
+	Sat           *int64   `json:"sat"`
+	Satpoint      string   `json:"satpoint"`
+	Timestamp     int64    `json:"timestamp"`
+	Value         *int64   `json:"value"`
+}
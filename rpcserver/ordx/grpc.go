@@ -0,0 +1,260 @@
+package ordx
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/rpcserver/pb"
+	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
+	"github.com/sat20-labs/indexer/share/base_indexer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcServer 和 rest 接口共用 Model，数据和准入控制（rpcEnter/rpcLeft）都一样，只是编码不同
+type GrpcServer struct {
+	pb.UnimplementedIndexerServer
+	model *Model
+}
+
+func NewGrpcServer(indexer base_indexer.Indexer) *GrpcServer {
+	return &GrpcServer{
+		model: NewModel(indexer),
+	}
+}
+
+func toPbAssetName(name *common.AssetName) *pb.AssetName {
+	return &pb.AssetName{Protocol: name.Protocol, Type: name.Type, Ticker: name.Ticker}
+}
+
+func toPbDisplayAsset(asset *common.DisplayAsset) *pb.DisplayAsset {
+	result := &pb.DisplayAsset{
+		Name:       toPbAssetName(&asset.AssetName),
+		Amount:     asset.Amount,
+		Precision:  int32(asset.Precision),
+		BindingSat: int32(asset.BindingSat),
+		Invalid:    asset.Invalid,
+	}
+	for _, r := range asset.Offsets {
+		result.Offsets = append(result.Offsets, &pb.OffsetRange{Start: r.Start, End: r.End})
+	}
+	for _, v := range asset.OffsetToAmts {
+		result.OffsetToAmts = append(result.OffsetToAmts, &pb.OffsetToAmount{Offset: v.Offset, Amount: v.Amount})
+	}
+	return result
+}
+
+func toPbAssetsInUtxo(info *common.AssetsInUtxo) *pb.AssetsInUtxo {
+	result := &pb.AssetsInUtxo{
+		UtxoId:    info.UtxoId,
+		Outpoint:  info.OutPoint,
+		Value:     info.Value,
+		PkScript:  info.PkScript,
+		Confirmed: info.Confirmed,
+	}
+	for _, asset := range info.Assets {
+		result.Assets = append(result.Assets, toPbDisplayAsset(asset))
+	}
+	return result
+}
+
+func toPbNftItem(item *rpcwire.NftItem) *pb.NftItem {
+	return &pb.NftItem{
+		Id:                 item.Id,
+		Name:               item.Name,
+		Sat:                item.Sat,
+		Address:            item.Address,
+		InscriptionId:      item.InscriptionId,
+		Utxo:               item.Utxo,
+		Value:              item.Value,
+		Height:             int32(item.BlockHeight),
+		Time:               item.BlockTime,
+		InscriptionAddress: item.InscriptionAddress,
+		CurseType:          int32(item.CurseType),
+	}
+}
+
+func checkHeight(height int32) error {
+	if height < 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid height %d", height)
+	}
+	return nil
+}
+
+func (s *GrpcServer) GetAddressSummary(ctx context.Context, req *pb.AddressSummaryRequest) (*pb.AddressSummaryResponse, error) {
+	if err := checkHeight(req.Height); err != nil {
+		return nil, err
+	}
+	assets, err := s.model.GetAssetSummaryV3(req.Address, 0, 0, int(req.Height))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result := &pb.AddressSummaryResponse{}
+	for _, asset := range assets {
+		result.Assets = append(result.Assets, toPbDisplayAsset(asset))
+	}
+	return result, nil
+}
+
+func (s *GrpcServer) GetUtxoInfo(ctx context.Context, req *pb.UtxoInfoRequest) (*pb.AssetsInUtxo, error) {
+	if err := checkHeight(req.Height); err != nil {
+		return nil, err
+	}
+	info, err := s.model.GetUtxoInfoV3(req.Utxo, int(req.Height))
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return toPbAssetsInUtxo(info), nil
+}
+
+func (s *GrpcServer) GetUtxosInfo(ctx context.Context, req *pb.UtxosInfoRequest) (*pb.UtxosInfoResponse, error) {
+	if err := checkHeight(req.Height); err != nil {
+		return nil, err
+	}
+	infos, err := s.model.GetUtxoInfoListV3(&rpcwire.UtxosReq{Utxos: req.Utxos}, int(req.Height))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result := &pb.UtxosInfoResponse{Utxos: make([]*pb.AssetsInUtxo, 0, len(infos))}
+	for _, info := range infos {
+		result.Utxos = append(result.Utxos, toPbAssetsInUtxo(info))
+	}
+	return result, nil
+}
+
+// StreamUtxosInfo 每收到一个请求返回一个结果，查询失败时只设置 Error，不中断流
+func (s *GrpcServer) StreamUtxosInfo(stream pb.Indexer_StreamUtxosInfoServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result := &pb.UtxoInfoResult{Utxo: req.Utxo}
+		if req.Height < 0 {
+			result.Error = "invalid height"
+		} else if info, err := s.model.GetUtxoInfoV3(req.Utxo, int(req.Height)); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Info = toPbAssetsInUtxo(info)
+		}
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *GrpcServer) GetTickerInfo(ctx context.Context, req *pb.TickerRequest) (*pb.TickerInfo, error) {
+	info, err := s.model.GetTickerInfo(req.Ticker)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &pb.TickerInfo{
+		Name:            toPbAssetName(&info.AssetName),
+		DisplayName:     info.DisplayName,
+		Id:              info.Id,
+		Divisibility:    int32(info.Divisibility),
+		StartBlock:      int32(info.StartBlock),
+		EndBlock:        int32(info.EndBlock),
+		SelfMint:        int32(info.SelfMint),
+		DeployHeight:    int32(info.DeployHeight),
+		DeployBlocktime: info.DeployBlocktime,
+		DeployTx:        info.DeployTx,
+		Limit:           info.Limit,
+		N:               int32(info.N),
+		TotalMinted:     info.TotalMinted,
+		MintTimes:       info.MintTimes,
+		MaxSupply:       info.MaxSupply,
+		HoldersCount:    int32(info.HoldersCount),
+		InscriptionId:   info.InscriptionId,
+		InscriptionNum:  info.InscriptionNum,
+		Description:     info.Description,
+		Rarity:          info.Rarity,
+		DeployAddress:   info.DeployAddress,
+		Content:         info.Content,
+		ContentType:     info.ContentType,
+		Delegate:        info.Delegate,
+		Status:          int32(info.Status),
+	}, nil
+}
+
+func (s *GrpcServer) GetHolders(ctx context.Context, req *pb.HoldersRequest) (*pb.HoldersResponse, error) {
+	if err := checkHeight(req.Height); err != nil {
+		return nil, err
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = 100
+	}
+	holders, total, err := s.model.GetHolderListV3(req.Ticker, req.Start, limit, int(req.Height))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result := &pb.HoldersResponse{Total: total, Start: req.Start}
+	for _, holder := range holders {
+		result.Holders = append(result.Holders, &pb.Holder{Address: holder.Wallet, Balance: holder.TotalBalance})
+	}
+	return result, nil
+}
+
+func (s *GrpcServer) GetMintHistory(ctx context.Context, req *pb.MintHistoryRequest) (*pb.MintHistoryResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	history, err := s.model.GetMintHistoryV3(req.Ticker, int(req.Start), int(limit))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	result := &pb.MintHistoryResponse{Ticker: history.Ticker, Total: int32(history.Total), Start: req.Start}
+	for _, item := range history.Items {
+		result.Items = append(result.Items, &pb.MintHistoryItem{
+			MintAddress:    item.MintAddress,
+			HolderAddress:  item.HolderAddress,
+			Balance:        item.Balance,
+			InscriptionId:  item.InscriptionID,
+			InscriptionNum: item.InscriptionNum,
+		})
+	}
+	return result, nil
+}
+
+func (s *GrpcServer) GetNft(ctx context.Context, req *pb.NftRequest) (*pb.NftInfo, error) {
+	if !s.model.indexer.IsProtocolEnabled(config.PROTOCOL_NFT) {
+		return nil, status.Error(codes.Unimplemented, "nft is disabled")
+	}
+	info, err := s.model.GetNftInfoWithInscriptionId(req.InscriptionId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return &pb.NftInfo{
+		Item:         toPbNftItem(&info.NftItem),
+		ContentType:  info.ContentType,
+		Content:      info.Content,
+		MetaProtocol: info.MetaProtocol,
+		MetaData:     info.MetaData,
+		Parents:      info.Parents,
+		Delegate:     info.Delegate,
+	}, nil
+}
+
+func (s *GrpcServer) GetName(ctx context.Context, req *pb.NameRequest) (*pb.NameInfo, error) {
+	if !s.model.indexer.IsProtocolEnabled(config.PROTOCOL_NS) {
+		return nil, status.Error(codes.Unimplemented, "name service is disabled")
+	}
+	info, err := s.model.GetNameInfo(req.Name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	result := &pb.NameInfo{Item: toPbNftItem(&info.NftItem)}
+	for _, kv := range info.KVItemList {
+		result.Kvs = append(result.Kvs, &pb.KVItem{Key: kv.Key, Value: kv.Value, InscriptionId: kv.InscriptionId})
+	}
+	sort.Slice(result.Kvs, func(i, j int) bool { return result.Kvs[i].Key < result.Kvs[j].Key })
+	return result, nil
+}
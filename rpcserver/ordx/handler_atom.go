@@ -0,0 +1,181 @@
+package ordx
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
+)
+
+func getStartLimit(c *gin.Context) (int, int) {
+	start, err := strconv.Atoi(c.DefaultQuery("start", "0"))
+	if err != nil {
+		start = 0
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", QueryParamDefaultLimit))
+	if err != nil {
+		limit = 100
+	}
+	return start, limit
+}
+
+// @Summary Get atomical
+// @Description Get an atomicals nft, realm or subrealm by atomical id.
+// @Description A realm or subrealm stays pending_candidate until 3 blocks after its commit, when an earlier commit can still take the name.
+// @Description Subrealms minted by paying under the parent's rules are not supported yet and have status rule_mint_unsupported.
+// @Tags ordx.atom
+// @Produce json
+// @Param id path string true "Atomical id, txid + i + index"
+// @Success 200 {object} rpcwire.AtomicalResp "Successful response"
+// @Router /v3/atom/atomical/{id} [get]
+func (s *Handle) getAtomical(c *gin.Context) {
+	resp := &rpcwire.AtomicalResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	result, err := s.model.GetAtomical(c.Param("id"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Data = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Resolve realm
+// @Description Resolve a realm name like abc.def to the deepest claimed realm or subrealm
+// @Tags ordx.atom
+// @Produce json
+// @Param name path string true "Realm name"
+// @Success 200 {object} rpcwire.RealmResp "Successful response"
+// @Router /v3/atom/realm/{name} [get]
+func (s *Handle) resolveRealm(c *gin.Context) {
+	resp := &rpcwire.RealmResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	result, err := s.model.ResolveRealm(c.Param("name"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Data = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get subrealms
+// @Description Get the claimed subrealms under a realm or subrealm.
+// @Description Only subrealms minted by the holder of the parent are included, rule based mints are not supported yet.
+// @Tags ordx.atom
+// @Produce json
+// @Param id path string true "Atomical id of the parent"
+// @Query start query int false "Start index for pagination"
+// @Query limit query int false "Limit for pagination"
+// @Success 200 {object} rpcwire.AtomicalListResp "Successful response"
+// @Router /v3/atom/subrealms/{id} [get]
+func (s *Handle) getSubrealms(c *gin.Context) {
+	resp := &rpcwire.AtomicalListResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	start, limit := getStartLimit(c)
+	result, total, err := s.model.GetSubrealms(c.Param("id"), start, limit)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = &rpcwire.AtomicalListData{
+		ListResp: rpcwire.ListResp{
+			Total: uint64(total),
+			Start: int64(start),
+		},
+		Detail: result,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get atomicals nfts with address
+// @Description Get the atomicals nfts, realms and subrealms held by an address
+// @Tags ordx.atom
+// @Produce json
+// @Param address path string true "Address"
+// @Query start query int false "Start index for pagination"
+// @Query limit query int false "Limit for pagination"
+// @Success 200 {object} rpcwire.AtomicalListResp "Successful response"
+// @Router /v3/atom/nfts/address/{address} [get]
+func (s *Handle) getAtomicalNftsWithAddress(c *gin.Context) {
+	resp := &rpcwire.AtomicalListResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	start, limit := getStartLimit(c)
+	result, total, err := s.model.GetAtomicalNftsWithAddress(c.Param("address"), start, limit)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = &rpcwire.AtomicalListData{
+		ListResp: rpcwire.ListResp{
+			Total: uint64(total),
+			Start: int64(start),
+		},
+		Detail: result,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get atomical events
+// @Description Get the mint, transfer and burn history of an atomicals nft
+// @Tags ordx.atom
+// @Produce json
+// @Param id path string true "Atomical id"
+// @Query start query int false "Start index for pagination"
+// @Query limit query int false "Limit for pagination"
+// @Success 200 {object} rpcwire.AtomicalEventListResp "Successful response"
+// @Router /v3/atom/events/{id} [get]
+func (s *Handle) getAtomicalEvents(c *gin.Context) {
+	resp := &rpcwire.AtomicalEventListResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	start, limit := getStartLimit(c)
+	result, total, err := s.model.GetAtomicalEvents(c.Param("id"), start, limit)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = &rpcwire.AtomicalEventListData{
+		ListResp: rpcwire.ListResp{
+			Total: uint64(total),
+			Start: int64(start),
+		},
+		Detail: result,
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,99 @@
+package ordx
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/common"
+	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
+)
+
+func activityFilterFromQuery(c *gin.Context) (*common.ActivityFilter, error) {
+	return newActivityFilter(c.Query("start_height"), c.Query("end_height"),
+		c.Query("cursor"), c.Query("limit"), c.Query("order"))
+}
+
+// @Summary Get brc20 activity of an address
+// @Description Get deploy, mint, inscribe-transfer, send/receive, cancelled and spent transfers of an address, with the balance after each event
+// @Tags ordx.brc20
+// @Produce json
+// @Param address path string true "Address"
+// @Param ticker path string true "Ticker, brc20:f:name"
+// @Query start_height query int false "Start height, inclusive"
+// @Query end_height query int false "End height, inclusive"
+// @Query cursor query string false "Cursor of the last item of the previous page"
+// @Query limit query int false "Limit, default 100, max 1000"
+// @Query order query string false "asc or desc"
+// @Success 200 {object} rpcwire.BRC20ActivityResp "Successful response"
+// @Router /v3/address/activity/{address}/{ticker} [get]
+func (s *Handle) getBRC20ActivityWithAddress(c *gin.Context) {
+	resp := &rpcwire.BRC20ActivityResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	filter, err := activityFilterFromQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	result, total, next, err := s.model.GetBRC20ActivityWithAddress(c.Param("address"), c.Param("ticker"), filter)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = &rpcwire.BRC20ActivityData{
+		Total:  uint64(total),
+		Next:   next,
+		Detail: result,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get brc20 activity of a ticker
+// @Description Get all activities of a ticker, with the balances of both sides after each event
+// @Tags ordx.brc20
+// @Produce json
+// @Param ticker path string true "Ticker, brc20:f:name"
+// @Query start_height query int false "Start height, inclusive"
+// @Query end_height query int false "End height, inclusive"
+// @Query cursor query string false "Cursor of the last item of the previous page"
+// @Query limit query int false "Limit, default 100, max 1000"
+// @Query order query string false "asc or desc"
+// @Success 200 {object} rpcwire.BRC20ActivityResp "Successful response"
+// @Router /v3/tick/activity/{ticker} [get]
+func (s *Handle) getBRC20ActivityWithTicker(c *gin.Context) {
+	resp := &rpcwire.BRC20ActivityResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	filter, err := activityFilterFromQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	result, total, next, err := s.model.GetBRC20ActivityWithTicker(c.Param("ticker"), filter)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = &rpcwire.BRC20ActivityData{
+		Total:  uint64(total),
+		Next:   next,
+		Detail: result,
+	}
+	c.JSON(http.StatusOK, resp)
+}
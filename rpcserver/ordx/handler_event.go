@@ -0,0 +1,111 @@
+package ordx
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/share/event_stream"
+)
+
+const (
+	eventPingInterval = 30 * time.Second
+	eventWriteTimeout = 10 * time.Second
+)
+
+var eventUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// 和其他接口一样，允许跨域访问
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// 过滤条件：types, address, ticker, protocol，多个值用逗号分隔，或者重复参数
+func newEventFilter(c *gin.Context) *event_stream.Filter {
+	return event_stream.NewFilter(
+		c.QueryArray("types"),
+		c.QueryArray("address"),
+		c.QueryArray("ticker"),
+		c.QueryArray("protocol"),
+	)
+}
+
+// Server-Sent Events
+func (s *Handle) streamEventsSSE(c *gin.Context) {
+	sub := event_stream.ShareEventHub.Subscribe(newEventFilter(c), event_stream.DefaultSubscriptionBuffer)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(eventPingInterval)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case ev, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(ev.Type, ev)
+			return true
+		case <-ticker.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
+
+// WebSocket，只推送，客户端发送的消息被忽略
+func (s *Handle) streamEventsWS(c *gin.Context) {
+	filter := newEventFilter(c)
+	conn, err := eventUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		common.Log.Warnf("websocket upgrade failed, %v", err)
+		return
+	}
+	defer conn.Close()
+
+	sub := event_stream.ShareEventHub.Subscribe(filter, event_stream.DefaultSubscriptionBuffer)
+	defer sub.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(eventPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(eventWriteTimeout))
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-ticker.C:
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventWriteTimeout))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ordx
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
@@ -106,6 +107,135 @@ func (s *Handle) delKVs(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+func (s *Handle) listKVs(c *gin.Context) {
+	resp := &rpcwire.ListKValuesResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	result, next, err := s.model.ListKVs(c.Param("pubkey"), c.Query("prefix"), c.Query("cursor"), limit)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Values = result
+		resp.Next = next
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Handle) getKVHistory(c *gin.Context) {
+	resp := &rpcwire.KValueHistoryResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	result, err := s.model.GetKVHistory(c.Param("pubkey"), c.Param("key"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Versions = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Handle) getKVChanges(c *gin.Context) {
+	resp := &rpcwire.KValueChangesResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	since, err := strconv.ParseUint(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	result, last, err := s.model.GetKVChanges(since, limit)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Changes = result
+		resp.Last = last
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Handle) getKVRecords(c *gin.Context) {
+	resp := &rpcwire.KValueRecordsResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	result, next, err := s.model.GetKVRecords(c.Query("cursor"), limit)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Records = result
+		resp.Next = next
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Handle) getKVRegistrations(c *gin.Context) {
+	resp := &rpcwire.KVRegistrationsResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	result, err := s.model.GetKVRegistrations()
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Registrations = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 func (s *Handle) registerPubKey(c *gin.Context) {
 	resp := &rpcwire.RegisterPubKeyResp{
 		BaseResp: rpcwire.BaseResp{
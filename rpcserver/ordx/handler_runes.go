@@ -0,0 +1,93 @@
+package ordx
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
+)
+
+// @Summary Get runes activity of an address
+// @Description Get etch, mint, transfer-in/out, burn and cenotaph events of an address, transfer-in and burn events carry the allocation source (edict, pointer or default-output)
+// @Tags ordx.runes
+// @Produce json
+// @Param address path string true "Address"
+// @Query ticker query string false "Rune, runes:f:name or runes:f:block:tx, all runes if empty"
+// @Query start_height query int false "Start height, inclusive"
+// @Query end_height query int false "End height, inclusive"
+// @Query cursor query string false "Cursor of the last item of the previous page"
+// @Query limit query int false "Limit, default 100, max 1000"
+// @Query order query string false "asc or desc"
+// @Success 200 {object} rpcwire.RunesActivityResp "Successful response"
+// @Router /v3/runes/address/activity/{address} [get]
+func (s *Handle) getRunesActivityWithAddress(c *gin.Context) {
+	resp := &rpcwire.RunesActivityResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	filter, err := activityFilterFromQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	result, total, next, err := s.model.GetRunesActivityWithAddress(c.Param("address"), c.Query("ticker"), filter)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = &rpcwire.RunesActivityData{
+		Total:  uint64(total),
+		Next:   next,
+		Detail: result,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get runes activity of a rune
+// @Description Get all etch, mint, transfer-in/out, burn and cenotaph events of a rune
+// @Tags ordx.runes
+// @Produce json
+// @Param ticker path string true "Rune, runes:f:name or runes:f:block:tx"
+// @Query start_height query int false "Start height, inclusive"
+// @Query end_height query int false "End height, inclusive"
+// @Query cursor query string false "Cursor of the last item of the previous page"
+// @Query limit query int false "Limit, default 100, max 1000"
+// @Query order query string false "asc or desc"
+// @Success 200 {object} rpcwire.RunesActivityResp "Successful response"
+// @Router /v3/runes/activity/{ticker} [get]
+func (s *Handle) getRunesActivityWithTicker(c *gin.Context) {
+	resp := &rpcwire.RunesActivityResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	filter, err := activityFilterFromQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	result, total, next, err := s.model.GetRunesActivityWithTicker(c.Param("ticker"), filter)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	resp.Data = &rpcwire.RunesActivityData{
+		Total:  uint64(total),
+		Next:   next,
+		Detail: result,
+	}
+	c.JSON(http.StatusOK, resp)
+}
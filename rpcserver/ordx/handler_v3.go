@@ -1,6 +1,7 @@
 package ordx
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,15 @@ import (
 	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
 )
 
+// height 参数可选，不传或者为0时返回当前的数据
+func getHeightQuery(c *gin.Context) (int, error) {
+	height, err := strconv.Atoi(c.DefaultQuery("height", "0"))
+	if err != nil || height < 0 {
+		return 0, fmt.Errorf("invalid height %s", c.Query("height"))
+	}
+	return height, nil
+}
+
 // include plain sats
 func (s *Handle) getAssetSummaryV3(c *gin.Context) {
 	resp := &rpcwire.AssetSummaryRespV3{
@@ -27,8 +37,15 @@ func (s *Handle) getAssetSummaryV3(c *gin.Context) {
 	if err != nil {
 		limit = 100
 	}
+	height, err := getHeightQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
 
-	result, err := s.model.GetAssetSummaryV3(address, int(start), limit)
+	result, err := s.model.GetAssetSummaryV3(address, int(start), limit, height)
 	if err != nil {
 		resp.Code = -1
 		resp.Msg = err.Error()
@@ -90,7 +107,14 @@ func (s *Handle) getUtxoInfoV3(c *gin.Context) {
 	}
 
 	utxo := c.Param("utxo")
-	result, err := s.model.GetUtxoInfoV3(utxo)
+	height, err := getHeightQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	result, err := s.model.GetUtxoInfoV3(utxo, height)
 	if err != nil {
 		resp.Code = -1
 		resp.Msg = err.Error()
@@ -110,6 +134,13 @@ func (s *Handle) getUtxoInfoListV3(c *gin.Context) {
 		},
 	}
 
+	height, err := getHeightQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
 	var req rpcwire.UtxosReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		resp.Code = -1
@@ -118,7 +149,7 @@ func (s *Handle) getUtxoInfoListV3(c *gin.Context) {
 		return
 	}
 
-	result, err := s.model.GetUtxoInfoListV3(&req)
+	result, err := s.model.GetUtxoInfoListV3(&req, height)
 	if err != nil {
 		resp.Code = -1
 		resp.Msg = err.Error()
@@ -189,6 +220,7 @@ func (s *Handle) getLockedUtxos(c *gin.Context) {
 // @Param ticker path string true "Ticker name"
 // @Query start query int false "Start index for pagination"
 // @Query limit query int false "Limit for pagination"
+// @Query height query int false "Holders at this height, default the current height"
 // @Security Bearer
 // @Success 200 {object} rpcwire.HolderListRespV3 "Successful response"
 // @Failure 401 "Invalid API Key"
@@ -211,7 +243,14 @@ func (s *Handle) getHolderListV3(c *gin.Context) {
 	if err != nil {
 		limit = 100
 	}
-	holderlist, total, err := s.model.GetHolderListV3(tickerName, uint64(start), uint64(limit))
+	height, err := getHeightQuery(c)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	holderlist, total, err := s.model.GetHolderListV3(tickerName, uint64(start), uint64(limit), height)
 	if err != nil {
 		resp.Code = -1
 		resp.Msg = err.Error()
@@ -279,3 +318,76 @@ func (s *Handle) getMintHistoryV3(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, resp)
 }
+
+func (s *Handle) getTxAssetsFlow(c *gin.Context) {
+	resp := &rpcwire.TxAssetsFlowResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	result, err := s.model.GetTxAssetsFlow(c.Param("txid"))
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Data = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Handle) simulateTxAssetsFlow(c *gin.Context) {
+	resp := &rpcwire.TxAssetsFlowResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	var req rpcwire.TxAssetsFlowReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	result, err := s.model.SimulateTxAssetsFlow(&req)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Data = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Handle) buildTransferPsbt(c *gin.Context) {
+	resp := &rpcwire.TransferPsbtResp{
+		BaseResp: rpcwire.BaseResp{
+			Code: 0,
+			Msg:  "ok",
+		},
+	}
+
+	var req rpcwire.TransferPsbtReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	result, err := s.model.BuildTransferPsbt(&req)
+	if err != nil {
+		resp.Code = -1
+		resp.Msg = err.Error()
+	} else {
+		resp.Data = result
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
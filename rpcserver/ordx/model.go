@@ -106,8 +106,18 @@ func (s *Model) getBindingSatFromOrdxTicker(tickerName *common.AssetName) int {
 	return ticker.N
 }
 
-func (s *Model) GetAssetSummaryV3(address string, start int, limit int) ([]*common.DisplayAsset, error) {
-	tickerMap := s.indexer.GetAssetSummaryInAddressV3(address)
+// height 大于0时，返回该高度的同质化资产
+func (s *Model) GetAssetSummaryV3(address string, start int, limit int, height int) ([]*common.DisplayAsset, error) {
+	var tickerMap map[common.TickerName]*common.Decimal
+	if height > 0 {
+		var err error
+		tickerMap, err = s.indexer.GetAssetSummaryInAddressAtHeight(address, height)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		tickerMap = s.indexer.GetAssetSummaryInAddressV3(address)
+	}
 
 	result := make([]*common.DisplayAsset, 0)
 	for tickName, balance := range tickerMap {
@@ -126,10 +136,15 @@ func (s *Model) GetAssetSummaryV3(address string, start int, limit int) ([]*comm
 	return result, nil
 }
 
-func (s *Model) GetUtxoInfoV3(utxo string) (*common.AssetsInUtxo, error) {
+func (s *Model) GetUtxoInfoV3(utxo string, height int) (*common.AssetsInUtxo, error) {
 	if s.indexer.IsUtxoSpent(utxo) {
 		return nil, fmt.Errorf("utxo %s is spent", utxo)
 	}
+	if height > 0 {
+		if err := s.indexer.CheckUtxoAtHeight(utxo, height); err != nil {
+			return nil, err
+		}
+	}
 	ret := s.indexer.GetTxOutputWithUtxoV3(utxo, false)
 	if ret == nil {
 		return nil, fmt.Errorf("can't find utxo %s", utxo)
@@ -137,10 +152,10 @@ func (s *Model) GetUtxoInfoV3(utxo string) (*common.AssetsInUtxo, error) {
 	return ret, nil
 }
 
-func (s *Model) GetUtxoInfoListV3(req *rpcwire.UtxosReq) ([]*common.AssetsInUtxo, error) {
+func (s *Model) GetUtxoInfoListV3(req *rpcwire.UtxosReq, height int) ([]*common.AssetsInUtxo, error) {
 	result := make([]*common.AssetsInUtxo, 0)
 	for _, utxo := range req.Utxos {
-		txOutput, err := s.GetUtxoInfoV3(utxo)
+		txOutput, err := s.GetUtxoInfoV3(utxo, height)
 		if err != nil {
 			continue
 		}
@@ -203,11 +218,20 @@ func hasInvalidAsset(txOut *common.AssetsInUtxo, assetName *common.AssetName) bo
 	return false
 }
 
-func (s *Model) GetHolderListV3(tickName string, start, limit uint64) ([]*rpcwire.HolderV3, uint64, error) {
+func (s *Model) GetHolderListV3(tickName string, start, limit uint64, height int) ([]*rpcwire.HolderV3, uint64, error) {
 	result := make([]*rpcwire.HolderV3, 0)
 
 	assetName := common.NewAssetNameFromString(tickName)
-	holders := s.indexer.GetHoldersWithTickV2(assetName)
+	var holders map[uint64]*common.Decimal
+	if height > 0 {
+		var err error
+		holders, err = s.indexer.GetHoldersWithTickAtHeight(assetName, height)
+		if err != nil {
+			return nil, 0, err
+		}
+	} else {
+		holders = s.indexer.GetHoldersWithTickV2(assetName)
+	}
 
 	result = make([]*rpcwire.HolderV3, 0, len(holders))
 	for address, amt := range holders {
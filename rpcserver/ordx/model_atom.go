@@ -0,0 +1,63 @@
+package ordx
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+var atomicalIdPattern = regexp.MustCompile(`^[a-f0-9]{64}i[0-9]+$`)
+
+func checkAtomicalId(atomicalId string) error {
+	if !atomicalIdPattern.MatchString(atomicalId) {
+		return fmt.Errorf("invalid atomical id %s", atomicalId)
+	}
+	return nil
+}
+
+func (s *Model) GetAtomical(atomicalId string) (*common.AtomicalInfo, error) {
+	if err := checkAtomicalId(atomicalId); err != nil {
+		return nil, err
+	}
+	result := s.indexer.GetAtomical(atomicalId)
+	if result == nil {
+		return nil, fmt.Errorf("can't find atomical %s", atomicalId)
+	}
+	return result, nil
+}
+
+func (s *Model) ResolveRealm(name string) (*common.RealmResolution, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty realm name")
+	}
+	result := s.indexer.ResolveRealm(name)
+	if result == nil {
+		return nil, fmt.Errorf("atom indexer is disabled")
+	}
+	return result, nil
+}
+
+func (s *Model) GetSubrealms(atomicalId string, start, limit int) ([]*common.AtomicalInfo, int, error) {
+	if err := checkAtomicalId(atomicalId); err != nil {
+		return nil, 0, err
+	}
+	result, total := s.indexer.GetSubrealms(atomicalId, start, limit)
+	return result, total, nil
+}
+
+func (s *Model) GetAtomicalNftsWithAddress(address string, start, limit int) ([]*common.AtomicalInfo, int, error) {
+	if address == "" {
+		return nil, 0, fmt.Errorf("empty address")
+	}
+	result, total := s.indexer.GetAtomicalNftsWithAddress(address, start, limit)
+	return result, total, nil
+}
+
+func (s *Model) GetAtomicalEvents(atomicalId string, start, limit int) ([]*common.AtomicalEvent, int, error) {
+	if err := checkAtomicalId(atomicalId); err != nil {
+		return nil, 0, err
+	}
+	result, total := s.indexer.GetAtomicalEvents(atomicalId, start, limit)
+	return result, total, nil
+}
@@ -0,0 +1,65 @@
+package ordx
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+const maxActivityLimit = 1000
+
+func parseBRC20Ticker(ticker string) (string, error) {
+	assetName := common.NewAssetNameFromString(ticker)
+	if assetName.Protocol != common.PROTOCOL_NAME_BRC20 || assetName.Ticker == "" {
+		return "", fmt.Errorf("invalid brc20 ticker %s", ticker)
+	}
+	return assetName.Ticker, nil
+}
+
+func newActivityFilter(startHeight, endHeight, cursor, limit, order string) (*common.ActivityFilter, error) {
+	filter := &common.ActivityFilter{Cursor: cursor, Limit: 100}
+	var err error
+	if startHeight != "" {
+		if filter.StartHeight, err = strconv.Atoi(startHeight); err != nil {
+			return nil, fmt.Errorf("invalid start_height %s", startHeight)
+		}
+	}
+	if endHeight != "" {
+		if filter.EndHeight, err = strconv.Atoi(endHeight); err != nil {
+			return nil, fmt.Errorf("invalid end_height %s", endHeight)
+		}
+	}
+	if limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+	if filter.Limit > maxActivityLimit {
+		filter.Limit = maxActivityLimit
+	}
+	switch order {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return nil, fmt.Errorf("invalid order %s", order)
+	}
+	return filter, nil
+}
+
+func (s *Model) GetBRC20ActivityWithAddress(address, ticker string, filter *common.ActivityFilter) ([]*common.BRC20Activity, int, string, error) {
+	name, err := parseBRC20Ticker(ticker)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return s.indexer.GetBRC20ActivityWithAddress(address, name, filter)
+}
+
+func (s *Model) GetBRC20ActivityWithTicker(ticker string, filter *common.ActivityFilter) ([]*common.BRC20Activity, int, string, error) {
+	name, err := parseBRC20Ticker(ticker)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return s.indexer.GetBRC20ActivityWithTicker(name, filter)
+}
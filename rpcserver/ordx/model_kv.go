@@ -90,6 +90,10 @@ func (s *Model) PutKVs(req *rpcwire.PutKValueReq) error {
 }
 
 func (s *Model) DelKVs(req *rpcwire.DelKValueReq) error {
+	if len(req.Keys) != 0 {
+		return fmt.Errorf("unsigned keys are not supported, sign each deletion with a sequence in values")
+	}
+
 	now := time.Now().UnixMicro()
 	pkHex := hex.EncodeToString(req.PubKey)
 	t, ok := s.nonceMap[pkHex]
@@ -112,7 +116,35 @@ func (s *Model) DelKVs(req *rpcwire.DelKValueReq) error {
 		return fmt.Errorf("verify signature failed, %v", err)
 	}
 
-	return s.indexer.DelKVs(req.PubKey, req.Keys)
+	return s.indexer.DelKVs(req.Values)
+}
+
+func (s *Model) ListKVs(pubkey, prefix, cursor string, limit int) ([]*rpcwire.KeyValue, string, error) {
+	pk, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.indexer.ListKVs(pk, prefix, cursor, limit)
+}
+
+func (s *Model) GetKVHistory(pubkey, key string) ([]*rpcwire.KVChange, error) {
+	pk, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return s.indexer.GetKVHistory(pk, key)
+}
+
+func (s *Model) GetKVChanges(since uint64, limit int) ([]*rpcwire.KVChange, uint64, error) {
+	return s.indexer.GetKVChanges(since, limit)
+}
+
+func (s *Model) GetKVRecords(cursor string, limit int) ([]*rpcwire.KVChange, string, error) {
+	return s.indexer.GetKVRecords(cursor, limit)
+}
+
+func (s *Model) GetKVRegistrations() ([]*rpcwire.KVRegistration, error) {
+	return s.indexer.GetKVRegistrations()
 }
 
 func (s *Model) RegisterPubKey(req *rpcwire.RegisterPubKeyReq) (string, error) {
@@ -0,0 +1,462 @@
+package ordx
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
+)
+
+const dustValue = 330
+
+// 构造转账交易的中间状态，输入按顺序排列：资产 utxo 在前，白聪在后
+type transferBuilder struct {
+	model          *Model
+	name           *common.AssetName
+	info           *common.TickerInfo
+	address        string
+	pkScript       []byte
+	changePkScript []byte
+	feeRate        int64
+
+	inputs   []*common.AssetsInUtxo
+	used     map[string]bool
+	outputs  []*wire.TxOut
+	warnings []string
+}
+
+// BuildTransferPsbt 从索引和内存池中选择 utxo，构造没有签名的转账 psbt，并预览每个输出的资产
+func (s *Model) BuildTransferPsbt(req *rpcwire.TransferPsbtReq) (*rpcwire.TransferPsbtData, error) {
+	if req.FeeRate <= 0 {
+		return nil, fmt.Errorf("invalid fee rate %d", req.FeeRate)
+	}
+	if len(req.Recipients) == 0 {
+		return nil, fmt.Errorf("no recipients")
+	}
+	params := s.indexer.GetChainParam()
+	pkScript, err := common.AddrToPkScript(req.Address, params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %s, %v", req.Address, err)
+	}
+	changePkScript := pkScript
+	if req.ChangeAddress != "" {
+		changePkScript, err = common.AddrToPkScript(req.ChangeAddress, params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid change address %s, %v", req.ChangeAddress, err)
+		}
+	}
+
+	b := &transferBuilder{
+		model:          s,
+		name:           common.NewAssetNameFromString(req.Asset),
+		address:        req.Address,
+		pkScript:       pkScript,
+		changePkScript: changePkScript,
+		feeRate:        req.FeeRate,
+		used:           make(map[string]bool),
+	}
+	if !common.IsPlainAsset(b.name) {
+		b.info = s.indexer.GetTickerInfo(b.name)
+		if b.info == nil {
+			return nil, fmt.Errorf("can't find ticker %s", req.Asset)
+		}
+		// 用索引器中的名字，比如 runes 的 id 换成名字
+		b.name = &b.info.AssetName
+	}
+
+	recipients := make([]*wire.TxOut, 0, len(req.Recipients))
+	for _, r := range req.Recipients {
+		script, err := common.AddrToPkScript(r.Address, params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient address %s, %v", r.Address, err)
+		}
+		recipients = append(recipients, wire.NewTxOut(r.Value, script))
+	}
+
+	switch b.name.Protocol {
+	case "":
+		err = b.addPlain(req.Recipients, recipients)
+	case common.PROTOCOL_NAME_ORDX:
+		err = b.addBindingAsset(req.Recipients, recipients)
+	case common.PROTOCOL_NAME_RUNES:
+		err = b.addRunes(req.Recipients, recipients)
+	case common.PROTOCOL_NAME_BRC20:
+		err = b.addBRC20(req.Recipients, recipients)
+	default:
+		err = fmt.Errorf("unsupported asset %s", req.Asset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fee, err := b.fund()
+	if err != nil {
+		return nil, err
+	}
+	return b.build(fee)
+}
+
+func (b *transferBuilder) parseAmount(amount string) (*common.Decimal, error) {
+	precision := 0
+	if b.info != nil {
+		precision = b.info.Divisibility
+	}
+	amt, err := common.NewDecimalFromString(amount, precision)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %s, %v", amount, err)
+	}
+	if amt.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount %s", amount)
+	}
+	return amt, nil
+}
+
+func (b *transferBuilder) addInput(utxo *common.AssetsInUtxo) {
+	b.inputs = append(b.inputs, utxo)
+	b.used[utxo.OutPoint] = true
+}
+
+// 已经被内存池中的交易花费或者已经选中的 utxo 不能再用
+func (b *transferBuilder) available(utxo *common.AssetsInUtxo) bool {
+	return !b.used[utxo.OutPoint] && !b.model.indexer.IsUtxoSpent(utxo.OutPoint)
+}
+
+func (b *transferBuilder) assetUtxos() ([]*common.AssetsInUtxo, error) {
+	utxos, err := b.model.indexer.GetAssetUTXOsInAddressWithTickV3(b.address, b.name, false)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*common.AssetsInUtxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		if b.available(utxo) {
+			result = append(result, utxo)
+		}
+	}
+	return result, nil
+}
+
+func (b *transferBuilder) findAsset(utxo *common.AssetsInUtxo) *common.DisplayAsset {
+	for _, asset := range utxo.Assets {
+		if asset.AssetName == *b.name && !asset.Invalid {
+			return asset
+		}
+	}
+	return nil
+}
+
+func (b *transferBuilder) addPlain(req []*rpcwire.TransferPsbtRecipient, recipients []*wire.TxOut) error {
+	for i, r := range req {
+		amt, err := b.parseAmount(r.Amount)
+		if err != nil {
+			return err
+		}
+		if amt.Int64() < dustValue {
+			return fmt.Errorf("not allow send %d sats", amt.Int64())
+		}
+		recipients[i].Value = amt.Int64()
+		b.outputs = append(b.outputs, recipients[i])
+	}
+	return nil
+}
+
+// ordx 的 ft 和稀有聪，资产绑定在聪上，按照 offset 切分输入的聪
+func (b *transferBuilder) addBindingAsset(req []*rpcwire.TransferPsbtRecipient, recipients []*wire.TxOut) error {
+	amts := make([]*common.Decimal, 0, len(req))
+	var required *common.Decimal
+	for _, r := range req {
+		amt, err := b.parseAmount(r.Amount)
+		if err != nil {
+			return err
+		}
+		amts = append(amts, amt)
+		required = required.Add(amt)
+	}
+
+	utxos, err := b.assetUtxos()
+	if err != nil {
+		return err
+	}
+	// 所有选中的输入拼接成一个大的输出，再按资产数量切分
+	total := common.NewTxOutput(0)
+	var selected *common.Decimal
+	for _, utxo := range utxos {
+		if selected.Cmp(required) >= 0 {
+			break
+		}
+		// 只用单一资产的 utxo，避免其他资产跟着转出去
+		asset := b.findAsset(utxo)
+		if asset == nil || len(utxo.Assets) != 1 || asset.BindingSat == 0 {
+			continue
+		}
+		if err := total.Append(utxo.ToTxOutput()); err != nil {
+			return err
+		}
+		b.addInput(utxo)
+		selected = selected.Add(&asset.ToAssetInfo().Amount)
+	}
+	if selected.Cmp(required) < 0 {
+		return fmt.Errorf("insufficient %s, required %s, available %s",
+			b.name.String(), required.String(), selected.String())
+	}
+
+	for i, amt := range amts {
+		part, rest, err := total.Split(b.name, 0, amt)
+		if err != nil {
+			return fmt.Errorf("can't split %s %s, %v", amt.String(), b.name.String(), err)
+		}
+		recipients[i].Value = part.Value()
+		b.outputs = append(b.outputs, recipients[i])
+		if rest == nil {
+			rest = common.NewTxOutput(0)
+		}
+		total = rest
+	}
+
+	// 剩余的资产找零，不足 330 聪时由后面的白聪补足
+	if total.HasAsset() {
+		value := total.Value()
+		if value < dustValue {
+			value = dustValue
+		}
+		b.outputs = append(b.outputs, wire.NewTxOut(value, b.changePkScript))
+	}
+	return nil
+}
+
+// runes 的每个接收者一个 edict，剩余的 runes 由 pointer 指向找零输出
+func (b *transferBuilder) addRunes(req []*rpcwire.TransferPsbtRecipient, recipients []*wire.TxOut) error {
+	runeId, err := runestone.RuneIdFromString(b.info.DisplayName)
+	if err != nil {
+		return err
+	}
+
+	edicts := make([]runestone.Edict, 0, len(req))
+	var required *common.Decimal
+	for i, r := range req {
+		amt, err := b.parseAmount(r.Amount)
+		if err != nil {
+			return err
+		}
+		required = required.Add(amt)
+		if recipients[i].Value < dustValue {
+			recipients[i].Value = dustValue
+		}
+		b.outputs = append(b.outputs, recipients[i])
+		edicts = append(edicts, runestone.Edict{ID: *runeId, Amount: amt.ToUint128(), Output: uint32(i)})
+	}
+
+	utxos, err := b.assetUtxos()
+	if err != nil {
+		return err
+	}
+	var selected *common.Decimal
+	otherRunes := false
+	for _, utxo := range utxos {
+		if selected.Cmp(required) >= 0 {
+			break
+		}
+		// 输入的聪会流向第一个输出，不能带有绑定在聪上的资产或者铭文
+		asset := b.findAsset(utxo)
+		if asset == nil || !onlyRunes(utxo) {
+			continue
+		}
+		b.addInput(utxo)
+		selected = selected.Add(&asset.ToAssetInfo().Amount)
+		if len(utxo.Assets) > 1 {
+			otherRunes = true
+		}
+	}
+	if selected.Cmp(required) < 0 {
+		return fmt.Errorf("insufficient %s, required %s, available %s",
+			b.name.String(), required.String(), selected.String())
+	}
+
+	stone := &runestone.Runestone{Edicts: edicts}
+	if otherRunes || selected.Cmp(required) > 0 {
+		pointer := uint32(len(b.outputs))
+		stone.Pointer = &pointer
+		b.outputs = append(b.outputs, wire.NewTxOut(dustValue, b.changePkScript))
+	}
+	script, err := stone.Encipher()
+	if err != nil {
+		return err
+	}
+	b.outputs = append(b.outputs, wire.NewTxOut(0, script))
+	return nil
+}
+
+func onlyRunes(utxo *common.AssetsInUtxo) bool {
+	for _, asset := range utxo.Assets {
+		if asset.Protocol != common.PROTOCOL_NAME_RUNES {
+			return false
+		}
+	}
+	return true
+}
+
+// brc20 需要已经铭刻好的 transfer 铭文，每个接收者对应一个数量相同的 transfer 铭文，整个 utxo 转给接收者
+func (b *transferBuilder) addBRC20(req []*rpcwire.TransferPsbtRecipient, recipients []*wire.TxOut) error {
+	utxos, err := b.assetUtxos()
+	if err != nil {
+		return err
+	}
+	for i, r := range req {
+		amt, err := b.parseAmount(r.Amount)
+		if err != nil {
+			return err
+		}
+		var found *common.AssetsInUtxo
+		for _, utxo := range utxos {
+			if !b.used[utxo.OutPoint] && b.isTransferInscription(utxo, amt) {
+				found = utxo
+				break
+			}
+		}
+		if found == nil {
+			return fmt.Errorf("no transfer inscription of %s %s, inscribe a transfer first",
+				amt.String(), b.name.String())
+		}
+		b.addInput(found)
+		recipients[i].Value = found.Value
+		b.outputs = append(b.outputs, recipients[i])
+	}
+	return nil
+}
+
+// utxo 中只有一个数量为 amt 的 transfer 铭文，其他资产只能是铭文本身
+func (b *transferBuilder) isTransferInscription(utxo *common.AssetsInUtxo, amt *common.Decimal) bool {
+	asset := b.findAsset(utxo)
+	if asset == nil || len(asset.OffsetToAmts) != 1 {
+		return false
+	}
+	for _, other := range utxo.Assets {
+		if other != asset && other.Type != common.ASSET_TYPE_NFT {
+			return false
+		}
+	}
+	transfer, err := common.NewDecimalFromString(asset.OffsetToAmts[0].Amount, asset.Precision)
+	if err != nil {
+		return false
+	}
+	return transfer.Cmp(amt) == 0
+}
+
+// 估算的虚拟大小，所有输入都来自同一个地址
+func (b *transferBuilder) vsize(inputs int, outputs []*wire.TxOut) int64 {
+	var inputSize int64
+	switch txscript.GetScriptClass(b.pkScript) {
+	case txscript.WitnessV1TaprootTy:
+		inputSize = 58
+	case txscript.WitnessV0PubKeyHashTy:
+		inputSize = 68
+	case txscript.ScriptHashTy:
+		inputSize = 91 // p2sh-p2wpkh
+	default:
+		inputSize = 148
+	}
+	size := int64(11) + int64(inputs)*inputSize
+	for _, out := range outputs {
+		size += int64(9 + len(out.PkScript))
+	}
+	return size
+}
+
+// fund 选择白聪支付输出和手续费，找零不足 330 聪时作为手续费
+func (b *transferBuilder) fund() (int64, error) {
+	var inputValue, outputValue int64
+	for _, input := range b.inputs {
+		inputValue += input.Value
+	}
+	for _, output := range b.outputs {
+		outputValue += output.Value
+	}
+
+	var plains []*common.AssetsInUtxo
+	loaded := false
+	for {
+		fee := b.vsize(len(b.inputs), b.outputs) * b.feeRate
+		if inputValue >= outputValue+fee {
+			break
+		}
+		if !loaded {
+			utxos, err := b.model.indexer.GetAssetUTXOsInAddressWithTickV3(b.address, &common.ASSET_PLAIN_SAT, false)
+			if err != nil {
+				return 0, err
+			}
+			// 按聪数量从大到小排列，跳过带有铭文的 utxo
+			for _, utxo := range utxos {
+				if len(utxo.Assets) == 0 && b.available(utxo) {
+					plains = append(plains, utxo)
+				}
+			}
+			loaded = true
+		}
+		if len(plains) == 0 {
+			return 0, fmt.Errorf("insufficient btc, required %d, available %d", outputValue+fee, inputValue)
+		}
+		b.addInput(plains[0])
+		inputValue += plains[0].Value
+		plains = plains[1:]
+	}
+
+	change := wire.NewTxOut(0, b.changePkScript)
+	fee := b.vsize(len(b.inputs), append(b.outputs, change)) * b.feeRate
+	change.Value = inputValue - outputValue - fee
+	if change.Value >= dustValue {
+		b.outputs = append(b.outputs, change)
+		return fee, nil
+	}
+	return inputValue - outputValue, nil
+}
+
+func (b *transferBuilder) build(fee int64) (*rpcwire.TransferPsbtData, error) {
+	tx := wire.NewMsgTx(2)
+	for _, input := range b.inputs {
+		outpoint, err := wire.NewOutPointFromString(input.OutPoint)
+		if err != nil {
+			return nil, err
+		}
+		tx.AddTxIn(wire.NewTxIn(outpoint, nil, nil))
+	}
+	for _, output := range b.outputs {
+		tx.AddTxOut(output)
+	}
+
+	preview, err := b.model.indexer.SimulateTxAssetsFlow(tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.Burns) != 0 {
+		burn := preview.Burns[0]
+		return nil, fmt.Errorf("%s %s would be burned (%s)", burn.Amount, burn.AssetName.String(), burn.Reason)
+	}
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if txscript.IsWitnessProgram(b.pkScript) {
+		for i, input := range b.inputs {
+			packet.Inputs[i].WitnessUtxo = wire.NewTxOut(input.Value, b.pkScript)
+		}
+	} else {
+		b.warnings = append(b.warnings, "non-witness inputs, fill in the previous transactions before signing")
+	}
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcwire.TransferPsbtData{
+		Psbt:     encoded,
+		Fee:      fee,
+		VSize:    b.vsize(len(b.inputs), b.outputs),
+		Inputs:   b.inputs,
+		Preview:  preview,
+		Warnings: b.warnings,
+	}, nil
+}
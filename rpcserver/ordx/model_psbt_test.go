@@ -0,0 +1,235 @@
+package ordx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/indexer/runes/runestone"
+	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
+	"github.com/sat20-labs/indexer/share/base_indexer"
+	"lukechampine.com/uint128"
+)
+
+const (
+	psbtTestSender   = "bc1p9jh2caef2ejxnnh342s4eaddwzntqvxsc2cdrsa25pxykvkmgm2sy5ycc5"
+	psbtTestReceiver = "bc1pr0fst3mzyvdkpzatx2cs2cahqwsrnr4nm403kfnv2ec4nys6h5qqt77vxn"
+)
+
+// 只实现构造 psbt 用到的接口
+type psbtTestIndexer struct {
+	base_indexer.Indexer
+	tickers map[common.AssetName]*common.TickerInfo
+	utxos   []*common.AssetsInUtxo
+	spent   map[string]bool
+	burns   []*common.TxFlowAsset
+}
+
+func (p *psbtTestIndexer) GetChainParam() *chaincfg.Params {
+	return &chaincfg.MainNetParams
+}
+
+func (p *psbtTestIndexer) GetTickerInfo(name *common.TickerName) *common.TickerInfo {
+	return p.tickers[*name]
+}
+
+func (p *psbtTestIndexer) IsUtxoSpent(utxo string) bool {
+	return p.spent[utxo]
+}
+
+func (p *psbtTestIndexer) GetAssetUTXOsInAddressWithTickV3(address string, name *common.TickerName, includeInvalid bool) ([]*common.AssetsInUtxo, error) {
+	result := make([]*common.AssetsInUtxo, 0)
+	for _, utxo := range p.utxos {
+		if common.IsPlainAsset(name) {
+			if len(utxo.Assets) == 0 {
+				result = append(result, utxo)
+			}
+			continue
+		}
+		for _, asset := range utxo.Assets {
+			if asset.AssetName == *name {
+				result = append(result, utxo)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (p *psbtTestIndexer) SimulateTxAssetsFlow(tx *wire.MsgTx) (*common.TxAssetsFlow, error) {
+	return &common.TxAssetsFlow{TxId: tx.TxHash().String(), Complete: true, Burns: p.burns}, nil
+}
+
+func decodeTestPsbt(t *testing.T, data *rpcwire.TransferPsbtData) *psbt.Packet {
+	raw, err := base64.StdEncoding.DecodeString(data.Psbt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(raw), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packet
+}
+
+func checkTestFee(t *testing.T, packet *psbt.Packet, data *rpcwire.TransferPsbtData, feeRate int64) {
+	var in, out int64
+	for _, input := range packet.Inputs {
+		if input.WitnessUtxo == nil {
+			t.Fatal("missing witness utxo")
+		}
+		in += input.WitnessUtxo.Value
+	}
+	for _, output := range packet.UnsignedTx.TxOut {
+		out += output.Value
+	}
+	if in-out != data.Fee || data.Fee < data.VSize*feeRate {
+		t.Fatalf("unexpected fee %d, in %d out %d vsize %d", data.Fee, in, out, data.VSize)
+	}
+}
+
+func TestBuildTransferPsbtOrdx(t *testing.T) {
+	pearl := common.AssetName{Protocol: common.PROTOCOL_NAME_ORDX, Type: common.ASSET_TYPE_FT, Ticker: "pearl"}
+	indexer := &psbtTestIndexer{
+		tickers: map[common.AssetName]*common.TickerInfo{pearl: {AssetName: pearl, N: 1}},
+		utxos: []*common.AssetsInUtxo{
+			{
+				OutPoint: "1111111111111111111111111111111111111111111111111111111111111111:0",
+				Value:    1000,
+				Assets: []*common.DisplayAsset{{
+					AssetName:  pearl,
+					Amount:     "1000",
+					BindingSat: 1,
+					Offsets:    []*common.OffsetRange{{Start: 0, End: 1000}},
+				}},
+			},
+			{OutPoint: "2222222222222222222222222222222222222222222222222222222222222222:1", Value: 5000},
+			{OutPoint: "3333333333333333333333333333333333333333333333333333333333333333:0", Value: 20000},
+		},
+		spent: map[string]bool{"3333333333333333333333333333333333333333333333333333333333333333:0": true},
+	}
+	model := NewModel(indexer)
+
+	data, err := model.BuildTransferPsbt(&rpcwire.TransferPsbtReq{
+		Address:    psbtTestSender,
+		Asset:      "ordx:f:pearl",
+		Recipients: []*rpcwire.TransferPsbtRecipient{{Address: psbtTestReceiver, Amount: "600"}},
+		FeeRate:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := decodeTestPsbt(t, data)
+	tx := packet.UnsignedTx
+	// 资产输入在前，花费过的白聪不会被选中
+	if len(tx.TxIn) != 2 || tx.TxIn[0].PreviousOutPoint.Index != 0 || tx.TxIn[1].PreviousOutPoint.Index != 1 {
+		t.Fatalf("unexpected inputs %v", tx.TxIn)
+	}
+	// 接收者 600 聪，资产找零 400 聪，最后是白聪找零
+	if len(tx.TxOut) != 3 || tx.TxOut[0].Value != 600 || tx.TxOut[1].Value != 400 {
+		t.Fatalf("unexpected outputs %v", tx.TxOut)
+	}
+	checkTestFee(t, packet, data, 2)
+
+	// 资产不足
+	if _, err = model.BuildTransferPsbt(&rpcwire.TransferPsbtReq{
+		Address:    psbtTestSender,
+		Asset:      "ordx:f:pearl",
+		Recipients: []*rpcwire.TransferPsbtRecipient{{Address: psbtTestReceiver, Amount: "1001"}},
+		FeeRate:    2,
+	}); err == nil {
+		t.Fatal("expected insufficient asset error")
+	}
+
+	indexer.burns = []*common.TxFlowAsset{{AssetName: pearl, Amount: "1", Output: -1, Reason: common.TX_FLOW_REASON_FEE}}
+	if _, err = model.BuildTransferPsbt(&rpcwire.TransferPsbtReq{
+		Address:    psbtTestSender,
+		Asset:      "ordx:f:pearl",
+		Recipients: []*rpcwire.TransferPsbtRecipient{{Address: psbtTestReceiver, Amount: "600"}},
+		FeeRate:    2,
+	}); err == nil {
+		t.Fatal("expected burn error")
+	}
+}
+
+func TestBuildTransferPsbtRunes(t *testing.T) {
+	runeName := common.AssetName{Protocol: common.PROTOCOL_NAME_RUNES, Type: common.ASSET_TYPE_FT, Ticker: "TEST•RUNE"}
+	indexer := &psbtTestIndexer{
+		tickers: map[common.AssetName]*common.TickerInfo{runeName: {AssetName: runeName, DisplayName: "840000:3", Divisibility: 2}},
+		utxos: []*common.AssetsInUtxo{
+			{
+				OutPoint: "1111111111111111111111111111111111111111111111111111111111111111:0",
+				Value:    546,
+				Assets:   []*common.DisplayAsset{{AssetName: runeName, Amount: "10.00", Precision: 2}},
+			},
+			{OutPoint: "2222222222222222222222222222222222222222222222222222222222222222:1", Value: 5000},
+		},
+	}
+	model := NewModel(indexer)
+
+	data, err := model.BuildTransferPsbt(&rpcwire.TransferPsbtReq{
+		Address:    psbtTestSender,
+		Asset:      "runes:f:TEST•RUNE",
+		Recipients: []*rpcwire.TransferPsbtRecipient{{Address: psbtTestReceiver, Amount: "2.5"}},
+		FeeRate:    3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := decodeTestPsbt(t, data)
+	tx := packet.UnsignedTx
+	// 接收者，runes 找零，runestone，白聪找零
+	if len(tx.TxIn) != 2 || len(tx.TxOut) != 4 || tx.TxOut[0].Value != 330 || tx.TxOut[1].Value != 330 || tx.TxOut[2].Value != 0 {
+		t.Fatalf("unexpected tx %v %v", tx.TxIn, tx.TxOut)
+	}
+	artifact, err := (&runestone.Runestone{}).DecipherFromTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stone := artifact.Runestone
+	if stone == nil || stone.Pointer == nil || *stone.Pointer != 1 || len(stone.Edicts) != 1 {
+		t.Fatalf("unexpected runestone %+v", artifact)
+	}
+	edict := stone.Edicts[0]
+	if edict.ID != (runestone.RuneId{Block: 840000, Tx: 3}) || edict.Amount != uint128.From64(250) || edict.Output != 0 {
+		t.Fatalf("unexpected edict %+v", edict)
+	}
+	checkTestFee(t, packet, data, 3)
+}
+
+func TestBuildTransferPsbtPlain(t *testing.T) {
+	indexer := &psbtTestIndexer{
+		utxos: []*common.AssetsInUtxo{
+			{OutPoint: "1111111111111111111111111111111111111111111111111111111111111111:0", Value: 1000},
+			{OutPoint: "2222222222222222222222222222222222222222222222222222222222222222:1", Value: 800},
+		},
+	}
+	model := NewModel(indexer)
+
+	data, err := model.BuildTransferPsbt(&rpcwire.TransferPsbtReq{
+		Address:    psbtTestSender,
+		Recipients: []*rpcwire.TransferPsbtRecipient{{Address: psbtTestReceiver, Amount: "1500"}},
+		FeeRate:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet := decodeTestPsbt(t, data)
+	// 找零不足 330 聪，作为手续费
+	if len(packet.UnsignedTx.TxIn) != 2 || len(packet.UnsignedTx.TxOut) != 1 || packet.UnsignedTx.TxOut[0].Value != 1500 {
+		t.Fatalf("unexpected tx %v", packet.UnsignedTx.TxOut)
+	}
+	checkTestFee(t, packet, data, 1)
+
+	if _, err = model.BuildTransferPsbt(&rpcwire.TransferPsbtReq{
+		Address:    psbtTestSender,
+		Recipients: []*rpcwire.TransferPsbtRecipient{{Address: psbtTestReceiver, Amount: "329"}},
+		FeeRate:    1,
+	}); err == nil {
+		t.Fatal("expected dust error")
+	}
+}
@@ -0,0 +1,35 @@
+package ordx
+
+import (
+	"fmt"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// ticker 格式 runes:f:name 或者 runes:f:block:tx
+func parseRunesTicker(ticker string) (string, error) {
+	assetName := common.NewAssetNameFromString(ticker)
+	if assetName.Protocol != common.PROTOCOL_NAME_RUNES || assetName.Ticker == "" {
+		return "", fmt.Errorf("invalid runes ticker %s", ticker)
+	}
+	return assetName.Ticker, nil
+}
+
+func (s *Model) GetRunesActivityWithAddress(address, ticker string, filter *common.ActivityFilter) ([]*common.RunesActivity, int, string, error) {
+	name := ""
+	if ticker != "" {
+		var err error
+		if name, err = parseRunesTicker(ticker); err != nil {
+			return nil, 0, "", err
+		}
+	}
+	return s.indexer.GetRunesActivityWithAddress(address, name, filter)
+}
+
+func (s *Model) GetRunesActivityWithTicker(ticker string, filter *common.ActivityFilter) ([]*common.RunesActivity, int, string, error) {
+	name, err := parseRunesTicker(ticker)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return s.indexer.GetRunesActivityWithTicker(name, filter)
+}
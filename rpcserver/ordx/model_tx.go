@@ -0,0 +1,104 @@
+package ordx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sat20-labs/indexer/common"
+	rpcwire "github.com/sat20-labs/indexer/rpcserver/wire"
+)
+
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+func (s *Model) GetTxAssetsFlow(txid string) (*common.TxAssetsFlow, error) {
+	if len(txid) != 64 {
+		return nil, fmt.Errorf("invalid txid %s", txid)
+	}
+	return s.indexer.GetTxAssetsFlow(txid)
+}
+
+func (s *Model) SimulateTxAssetsFlow(req *rpcwire.TxAssetsFlowReq) (*common.TxAssetsFlow, error) {
+	tx, err := decodeTxOrPsbt(req.Tx)
+	if err != nil {
+		return nil, err
+	}
+	return s.indexer.SimulateTxAssetsFlow(tx)
+}
+
+func decodeTxOrPsbt(raw string) (*wire.MsgTx, error) {
+	raw = strings.TrimSpace(raw)
+	data, err := hex.DecodeString(raw)
+	if err != nil {
+		data, err = base64.StdEncoding.DecodeString(raw)
+		if err != nil || !bytes.HasPrefix(data, psbtMagic) {
+			return nil, fmt.Errorf("tx should be a hex raw tx, or a hex/base64 psbt")
+		}
+	}
+
+	if bytes.HasPrefix(data, psbtMagic) {
+		packet, err := psbt.NewFromRawBytes(bytes.NewReader(data), false)
+		if err != nil {
+			return nil, err
+		}
+		return psbtToMsgTx(packet)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// 铭文在 witness 中，没有签名的 psbt 需要从 taproot 脚本中恢复 witness
+func psbtToMsgTx(packet *psbt.Packet) (*wire.MsgTx, error) {
+	tx := packet.UnsignedTx.Copy()
+	for i, input := range packet.Inputs {
+		if i >= len(tx.TxIn) {
+			break
+		}
+		if len(input.FinalScriptWitness) > 0 {
+			witness, err := readWitness(input.FinalScriptWitness)
+			if err != nil {
+				return nil, err
+			}
+			tx.TxIn[i].Witness = witness
+			continue
+		}
+		if len(input.TaprootLeafScript) > 0 {
+			leaf := input.TaprootLeafScript[0]
+			tx.TxIn[i].Witness = wire.TxWitness{
+				make([]byte, 64), // 签名的占位
+				leaf.Script,
+				leaf.ControlBlock,
+			}
+		}
+	}
+	return tx, nil
+}
+
+func readWitness(data []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(data)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	// 数量和长度都来自请求，不能超过数据本身的长度
+	if count > uint64(len(data)) {
+		return nil, fmt.Errorf("witness item count %d exceeds data length %d", count, len(data))
+	}
+	witness := make(wire.TxWitness, 0, count)
+	for i := uint64(0); i < count; i++ {
+		item, err := wire.ReadVarBytes(r, 0, uint32(r.Len()), "witness")
+		if err != nil {
+			return nil, err
+		}
+		witness = append(witness, item)
+	}
+	return witness, nil
+}
@@ -0,0 +1,40 @@
+package ordx
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestReadWitness(t *testing.T) {
+	var buf bytes.Buffer
+	witness := wire.TxWitness{make([]byte, 64), []byte{0x51}, {}}
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(witness))); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&buf, 0, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := readWitness(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != len(witness) || !bytes.Equal(result[1], witness[1]) {
+		t.Fatalf("unexpected witness %x", result)
+	}
+
+	// 数量或者长度超过数据本身
+	invalid := map[string][]byte{
+		"huge count": {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+		"huge item":  {0x01, 0xfe, 0xff, 0xff, 0xff, 0x7f},
+		"short item": {0x01, 0x05, 0x01, 0x02},
+	}
+	for name, data := range invalid {
+		if _, err := readWitness(data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
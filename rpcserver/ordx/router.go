@@ -2,6 +2,7 @@ package ordx
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/sat20-labs/indexer/config"
 	"github.com/sat20-labs/indexer/share/base_indexer"
 )
 
@@ -50,41 +51,59 @@ func (s *Service) InitRouter(r *gin.Engine, proxy string) {
 	r.GET(proxy+"/deploy/mintable/:protocol", s.handle.getMintableTickers)
 	r.POST(proxy+"/collection", s.handle.addCollection)
 
+	// 关闭的协议不提供接口
+	indexer := s.handle.model.indexer
+
 	// ft
-	// 所有ticker的数据
-	r.GET(proxy+"/tick/status", s.handle.getTickerStatusList)
-	// 某个ticker的数据
-	r.GET(proxy+"/tick/info/:ticker", s.handle.getTickerStatus)
-	// 获取某个ticker的持有人和持有数量列表
-	r.GET(proxy+"/tick/holders/:ticker", s.handle.getHolderList)
-	// 获取某个铭文的铸造历史记录
-	r.GET(proxy+"/tick/history/:ticker", s.handle.getMintHistory)
-	// 获取某个ticker已经被拆分的nft列表
-	r.GET(proxy+"/splittedInscriptions/:ticker", s.handle.getSplittedInscriptionList)
-	r.GET(proxy+"/mint/details/:inscriptionid", s.handle.getMintDetailInfo)
-	r.GET(proxy+"/mint/permission/:ticker/:address", s.handle.getMintPermission)
-	r.GET(proxy+"/fee/discount/:address", s.handle.getFeeInfo)
+	if indexer.IsProtocolEnabled(config.PROTOCOL_FT) {
+		// 所有ticker的数据
+		r.GET(proxy+"/tick/status", s.handle.getTickerStatusList)
+		// 某个ticker的数据
+		r.GET(proxy+"/tick/info/:ticker", s.handle.getTickerStatus)
+		// 获取某个ticker的持有人和持有数量列表
+		r.GET(proxy+"/tick/holders/:ticker", s.handle.getHolderList)
+		// 获取某个铭文的铸造历史记录
+		r.GET(proxy+"/tick/history/:ticker", s.handle.getMintHistory)
+		// 获取某个ticker已经被拆分的nft列表
+		r.GET(proxy+"/splittedInscriptions/:ticker", s.handle.getSplittedInscriptionList)
+		r.GET(proxy+"/mint/details/:inscriptionid", s.handle.getMintDetailInfo)
+		r.GET(proxy+"/mint/permission/:ticker/:address", s.handle.getMintPermission)
+		r.GET(proxy+"/fee/discount/:address", s.handle.getFeeInfo)
+	}
 
 	// 名字服务
-	r.GET(proxy+"/ns/status", s.handle.getNSStatus)
-	r.GET(proxy+"/ns/name/:name", s.handle.getNameInfo)
-	r.GET(proxy+"/ns/values/:name/:prefix", s.handle.getNameValues)
-	r.GET(proxy+"/ns/routing/:name", s.handle.getNameRouting)
-	r.GET(proxy+"/ns/address/:address", s.handle.getNamesWithAddress)
-	r.GET(proxy+"/ns/address/:address/:sub", s.handle.getNamesWithAddress)
-	r.GET(proxy+"/ns/address/:address/:sub/:filters", s.handle.getNamesWithFilters)
-	r.GET(proxy+"/ns/sat/:sat", s.handle.getNamesWithSat)
-	r.GET(proxy+"/ns/inscription/:id", s.handle.getNameWithInscriptionId)
-	r.POST(proxy+"/ns/check", s.handle.checkNames)
+	if indexer.IsProtocolEnabled(config.PROTOCOL_NS) {
+		r.GET(proxy+"/ns/status", s.handle.getNSStatus)
+		r.GET(proxy+"/ns/name/:name", s.handle.getNameInfo)
+		r.GET(proxy+"/ns/values/:name/:prefix", s.handle.getNameValues)
+		r.GET(proxy+"/ns/routing/:name", s.handle.getNameRouting)
+		r.GET(proxy+"/ns/address/:address", s.handle.getNamesWithAddress)
+		r.GET(proxy+"/ns/address/:address/:sub", s.handle.getNamesWithAddress)
+		r.GET(proxy+"/ns/address/:address/:sub/:filters", s.handle.getNamesWithFilters)
+		r.GET(proxy+"/ns/sat/:sat", s.handle.getNamesWithSat)
+		r.GET(proxy+"/ns/inscription/:id", s.handle.getNameWithInscriptionId)
+		r.POST(proxy+"/ns/check", s.handle.checkNames)
+	}
 
 	// nft
-	r.GET(proxy+"/nft/status", s.handle.getNftStatus)
-	r.GET(proxy+"/nft/nftid/:id", s.handle.getNftInfo)
-	r.GET(proxy+"/nft/address/:address", s.handle.getNftsWithAddress)
-	r.GET(proxy+"/nft/sat/:sat", s.handle.getNftsWithSat)
-	r.GET(proxy+"/nft/inscription/:id", s.handle.getNftWithInscriptionId)
-	r.GET(proxy+"/nft/gallery/:id", s.handle.getGallery)
-	r.GET(proxy+"/nft/collection/:id", s.handle.getCollection)
+	if indexer.IsProtocolEnabled(config.PROTOCOL_NFT) {
+		r.GET(proxy+"/nft/status", s.handle.getNftStatus)
+		r.GET(proxy+"/nft/nftid/:id", s.handle.getNftInfo)
+		r.GET(proxy+"/nft/address/:address", s.handle.getNftsWithAddress)
+		r.GET(proxy+"/nft/sat/:sat", s.handle.getNftsWithSat)
+		r.GET(proxy+"/nft/inscription/:id", s.handle.getNftWithInscriptionId)
+		r.GET(proxy+"/nft/gallery/:id", s.handle.getGallery)
+		r.GET(proxy+"/nft/collection/:id", s.handle.getCollection)
+	}
+
+	// atomicals nft, realm, subrealm
+	if indexer.IsProtocolEnabled(config.PROTOCOL_ATOM) {
+		r.GET(proxy+"/v3/atom/atomical/:id", s.handle.getAtomical)
+		r.GET(proxy+"/v3/atom/realm/:name", s.handle.resolveRealm)
+		r.GET(proxy+"/v3/atom/subrealms/:id", s.handle.getSubrealms)
+		r.GET(proxy+"/v3/atom/nfts/address/:address", s.handle.getAtomicalNftsWithAddress)
+		r.GET(proxy+"/v3/atom/events/:id", s.handle.getAtomicalEvents)
+	}
 
 	/////////////////////////////////////////
 	// version 2.0 interface for STP
@@ -110,14 +129,45 @@ func (s *Service) InitRouter(r *gin.Engine, proxy string) {
 	r.GET(proxy+"/v3/tick/holders/:ticker", s.handle.getHolderListV3)
 	// // 铸造历史
 	r.GET(proxy+"/v3/tick/history/:ticker", s.handle.getMintHistoryV3)
+
+	// brc20 活动历史，支持 start_height, end_height, cursor, limit, order 参数
+	if indexer.IsProtocolEnabled(config.PROTOCOL_BRC20) {
+		r.GET(proxy+"/v3/address/activity/:address/:ticker", s.handle.getBRC20ActivityWithAddress)
+		r.GET(proxy+"/v3/tick/activity/:ticker", s.handle.getBRC20ActivityWithTicker)
+	}
+
+	// runes 活动历史，参数同上，地址查询可以用 ticker 参数过滤
+	if indexer.IsProtocolEnabled(config.PROTOCOL_RUNES) {
+		r.GET(proxy+"/v3/runes/address/activity/:address", s.handle.getRunesActivityWithAddress)
+		r.GET(proxy+"/v3/runes/activity/:ticker", s.handle.getRunesActivityWithTicker)
+	}
+
+	// 交易中资产从输入到输出的流向，包括销毁和失效的资产
+	r.GET(proxy+"/v3/tx/assets/:txid", s.handle.getTxAssetsFlow)
+	// 预览未广播的交易（raw tx 或者 psbt），签名前检查是否会误烧资产
+	r.POST(proxy+"/v3/tx/assets", s.handle.simulateTxAssetsFlow)
+	// 构造资产转账的 psbt，由索引器选择 utxo 并切分绑定的聪，返回未签名的 psbt 和资产预览
+	r.POST(proxy+"/v3/psbt/transfer", s.handle.buildTransferPsbt)
 	// // 某条铸造记录
 	// r.GET(proxy+"/v3/mint/details/:ticker/:id", s.handle.getMintDetailInfo)
 
+	// 实时事件推送：区块、重组、资产部署/铸造/转移、内存池交易
+	// 过滤参数：types, address, ticker, protocol
+	r.GET(proxy+"/v3/events", s.handle.streamEventsSSE)
+	r.GET(proxy+"/v3/events/ws", s.handle.streamEventsWS)
+
 	// kv记录
 	r.POST(proxy+"/kv/nonce", s.handle.getNonce)
 	r.GET(proxy+"/kv/get/:pubkey/:key", s.handle.getkv)
 	r.POST(proxy+"/kv/put", s.handle.putKVs)
 	r.POST(proxy+"/kv/del", s.handle.delKVs)
+	// 按前缀列出 key，key 的历史版本，所有修改（从 since 之后开始）
+	r.GET(proxy+"/kv/list/:pubkey", s.handle.listKVs)
+	r.GET(proxy+"/kv/history/:pubkey/:key", s.handle.getKVHistory)
+	r.GET(proxy+"/kv/changes", s.handle.getKVChanges)
+	// 其他索引器全量同步 kv 存储
+	r.GET(proxy+"/kv/records", s.handle.getKVRecords)
+	r.GET(proxy+"/kv/registrations", s.handle.getKVRegistrations)
 	// 注册公钥，并返回索引器公钥
 	r.POST(proxy+"/kv/register", s.handle.registerPubKey)
 	r.GET(proxy+"/v3/indexer/pubkey", s.handle.getIndexerPubKey)
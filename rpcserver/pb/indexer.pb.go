@@ -0,0 +1,2006 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        v3.21.12
+// source: rpcserver/pb/indexer.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AssetName struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Protocol      string                 `protobuf:"bytes,1,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Ticker        string                 `protobuf:"bytes,3,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssetName) Reset() {
+	*x = AssetName{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetName) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetName) ProtoMessage() {}
+
+func (x *AssetName) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetName.ProtoReflect.Descriptor instead.
+func (*AssetName) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AssetName) GetProtocol() string {
+	if x != nil {
+		return x.Protocol
+	}
+	return ""
+}
+
+func (x *AssetName) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AssetName) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+type OffsetRange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int64                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int64                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OffsetRange) Reset() {
+	*x = OffsetRange{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OffsetRange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OffsetRange) ProtoMessage() {}
+
+func (x *OffsetRange) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OffsetRange.ProtoReflect.Descriptor instead.
+func (*OffsetRange) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OffsetRange) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *OffsetRange) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+type OffsetToAmount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Offset        int64                  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Amount        string                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OffsetToAmount) Reset() {
+	*x = OffsetToAmount{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OffsetToAmount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OffsetToAmount) ProtoMessage() {}
+
+func (x *OffsetToAmount) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OffsetToAmount.ProtoReflect.Descriptor instead.
+func (*OffsetToAmount) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *OffsetToAmount) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *OffsetToAmount) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+type DisplayAsset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          *AssetName             `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Amount        string                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Precision     int32                  `protobuf:"varint,3,opt,name=precision,proto3" json:"precision,omitempty"`
+	BindingSat    int32                  `protobuf:"varint,4,opt,name=binding_sat,json=bindingSat,proto3" json:"binding_sat,omitempty"`
+	Offsets       []*OffsetRange         `protobuf:"bytes,5,rep,name=offsets,proto3" json:"offsets,omitempty"`
+	OffsetToAmts  []*OffsetToAmount      `protobuf:"bytes,6,rep,name=offset_to_amts,json=offsetToAmts,proto3" json:"offset_to_amts,omitempty"`
+	Invalid       bool                   `protobuf:"varint,7,opt,name=invalid,proto3" json:"invalid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisplayAsset) Reset() {
+	*x = DisplayAsset{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisplayAsset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisplayAsset) ProtoMessage() {}
+
+func (x *DisplayAsset) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisplayAsset.ProtoReflect.Descriptor instead.
+func (*DisplayAsset) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DisplayAsset) GetName() *AssetName {
+	if x != nil {
+		return x.Name
+	}
+	return nil
+}
+
+func (x *DisplayAsset) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *DisplayAsset) GetPrecision() int32 {
+	if x != nil {
+		return x.Precision
+	}
+	return 0
+}
+
+func (x *DisplayAsset) GetBindingSat() int32 {
+	if x != nil {
+		return x.BindingSat
+	}
+	return 0
+}
+
+func (x *DisplayAsset) GetOffsets() []*OffsetRange {
+	if x != nil {
+		return x.Offsets
+	}
+	return nil
+}
+
+func (x *DisplayAsset) GetOffsetToAmts() []*OffsetToAmount {
+	if x != nil {
+		return x.OffsetToAmts
+	}
+	return nil
+}
+
+func (x *DisplayAsset) GetInvalid() bool {
+	if x != nil {
+		return x.Invalid
+	}
+	return false
+}
+
+type AssetsInUtxo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UtxoId        uint64                 `protobuf:"varint,1,opt,name=utxo_id,json=utxoId,proto3" json:"utxo_id,omitempty"`
+	Outpoint      string                 `protobuf:"bytes,2,opt,name=outpoint,proto3" json:"outpoint,omitempty"`
+	Value         int64                  `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	PkScript      []byte                 `protobuf:"bytes,4,opt,name=pk_script,json=pkScript,proto3" json:"pk_script,omitempty"`
+	Assets        []*DisplayAsset        `protobuf:"bytes,5,rep,name=assets,proto3" json:"assets,omitempty"`
+	Confirmed     bool                   `protobuf:"varint,6,opt,name=confirmed,proto3" json:"confirmed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssetsInUtxo) Reset() {
+	*x = AssetsInUtxo{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssetsInUtxo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssetsInUtxo) ProtoMessage() {}
+
+func (x *AssetsInUtxo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssetsInUtxo.ProtoReflect.Descriptor instead.
+func (*AssetsInUtxo) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AssetsInUtxo) GetUtxoId() uint64 {
+	if x != nil {
+		return x.UtxoId
+	}
+	return 0
+}
+
+func (x *AssetsInUtxo) GetOutpoint() string {
+	if x != nil {
+		return x.Outpoint
+	}
+	return ""
+}
+
+func (x *AssetsInUtxo) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *AssetsInUtxo) GetPkScript() []byte {
+	if x != nil {
+		return x.PkScript
+	}
+	return nil
+}
+
+func (x *AssetsInUtxo) GetAssets() []*DisplayAsset {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
+}
+
+func (x *AssetsInUtxo) GetConfirmed() bool {
+	if x != nil {
+		return x.Confirmed
+	}
+	return false
+}
+
+// height 大于0时查询历史高度的数据
+type AddressSummaryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Height        int32                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddressSummaryRequest) Reset() {
+	*x = AddressSummaryRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddressSummaryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddressSummaryRequest) ProtoMessage() {}
+
+func (x *AddressSummaryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddressSummaryRequest.ProtoReflect.Descriptor instead.
+func (*AddressSummaryRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AddressSummaryRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *AddressSummaryRequest) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type AddressSummaryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Assets        []*DisplayAsset        `protobuf:"bytes,1,rep,name=assets,proto3" json:"assets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddressSummaryResponse) Reset() {
+	*x = AddressSummaryResponse{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddressSummaryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddressSummaryResponse) ProtoMessage() {}
+
+func (x *AddressSummaryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddressSummaryResponse.ProtoReflect.Descriptor instead.
+func (*AddressSummaryResponse) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *AddressSummaryResponse) GetAssets() []*DisplayAsset {
+	if x != nil {
+		return x.Assets
+	}
+	return nil
+}
+
+type UtxoInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Utxo          string                 `protobuf:"bytes,1,opt,name=utxo,proto3" json:"utxo,omitempty"`
+	Height        int32                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UtxoInfoRequest) Reset() {
+	*x = UtxoInfoRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UtxoInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UtxoInfoRequest) ProtoMessage() {}
+
+func (x *UtxoInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UtxoInfoRequest.ProtoReflect.Descriptor instead.
+func (*UtxoInfoRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UtxoInfoRequest) GetUtxo() string {
+	if x != nil {
+		return x.Utxo
+	}
+	return ""
+}
+
+func (x *UtxoInfoRequest) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type UtxosInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Utxos         []string               `protobuf:"bytes,1,rep,name=utxos,proto3" json:"utxos,omitempty"`
+	Height        int32                  `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UtxosInfoRequest) Reset() {
+	*x = UtxosInfoRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UtxosInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UtxosInfoRequest) ProtoMessage() {}
+
+func (x *UtxosInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UtxosInfoRequest.ProtoReflect.Descriptor instead.
+func (*UtxosInfoRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UtxosInfoRequest) GetUtxos() []string {
+	if x != nil {
+		return x.Utxos
+	}
+	return nil
+}
+
+func (x *UtxosInfoRequest) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+// 已经花费或者找不到的 utxo 不返回
+type UtxosInfoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Utxos         []*AssetsInUtxo        `protobuf:"bytes,1,rep,name=utxos,proto3" json:"utxos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UtxosInfoResponse) Reset() {
+	*x = UtxosInfoResponse{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UtxosInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UtxosInfoResponse) ProtoMessage() {}
+
+func (x *UtxosInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UtxosInfoResponse.ProtoReflect.Descriptor instead.
+func (*UtxosInfoResponse) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UtxosInfoResponse) GetUtxos() []*AssetsInUtxo {
+	if x != nil {
+		return x.Utxos
+	}
+	return nil
+}
+
+// 流式查询的结果，每个请求对应一个结果
+type UtxoInfoResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Utxo          string                 `protobuf:"bytes,1,opt,name=utxo,proto3" json:"utxo,omitempty"`
+	Info          *AssetsInUtxo          `protobuf:"bytes,2,opt,name=info,proto3" json:"info,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UtxoInfoResult) Reset() {
+	*x = UtxoInfoResult{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UtxoInfoResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UtxoInfoResult) ProtoMessage() {}
+
+func (x *UtxoInfoResult) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UtxoInfoResult.ProtoReflect.Descriptor instead.
+func (*UtxoInfoResult) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UtxoInfoResult) GetUtxo() string {
+	if x != nil {
+		return x.Utxo
+	}
+	return ""
+}
+
+func (x *UtxoInfoResult) GetInfo() *AssetsInUtxo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *UtxoInfoResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type TickerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ticker        string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TickerRequest) Reset() {
+	*x = TickerRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TickerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TickerRequest) ProtoMessage() {}
+
+func (x *TickerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TickerRequest.ProtoReflect.Descriptor instead.
+func (*TickerRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TickerRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+type TickerInfo struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            *AssetName             `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DisplayName     string                 `protobuf:"bytes,2,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Id              int64                  `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"`
+	Divisibility    int32                  `protobuf:"varint,4,opt,name=divisibility,proto3" json:"divisibility,omitempty"`
+	StartBlock      int32                  `protobuf:"varint,5,opt,name=start_block,json=startBlock,proto3" json:"start_block,omitempty"`
+	EndBlock        int32                  `protobuf:"varint,6,opt,name=end_block,json=endBlock,proto3" json:"end_block,omitempty"`
+	SelfMint        int32                  `protobuf:"varint,7,opt,name=self_mint,json=selfMint,proto3" json:"self_mint,omitempty"`
+	DeployHeight    int32                  `protobuf:"varint,8,opt,name=deploy_height,json=deployHeight,proto3" json:"deploy_height,omitempty"`
+	DeployBlocktime int64                  `protobuf:"varint,9,opt,name=deploy_blocktime,json=deployBlocktime,proto3" json:"deploy_blocktime,omitempty"`
+	DeployTx        string                 `protobuf:"bytes,10,opt,name=deploy_tx,json=deployTx,proto3" json:"deploy_tx,omitempty"`
+	Limit           string                 `protobuf:"bytes,11,opt,name=limit,proto3" json:"limit,omitempty"`
+	N               int32                  `protobuf:"varint,12,opt,name=n,proto3" json:"n,omitempty"`
+	TotalMinted     string                 `protobuf:"bytes,13,opt,name=total_minted,json=totalMinted,proto3" json:"total_minted,omitempty"`
+	MintTimes       int64                  `protobuf:"varint,14,opt,name=mint_times,json=mintTimes,proto3" json:"mint_times,omitempty"`
+	MaxSupply       string                 `protobuf:"bytes,15,opt,name=max_supply,json=maxSupply,proto3" json:"max_supply,omitempty"`
+	HoldersCount    int32                  `protobuf:"varint,16,opt,name=holders_count,json=holdersCount,proto3" json:"holders_count,omitempty"`
+	InscriptionId   string                 `protobuf:"bytes,17,opt,name=inscription_id,json=inscriptionId,proto3" json:"inscription_id,omitempty"`
+	InscriptionNum  int64                  `protobuf:"varint,18,opt,name=inscription_num,json=inscriptionNum,proto3" json:"inscription_num,omitempty"`
+	Description     string                 `protobuf:"bytes,19,opt,name=description,proto3" json:"description,omitempty"`
+	Rarity          string                 `protobuf:"bytes,20,opt,name=rarity,proto3" json:"rarity,omitempty"`
+	DeployAddress   string                 `protobuf:"bytes,21,opt,name=deploy_address,json=deployAddress,proto3" json:"deploy_address,omitempty"`
+	Content         []byte                 `protobuf:"bytes,22,opt,name=content,proto3" json:"content,omitempty"`
+	ContentType     string                 `protobuf:"bytes,23,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Delegate        string                 `protobuf:"bytes,24,opt,name=delegate,proto3" json:"delegate,omitempty"`
+	Status          int32                  `protobuf:"varint,25,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TickerInfo) Reset() {
+	*x = TickerInfo{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TickerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TickerInfo) ProtoMessage() {}
+
+func (x *TickerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TickerInfo.ProtoReflect.Descriptor instead.
+func (*TickerInfo) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TickerInfo) GetName() *AssetName {
+	if x != nil {
+		return x.Name
+	}
+	return nil
+}
+
+func (x *TickerInfo) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetDivisibility() int32 {
+	if x != nil {
+		return x.Divisibility
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetStartBlock() int32 {
+	if x != nil {
+		return x.StartBlock
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetEndBlock() int32 {
+	if x != nil {
+		return x.EndBlock
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetSelfMint() int32 {
+	if x != nil {
+		return x.SelfMint
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetDeployHeight() int32 {
+	if x != nil {
+		return x.DeployHeight
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetDeployBlocktime() int64 {
+	if x != nil {
+		return x.DeployBlocktime
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetDeployTx() string {
+	if x != nil {
+		return x.DeployTx
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetLimit() string {
+	if x != nil {
+		return x.Limit
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetTotalMinted() string {
+	if x != nil {
+		return x.TotalMinted
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetMintTimes() int64 {
+	if x != nil {
+		return x.MintTimes
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetMaxSupply() string {
+	if x != nil {
+		return x.MaxSupply
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetHoldersCount() int32 {
+	if x != nil {
+		return x.HoldersCount
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetInscriptionId() string {
+	if x != nil {
+		return x.InscriptionId
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetInscriptionNum() int64 {
+	if x != nil {
+		return x.InscriptionNum
+	}
+	return 0
+}
+
+func (x *TickerInfo) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetRarity() string {
+	if x != nil {
+		return x.Rarity
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetDeployAddress() string {
+	if x != nil {
+		return x.DeployAddress
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *TickerInfo) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetDelegate() string {
+	if x != nil {
+		return x.Delegate
+	}
+	return ""
+}
+
+func (x *TickerInfo) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+type HoldersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ticker        string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Start         uint64                 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Limit         uint64                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Height        int32                  `protobuf:"varint,4,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldersRequest) Reset() {
+	*x = HoldersRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldersRequest) ProtoMessage() {}
+
+func (x *HoldersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldersRequest.ProtoReflect.Descriptor instead.
+func (*HoldersRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *HoldersRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *HoldersRequest) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *HoldersRequest) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *HoldersRequest) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type Holder struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Balance       string                 `protobuf:"bytes,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Holder) Reset() {
+	*x = Holder{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Holder) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Holder) ProtoMessage() {}
+
+func (x *Holder) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Holder.ProtoReflect.Descriptor instead.
+func (*Holder) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Holder) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Holder) GetBalance() string {
+	if x != nil {
+		return x.Balance
+	}
+	return ""
+}
+
+type HoldersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         uint64                 `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Start         uint64                 `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Holders       []*Holder              `protobuf:"bytes,3,rep,name=holders,proto3" json:"holders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HoldersResponse) Reset() {
+	*x = HoldersResponse{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HoldersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HoldersResponse) ProtoMessage() {}
+
+func (x *HoldersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HoldersResponse.ProtoReflect.Descriptor instead.
+func (*HoldersResponse) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HoldersResponse) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *HoldersResponse) GetStart() uint64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *HoldersResponse) GetHolders() []*Holder {
+	if x != nil {
+		return x.Holders
+	}
+	return nil
+}
+
+type MintHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ticker        string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Start         int32                  `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MintHistoryRequest) Reset() {
+	*x = MintHistoryRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MintHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MintHistoryRequest) ProtoMessage() {}
+
+func (x *MintHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MintHistoryRequest.ProtoReflect.Descriptor instead.
+func (*MintHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *MintHistoryRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *MintHistoryRequest) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *MintHistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type MintHistoryItem struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	MintAddress    string                 `protobuf:"bytes,1,opt,name=mint_address,json=mintAddress,proto3" json:"mint_address,omitempty"`
+	HolderAddress  string                 `protobuf:"bytes,2,opt,name=holder_address,json=holderAddress,proto3" json:"holder_address,omitempty"`
+	Balance        string                 `protobuf:"bytes,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	InscriptionId  string                 `protobuf:"bytes,4,opt,name=inscription_id,json=inscriptionId,proto3" json:"inscription_id,omitempty"`
+	InscriptionNum int64                  `protobuf:"varint,5,opt,name=inscription_num,json=inscriptionNum,proto3" json:"inscription_num,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MintHistoryItem) Reset() {
+	*x = MintHistoryItem{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MintHistoryItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MintHistoryItem) ProtoMessage() {}
+
+func (x *MintHistoryItem) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MintHistoryItem.ProtoReflect.Descriptor instead.
+func (*MintHistoryItem) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *MintHistoryItem) GetMintAddress() string {
+	if x != nil {
+		return x.MintAddress
+	}
+	return ""
+}
+
+func (x *MintHistoryItem) GetHolderAddress() string {
+	if x != nil {
+		return x.HolderAddress
+	}
+	return ""
+}
+
+func (x *MintHistoryItem) GetBalance() string {
+	if x != nil {
+		return x.Balance
+	}
+	return ""
+}
+
+func (x *MintHistoryItem) GetInscriptionId() string {
+	if x != nil {
+		return x.InscriptionId
+	}
+	return ""
+}
+
+func (x *MintHistoryItem) GetInscriptionNum() int64 {
+	if x != nil {
+		return x.InscriptionNum
+	}
+	return 0
+}
+
+type MintHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ticker        string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	Start         int32                  `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	Items         []*MintHistoryItem     `protobuf:"bytes,4,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MintHistoryResponse) Reset() {
+	*x = MintHistoryResponse{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MintHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MintHistoryResponse) ProtoMessage() {}
+
+func (x *MintHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MintHistoryResponse.ProtoReflect.Descriptor instead.
+func (*MintHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *MintHistoryResponse) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *MintHistoryResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *MintHistoryResponse) GetStart() int32 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *MintHistoryResponse) GetItems() []*MintHistoryItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type NftRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InscriptionId string                 `protobuf:"bytes,1,opt,name=inscription_id,json=inscriptionId,proto3" json:"inscription_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NftRequest) Reset() {
+	*x = NftRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NftRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NftRequest) ProtoMessage() {}
+
+func (x *NftRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NftRequest.ProtoReflect.Descriptor instead.
+func (*NftRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *NftRequest) GetInscriptionId() string {
+	if x != nil {
+		return x.InscriptionId
+	}
+	return ""
+}
+
+type NftItem struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name               string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Sat                int64                  `protobuf:"varint,3,opt,name=sat,proto3" json:"sat,omitempty"`
+	Address            string                 `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	InscriptionId      string                 `protobuf:"bytes,5,opt,name=inscription_id,json=inscriptionId,proto3" json:"inscription_id,omitempty"`
+	Utxo               string                 `protobuf:"bytes,6,opt,name=utxo,proto3" json:"utxo,omitempty"`
+	Value              int64                  `protobuf:"varint,7,opt,name=value,proto3" json:"value,omitempty"`
+	Height             int32                  `protobuf:"varint,8,opt,name=height,proto3" json:"height,omitempty"`
+	Time               int64                  `protobuf:"varint,9,opt,name=time,proto3" json:"time,omitempty"`
+	InscriptionAddress string                 `protobuf:"bytes,10,opt,name=inscription_address,json=inscriptionAddress,proto3" json:"inscription_address,omitempty"`
+	CurseType          int32                  `protobuf:"varint,11,opt,name=curse_type,json=curseType,proto3" json:"curse_type,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *NftItem) Reset() {
+	*x = NftItem{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NftItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NftItem) ProtoMessage() {}
+
+func (x *NftItem) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NftItem.ProtoReflect.Descriptor instead.
+func (*NftItem) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *NftItem) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *NftItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *NftItem) GetSat() int64 {
+	if x != nil {
+		return x.Sat
+	}
+	return 0
+}
+
+func (x *NftItem) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *NftItem) GetInscriptionId() string {
+	if x != nil {
+		return x.InscriptionId
+	}
+	return ""
+}
+
+func (x *NftItem) GetUtxo() string {
+	if x != nil {
+		return x.Utxo
+	}
+	return ""
+}
+
+func (x *NftItem) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *NftItem) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *NftItem) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *NftItem) GetInscriptionAddress() string {
+	if x != nil {
+		return x.InscriptionAddress
+	}
+	return ""
+}
+
+func (x *NftItem) GetCurseType() int32 {
+	if x != nil {
+		return x.CurseType
+	}
+	return 0
+}
+
+type NftInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Item          *NftItem               `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	ContentType   []byte                 `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	Content       []byte                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	MetaProtocol  []byte                 `protobuf:"bytes,4,opt,name=meta_protocol,json=metaProtocol,proto3" json:"meta_protocol,omitempty"`
+	MetaData      []byte                 `protobuf:"bytes,5,opt,name=meta_data,json=metaData,proto3" json:"meta_data,omitempty"`
+	Parents       []string               `protobuf:"bytes,6,rep,name=parents,proto3" json:"parents,omitempty"`
+	Delegate      string                 `protobuf:"bytes,7,opt,name=delegate,proto3" json:"delegate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NftInfo) Reset() {
+	*x = NftInfo{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NftInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NftInfo) ProtoMessage() {}
+
+func (x *NftInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NftInfo.ProtoReflect.Descriptor instead.
+func (*NftInfo) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *NftInfo) GetItem() *NftItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *NftInfo) GetContentType() []byte {
+	if x != nil {
+		return x.ContentType
+	}
+	return nil
+}
+
+func (x *NftInfo) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *NftInfo) GetMetaProtocol() []byte {
+	if x != nil {
+		return x.MetaProtocol
+	}
+	return nil
+}
+
+func (x *NftInfo) GetMetaData() []byte {
+	if x != nil {
+		return x.MetaData
+	}
+	return nil
+}
+
+func (x *NftInfo) GetParents() []string {
+	if x != nil {
+		return x.Parents
+	}
+	return nil
+}
+
+func (x *NftInfo) GetDelegate() string {
+	if x != nil {
+		return x.Delegate
+	}
+	return ""
+}
+
+type NameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameRequest) Reset() {
+	*x = NameRequest{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameRequest) ProtoMessage() {}
+
+func (x *NameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameRequest.ProtoReflect.Descriptor instead.
+func (*NameRequest) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *NameRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type KVItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	InscriptionId string                 `protobuf:"bytes,3,opt,name=inscription_id,json=inscriptionId,proto3" json:"inscription_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVItem) Reset() {
+	*x = KVItem{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVItem) ProtoMessage() {}
+
+func (x *KVItem) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVItem.ProtoReflect.Descriptor instead.
+func (*KVItem) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *KVItem) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVItem) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KVItem) GetInscriptionId() string {
+	if x != nil {
+		return x.InscriptionId
+	}
+	return ""
+}
+
+type NameInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Item          *NftItem               `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Kvs           []*KVItem              `protobuf:"bytes,2,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameInfo) Reset() {
+	*x = NameInfo{}
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameInfo) ProtoMessage() {}
+
+func (x *NameInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_rpcserver_pb_indexer_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameInfo.ProtoReflect.Descriptor instead.
+func (*NameInfo) Descriptor() ([]byte, []int) {
+	return file_rpcserver_pb_indexer_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *NameInfo) GetItem() *NftItem {
+	if x != nil {
+		return x.Item
+	}
+	return nil
+}
+
+func (x *NameInfo) GetKvs() []*KVItem {
+	if x != nil {
+		return x.Kvs
+	}
+	return nil
+}
+
+var File_rpcserver_pb_indexer_proto protoreflect.FileDescriptor
+
+const file_rpcserver_pb_indexer_proto_rawDesc = "" +
+	"\n" +
+	"\x1arpcserver/pb/indexer.proto\x12\fpb.rpcserver\"S\n" +
+	"\tAssetName\x12\x1a\n" +
+	"\bprotocol\x18\x01 \x01(\tR\bprotocol\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06ticker\x18\x03 \x01(\tR\x06ticker\"5\n" +
+	"\vOffsetRange\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x03R\x03end\"@\n" +
+	"\x0eOffsetToAmount\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\tR\x06amount\"\xa5\x02\n" +
+	"\fDisplayAsset\x12+\n" +
+	"\x04name\x18\x01 \x01(\v2\x17.pb.rpcserver.AssetNameR\x04name\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\tR\x06amount\x12\x1c\n" +
+	"\tprecision\x18\x03 \x01(\x05R\tprecision\x12\x1f\n" +
+	"\vbinding_sat\x18\x04 \x01(\x05R\n" +
+	"bindingSat\x123\n" +
+	"\aoffsets\x18\x05 \x03(\v2\x19.pb.rpcserver.OffsetRangeR\aoffsets\x12B\n" +
+	"\x0eoffset_to_amts\x18\x06 \x03(\v2\x1c.pb.rpcserver.OffsetToAmountR\foffsetToAmts\x12\x18\n" +
+	"\ainvalid\x18\a \x01(\bR\ainvalid\"\xc8\x01\n" +
+	"\fAssetsInUtxo\x12\x17\n" +
+	"\autxo_id\x18\x01 \x01(\x04R\x06utxoId\x12\x1a\n" +
+	"\boutpoint\x18\x02 \x01(\tR\boutpoint\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x03R\x05value\x12\x1b\n" +
+	"\tpk_script\x18\x04 \x01(\fR\bpkScript\x122\n" +
+	"\x06assets\x18\x05 \x03(\v2\x1a.pb.rpcserver.DisplayAssetR\x06assets\x12\x1c\n" +
+	"\tconfirmed\x18\x06 \x01(\bR\tconfirmed\"I\n" +
+	"\x15AddressSummaryRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x05R\x06height\"L\n" +
+	"\x16AddressSummaryResponse\x122\n" +
+	"\x06assets\x18\x01 \x03(\v2\x1a.pb.rpcserver.DisplayAssetR\x06assets\"=\n" +
+	"\x0fUtxoInfoRequest\x12\x12\n" +
+	"\x04utxo\x18\x01 \x01(\tR\x04utxo\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x05R\x06height\"@\n" +
+	"\x10UtxosInfoRequest\x12\x14\n" +
+	"\x05utxos\x18\x01 \x03(\tR\x05utxos\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x05R\x06height\"E\n" +
+	"\x11UtxosInfoResponse\x120\n" +
+	"\x05utxos\x18\x01 \x03(\v2\x1a.pb.rpcserver.AssetsInUtxoR\x05utxos\"j\n" +
+	"\x0eUtxoInfoResult\x12\x12\n" +
+	"\x04utxo\x18\x01 \x01(\tR\x04utxo\x12.\n" +
+	"\x04info\x18\x02 \x01(\v2\x1a.pb.rpcserver.AssetsInUtxoR\x04info\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"'\n" +
+	"\rTickerRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\"\xa4\x06\n" +
+	"\n" +
+	"TickerInfo\x12+\n" +
+	"\x04name\x18\x01 \x01(\v2\x17.pb.rpcserver.AssetNameR\x04name\x12!\n" +
+	"\fdisplay_name\x18\x02 \x01(\tR\vdisplayName\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\x03R\x02id\x12\"\n" +
+	"\fdivisibility\x18\x04 \x01(\x05R\fdivisibility\x12\x1f\n" +
+	"\vstart_block\x18\x05 \x01(\x05R\n" +
+	"startBlock\x12\x1b\n" +
+	"\tend_block\x18\x06 \x01(\x05R\bendBlock\x12\x1b\n" +
+	"\tself_mint\x18\a \x01(\x05R\bselfMint\x12#\n" +
+	"\rdeploy_height\x18\b \x01(\x05R\fdeployHeight\x12)\n" +
+	"\x10deploy_blocktime\x18\t \x01(\x03R\x0fdeployBlocktime\x12\x1b\n" +
+	"\tdeploy_tx\x18\n" +
+	" \x01(\tR\bdeployTx\x12\x14\n" +
+	"\x05limit\x18\v \x01(\tR\x05limit\x12\f\n" +
+	"\x01n\x18\f \x01(\x05R\x01n\x12!\n" +
+	"\ftotal_minted\x18\r \x01(\tR\vtotalMinted\x12\x1d\n" +
+	"\n" +
+	"mint_times\x18\x0e \x01(\x03R\tmintTimes\x12\x1d\n" +
+	"\n" +
+	"max_supply\x18\x0f \x01(\tR\tmaxSupply\x12#\n" +
+	"\rholders_count\x18\x10 \x01(\x05R\fholdersCount\x12%\n" +
+	"\x0einscription_id\x18\x11 \x01(\tR\rinscriptionId\x12'\n" +
+	"\x0finscription_num\x18\x12 \x01(\x03R\x0einscriptionNum\x12 \n" +
+	"\vdescription\x18\x13 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06rarity\x18\x14 \x01(\tR\x06rarity\x12%\n" +
+	"\x0edeploy_address\x18\x15 \x01(\tR\rdeployAddress\x12\x18\n" +
+	"\acontent\x18\x16 \x01(\fR\acontent\x12!\n" +
+	"\fcontent_type\x18\x17 \x01(\tR\vcontentType\x12\x1a\n" +
+	"\bdelegate\x18\x18 \x01(\tR\bdelegate\x12\x16\n" +
+	"\x06status\x18\x19 \x01(\x05R\x06status\"l\n" +
+	"\x0eHoldersRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x04R\x05start\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x04R\x05limit\x12\x16\n" +
+	"\x06height\x18\x04 \x01(\x05R\x06height\"<\n" +
+	"\x06Holder\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\tR\abalance\"m\n" +
+	"\x0fHoldersResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x04R\x05total\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x04R\x05start\x12.\n" +
+	"\aholders\x18\x03 \x03(\v2\x14.pb.rpcserver.HolderR\aholders\"X\n" +
+	"\x12MintHistoryRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x05R\x05start\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"\xc5\x01\n" +
+	"\x0fMintHistoryItem\x12!\n" +
+	"\fmint_address\x18\x01 \x01(\tR\vmintAddress\x12%\n" +
+	"\x0eholder_address\x18\x02 \x01(\tR\rholderAddress\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\tR\abalance\x12%\n" +
+	"\x0einscription_id\x18\x04 \x01(\tR\rinscriptionId\x12'\n" +
+	"\x0finscription_num\x18\x05 \x01(\x03R\x0einscriptionNum\"\x8e\x01\n" +
+	"\x13MintHistoryResponse\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05start\x18\x03 \x01(\x05R\x05start\x123\n" +
+	"\x05items\x18\x04 \x03(\v2\x1d.pb.rpcserver.MintHistoryItemR\x05items\"3\n" +
+	"\n" +
+	"NftRequest\x12%\n" +
+	"\x0einscription_id\x18\x01 \x01(\tR\rinscriptionId\"\xa6\x02\n" +
+	"\aNftItem\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x10\n" +
+	"\x03sat\x18\x03 \x01(\x03R\x03sat\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\tR\aaddress\x12%\n" +
+	"\x0einscription_id\x18\x05 \x01(\tR\rinscriptionId\x12\x12\n" +
+	"\x04utxo\x18\x06 \x01(\tR\x04utxo\x12\x14\n" +
+	"\x05value\x18\a \x01(\x03R\x05value\x12\x16\n" +
+	"\x06height\x18\b \x01(\x05R\x06height\x12\x12\n" +
+	"\x04time\x18\t \x01(\x03R\x04time\x12/\n" +
+	"\x13inscription_address\x18\n" +
+	" \x01(\tR\x12inscriptionAddress\x12\x1d\n" +
+	"\n" +
+	"curse_type\x18\v \x01(\x05R\tcurseType\"\xe9\x01\n" +
+	"\aNftInfo\x12)\n" +
+	"\x04item\x18\x01 \x01(\v2\x15.pb.rpcserver.NftItemR\x04item\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\fR\vcontentType\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\fR\acontent\x12#\n" +
+	"\rmeta_protocol\x18\x04 \x01(\fR\fmetaProtocol\x12\x1b\n" +
+	"\tmeta_data\x18\x05 \x01(\fR\bmetaData\x12\x18\n" +
+	"\aparents\x18\x06 \x03(\tR\aparents\x12\x1a\n" +
+	"\bdelegate\x18\a \x01(\tR\bdelegate\"!\n" +
+	"\vNameRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"W\n" +
+	"\x06KVItem\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x12%\n" +
+	"\x0einscription_id\x18\x03 \x01(\tR\rinscriptionId\"]\n" +
+	"\bNameInfo\x12)\n" +
+	"\x04item\x18\x01 \x01(\v2\x15.pb.rpcserver.NftItemR\x04item\x12&\n" +
+	"\x03kvs\x18\x02 \x03(\v2\x14.pb.rpcserver.KVItemR\x03kvs2\xbb\x05\n" +
+	"\aIndexer\x12^\n" +
+	"\x11GetAddressSummary\x12#.pb.rpcserver.AddressSummaryRequest\x1a$.pb.rpcserver.AddressSummaryResponse\x12H\n" +
+	"\vGetUtxoInfo\x12\x1d.pb.rpcserver.UtxoInfoRequest\x1a\x1a.pb.rpcserver.AssetsInUtxo\x12O\n" +
+	"\fGetUtxosInfo\x12\x1e.pb.rpcserver.UtxosInfoRequest\x1a\x1f.pb.rpcserver.UtxosInfoResponse\x12R\n" +
+	"\x0fStreamUtxosInfo\x12\x1d.pb.rpcserver.UtxoInfoRequest\x1a\x1c.pb.rpcserver.UtxoInfoResult(\x010\x01\x12F\n" +
+	"\rGetTickerInfo\x12\x1b.pb.rpcserver.TickerRequest\x1a\x18.pb.rpcserver.TickerInfo\x12I\n" +
+	"\n" +
+	"GetHolders\x12\x1c.pb.rpcserver.HoldersRequest\x1a\x1d.pb.rpcserver.HoldersResponse\x12U\n" +
+	"\x0eGetMintHistory\x12 .pb.rpcserver.MintHistoryRequest\x1a!.pb.rpcserver.MintHistoryResponse\x129\n" +
+	"\x06GetNft\x12\x18.pb.rpcserver.NftRequest\x1a\x15.pb.rpcserver.NftInfo\x12<\n" +
+	"\aGetName\x12\x19.pb.rpcserver.NameRequest\x1a\x16.pb.rpcserver.NameInfoB\x0fZ\r/rpcserver/pbb\x06proto3"
+
+var (
+	file_rpcserver_pb_indexer_proto_rawDescOnce sync.Once
+	file_rpcserver_pb_indexer_proto_rawDescData []byte
+)
+
+func file_rpcserver_pb_indexer_proto_rawDescGZIP() []byte {
+	file_rpcserver_pb_indexer_proto_rawDescOnce.Do(func() {
+		file_rpcserver_pb_indexer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_rpcserver_pb_indexer_proto_rawDesc), len(file_rpcserver_pb_indexer_proto_rawDesc)))
+	})
+	return file_rpcserver_pb_indexer_proto_rawDescData
+}
+
+var file_rpcserver_pb_indexer_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_rpcserver_pb_indexer_proto_goTypes = []any{
+	(*AssetName)(nil),              // 0: pb.rpcserver.AssetName
+	(*OffsetRange)(nil),            // 1: pb.rpcserver.OffsetRange
+	(*OffsetToAmount)(nil),         // 2: pb.rpcserver.OffsetToAmount
+	(*DisplayAsset)(nil),           // 3: pb.rpcserver.DisplayAsset
+	(*AssetsInUtxo)(nil),           // 4: pb.rpcserver.AssetsInUtxo
+	(*AddressSummaryRequest)(nil),  // 5: pb.rpcserver.AddressSummaryRequest
+	(*AddressSummaryResponse)(nil), // 6: pb.rpcserver.AddressSummaryResponse
+	(*UtxoInfoRequest)(nil),        // 7: pb.rpcserver.UtxoInfoRequest
+	(*UtxosInfoRequest)(nil),       // 8: pb.rpcserver.UtxosInfoRequest
+	(*UtxosInfoResponse)(nil),      // 9: pb.rpcserver.UtxosInfoResponse
+	(*UtxoInfoResult)(nil),         // 10: pb.rpcserver.UtxoInfoResult
+	(*TickerRequest)(nil),          // 11: pb.rpcserver.TickerRequest
+	(*TickerInfo)(nil),             // 12: pb.rpcserver.TickerInfo
+	(*HoldersRequest)(nil),         // 13: pb.rpcserver.HoldersRequest
+	(*Holder)(nil),                 // 14: pb.rpcserver.Holder
+	(*HoldersResponse)(nil),        // 15: pb.rpcserver.HoldersResponse
+	(*MintHistoryRequest)(nil),     // 16: pb.rpcserver.MintHistoryRequest
+	(*MintHistoryItem)(nil),        // 17: pb.rpcserver.MintHistoryItem
+	(*MintHistoryResponse)(nil),    // 18: pb.rpcserver.MintHistoryResponse
+	(*NftRequest)(nil),             // 19: pb.rpcserver.NftRequest
+	(*NftItem)(nil),                // 20: pb.rpcserver.NftItem
+	(*NftInfo)(nil),                // 21: pb.rpcserver.NftInfo
+	(*NameRequest)(nil),            // 22: pb.rpcserver.NameRequest
+	(*KVItem)(nil),                 // 23: pb.rpcserver.KVItem
+	(*NameInfo)(nil),               // 24: pb.rpcserver.NameInfo
+}
+var file_rpcserver_pb_indexer_proto_depIdxs = []int32{
+	0,  // 0: pb.rpcserver.DisplayAsset.name:type_name -> pb.rpcserver.AssetName
+	1,  // 1: pb.rpcserver.DisplayAsset.offsets:type_name -> pb.rpcserver.OffsetRange
+	2,  // 2: pb.rpcserver.DisplayAsset.offset_to_amts:type_name -> pb.rpcserver.OffsetToAmount
+	3,  // 3: pb.rpcserver.AssetsInUtxo.assets:type_name -> pb.rpcserver.DisplayAsset
+	3,  // 4: pb.rpcserver.AddressSummaryResponse.assets:type_name -> pb.rpcserver.DisplayAsset
+	4,  // 5: pb.rpcserver.UtxosInfoResponse.utxos:type_name -> pb.rpcserver.AssetsInUtxo
+	4,  // 6: pb.rpcserver.UtxoInfoResult.info:type_name -> pb.rpcserver.AssetsInUtxo
+	0,  // 7: pb.rpcserver.TickerInfo.name:type_name -> pb.rpcserver.AssetName
+	14, // 8: pb.rpcserver.HoldersResponse.holders:type_name -> pb.rpcserver.Holder
+	17, // 9: pb.rpcserver.MintHistoryResponse.items:type_name -> pb.rpcserver.MintHistoryItem
+	20, // 10: pb.rpcserver.NftInfo.item:type_name -> pb.rpcserver.NftItem
+	20, // 11: pb.rpcserver.NameInfo.item:type_name -> pb.rpcserver.NftItem
+	23, // 12: pb.rpcserver.NameInfo.kvs:type_name -> pb.rpcserver.KVItem
+	5,  // 13: pb.rpcserver.Indexer.GetAddressSummary:input_type -> pb.rpcserver.AddressSummaryRequest
+	7,  // 14: pb.rpcserver.Indexer.GetUtxoInfo:input_type -> pb.rpcserver.UtxoInfoRequest
+	8,  // 15: pb.rpcserver.Indexer.GetUtxosInfo:input_type -> pb.rpcserver.UtxosInfoRequest
+	7,  // 16: pb.rpcserver.Indexer.StreamUtxosInfo:input_type -> pb.rpcserver.UtxoInfoRequest
+	11, // 17: pb.rpcserver.Indexer.GetTickerInfo:input_type -> pb.rpcserver.TickerRequest
+	13, // 18: pb.rpcserver.Indexer.GetHolders:input_type -> pb.rpcserver.HoldersRequest
+	16, // 19: pb.rpcserver.Indexer.GetMintHistory:input_type -> pb.rpcserver.MintHistoryRequest
+	19, // 20: pb.rpcserver.Indexer.GetNft:input_type -> pb.rpcserver.NftRequest
+	22, // 21: pb.rpcserver.Indexer.GetName:input_type -> pb.rpcserver.NameRequest
+	6,  // 22: pb.rpcserver.Indexer.GetAddressSummary:output_type -> pb.rpcserver.AddressSummaryResponse
+	4,  // 23: pb.rpcserver.Indexer.GetUtxoInfo:output_type -> pb.rpcserver.AssetsInUtxo
+	9,  // 24: pb.rpcserver.Indexer.GetUtxosInfo:output_type -> pb.rpcserver.UtxosInfoResponse
+	10, // 25: pb.rpcserver.Indexer.StreamUtxosInfo:output_type -> pb.rpcserver.UtxoInfoResult
+	12, // 26: pb.rpcserver.Indexer.GetTickerInfo:output_type -> pb.rpcserver.TickerInfo
+	15, // 27: pb.rpcserver.Indexer.GetHolders:output_type -> pb.rpcserver.HoldersResponse
+	18, // 28: pb.rpcserver.Indexer.GetMintHistory:output_type -> pb.rpcserver.MintHistoryResponse
+	21, // 29: pb.rpcserver.Indexer.GetNft:output_type -> pb.rpcserver.NftInfo
+	24, // 30: pb.rpcserver.Indexer.GetName:output_type -> pb.rpcserver.NameInfo
+	22, // [22:31] is the sub-list for method output_type
+	13, // [13:22] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_rpcserver_pb_indexer_proto_init() }
+func file_rpcserver_pb_indexer_proto_init() {
+	if File_rpcserver_pb_indexer_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_rpcserver_pb_indexer_proto_rawDesc), len(file_rpcserver_pb_indexer_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   25,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_rpcserver_pb_indexer_proto_goTypes,
+		DependencyIndexes: file_rpcserver_pb_indexer_proto_depIdxs,
+		MessageInfos:      file_rpcserver_pb_indexer_proto_msgTypes,
+	}.Build()
+	File_rpcserver_pb_indexer_proto = out.File
+	file_rpcserver_pb_indexer_proto_goTypes = nil
+	file_rpcserver_pb_indexer_proto_depIdxs = nil
+}
@@ -0,0 +1,420 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: rpcserver/pb/indexer.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Indexer_GetAddressSummary_FullMethodName = "/pb.rpcserver.Indexer/GetAddressSummary"
+	Indexer_GetUtxoInfo_FullMethodName       = "/pb.rpcserver.Indexer/GetUtxoInfo"
+	Indexer_GetUtxosInfo_FullMethodName      = "/pb.rpcserver.Indexer/GetUtxosInfo"
+	Indexer_StreamUtxosInfo_FullMethodName   = "/pb.rpcserver.Indexer/StreamUtxosInfo"
+	Indexer_GetTickerInfo_FullMethodName     = "/pb.rpcserver.Indexer/GetTickerInfo"
+	Indexer_GetHolders_FullMethodName        = "/pb.rpcserver.Indexer/GetHolders"
+	Indexer_GetMintHistory_FullMethodName    = "/pb.rpcserver.Indexer/GetMintHistory"
+	Indexer_GetNft_FullMethodName            = "/pb.rpcserver.Indexer/GetNft"
+	Indexer_GetName_FullMethodName           = "/pb.rpcserver.Indexer/GetName"
+)
+
+// IndexerClient is the client API for Indexer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IndexerClient interface {
+	GetAddressSummary(ctx context.Context, in *AddressSummaryRequest, opts ...grpc.CallOption) (*AddressSummaryResponse, error)
+	GetUtxoInfo(ctx context.Context, in *UtxoInfoRequest, opts ...grpc.CallOption) (*AssetsInUtxo, error)
+	GetUtxosInfo(ctx context.Context, in *UtxosInfoRequest, opts ...grpc.CallOption) (*UtxosInfoResponse, error)
+	StreamUtxosInfo(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[UtxoInfoRequest, UtxoInfoResult], error)
+	GetTickerInfo(ctx context.Context, in *TickerRequest, opts ...grpc.CallOption) (*TickerInfo, error)
+	GetHolders(ctx context.Context, in *HoldersRequest, opts ...grpc.CallOption) (*HoldersResponse, error)
+	GetMintHistory(ctx context.Context, in *MintHistoryRequest, opts ...grpc.CallOption) (*MintHistoryResponse, error)
+	GetNft(ctx context.Context, in *NftRequest, opts ...grpc.CallOption) (*NftInfo, error)
+	GetName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*NameInfo, error)
+}
+
+type indexerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIndexerClient(cc grpc.ClientConnInterface) IndexerClient {
+	return &indexerClient{cc}
+}
+
+func (c *indexerClient) GetAddressSummary(ctx context.Context, in *AddressSummaryRequest, opts ...grpc.CallOption) (*AddressSummaryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddressSummaryResponse)
+	err := c.cc.Invoke(ctx, Indexer_GetAddressSummary_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexerClient) GetUtxoInfo(ctx context.Context, in *UtxoInfoRequest, opts ...grpc.CallOption) (*AssetsInUtxo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AssetsInUtxo)
+	err := c.cc.Invoke(ctx, Indexer_GetUtxoInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexerClient) GetUtxosInfo(ctx context.Context, in *UtxosInfoRequest, opts ...grpc.CallOption) (*UtxosInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UtxosInfoResponse)
+	err := c.cc.Invoke(ctx, Indexer_GetUtxosInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexerClient) StreamUtxosInfo(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[UtxoInfoRequest, UtxoInfoResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Indexer_ServiceDesc.Streams[0], Indexer_StreamUtxosInfo_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UtxoInfoRequest, UtxoInfoResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Indexer_StreamUtxosInfoClient = grpc.BidiStreamingClient[UtxoInfoRequest, UtxoInfoResult]
+
+func (c *indexerClient) GetTickerInfo(ctx context.Context, in *TickerRequest, opts ...grpc.CallOption) (*TickerInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TickerInfo)
+	err := c.cc.Invoke(ctx, Indexer_GetTickerInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexerClient) GetHolders(ctx context.Context, in *HoldersRequest, opts ...grpc.CallOption) (*HoldersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HoldersResponse)
+	err := c.cc.Invoke(ctx, Indexer_GetHolders_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexerClient) GetMintHistory(ctx context.Context, in *MintHistoryRequest, opts ...grpc.CallOption) (*MintHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MintHistoryResponse)
+	err := c.cc.Invoke(ctx, Indexer_GetMintHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexerClient) GetNft(ctx context.Context, in *NftRequest, opts ...grpc.CallOption) (*NftInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NftInfo)
+	err := c.cc.Invoke(ctx, Indexer_GetNft_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexerClient) GetName(ctx context.Context, in *NameRequest, opts ...grpc.CallOption) (*NameInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NameInfo)
+	err := c.cc.Invoke(ctx, Indexer_GetName_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IndexerServer is the server API for Indexer service.
+// All implementations must embed UnimplementedIndexerServer
+// for forward compatibility.
+type IndexerServer interface {
+	GetAddressSummary(context.Context, *AddressSummaryRequest) (*AddressSummaryResponse, error)
+	GetUtxoInfo(context.Context, *UtxoInfoRequest) (*AssetsInUtxo, error)
+	GetUtxosInfo(context.Context, *UtxosInfoRequest) (*UtxosInfoResponse, error)
+	StreamUtxosInfo(grpc.BidiStreamingServer[UtxoInfoRequest, UtxoInfoResult]) error
+	GetTickerInfo(context.Context, *TickerRequest) (*TickerInfo, error)
+	GetHolders(context.Context, *HoldersRequest) (*HoldersResponse, error)
+	GetMintHistory(context.Context, *MintHistoryRequest) (*MintHistoryResponse, error)
+	GetNft(context.Context, *NftRequest) (*NftInfo, error)
+	GetName(context.Context, *NameRequest) (*NameInfo, error)
+	mustEmbedUnimplementedIndexerServer()
+}
+
+// UnimplementedIndexerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedIndexerServer struct{}
+
+func (UnimplementedIndexerServer) GetAddressSummary(context.Context, *AddressSummaryRequest) (*AddressSummaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAddressSummary not implemented")
+}
+func (UnimplementedIndexerServer) GetUtxoInfo(context.Context, *UtxoInfoRequest) (*AssetsInUtxo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUtxoInfo not implemented")
+}
+func (UnimplementedIndexerServer) GetUtxosInfo(context.Context, *UtxosInfoRequest) (*UtxosInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUtxosInfo not implemented")
+}
+func (UnimplementedIndexerServer) StreamUtxosInfo(grpc.BidiStreamingServer[UtxoInfoRequest, UtxoInfoResult]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamUtxosInfo not implemented")
+}
+func (UnimplementedIndexerServer) GetTickerInfo(context.Context, *TickerRequest) (*TickerInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTickerInfo not implemented")
+}
+func (UnimplementedIndexerServer) GetHolders(context.Context, *HoldersRequest) (*HoldersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHolders not implemented")
+}
+func (UnimplementedIndexerServer) GetMintHistory(context.Context, *MintHistoryRequest) (*MintHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMintHistory not implemented")
+}
+func (UnimplementedIndexerServer) GetNft(context.Context, *NftRequest) (*NftInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNft not implemented")
+}
+func (UnimplementedIndexerServer) GetName(context.Context, *NameRequest) (*NameInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetName not implemented")
+}
+func (UnimplementedIndexerServer) mustEmbedUnimplementedIndexerServer() {}
+func (UnimplementedIndexerServer) testEmbeddedByValue()                 {}
+
+// UnsafeIndexerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IndexerServer will
+// result in compilation errors.
+type UnsafeIndexerServer interface {
+	mustEmbedUnimplementedIndexerServer()
+}
+
+func RegisterIndexerServer(s grpc.ServiceRegistrar, srv IndexerServer) {
+	// If the following call pancis, it indicates UnimplementedIndexerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Indexer_ServiceDesc, srv)
+}
+
+func _Indexer_GetAddressSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetAddressSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetAddressSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetAddressSummary(ctx, req.(*AddressSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Indexer_GetUtxoInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UtxoInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetUtxoInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetUtxoInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetUtxoInfo(ctx, req.(*UtxoInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Indexer_GetUtxosInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UtxosInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetUtxosInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetUtxosInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetUtxosInfo(ctx, req.(*UtxosInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Indexer_StreamUtxosInfo_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IndexerServer).StreamUtxosInfo(&grpc.GenericServerStream[UtxoInfoRequest, UtxoInfoResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Indexer_StreamUtxosInfoServer = grpc.BidiStreamingServer[UtxoInfoRequest, UtxoInfoResult]
+
+func _Indexer_GetTickerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TickerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetTickerInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetTickerInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetTickerInfo(ctx, req.(*TickerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Indexer_GetHolders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HoldersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetHolders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetHolders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetHolders(ctx, req.(*HoldersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Indexer_GetMintHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MintHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetMintHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetMintHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetMintHistory(ctx, req.(*MintHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Indexer_GetNft_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NftRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetNft(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetNft_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetNft(ctx, req.(*NftRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Indexer_GetName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexerServer).GetName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Indexer_GetName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexerServer).GetName(ctx, req.(*NameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Indexer_ServiceDesc is the grpc.ServiceDesc for Indexer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Indexer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.rpcserver.Indexer",
+	HandlerType: (*IndexerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAddressSummary",
+			Handler:    _Indexer_GetAddressSummary_Handler,
+		},
+		{
+			MethodName: "GetUtxoInfo",
+			Handler:    _Indexer_GetUtxoInfo_Handler,
+		},
+		{
+			MethodName: "GetUtxosInfo",
+			Handler:    _Indexer_GetUtxosInfo_Handler,
+		},
+		{
+			MethodName: "GetTickerInfo",
+			Handler:    _Indexer_GetTickerInfo_Handler,
+		},
+		{
+			MethodName: "GetHolders",
+			Handler:    _Indexer_GetHolders_Handler,
+		},
+		{
+			MethodName: "GetMintHistory",
+			Handler:    _Indexer_GetMintHistory_Handler,
+		},
+		{
+			MethodName: "GetNft",
+			Handler:    _Indexer_GetNft_Handler,
+		},
+		{
+			MethodName: "GetName",
+			Handler:    _Indexer_GetName_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamUtxosInfo",
+			Handler:       _Indexer_StreamUtxosInfo_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "rpcserver/pb/indexer.proto",
+}
@@ -0,0 +1,154 @@
+package rpcserver
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sat20-labs/indexer/common"
+)
+
+// 每天的请求计数保存在 localDB 中，重启后继续有效
+const apiQuotaDBPrefix = "apiquota:"
+
+const apiQuotaFlushInterval = 30 * time.Second
+
+type quotaCounter struct {
+	day   string
+	count int
+	dirty bool
+}
+
+// QuotaStore 按 UTC 自然日统计请求数量
+type QuotaStore struct {
+	db       common.KVDB
+	mutex    sync.Mutex
+	counters map[string]*quotaCounter
+	now      func() time.Time
+	prunedAt string // 最近一次清理过期计数的日期
+}
+
+func NewQuotaStore(db common.KVDB) *QuotaStore {
+	return &QuotaStore{
+		db:       db,
+		counters: make(map[string]*quotaCounter),
+		now:      time.Now,
+	}
+}
+
+func quotaDay(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+func getQuotaKey(day, key string) string {
+	return apiQuotaDBPrefix + day + ":" + key
+}
+
+// untilNextDay 距离下一次计数清零的时间
+func untilNextDay(t time.Time) time.Duration {
+	t = t.UTC()
+	next := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+	return next.Sub(t)
+}
+
+// Incr 计数加一，超过限额时返回 false 和需要等待的时间
+func (s *QuotaStore) Incr(key string, limit int) (bool, time.Duration) {
+	now := s.now()
+	day := quotaDay(now)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	counter := s.counters[key]
+	if counter == nil || counter.day != day {
+		counter = &quotaCounter{day: day, count: s.load(day, key)}
+		s.counters[key] = counter
+	}
+	if counter.count >= limit {
+		return false, untilNextDay(now)
+	}
+	counter.count++
+	counter.dirty = true
+	return true, 0
+}
+
+func (s *QuotaStore) load(day, key string) int {
+	if s.db == nil {
+		return 0
+	}
+	value, err := s.db.Read([]byte(getQuotaKey(day, key)))
+	if err != nil {
+		return 0
+	}
+	count, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// Flush 把有变化的计数写入数据库，每天第一次调用时清除已经过期的计数
+func (s *QuotaStore) Flush() {
+	if s.db == nil {
+		return
+	}
+	today := quotaDay(s.now())
+
+	s.mutex.Lock()
+	wb := s.db.NewWriteBatch()
+	defer wb.Close()
+	for key, counter := range s.counters {
+		// 已经过期的计数不需要再保存
+		if counter.day != today {
+			delete(s.counters, key)
+			continue
+		}
+		if !counter.dirty {
+			continue
+		}
+		err := wb.Put([]byte(getQuotaKey(counter.day, key)), []byte(strconv.Itoa(counter.count)))
+		if err != nil {
+			common.Log.Errorf("QuotaStore.Flush put %s failed: %v", key, err)
+			continue
+		}
+		counter.dirty = false
+	}
+	if err := wb.Flush(); err != nil {
+		common.Log.Errorf("QuotaStore.Flush failed: %v", err)
+	}
+	s.mutex.Unlock()
+
+	if s.prunedAt != today {
+		s.pruneOldDays(today)
+		s.prunedAt = today
+	}
+}
+
+func (s *QuotaStore) pruneOldDays(day string) {
+	today := getQuotaKey(day, "")
+	var keys [][]byte
+	err := s.db.BatchRead([]byte(apiQuotaDBPrefix), false, func(k, v []byte) error {
+		if !strings.HasPrefix(string(k), today) {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		common.Log.Errorf("QuotaStore.pruneOldDays read failed: %v", err)
+		return
+	}
+	for _, key := range keys {
+		if err := s.db.Delete(key); err != nil {
+			common.Log.Errorf("QuotaStore.pruneOldDays delete %s failed: %v", string(key), err)
+		}
+	}
+}
+
+func (s *QuotaStore) run() {
+	ticker := time.NewTicker(apiQuotaFlushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.Flush()
+	}
+}
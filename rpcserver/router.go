@@ -9,7 +9,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/didip/tollbooth/v7/limiter"
 	"github.com/gin-contrib/cors"
 	//"github.com/gin-contrib/logger"
 	"github.com/gin-gonic/gin"
@@ -17,10 +16,12 @@ import (
 	//"github.com/rs/zerolog"
 	"github.com/sat20-labs/indexer/config"
 	"github.com/sat20-labs/indexer/indexer"
+	"github.com/sat20-labs/indexer/rpcserver/admin"
 	"github.com/sat20-labs/indexer/rpcserver/base"
 	"github.com/sat20-labs/indexer/rpcserver/bitcoind"
 	"github.com/sat20-labs/indexer/rpcserver/ord"
 	"github.com/sat20-labs/indexer/rpcserver/ordx"
+	"github.com/sat20-labs/indexer/share/metrics"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -40,28 +41,32 @@ const (
 	CONTENT_TYPE_JSON = "application/json"
 )
 
-type RateLimit struct {
-	limit    *limiter.Limiter
-	reqCount int
-}
-
 type Rpc struct {
 	basicService *base.Service
 	ordxService  *ordx.Service
 	ordService   *ord.Service
 	btcdService  *bitcoind.Service
-	//apidoc           *APIDoc
+	adminService *admin.Service
+	grpcServer   *ordx.GrpcServer
+	apidoc       *APIDoc
 }
 
 func NewRpc(baseIndexer *indexer.IndexerMgr, chain string) *Rpc {
 	btcdService := bitcoind.NewService(baseIndexer.Config(), baseIndexer.LocalDB())
 	baseIndexer.SetBTCLuckyTemplateService(btcdService.BTCLuckyTemplateService())
+	// 铭文相关的接口依赖 nft 索引器
+	var ordService *ord.Service
+	if baseIndexer.IsProtocolEnabled(config.PROTOCOL_NFT) {
+		ordService = ord.NewService()
+	}
 	return &Rpc{
 		basicService: base.NewService(baseIndexer),
 		ordxService:  ordx.NewService(baseIndexer),
-		ordService:   ord.NewService(),
+		ordService:   ordService,
 		btcdService:  btcdService,
-		//apidoc:           &APIDoc{},
+		adminService: admin.NewService(baseIndexer),
+		grpcServer:   ordx.NewGrpcServer(baseIndexer),
+		apidoc:       NewAPIDoc(baseIndexer.LocalDB()),
 	}
 }
 
@@ -120,15 +125,18 @@ func (s *Rpc) Start(rpcUrl, swaggerHost, swaggerSchemes, rpcProxy, rpcLogFile st
 	engine.GET(rpcProxy+"/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// api config
-	// err := s.apidoc.InitApiConf(apiConf)
-	// if err != nil {
-	// 	return err
-	// }
+	err := s.apidoc.InitApiConf(apiConf)
+	if err != nil {
+		return err
+	}
 
-	// err = s.apidoc.ApplyApiConf(r, rpcProxy)
-	// if err != nil {
-	// 	return err
-	// }
+	err = s.apidoc.ApplyApiConf(engine, rpcProxy)
+	if err != nil {
+		return err
+	}
+
+	// prometheus，在鉴权之后、压缩和统计中间件之前注册，抓取时需要 api key 或者把抓取的主机加到 nolimit_host_list
+	engine.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// common header
 	engine.Use(func(c *gin.Context) {
@@ -168,12 +176,17 @@ func (s *Rpc) Start(rpcUrl, swaggerHost, swaggerSchemes, rpcProxy, rpcLogFile st
 
 	// Compression middleware
 	engine.Use(CompressionMiddleware())
+	// 在压缩之后，才能看到响应的原始内容
+	engine.Use(MetricsMiddleware())
 
 	// router
 	s.basicService.InitRouter(engine, rpcProxy)
 	s.ordxService.InitRouter(engine, rpcProxy)
-	s.ordService.InitRouter(engine, rpcProxy)
+	if s.ordService != nil {
+		s.ordService.InitRouter(engine, rpcProxy)
+	}
 	s.btcdService.InitRouter(engine, rpcProxy)
+	s.adminService.InitRouter(engine, rpcProxy, s.apidoc.AdminAuth())
 
 	parts := strings.Split(rpcUrl, ":")
 	var port string
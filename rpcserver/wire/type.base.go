@@ -15,6 +15,17 @@ type SatInfo struct {
 	Epoch      int64    `json:"epoch"`
 	Period     int64    `json:"period"`
 	Satributes []string `json:"satributes"`
+	// 当前所在的位置，已经被烧掉时为空
+	Utxo    string `json:"utxo"`
+	Offset  int64  `json:"offset"`
+	Value   int64  `json:"value"`
+	Address string `json:"address"`
+}
+
+type UtxoSatRanges struct {
+	Utxo  string     `json:"utxo"`
+	Value int64      `json:"value"`
+	Sats  []SatRange `json:"sats"`
 }
 
 type SpecificSatInUtxo struct {
@@ -92,6 +103,11 @@ type SatInfoResp struct {
 	Data *SatInfo `json:"data"`
 }
 
+type UtxoSatRangesResp struct {
+	BaseResp
+	Data *UtxoSatRanges `json:"data"`
+}
+
 type SpecificSatReq struct {
 	Address string  `json:"address"`
 	Sats    []int64 `json:"sats"`
@@ -3,6 +3,9 @@ package wire
 import "github.com/sat20-labs/indexer/common"
 
 type KeyValue = common.KeyValue
+type KeyDeletion = common.KeyDeletion
+type KVChange = common.KVChange
+type KVRegistration = common.KVRegistration
 
 type GetNonceReq struct {
 	PubKey []byte `json:"pubkey"`
@@ -42,16 +45,45 @@ type PutKValueResp struct {
 }
 
 type DelKValueReq struct {
-	Keys      []string `json:"keys"`
-	Nonce     []byte   `json:"Nonce"`
-	PubKey    []byte   `json:"pubkey"`
-	Signature []byte   `json:"signature"`
+	Keys      []string       `json:"keys"` // 不再支持，用 Values
+	Values    []*KeyDeletion `json:"values"`
+	Nonce     []byte         `json:"Nonce"`
+	PubKey    []byte         `json:"pubkey"`
+	Signature []byte         `json:"signature"`
 }
 
 type DelKValueResp struct {
 	BaseResp
 }
 
+type ListKValuesResp struct {
+	BaseResp
+	Values []*KeyValue `json:"values"`
+	Next   string      `json:"next"` // 下一页的 cursor，为空时没有更多数据
+}
+
+type KValueHistoryResp struct {
+	BaseResp
+	Versions []*KVChange `json:"versions"`
+}
+
+type KValueChangesResp struct {
+	BaseResp
+	Changes []*KVChange `json:"changes"`
+	Last    uint64      `json:"last"`
+}
+
+type KValueRecordsResp struct {
+	BaseResp
+	Records []*KVChange `json:"records"`
+	Next    string      `json:"next"`
+}
+
+type KVRegistrationsResp struct {
+	BaseResp
+	Registrations []*KVRegistration `json:"registrations"`
+}
+
 type RegisterPubKeyReq struct {
 	PubKey string `json:"pubkey"`
 }
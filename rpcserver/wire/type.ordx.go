@@ -583,3 +583,103 @@ type MintHistoryItemV3 struct {
 	InscriptionID  string `json:"inscriptionId,omitempty" example:"bac89275b4c0a0ba6aaa603d749a1c88ae3033da9f6d6e661a28fb40e8dca362i0"`
 	InscriptionNum int64  `json:"inscriptionNumber,omitempty" example:"67269474" description:"Inscription number of the holder"`
 }
+
+// tx的资产流向
+type TxAssetsFlowReq struct {
+	// hex 格式的交易（可以没有签名），或者 hex/base64 格式的 psbt
+	Tx string `json:"tx" binding:"required"`
+}
+
+type TxAssetsFlowResp struct {
+	BaseResp
+	Data *common.TxAssetsFlow `json:"data"`
+}
+
+// 构造资产转账的 psbt
+type TransferPsbtRecipient struct {
+	Address string `json:"address" binding:"required"`
+	// 资产数量，白聪时是聪的数量
+	Amount string `json:"amount" binding:"required"`
+	// 可选，只用于 runes，输出的聪数量，默认 330；其他资产由绑定的聪决定
+	Value int64 `json:"value,omitempty"`
+}
+
+type TransferPsbtReq struct {
+	Address string `json:"address" binding:"required"`
+	// 比如 ordx:f:pearl, runes:f:840000:3, brc20:f:ordi, ordx:e:uncommon，空表示白聪
+	Asset      string                   `json:"asset"`
+	Recipients []*TransferPsbtRecipient `json:"recipients" binding:"required"`
+	// sat/vB
+	FeeRate int64 `json:"feeRate" binding:"required"`
+	// 可选，找零地址，默认是发送地址
+	ChangeAddress string `json:"changeAddress,omitempty"`
+}
+
+type TransferPsbtData struct {
+	// base64 格式，没有签名
+	Psbt  string `json:"psbt"`
+	Fee   int64  `json:"fee"`
+	VSize int64  `json:"vsize"`
+	// 选中的输入，顺序和 psbt 中的一致
+	Inputs []*common.AssetsInUtxo `json:"inputs"`
+	// 每个输出分配到的资产
+	Preview  *common.TxAssetsFlow `json:"preview"`
+	Warnings []string             `json:"warnings,omitempty"`
+}
+
+type TransferPsbtResp struct {
+	BaseResp
+	Data *TransferPsbtData `json:"data"`
+}
+
+type AtomicalResp struct {
+	BaseResp
+	Data *common.AtomicalInfo `json:"data"`
+}
+
+type RealmResp struct {
+	BaseResp
+	Data *common.RealmResolution `json:"data"`
+}
+
+type AtomicalListData struct {
+	ListResp
+	Detail []*common.AtomicalInfo `json:"detail"`
+}
+
+type AtomicalListResp struct {
+	BaseResp
+	Data *AtomicalListData `json:"data"`
+}
+
+type AtomicalEventListData struct {
+	ListResp
+	Detail []*common.AtomicalEvent `json:"detail"`
+}
+
+type AtomicalEventListResp struct {
+	BaseResp
+	Data *AtomicalEventListData `json:"data"`
+}
+
+type BRC20ActivityData struct {
+	Total  uint64                  `json:"total"`
+	Next   string                  `json:"next"` // 下一页的 cursor，为空表示没有更多数据
+	Detail []*common.BRC20Activity `json:"detail"`
+}
+
+type BRC20ActivityResp struct {
+	BaseResp
+	Data *BRC20ActivityData `json:"data"`
+}
+
+type RunesActivityData struct {
+	Total  uint64                  `json:"total"`
+	Next   string                  `json:"next"` // 下一页的 cursor，为空表示没有更多数据
+	Detail []*common.RunesActivity `json:"detail"`
+}
+
+type RunesActivityResp struct {
+	BaseResp
+	Data *RunesActivityData `json:"data"`
+}
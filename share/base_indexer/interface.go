@@ -2,6 +2,7 @@ package base_indexer
 
 import (
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/sat20-labs/indexer/common"
 )
 
@@ -13,6 +14,8 @@ type Indexer interface {
 	GetChainTip() int
 	GetSyncHeight() int
 	GetBlockInfo(int) (*common.BlockInfo, error)
+	// 协议索引器是否打开，name 是配置 protocols 中的名字
+	IsProtocolEnabled(name string) bool
 
 	// base indexer
 	GetAddressById(addressId uint64) string
@@ -24,6 +27,9 @@ type Indexer interface {
 	GetUTXOsWithAddress(address string) (map[uint64]int64, error)
 	// return: address
 	GetHolderAddress(inscriptionId string) string
+	// 需要打开聪区间索引
+	LocateSat(sat int64) (*common.SatLocation, error)
+	GetSatRangesWithUtxo(utxo string) ([]*common.Range, error)
 
 	// ordx Asset
 	// return: tick->amount
@@ -100,6 +106,9 @@ type Indexer interface {
 	GetBindingSat(tickerName *common.TickerName) int
 
 	GetAssetSummaryInAddressV3(address string) map[common.TickerName]*common.Decimal
+	// 历史高度的数据，只包括 ordx ft、brc20、runes 和 atom
+	GetAssetSummaryInAddressAtHeight(address string, height int) (map[common.TickerName]*common.Decimal, error)
+	CheckUtxoAtHeight(utxo string, height int) error
 	// return: mint info sorted by inscribed time
 	GetMintHistoryWithAddressV2(address string, tickerName *common.TickerName, start, limit int) ([]*common.MintInfo, int)
 	// return: ticker -> asset info (inscriptinId -> asset ranges)
@@ -110,6 +119,7 @@ type Indexer interface {
 	GetTickerMapV2(protcol string, start, limit int) ([]string, int)
 	// return: addressId -> asset amount
 	GetHoldersWithTickV2(tickerName *common.TickerName) map[uint64]*common.Decimal
+	GetHoldersWithTickAtHeight(tickerName *common.TickerName, height int) (map[uint64]*common.Decimal, error)
 	// return: asset amount, mint times
 	GetMintAmountV2(tickerName *common.TickerName) (*common.Decimal, int64)
 	// return:  mint info sorted by inscribed time
@@ -119,8 +129,16 @@ type Indexer interface {
 	// kv
 	IsSupportedKey(pubkey []byte) bool
 	PutKVs(kvs []*common.KeyValue) error
-	DelKVs(pubkey []byte, keys []string) error
+	DelKVs(dels []*common.KeyDeletion) error
 	GetKVs(pubkey []byte, keys []string) ([]*common.KeyValue, error)
+	// return: values, next cursor
+	ListKVs(pubkey []byte, prefix, cursor string, limit int) ([]*common.KeyValue, string, error)
+	GetKVHistory(pubkey []byte, key string) ([]*common.KVChange, error)
+	// return: changes after since, last change id
+	GetKVChanges(since uint64, limit int) ([]*common.KVChange, uint64, error)
+	// 其他索引器全量同步用，return: records, next cursor
+	GetKVRecords(cursor string, limit int) ([]*common.KVChange, string, error)
+	GetKVRegistrations() ([]*common.KVRegistration, error)
 
 	GetIndexerPubKey() string
 	RegisterPubKey(string) (string, error)
@@ -129,4 +147,26 @@ type Indexer interface {
 	IsUtxoSpent(utxo string) bool
 	UnlockOrdinals(utxos []string, pubkey, sig []byte) (map[string]error, error)
 	GetLockedUTXOsInAddress(address string) ([]*common.AssetsInUtxo, error)
+
+	// tx
+	// 交易中资产从输入到输出的流向
+	GetTxAssetsFlow(txid string) (*common.TxAssetsFlow, error)
+	// 模拟未广播的交易，预览资产的分配
+	SimulateTxAssetsFlow(tx *wire.MsgTx) (*common.TxAssetsFlow, error)
+
+	// brc20 活动历史，return: 活动列表，区间内总数，下一页的 cursor
+	GetBRC20ActivityWithAddress(address, ticker string, filter *common.ActivityFilter) ([]*common.BRC20Activity, int, string, error)
+	GetBRC20ActivityWithTicker(ticker string, filter *common.ActivityFilter) ([]*common.BRC20Activity, int, string, error)
+
+	// runes 活动历史，ticker 可以是符文名字或者 id，地址查询时 ticker 为空返回所有符文
+	GetRunesActivityWithAddress(address, ticker string, filter *common.ActivityFilter) ([]*common.RunesActivity, int, string, error)
+	GetRunesActivityWithTicker(ticker string, filter *common.ActivityFilter) ([]*common.RunesActivity, int, string, error)
+
+	// atomicals nft/realm/subrealm
+	GetAtomical(atomicalId string) *common.AtomicalInfo
+	// 逐级解析 realm 名字，比如 abc.def
+	ResolveRealm(name string) *common.RealmResolution
+	GetSubrealms(atomicalId string, start, limit int) ([]*common.AtomicalInfo, int)
+	GetAtomicalNftsWithAddress(address string, start, limit int) ([]*common.AtomicalInfo, int)
+	GetAtomicalEvents(atomicalId string, start, limit int) ([]*common.AtomicalEvent, int)
 }
@@ -0,0 +1,225 @@
+package bitcoin_zmq
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/go-zeromq/zmq4"
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+)
+
+const (
+	TOPIC_HASHBLOCK = "hashblock"
+	TOPIC_RAWTX     = "rawtx"
+	TOPIC_SEQUENCE  = "sequence"
+)
+
+// sequence 消息中的事件类型
+const (
+	SEQUENCE_BLOCK_CONNECTED    = 'C'
+	SEQUENCE_BLOCK_DISCONNECTED = 'D'
+	SEQUENCE_TX_ADDED           = 'A'
+	SEQUENCE_TX_REMOVED         = 'R'
+)
+
+const reconnectInterval = 5 * time.Second
+
+// Listener 回调在订阅线程中执行，耗时的操作需要自己另起线程
+type Listener struct {
+	OnBlock    func(hash string)
+	OnRawTx    func(raw []byte)
+	OnSequence func(hash string, label byte, mempoolSeq uint64)
+	// 消息序号不连续，或者重新连接，说明可能丢失了通知
+	OnGap func(topic string)
+}
+
+// Subscriber 订阅 bitcoind 的 zmq 通知，同一个地址的 topic 共用一个连接
+type Subscriber struct {
+	endpoints map[string][]string
+	listener  *Listener
+
+	mutex   sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	sockets map[string]zmq4.Socket
+	wg      sync.WaitGroup
+}
+
+func NewSubscriber(cfg *config.ZMQ, listener *Listener) *Subscriber {
+	endpoints := make(map[string][]string)
+	add := func(endpoint, topic string) {
+		if endpoint != "" {
+			endpoints[endpoint] = append(endpoints[endpoint], topic)
+		}
+	}
+	add(cfg.HashBlock, TOPIC_HASHBLOCK)
+	add(cfg.RawTx, TOPIC_RAWTX)
+	add(cfg.Sequence, TOPIC_SEQUENCE)
+	return &Subscriber{
+		endpoints: endpoints,
+		listener:  listener,
+		sockets:   make(map[string]zmq4.Socket),
+	}
+}
+
+func (s *Subscriber) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cancel != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for endpoint, topics := range s.endpoints {
+		s.wg.Add(1)
+		go func(endpoint string, topics []string) {
+			defer s.wg.Done()
+			s.run(endpoint, topics)
+		}(endpoint, topics)
+	}
+}
+
+func (s *Subscriber) Stop() {
+	s.mutex.Lock()
+	if s.cancel == nil {
+		s.mutex.Unlock()
+		return
+	}
+	s.cancel()
+	for _, sock := range s.sockets {
+		sock.Close()
+	}
+	s.mutex.Unlock()
+	s.wg.Wait()
+
+	s.mutex.Lock()
+	s.cancel = nil
+	s.mutex.Unlock()
+}
+
+func (s *Subscriber) stopped() bool {
+	return s.ctx.Err() != nil
+}
+
+func (s *Subscriber) run(endpoint string, topics []string) {
+	first := true
+	for !s.stopped() {
+		if !first {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(reconnectInterval):
+			}
+		}
+		first = false
+
+		sock, err := s.connect(endpoint, topics)
+		if err != nil {
+			common.Log.Errorf("zmq connect %s failed, %v", endpoint, err)
+			continue
+		}
+		common.Log.Infof("zmq subscribed %v at %s", topics, endpoint)
+		// 断线期间的通知都丢失了
+		for _, topic := range topics {
+			s.notifyGap(topic)
+		}
+
+		s.receive(sock)
+
+		s.mutex.Lock()
+		delete(s.sockets, endpoint)
+		s.mutex.Unlock()
+		sock.Close()
+		if !s.stopped() {
+			common.Log.Warningf("zmq disconnected from %s, will reconnect...", endpoint)
+		}
+	}
+}
+
+func (s *Subscriber) connect(endpoint string, topics []string) (zmq4.Socket, error) {
+	sock := zmq4.NewSub(s.ctx, zmq4.WithDialerMaxRetries(0))
+	err := sock.Dial(endpoint)
+	if err != nil {
+		sock.Close()
+		return nil, err
+	}
+	for _, topic := range topics {
+		err = sock.SetOption(zmq4.OptionSubscribe, topic)
+		if err != nil {
+			sock.Close()
+			return nil, err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped() {
+		sock.Close()
+		return nil, s.ctx.Err()
+	}
+	s.sockets[endpoint] = sock
+	return sock, nil
+}
+
+// receive 消息格式: topic, body, 4字节小端的序号
+func (s *Subscriber) receive(sock zmq4.Socket) {
+	lastSeq := make(map[string]uint32)
+	for {
+		msg, err := sock.Recv()
+		if err != nil {
+			if !s.stopped() {
+				common.Log.Errorf("zmq recv failed, %v", err)
+			}
+			return
+		}
+		if len(msg.Frames) < 2 {
+			continue
+		}
+		topic := string(msg.Frames[0])
+		if len(msg.Frames) >= 3 && len(msg.Frames[2]) == 4 {
+			seq := binary.LittleEndian.Uint32(msg.Frames[2])
+			if last, ok := lastSeq[topic]; ok && seq != last+1 {
+				common.Log.Warningf("zmq %s sequence gap %d -> %d", topic, last, seq)
+				s.notifyGap(topic)
+			}
+			lastSeq[topic] = seq
+		}
+		s.dispatch(topic, msg.Frames[1])
+	}
+}
+
+func (s *Subscriber) notifyGap(topic string) {
+	if s.listener.OnGap != nil {
+		s.listener.OnGap(topic)
+	}
+}
+
+func (s *Subscriber) dispatch(topic string, body []byte) {
+	switch topic {
+	case TOPIC_HASHBLOCK:
+		if len(body) != 32 || s.listener.OnBlock == nil {
+			return
+		}
+		// bitcoind 发送的已经是显示顺序
+		s.listener.OnBlock(hex.EncodeToString(body))
+
+	case TOPIC_RAWTX:
+		if s.listener.OnRawTx != nil {
+			s.listener.OnRawTx(body)
+		}
+
+	case TOPIC_SEQUENCE:
+		// hash(32) + label(1) + mempool sequence(8，只有 A/R 才有)
+		if len(body) < 33 || s.listener.OnSequence == nil {
+			return
+		}
+		var mempoolSeq uint64
+		if len(body) >= 41 {
+			mempoolSeq = binary.LittleEndian.Uint64(body[33:41])
+		}
+		s.listener.OnSequence(hex.EncodeToString(body[:32]), body[32], mempoolSeq)
+	}
+}
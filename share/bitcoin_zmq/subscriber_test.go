@@ -0,0 +1,156 @@
+package bitcoin_zmq
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-zeromq/zmq4"
+	"github.com/sat20-labs/indexer/config"
+)
+
+// fakePublisher 模拟 bitcoind 的 zmq 通知
+type fakePublisher struct {
+	sock zmq4.Socket
+	seq  map[string]uint32
+}
+
+func newFakePublisher(t *testing.T) *fakePublisher {
+	sock := zmq4.NewPub(context.Background())
+	if err := sock.Listen("tcp://127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sock.Close() })
+	return &fakePublisher{sock: sock, seq: make(map[string]uint32)}
+}
+
+func (p *fakePublisher) endpoint() string {
+	return "tcp://" + p.sock.Addr().String()
+}
+
+func (p *fakePublisher) publish(t *testing.T, topic string, body []byte) {
+	seq := make([]byte, 4)
+	binary.LittleEndian.PutUint32(seq, p.seq[topic])
+	p.seq[topic]++
+	if err := p.sock.Send(zmq4.NewMsgFrom([]byte(topic), body, seq)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type recorder struct {
+	mutex  sync.Mutex
+	blocks []string
+	txs    [][]byte
+	seqs   []string
+	gaps   []string
+}
+
+func (r *recorder) listener() *Listener {
+	return &Listener{
+		OnBlock: func(hash string) {
+			r.mutex.Lock()
+			r.blocks = append(r.blocks, hash)
+			r.mutex.Unlock()
+		},
+		OnRawTx: func(raw []byte) {
+			r.mutex.Lock()
+			r.txs = append(r.txs, raw)
+			r.mutex.Unlock()
+		},
+		OnSequence: func(hash string, label byte, mempoolSeq uint64) {
+			r.mutex.Lock()
+			r.seqs = append(r.seqs, string(label)+hash[:4])
+			r.mutex.Unlock()
+		},
+		OnGap: func(topic string) {
+			r.mutex.Lock()
+			r.gaps = append(r.gaps, topic)
+			r.mutex.Unlock()
+		},
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubscriberDispatchesNotifications(t *testing.T) {
+	pub := newFakePublisher(t)
+	r := &recorder{}
+	sub := NewSubscriber(&config.ZMQ{
+		HashBlock: pub.endpoint(),
+		RawTx:     pub.endpoint(),
+		Sequence:  pub.endpoint(),
+	}, r.listener())
+	sub.Start()
+	defer sub.Stop()
+
+	// 订阅生效之前的消息会被丢弃，所以一直发送直到收到为止
+	blockHash := bytes.Repeat([]byte{0xab}, 32)
+	waitFor(t, func() bool {
+		pub.publish(t, TOPIC_HASHBLOCK, blockHash)
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		return len(r.blocks) > 0
+	})
+	r.mutex.Lock()
+	if r.blocks[0] != strings.Repeat("ab", 32) {
+		t.Fatalf("unexpected block hash %s", r.blocks[0])
+	}
+	r.gaps = nil
+	r.mutex.Unlock()
+
+	pub.publish(t, TOPIC_RAWTX, []byte{1, 2, 3})
+	txSeq := append(bytes.Repeat([]byte{0xcd}, 32), SEQUENCE_TX_REMOVED)
+	txSeq = binary.LittleEndian.AppendUint64(txSeq, 7)
+	pub.publish(t, TOPIC_SEQUENCE, txSeq)
+	waitFor(t, func() bool {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		return len(r.txs) == 1 && len(r.seqs) == 1
+	})
+	r.mutex.Lock()
+	if !bytes.Equal(r.txs[0], []byte{1, 2, 3}) || r.seqs[0] != "Rcdcd" || len(r.gaps) != 0 {
+		t.Fatalf("unexpected notifications %v %v %v", r.txs, r.seqs, r.gaps)
+	}
+	r.mutex.Unlock()
+
+	// 跳过一个序号
+	pub.seq[TOPIC_RAWTX]++
+	pub.publish(t, TOPIC_RAWTX, []byte{4})
+	waitFor(t, func() bool {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		return len(r.txs) == 2
+	})
+	r.mutex.Lock()
+	if len(r.gaps) != 1 || r.gaps[0] != TOPIC_RAWTX {
+		t.Fatalf("sequence gap not reported: %v", r.gaps)
+	}
+	r.mutex.Unlock()
+}
+
+func TestSubscriberStopWithoutPublisher(t *testing.T) {
+	sub := NewSubscriber(&config.ZMQ{HashBlock: "tcp://127.0.0.1:1"}, (&recorder{}).listener())
+	sub.Start()
+	done := make(chan struct{})
+	go func() {
+		sub.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop blocked")
+	}
+}
@@ -0,0 +1,275 @@
+package event_stream
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 推送给订阅者的事件类型
+const (
+	EVENT_NEW_BLOCK     = "block"
+	EVENT_REORG         = "reorg"
+	EVENT_DEPLOY        = "deploy"
+	EVENT_MINT          = "mint"
+	EVENT_TRANSFER      = "transfer"
+	EVENT_MEMPOOL_TX    = "mempool_tx"
+	EVENT_MEMPOOL_SPEND = "mempool_spend"
+
+	EVENT_INSCRIBE_TRANSFER = "inscribe_transfer" // brc20 铸造 transfer 铭文
+	EVENT_DROPPED           = "dropped"           // 订阅者处理太慢，之前丢弃了 Dropped 个事件
+)
+
+const DefaultSubscriptionBuffer = 1024
+
+type AssetAmount struct {
+	Name       string `json:"name"` // AssetName.String()
+	Amount     string `json:"amount"`
+	BindingSat uint32 `json:"bindingSat,omitempty"`
+}
+
+type TxIO struct {
+	Utxo    string         `json:"utxo"`
+	Address string         `json:"address"`
+	Value   int64          `json:"value"`
+	Assets  []*AssetAmount `json:"assets,omitempty"`
+}
+
+type Event struct {
+	Seq       uint64   `json:"seq"`
+	Type      string   `json:"type"`
+	Time      int64    `json:"time"`
+	Height    int      `json:"height,omitempty"` // 0 表示在内存池中
+	BlockHash string   `json:"blockHash,omitempty"`
+	TxId      string   `json:"txid,omitempty"`
+	Protocol  string   `json:"protocol,omitempty"`
+	Ticker    string   `json:"ticker,omitempty"`
+	Addresses []string `json:"addresses,omitempty"`
+	Amount    string   `json:"amount,omitempty"`
+	Inputs    []*TxIO  `json:"inputs,omitempty"`
+	Outputs   []*TxIO  `json:"outputs,omitempty"`
+	Dropped   uint64   `json:"dropped,omitempty"`
+}
+
+// Filter 为空的字段表示不过滤
+type Filter struct {
+	Types     map[string]bool
+	Addresses map[string]bool
+	Tickers   map[string]bool
+	Protocols map[string]bool
+}
+
+func toSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make(map[string]bool)
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				result[item] = true
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func NewFilter(types, addresses, tickers, protocols []string) *Filter {
+	return &Filter{
+		Types:     toSet(types),
+		Addresses: toSet(addresses),
+		Tickers:   toSet(tickers),
+		Protocols: toSet(protocols),
+	}
+}
+
+func (p *Event) assetNames() []string {
+	names := make([]string, 0)
+	for _, ios := range [][]*TxIO{p.Inputs, p.Outputs} {
+		for _, io := range ios {
+			for _, asset := range io.Assets {
+				names = append(names, asset.Name)
+			}
+		}
+	}
+	return names
+}
+
+func (p *Filter) matchTicker(ticker string) bool {
+	if p.Tickers[ticker] {
+		return true
+	}
+	// 支持 protocol:type:ticker 和只有 ticker 两种写法
+	parts := strings.Split(ticker, ":")
+	return len(parts) > 1 && p.Tickers[parts[len(parts)-1]]
+}
+
+func (p *Filter) Match(ev *Event) bool {
+	if p == nil {
+		return true
+	}
+	if p.Types != nil && !p.Types[ev.Type] {
+		return false
+	}
+
+	// 区块和重组事件，不按资产和地址过滤
+	if ev.Type == EVENT_NEW_BLOCK || ev.Type == EVENT_REORG || ev.Type == EVENT_DROPPED {
+		return true
+	}
+
+	if p.Addresses != nil {
+		found := false
+		for _, address := range ev.Addresses {
+			if p.Addresses[address] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if p.Protocols == nil && p.Tickers == nil {
+		return true
+	}
+
+	names := ev.assetNames()
+	if ev.Ticker != "" {
+		names = append(names, ev.Protocol+":f:"+ev.Ticker)
+	}
+	if p.Protocols != nil {
+		found := p.Protocols[ev.Protocol]
+		for _, name := range names {
+			if found {
+				break
+			}
+			found = p.Protocols[strings.Split(name, ":")[0]]
+		}
+		if !found {
+			return false
+		}
+	}
+	if p.Tickers != nil {
+		found := false
+		for _, name := range names {
+			if p.matchTicker(name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type Subscription struct {
+	id      uint64
+	hub     *Hub
+	filter  *Filter
+	C       chan *Event
+	dropped uint64
+	pending uint64 // 丢弃后还没有通知订阅者的数量
+}
+
+// Dropped 由于订阅者处理太慢而丢弃的事件数量
+func (p *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&p.dropped)
+}
+
+func (p *Subscription) Close() {
+	p.hub.unsubscribe(p)
+}
+
+type Hub struct {
+	mutex  sync.RWMutex
+	subs   map[uint64]*Subscription
+	nextId uint64
+	seq    uint64
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs: make(map[uint64]*Subscription),
+	}
+}
+
+var ShareEventHub = NewHub()
+
+func (p *Hub) Subscribe(filter *Filter, bufSize int) *Subscription {
+	if bufSize <= 0 {
+		bufSize = DefaultSubscriptionBuffer
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.nextId++
+	sub := &Subscription{
+		id:     p.nextId,
+		hub:    p,
+		filter: filter,
+		C:      make(chan *Event, bufSize),
+	}
+	p.subs[sub.id] = sub
+	return sub
+}
+
+func (p *Hub) unsubscribe(sub *Subscription) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, ok := p.subs[sub.id]; ok {
+		delete(p.subs, sub.id)
+		close(sub.C)
+	}
+}
+
+// HasSubscribers 没有订阅者时，生产者可以跳过事件的构造
+func (p *Hub) HasSubscribers() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return len(p.subs) > 0
+}
+
+// Publish 不会阻塞调用者，订阅者的缓存满了就丢弃事件。丢弃之后，在下一个能放入缓存的事件之前
+// 先发一个 EVENT_DROPPED 事件，告诉订阅者丢了多少个，订阅者可以重新查询数据
+func (p *Hub) Publish(ev *Event) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if len(p.subs) == 0 {
+		return
+	}
+	ev.Seq = atomic.AddUint64(&p.seq, 1)
+	if ev.Time == 0 {
+		ev.Time = time.Now().Unix()
+	}
+	for _, sub := range p.subs {
+		if !sub.filter.Match(ev) {
+			continue
+		}
+		sub.send(ev)
+	}
+}
+
+func (p *Subscription) send(ev *Event) {
+	if n := atomic.SwapUint64(&p.pending, 0); n > 0 {
+		// 序号跟紧接着的事件相同
+		notice := &Event{Seq: ev.Seq, Type: EVENT_DROPPED, Time: ev.Time, Dropped: n}
+		select {
+		case p.C <- notice:
+		default:
+			atomic.AddUint64(&p.pending, n)
+		}
+	}
+	select {
+	case p.C <- ev:
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+		atomic.AddUint64(&p.pending, 1)
+	}
+}
@@ -0,0 +1,86 @@
+package event_stream
+
+import "testing"
+
+func TestHubFiltersByAddressTickerAndProtocol(t *testing.T) {
+	hub := NewHub()
+	byAddress := hub.Subscribe(NewFilter(nil, []string{"addr1"}, nil, nil), 8)
+	byTicker := hub.Subscribe(NewFilter(nil, nil, []string{"pearl"}, nil), 8)
+	byProtocol := hub.Subscribe(NewFilter([]string{EVENT_TRANSFER}, nil, nil, []string{"runes"}), 8)
+	defer byAddress.Close()
+	defer byTicker.Close()
+	defer byProtocol.Close()
+
+	hub.Publish(&Event{Type: EVENT_NEW_BLOCK, Height: 1})
+	hub.Publish(&Event{
+		Type:      EVENT_TRANSFER,
+		Addresses: []string{"addr1", "addr2"},
+		Outputs: []*TxIO{{
+			Address: "addr2",
+			Assets:  []*AssetAmount{{Name: "ordx:f:pearl", Amount: "100"}},
+		}},
+	})
+	hub.Publish(&Event{Type: EVENT_MINT, Protocol: "runes", Ticker: "DOG", Addresses: []string{"addr3"}})
+
+	if n := len(byAddress.C); n != 2 {
+		t.Fatalf("address subscriber got %d events, want 2", n)
+	}
+	if n := len(byTicker.C); n != 2 {
+		t.Fatalf("ticker subscriber got %d events, want 2", n)
+	}
+	if n := len(byProtocol.C); n != 0 {
+		t.Fatalf("protocol subscriber got %d events, want 0", n)
+	}
+}
+
+func TestHubDropsEventsForSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(nil, 1)
+	hub.Publish(&Event{Type: EVENT_NEW_BLOCK})
+	hub.Publish(&Event{Type: EVENT_NEW_BLOCK})
+	if sub.Dropped() != 1 {
+		t.Fatalf("dropped = %d, want 1", sub.Dropped())
+	}
+	sub.Close()
+	if hub.HasSubscribers() {
+		t.Fatal("subscription not removed")
+	}
+	if _, ok := <-sub.C; !ok {
+		t.Fatal("buffered event lost after close")
+	}
+}
+
+func TestHubNotifiesDroppedEvents(t *testing.T) {
+	hub := NewHub()
+	sub := hub.Subscribe(NewFilter([]string{EVENT_MINT}, nil, nil, nil), 2)
+	defer sub.Close()
+	for i := 0; i < 4; i++ {
+		hub.Publish(&Event{Type: EVENT_MINT})
+	}
+	<-sub.C
+	<-sub.C
+
+	// 缓存有空间后，先收到丢弃的数量，再收到新的事件
+	hub.Publish(&Event{Type: EVENT_MINT})
+	notice := <-sub.C
+	if notice.Type != EVENT_DROPPED || notice.Dropped != 2 {
+		t.Fatalf("unexpected notice %+v", notice)
+	}
+	if ev := <-sub.C; ev.Type != EVENT_MINT || ev.Seq != notice.Seq {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	// 缓存满的时候通知也放不下，丢弃的数量继续累计
+	for i := 0; i < 4; i++ {
+		hub.Publish(&Event{Type: EVENT_MINT})
+	}
+	<-sub.C
+	<-sub.C
+	hub.Publish(&Event{Type: EVENT_MINT})
+	if notice := <-sub.C; notice.Type != EVENT_DROPPED || notice.Dropped != 2 {
+		t.Fatalf("unexpected notice %+v", notice)
+	}
+	if sub.Dropped() != 4 {
+		t.Fatalf("dropped = %d, want 4", sub.Dropped())
+	}
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "sat20_indexer"
+
+// 区块处理的各个阶段
+const (
+	STAGE_PREFETCH        = "prefetch"
+	STAGE_ASSIGN_ORDINALS = "assign_ordinals"
+	STAGE_EXOTIC          = "exotic"
+	STAGE_INSCRIPTIONS    = "inscriptions"
+	STAGE_NFT             = "nft"
+	STAGE_NS              = "ns"
+	STAGE_BRC20           = "brc20"
+	STAGE_RUNES           = "runes"
+	STAGE_FT              = "ft"
+	STAGE_ATOM            = "atom"
+	STAGE_BLOCK           = "block" // 整个区块
+)
+
+// 写数据库的阶段
+const (
+	FLUSH_BASE     = "base"
+	FLUSH_INDEXERS = "indexers"
+	FLUSH_TOTAL    = "total"
+)
+
+// 区块处理和写库的耗时从毫秒到几分钟
+var slowBuckets = prometheus.ExponentialBuckets(0.001, 2, 18)
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	SyncHeight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sync_height",
+		Help:      "Height of the last processed block.",
+	})
+	ChainTip = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_tip",
+		Help:      "Height of the bitcoind chain tip seen by the indexer.",
+	})
+	LastBlockTime = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "last_block_processed_timestamp_seconds",
+		Help:      "Unix time when the last block was processed.",
+	})
+	BlockStageSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "block_stage_seconds",
+		Help:      "Time spent on each stage of block processing.",
+		Buckets:   slowBuckets,
+	}, []string{"stage"})
+	FlushSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "flush_seconds",
+		Help:      "Time spent writing buffered index data to the databases.",
+		Buckets:   slowBuckets,
+	}, []string{"stage"})
+	BarrierHoldSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reader_barrier_hold_seconds",
+		Help:      "Time the indexer state write barrier blocks readers.",
+		Buckets:   slowBuckets,
+	}, []string{"label"})
+
+	RPCSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_seconds",
+		Help:      "RPC request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	RPCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "RPC requests answered with an HTTP error or a non-zero code.",
+	}, []string{"method", "route"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SyncHeight,
+		ChainTip,
+		LastBlockTime,
+		BlockStageSeconds,
+		FlushSeconds,
+		BarrierHoldSeconds,
+		RPCSeconds,
+		RPCErrors,
+	)
+}
+
+func ObserveStage(stage string, start time.Time) {
+	BlockStageSeconds.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+}
+
+func ObserveFlush(stage string, start time.Time) {
+	FlushSeconds.WithLabelValues(stage).Observe(time.Since(start).Seconds())
+}
+
+func ObserveBarrier(label string, held time.Duration) {
+	BarrierHoldSeconds.WithLabelValues(label).Observe(held.Seconds())
+}
+
+// SetSyncHeight 处理完一个区块后调用
+func SetSyncHeight(height int) {
+	SyncHeight.Set(float64(height))
+	LastBlockTime.SetToCurrentTime()
+}
+
+func SetChainTip(height int) {
+	ChainTip.Set(float64(height))
+}
+
+// Register 注册其他模块的 collector，重复注册时忽略
+func Register(c prometheus.Collector) error {
+	err := Registry.Register(c)
+	if _, ok := err.(prometheus.AlreadyRegisteredError); ok {
+		return nil
+	}
+	return err
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"github.com/sat20-labs/indexer/common"
+	"github.com/sat20-labs/indexer/config"
+	"github.com/sat20-labs/indexer/indexer"
+)
+
+// 导出时不同步区块，数据库中的数据停在最后一次写入的高度
+func exportSnapshot(indexerMgr *indexer.IndexerMgr, dir string) error {
+	manifest, err := indexerMgr.ExportSnapshot(dir)
+	if err != nil {
+		return err
+	}
+	common.Log.Infof("snapshot %s: chain %s, height %d, hash %s", dir, manifest.Chain, manifest.Height, manifest.BlockHash)
+	return nil
+}
+
+// 导入后从快照的高度开始同步
+func importSnapshot(conf *config.YamlConf, dir string) error {
+	manifest, err := indexer.ImportSnapshot(dir, conf.DB.Path, conf.Chain)
+	if err != nil {
+		return err
+	}
+	common.Log.Infof("db %s: chain %s, height %d, hash %s", conf.DB.Path, manifest.Chain, manifest.Height, manifest.BlockHash)
+	return nil
+}